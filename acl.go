@@ -0,0 +1,177 @@
+package jungledb
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Access is what a user may do in a namespace.
+type Access int
+
+const (
+	AccessNone      Access = iota // No access
+	AccessRead                    // Reads only
+	AccessReadWrite               // Reads and writes
+)
+
+var accessNames = []string{"none", "read", "readwrite"}
+
+func (a Access) String() string {
+	if a < 0 || int(a) >= len(accessNames) {
+		return fmt.Sprintf("Access(%d)", int(a))
+	}
+	return accessNames[a]
+}
+
+// MarshalText encodes a as "none", "read" or "readwrite".
+func (a Access) MarshalText() ([]byte, error) {
+	if a < 0 || int(a) >= len(accessNames) {
+		return nil, fmt.Errorf("invalid access %d", int(a))
+	}
+	return []byte(accessNames[a]), nil
+}
+
+// UnmarshalText decodes "none", "read" or "readwrite".
+func (a *Access) UnmarshalText(text []byte) error {
+	for i, name := range accessNames {
+		if string(text) == name {
+			*a = Access(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid access %q", text)
+}
+
+// ACLUser is a user of the network servers, identified by a secret token.
+type ACLUser struct {
+	Name  string `json:"name"`  // Recorded as the actor of the user's writes, see WithActor
+	Token string `json:"token"` // Secret presented by clients
+
+	// Namespaces grants access per namespace (see Namespace). "" is the root namespace
+	// and "*" applies to every namespace not listed.
+	Namespaces map[string]Access `json:"namespaces"`
+}
+
+// access returns what u may do in namespace ns.
+func (u *ACLUser) access(ns string) Access {
+	if a, ok := u.Namespaces[ns]; ok {
+		return a
+	}
+	return u.Namespaces["*"]
+}
+
+// readsAll reports whether u may read every namespace, as replicas do.
+func (u *ACLUser) readsAll() bool {
+	for _, a := range u.Namespaces {
+		if a < AccessRead {
+			return false
+		}
+	}
+	return u.access("") >= AccessRead && u.access("*") >= AccessRead
+}
+
+// ACL controls which users may use the RESP, HTTP and gRPC servers, and which
+// namespaces they may read or write. Its users can be replaced at any time, for example
+// on SIGHUP, without restarting the servers. An ACL is safe for concurrent use.
+type ACL struct {
+	mu    sync.RWMutex
+	users map[string]*ACLUser // By token
+}
+
+// NewACL returns an ACL with the given users.
+func NewACL(users ...ACLUser) (*ACL, error) {
+	a := &ACL{}
+	if err := a.SetUsers(users...); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// SetUsers replaces the users of a. Tokens must be non-empty and unique; otherwise a is
+// left unchanged.
+func (a *ACL) SetUsers(users ...ACLUser) error {
+	byToken := make(map[string]*ACLUser, len(users))
+	for i := range users {
+		u := users[i]
+		if u.Token == "" {
+			return fmt.Errorf("user %q has no token", u.Name)
+		}
+		if _, ok := byToken[u.Token]; ok {
+			return fmt.Errorf("user %q reuses the token of another user", u.Name)
+		}
+		byToken[u.Token] = &u
+	}
+	a.mu.Lock()
+	a.users = byToken
+	a.mu.Unlock()
+	return nil
+}
+
+// LoadFile replaces the users of a with those in the JSON file at path, an array of
+// ACLUser objects such as
+//
+//	[{"name": "billing", "token": "s3cret", "namespaces": {"billing": "readwrite", "": "read"}}]
+//
+// On error a is left unchanged.
+func (a *ACL) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var users []ACLUser
+	if err := json.Unmarshal(data, &users); err != nil {
+		return fmt.Errorf("failed to parse ACL file %s: %v", path, err)
+	}
+	return a.SetUsers(users...)
+}
+
+// user returns the user holding token.
+func (a *ACL) user(token string) (*ACLUser, bool) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	u, ok := a.users[token]
+	return u, ok
+}
+
+// WithACL requires clients of the RESP, HTTP and gRPC servers to authenticate as a user
+// of acl, and restricts them to the namespaces it grants them. Without it the servers
+// are open to every client.
+func WithACL(acl *ACL) Option {
+	return func(o *options) {
+		o.acl = acl
+	}
+}
+
+// Authorize returns the handle a server request should use for namespace ns ("" for the
+// root namespace) after checking that the user holding token has at least the access
+// need there. Writes through the handle are attributed to the user in the audit log.
+// Without WithACL every request is allowed and token is ignored. Servers call Authorize
+// for every request; it is exported for custom servers built on the database.
+func (db *DB) Authorize(token, ns string, need Access) (*DB, error) {
+	if strings.IndexByte(ns, 0) >= 0 {
+		return nil, fmt.Errorf("invalid namespace name %q", ns)
+	}
+	h := db
+	if acl := db.opts.acl; acl != nil {
+		u, ok := acl.user(token)
+		if !ok {
+			reason := "unknown token"
+			if token == "" {
+				reason = "no token"
+			}
+			db.authFailed("authentication failed", "reason", reason, "namespace", ns)
+			return nil, ErrUnauthenticated
+		}
+		if u.access(ns) < need {
+			return nil, fmt.Errorf("%w: %s needs %s access to namespace %q", ErrPermissionDenied, u.Name, need, ns)
+		}
+		h = db.WithActor(u.Name, "")
+	}
+	if ns != "" {
+		h = h.Namespace(ns)
+	}
+	return h, nil
+}
@@ -0,0 +1,122 @@
+package jungledb
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// TestAuthorize tests that Authorize checks tokens and per-namespace access and returns
+// handles scoped to the namespace.
+func TestAuthorize(t *testing.T) {
+	acl, err := NewACL(
+		ACLUser{Name: "admin", Token: "t-admin", Namespaces: map[string]Access{"*": AccessReadWrite, "": AccessReadWrite}},
+		ACLUser{Name: "billing", Token: "t-billing", Namespaces: map[string]Access{"billing": AccessReadWrite, "": AccessRead}},
+	)
+	if err != nil {
+		t.Fatalf("NewACL failed: %v", err)
+	}
+	db, err := Open("testdata/authorize.db", WithACL(acl), WithAudit())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Authorize("nope", "", AccessRead); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("unknown token: expected ErrUnauthenticated, got %v", err)
+	}
+	if _, err := db.Authorize("t-billing", "", AccessReadWrite); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("write to root: expected ErrPermissionDenied, got %v", err)
+	}
+	if _, err := db.Authorize("t-billing", "other", AccessRead); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("unlisted namespace: expected ErrPermissionDenied, got %v", err)
+	}
+	if _, err := db.Authorize("t-admin", "a\x00b", AccessRead); err == nil {
+		t.Error("namespace with NUL should be rejected")
+	}
+
+	h, err := db.Authorize("t-billing", "billing", AccessReadWrite)
+	if err != nil {
+		t.Fatalf("Authorize failed: %v", err)
+	}
+	if err := h.Hset("invoice:1", "total", []byte("10")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if ok, _ := db.Namespace("billing").HhasKey("invoice:1", "total"); !ok {
+		t.Error("write through the handle should land in the namespace")
+	}
+	if ok, _ := db.HhasKey("invoice:1", "total"); ok {
+		t.Error("write through the handle should not land in the root namespace")
+	}
+	entries, err := db.AuditLog("", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("AuditLog failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Actor != "billing" {
+		t.Errorf("write should be attributed to billing, got %+v", entries)
+	}
+
+	// Reloading takes effect for later requests
+	if err := acl.SetUsers(ACLUser{Name: "billing", Token: "t-new", Namespaces: map[string]Access{"billing": AccessRead}}); err != nil {
+		t.Fatalf("SetUsers failed: %v", err)
+	}
+	if _, err := db.Authorize("t-billing", "billing", AccessRead); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("old token: expected ErrUnauthenticated, got %v", err)
+	}
+	if _, err := db.Authorize("t-new", "billing", AccessReadWrite); !errors.Is(err, ErrPermissionDenied) {
+		t.Errorf("downgraded user: expected ErrPermissionDenied, got %v", err)
+	}
+
+	// Without an ACL everything is allowed
+	open, err := Open("testdata/authorize-open.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer open.Close()
+	if _, err := open.Authorize("", "any", AccessReadWrite); err != nil {
+		t.Errorf("Authorize without ACL failed: %v", err)
+	}
+}
+
+// TestACLLoadFile tests that ACLs load from JSON files and that invalid users are rejected
+// without changing the ACL.
+func TestACLLoadFile(t *testing.T) {
+	path := "testdata/acl.json"
+	if err := os.WriteFile(path, []byte(`[{"name": "ro", "token": "s3cret", "namespaces": {"": "read", "logs": "readwrite"}}]`), 0600); err != nil {
+		t.Fatalf("failed to write ACL file: %v", err)
+	}
+	acl, err := NewACL()
+	if err != nil {
+		t.Fatalf("NewACL failed: %v", err)
+	}
+	if err := acl.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile failed: %v", err)
+	}
+	u, ok := acl.user("s3cret")
+	if !ok || u.Name != "ro" || u.access("") != AccessRead || u.access("logs") != AccessReadWrite || u.access("x") != AccessNone {
+		t.Errorf("loaded user mismatch: %+v", u)
+	}
+
+	if err := acl.SetUsers(ACLUser{Name: "a", Token: "x"}, ACLUser{Name: "b", Token: "x"}); err == nil {
+		t.Error("duplicate tokens should be rejected")
+	}
+	if err := acl.SetUsers(ACLUser{Name: "a"}); err == nil {
+		t.Error("empty token should be rejected")
+	}
+	if err := os.WriteFile(path, []byte(`[{"name": "bad", "token": "t", "namespaces": {"": "write"}}]`), 0600); err != nil {
+		t.Fatalf("failed to write ACL file: %v", err)
+	}
+	if err := acl.LoadFile(path); err == nil {
+		t.Error("invalid access should be rejected")
+	}
+	if _, ok := acl.user("s3cret"); !ok {
+		t.Error("failed reloads should leave the ACL unchanged")
+	}
+
+	data, err := json.Marshal(map[string]Access{"a": AccessReadWrite})
+	if err != nil || string(data) != `{"a":"readwrite"}` {
+		t.Errorf("Access marshal mismatch: %s %v", data, err)
+	}
+}
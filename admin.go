@@ -0,0 +1,180 @@
+package jungledb
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// compactTxSize bounds how many bytes Compact copies per transaction.
+	compactTxSize = 64 << 20
+
+	// compactProgressInterval is how often Compact logs the size of the copy written so far.
+	compactProgressInterval = 5 * time.Second
+)
+
+// Stats summarizes the contents and storage of a database.
+type Stats struct {
+	Keys       int   // User keys (hashes plus sorted sets)
+	Hashes     int   // Hash keys
+	SortedSets int   // Sorted set keys
+	Fields     int   // Hash fields across all hashes
+	Members    int   // Sorted set members across all sorted sets
+	OpLogSize  int   // Entries retained in the operation log
+	FileSize   int64 // Size of the database file in bytes
+	FreePages  int   // Pages on the freelist, reclaimable by Compact
+	PageSize   int   // Database page size in bytes
+
+	AllocatedSize int64 // Bytes allocated to the file on disk, including preallocated space not in use yet
+	PendingPages  int   // Pages freed but still read by open transactions
+	FreeBytes     int   // Bytes of the free pages, reused before the file grows
+	FreelistBytes int   // Bytes taken by the freelist itself
+}
+
+// Stats returns key counts and storage statistics, gathered in a single read transaction.
+// LargestValue reports the largest value.
+func (db *DB) Stats() (Stats, error) {
+	var stats Stats
+	err := db.view("Stats", "", func(tx *bbolt.Tx) error {
+		stats.FileSize = tx.Size()
+		stats.PageSize = tx.DB().Info().PageSize
+		dbStats := tx.DB().Stats()
+		stats.FreePages = dbStats.FreePageN
+		stats.PendingPages = dbStats.PendingPageN
+		stats.FreeBytes = dbStats.FreeAlloc
+		stats.FreelistBytes = dbStats.FreelistInuse
+		if info, err := os.Stat(db.filePath); err == nil {
+			stats.AllocatedSize = info.Size()
+		}
+
+		return tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+			if string(name) == opLogBucket {
+				stats.OpLogSize = b.Stats().KeyN
+				return nil
+			}
+			if isInternalBucket(tx, name) {
+				return nil
+			}
+
+			stats.Keys++
+			if keyType(tx, name) == typeZset {
+				stats.SortedSets++
+				stats.Members += b.Stats().KeyN
+			} else {
+				stats.Hashes++
+				stats.Fields += b.Stats().KeyN
+			}
+			return nil
+		})
+	})
+	return stats, err
+}
+
+// Backup writes a consistent copy of the whole database file to w without blocking
+// writers, and returns the number of bytes written. The copy can be opened with Open.
+func (db *DB) Backup(w io.Writer) (int64, error) {
+	var n int64
+	err := db.labeled("Backup", "", func() error {
+		return db.db.View(func(tx *bbolt.Tx) error {
+			var err error
+			n, err = tx.WriteTo(w)
+			return err
+		})
+	})
+	return n, closedError(err)
+}
+
+// BackupFile writes a consistent copy of the database to path, which must not exist.
+func (db *DB) BackupFile(path string) error {
+	if err := ensureDir(path); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %v", err)
+	}
+	if _, err := db.Backup(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return fmt.Errorf("failed to write backup: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Compact writes a compacted copy of the database to dstPath, which must not exist.
+// Deleted data leaves free pages behind that bbolt reuses but never returns to the file
+// system; the copy contains only live data. To compact in place, close the database and
+// replace its file with the copy. The copy is paced by BackgroundLimits.
+func (db *DB) Compact(dstPath string) error {
+	if db.isClosed() {
+		return ErrClosed
+	}
+	if _, err := os.Stat(dstPath); err == nil {
+		return fmt.Errorf("compaction target %s already exists", dstPath)
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := ensureDir(dstPath); err != nil {
+		return err
+	}
+
+	dst, err := bbolt.Open(dstPath, 0666, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create compaction target: %v", err)
+	}
+
+	var srcSize int64
+	db.db.View(func(tx *bbolt.Tx) error {
+		srcSize = tx.Size()
+		return nil
+	})
+	start := time.Now()
+	db.log.Info("compaction started", "path", dstPath, "size", srcSize)
+
+	// bbolt reports no progress, so sample the size of the copy while it is written
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(compactProgressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if fi, err := os.Stat(dstPath); err == nil {
+					db.log.Info("compaction progress", "path", dstPath, "written", fi.Size(), "size", srcSize)
+				}
+			}
+		}
+	}()
+
+	err = db.labeled("Compact", "", func() error {
+		return compactCopy(dst, db.db, compactTxSize, &db.throttle)
+	})
+	close(done)
+	if err != nil {
+		dst.Close()
+		os.Remove(dstPath)
+		db.log.Warn("compaction failed", "path", dstPath, "error", err)
+		return fmt.Errorf("failed to compact database: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+
+	attrs := []any{"path", dstPath, "size", srcSize, "duration", time.Since(start)}
+	if fi, err := os.Stat(dstPath); err == nil {
+		attrs = append(attrs, "compacted_size", fi.Size())
+	}
+	db.log.Info("compaction finished", attrs...)
+	return nil
+}
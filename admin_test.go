@@ -0,0 +1,80 @@
+package jungledb
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestStatsBackupCompact tests Stats, BackupFile and Compact.
+func TestStatsBackupCompact(t *testing.T) {
+	db, err := Open("testdata/admin.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 100; i++ {
+		if err := db.Hset(fmt.Sprintf("h%d", i), "f", make([]byte, 1024)); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	if err := db.Zadd("z", 1, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Zadd("z", 2, "b"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if _, err := db.DeleteByPattern("h[1-9]*"); err != nil {
+		t.Fatalf("DeleteByPattern failed: %v", err)
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.Keys != 2 || stats.Hashes != 1 || stats.SortedSets != 1 || stats.Fields != 1 || stats.Members != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if stats.FileSize == 0 || stats.PageSize == 0 {
+		t.Errorf("missing storage stats: %+v", stats)
+	}
+
+	if err := db.BackupFile("testdata/admin_backup.db"); err != nil {
+		t.Fatalf("BackupFile failed: %v", err)
+	}
+	if err := db.BackupFile("testdata/admin_backup.db"); err == nil {
+		t.Error("BackupFile over an existing file should fail")
+	}
+	if err := db.Compact("testdata/admin_compact.db"); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+
+	for _, path := range []string{"testdata/admin_backup.db", "testdata/admin_compact.db"} {
+		copyDB, err := Open(path)
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", path, err)
+		}
+		members, err := copyDB.Zrange("z", 0, -1)
+		if err != nil {
+			t.Fatalf("Zrange failed: %v", err)
+		}
+		if !equal(members, []string{"a", "b"}) {
+			t.Errorf("%s: zset mismatch: got %v", path, members)
+		}
+		copyStats, err := copyDB.Stats()
+		if err != nil {
+			t.Fatalf("Stats failed: %v", err)
+		}
+		if copyStats.Keys != 2 {
+			t.Errorf("%s: expected 2 keys, got %d", path, copyStats.Keys)
+		}
+		copyDB.Close()
+	}
+
+	backup, _ := os.Stat("testdata/admin_backup.db")
+	compacted, _ := os.Stat("testdata/admin_compact.db")
+	if compacted.Size() >= backup.Size() {
+		t.Errorf("compacted file (%d bytes) not smaller than backup (%d bytes)", compacted.Size(), backup.Size())
+	}
+}
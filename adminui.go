@@ -0,0 +1,199 @@
+package jungledb
+
+import (
+	"crypto/subtle"
+	_ "embed"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// adminPreviewLimit bounds how many entries the admin UI shows for a single key.
+const adminPreviewLimit = 200
+
+//go:embed ui/admin.html
+var adminPage []byte
+
+// AdminHandler returns an http.Handler serving a web admin UI for browsing keys, viewing
+// database and per-key statistics and the slow log (which it can reset), downloading a backup and
+// writing a compacted copy of the file. Every request must carry token, either as an
+// "Authorization: Bearer <token>" header or a "token" query parameter (used when opening
+// the page in a browser). An empty token disables the handler entirely.
+// Mount it under a prefix with http.StripPrefix.
+func (db *DB) AdminHandler(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(adminPage)
+	})
+	mux.HandleFunc("GET /api/stats", db.adminStats)
+	mux.HandleFunc("GET /api/keys", db.adminKeys)
+	mux.HandleFunc("GET /api/key", db.adminKey)
+	mux.HandleFunc("GET /api/slow", db.adminSlowOps)
+	mux.HandleFunc("DELETE /api/slow", db.adminResetSlowOps)
+	mux.HandleFunc("POST /api/backup", db.adminBackup)
+	mux.HandleFunc("POST /api/compact", db.adminCompact)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		given := r.URL.Query().Get("token")
+		if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+			given = strings.TrimPrefix(auth, "Bearer ")
+		}
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// adminKeyInfo describes one key in the admin UI.
+type adminKeyInfo struct {
+	Key     string `json:"key"`
+	Type    string `json:"type"`
+	Entries int    `json:"entries"`
+}
+
+func (db *DB) adminStats(w http.ResponseWriter, r *http.Request) {
+	stats, err := db.Stats()
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+func (db *DB) adminKeys(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 100
+	if s := q.Get("limit"); s != "" {
+		if n, err := strconv.Atoi(s); err == nil && n > 0 {
+			limit = n
+		}
+	}
+	keys, next, err := db.ListKeys(q.Get("pattern"), q.Get("cursor"), limit)
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	infos := make([]adminKeyInfo, 0, len(keys))
+	err = db.view("AdminKeys", "", func(tx *bbolt.Tx) error {
+		for _, key := range keys {
+			b := tx.Bucket([]byte(key))
+			if b == nil {
+				continue // Deleted since listing
+			}
+			infos = append(infos, adminKeyInfo{Key: key, Type: keyType(tx, []byte(key)), Entries: b.Stats().KeyN})
+		}
+		return nil
+	})
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"keys": infos, "cursor": next})
+}
+
+// adminKeyDetail is the per-key view of the admin UI.
+type adminKeyDetail struct {
+	adminKeyInfo
+	Depth     int               `json:"depth"`     // B+tree depth
+	LeafPages int               `json:"leafPages"` // Leaf pages in use
+	LeafBytes int               `json:"leafBytes"` // Bytes used in leaf pages
+	Fields    map[string]string `json:"fields,omitempty"`
+	Members   []zsetMember      `json:"members,omitempty"`
+	Truncated bool              `json:"truncated"` // More entries exist than were returned
+}
+
+func (db *DB) adminKey(w http.ResponseWriter, r *http.Request) {
+	key := r.URL.Query().Get("key")
+	var detail *adminKeyDetail
+	err := db.view("AdminKey", key, func(tx *bbolt.Tx) error {
+		b := tx.Bucket([]byte(key))
+		if b == nil || isInternalBucket(tx, []byte(key)) {
+			return nil
+		}
+		bs := b.Stats()
+		detail = &adminKeyDetail{
+			adminKeyInfo: adminKeyInfo{Key: key, Type: keyType(tx, []byte(key)), Entries: bs.KeyN},
+			Depth:        bs.Depth,
+			LeafPages:    bs.LeafPageN,
+			LeafBytes:    bs.LeafInuse,
+			Truncated:    bs.KeyN > adminPreviewLimit,
+		}
+
+		c := b.Cursor()
+		n := 0
+		for k, v := c.First(); k != nil && n < adminPreviewLimit; k, v = c.Next() {
+			if detail.Type == typeZset {
+				score := math.Float64frombits(binary.BigEndian.Uint64(k[:8]))
+				detail.Members = append(detail.Members, zsetMember{string(k[8:]), score})
+			} else {
+				if detail.Fields == nil {
+					detail.Fields = make(map[string]string)
+				}
+				detail.Fields[string(k)] = string(v)
+			}
+			n++
+		}
+		return nil
+	})
+	if err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if detail == nil {
+		writeHTTPError(w, http.StatusNotFound, fmt.Errorf("key %s does not exist", key))
+		return
+	}
+	writeJSON(w, http.StatusOK, detail)
+}
+
+func (db *DB) adminSlowOps(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, db.SlowLog(0))
+}
+
+func (db *DB) adminResetSlowOps(w http.ResponseWriter, r *http.Request) {
+	db.ResetSlowLog()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// adminBackup streams a consistent copy of the database as a download.
+func (db *DB) adminBackup(w http.ResponseWriter, r *http.Request) {
+	name := fmt.Sprintf("%s-%s.db", strings.TrimSuffix(filepath.Base(db.filePath), filepath.Ext(db.filePath)),
+		time.Now().UTC().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	db.Backup(w) // Headers are sent; a failure can only truncate the download
+}
+
+// adminCompact writes a compacted copy next to the database file, replacing any earlier one.
+func (db *DB) adminCompact(w http.ResponseWriter, r *http.Request) {
+	dst := db.filePath + ".compacted"
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		writeHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if err := db.Compact(dst); err != nil {
+		writeHTTPError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	result := map[string]any{"path": dst}
+	if fi, err := os.Stat(db.filePath); err == nil {
+		result["before"] = fi.Size()
+	}
+	if fi, err := os.Stat(dst); err == nil {
+		result["after"] = fi.Size()
+	}
+	writeJSON(w, http.StatusOK, result)
+}
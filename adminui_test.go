@@ -0,0 +1,126 @@
+package jungledb
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestAdminHandler tests authentication and the admin UI endpoints.
+func TestAdminHandler(t *testing.T) {
+	db, err := Open("testdata/adminui.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hmset("user:1", map[string][]byte{"name": []byte("Alice")}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	if err := db.Zadd("ranking", 4, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	db.slowOps.add(SlowOp{Op: "Hget", Key: "user:1", Duration: time.Second, Time: time.Now()})
+
+	srv := httptest.NewServer(db.AdminHandler("secret"))
+	defer srv.Close()
+
+	request := func(method, path, token string) (int, []byte) {
+		t.Helper()
+		req, _ := http.NewRequest(method, srv.URL+path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, body
+	}
+
+	// Authentication
+	if status, _ := request("GET", "/api/stats", ""); status != http.StatusUnauthorized {
+		t.Errorf("missing token: expected 401, got %d", status)
+	}
+	if status, _ := request("GET", "/api/stats", "wrong"); status != http.StatusUnauthorized {
+		t.Errorf("wrong token: expected 401, got %d", status)
+	}
+	status, body := request("GET", "/?token=secret", "")
+	if status != http.StatusOK || !strings.Contains(string(body), "<title>jungledb admin</title>") {
+		t.Errorf("admin page not served: %d", status)
+	}
+
+	// Keyspace browsing
+	status, body = request("GET", "/api/keys", "secret")
+	var keys struct {
+		Keys []adminKeyInfo `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &keys); err != nil || status != http.StatusOK {
+		t.Fatalf("keys failed: %d %s", status, body)
+	}
+	if len(keys.Keys) != 2 || keys.Keys[0] != (adminKeyInfo{"ranking", typeZset, 1}) || keys.Keys[1] != (adminKeyInfo{"user:1", typeHash, 1}) {
+		t.Errorf("keys mismatch: %+v", keys.Keys)
+	}
+
+	status, body = request("GET", "/api/key?key=ranking", "secret")
+	var detail adminKeyDetail
+	if err := json.Unmarshal(body, &detail); err != nil || status != http.StatusOK {
+		t.Fatalf("key failed: %d %s", status, body)
+	}
+	if len(detail.Members) != 1 || detail.Members[0] != (zsetMember{"a", 4}) || detail.Depth == 0 {
+		t.Errorf("key detail mismatch: %+v", detail)
+	}
+	if status, _ := request("GET", "/api/key?key=ranking_members", "secret"); status != http.StatusNotFound {
+		t.Errorf("internal bucket exposed: got %d", status)
+	}
+
+	// Slow operations
+	status, body = request("GET", "/api/slow", "secret")
+	var ops []SlowOp
+	if err := json.Unmarshal(body, &ops); err != nil || status != http.StatusOK {
+		t.Fatalf("slow failed: %d %s", status, body)
+	}
+	if len(ops) != 1 || ops[0].Op != "Hget" || ops[0].Duration != time.Second {
+		t.Errorf("slow ops mismatch: %+v", ops)
+	}
+	if status, _ := request("DELETE", "/api/slow", "secret"); status != http.StatusNoContent {
+		t.Errorf("slow log reset: expected 204, got %d", status)
+	}
+	if n := db.SlowLogLen(); n != 0 {
+		t.Errorf("expected an empty slow log after reset, got %d entries", n)
+	}
+
+	// Backup download can be opened as a database
+	status, body = request("POST", "/api/backup", "secret")
+	if status != http.StatusOK {
+		t.Fatalf("backup failed: %d", status)
+	}
+	if err := os.WriteFile("testdata/adminui_backup.db", body, 0666); err != nil {
+		t.Fatalf("failed to write backup: %v", err)
+	}
+	backup, err := Open("testdata/adminui_backup.db")
+	if err != nil {
+		t.Fatalf("failed to open backup: %v", err)
+	}
+	if name, _ := backup.Hget("user:1", "name"); string(name) != "Alice" {
+		t.Errorf("backup content mismatch: got %q", name)
+	}
+	backup.Close()
+
+	// Compaction writes a copy next to the file, repeatedly
+	for i := 0; i < 2; i++ {
+		if status, body := request("POST", "/api/compact", "secret"); status != http.StatusOK {
+			t.Fatalf("compact failed: %d %s", status, body)
+		}
+	}
+	if _, err := os.Stat("testdata/adminui.db.compacted"); err != nil {
+		t.Errorf("compacted copy missing: %v", err)
+	}
+}
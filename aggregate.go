@@ -0,0 +1,61 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"go.etcd.io/bbolt"
+)
+
+// IntAggregate summarizes the integer fields read by HaggregateInt.
+type IntAggregate struct {
+	Count int   // Integer fields, zero if none matched
+	Sum   int64 // Sum of their values
+	Min   int64 // Smallest value, zero if Count is zero
+	Max   int64 // Largest value, zero if Count is zero
+}
+
+// HaggregateInt returns the count, sum, minimum and maximum of the integer values, as
+// written by Hincr, of the fields of a hash whose names start with prefix, in a single
+// pass over them. Fields whose values are not 8-byte integers are skipped. It fails
+// with ErrOverflow if the sum overflows an int64.
+func (db *DB) HaggregateInt(key, prefix string) (IntAggregate, error) {
+	if err := checkKey(key); err != nil {
+		return IntAggregate{}, err
+	}
+	key = db.nsKey(key)
+	var agg IntAggregate
+	err := db.view("HaggregateInt", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err // Bucket does not exist, return zeros
+		}
+
+		cursor := bucket.Cursor()
+		prefixBytes := []byte(prefix)
+		for k, v := cursor.Seek(prefixBytes); k != nil && bytes.HasPrefix(k, prefixBytes); k, v = cursor.Next() {
+			if len(v) != 8 {
+				continue
+			}
+			n := int64(binary.BigEndian.Uint64(v))
+			sum := agg.Sum + n
+			if (n > 0 && sum < agg.Sum) || (n < 0 && sum > agg.Sum) {
+				return ErrOverflow
+			}
+			if agg.Count == 0 || n < agg.Min {
+				agg.Min = n
+			}
+			if agg.Count == 0 || n > agg.Max {
+				agg.Max = n
+			}
+			agg.Sum = sum
+			agg.Count++
+		}
+		return nil
+	})
+	if err != nil {
+		return IntAggregate{}, err
+	}
+	db.metrics.addRead(8 * agg.Count)
+	return agg, nil
+}
@@ -0,0 +1,46 @@
+package jungledb
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestHaggregateInt tests that HaggregateInt summarizes the integer fields under a prefix.
+func TestHaggregateInt(t *testing.T) {
+	db, err := Open("testdata/aggregate.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if agg, err := db.HaggregateInt("stats", "hits:"); err != nil || agg != (IntAggregate{}) {
+		t.Errorf("expected zeros for a missing key, got %+v, %v", agg, err)
+	}
+
+	for field, n := range map[string]int64{"hits:a": 5, "hits:b": -3, "hits:c": 12, "misses:a": 100} {
+		if _, err := db.Hincr("stats", field, n); err != nil {
+			t.Fatalf("Hincr failed: %v", err)
+		}
+	}
+	if err := db.Hset("stats", "hits:label", []byte("not a number")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	agg, err := db.HaggregateInt("stats", "hits:")
+	if err != nil {
+		t.Fatalf("HaggregateInt failed: %v", err)
+	}
+	if want := (IntAggregate{Count: 3, Sum: 14, Min: -3, Max: 12}); agg != want {
+		t.Errorf("expected %+v, got %+v", want, agg)
+	}
+	if agg, _ := db.HaggregateInt("stats", ""); agg.Count != 4 || agg.Sum != 114 {
+		t.Errorf("expected every integer field with an empty prefix, got %+v", agg)
+	}
+
+	if _, err := db.Hincr("stats", "hits:d", math.MaxInt64); err != nil {
+		t.Fatalf("Hincr failed: %v", err)
+	}
+	if _, err := db.HaggregateInt("stats", "hits:"); !errors.Is(err, ErrOverflow) {
+		t.Errorf("expected ErrOverflow, got %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+package jungledb
+
+import (
+	"errors"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// Attach opens the database file at path with opts and attaches it to db under alias, so
+// that code holding db can reach it with Attached instead of passing a second handle
+// around, for example to keep cold data in a separate file. Attached databases are closed
+// with db. It fails if alias is already attached.
+func (db *DB) Attach(alias, path string, opts ...Option) error {
+	if alias == "" {
+		return errors.New("empty alias")
+	}
+	db.attachMu.Lock()
+	defer db.attachMu.Unlock()
+	if db.isClosed() {
+		return ErrClosed
+	}
+	if _, ok := db.attached[alias]; ok {
+		return fmt.Errorf("a database is already attached as %q", alias)
+	}
+	other, err := Open(path, opts...)
+	if err != nil {
+		return err
+	}
+	if db.attached == nil {
+		db.attached = make(map[string]*DB)
+	}
+	db.attached[alias] = other
+	return nil
+}
+
+// Attached returns the database attached under alias.
+func (db *DB) Attached(alias string) (*DB, error) {
+	db.attachMu.Lock()
+	defer db.attachMu.Unlock()
+	other, ok := db.attached[alias]
+	if !ok {
+		return nil, fmt.Errorf("no database attached as %q", alias)
+	}
+	return other, nil
+}
+
+// Detach closes the database attached under alias and removes it.
+func (db *DB) Detach(alias string) error {
+	db.attachMu.Lock()
+	other, ok := db.attached[alias]
+	delete(db.attached, alias)
+	db.attachMu.Unlock()
+	if !ok {
+		return fmt.Errorf("no database attached as %q", alias)
+	}
+	return other.Close()
+}
+
+// detachAll closes every attached database.
+func (db *DB) detachAll() error {
+	db.attachMu.Lock()
+	attached := db.attached
+	db.attached = nil
+	db.attachMu.Unlock()
+	var errs []error
+	for alias, other := range attached {
+		if err := other.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close database attached as %q: %w", alias, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CopyTo copies a hash or sorted set, with its TTL, to dstKey in dst, which can be another
+// database, such as one attached to db, or a namespace of one. It fails with
+// ErrKeyNotFound if srcKey does not exist and with ErrKeyExists if dstKey already exists
+// in dst.
+func (db *DB) CopyTo(dst *DB, srcKey, dstKey string) error {
+	if err := checkKey(srcKey, dstKey); err != nil {
+		return err
+	}
+	src := db.nsKey(srcKey)
+	var rec exportRecord
+	err := db.view("CopyTo", src, func(tx *bbolt.Tx) error {
+		bucket := db.liveBucket(tx, src)
+		if bucket == nil {
+			return fmt.Errorf("%w: %s", ErrKeyNotFound, src)
+		}
+		rec = newExportRecord(tx, []byte(src), bucket)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rec.Key = dst.nsKey(dstKey)
+	return dst.update("CopyTo", rec.Key, func(tx *txn) error {
+		if dst.liveBucket(tx.Tx, rec.Key) != nil {
+			return fmt.Errorf("%w: %s", ErrKeyExists, rec.Key)
+		}
+		return importRecord(tx, rec)
+	})
+}
+
+// MoveTo is like CopyTo but then deletes srcKey. The copy and the delete are separate
+// transactions, so a crash between them leaves the key in both databases.
+func (db *DB) MoveTo(dst *DB, srcKey, dstKey string) error {
+	if err := db.CopyTo(dst, srcKey, dstKey); err != nil {
+		return err
+	}
+	return db.HdelBucket(srcKey)
+}
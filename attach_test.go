@@ -0,0 +1,65 @@
+package jungledb
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestAttach tests that attached databases are reachable by alias and that keys can be
+// moved between files.
+func TestAttach(t *testing.T) {
+	db, err := Open("testdata/attach_hot.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Attach("cold", "testdata/attach_cold.db"); err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+	if err := db.Attach("cold", "testdata/attach_other.db"); err == nil {
+		t.Errorf("expected an error attaching an alias twice")
+	}
+	cold, err := db.Attached("cold")
+	if err != nil {
+		t.Fatalf("Attached failed: %v", err)
+	}
+
+	if err := db.Hset("order:1", "total", []byte("42")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Zadd("ranking", 1, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.MoveTo(cold, "order:1", "archive:order:1"); err != nil {
+		t.Fatalf("MoveTo failed: %v", err)
+	}
+	if v, err := cold.Hget("archive:order:1", "total"); err != nil || string(v) != "42" {
+		t.Errorf("expected the hash in the cold file, got %q %v", v, err)
+	}
+	if fields, err := db.Hscan("order:1"); err != nil || len(fields) != 0 {
+		t.Errorf("expected the hash gone from the hot file, got %v %v", fields, err)
+	}
+	if err := db.CopyTo(cold.Namespace("tenant"), "ranking", "ranking"); err != nil {
+		t.Fatalf("CopyTo failed: %v", err)
+	}
+	if score, err := cold.Namespace("tenant").Zscore("ranking", "a"); err != nil || score != 1 {
+		t.Errorf("expected the sorted set copied, got %v %v", score, err)
+	}
+	if err := db.CopyTo(cold.Namespace("tenant"), "ranking", "ranking"); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+	if err := db.CopyTo(cold, "missing", "missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	if err := db.Detach("cold"); err != nil {
+		t.Fatalf("Detach failed: %v", err)
+	}
+	if _, err := cold.Hget("archive:order:1", "total"); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected the detached database closed, got %v", err)
+	}
+	if _, err := db.Attached("cold"); err == nil {
+		t.Errorf("expected an error for a detached alias")
+	}
+}
@@ -0,0 +1,84 @@
+package jungledb
+
+import (
+	"testing"
+	"time"
+)
+
+// TestAuditLog tests that writes are recorded with their actor and can be queried.
+func TestAuditLog(t *testing.T) {
+	db, err := Open("testdata/audit.db", WithAudit())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	alice := db.WithActor("alice", "ticket 42")
+	if err := alice.Hset("user:1", "name", []byte("Alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Zadd("ranking", 1, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	between := time.Now()
+	if err := db.WithActor("bob", "").Rename("user:1", "user:2"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if err := db.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+
+	all, err := db.AuditLog("", time.Time{}, time.Time{})
+	if err != nil {
+		t.Fatalf("AuditLog failed: %v", err)
+	}
+	if len(all) != 5 {
+		t.Fatalf("expected 5 entries surviving FlushAll, got %+v", all)
+	}
+	first := all[0]
+	if first.Actor != "alice" || first.Reason != "ticket 42" || first.Op != "Hset" ||
+		first.Event.Type != EventHset || string(first.Event.Value) != "Alice" || first.Time.IsZero() {
+		t.Errorf("unexpected first entry: %+v", first)
+	}
+	if all[1].Actor != "" || all[1].Event.Type != EventZadd {
+		t.Errorf("unexpected anonymous entry: %+v", all[1])
+	}
+
+	// Queries by key match renames on either side
+	for _, key := range []string{"user:1", "user:2"} {
+		entries, err := db.AuditLog(key, time.Time{}, time.Time{})
+		if err != nil {
+			t.Fatalf("AuditLog failed: %v", err)
+		}
+		last := entries[len(entries)-1]
+		if last.Op != "FlushAll" && (last.Op != "Rename" || last.Actor != "bob") {
+			t.Errorf("%s: unexpected last entry %+v", key, last)
+		}
+	}
+
+	// Time ranges are half-open
+	before, err := db.AuditLog("", time.Time{}, between)
+	if err != nil {
+		t.Fatalf("AuditLog failed: %v", err)
+	}
+	after, err := db.AuditLog("", between, time.Time{})
+	if err != nil {
+		t.Fatalf("AuditLog failed: %v", err)
+	}
+	if len(before) != 2 || len(after) != 3 {
+		t.Errorf("expected 2 entries before and 3 after, got %d and %d", len(before), len(after))
+	}
+
+	// Disabled by default
+	plain, err := Open("testdata/audit_off.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer plain.Close()
+	if err := plain.Hset("k", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if entries, err := plain.AuditLog("", time.Time{}, time.Time{}); err != nil || len(entries) != 0 {
+		t.Errorf("expected no audit entries, got %v %v", entries, err)
+	}
+}
@@ -0,0 +1,93 @@
+package jungledb
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// BackupTarget stores backups, for example in object storage (see the s3backup package)
+// or in a directory (see DirTarget).
+type BackupTarget interface {
+	// Put stores the size bytes read from r as the backup name. It must not keep a partial
+	// backup under name if it fails, or if reading r fails.
+	Put(ctx context.Context, name string, r io.Reader, size int64) error
+}
+
+// DirTarget is a BackupTarget writing backups as files of a directory.
+type DirTarget string
+
+// Put writes the backup to a temporary file of the directory and renames it to name once
+// complete.
+func (d DirTarget) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	path := filepath.Join(string(d), name)
+	if err := ensureDir(path); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(path), ".backup-*")
+	if err != nil {
+		return fmt.Errorf("failed to create backup file: %v", err)
+	}
+	defer os.Remove(f.Name()) // Fails once renamed
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
+
+// BackupTo streams a consistent copy of the database file to target as the backup name,
+// without staging it on local disk or blocking writers, and returns its size.
+func (db *DB) BackupTo(ctx context.Context, target BackupTarget, name string) (int64, error) {
+	var n int64
+	err := db.db.View(func(tx *bbolt.Tx) error {
+		pr, pw := io.Pipe()
+		copied := make(chan struct{})
+		go func() {
+			defer close(copied)
+			_, err := tx.WriteTo(pw)
+			pw.CloseWithError(err)
+		}()
+		err := target.Put(ctx, name, pr, tx.Size())
+		pr.CloseWithError(io.ErrClosedPipe) // Stops the copy if Put returned early
+		<-copied                            // The transaction must outlive the copy
+		n = tx.Size()
+		return err
+	})
+	if err != nil {
+		return 0, closedError(fmt.Errorf("failed to back up to %s: %w", name, err))
+	}
+	return n, nil
+}
+
+// ScheduleBackups backs up the database to target every interval until ctx ends, naming
+// each backup with name, called with the time it starts. A failed backup is logged and
+// retried at the next interval.
+func (db *DB) ScheduleBackups(ctx context.Context, interval time.Duration, target BackupTarget, name func(time.Time) string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-db.clock().After(interval):
+			backup := name(now)
+			n, err := db.BackupTo(ctx, target, backup)
+			if err != nil {
+				db.log.Warn("scheduled backup failed", "name", backup, "error", err)
+				continue
+			}
+			db.log.Info("scheduled backup done", "name", backup, "size", n, "duration", db.now().Sub(now))
+		}
+	}
+}
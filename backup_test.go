@@ -0,0 +1,49 @@
+package jungledb
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+)
+
+// failingTarget is a BackupTarget that fails after reading part of the backup.
+type failingTarget struct{}
+
+func (failingTarget) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	if _, err := io.ReadFull(r, make([]byte, 16)); err != nil {
+		return err
+	}
+	return errors.New("upload failed")
+}
+
+// TestBackupTo tests streaming backups to a directory and a failing target.
+func TestBackupTo(t *testing.T) {
+	db, err := Open("testdata/backup_to.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Hset("user:1", "name", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	if _, err := db.BackupTo(context.Background(), DirTarget("testdata/backups"), "first.db"); err != nil {
+		t.Fatalf("BackupTo failed: %v", err)
+	}
+	backup, err := Open("testdata/backups/first.db")
+	if err != nil {
+		t.Fatalf("failed to open backup: %v", err)
+	}
+	defer backup.Close()
+	if v, err := backup.Hget("user:1", "name"); err != nil || string(v) != "alice" {
+		t.Errorf("expected the backup to hold the data, got %q %v", v, err)
+	}
+
+	if _, err := db.BackupTo(context.Background(), failingTarget{}, "second.db"); err == nil {
+		t.Errorf("expected the target error")
+	}
+	if err := db.Hset("user:1", "name", []byte("bob")); err != nil {
+		t.Errorf("expected writes to go on after a failed backup, got %v", err)
+	}
+}
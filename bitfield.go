@@ -0,0 +1,184 @@
+package jungledb
+
+import (
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// BitFieldKind is the kind of a BitFieldOp.
+type BitFieldKind int
+
+const (
+	BitFieldGet  BitFieldKind = iota // Read the integer
+	BitFieldSet                      // Replace the integer with Value, returning the previous one
+	BitFieldIncr                     // Add Value to the integer, returning the new one
+)
+
+// BitFieldOp is one operation of HbitField on an integer packed at a bit offset of a
+// field value, as Redis BITFIELD does. Bits are numbered from the most significant bit
+// of the first byte, and integers are stored big-endian.
+type BitFieldOp struct {
+	Kind     BitFieldKind
+	Signed   bool     // Two's complement integer of 1 to 64 bits, rather than unsigned of 1 to 63
+	Bits     int      // Width of the integer
+	Offset   int      // Bit offset of the integer in the value
+	Value    int64    // Value written by BitFieldSet, or added by BitFieldIncr
+	Overflow Overflow // What BitFieldSet and BitFieldIncr do with values that do not fit in Bits
+}
+
+// HbitField applies ops, in order, to integers packed in the value of a field of a hash,
+// atomically, and returns the result of each op. The value is extended with zero bytes
+// as needed, and bits past its end read as zero. If an op fails, for example one that
+// overflows with OverflowError, nothing is written. A missing field reads as an empty
+// value, and ops that only read do not create it.
+func (db *DB) HbitField(key, field string, ops ...BitFieldOp) ([]int64, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
+	size := 0
+	writes := false
+	for _, op := range ops {
+		if op.Offset < 0 || op.Bits < 1 || op.Bits > 64 || (op.Bits == 64 && !op.Signed) {
+			return nil, fmt.Errorf("invalid bit field of %d bits at offset %d: widths range from 1 to 64 bits signed, 63 unsigned", op.Bits, op.Offset)
+		}
+		if op.Kind != BitFieldGet {
+			writes = true
+			size = max(size, (op.Offset+op.Bits+7)/8)
+		}
+	}
+	if limit := db.maxValueSize(); limit > 0 && size > limit {
+		return nil, ErrValueTooLarge
+	}
+
+	var results []int64
+	if !writes {
+		err := db.view("HbitField", key, func(tx *bbolt.Tx) error {
+			bucket, err := db.hashBucket(tx, key)
+			if err != nil {
+				return err
+			}
+			var value []byte
+			if bucket != nil {
+				value, _ = getField(bucket, field)
+			}
+			results, _, err = applyBitField(value, ops)
+			return err
+		})
+		return results, err
+	}
+
+	err := db.update("HbitField", key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		current := bucket.Get([]byte(field))
+		value := make([]byte, max(len(current), size))
+		copy(value, current)
+		results, value, err = applyBitField(value, ops)
+		if err != nil {
+			return err
+		}
+		tx.record(Event{Type: EventHset, Key: key, Field: field, Value: value})
+		return bucket.Put([]byte(field), value)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// applyBitField applies ops to value, which must be long enough for the ops that write,
+// and returns their results and the value.
+func applyBitField(value []byte, ops []BitFieldOp) ([]int64, []byte, error) {
+	results := make([]int64, len(ops))
+	for i, op := range ops {
+		current := op.decode(getBits(value, op.Offset, op.Bits))
+		switch op.Kind {
+		case BitFieldGet:
+			results[i] = current
+		case BitFieldSet:
+			v, err := op.fit(op.Value, op.Value < 0, false)
+			if err != nil {
+				return nil, nil, err
+			}
+			setBits(value, op.Offset, op.Bits, uint64(v))
+			results[i] = current
+		case BitFieldIncr:
+			sum := current + op.Value
+			wrapped := (op.Value > 0 && sum < current) || (op.Value < 0 && sum > current)
+			v, err := op.fit(sum, op.Value < 0, wrapped)
+			if err != nil {
+				return nil, nil, err
+			}
+			setBits(value, op.Offset, op.Bits, uint64(v))
+			results[i] = v
+		default:
+			return nil, nil, fmt.Errorf("unknown bit field operation %d", op.Kind)
+		}
+	}
+	return results, value, nil
+}
+
+// fit brings v within the range of the integer of op, handling overflow as op says.
+// below tells which way v overflows, and wrapped that computing it overflowed an int64
+// already, leaving only its low bits right.
+func (op BitFieldOp) fit(v int64, below, wrapped bool) (int64, error) {
+	lo, hi := int64(0), int64(1)<<op.Bits-1
+	if op.Signed {
+		lo, hi = -1<<(op.Bits-1), 1<<(op.Bits-1)-1
+	}
+	if !wrapped && v >= lo && v <= hi {
+		return v, nil
+	}
+	switch op.Overflow {
+	case OverflowSaturate:
+		if below {
+			return lo, nil
+		}
+		return hi, nil
+	case OverflowWrap:
+		return op.decode(uint64(v)), nil
+	default:
+		return 0, ErrOverflow
+	}
+}
+
+// decode returns the integer of op held in the low bits of v.
+func (op BitFieldOp) decode(v uint64) int64 {
+	shift := 64 - op.Bits
+	if op.Signed {
+		return int64(v<<shift) >> shift
+	}
+	return int64(v << shift >> shift)
+}
+
+// getBits reads the n bits of b starting at bit offset, as an unsigned integer. Bits
+// past the end of b read as zero.
+func getBits(b []byte, offset, n int) uint64 {
+	var v uint64
+	for i := offset; i < offset+n; i++ {
+		v <<= 1
+		if i/8 < len(b) && b[i/8]&(0x80>>(i%8)) != 0 {
+			v |= 1
+		}
+	}
+	return v
+}
+
+// setBits writes the low n bits of v to b starting at bit offset.
+func setBits(b []byte, offset, n int, v uint64) {
+	for i := offset + n - 1; i >= offset; i-- {
+		if v&1 != 0 {
+			b[i/8] |= 0x80 >> (i % 8)
+		} else {
+			b[i/8] &^= 0x80 >> (i % 8)
+		}
+		v >>= 1
+	}
+}
@@ -0,0 +1,86 @@
+package jungledb
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"slices"
+	"testing"
+)
+
+// TestHbitField tests reading, writing and incrementing integers packed in a field value.
+func TestHbitField(t *testing.T) {
+	db, err := Open("testdata/bitfield.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Reads of a missing field see zeros and create nothing
+	results, err := db.HbitField("packed", "f", BitFieldOp{Bits: 8, Offset: 100})
+	if err != nil || len(results) != 1 || results[0] != 0 {
+		t.Errorf("HbitField of a missing field = %v, %v", results, err)
+	}
+	if _, found, _ := db.HgetOK("packed", "f"); found {
+		t.Errorf("expected a read to leave the field missing")
+	}
+
+	results, err = db.HbitField("packed", "f",
+		BitFieldOp{Kind: BitFieldSet, Bits: 4, Offset: 0, Value: 0xA},
+		BitFieldOp{Kind: BitFieldSet, Bits: 4, Offset: 4, Value: 0x5},
+		BitFieldOp{Kind: BitFieldSet, Signed: true, Bits: 8, Offset: 8, Value: -2},
+		BitFieldOp{Kind: BitFieldIncr, Bits: 4, Offset: 4, Value: 3},
+		BitFieldOp{Kind: BitFieldGet, Signed: true, Bits: 8, Offset: 8},
+	)
+	if err != nil {
+		t.Fatalf("HbitField failed: %v", err)
+	}
+	if want := []int64{0, 0, 0, 8, -2}; !slices.Equal(results, want) {
+		t.Errorf("expected results %v, got %v", want, results)
+	}
+	if v, _ := db.Hget("packed", "f"); !bytes.Equal(v, []byte{0xA8, 0xFE}) {
+		t.Errorf("expected the value a8fe, got %x", v)
+	}
+
+	overflow := []struct {
+		name     string
+		overflow Overflow
+		delta    int64
+		want     int64
+	}{
+		{"saturate", OverflowSaturate, 10, 15},
+		{"wrap", OverflowWrap, 10, 2},
+		{"saturate below", OverflowSaturate, -20, 0},
+	}
+	for _, tt := range overflow {
+		if _, err := db.HbitField("packed", tt.name, BitFieldOp{Kind: BitFieldSet, Bits: 4, Offset: 3, Value: 8}); err != nil {
+			t.Fatalf("%s: HbitField failed: %v", tt.name, err)
+		}
+		results, err := db.HbitField("packed", tt.name, BitFieldOp{Kind: BitFieldIncr, Bits: 4, Offset: 3, Value: tt.delta, Overflow: tt.overflow})
+		if err != nil || results[0] != tt.want {
+			t.Errorf("%s: expected %d, got %v, %v", tt.name, tt.want, results, err)
+		}
+	}
+
+	// A failing op leaves the value untouched, including the ops before it
+	_, err = db.HbitField("packed", "f",
+		BitFieldOp{Kind: BitFieldSet, Bits: 8, Offset: 0, Value: 0},
+		BitFieldOp{Kind: BitFieldIncr, Signed: true, Bits: 8, Offset: 8, Value: -127},
+	)
+	if !errors.Is(err, ErrOverflow) {
+		t.Errorf("expected ErrOverflow, got %v", err)
+	}
+	if v, _ := db.Hget("packed", "f"); !bytes.Equal(v, []byte{0xA8, 0xFE}) {
+		t.Errorf("expected the value to stay a8fe, got %x", v)
+	}
+
+	results, err = db.HbitField("packed", "wide", BitFieldOp{Kind: BitFieldIncr, Signed: true, Bits: 64, Offset: 5, Value: math.MaxInt64, Overflow: OverflowWrap},
+		BitFieldOp{Kind: BitFieldIncr, Signed: true, Bits: 64, Offset: 5, Value: 1, Overflow: OverflowWrap})
+	if err != nil || results[1] != math.MinInt64 {
+		t.Errorf("expected a 64-bit integer to wrap to math.MinInt64, got %v, %v", results, err)
+	}
+
+	if _, err := db.HbitField("packed", "f", BitFieldOp{Bits: 64}); err == nil {
+		t.Errorf("expected an error for a 64-bit unsigned integer")
+	}
+}
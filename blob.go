@@ -0,0 +1,252 @@
+package jungledb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// blobPrefix starts the names of the hashes holding blobs, followed by the hex SHA-256
+	// of the blob. Their fields are the reference count and the size as 8-byte integers,
+	// and the chunks of the blob, named by chunkField.
+	blobPrefix = "__jungledb.blob:"
+
+	// blobChunkSize is the largest chunk blobs are split into, unless the maximum value
+	// size is smaller.
+	blobChunkSize = 64 << 10
+
+	// blobGCBatchSize bounds the blobs visited per transaction by BlobGC.
+	blobGCBatchSize = 500
+)
+
+// Fields of the hashes holding blobs, besides the chunks.
+const (
+	blobRefsField = "refs"
+	blobSizeField = "size"
+)
+
+// BlobPut stores data as a content-addressed blob with one reference and returns its
+// hash, the hex SHA-256 of data. Storing data that is already stored only adds a
+// reference, so identical attachments take the space of one. Blobs are split into
+// chunks that fit the maximum value size (see WithMaxValueSize) and stored in hidden
+// hashes of the namespace of db, so they are replicated and exported with the keys.
+func (db *DB) BlobPut(data []byte) (hash string, err error) {
+	sum := sha256.Sum256(data)
+	hash = hex.EncodeToString(sum[:])
+	name := db.nsKey(blobPrefix + hash)
+	chunkSize := blobChunkSize
+	if limit := db.maxValueSize(); limit > 0 {
+		chunkSize = min(chunkSize, limit)
+	}
+	err = db.update("BlobPut", name, func(tx *txn) error {
+		if err := checkType(tx.Tx, name, typeHash); err != nil {
+			return err
+		}
+		if bucket := db.liveBucket(tx.Tx, name); bucket != nil {
+			_, err := incrField(tx, bucket, name, blobRefsField, 1, OverflowError, false)
+			return err
+		}
+		if tx.Bucket([]byte(name)) != nil { // Its TTL elapsed, so store it anew
+			if err := expireKey(tx, name); err != nil {
+				return err
+			}
+		}
+		for i := 0; i*chunkSize < len(data); i++ {
+			chunk := data[i*chunkSize : min((i+1)*chunkSize, len(data))]
+			if err := hset(tx, name, chunkField(i), bytes.Clone(chunk)); err != nil {
+				return err
+			}
+		}
+		if err := hset(tx, name, blobSizeField, binary.BigEndian.AppendUint64(nil, uint64(len(data)))); err != nil {
+			return err
+		}
+		return hset(tx, name, blobRefsField, binary.BigEndian.AppendUint64(nil, 1))
+	})
+	if err != nil {
+		return "", err
+	}
+	return hash, nil
+}
+
+// BlobGet returns the blob stored by BlobPut under hash, or ErrKeyNotFound if there is
+// none. Unreferenced blobs remain readable until BlobGC removes them.
+func (db *DB) BlobGet(hash string) ([]byte, error) {
+	if err := checkBlobHash(hash); err != nil {
+		return nil, err
+	}
+	name := db.nsKey(blobPrefix + hash)
+	var data []byte
+	err := db.view("BlobGet", name, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, name)
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return fmt.Errorf("%w: blob %s", ErrKeyNotFound, hash)
+		}
+		size, err := blobInt(bucket, blobSizeField)
+		if err != nil {
+			return err
+		}
+		data = make([]byte, 0, size)
+		for i := 0; uint64(len(data)) < size; i++ {
+			chunk := bucket.Get([]byte(chunkField(i)))
+			if chunk == nil {
+				return fmt.Errorf("blob %s is missing chunk %d", hash, i)
+			}
+			data = append(data, chunk...)
+		}
+		if uint64(len(data)) != size {
+			return fmt.Errorf("blob %s holds %d bytes, want %d", hash, len(data), size)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	db.metrics.addRead(len(data))
+	return data, nil
+}
+
+// BlobLink adds a reference to the blob stored under hash, for example when a second
+// message shares an attachment, or fails with ErrKeyNotFound if there is none.
+func (db *DB) BlobLink(hash string) error {
+	return db.blobRef("BlobLink", hash, 1)
+}
+
+// BlobUnlink removes a reference to the blob stored under hash. Blobs left without
+// references are kept until BlobGC, so a blob stored again meanwhile needs no rewrite.
+func (db *DB) BlobUnlink(hash string) error {
+	return db.blobRef("BlobUnlink", hash, -1)
+}
+
+// BlobRefs returns the references to the blob stored under hash, or ErrKeyNotFound if
+// there is none.
+func (db *DB) BlobRefs(hash string) (int64, error) {
+	if err := checkBlobHash(hash); err != nil {
+		return 0, err
+	}
+	name := db.nsKey(blobPrefix + hash)
+	var refs uint64
+	err := db.view("BlobRefs", name, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, name)
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return fmt.Errorf("%w: blob %s", ErrKeyNotFound, hash)
+		}
+		refs, err = blobInt(bucket, blobRefsField)
+		return err
+	})
+	return int64(refs), err
+}
+
+// BlobGC removes the blobs of the namespace of db that have no references left, in
+// batches, and returns how many it removed.
+func (db *DB) BlobGC() (removed int, err error) {
+	prefix := []byte(db.nsKey(blobPrefix))
+	after := prefix
+	for {
+		var batch int
+		var more bool
+		err := db.update("BlobGC", "", func(tx *txn) error {
+			// Collect the blobs first: deleting buckets moves cursors
+			var names []string
+			c := tx.Cursor()
+			name, v := c.Seek(after)
+			if bytes.Equal(name, after) {
+				name, v = c.Next()
+			}
+			for ; name != nil && bytes.HasPrefix(name, prefix) && len(names) < blobGCBatchSize; name, v = c.Next() {
+				if v == nil {
+					names = append(names, string(name))
+				}
+			}
+			more = name != nil && bytes.HasPrefix(name, prefix)
+			if len(names) > 0 {
+				after = []byte(names[len(names)-1])
+			}
+
+			for _, name := range names {
+				bucket := db.liveBucket(tx.Tx, name)
+				if bucket == nil || keyType(tx.Tx, []byte(name)) != typeHash {
+					continue
+				}
+				if refs, err := blobInt(bucket, blobRefsField); err != nil || refs > 0 {
+					continue // Not a blob BlobPut stored, or still in use
+				}
+				if err := deleteKey(tx, name); err != nil {
+					return err
+				}
+				tx.record(Event{Type: EventDelete, Key: name})
+				batch++
+			}
+			return nil
+		})
+		if err != nil {
+			return removed, err
+		}
+		removed += batch
+		if !more {
+			return removed, nil
+		}
+	}
+}
+
+// blobRef adds delta to the references to the blob stored under hash.
+func (db *DB) blobRef(op, hash string, delta int64) error {
+	if err := checkBlobHash(hash); err != nil {
+		return err
+	}
+	name := db.nsKey(blobPrefix + hash)
+	return db.update(op, name, func(tx *txn) error {
+		bucket, err := db.hashBucket(tx.Tx, name)
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return fmt.Errorf("%w: blob %s", ErrKeyNotFound, hash)
+		}
+		refs, err := blobInt(bucket, blobRefsField)
+		if err != nil {
+			return err
+		}
+		if delta < 0 && refs == 0 {
+			return fmt.Errorf("blob %s has no references to remove", hash)
+		}
+		_, err = incrField(tx, bucket, name, blobRefsField, delta, OverflowError, false)
+		return err
+	})
+}
+
+// blobInt reads an integer field of the hash holding a blob.
+func blobInt(bucket *bbolt.Bucket, field string) (uint64, error) {
+	v := bucket.Get([]byte(field))
+	if len(v) != 8 {
+		return 0, fmt.Errorf("%w: field %q of a blob is not an 8-byte integer", ErrWrongType, field)
+	}
+	return binary.BigEndian.Uint64(v), nil
+}
+
+// chunkField returns the field holding chunk i of a blob.
+func chunkField(i int) string {
+	return fmt.Sprintf("chunk:%08x", i)
+}
+
+// checkBlobHash returns an error unless hash is a hash BlobPut returns.
+func checkBlobHash(hash string) error {
+	valid := len(hash) == 2*sha256.Size
+	for i := 0; i < len(hash) && valid; i++ {
+		valid = '0' <= hash[i] && hash[i] <= '9' || 'a' <= hash[i] && hash[i] <= 'f'
+	}
+	if !valid {
+		return fmt.Errorf("invalid blob hash %q: want a lowercase hex SHA-256", hash)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package jungledb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// TestBlobs tests that blobs are stored once per content, split into chunks, counted by
+// reference and removed by BlobGC once unreferenced, without showing up as keys.
+func TestBlobs(t *testing.T) {
+	db, err := Open("testdata/blobs.db", WithMaxValueSize(1000))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	large := bytes.Repeat([]byte("0123456789"), 550) // Six chunks
+	hash, err := db.BlobPut(large)
+	if err != nil {
+		t.Fatalf("BlobPut failed: %v", err)
+	}
+	if sum := sha256.Sum256(large); hash != hex.EncodeToString(sum[:]) {
+		t.Fatalf("BlobPut returned hash %s, want the hex SHA-256 of the data", hash)
+	}
+	again, err := db.BlobPut(bytes.Clone(large))
+	if err != nil {
+		t.Fatalf("BlobPut failed: %v", err)
+	}
+	if again != hash {
+		t.Fatalf("BlobPut of the same data: got hash %s, want %s", again, hash)
+	}
+	empty, err := db.BlobPut(nil)
+	if err != nil {
+		t.Fatalf("BlobPut failed: %v", err)
+	}
+
+	if data, err := db.BlobGet(hash); err != nil || !bytes.Equal(data, large) {
+		t.Fatalf("BlobGet: got %d bytes, error %v, want %d bytes", len(data), err, len(large))
+	}
+	if data, err := db.BlobGet(empty); err != nil || len(data) != 0 {
+		t.Fatalf("BlobGet of the empty blob: got %q, error %v", data, err)
+	}
+	if _, err := db.BlobGet("NOT-A-HASH"); err == nil {
+		t.Fatalf("BlobGet of an invalid hash succeeded")
+	}
+	keys, _, err := db.ListKeys("*", "", 0)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Fatalf("ListKeys: got %q, want no keys", keys)
+	}
+
+	if err := db.BlobLink(hash); err != nil {
+		t.Fatalf("BlobLink failed: %v", err)
+	}
+	if refs, err := db.BlobRefs(hash); err != nil || refs != 3 {
+		t.Fatalf("BlobRefs: got %d, error %v, want 3", refs, err)
+	}
+	for range 3 {
+		if err := db.BlobUnlink(hash); err != nil {
+			t.Fatalf("BlobUnlink failed: %v", err)
+		}
+	}
+	if err := db.BlobUnlink(hash); err == nil {
+		t.Fatalf("BlobUnlink of an unreferenced blob succeeded")
+	}
+	if _, err := db.BlobGet(hash); err != nil {
+		t.Fatalf("BlobGet of an unreferenced blob before BlobGC failed: %v", err)
+	}
+
+	removed, err := db.BlobGC()
+	if err != nil {
+		t.Fatalf("BlobGC failed: %v", err)
+	}
+	if removed != 1 {
+		t.Fatalf("BlobGC removed %d blobs, want 1", removed)
+	}
+	if _, err := db.BlobGet(hash); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("BlobGet of a collected blob: got error %v, want ErrKeyNotFound", err)
+	}
+	if err := db.BlobLink(hash); !errors.Is(err, ErrKeyNotFound) {
+		t.Fatalf("BlobLink of a collected blob: got error %v, want ErrKeyNotFound", err)
+	}
+	if _, err := db.BlobGet(empty); err != nil {
+		t.Fatalf("BlobGet of a referenced blob after BlobGC failed: %v", err)
+	}
+}
@@ -0,0 +1,369 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// accessBucket tracks keys of cache namespaces:
+	// key -> 8-byte last access (Unix nanoseconds) + 8-byte access count + 8-byte size.
+	accessBucket = internalPrefix + "access"
+
+	// cacheBucket holds the totals of each cache namespace:
+	// prefix -> 8-byte key count + 8-byte size.
+	cacheBucket = internalPrefix + "cache"
+
+	// evictionBucket holds the eviction order of each cache namespace, in a bucket named
+	// "p" + its prefix: 'l' + 8-byte last access + key for EvictLRU, and 'f' + 8-byte access
+	// count + 8-byte last access + key for EvictLFU, both kept so the policy can change.
+	evictionBucket = internalPrefix + "eviction"
+)
+
+// EvictionPolicy selects which keys a cache namespace evicts first.
+type EvictionPolicy int
+
+const (
+	EvictLRU EvictionPolicy = iota // Least recently used keys first
+	EvictLFU                       // Least frequently used keys first, least recently used among equals
+)
+
+// CacheNamespace caps the keys starting with Prefix so they can be used as a cache.
+// Writes that take the namespace beyond MaxKeys keys or MaxBytes bytes (as measured by
+// Usage) evict keys according to Policy until it fits again. A zero cap is unlimited.
+type CacheNamespace struct {
+	Prefix   string
+	MaxKeys  int
+	MaxBytes int64
+	Policy   EvictionPolicy
+}
+
+// WithCache turns namespaces into caches; see CacheNamespace. A key belongs to the first
+// namespace whose prefix it starts with. Reads and writes of cached keys count as
+// accesses; reads are collected in memory and stored in batches by the next write.
+// Evicted keys are reported to watchers and the operation log as EventEvicted.
+func WithCache(namespaces ...CacheNamespace) Option {
+	return func(o *options) {
+		o.caches = append(o.caches, namespaces...)
+	}
+}
+
+// keyAccess is the access record of a cached key.
+type keyAccess struct {
+	last int64 // Unix nanoseconds
+	hits uint64
+	size int64
+}
+
+func (a keyAccess) encode() []byte {
+	b := make([]byte, 24)
+	binary.BigEndian.PutUint64(b, uint64(a.last))
+	binary.BigEndian.PutUint64(b[8:], a.hits)
+	binary.BigEndian.PutUint64(b[16:], uint64(a.size))
+	return b
+}
+
+func decodeKeyAccess(b []byte) (keyAccess, bool) {
+	if len(b) != 24 {
+		return keyAccess{}, false
+	}
+	return keyAccess{
+		last: int64(binary.BigEndian.Uint64(b)),
+		hits: binary.BigEndian.Uint64(b[8:]),
+		size: int64(binary.BigEndian.Uint64(b[16:])),
+	}, true
+}
+
+// orderKeys returns the keys under which the eviction order holds key with access a.
+func orderKeys(key string, a keyAccess) (lru, lfu []byte) {
+	lru = make([]byte, 9, 9+len(key))
+	lru[0] = 'l'
+	binary.BigEndian.PutUint64(lru[1:], uint64(a.last))
+	lfu = make([]byte, 17, 17+len(key))
+	lfu[0] = 'f'
+	binary.BigEndian.PutUint64(lfu[1:], a.hits)
+	binary.BigEndian.PutUint64(lfu[9:], uint64(a.last))
+	return append(lru, key...), append(lfu, key...)
+}
+
+// putOrder adds key with access a to the eviction order.
+func putOrder(order *bbolt.Bucket, key string, a keyAccess) error {
+	lru, lfu := orderKeys(key, a)
+	if err := order.Put(lru, []byte{}); err != nil {
+		return err
+	}
+	return order.Put(lfu, []byte{})
+}
+
+// deleteOrder removes key with access a from the eviction order.
+func deleteOrder(order *bbolt.Bucket, key string, a keyAccess) error {
+	lru, lfu := orderKeys(key, a)
+	if err := order.Delete(lru); err != nil {
+		return err
+	}
+	return order.Delete(lfu)
+}
+
+// cacheTotals is the size of a cache namespace.
+type cacheTotals struct {
+	keys  int64
+	bytes int64
+}
+
+// pendingAccesses collects reads of cached keys until the next write stores them.
+type pendingAccesses struct {
+	mu   sync.Mutex
+	keys map[string]keyAccess // last and hits only
+}
+
+// cacheFor returns the index of the cache namespace key belongs to, or -1.
+func (db *DB) cacheFor(key string) int {
+	for i, c := range db.opts.caches {
+		if strings.HasPrefix(key, c.Prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// touch records a read of key if it belongs to a cache namespace.
+func (db *DB) touch(key string) {
+	if key == "" || db.cacheFor(key) < 0 {
+		return
+	}
+	p := &db.accesses
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keys == nil {
+		p.keys = make(map[string]keyAccess)
+	}
+	a := p.keys[key]
+	a.last = db.now().UnixNano()
+	a.hits++
+	p.keys[key] = a
+}
+
+// trackCache stores pending reads, accounts for the keys changed by tx and evicts keys
+// from cache namespaces that no longer fit.
+func (db *DB) trackCache(tx *txn) error {
+	if len(db.opts.caches) == 0 {
+		return nil
+	}
+
+	p := &db.accesses
+	p.mu.Lock()
+	reads := p.keys
+	p.keys = nil
+	p.mu.Unlock()
+	if len(reads) == 0 && len(tx.events) == 0 {
+		return nil
+	}
+
+	access, err := tx.CreateBucketIfNotExists([]byte(accessBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create access bucket: %v", err)
+	}
+	totals, err := tx.CreateBucketIfNotExists([]byte(cacheBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create cache bucket: %v", err)
+	}
+	orders, err := db.cacheOrders(tx, access)
+	if err != nil {
+		return err
+	}
+
+	for key, read := range reads {
+		a, ok := decodeKeyAccess(access.Get([]byte(key)))
+		i := db.cacheFor(key)
+		if !ok || i < 0 {
+			continue // Gone, or never written
+		}
+		if err := deleteOrder(orders[i], key, a); err != nil {
+			return err
+		}
+		a.last = max(a.last, read.last)
+		a.hits += read.hits
+		if err := access.Put([]byte(key), a.encode()); err != nil {
+			return err
+		}
+		if err := putOrder(orders[i], key, a); err != nil {
+			return err
+		}
+	}
+
+	now := db.now().UnixNano()
+	changed := make(map[string]bool)
+	grown := make(map[int]bool)
+	for _, ev := range tx.events {
+		for _, key := range []string{ev.Key, ev.Target} {
+			i := db.cacheFor(key)
+			if key == "" || i < 0 || changed[key] {
+				continue
+			}
+			changed[key] = true
+
+			t := getCacheTotals(totals, db.opts.caches[i].Prefix)
+			a, had := decodeKeyAccess(access.Get([]byte(key)))
+			if had {
+				t.keys--
+				t.bytes -= a.size
+				if err := deleteOrder(orders[i], key, a); err != nil {
+					return err
+				}
+			}
+			usage, exists := keyUsage(tx.Tx, key)
+			if exists {
+				a = keyAccess{last: now, hits: a.hits + 1, size: usage.Bytes}
+				t.keys++
+				t.bytes += a.size
+				if err := access.Put([]byte(key), a.encode()); err != nil {
+					return err
+				}
+				err = putOrder(orders[i], key, a)
+				grown[i] = true
+			} else if had {
+				err = access.Delete([]byte(key))
+			}
+			if err != nil {
+				return err
+			}
+			if err := putCacheTotals(totals, db.opts.caches[i].Prefix, t); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := range grown {
+		if err := db.evict(tx, access, totals, orders[i], i, changed); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cacheOrders returns the eviction order bucket of each cache namespace. Databases whose
+// access records predate the eviction order have it built from them once.
+func (db *DB) cacheOrders(tx *txn, access *bbolt.Bucket) ([]*bbolt.Bucket, error) {
+	eviction := tx.Bucket([]byte(evictionBucket))
+	build := eviction == nil
+	if build {
+		var err error
+		if eviction, err = tx.CreateBucket([]byte(evictionBucket)); err != nil {
+			return nil, fmt.Errorf("failed to create eviction bucket: %v", err)
+		}
+	}
+	orders := make([]*bbolt.Bucket, len(db.opts.caches))
+	for i, ns := range db.opts.caches {
+		order, err := eviction.CreateBucketIfNotExists([]byte("p" + ns.Prefix)) // Bucket names cannot be empty
+		if err != nil {
+			return nil, fmt.Errorf("failed to create eviction order of %s: %v", ns.Prefix, err)
+		}
+		orders[i] = order
+	}
+	if !build {
+		return orders, nil
+	}
+	err := access.ForEach(func(k, v []byte) error {
+		a, ok := decodeKeyAccess(v)
+		i := db.cacheFor(string(k))
+		if !ok || i < 0 {
+			return nil
+		}
+		return putOrder(orders[i], string(k), a)
+	})
+	return orders, err
+}
+
+// evict removes keys from cache namespace i, in the order kept in order, until it fits
+// within its caps. Keys written by tx itself go last, so that a write is not undone by its
+// own eviction.
+func (db *DB) evict(tx *txn, access, totals, order *bbolt.Bucket, i int, written map[string]bool) error {
+	ns := db.opts.caches[i]
+	t := getCacheTotals(totals, ns.Prefix)
+	fits := func() bool {
+		return (ns.MaxKeys <= 0 || t.keys <= int64(ns.MaxKeys)) && (ns.MaxBytes <= 0 || t.bytes <= ns.MaxBytes)
+	}
+	if fits() {
+		return nil
+	}
+
+	rank, skip := []byte{'l'}, 9
+	if ns.Policy == EvictLFU {
+		rank, skip = []byte{'f'}, 17
+	}
+	// Victims are collected first, as deleting moves a cursor
+	var victims []string
+	for _, last := range []bool{false, true} {
+		c := order.Cursor()
+		for k, _ := c.Seek(rank); k != nil && bytes.HasPrefix(k, rank) && !fits(); k, _ = c.Next() {
+			key := string(k[skip:])
+			if written[key] != last {
+				continue
+			}
+			a, _ := decodeKeyAccess(access.Get([]byte(key)))
+			victims = append(victims, key)
+			t.keys--
+			t.bytes -= a.size
+		}
+	}
+
+	for _, key := range victims {
+		a, _ := decodeKeyAccess(access.Get([]byte(key)))
+		if err := deleteKey(tx, key); err != nil {
+			return fmt.Errorf("failed to evict key %s: %v", key, err)
+		}
+		if err := access.Delete([]byte(key)); err != nil {
+			return err
+		}
+		if err := deleteOrder(order, key, a); err != nil {
+			return err
+		}
+		tx.record(Event{Type: EventEvicted, Key: key})
+	}
+	return putCacheTotals(totals, ns.Prefix, t)
+}
+
+func getCacheTotals(totals *bbolt.Bucket, prefix string) cacheTotals {
+	v := totals.Get([]byte(prefix))
+	if len(v) != 16 {
+		return cacheTotals{}
+	}
+	return cacheTotals{keys: int64(binary.BigEndian.Uint64(v)), bytes: int64(binary.BigEndian.Uint64(v[8:]))}
+}
+
+func putCacheTotals(totals *bbolt.Bucket, prefix string, t cacheTotals) error {
+	v := make([]byte, 16)
+	binary.BigEndian.PutUint64(v, uint64(t.keys))
+	binary.BigEndian.PutUint64(v[8:], uint64(t.bytes))
+	return totals.Put([]byte(prefix), v)
+}
+
+// CacheStats reports the size of a cache namespace configured with WithCache.
+type CacheStats struct {
+	Prefix string
+	Keys   int64
+	Bytes  int64
+}
+
+// CacheStats returns the current size of every cache namespace, in the order they were
+// configured.
+func (db *DB) CacheStats() ([]CacheStats, error) {
+	stats := make([]CacheStats, len(db.opts.caches))
+	err := db.view("CacheStats", "", func(tx *bbolt.Tx) error {
+		totals := tx.Bucket([]byte(cacheBucket))
+		for i, ns := range db.opts.caches {
+			stats[i].Prefix = ns.Prefix
+			if totals != nil {
+				t := getCacheTotals(totals, ns.Prefix)
+				stats[i].Keys, stats[i].Bytes = t.keys, t.bytes
+			}
+		}
+		return nil
+	})
+	return stats, err
+}
@@ -0,0 +1,135 @@
+package jungledb
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.etcd.io/bbolt"
+)
+
+// TestCacheLRU tests that a cache namespace evicts its least recently used keys.
+func TestCacheLRU(t *testing.T) {
+	db, err := Open("testdata/cache_lru.db", WithCache(CacheNamespace{Prefix: "cache:", MaxKeys: 3}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	events, cancel := db.Watch("cache:*")
+	defer cancel()
+
+	for i := 0; i < 3; i++ {
+		if err := db.Hset(fmt.Sprintf("cache:%d", i), "f", []byte("v")); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	// Reading cache:0 makes cache:1 the least recently used key
+	if _, err := db.Hget("cache:0", "f"); err != nil {
+		t.Fatalf("Hget failed: %v", err)
+	}
+	if err := db.Hset("cache:3", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	// Keys outside the namespace are never evicted or counted
+	if err := db.Hset("other", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	for key, want := range map[string]bool{"cache:0": true, "cache:1": false, "cache:2": true, "cache:3": true, "other": true} {
+		if _, err := db.Usage(key); (err == nil) != want {
+			t.Errorf("%s: expected present=%v, got %v", key, want, err)
+		}
+	}
+
+	evicted := ""
+	for len(events) > 0 {
+		if ev := <-events; ev.Type == EventEvicted {
+			evicted = ev.Key
+		}
+	}
+	if evicted != "cache:1" {
+		t.Errorf("expected an EventEvicted for cache:1, got %q", evicted)
+	}
+
+	stats, err := db.CacheStats()
+	if err != nil {
+		t.Fatalf("CacheStats failed: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Keys != 3 || stats[0].Bytes != 3*2 {
+		t.Errorf("CacheStats = %+v, expected 3 keys of 2 bytes", stats)
+	}
+
+	if err := db.HdelBucket("cache:0"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if stats, _ := db.CacheStats(); stats[0].Keys != 2 {
+		t.Errorf("CacheStats after delete = %+v, expected 2 keys", stats)
+	}
+}
+
+// TestCacheLFU tests byte caps and least frequently used eviction.
+func TestCacheLFU(t *testing.T) {
+	db, err := Open("testdata/cache_lfu.db", WithCache(CacheNamespace{Prefix: "lfu:", MaxBytes: 30, Policy: EvictLFU}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	value := []byte("123456789") // 10 bytes with the field name
+	for _, key := range []string{"lfu:a", "lfu:b", "lfu:c"} {
+		if err := db.Hset(key, "f", value); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		db.Hget("lfu:a", "f")
+		db.Hget("lfu:c", "f")
+	}
+	if err := db.Hset("lfu:d", "f", value); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	if _, err := db.Usage("lfu:b"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("lfu:b: expected eviction, got %v", err)
+	}
+	for _, key := range []string{"lfu:a", "lfu:c", "lfu:d"} {
+		if _, err := db.Usage(key); err != nil {
+			t.Errorf("%s: expected to be kept, got %v", key, err)
+		}
+	}
+}
+
+// TestCacheEvictionOrderBuilt tests that the eviction order of a database written before
+// it was kept is built from the access records.
+func TestCacheEvictionOrderBuilt(t *testing.T) {
+	db, err := Open("testdata/cache_order.db", WithCache(CacheNamespace{Prefix: "cache:", MaxKeys: 3}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 3; i++ {
+		if err := db.Hset(fmt.Sprintf("cache:%d", i), "f", []byte("v")); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket([]byte(evictionBucket))
+	})
+	if err != nil {
+		t.Fatalf("failed to drop the eviction order: %v", err)
+	}
+	if _, err := db.Hget("cache:0", "f"); err != nil {
+		t.Fatalf("Hget failed: %v", err)
+	}
+	if err := db.Hset("cache:3", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	for key, want := range map[string]bool{"cache:0": true, "cache:1": false, "cache:2": true, "cache:3": true} {
+		if _, err := db.Usage(key); (err == nil) != want {
+			t.Errorf("%s: expected present=%v, got %v", key, want, err)
+		}
+	}
+}
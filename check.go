@@ -0,0 +1,268 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// Inconsistency is a problem found by Check.
+type Inconsistency struct {
+	Key      string // Empty for problems of the file itself
+	Problem  string
+	Repaired bool
+}
+
+// Check verifies the database and returns the inconsistencies found, none for a
+// healthy database. It runs bbolt's consistency check of the file's pages, then checks
+// that every sorted set entry has a member index entry with the same score and vice
+// versa, that keys hold what their type allows and that the TTL buckets and the record
+// of which keys are sorted sets agree with each other and point at existing keys. With
+// repair, the problems that can be fixed without losing data are, in a single
+// transaction: missing indexes and index entries are rebuilt from the entries they
+// index, stale and malformed entries are removed and TTLs of missing keys are dropped. Repairs are not recorded in the operation or audit logs.
+// Damaged pages cannot be repaired; restore a backup instead.
+func (db *DB) Check(repair bool) ([]Inconsistency, error) {
+	var found []Inconsistency
+	if !repair {
+		err := db.view("Check", "", func(tx *bbolt.Tx) error {
+			var err error
+			found, err = check(tx, false)
+			return err
+		})
+		return found, err
+	}
+	err := db.update("Check", "", func(tx *txn) error {
+		return db.labeled("Check", "", func() error {
+			var err error
+			found, err = check(tx.Tx, true)
+			return err
+		})
+	})
+	db.clearHot() // Repairs record no events
+	return found, err
+}
+
+// check verifies the database in tx, repairing what it can if repair is set.
+func check(tx *bbolt.Tx, repair bool) ([]Inconsistency, error) {
+	var found []Inconsistency
+	for err := range tx.Check() {
+		found = append(found, Inconsistency{Problem: err.Error()})
+	}
+
+	var names [][]byte
+	tx.ForEach(func(name []byte, _ *bbolt.Bucket) error {
+		if !isInternalBucket(tx, name) {
+			names = append(names, bytes.Clone(name))
+		}
+		return nil
+	})
+	for _, name := range names {
+		var err error
+		if keyType(tx, name) == typeZset {
+			err = checkZset(tx, name, repair, &found)
+		} else {
+			checkHash(tx, name, &found)
+		}
+		if err != nil {
+			return found, err
+		}
+	}
+
+	if err := checkZsets(tx, repair, &found); err != nil {
+		return found, err
+	}
+	if err := checkTTLs(tx, repair, &found); err != nil {
+		return found, err
+	}
+	return found, nil
+}
+
+// checkZsets checks that the keys recorded as sorted sets exist.
+func checkZsets(tx *bbolt.Tx, repair bool, found *[]Inconsistency) error {
+	zsets := tx.Bucket(zsetsName)
+	if zsets == nil {
+		return nil
+	}
+	var stale [][]byte
+	zsets.ForEach(func(name, _ []byte) error {
+		if tx.Bucket(name) == nil {
+			stale = append(stale, bytes.Clone(name))
+		}
+		return nil
+	})
+	for _, name := range stale {
+		err := fix(found, name, repair, func() error { return zsets.Delete(name) }, "recorded as a sorted set but missing")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fix records a problem of key and, if repair is set, repairs it with fn.
+func fix(found *[]Inconsistency, key []byte, repair bool, fn func() error, format string, args ...any) error {
+	problem := Inconsistency{Key: string(key), Problem: fmt.Sprintf(format, args...)}
+	if repair && fn != nil {
+		if err := fn(); err != nil {
+			return fmt.Errorf("failed to repair %s: %v", key, err)
+		}
+		problem.Repaired = true
+	}
+	*found = append(*found, problem)
+	return nil
+}
+
+// checkHash checks that the hash name holds only fields.
+func checkHash(tx *bbolt.Tx, name []byte, found *[]Inconsistency) {
+	tx.Bucket(name).ForEach(func(k, v []byte) error {
+		if v == nil {
+			fix(found, name, false, nil, "field %q of the hash is a nested bucket", k)
+		}
+		return nil
+	})
+}
+
+// checkZset checks that the score-ordered bucket of the sorted set name and its member
+// index hold the same members and scores. The index wins when they disagree on a score,
+// as zadd and zrem look members up there.
+func checkZset(tx *bbolt.Tx, name []byte, repair bool, found *[]Inconsistency) error {
+	main := tx.Bucket(name)
+	index := membersBucket(tx, string(name))
+	if index == nil {
+		// Rebuilt from the entries of the sorted set below
+		err := fix(found, name, repair, func() error {
+			var err error
+			index, err = tx.CreateBucket(append(bytes.Clone(name), membersSuffix...))
+			return err
+		}, "the member index of the sorted set is missing")
+		if err != nil || index == nil {
+			return err
+		}
+	}
+
+	// Repairs are collected and applied once iteration is done, as bbolt cursors do not
+	// survive changes to their bucket
+	type pending struct {
+		problem int // Index in found
+		fn      func() error
+	}
+	var repairs []pending
+	problem := func(fn func() error, format string, args ...any) error {
+		if fn != nil {
+			repairs = append(repairs, pending{len(*found), fn})
+		}
+		return fix(found, name, false, nil, format, args...)
+	}
+	apply := func() error {
+		defer func() { repairs = nil }()
+		if !repair {
+			return nil
+		}
+		for _, r := range repairs {
+			if err := r.fn(); err != nil {
+				return fmt.Errorf("failed to repair %s: %v", name, err)
+			}
+			(*found)[r.problem].Repaired = true
+		}
+		return nil
+	}
+
+	err := index.ForEach(func(member, score []byte) error {
+		member, score = bytes.Clone(member), bytes.Clone(score)
+		switch {
+		case score == nil:
+			return problem(nil, "member %q of the member index is a nested bucket", member)
+		case len(score) != 8:
+			return problem(func() error { return index.Delete(member) }, "member %q has a malformed score in the member index", member)
+		case main.Get(append(score, member...)) == nil:
+			return problem(func() error { return main.Put(append(score, member...), []byte{}) }, "member %q of the member index is missing from the sorted set", member)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := apply(); err != nil {
+		return err
+	}
+
+	indexed := make(map[string]bool) // Members added to the index by this check
+	err = main.ForEach(func(k, v []byte) error {
+		k = bytes.Clone(k)
+		if v == nil {
+			return problem(nil, "entry %q of the sorted set is a nested bucket", k)
+		}
+		if len(k) < 8 {
+			return problem(func() error { return main.Delete(k) }, "malformed sorted set entry %q", k)
+		}
+		score, member := k[:8], k[8:]
+		switch current := index.Get(member); {
+		case current == nil && !indexed[string(member)]:
+			indexed[string(member)] = true
+			return problem(func() error { return index.Put(member, score) }, "member %q is missing from the member index", member)
+		case !bytes.Equal(current, score):
+			return problem(func() error { return main.Delete(k) }, "member %q has a stale entry in the sorted set", member)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return apply()
+}
+
+// checkTTLs checks that every TTL belongs to an existing key and that the TTL bucket and
+// the expiry index agree.
+func checkTTLs(tx *bbolt.Tx, repair bool, found *[]Inconsistency) error {
+	ttls := tx.Bucket([]byte(ttlBucket))
+	index := tx.Bucket([]byte(expiryBucket))
+
+	type entry struct{ key, value []byte }
+	collect := func(b *bbolt.Bucket) []entry {
+		var entries []entry
+		if b != nil {
+			b.ForEach(func(k, v []byte) error {
+				entries = append(entries, entry{bytes.Clone(k), bytes.Clone(v)})
+				return nil
+			})
+		}
+		return entries
+	}
+
+	for _, e := range collect(ttls) {
+		key := e.key
+		var err error
+		switch {
+		case len(e.value) != 8:
+			err = fix(found, key, repair, func() error { return ttls.Delete(key) }, "malformed TTL")
+		case tx.Bucket(key) == nil:
+			err = fix(found, key, repair, func() error { return putExpiry(tx, string(key), 0) }, "TTL of a missing key")
+		case index == nil || index.Get(expiryKey(int64(binary.BigEndian.Uint64(e.value)), string(key))) == nil:
+			err = fix(found, key, repair, func() error {
+				return putExpiry(tx, string(key), int64(binary.BigEndian.Uint64(e.value)))
+			}, "TTL missing from the expiry index")
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// The index is read again, as repairs of the TTL bucket may have changed it
+	index = tx.Bucket([]byte(expiryBucket))
+	for _, e := range collect(index) {
+		k := e.key
+		var err error
+		if len(k) < 8 {
+			err = fix(found, nil, repair, func() error { return index.Delete(k) }, "malformed expiry index entry %q", k)
+		} else if key := k[8:]; expiry(tx, string(key)) != int64(binary.BigEndian.Uint64(k)) {
+			err = fix(found, key, repair, func() error { return index.Delete(k) }, "expiry index entry does not match the TTL")
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package jungledb
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// TestCheck tests finding and repairing broken sorted set indexes and TTLs.
+func TestCheck(t *testing.T) {
+	db, err := Open("testdata/check.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Zadd("ranking", 1, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Zadd("ranking", 2, "bob"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Hset("session", "user", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Expire("session", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+
+	if found, err := db.Check(false); err != nil || len(found) != 0 {
+		t.Fatalf("expected a healthy database, got %v %v", found, err)
+	}
+
+	var deadline int64
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		deadline = expiry(tx, "session")
+		if err := tx.Bucket([]byte("ranking_members")).Delete([]byte("alice")); err != nil {
+			return err
+		}
+		stale := append(encodeSeq(math.Float64bits(5)), "bob"...)
+		if err := tx.Bucket([]byte("ranking")).Put(stale, []byte{}); err != nil {
+			return err
+		}
+		if err := tx.Bucket([]byte(expiryBucket)).Delete(expiryKey(deadline, "session")); err != nil {
+			return err
+		}
+		return tx.Bucket([]byte(ttlBucket)).Put([]byte("gone"), encodeSeq(uint64(deadline)))
+	})
+	if err != nil {
+		t.Fatalf("failed to corrupt the database: %v", err)
+	}
+
+	found, err := db.Check(false)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	if len(found) != 4 {
+		t.Fatalf("expected 4 problems, got %v", found)
+	}
+	for _, p := range found {
+		if p.Repaired {
+			t.Errorf("expected no repair without asking, got %v", p)
+		}
+	}
+
+	found, err = db.Check(true)
+	if err != nil || len(found) != 4 {
+		t.Fatalf("expected 4 problems, got %v %v", found, err)
+	}
+	for _, p := range found {
+		if !p.Repaired {
+			t.Errorf("expected %v to be repaired", p)
+		}
+	}
+	if found, err := db.Check(false); err != nil || len(found) != 0 {
+		t.Errorf("expected a healthy database after repair, got %v %v", found, err)
+	}
+
+	if score, err := db.Zscore("ranking", "alice"); err != nil || score != 1 {
+		t.Errorf("expected alice to be indexed again, got %v %v", score, err)
+	}
+	if members, err := db.Zrange("ranking", 0, -1); err != nil || len(members) != 2 {
+		t.Errorf("expected the stale entry to be gone, got %v %v", members, err)
+	}
+	err = db.db.View(func(tx *bbolt.Tx) error {
+		if tx.Bucket([]byte(expiryBucket)).Get(expiryKey(deadline, "session")) == nil {
+			t.Errorf("expected the expiry index entry to be rebuilt")
+		}
+		if expiry(tx, "gone") != 0 {
+			t.Errorf("expected the TTL of the missing key to be dropped")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View failed: %v", err)
+	}
+}
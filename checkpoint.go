@@ -0,0 +1,84 @@
+package jungledb
+
+import (
+	"bytes"
+
+	"go.etcd.io/bbolt"
+)
+
+// defaultCheckpointEvery is how many keys scans process between checkpoints by default.
+const defaultCheckpointEvery = 1000
+
+// checkpoints calls the Checkpoint function of a scan every so many keys.
+type checkpoints struct {
+	fn    func(after string) error
+	every int
+	n     int    // Keys processed since the last checkpoint
+	last  string // Key processed last, along with all the keys before it
+}
+
+func newCheckpoints(fn func(after string) error, every int) *checkpoints {
+	if every <= 0 {
+		every = defaultCheckpointEvery
+	}
+	return &checkpoints{fn: fn, every: every}
+}
+
+// done records that the keys up to after are processed and takes a checkpoint if one is
+// due, calling flush first so that the output up to it is written.
+func (c *checkpoints) done(after string, flush func() error) error {
+	c.last = after
+	c.n++
+	if c.n < c.every {
+		return nil
+	}
+	return c.take(flush)
+}
+
+// finish takes the checkpoint of the last key processed once the scan has completed.
+func (c *checkpoints) finish(flush func() error) error {
+	if c.n == 0 {
+		return nil
+	}
+	return c.take(flush)
+}
+
+func (c *checkpoints) take(flush func() error) error {
+	c.n = 0
+	if c.fn == nil {
+		return nil
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	return c.fn(c.last)
+}
+
+// noFlush is the flush function of scans without output of their own.
+func noFlush() error { return nil }
+
+// exportKeys calls fn for each live key of tx matching opts.Pattern, in order, starting
+// after opts.After, and takes the checkpoints opts asks for, calling flush before each.
+func (db *DB) exportKeys(tx *bbolt.Tx, opts ExportOptions, flush func() error, fn func(name []byte, b *bbolt.Bucket) error) error {
+	cp := newCheckpoints(opts.Checkpoint, opts.CheckpointEvery)
+	c := tx.Cursor()
+	name, v := c.First()
+	if opts.After != "" {
+		name, v = c.Seek([]byte(opts.After))
+		if bytes.Equal(name, []byte(opts.After)) {
+			name, v = c.Next()
+		}
+	}
+	for ; name != nil; name, v = c.Next() {
+		if v != nil || isInternalBucket(tx, name) || !matchPattern(opts.Pattern, string(name)) || db.liveBucket(tx, string(name)) == nil {
+			continue
+		}
+		if err := fn(name, tx.Bucket(name)); err != nil {
+			return err
+		}
+		if err := cp.done(string(name), flush); err != nil {
+			return err
+		}
+	}
+	return cp.finish(flush)
+}
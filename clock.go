@@ -0,0 +1,76 @@
+package jungledb
+
+import "time"
+
+// Clock tells the time and waits for it, see WithClock.
+type Clock interface {
+	Now() time.Time
+	// After returns a channel receiving the time once d has elapsed, like time.After.
+	After(d time.Duration) <-chan time.Time
+}
+
+// systemClock is the Clock of the system.
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// WithClock makes the database follow c rather than the system clock for everything
+// scheduled in time, so that tests can move time forward instead of sleeping;
+// jungletest.Clock is such a clock. The deadlines of TTLs, leases and locks, the ages of
+// undo records, trash and tiered keys and the times of audit entries follow c, and so do
+// the expiry sweeper, the polling of Lock, maintenance windows and ScheduleBackups.
+// Durations measured for metrics, the slow log and load tests, and the pacing of
+// BackgroundLimits, stay on the system clock since they measure real work.
+func WithClock(c Clock) Option {
+	return func(o *options) {
+		o.clock = c
+	}
+}
+
+// clock returns the clock set with WithClock, or the system clock.
+func (db *DB) clock() Clock {
+	if db.opts.clock != nil {
+		return db.opts.clock
+	}
+	return systemClock{}
+}
+
+// At returns a handle to the same database for which the current time is t rather than
+// the time of the database clock: its operations expire the keys whose TTL elapsed by t,
+// count TTLs from t and keep the keys whose TTL elapses after t, whenever they run.
+// Replicated state machines such as package cluster apply writes at the time the leader
+// proposed them, so that every node expires the same keys. The handle shares everything
+// else, including Close, with db.
+func (db *DB) At(t time.Time) *DB {
+	return &DB{core: db.core, actor: db.actor, reason: db.reason, ns: db.ns, at: t}
+}
+
+// now returns the current time of db, see At, or else of the database clock.
+func (db *DB) now() time.Time {
+	if !db.at.IsZero() {
+		return db.at
+	}
+	return db.clock().Now()
+}
+
+// Now returns the current time of db: the time set with At, or else the time of the
+// database clock. Code built on db, such as package sessions, uses it to stay on the
+// clock of its TTLs.
+func (db *DB) Now() time.Time {
+	return db.now()
+}
+
+// sleepUntil waits until t on the database clock and reports whether stop stayed open.
+func (db *DB) sleepUntil(t time.Time, stop <-chan struct{}) bool {
+	d := t.Sub(db.now())
+	if d <= 0 {
+		return sleep(0, stop)
+	}
+	select {
+	case <-stop:
+		return false
+	case <-db.clock().After(d):
+		return true
+	}
+}
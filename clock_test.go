@@ -0,0 +1,117 @@
+package jungledb
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// manualClock is a clock that only moves when told to: timers set with After fire as
+// advance moves the clock past them.
+type manualClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []manualTimer
+}
+
+// manualTimer is a channel returned by After and the time it fires.
+type manualTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// newManualClock returns a manualClock set to now.
+func newManualClock(now time.Time) *manualClock {
+	return &manualClock{now: now}
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := manualTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.c <- c.now
+	} else {
+		c.timers = append(c.timers, timer)
+	}
+	return timer.c
+}
+
+// advance moves the clock forward by d, firing the timers due.
+func (c *manualClock) advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+		} else {
+			timer.c <- c.now
+		}
+	}
+	clear(c.timers[len(pending):])
+	c.timers = pending
+}
+
+// waiting reports the number of timers yet to fire, so that a test can tell when a
+// goroutine has started waiting on the clock.
+func (c *manualClock) waiting() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+// TestWithClock tests that lock deadlines follow the clock set with WithClock.
+func TestWithClock(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	db, err := Open("testdata/clock.db", WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if ok, err := db.TryLock("job", "a", time.Minute); err != nil || !ok {
+		t.Fatalf("TryLock failed: %v %v", ok, err)
+	}
+	if ok, err := db.TryLock("job", "b", time.Minute); err != nil || ok {
+		t.Errorf("expected the lock to be held, got %v %v", ok, err)
+	}
+	clock.advance(time.Minute)
+	if ok, err := db.TryLock("job", "b", time.Minute); err != nil || !ok {
+		t.Errorf("expected the lock to have expired, got %v %v", ok, err)
+	}
+}
+
+// TestAt tests that a handle returned by At expires keys as of its time.
+func TestAt(t *testing.T) {
+	db, err := Open("testdata/at.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("session", "user", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Expire("session", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+
+	past := db.At(time.Now().Add(-time.Hour))
+	if v, err := past.Hget("session", "user"); err != nil || string(v) != "alice" {
+		t.Errorf("Hget in the past: expected alice, got %q %v", v, err)
+	}
+	if v, err := db.At(time.Now().Add(2*time.Hour)).Hget("session", "user"); err != nil || v != nil {
+		t.Errorf("Hget after the TTL: expected nothing, got %q %v", v, err)
+	}
+	if ttl, err := past.TTL("session"); err != nil || ttl <= time.Hour {
+		t.Errorf("TTL in the past: expected over an hour, got %v %v", ttl, err)
+	}
+}
@@ -0,0 +1,554 @@
+// Package cluster runs a jungledb database as a Raft-replicated state machine.
+//
+// Every node owns a local *jungledb.DB. Writes made through a Node are proposed to the
+// Raft leader, committed to a quorum and then applied to each node's database in the same
+// order, so all nodes converge on identical contents. Reads are served from the local
+// database and may lag behind the leader on followers; call Barrier on the leader for
+// read-your-writes semantics.
+package cluster
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/ehebe/jungledb"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/raft"
+	raftboltdb "github.com/hashicorp/raft-boltdb/v2"
+)
+
+// ErrNotLeader is returned for writes attempted on a node that is not the leader.
+var ErrNotLeader = raft.ErrNotLeader
+
+// Config configures a cluster node.
+type Config struct {
+	NodeID    string // Unique, stable identifier of this node
+	BindAddr  string // TCP address used for Raft traffic, e.g. "10.0.0.1:7000"
+	DataDir   string // Directory for the Raft log, stable store and snapshots
+	Bootstrap bool   // Bootstrap a new cluster with this node as its only voter
+
+	// TLS, if set, secures the default transport: nodes accept and make only TLS
+	// connections with this configuration, which should require and verify client
+	// certificates (see jungledb.LoadTLSConfig). Its ClientCAs also verify the nodes
+	// dialed unless RootCAs is set, and its certificates are presented to them.
+	TLS *tls.Config
+
+	// ApplyTimeout bounds how long a write waits to be committed. Defaults to 10s.
+	ApplyTimeout time.Duration
+
+	// Raft optionally overrides Raft tuning; LocalID is always taken from NodeID. Unless
+	// it sets Logger or LogOutput, Raft, the default transport and the default snapshot
+	// store report to the logger of the database, see jungledb.WithLogger.
+	Raft *raft.Config
+
+	// Transport and the stores default to TCP on BindAddr and files under DataDir.
+	// They can be supplied to embed the node in custom networking or for testing.
+	Transport     raft.Transport
+	LogStore      raft.LogStore
+	StableStore   raft.StableStore
+	SnapshotStore raft.SnapshotStore
+}
+
+// Health summarizes a node's view of the cluster.
+type Health struct {
+	NodeID       string
+	State        string // "Leader", "Follower", "Candidate" or "Shutdown"
+	IsLeader     bool
+	LeaderID     string
+	LeaderAddr   string
+	LastIndex    uint64
+	AppliedIndex uint64
+}
+
+// Node is a member of a jungledb Raft cluster. It exposes the jungledb API,
+// routing writes through Raft and reads to the local database.
+type Node struct {
+	db           *jungledb.DB
+	raft         *raft.Raft
+	nodeID       string
+	applyTimeout time.Duration
+	closers      []io.Closer
+}
+
+// Open starts a cluster node replicating into db.
+func Open(db *jungledb.DB, cfg Config) (*Node, error) {
+	if cfg.NodeID == "" {
+		return nil, errors.New("cluster: NodeID is required")
+	}
+	if cfg.ApplyTimeout <= 0 {
+		cfg.ApplyTimeout = 10 * time.Second
+	}
+
+	conf := raft.DefaultConfig()
+	if cfg.Raft != nil {
+		c := *cfg.Raft
+		conf = &c
+	}
+	conf.LocalID = raft.ServerID(cfg.NodeID)
+	switch {
+	case conf.Logger != nil:
+	case conf.LogOutput != nil:
+		conf.Logger = hclog.New(&hclog.LoggerOptions{Name: "raft", Level: hclog.LevelFromString(conf.LogLevel), Output: conf.LogOutput})
+	default:
+		conf.Logger = newLogger(db.Logger())
+	}
+
+	n := &Node{db: db, nodeID: cfg.NodeID, applyTimeout: cfg.ApplyTimeout}
+
+	if err := n.initStores(&cfg, conf.Logger); err != nil {
+		n.closeStores()
+		return nil, err
+	}
+
+	r, err := raft.NewRaft(conf, &fsm{db: db}, cfg.LogStore, cfg.StableStore, cfg.SnapshotStore, cfg.Transport)
+	if err != nil {
+		n.closeStores()
+		return nil, fmt.Errorf("cluster: failed to start raft: %v", err)
+	}
+	n.raft = r
+
+	if cfg.Bootstrap {
+		configuration := raft.Configuration{Servers: []raft.Server{{
+			ID:      conf.LocalID,
+			Address: cfg.Transport.LocalAddr(),
+		}}}
+		if err := r.BootstrapCluster(configuration).Error(); err != nil && !errors.Is(err, raft.ErrCantBootstrap) {
+			n.Shutdown()
+			return nil, fmt.Errorf("cluster: failed to bootstrap: %v", err)
+		}
+	}
+
+	return n, nil
+}
+
+// initStores fills in default transport and stores, logging to logger, for anything not
+// supplied in cfg.
+func (n *Node) initStores(cfg *Config, logger hclog.Logger) error {
+	if cfg.LogStore == nil || cfg.StableStore == nil || cfg.SnapshotStore == nil {
+		if cfg.DataDir == "" {
+			return errors.New("cluster: DataDir is required unless all stores are supplied")
+		}
+		if err := os.MkdirAll(cfg.DataDir, 0755); err != nil {
+			return err
+		}
+	}
+
+	if cfg.LogStore == nil || cfg.StableStore == nil {
+		store, err := raftboltdb.NewBoltStore(filepath.Join(cfg.DataDir, "raft.db"))
+		if err != nil {
+			return fmt.Errorf("cluster: failed to open raft store: %v", err)
+		}
+		n.closers = append(n.closers, store)
+		if cfg.LogStore == nil {
+			cfg.LogStore = store
+		}
+		if cfg.StableStore == nil {
+			cfg.StableStore = store
+		}
+	}
+
+	if cfg.SnapshotStore == nil {
+		snaps, err := raft.NewFileSnapshotStoreWithLogger(cfg.DataDir, 2, logger.Named("snapshot"))
+		if err != nil {
+			return fmt.Errorf("cluster: failed to open snapshot store: %v", err)
+		}
+		cfg.SnapshotStore = snaps
+	}
+
+	if cfg.Transport == nil {
+		if cfg.BindAddr == "" {
+			return errors.New("cluster: BindAddr is required unless a Transport is supplied")
+		}
+		var trans *raft.NetworkTransport
+		var err error
+		if cfg.TLS != nil {
+			var stream *tlsStreamLayer
+			if stream, err = newTLSStreamLayer(cfg.BindAddr, cfg.TLS); err == nil {
+				trans = raft.NewNetworkTransportWithLogger(stream, 3, 10*time.Second, logger.Named("net"))
+			}
+		} else {
+			trans, err = raft.NewTCPTransportWithLogger(cfg.BindAddr, nil, 3, 10*time.Second, logger.Named("net"))
+		}
+		if err != nil {
+			return fmt.Errorf("cluster: failed to start transport: %v", err)
+		}
+		n.closers = append(n.closers, trans)
+		cfg.Transport = trans
+	}
+	return nil
+}
+
+// tlsStreamLayer is a raft.StreamLayer over TLS connections.
+type tlsStreamLayer struct {
+	net.Listener
+	client *tls.Config
+}
+
+func newTLSStreamLayer(bindAddr string, cfg *tls.Config) (*tlsStreamLayer, error) {
+	ln, err := tls.Listen("tcp", bindAddr, cfg)
+	if err != nil {
+		return nil, err
+	}
+	client := cfg.Clone()
+	if client.RootCAs == nil {
+		client.RootCAs = cfg.ClientCAs
+	}
+	return &tlsStreamLayer{Listener: ln, client: client}, nil
+}
+
+// Dial implements raft.StreamLayer.
+func (s *tlsStreamLayer) Dial(address raft.ServerAddress, timeout time.Duration) (net.Conn, error) {
+	return tls.DialWithDialer(&net.Dialer{Timeout: timeout}, "tcp", string(address), s.client)
+}
+
+func (n *Node) closeStores() {
+	for _, c := range n.closers {
+		c.Close()
+	}
+	n.closers = nil
+}
+
+// Shutdown stops the node. The underlying database is left open.
+func (n *Node) Shutdown() error {
+	var err error
+	if n.raft != nil {
+		err = n.raft.Shutdown().Error()
+	}
+	n.closeStores()
+	return err
+}
+
+// DB returns the local database. Writing to it directly bypasses replication.
+func (n *Node) DB() *jungledb.DB {
+	return n.db
+}
+
+// IsLeader reports whether this node is currently the leader.
+func (n *Node) IsLeader() bool {
+	return n.raft.State() == raft.Leader
+}
+
+// Leader returns the address and ID of the current leader, empty if unknown.
+func (n *Node) Leader() (addr string, id string) {
+	a, i := n.raft.LeaderWithID()
+	return string(a), string(i)
+}
+
+// Health reports this node's role and replication progress.
+func (n *Node) Health() Health {
+	addr, id := n.Leader()
+	return Health{
+		NodeID:       n.nodeID,
+		State:        n.raft.State().String(),
+		IsLeader:     n.IsLeader(),
+		LeaderID:     id,
+		LeaderAddr:   addr,
+		LastIndex:    n.raft.LastIndex(),
+		AppliedIndex: n.raft.AppliedIndex(),
+	}
+}
+
+// Stats returns Raft's internal statistics.
+func (n *Node) Stats() map[string]string {
+	return n.raft.Stats()
+}
+
+// AddVoter adds a node to the cluster. It must be called on the leader.
+func (n *Node) AddVoter(nodeID, addr string) error {
+	return n.raft.AddVoter(raft.ServerID(nodeID), raft.ServerAddress(addr), 0, n.applyTimeout).Error()
+}
+
+// RemoveServer removes a node from the cluster. It must be called on the leader.
+func (n *Node) RemoveServer(nodeID string) error {
+	return n.raft.RemoveServer(raft.ServerID(nodeID), 0, n.applyTimeout).Error()
+}
+
+// Barrier blocks until every write committed before the call has been applied locally.
+// On the leader this gives reads issued afterwards read-your-writes consistency.
+func (n *Node) Barrier() error {
+	return n.raft.Barrier(n.applyTimeout).Error()
+}
+
+// apply proposes a command and waits for it to be applied, returning the FSM result.
+// The command carries the time of the leader, which every node applies it at.
+func (n *Node) apply(cmd command) (int64, error) {
+	cmd.Time = time.Now().UnixNano()
+	data, err := json.Marshal(cmd)
+	if err != nil {
+		return 0, err
+	}
+
+	future := n.raft.Apply(data, n.applyTimeout)
+	if err := future.Error(); err != nil {
+		return 0, err
+	}
+	res, ok := future.Response().(applyResult)
+	if !ok {
+		return 0, fmt.Errorf("cluster: unexpected apply response %T", future.Response())
+	}
+	return res.value, res.err
+}
+
+// Hset sets the field value in a hash.
+func (n *Node) Hset(key, field string, value []byte) error {
+	_, err := n.apply(command{Op: opHset, Key: key, Field: field, Value: value})
+	return err
+}
+
+// Hmset sets multiple field values in a hash.
+func (n *Node) Hmset(key string, fields map[string][]byte) error {
+	_, err := n.apply(command{Op: opHmset, Key: key, Fields: fields})
+	return err
+}
+
+// Hincr increments the integer value of a field in a hash.
+func (n *Node) Hincr(key, field string, delta int64) (int64, error) {
+	return n.apply(command{Op: opHincr, Key: key, Field: field, Delta: delta})
+}
+
+// Hdel deletes a field from a hash.
+func (n *Node) Hdel(key, field string) error {
+	_, err := n.apply(command{Op: opHdel, Key: key, Field: field})
+	return err
+}
+
+// Hmdel deletes multiple fields from a hash.
+func (n *Node) Hmdel(key string, fields []string) error {
+	_, err := n.apply(command{Op: opHmdel, Key: key, Names: fields})
+	return err
+}
+
+// HdelBucket deletes an entire hash or sorted set.
+func (n *Node) HdelBucket(key string) error {
+	_, err := n.apply(command{Op: opHdelBucket, Key: key})
+	return err
+}
+
+// Zadd adds a member to a sorted set.
+func (n *Node) Zadd(key string, score float64, member string) error {
+	_, err := n.apply(command{Op: opZadd, Key: key, Score: commandScore(score), Field: member})
+	return err
+}
+
+// Zrem removes a member from a sorted set.
+func (n *Node) Zrem(key, member string) error {
+	_, err := n.apply(command{Op: opZrem, Key: key, Field: member})
+	return err
+}
+
+// Hget retrieves the value of a field in a hash from the local database.
+func (n *Node) Hget(key, field string) ([]byte, error) {
+	return n.db.Hget(key, field)
+}
+
+// Hmget retrieves the values of multiple fields in a hash from the local database.
+func (n *Node) Hmget(key string, fields []string) ([][]byte, error) {
+	return n.db.Hmget(key, fields)
+}
+
+// HgetInt retrieves the integer value of a field in a hash from the local database.
+func (n *Node) HgetInt(key, field string) (int64, error) {
+	return n.db.HgetInt(key, field)
+}
+
+// HhasKey checks if a field exists in a hash in the local database.
+func (n *Node) HhasKey(key, field string) (bool, error) {
+	return n.db.HhasKey(key, field)
+}
+
+// Hscan scans all fields and values in a hash in the local database.
+func (n *Node) Hscan(key string) (map[string][]byte, error) {
+	return n.db.Hscan(key)
+}
+
+// Hprefix scans fields with a prefix in a hash in the local database.
+func (n *Node) Hprefix(key, prefix string) (map[string][]byte, error) {
+	return n.db.Hprefix(key, prefix)
+}
+
+// Zrange returns members within a range of a sorted set in the local database.
+func (n *Node) Zrange(key string, start, stop int) ([]string, error) {
+	return n.db.Zrange(key, start, stop)
+}
+
+// Zrevrange returns members within a range of a sorted set, in descending order, from the local database.
+func (n *Node) Zrevrange(key string, start, stop int) ([]string, error) {
+	return n.db.Zrevrange(key, start, stop)
+}
+
+// Zscore returns the score of a member in a sorted set in the local database.
+func (n *Node) Zscore(key, member string) (float64, error) {
+	return n.db.Zscore(key, member)
+}
+
+// Zcard returns the number of members in a sorted set in the local database.
+func (n *Node) Zcard(key string) (int, error) {
+	return n.db.Zcard(key)
+}
+
+// Command operations replicated through the Raft log.
+const (
+	opHset       = "hset"
+	opHmset      = "hmset"
+	opHincr      = "hincr"
+	opHdel       = "hdel"
+	opHmdel      = "hmdel"
+	opHdelBucket = "hdelbucket"
+	opZadd       = "zadd"
+	opZrem       = "zrem"
+)
+
+// command is a write operation as stored in the Raft log.
+type command struct {
+	Op     string            `json:"op"`
+	Key    string            `json:"key"`
+	Field  string            `json:"field,omitempty"`
+	Value  []byte            `json:"value,omitempty"`
+	Fields map[string][]byte `json:"fields,omitempty"`
+	Names  []string          `json:"names,omitempty"`
+	Score  commandScore      `json:"score,omitempty"`
+	Delta  int64             `json:"delta,omitempty"`
+	Time   int64             `json:"time,omitempty"` // Of the leader in Unix nanoseconds, zero in older entries
+}
+
+// commandScore is a sorted set score, encoded as a string so that infinite scores, which
+// JSON numbers cannot hold, can be replicated. Numbers are accepted too, as written by
+// earlier versions.
+type commandScore float64
+
+func (s commandScore) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, strconv.FormatFloat(float64(s), 'g', -1, 64)), nil
+}
+
+func (s *commandScore) UnmarshalJSON(data []byte) error {
+	var str string
+	if json.Unmarshal(data, &str) != nil {
+		return json.Unmarshal(data, (*float64)(s))
+	}
+	f, err := strconv.ParseFloat(str, 64)
+	if err != nil || math.IsNaN(f) {
+		return fmt.Errorf("invalid score %q", str)
+	}
+	*s = commandScore(f)
+	return nil
+}
+
+// applyResult is what the FSM returns for each applied command.
+type applyResult struct {
+	value int64
+	err   error
+}
+
+// fsm applies committed commands to the local database.
+type fsm struct {
+	db *jungledb.DB
+}
+
+// Apply applies one committed log entry.
+func (f *fsm) Apply(l *raft.Log) any {
+	var cmd command
+	if err := json.Unmarshal(l.Data, &cmd); err != nil {
+		return applyResult{err: fmt.Errorf("cluster: invalid command: %v", err)}
+	}
+
+	db := f.db
+	if cmd.Time != 0 {
+		db = db.At(time.Unix(0, cmd.Time)) // Expire the keys the leader saw expired, not those of the local clock
+	}
+	var res applyResult
+	switch cmd.Op {
+	case opHset:
+		value := cmd.Value
+		if value == nil {
+			value = []byte{}
+		}
+		res.err = db.Hset(cmd.Key, cmd.Field, value)
+	case opHmset:
+		res.err = db.Hmset(cmd.Key, cmd.Fields)
+	case opHincr:
+		res.value, res.err = db.Hincr(cmd.Key, cmd.Field, cmd.Delta)
+	case opHdel:
+		res.err = db.Hdel(cmd.Key, cmd.Field)
+	case opHmdel:
+		res.err = db.Hmdel(cmd.Key, cmd.Names)
+	case opHdelBucket:
+		res.err = db.HdelBucket(cmd.Key)
+	case opZadd:
+		res.err = db.Zadd(cmd.Key, float64(cmd.Score), cmd.Field)
+	case opZrem:
+		res.err = db.Zrem(cmd.Key, cmd.Field)
+	default:
+		res.err = fmt.Errorf("cluster: unknown operation %q", cmd.Op)
+	}
+	return res
+}
+
+// Snapshot starts a read transaction of the database, which Persist streams to the sink
+// while commands keep being applied.
+func (f *fsm) Snapshot() (raft.FSMSnapshot, error) {
+	snap, err := f.db.Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return &snapshot{snap: snap}, nil
+}
+
+// Restore replaces the database contents with a snapshot, in a single transaction.
+func (f *fsm) Restore(rc io.ReadCloser) error {
+	defer rc.Close()
+	_, err := f.db.Load(rc)
+	return err
+}
+
+// snapshot is a point-in-time view of the database, persisted as a JSON export.
+type snapshot struct {
+	snap *jungledb.Snapshot
+}
+
+func (s *snapshot) Persist(sink raft.SnapshotSink) error {
+	if err := s.snap.Export(sink, jungledb.ExportOptions{}); err != nil {
+		sink.Cancel()
+		return err
+	}
+	return sink.Close()
+}
+
+func (s *snapshot) Release() {
+	s.snap.Release()
+}
+
+// newLogger returns a Raft logger reporting to log.
+func newLogger(log *slog.Logger) hclog.Logger {
+	logger := hclog.NewInterceptLogger(&hclog.LoggerOptions{Name: "raft", Level: hclog.Debug, Output: io.Discard})
+	logger.RegisterSink(slogSink{log})
+	return logger
+}
+
+// slogSink forwards the messages of a Raft logger to a slog.Logger.
+type slogSink struct {
+	log *slog.Logger
+}
+
+func (s slogSink) Accept(name string, level hclog.Level, msg string, args ...any) {
+	l := slog.LevelInfo
+	switch {
+	case level <= hclog.Debug:
+		l = slog.LevelDebug
+	case level == hclog.Warn:
+		l = slog.LevelWarn
+	case level >= hclog.Error:
+		l = slog.LevelError
+	}
+	s.log.Log(context.Background(), l, msg, append([]any{"component", name}, args...)...)
+}
@@ -0,0 +1,307 @@
+package cluster
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"net"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/ehebe/jungledb"
+	"github.com/hashicorp/raft"
+)
+
+// TestMain cleans up test files before and after running tests.
+func TestMain(m *testing.M) {
+	os.RemoveAll("testdata")
+	os.MkdirAll("testdata", 0755)
+
+	code := m.Run()
+
+	os.RemoveAll("testdata")
+	os.Exit(code)
+}
+
+// waitFor polls cond until it holds or the timeout expires.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// startCluster starts n nodes over an in-memory transport, bootstrapping the first one
+// and joining the rest through it.
+func startCluster(t *testing.T, n int) []*Node {
+	t.Helper()
+
+	conf := raft.DefaultConfig()
+	conf.HeartbeatTimeout = 50 * time.Millisecond
+	conf.ElectionTimeout = 50 * time.Millisecond
+	conf.LeaderLeaseTimeout = 50 * time.Millisecond
+	conf.CommitTimeout = 5 * time.Millisecond
+	conf.LogOutput = io.Discard
+
+	var nodes []*Node
+	var transports []*raft.InmemTransport
+	for i := 0; i < n; i++ {
+		db, err := jungledb.Open(fmt.Sprintf("testdata/node%d.db", i))
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+
+		_, trans := raft.NewInmemTransport(raft.ServerAddress(fmt.Sprintf("node%d", i)))
+		for _, other := range transports {
+			trans.Connect(other.LocalAddr(), other)
+			other.Connect(trans.LocalAddr(), trans)
+		}
+		transports = append(transports, trans)
+
+		store := raft.NewInmemStore()
+		node, err := Open(db, Config{
+			NodeID:        fmt.Sprintf("node%d", i),
+			Bootstrap:     i == 0,
+			Raft:          conf,
+			Transport:     trans,
+			LogStore:      store,
+			StableStore:   store,
+			SnapshotStore: raft.NewInmemSnapshotStore(),
+		})
+		if err != nil {
+			t.Fatalf("failed to start node %d: %v", i, err)
+		}
+		t.Cleanup(func() { node.Shutdown() })
+		nodes = append(nodes, node)
+
+		if i == 0 {
+			waitFor(t, "bootstrap leader", node.IsLeader)
+			continue
+		}
+		if err := nodes[0].AddVoter(node.nodeID, string(trans.LocalAddr())); err != nil {
+			t.Fatalf("AddVoter failed: %v", err)
+		}
+	}
+	return nodes
+}
+
+// TestClusterReplication tests that leader writes reach every follower and followers reject writes.
+func TestClusterReplication(t *testing.T) {
+	nodes := startCluster(t, 3)
+	leader := nodes[0]
+
+	if err := leader.Hset("user:1", "name", []byte("Alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := leader.Zadd("scores", 7, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	n, err := leader.Hincr("user:1", "visits", 3)
+	if err != nil {
+		t.Fatalf("Hincr failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("Hincr result mismatch: expected 3, got %d", n)
+	}
+
+	for i, node := range nodes[1:] {
+		waitFor(t, fmt.Sprintf("replication to node %d", i+1), func() bool {
+			v, _ := node.HgetInt("user:1", "visits")
+			return v == 3
+		})
+		name, err := node.Hget("user:1", "name")
+		if err != nil {
+			t.Fatalf("Hget failed: %v", err)
+		}
+		if string(name) != "Alice" {
+			t.Errorf("node %d name mismatch: got %q", i+1, name)
+		}
+		score, err := node.Zscore("scores", "alice")
+		if err != nil {
+			t.Fatalf("Zscore failed: %v", err)
+		}
+		if score != 7 {
+			t.Errorf("node %d score mismatch: expected 7, got %f", i+1, score)
+		}
+
+		if node.IsLeader() {
+			t.Errorf("node %d unexpectedly leader", i+1)
+		}
+		if err := node.Hset("user:1", "name", []byte("Mallory")); err != ErrNotLeader {
+			t.Errorf("follower write: expected ErrNotLeader, got %v", err)
+		}
+		if _, id := node.Leader(); id != "node0" {
+			t.Errorf("node %d sees leader %q, expected node0", i+1, id)
+		}
+	}
+
+	h := leader.Health()
+	if !h.IsLeader || h.State != "Leader" || h.LeaderID != "node0" {
+		t.Errorf("unexpected leader health: %+v", h)
+	}
+}
+
+// TestClusterSnapshotRestore tests that a snapshot, infinite scores included, replaces the
+// keys of the node restoring it.
+func TestClusterSnapshotRestore(t *testing.T) {
+	nodes := startCluster(t, 1)
+	leader := nodes[0]
+	if err := leader.Zadd("bounds", math.Inf(-1), "low"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := leader.Zadd("bounds", math.Inf(1), "high"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := leader.Hset("user:1", "name", []byte("Alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	src := &fsm{db: leader.DB()}
+	snap, err := src.Snapshot()
+	if err != nil {
+		t.Fatalf("Snapshot failed: %v", err)
+	}
+	written := make(chan error)
+	go func() { written <- leader.Hset("user:2", "name", []byte("Bob")) }() // May wait for Release
+	sink := &memSink{}
+	if err := snap.Persist(sink); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	snap.Release()
+	if err := <-written; err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	db, err := jungledb.Open("testdata/restore.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Hset("stale", "field", []byte("value")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := (&fsm{db: db}).Restore(io.NopCloser(&sink.buf)); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	keys, _, err := db.ListKeys("*", "", 0)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	for key, want := range map[string]bool{"bounds": true, "user:1": true, "user:2": false, "stale": false} {
+		if slices.Contains(keys, key) != want {
+			t.Errorf("key %s restored: expected %v, keys are %v", key, want, keys)
+		}
+	}
+	for member, want := range map[string]float64{"low": math.Inf(-1), "high": math.Inf(1)} {
+		score, err := db.Zscore("bounds", member)
+		if err != nil {
+			t.Fatalf("Zscore failed: %v", err)
+		}
+		if score != want {
+			t.Errorf("score of %s mismatch: expected %v, got %v", member, want, score)
+		}
+	}
+}
+
+// memSink is a raft.SnapshotSink writing to memory.
+type memSink struct {
+	buf bytes.Buffer
+}
+
+func (s *memSink) Write(p []byte) (int, error) { return s.buf.Write(p) }
+func (s *memSink) Close() error                { return nil }
+func (s *memSink) ID() string                  { return "mem" }
+func (s *memSink) Cancel() error               { return nil }
+
+// TestTLSStreamLayer tests that nodes using Config.TLS connect to each other and refuse
+// clients without a certificate.
+func TestTLSStreamLayer(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "node"},
+		IPAddresses:           []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	pool := x509.NewCertPool()
+	pool.AddCert(cert)
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+
+	a, err := newTLSStreamLayer("127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer a.Close()
+	b, err := newTLSStreamLayer("127.0.0.1:0", cfg)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer b.Close()
+
+	go func() {
+		for {
+			conn, err := a.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	conn, err := b.Dial(raft.ServerAddress(a.Addr().String()), time.Second)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	buf := make([]byte, 4)
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "ping" {
+		t.Errorf("echo mismatch: got %q %v", buf, err)
+	}
+
+	anonymous, err := tls.Dial("tcp", a.Addr().String(), &tls.Config{RootCAs: pool})
+	if err == nil {
+		_, err = anonymous.Read(buf)
+		anonymous.Close()
+	}
+	if err == nil {
+		t.Error("connection without a client certificate should fail")
+	}
+}
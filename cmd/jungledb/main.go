@@ -0,0 +1,535 @@
+// Command jungledb inspects and edits jungledb databases from the command line.
+//
+// Usage:
+//
+//	jungledb [-db path | -socket path] <command> [arguments]
+//
+// Data commands work on a database file (-db) or, while another process holds the file,
+// through that process's unix socket (-socket, see DB.ServeUnix):
+//
+//	get <key> <field>            print a field value
+//	set <key> <field> <value>    set a field value
+//	incr <key> <field> [delta]   increment an integer field
+//	del <key> [field...]         delete fields, or the whole key when none are given
+//	scan <key> [prefix]          print the fields of a hash, optionally by prefix
+//	zadd <key> <score> <member>  add a sorted set member
+//	zrange <key> [start stop]    print sorted set members with scores
+//	keys [pattern]               list keys (-db only)
+//	bench [flags]                generate load and report throughput and latency
+//
+// Admin commands require -db:
+//
+//	stats                          print key counts and storage statistics
+//	meta                           print the identity of the database and what created it
+//	sizes [-n 10]                  print the largest buckets by bytes and by entries, and the largest value
+//	compact                        rewrite the file without free pages
+//	fsck [-repair]                 check the file and the consistency of its keys
+//	backup <file>                  write a consistent copy of the database
+//	export [-format json|resp|csv|parquet] [-pattern p] [file]   export keys (stdout by default)
+//	import [-format json|resp|csv] [file]                 import keys (stdin by default)
+//	shell                          interactive shell with key completion and paging
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/ehebe/jungledb"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// run executes the command line and returns the process exit code.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("jungledb", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dbPath := fs.String("db", "", "path of the database file")
+	socket := fs.String("socket", "", "unix socket of a running jungledb daemon")
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: jungledb [-db path | -socket path] <command> [arguments]")
+		fmt.Fprintln(stderr, "commands: get set incr del scan zadd zrange keys bench stats meta sizes compact fsck backup export import shell")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 || (*dbPath == "") == (*socket == "") {
+		fs.Usage()
+		return 2
+	}
+
+	cmd, cmdArgs := fs.Arg(0), fs.Args()[1:]
+	c := &cli{stdin: stdin, stdout: stdout, dbPath: *dbPath}
+
+	var err error
+	if *socket != "" {
+		var client *jungledb.Client
+		if client, err = jungledb.DialUnix(*socket); err == nil {
+			c.store = client
+			err = c.exec(cmd, cmdArgs)
+			client.Close()
+		}
+	} else if cmd == "compact" {
+		err = c.compact(cmdArgs) // Needs the file closed to replace it
+	} else {
+		var db *jungledb.DB
+		if db, err = jungledb.Open(*dbPath); err == nil {
+			c.db, c.store = db, db
+			err = c.exec(cmd, cmdArgs)
+			if cerr := db.Close(); err == nil {
+				err = cerr
+			}
+		}
+	}
+
+	if errors.Is(err, errUsage) {
+		fs.Usage()
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "jungledb: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// errUsage reports a malformed command line.
+var errUsage = errors.New("usage")
+
+// cli holds the state shared by the commands.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	dbPath string
+	store  jungledb.Store
+	db     *jungledb.DB // nil when connected through a socket
+}
+
+// exec dispatches a command.
+func (c *cli) exec(cmd string, args []string) error {
+	switch cmd {
+	case "get":
+		return c.get(args)
+	case "set":
+		return c.set(args)
+	case "incr":
+		return c.incr(args)
+	case "del":
+		return c.del(args)
+	case "scan":
+		return c.scan(args)
+	case "zadd":
+		return c.zadd(args)
+	case "zrange":
+		return c.zrange(args)
+	case "bench":
+		return c.bench(args)
+	}
+
+	if c.db == nil {
+		return fmt.Errorf("command %q requires -db", cmd)
+	}
+	switch cmd {
+	case "keys":
+		return c.keys(args)
+	case "stats":
+		return c.stats(args)
+	case "meta":
+		return c.meta(args)
+	case "sizes":
+		return c.sizes(args)
+	case "fsck":
+		return c.fsck(args)
+	case "backup":
+		return c.backup(args)
+	case "export":
+		return c.export(args)
+	case "import":
+		return c.importKeys(args)
+	case "shell":
+		return c.shell(args)
+	default:
+		return fmt.Errorf("unknown command %q", cmd)
+	}
+}
+
+func (c *cli) get(args []string) error {
+	if len(args) != 2 {
+		return errUsage
+	}
+	value, err := c.store.Hget(args[0], args[1])
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return fmt.Errorf("%w: %s in %s", jungledb.ErrFieldNotFound, args[1], args[0])
+	}
+	fmt.Fprintf(c.stdout, "%s\n", value)
+	return nil
+}
+
+func (c *cli) set(args []string) error {
+	if len(args) != 3 {
+		return errUsage
+	}
+	return c.store.Hset(args[0], args[1], []byte(args[2]))
+}
+
+func (c *cli) incr(args []string) error {
+	if len(args) != 2 && len(args) != 3 {
+		return errUsage
+	}
+	delta := int64(1)
+	if len(args) == 3 {
+		var err error
+		if delta, err = strconv.ParseInt(args[2], 10, 64); err != nil {
+			return fmt.Errorf("invalid delta: %s", args[2])
+		}
+	}
+	n, err := c.store.Hincr(args[0], args[1], delta)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, n)
+	return nil
+}
+
+func (c *cli) del(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	if len(args) == 1 {
+		return c.store.HdelBucket(args[0])
+	}
+	return c.store.Hmdel(args[0], args[1:])
+}
+
+func (c *cli) scan(args []string) error {
+	if len(args) != 1 && len(args) != 2 {
+		return errUsage
+	}
+	var fields map[string][]byte
+	var err error
+	if len(args) == 2 {
+		fields, err = c.store.Hprefix(args[0], args[1])
+	} else {
+		fields, err = c.store.Hscan(args[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(c.stdout, "%s\t%s\n", name, fields[name])
+	}
+	return nil
+}
+
+func (c *cli) zadd(args []string) error {
+	if len(args) != 3 {
+		return errUsage
+	}
+	score, err := strconv.ParseFloat(args[1], 64)
+	if err != nil {
+		return fmt.Errorf("invalid score: %s", args[1])
+	}
+	return c.store.Zadd(args[0], score, args[2])
+}
+
+func (c *cli) zrange(args []string) error {
+	if len(args) != 1 && len(args) != 3 {
+		return errUsage
+	}
+	start, stop := 0, -1
+	if len(args) == 3 {
+		var err1, err2 error
+		start, err1 = strconv.Atoi(args[1])
+		stop, err2 = strconv.Atoi(args[2])
+		if err1 != nil || err2 != nil {
+			return fmt.Errorf("invalid range: %s %s", args[1], args[2])
+		}
+	}
+	members, err := c.store.Zrange(args[0], start, stop)
+	if err != nil {
+		return err
+	}
+	for _, member := range members {
+		score, err := c.store.Zscore(args[0], member)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(c.stdout, "%s\t%s\n", member, strconv.FormatFloat(score, 'g', -1, 64))
+	}
+	return nil
+}
+
+func (c *cli) keys(args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	pattern := ""
+	if len(args) == 1 {
+		pattern = args[0]
+	}
+	keys, _, err := c.db.ListKeys(pattern, "", 0)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		fmt.Fprintln(c.stdout, key)
+	}
+	return nil
+}
+
+// bench runs a load test, see jungledb.RunLoad.
+func (c *cli) bench(args []string) error {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	var cfg jungledb.LoadConfig
+	fs.DurationVar(&cfg.Duration, "duration", 10*time.Second, "how long to run")
+	fs.IntVar(&cfg.Ops, "ops", 0, "stop after this many operations instead")
+	fs.IntVar(&cfg.Concurrency, "c", 1, "concurrent clients")
+	fs.Float64Var(&cfg.ReadRatio, "reads", 0.9, "share of reads, the rest are writes")
+	fs.IntVar(&cfg.Keys, "keys", 10000, "number of distinct keys")
+	fs.StringVar(&cfg.Distribution, "dist", jungledb.DistUniform, "key distribution: uniform or zipf")
+	fs.IntVar(&cfg.ValueSize, "value", 100, "bytes per value written")
+	fs.StringVar(&cfg.KeyPrefix, "prefix", "bench:", "prefix of the keys used")
+	fs.BoolVar(&cfg.Populate, "populate", false, "write every key before the run")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+	report, err := jungledb.RunLoad(context.Background(), c.store, cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(c.stdout, report)
+	return nil
+}
+
+func (c *cli) stats(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	stats, err := c.db.Stats()
+	if err != nil {
+		return err
+	}
+	largest, err := c.db.LargestValue()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "keys\t%d\n", stats.Keys)
+	fmt.Fprintf(c.stdout, "hashes\t%d\n", stats.Hashes)
+	fmt.Fprintf(c.stdout, "sorted_sets\t%d\n", stats.SortedSets)
+	fmt.Fprintf(c.stdout, "fields\t%d\n", stats.Fields)
+	fmt.Fprintf(c.stdout, "members\t%d\n", stats.Members)
+	fmt.Fprintf(c.stdout, "largest_value\t%d\n", largest.Size)
+	fmt.Fprintf(c.stdout, "oplog_entries\t%d\n", stats.OpLogSize)
+	fmt.Fprintf(c.stdout, "file_size\t%d\n", stats.FileSize)
+	fmt.Fprintf(c.stdout, "allocated_size\t%d\n", stats.AllocatedSize)
+	fmt.Fprintf(c.stdout, "free_pages\t%d\n", stats.FreePages)
+	fmt.Fprintf(c.stdout, "pending_pages\t%d\n", stats.PendingPages)
+	fmt.Fprintf(c.stdout, "free_bytes\t%d\n", stats.FreeBytes)
+	fmt.Fprintf(c.stdout, "freelist_bytes\t%d\n", stats.FreelistBytes)
+	fmt.Fprintf(c.stdout, "page_size\t%d\n", stats.PageSize)
+	return nil
+}
+
+func (c *cli) meta(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	meta, err := c.db.Meta()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "id\t%s\n", meta.ID)
+	if !meta.Created.IsZero() {
+		fmt.Fprintf(c.stdout, "created\t%s\n", meta.Created.UTC().Format(time.RFC3339))
+	}
+	fmt.Fprintf(c.stdout, "format\t%d\n", meta.Format)
+	fmt.Fprintf(c.stdout, "creator\t%s\n", meta.Creator)
+	fmt.Fprintf(c.stdout, "version\t%s\n", meta.Version)
+	return nil
+}
+
+func (c *cli) sizes(args []string) error {
+	fs := flag.NewFlagSet("sizes", flag.ContinueOnError)
+	n := fs.Int("n", 10, "buckets to print in each ranking")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+	report, err := c.db.SizeReport(*n)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "buckets\t%d\n", report.Buckets)
+	fmt.Fprintf(c.stdout, "bytes\t%d\n", report.Bytes)
+	fmt.Fprintf(c.stdout, "largest_value\t%d\n", report.LargestValue.Size)
+	for _, ranking := range []struct {
+		name  string
+		sizes []jungledb.BucketSize
+	}{{"by_bytes", report.ByBytes}, {"by_entries", report.ByEntries}} {
+		fmt.Fprintln(c.stdout, ranking.name)
+		for _, size := range ranking.sizes {
+			kind := size.Type
+			if size.Internal {
+				kind = "internal"
+			}
+			fmt.Fprintf(c.stdout, "%s\t%s\t%d\t%d\n", size.Name, kind, size.Bytes, size.Entries)
+		}
+	}
+	return nil
+}
+
+// compact rewrites the database file through a temporary copy.
+func (c *cli) compact(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	db, err := jungledb.Open(c.dbPath)
+	if err != nil {
+		return err
+	}
+	tmp := c.dbPath + ".compact"
+	os.Remove(tmp) // Left over from an interrupted run
+	if err := db.Compact(tmp); err != nil {
+		db.Close()
+		return err
+	}
+	if err := db.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	before, _ := os.Stat(c.dbPath)
+	after, _ := os.Stat(tmp)
+	if err := os.Rename(tmp, c.dbPath); err != nil {
+		return fmt.Errorf("failed to replace database file: %v", err)
+	}
+	if before != nil && after != nil {
+		fmt.Fprintf(c.stdout, "compacted %d -> %d bytes\n", before.Size(), after.Size())
+	}
+	return nil
+}
+
+// fsck prints the inconsistencies of the database, repairing them with -repair, and fails
+// if any is left.
+func (c *cli) fsck(args []string) error {
+	fs := flag.NewFlagSet("fsck", flag.ContinueOnError)
+	repair := fs.Bool("repair", false, "repair the problems that can be fixed without losing data")
+	if err := fs.Parse(args); err != nil || fs.NArg() != 0 {
+		return errUsage
+	}
+	found, err := c.db.Check(*repair)
+	if err != nil {
+		return err
+	}
+	left := 0
+	for _, p := range found {
+		key, status := p.Key, "found"
+		if key == "" {
+			key = "-"
+		}
+		if p.Repaired {
+			status = "repaired"
+		} else {
+			left++
+		}
+		fmt.Fprintf(c.stdout, "%s\t%s\t%s\n", status, key, p.Problem)
+	}
+	if left > 0 {
+		return fmt.Errorf("%d problems left", left)
+	}
+	return nil
+}
+
+func (c *cli) backup(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	return c.db.BackupFile(args[0])
+}
+
+func (c *cli) export(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	format := fs.String("format", "json", "output format: json, resp, csv or parquet")
+	pattern := fs.String("pattern", "", "only export keys matching this glob")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 1 {
+		return errUsage
+	}
+
+	w := c.stdout
+	if fs.NArg() == 1 {
+		f, err := os.Create(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	opts := jungledb.ExportOptions{Pattern: *pattern}
+	switch *format {
+	case "json":
+		return c.db.Export(w, opts)
+	case "resp":
+		return c.db.ExportRESP(w, opts)
+	case "csv":
+		return c.db.ExportCSV(w, jungledb.CSVOptions{Pattern: *pattern, Header: true})
+	case "parquet":
+		return c.db.ExportParquet(w, *pattern)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+}
+
+func (c *cli) importKeys(args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	format := fs.String("format", "json", "input format: json, resp or csv")
+	if err := fs.Parse(args); err != nil || fs.NArg() > 1 {
+		return errUsage
+	}
+
+	r := c.stdin
+	if fs.NArg() == 1 {
+		f, err := os.Open(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	var n int
+	var err error
+	switch *format {
+	case "json":
+		n, err = c.db.Import(r)
+	case "resp":
+		n, err = c.db.ImportRESP(r)
+	case "csv":
+		n, err = c.db.ImportCSV(r, jungledb.CSVOptions{Header: true})
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "imported %d\n", n)
+	return nil
+}
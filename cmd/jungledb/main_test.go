@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestMain cleans up test files before and after running tests.
+func TestMain(m *testing.M) {
+	os.RemoveAll("testdata")
+	os.MkdirAll("testdata", 0755)
+
+	code := m.Run()
+
+	os.RemoveAll("testdata")
+	os.Exit(code)
+}
+
+// runCLI runs the command line and returns its exit code and output.
+func runCLI(t *testing.T, stdin string, args ...string) (int, string, string) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	code := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// TestCommands tests the data and admin commands against a database file.
+func TestCommands(t *testing.T) {
+	db := "testdata/cli.db"
+
+	tests := []struct {
+		args []string
+		code int
+		out  string
+	}{
+		{[]string{"-db", db, "set", "user:1", "name", "Alice"}, 0, ""},
+		{[]string{"-db", db, "set", "user:1", "city", "Paris"}, 0, ""},
+		{[]string{"-db", db, "get", "user:1", "name"}, 0, "Alice\n"},
+		{[]string{"-db", db, "get", "user:1", "missing"}, 1, ""},
+		{[]string{"-db", db, "scan", "user:1"}, 0, "city\tParis\nname\tAlice\n"},
+		{[]string{"-db", db, "incr", "stats", "hits", "3"}, 0, "3\n"},
+		{[]string{"-db", db, "zadd", "ranking", "2", "b"}, 0, ""},
+		{[]string{"-db", db, "zadd", "ranking", "1.5", "a"}, 0, ""},
+		{[]string{"-db", db, "zrange", "ranking"}, 0, "a\t1.5\nb\t2\n"},
+		{[]string{"-db", db, "del", "user:1", "city"}, 0, ""},
+		{[]string{"-db", db, "del", "stats"}, 0, ""},
+		{[]string{"-db", db, "keys"}, 0, "ranking\nuser:1\n"},
+		{[]string{"-db", db, "export", "-pattern", "user:*"}, 0, `{"key":"user:1","type":"hash","fields":{"name":"QWxpY2U="}}` + "\n"},
+		{[]string{"-db", db, "export", "-format", "csv", "-pattern", "user:*"}, 0, "type,key,field,value,score\nhash,user:1,name,Alice,\n"},
+		{[]string{"-db", db, "backup", "testdata/cli_backup.db"}, 0, ""},
+		{[]string{"-db", db, "fsck"}, 0, ""},
+		{[]string{"-db", db, "fsck", "-repair"}, 0, ""},
+		{[]string{"-db", "testdata/cli_backup.db", "get", "user:1", "name"}, 0, "Alice\n"},
+		{[]string{"-db", db, "bogus"}, 1, ""},
+		{[]string{"get", "user:1", "name"}, 2, ""},
+		{[]string{"-db", db, "get", "user:1"}, 2, ""},
+	}
+
+	for _, tc := range tests {
+		code, out, stderr := runCLI(t, "", tc.args...)
+		if code != tc.code {
+			t.Errorf("%v: expected exit code %d, got %d (%s)", tc.args, tc.code, code, stderr)
+			continue
+		}
+		if tc.code == 0 && out != tc.out {
+			t.Errorf("%v: expected output %q, got %q", tc.args, tc.out, out)
+		}
+	}
+
+	// Import into a fresh database, then check stats and compaction
+	export := `{"key":"h","type":"hash","fields":{"f":"dg=="}}` + "\n"
+	if code, out, stderr := runCLI(t, export, "-db", "testdata/cli_import.db", "import"); code != 0 || out != "imported 1\n" {
+		t.Fatalf("import failed: %d %q %s", code, out, stderr)
+	}
+	code, out, _ := runCLI(t, "", "-db", "testdata/cli_import.db", "stats")
+	if code != 0 || !strings.Contains(out, "keys\t1\n") || !strings.Contains(out, "fields\t1\n") {
+		t.Errorf("unexpected stats output: %q", out)
+	}
+	if code, out, stderr := runCLI(t, "", "-db", "testdata/cli_import.db", "compact"); code != 0 || !strings.HasPrefix(out, "compacted ") {
+		t.Errorf("compact failed: %d %q %s", code, out, stderr)
+	}
+	if code, out, _ := runCLI(t, "", "-db", "testdata/cli_import.db", "get", "h", "f"); code != 0 || out != "v\n" {
+		t.Errorf("data lost by compact: %d %q", code, out)
+	}
+	if code, out, _ := runCLI(t, "", "-db", "testdata/cli_import.db", "meta"); code != 0 || !strings.Contains(out, "format\t2\n") || strings.Contains(out, "id\t\n") {
+		t.Errorf("unexpected meta output: %d %q", code, out)
+	}
+	if code, out, _ := runCLI(t, "", "-db", "testdata/cli_import.db", "sizes"); code != 0 || !strings.HasPrefix(out, "buckets\t2\n") || !strings.Contains(out, "\nh\thash\t") {
+		t.Errorf("unexpected sizes output: %d %q", code, out)
+	}
+
+	// Load test a fresh database
+	code, out, stderr := runCLI(t, "", "-db", "testdata/cli_bench.db", "bench", "-ops", "200", "-c", "4", "-keys", "50", "-populate", "-dist", "zipf")
+	if code != 0 || !strings.HasPrefix(out, "ops\t200\n") || !strings.Contains(out, "errors\t0\n") {
+		t.Errorf("bench failed: %d %q %s", code, out, stderr)
+	}
+	if code, _, _ := runCLI(t, "", "-db", "testdata/cli_bench.db", "bench", "-dist", "bogus"); code != 1 {
+		t.Errorf("expected bench to reject an unknown distribution, got exit code %d", code)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/peterh/liner"
+)
+
+const (
+	// shellPageSize is how many output lines the shell prints before pausing.
+	shellPageSize = 40
+
+	// shellCompletions bounds how many keys are offered for tab completion.
+	shellCompletions = 100
+)
+
+// shellCommands are the commands offered for completion, and the ones whose first
+// argument is a key.
+var (
+	shellCommands = []string{"get", "set", "incr", "del", "scan", "zadd", "zrange",
+		"keys", "stats", "backup", "export", "more", "help", "exit"}
+	shellKeyCommands = map[string]bool{"get": true, "set": true, "incr": true, "del": true,
+		"scan": true, "zadd": true, "zrange": true}
+)
+
+const shellHelp = `commands:
+  keys [pattern]               list keys
+  scan <key> [prefix]          show hash fields
+  zrange <key> [start stop]    show sorted set members with scores
+  get <key> <field>            show a field value
+  set <key> <field> <value>    set a field value
+  incr <key> <field> [delta]   increment an integer field
+  del <key> [field...]         delete fields or a whole key
+  zadd <key> <score> <member>  add a sorted set member
+  stats                        show database statistics
+  more                         show the next page of output
+  exit                         leave the shell
+Arguments containing spaces can be double-quoted. Tab completes commands and keys.
+`
+
+// prompter reads lines interactively; *liner.State implements it.
+type prompter interface {
+	Prompt(prompt string) (string, error)
+	AppendHistory(item string)
+}
+
+// shell runs an interactive session against the open database.
+func (c *cli) shell(args []string) error {
+	if len(args) != 0 {
+		return errUsage
+	}
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetWordCompleter(c.complete)
+	return c.runShell(line)
+}
+
+// runShell reads and executes commands until exit or end of input. Long output is paged.
+func (c *cli) runShell(p prompter) error {
+	out := c.stdout
+	defer func() { c.stdout = out }()
+
+	fmt.Fprintf(out, "jungledb shell on %s, type help for commands\n", c.dbPath)
+	var pending []string
+	for {
+		input, err := p.Prompt("jungledb> ")
+		if errors.Is(err, io.EOF) {
+			fmt.Fprintln(out)
+			return nil
+		} else if errors.Is(err, liner.ErrPromptAborted) {
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		words, err := splitWords(input)
+		if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+			continue
+		}
+		if len(words) == 0 {
+			continue
+		}
+		p.AppendHistory(input)
+
+		switch words[0] {
+		case "exit", "quit":
+			return nil
+		case "help":
+			fmt.Fprint(out, shellHelp)
+			continue
+		case "more":
+			pending = printPage(out, pending)
+			continue
+		case "shell", "compact", "import":
+			fmt.Fprintf(out, "error: %s is not available in the shell\n", words[0])
+			continue
+		}
+
+		var buf bytes.Buffer
+		c.stdout = &buf
+		err = c.exec(words[0], words[1:])
+		c.stdout = out
+		if errors.Is(err, errUsage) {
+			err = fmt.Errorf("wrong arguments for %s, type help for usage", words[0])
+		}
+		if err != nil {
+			fmt.Fprintf(out, "error: %v\n", err)
+			pending = nil
+			continue
+		}
+
+		pending = strings.SplitAfter(buf.String(), "\n")
+		if pending[len(pending)-1] == "" {
+			pending = pending[:len(pending)-1]
+		}
+		pending = printPage(out, pending)
+	}
+}
+
+// printPage prints up to shellPageSize lines and returns the lines left for "more".
+func printPage(w io.Writer, lines []string) []string {
+	n := min(len(lines), shellPageSize)
+	for _, line := range lines[:n] {
+		fmt.Fprint(w, line)
+	}
+	rest := lines[n:]
+	if len(rest) > 0 {
+		fmt.Fprintf(w, "-- %d more lines, type more to continue --\n", len(rest))
+	}
+	return rest
+}
+
+// complete offers command names for the first word and key names for the key argument.
+func (c *cli) complete(line string, pos int) (string, []string, string) {
+	head, tail := line[:pos], line[pos:]
+	start := strings.LastIndexByte(head, ' ') + 1
+	word := head[start:]
+	head = head[:start]
+	prior := strings.Fields(head)
+
+	var completions []string
+	switch {
+	case len(prior) == 0:
+		for _, cmd := range shellCommands {
+			if strings.HasPrefix(cmd, word) {
+				completions = append(completions, cmd+" ")
+			}
+		}
+	case len(prior) == 1 && shellKeyCommands[prior[0]]:
+		keys, _, err := c.db.ListKeys(escapeGlob(word)+"*", "", shellCompletions)
+		if err != nil {
+			return head, nil, tail
+		}
+		for _, key := range keys {
+			if strings.ContainsAny(key, " \"") {
+				key = quoteWord(key)
+			}
+			completions = append(completions, key+" ")
+		}
+	}
+	return head, completions, tail
+}
+
+// escapeGlob escapes glob metacharacters so s matches literally.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '*', '?', '[', ']', '\\':
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// quoteWord double-quotes s for the shell, escaping quotes and backslashes.
+func quoteWord(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// splitWords splits a command line on spaces, honoring double quotes and backslash escapes.
+func splitWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord, quoted := false, false
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch == '\\' && i+1 < len(line):
+			i++
+			word.WriteByte(line[i])
+			inWord = true
+		case ch == '"':
+			quoted = !quoted
+			inWord = true
+		case (ch == ' ' || ch == '\t') && !quoted:
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteByte(ch)
+			inWord = true
+		}
+	}
+	if quoted {
+		return nil, errors.New("unterminated quote")
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
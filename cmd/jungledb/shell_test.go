@@ -0,0 +1,102 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/ehebe/jungledb"
+)
+
+// scriptedPrompter feeds prepared lines to the shell.
+type scriptedPrompter struct {
+	lines []string
+}
+
+func (p *scriptedPrompter) Prompt(string) (string, error) {
+	if len(p.lines) == 0 {
+		return "", io.EOF
+	}
+	line := p.lines[0]
+	p.lines = p.lines[1:]
+	return line, nil
+}
+
+func (p *scriptedPrompter) AppendHistory(string) {}
+
+// TestShell tests command execution, paging and completion in the shell.
+func TestShell(t *testing.T) {
+	db, err := jungledb.Open("testdata/shell.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	fields := make(map[string][]byte)
+	for i := 0; i < shellPageSize+5; i++ {
+		fields[fmt.Sprintf("f%03d", i)] = []byte("v")
+	}
+	if err := db.Hmset("big hash", fields); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+
+	var out bytes.Buffer
+	c := &cli{stdout: &out, dbPath: "testdata/shell.db", db: db, store: db}
+	p := &scriptedPrompter{lines: []string{
+		`set user:1 name "Alice Smith"`,
+		`get user:1 name`,
+		`scan "big hash"`,
+		`more`,
+		`get user:1`,
+		`bogus`,
+		`exit`,
+		`get user:1 name`, // Never reached
+	}}
+	if err := c.runShell(p); err != nil {
+		t.Fatalf("shell failed: %v", err)
+	}
+
+	got := out.String()
+	for _, want := range []string{
+		"Alice Smith\n",
+		"f000\tv\n",
+		"-- 5 more lines, type more to continue --\n",
+		fmt.Sprintf("f%03d\tv\n", shellPageSize+4),
+		"error: wrong arguments for get",
+		`error: unknown command "bogus"`,
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("shell output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Count(got, "Alice Smith") != 1 {
+		t.Errorf("commands after exit were executed:\n%s", got)
+	}
+
+	// Completion of commands and of quoted keys
+	head, completions, _ := c.complete("zr", 2)
+	if head != "" || len(completions) != 1 || completions[0] != "zrange " {
+		t.Errorf("command completion mismatch: %q %v", head, completions)
+	}
+	head, completions, _ = c.complete("scan b", 6)
+	if head != "scan " || len(completions) != 1 || completions[0] != `"big hash" ` {
+		t.Errorf("key completion mismatch: %q %v", head, completions)
+	}
+}
+
+// TestSplitWords tests the shell's command line splitting.
+func TestSplitWords(t *testing.T) {
+	words, err := splitWords(`set "my key" field a\ b ""`)
+	if err != nil {
+		t.Fatalf("splitWords failed: %v", err)
+	}
+	want := []string{"set", "my key", "field", "a b", ""}
+	if fmt.Sprint(words) != fmt.Sprint(want) || len(words) != len(want) {
+		t.Errorf("splitWords mismatch: expected %q, got %q", want, words)
+	}
+	if _, err := splitWords(`get "open`); err == nil {
+		t.Error("unterminated quote should fail")
+	}
+}
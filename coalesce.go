@@ -0,0 +1,92 @@
+package jungledb
+
+import (
+	"sync"
+	"time"
+)
+
+// WithReadCoalescing makes concurrent reads of the same hash field share a single read
+// transaction, so that a stampede of identical reads, such as every request of a cold
+// start reading the same configuration, costs one read. A read only joins one already
+// in flight if no write has committed since that one started, so reads still see every
+// write that committed before they were made. Each reader runs the hooks and is counted
+// in the metrics as usual, and gets its own copy of the value.
+func WithReadCoalescing() Option {
+	return func(o *options) {
+		o.coalesceReads = true
+	}
+}
+
+// readFlight is a field read shared by concurrent readers.
+type readFlight struct {
+	gen   uint64 // Write generation when the read started
+	done  chan struct{}
+	value []byte
+	found bool
+	err   error
+}
+
+// readFlights tracks the field reads in flight, by key and field.
+type readFlights struct {
+	mu      sync.Mutex
+	flights map[[2]string]*readFlight
+}
+
+// coalescedHget is hget for WithReadCoalescing: it joins a read of the same field in
+// flight, or starts one that others can join.
+func (db *DB) coalescedHget(op, key, field string) ([]byte, bool, error) {
+	id := [2]string{key, field}
+	gen := db.writeGen.Load()
+	g := &db.flights
+	g.mu.Lock()
+	f := g.flights[id]
+	if f == nil || f.gen != gen {
+		f = &readFlight{gen: gen, done: make(chan struct{})}
+		if g.flights == nil {
+			g.flights = make(map[[2]string]*readFlight)
+		}
+		g.flights[id] = f
+		g.mu.Unlock()
+
+		f.value, f.found, f.err = db.readField(op, key, field, true)
+		g.mu.Lock()
+		if g.flights[id] == f {
+			delete(g.flights, id)
+		}
+		g.mu.Unlock()
+		close(f.done)
+		return cloneValue(f.value, f.found), f.found, f.err // f.value is shared with the readers that joined
+	}
+	g.mu.Unlock()
+
+	return db.joinRead(op, key, f)
+}
+
+// joinRead waits for the read f in flight on behalf of op, as if op had read the field
+// itself.
+func (db *DB) joinRead(op, key string, f *readFlight) (value []byte, found bool, err error) {
+	o := Op{Name: op, Key: key, Actor: db.actor}
+	defer db.observe(o, time.Now(), &err)
+	if db.closing.Load() {
+		return nil, false, ErrClosed
+	}
+	if err := db.runBeforeHooks(o); err != nil {
+		return nil, false, err
+	}
+	<-f.done
+	if f.err != nil {
+		return nil, false, f.err
+	}
+	db.metrics.addCoalesced()
+	value = cloneValue(f.value, f.found)
+	db.metrics.addRead(len(value))
+	return value, f.found, nil
+}
+
+// cloneValue copies a field value, keeping found values non-nil.
+func cloneValue(v []byte, found bool) []byte {
+	if !found {
+		return nil
+	}
+	return append([]byte{}, v...)
+}
@@ -0,0 +1,74 @@
+package jungledb
+
+import (
+	"sync"
+	"testing"
+)
+
+// TestReadCoalescing tests that concurrent reads of a field share reads in flight, get
+// their own copies of the value, and still see writes committed before them.
+func TestReadCoalescing(t *testing.T) {
+	// Every reader runs the hooks once it has either started a read or joined one
+	var started sync.WaitGroup
+	hook := func(op Op) error {
+		if op.Name == "Hget" {
+			started.Done()
+		}
+		return nil
+	}
+	db, err := Open("testdata/coalesce.db", WithReadCoalescing(), WithBeforeHook(hook))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("config", "flags", []byte("on")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	// Hold up the first read before its transaction so the others pile up behind it
+	var wg sync.WaitGroup
+	db.mu.Lock()
+	const readers = 50
+	started.Add(readers)
+	values := make([][]byte, readers)
+	for i := range readers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := db.Hget("config", "flags")
+			if err != nil {
+				t.Errorf("Hget failed: %v", err)
+			}
+			values[i] = v
+		}()
+	}
+	started.Wait()
+	db.mu.Unlock()
+	wg.Wait()
+
+	for i, v := range values {
+		if string(v) != "on" {
+			t.Fatalf("reader %d got %q, expected on", i, v)
+		}
+	}
+	values[0][0] = 'X' // Copies are not shared
+	if string(values[1]) != "on" {
+		t.Errorf("readers share the value they got")
+	}
+	m := db.Metrics()
+	if m.Ops["Hget"].Latency.Count != readers || m.Coalesced != readers-1 || m.ReadTx.Count != 1 {
+		t.Errorf("expected %d reads in 1 transaction, %d of them coalesced, got %d in %d and %d", readers, readers-1, m.Ops["Hget"].Latency.Count, m.ReadTx.Count, m.Coalesced)
+	}
+	started.Add(1) // For the Hget below
+
+	if err := db.Hset("config", "flags", []byte("off")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if v, err := db.Hget("config", "flags"); err != nil || string(v) != "off" {
+		t.Errorf("Hget after a write = %q, %v; expected off", v, err)
+	}
+	if v, found, err := db.HgetOK("config", "missing"); err != nil || found || v != nil {
+		t.Errorf("HgetOK of a missing field = %q, %v, %v", v, found, err)
+	}
+}
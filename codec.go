@@ -0,0 +1,85 @@
+package jungledb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// The encodings below are those of the database file, for systems reading or writing
+// its data outside of jungledb. Every value round-trips: Decode(Encode(v)) returns v,
+// bit for bit for scores, and Encode(Decode(b)) returns b for every b Decode accepts.
+// Decode functions return ErrMalformed for input no Encode function produces.
+
+// EncodeInt encodes an integer field value, as written by Hincr: 8 bytes, big-endian,
+// two's complement.
+func EncodeInt(v int64) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(v))
+}
+
+// DecodeInt decodes an integer field value, see EncodeInt.
+func DecodeInt(b []byte) (int64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("%w: integer of %d bytes", ErrMalformed, len(b))
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+// EncodeScore encodes a sorted set score, as kept in the member index of a sorted set:
+// the 8 bytes of its IEEE 754 representation, big-endian. Byte order matches numeric
+// order for non-negative scores only.
+func EncodeScore(score float64) []byte {
+	return binary.BigEndian.AppendUint64(nil, math.Float64bits(score))
+}
+
+// DecodeScore decodes a sorted set score, see EncodeScore.
+func DecodeScore(b []byte) (float64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("%w: score of %d bytes", ErrMalformed, len(b))
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+}
+
+// EncodeZsetEntry encodes the key of a member in the score-ordered bucket of a sorted
+// set: its encoded score followed by the member. The value of the entry is empty.
+func EncodeZsetEntry(score float64, member string) []byte {
+	return append(EncodeScore(score), member...)
+}
+
+// DecodeZsetEntry decodes the key of a sorted set entry, see EncodeZsetEntry.
+func DecodeZsetEntry(b []byte) (score float64, member string, err error) {
+	if len(b) < 8 {
+		return 0, "", fmt.Errorf("%w: sorted set entry of %d bytes", ErrMalformed, len(b))
+	}
+	score, _ = DecodeScore(b[:8])
+	return score, string(b[8:]), nil
+}
+
+// EncodeExpiry encodes the deadline of a key in the TTL bucket, in Unix nanoseconds:
+// 8 bytes, big-endian.
+func EncodeExpiry(at int64) []byte {
+	return encodeSeq(uint64(at))
+}
+
+// DecodeExpiry decodes the deadline of a key, see EncodeExpiry.
+func DecodeExpiry(b []byte) (int64, error) {
+	if len(b) != 8 {
+		return 0, fmt.Errorf("%w: deadline of %d bytes", ErrMalformed, len(b))
+	}
+	return int64(binary.BigEndian.Uint64(b)), nil
+}
+
+// EncodeExpiryKey encodes an entry of the expiry index, which orders keys by deadline:
+// the encoded deadline followed by the key.
+func EncodeExpiryKey(at int64, key string) []byte {
+	return append(EncodeExpiry(at), key...)
+}
+
+// DecodeExpiryKey decodes an entry of the expiry index, see EncodeExpiryKey.
+func DecodeExpiryKey(b []byte) (at int64, key string, err error) {
+	if len(b) < 8 {
+		return 0, "", fmt.Errorf("%w: expiry entry of %d bytes", ErrMalformed, len(b))
+	}
+	at, _ = DecodeExpiry(b[:8])
+	return at, string(b[8:]), nil
+}
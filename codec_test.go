@@ -0,0 +1,112 @@
+package jungledb
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+
+	"go.etcd.io/bbolt"
+)
+
+// TestCodec tests that the public encodings match the database file and round-trip.
+func TestCodec(t *testing.T) {
+	db, err := Open("testdata/codec.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Hincr("counters", "hits", -42); err != nil {
+		t.Fatalf("Hincr failed: %v", err)
+	}
+	if err := db.Zadd("ranking", 1.5, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Expire("ranking", 1<<40); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+
+	v, err := db.Hget("counters", "hits")
+	if n, err2 := DecodeInt(v); err != nil || err2 != nil || n != -42 || !bytes.Equal(EncodeInt(n), v) {
+		t.Errorf("expected the counter to decode, got %d %v %v", n, err, err2)
+	}
+	db.db.View(func(tx *bbolt.Tx) error {
+		k, _ := tx.Bucket([]byte("ranking")).Cursor().First()
+		if score, member, err := DecodeZsetEntry(k); err != nil || score != 1.5 || member != "alice" {
+			t.Errorf("expected the sorted set entry to decode, got %v %q %v", score, member, err)
+		}
+		score := tx.Bucket([]byte("ranking" + membersSuffix)).Get([]byte("alice"))
+		if s, err := DecodeScore(score); err != nil || s != 1.5 {
+			t.Errorf("expected the member index score to decode, got %v %v", s, err)
+		}
+		at, err := DecodeExpiry(tx.Bucket([]byte(ttlBucket)).Get([]byte("ranking")))
+		if err != nil || at == 0 {
+			t.Errorf("expected the deadline to decode, got %d %v", at, err)
+		}
+		k, _ = tx.Bucket([]byte(expiryBucket)).Cursor().First()
+		if at2, key, err := DecodeExpiryKey(k); err != nil || at2 != at || key != "ranking" {
+			t.Errorf("expected the expiry entry to decode, got %d %q %v", at2, key, err)
+		}
+		return nil
+	})
+
+	for _, score := range []float64{0, math.Copysign(0, -1), -1.5, math.MaxFloat64, math.Inf(-1), math.NaN()} {
+		got, err := DecodeScore(EncodeScore(score))
+		if err != nil || math.Float64bits(got) != math.Float64bits(score) {
+			t.Errorf("score %v did not round-trip: %v %v", score, got, err)
+		}
+	}
+	for _, b := range [][]byte{nil, {1, 2, 3}, make([]byte, 9)} {
+		if _, err := DecodeInt(b); !errors.Is(err, ErrMalformed) {
+			t.Errorf("expected ErrMalformed for %v, got %v", b, err)
+		}
+	}
+	if _, _, err := DecodeZsetEntry([]byte("short")); !errors.Is(err, ErrMalformed) {
+		t.Errorf("expected ErrMalformed for a short entry, got %v", err)
+	}
+}
+
+// FuzzZsetEntry tests that sorted set entries round-trip both ways.
+func FuzzZsetEntry(f *testing.F) {
+	f.Add(1.5, "alice")
+	f.Add(math.Inf(-1), "")
+	f.Fuzz(func(t *testing.T, score float64, member string) {
+		b := EncodeZsetEntry(score, member)
+		s, m, err := DecodeZsetEntry(b)
+		if err != nil || math.Float64bits(s) != math.Float64bits(score) || m != member {
+			t.Fatalf("(%v, %q) decoded as (%v, %q) %v", score, member, s, m, err)
+		}
+		if !bytes.Equal(EncodeZsetEntry(s, m), b) {
+			t.Fatalf("(%v, %q) re-encoded differently", score, member)
+		}
+	})
+}
+
+// FuzzDecode tests that the decoders reject or round-trip arbitrary input.
+func FuzzDecode(f *testing.F) {
+	f.Add([]byte{})
+	f.Add(EncodeInt(-1))
+	f.Add(EncodeExpiryKey(1<<62, "session"))
+	f.Fuzz(func(t *testing.T, b []byte) {
+		if n, err := DecodeInt(b); err == nil && !bytes.Equal(EncodeInt(n), b) {
+			t.Fatalf("integer %x re-encoded differently", b)
+		}
+		if s, err := DecodeScore(b); err == nil && !bytes.Equal(EncodeScore(s), b) {
+			t.Fatalf("score %x re-encoded differently", b)
+		}
+		if at, err := DecodeExpiry(b); err == nil && !bytes.Equal(EncodeExpiry(at), b) {
+			t.Fatalf("deadline %x re-encoded differently", b)
+		}
+		if score, member, err := DecodeZsetEntry(b); err == nil && !bytes.Equal(EncodeZsetEntry(score, member), b) {
+			t.Fatalf("sorted set entry %x re-encoded differently", b)
+		}
+		if at, key, err := DecodeExpiryKey(b); err == nil && !bytes.Equal(EncodeExpiryKey(at, key), b) {
+			t.Fatalf("expiry entry %x re-encoded differently", b)
+		}
+		if len(b) < 8 {
+			if _, _, err := DecodeExpiryKey(b); !errors.Is(err, ErrMalformed) {
+				t.Fatalf("expected ErrMalformed for %x, got %v", b, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package jungledb
+
+import "math"
+
+// Overflow selects what an increment does when the result falls outside the range of
+// its counter.
+type Overflow int
+
+const (
+	OverflowError    Overflow = iota // Fail with ErrOverflow, leaving the counter unchanged
+	OverflowSaturate                 // Stop at the minimum or maximum value of the counter
+	OverflowWrap                     // Wrap around, as Go's integer arithmetic does
+)
+
+// HincrOverflow is like Hincr but handles overflow as overflow says, so that a counter
+// can, for example, saturate at math.MaxInt64 rather than fail.
+func (db *DB) HincrOverflow(key, field string, delta int64, overflow Overflow) (int64, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	key = db.nsKey(key)
+	newValue, err := db.hincr("HincrOverflow", key, field, delta, overflow, false)
+	return int64(newValue), err
+}
+
+// HincrUint increments the unsigned integer value of a field in a hash, from 0 to
+// math.MaxUint64, handling overflow as overflow says: decrementing below 0 overflows
+// too. Values are stored as 8-byte binary integers, like those of Hincr; read them with
+// HgetUint.
+func (db *DB) HincrUint(key, field string, delta int64, overflow Overflow) (uint64, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	key = db.nsKey(key)
+	return db.hincr("HincrUint", key, field, delta, overflow, true)
+}
+
+// HgetUint retrieves the unsigned integer value of a field in a hash, as written by
+// HincrUint. A missing field reads as 0.
+func (db *DB) HgetUint(key, field string) (uint64, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	key = db.nsKey(key)
+	value, _, err := db.hgetInt("HgetUint", key, field)
+	return uint64(value), err
+}
+
+// addCounter adds delta to the counter value, the bits of an int64 or, if unsigned, a
+// uint64, handling overflow as overflow says.
+func addCounter(value uint64, delta int64, overflow Overflow, unsigned bool) (uint64, error) {
+	var sum, limit uint64
+	var overflowed bool
+	switch {
+	case unsigned && delta >= 0:
+		sum, limit = value+uint64(delta), math.MaxUint64
+		overflowed = sum < value
+	case unsigned:
+		d := uint64(-delta) // Also right for math.MinInt64
+		sum, limit = value-d, 0
+		overflowed = d > value
+	default:
+		current := int64(value)
+		next := current + delta
+		sum, overflowed = uint64(next), (delta > 0 && next < current) || (delta < 0 && next > current)
+		bound := int64(math.MinInt64)
+		if delta > 0 {
+			bound = math.MaxInt64
+		}
+		limit = uint64(bound)
+	}
+	if !overflowed {
+		return sum, nil
+	}
+	switch overflow {
+	case OverflowSaturate:
+		return limit, nil
+	case OverflowWrap:
+		return sum, nil
+	default:
+		return 0, ErrOverflow
+	}
+}
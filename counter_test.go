@@ -0,0 +1,76 @@
+package jungledb
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestHincrOverflow tests that counters fail, saturate or wrap on overflow as asked.
+func TestHincrOverflow(t *testing.T) {
+	db, err := Open("testdata/counter.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	tests := []struct {
+		name     string
+		start    int64
+		delta    int64
+		overflow Overflow
+		want     int64
+		err      error
+	}{
+		{"error", math.MaxInt64 - 1, 2, OverflowError, math.MaxInt64 - 1, ErrOverflow},
+		{"saturate up", math.MaxInt64 - 1, 2, OverflowSaturate, math.MaxInt64, nil},
+		{"saturate down", math.MinInt64 + 1, -2, OverflowSaturate, math.MinInt64, nil},
+		{"wrap", math.MaxInt64, 1, OverflowWrap, math.MinInt64, nil},
+		{"no overflow", 5, -7, OverflowSaturate, -2, nil},
+	}
+	for _, tt := range tests {
+		if _, err := db.Hincr("signed", tt.name, tt.start); err != nil {
+			t.Fatalf("%s: Hincr failed: %v", tt.name, err)
+		}
+		got, err := db.HincrOverflow("signed", tt.name, tt.delta, tt.overflow)
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: expected error %v, got %v", tt.name, tt.err, err)
+		}
+		if stored, _ := db.HgetInt("signed", tt.name); stored != tt.want || (err == nil && got != tt.want) {
+			t.Errorf("%s: expected %d, got %d (stored %d)", tt.name, tt.want, got, stored)
+		}
+	}
+}
+
+// TestHincrUint tests unsigned counters, which span 0 to math.MaxUint64.
+func TestHincrUint(t *testing.T) {
+	db, err := Open("testdata/counter.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.HincrUint("bytes", "in", -1, OverflowError); !errors.Is(err, ErrOverflow) {
+		t.Errorf("expected decrementing 0 to overflow, got %v", err)
+	}
+	if got, err := db.HincrUint("bytes", "in", -1, OverflowSaturate); err != nil || got != 0 {
+		t.Errorf("expected 0 saturating below 0, got %d, %v", got, err)
+	}
+	for range 3 {
+		if _, err := db.HincrUint("bytes", "in", math.MaxInt64, OverflowError); err != nil && !errors.Is(err, ErrOverflow) {
+			t.Fatalf("HincrUint failed: %v", err)
+		}
+	}
+	if got, _ := db.HgetUint("bytes", "in"); got != 2*math.MaxInt64 {
+		t.Errorf("expected the counter to pass math.MaxInt64 and stop short of overflowing, got %d", got)
+	}
+	if got, err := db.HincrUint("bytes", "in", math.MaxInt64, OverflowSaturate); err != nil || got != math.MaxUint64 {
+		t.Errorf("expected math.MaxUint64 saturating, got %d, %v", got, err)
+	}
+	if got, err := db.HincrUint("bytes", "in", 2, OverflowWrap); err != nil || got != 1 {
+		t.Errorf("expected 1 wrapping, got %d, %v", got, err)
+	}
+	if got, err := db.HincrUint("bytes", "in", math.MinInt64, OverflowSaturate); err != nil || got != 0 {
+		t.Errorf("expected 0 subtracting math.MinInt64 from 1, got %d, %v", got, err)
+	}
+}
@@ -0,0 +1,67 @@
+package jungledb
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// createSeq tells apart the temporary files of databases created concurrently.
+var createSeq atomic.Uint64
+
+// createFile creates a new database at path unless a file is there already. The database
+// is initialized and stamped with its format and identity in a temporary file next to
+// path, synced and only then moved into place, so a crash while creating it leaves at
+// worst a stray temporary file and never a half-initialized database at path.
+func createFile(path string, opts []Option) error {
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		return nil // Exists, or Open reports the error
+	}
+
+	tmp := fmt.Sprintf("%s.%d-%d.creating", path, os.Getpid(), createSeq.Add(1))
+	os.Remove(tmp)       // Left over from a crash of an earlier process with this pid
+	defer os.Remove(tmp) // Unlinked from path once in place, or a failed attempt
+
+	// bbolt writes and syncs the initial pages of an empty file when opening it, and the
+	// format stamp is synced on commit
+	db, err := bbolt.Open(tmp, 0666, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		return fmt.Errorf("failed to initialize database: %v", err)
+	}
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if err := db.Update(func(tx *bbolt.Tx) error { return stampNew(tx, &o) }); err != nil {
+		db.Close()
+		return fmt.Errorf("failed to initialize database: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		return fmt.Errorf("failed to initialize database: %v", err)
+	}
+
+	// Link rather than rename, so a database created meanwhile by another process is kept
+	if err := os.Link(tmp, path); errors.Is(err, os.ErrExist) {
+		return nil
+	} else if err != nil {
+		if err := os.Rename(tmp, path); err != nil { // File systems without hard links
+			return fmt.Errorf("failed to create database: %v", err)
+		}
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// syncDir flushes the entries of dir to disk, so a file just moved into it survives a
+// crash. Not every platform can sync directories, so this is best effort.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,55 @@
+package jungledb
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"go.etcd.io/bbolt"
+)
+
+// TestCreateFile tests that Open creates new databases stamped with the format and
+// leaves no temporary file behind, and that existing files are left alone.
+func TestCreateFile(t *testing.T) {
+	dir := "testdata/create"
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	path := filepath.Join(dir, "new.db")
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	var format []byte
+	db.db.View(func(tx *bbolt.Tx) error {
+		if bucket := tx.Bucket([]byte(metaBucket)); bucket != nil {
+			format = append(format, bucket.Get(formatKey)...)
+		}
+		return nil
+	})
+	if err := db.Hset("h", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	db.Close()
+	if string(format) != strconv.Itoa(formatVersion) {
+		t.Errorf("expected a new database stamped with format %d, got %q", formatVersion, format)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to list directory: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "new.db" {
+		t.Errorf("expected only new.db in %s, got %v", dir, entries)
+	}
+
+	// Reopening keeps the data
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	if v, err := db.Hget("h", "f"); err != nil || string(v) != "v" {
+		t.Errorf("Hget after reopening = %q, %v; expected v", v, err)
+	}
+}
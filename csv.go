@@ -0,0 +1,253 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"go.etcd.io/bbolt"
+)
+
+// Data held by the columns of a CSV export, see CSVColumn.
+const (
+	CSVType  = "type"  // "hash" or "zset"
+	CSVKey   = "key"   // Key of the hash or sorted set
+	CSVField = "field" // Field of a hash, or member of a sorted set
+	CSVValue = "value" // Value of a hash field, empty for sorted sets
+	CSVScore = "score" // Score of a sorted set member, empty for hashes
+)
+
+// base64Prefix marks CSV values written in base64.
+const base64Prefix = "base64:"
+
+// CSVColumn is a column of a CSV export: its name in the header row and the data it holds.
+type CSVColumn struct {
+	Name string
+	Data string // CSVType, CSVKey, CSVField, CSVValue or CSVScore
+}
+
+// CSVOptions controls the layout of ExportCSV and ImportCSV.
+type CSVOptions struct {
+	// Pattern restricts an export to keys matching a glob (see ListKeys). Empty exports everything.
+	Pattern string
+
+	// Columns maps the columns of the file to data, in order. A column may be left out:
+	// without a type column, rows with a score are sorted set members. The default is a
+	// column named after each kind of data: type, key, field, value and score.
+	Columns []CSVColumn
+
+	// Header makes exports start with a row of column names, and imports read that row
+	// to find the columns by name, in whatever order the file has them.
+	Header bool
+
+	// Base64 writes every value in base64. Otherwise only values that are not valid
+	// UTF-8, or that start with "base64:", are; they are prefixed with "base64:".
+	Base64 bool
+}
+
+// columns returns the columns of opts, or the default ones.
+func (opts CSVOptions) columns() ([]CSVColumn, error) {
+	if opts.Columns == nil {
+		return []CSVColumn{{CSVType, CSVType}, {CSVKey, CSVKey}, {CSVField, CSVField}, {CSVValue, CSVValue}, {CSVScore, CSVScore}}, nil
+	}
+	seen := make(map[string]bool)
+	for _, col := range opts.Columns {
+		switch col.Data {
+		case CSVType, CSVKey, CSVField, CSVValue, CSVScore:
+		default:
+			return nil, fmt.Errorf("unknown CSV column data %q", col.Data)
+		}
+		seen[col.Data] = true
+	}
+	if !seen[CSVKey] || !seen[CSVField] {
+		return nil, fmt.Errorf("CSV columns need a key and a field")
+	}
+	return opts.Columns, nil
+}
+
+// ExportCSV writes the database as CSV, for spreadsheets and analysts: a row per hash
+// field, with its value, and per sorted set member, with its score, laid out as
+// opts.Columns says. Expired keys are skipped and TTLs are not exported. The export is a
+// consistent snapshot.
+func (db *DB) ExportCSV(w io.Writer, opts CSVOptions) error {
+	columns, err := opts.columns()
+	if err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	if opts.Header {
+		header := make([]string, len(columns))
+		for i, col := range columns {
+			header[i] = col.Name
+		}
+		cw.Write(header)
+	}
+
+	row := make([]string, len(columns))
+	put := func(data map[string]string) error {
+		for i, col := range columns {
+			row[i] = data[col.Data]
+		}
+		return cw.Write(row)
+	}
+	err = db.view("ExportCSV", opts.Pattern, func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+			if isInternalBucket(tx, name) || !matchPattern(opts.Pattern, string(name)) || db.liveBucket(tx, string(name)) == nil {
+				return nil
+			}
+			typ := keyType(tx, name)
+			return b.ForEach(func(k, v []byte) error {
+				if typ == typeZset {
+					score, member, _ := DecodeZsetEntry(k)
+					return put(map[string]string{CSVType: typ, CSVKey: string(name), CSVField: member, CSVScore: formatScore(score)})
+				}
+				return put(map[string]string{CSVType: typ, CSVKey: string(name), CSVField: string(k), CSVValue: encodeCSVValue(v, opts.Base64)})
+			})
+		})
+	})
+	if err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// encodeCSVValue formats a hash value for a CSV export.
+func encodeCSVValue(v []byte, force bool) string {
+	if !force && utf8.Valid(v) && !bytes.HasPrefix(v, []byte(base64Prefix)) {
+		return string(v)
+	}
+	return base64Prefix + base64.StdEncoding.EncodeToString(v)
+}
+
+// ImportCSV loads a CSV file laid out as opts says, such as one written by ExportCSV,
+// and returns the number of rows imported. Hash fields are set and sorted set members
+// added with Zadd semantics. Rows are written in batched transactions.
+func (db *DB) ImportCSV(r io.Reader, opts CSVOptions) (int, error) {
+	columns, err := opts.columns()
+	if err != nil {
+		return 0, err
+	}
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1 // Checked against the columns below
+
+	// index maps each kind of data to the position of its column, -1 if absent
+	index := map[string]int{CSVType: -1, CSVKey: -1, CSVField: -1, CSVValue: -1, CSVScore: -1}
+	if opts.Header {
+		header, err := cr.Read()
+		if err != nil {
+			return 0, fmt.Errorf("failed to read CSV header: %v", err)
+		}
+		for _, col := range columns {
+			for i, name := range header {
+				if name == col.Name {
+					index[col.Data] = i
+				}
+			}
+		}
+		if index[CSVKey] < 0 || index[CSVField] < 0 {
+			return 0, fmt.Errorf("CSV header lacks a key or field column: %q", header)
+		}
+	} else {
+		for i, col := range columns {
+			index[col.Data] = i
+		}
+	}
+
+	imported, line := 0, 0
+	batch := make([][]string, 0, importBatchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := db.update("ImportCSV", "", func(tx *txn) error {
+			for _, row := range batch {
+				if err := importCSVRow(tx, row, index); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		imported += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return imported, fmt.Errorf("failed to read CSV row: %v", err)
+		}
+		line++
+		for _, i := range index {
+			if i >= len(row) {
+				return imported, fmt.Errorf("CSV row %d has %d columns, want %d", line, len(row), i+1)
+			}
+		}
+		batch = append(batch, row)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return imported, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return imported, err
+	}
+	return imported, nil
+}
+
+// importCSVRow writes one CSV row, whose columns are at index, inside a read-write transaction.
+func importCSVRow(tx *txn, row []string, index map[string]int) error {
+	get := func(data string) string {
+		if i := index[data]; i >= 0 {
+			return row[i]
+		}
+		return ""
+	}
+	key, field := get(CSVKey), get(CSVField)
+	typ := get(CSVType)
+	if typ == "" {
+		typ = typeHash
+		if get(CSVScore) != "" {
+			typ = typeZset
+		}
+	}
+
+	switch typ {
+	case typeHash:
+		value := []byte(get(CSVValue))
+		if bytes.HasPrefix(value, []byte(base64Prefix)) {
+			var err error
+			if value, err = base64.StdEncoding.DecodeString(string(value[len(base64Prefix):])); err != nil {
+				return fmt.Errorf("invalid base64 value for %s %s: %v", key, field, err)
+			}
+		}
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		tx.record(Event{Type: EventHset, Key: key, Field: field, Value: value})
+		return bucket.Put([]byte(field), value)
+	case typeZset:
+		score, err := parseScore(get(CSVScore))
+		if err != nil {
+			return fmt.Errorf("invalid score for %s %s: %v", key, field, err)
+		}
+		return zadd(tx, key, score, field)
+	default:
+		return fmt.Errorf("unknown type %q for key %s", typ, key)
+	}
+}
@@ -0,0 +1,97 @@
+package jungledb
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestCSV tests that hashes and sorted sets round-trip through CSV, binary values included.
+func TestCSV(t *testing.T) {
+	db, err := Open("testdata/csv.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	fields := map[string][]byte{
+		"name":   []byte("Alice, \"the\" admin\nof Paris"),
+		"avatar": {0xff, 0x00, 0xfe},
+		"note":   []byte("base64:literal"),
+	}
+	if err := db.Hmset("user:1", fields); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	if err := db.Zadd("ranking", 2.5, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Zadd("ranking", math.Inf(-1), "bob"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := db.ExportCSV(&buf, CSVOptions{Header: true}); err != nil {
+		t.Fatalf("ExportCSV failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "type,key,field,value,score\n") || !strings.Contains(buf.String(), "zset,ranking,bob,,-inf\n") {
+		t.Errorf("unexpected export:\n%s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "hash,user:1,avatar,base64:/wD+,\n") {
+		t.Errorf("expected binary values in base64:\n%s", buf.String())
+	}
+
+	other, err := Open("testdata/csv_import.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer other.Close()
+	if n, err := other.ImportCSV(&buf, CSVOptions{Header: true}); err != nil || n != 5 {
+		t.Fatalf("ImportCSV failed: %d %v", n, err)
+	}
+	if got, err := other.Hscan("user:1"); err != nil || !equalByteMap(got, fields) {
+		t.Errorf("expected the hash to round-trip, got %q %v", got, err)
+	}
+	if score, err := other.Zscore("ranking", "bob"); err != nil || !math.IsInf(score, -1) {
+		t.Errorf("expected the sorted set to round-trip, got %v %v", score, err)
+	}
+}
+
+// TestCSVColumns tests exporting and importing with mapped columns.
+func TestCSVColumns(t *testing.T) {
+	db, err := Open("testdata/csv_columns.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Hset("user:1", "name", []byte("Alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	columns := []CSVColumn{{"user_id", CSVKey}, {"attribute", CSVField}, {"data", CSVValue}}
+	var buf bytes.Buffer
+	if err := db.ExportCSV(&buf, CSVOptions{Columns: columns, Base64: true}); err != nil {
+		t.Fatalf("ExportCSV failed: %v", err)
+	}
+	if buf.String() != "user:1,name,base64:QWxpY2U=\n" {
+		t.Errorf("unexpected export: %q", buf.String())
+	}
+	if err := db.ExportCSV(&buf, CSVOptions{Columns: []CSVColumn{{"k", CSVKey}}}); err == nil {
+		t.Error("expected columns without a field to be rejected")
+	}
+
+	// Columns are found by name in a header, without a type: rows with a score are members
+	input := "score,attribute,user_id,data\n,city,user:1,Paris\n3,alice,ranking,\n"
+	columns = append(columns, CSVColumn{"score", CSVScore})
+	if n, err := db.ImportCSV(strings.NewReader(input), CSVOptions{Columns: columns, Header: true}); err != nil || n != 2 {
+		t.Fatalf("ImportCSV failed: %d %v", n, err)
+	}
+	if v, err := db.Hget("user:1", "city"); err != nil || string(v) != "Paris" {
+		t.Errorf("expected the hash field, got %q %v", v, err)
+	}
+	if score, err := db.Zscore("ranking", "alice"); err != nil || score != 3 {
+		t.Errorf("expected the sorted set member, got %v %v", score, err)
+	}
+	if _, err := db.ImportCSV(strings.NewReader("user:1\n"), CSVOptions{Columns: columns}); err == nil {
+		t.Error("expected a short row to be rejected")
+	}
+}
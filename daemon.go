@@ -0,0 +1,308 @@
+package jungledb
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Store is the hash and sorted set API shared by *DB and *Client, so code can run
+// either in the process that owns the database file or in one connected to it.
+type Store interface {
+	Hset(key, field string, value []byte) error
+	Hget(key, field string) ([]byte, error)
+	Hmset(key string, fields map[string][]byte) error
+	Hmget(key string, fields []string) ([][]byte, error)
+	Hincr(key, field string, delta int64) (int64, error)
+	HgetInt(key, field string) (int64, error)
+	HhasKey(key, field string) (bool, error)
+	Hdel(key, field string) error
+	Hmdel(key string, fields []string) error
+	Hscan(key string) (map[string][]byte, error)
+	Hprefix(key, prefix string) (map[string][]byte, error)
+	Hrscan(key string) (map[string][]byte, error)
+	HdelBucket(key string) error
+	Zadd(key string, score float64, member string) error
+	Zrange(key string, start, stop int) ([]string, error)
+	Zrevrange(key string, start, stop int) ([]string, error)
+	Zscore(key, member string) (float64, error)
+	Zrem(key, member string) error
+	Zcard(key string) (int, error)
+	Close() error
+}
+
+var (
+	_ Store = (*DB)(nil)
+	_ Store = (*Client)(nil)
+)
+
+// ServeUnix lets other processes on the host share the database through a unix socket at
+// path, which bbolt's single-process file lock would otherwise prevent. The socket speaks
+// the Redis protocol (see ServeRESP); use DialUnix for a Go client. A stale socket file
+// left by a previous run is removed. ServeUnix blocks until the listener fails.
+func (db *DB) ServeUnix(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale socket: %v", err)
+	}
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		return err
+	}
+	return db.ServeRESP(ln)
+}
+
+// Client accesses a database served by another process. It implements Store.
+// A Client is safe for concurrent use; requests are serialized over one connection.
+type Client struct {
+	mu   sync.Mutex
+	conn *redisClient
+}
+
+// DialUnix connects to a database served with ServeUnix at path.
+func DialUnix(path string) (*Client, error) {
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %v", path, err)
+	}
+	return &Client{conn: &redisClient{conn: conn, r: newRESPReader(conn), w: newRESPWriter(conn)}}, nil
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	return c.conn.close()
+}
+
+// Auth authenticates the connection as the user holding token, for servers with an ACL
+// (see WithACL).
+func (c *Client) Auth(token string) error {
+	_, err := c.do("AUTH", token)
+	return err
+}
+
+// do sends one command and returns its reply.
+func (c *Client) do(args ...string) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.do(args...)
+}
+
+// doBytes sends a command with binary arguments and returns its reply.
+func (c *Client) doBytes(args ...[]byte) (any, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.doBytes(args...)
+}
+
+// replyInt converts an integer reply.
+func replyInt(reply any, err error) (int64, error) {
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("unexpected reply %T", reply)
+	}
+	return n, nil
+}
+
+// replyBulks converts an array reply of bulk strings.
+func replyBulks(reply any, err error) ([][]byte, error) {
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected reply %T", reply)
+	}
+	values := make([][]byte, len(items))
+	for i, item := range items {
+		if values[i], ok = item.([]byte); !ok {
+			return nil, fmt.Errorf("unexpected array item %T", item)
+		}
+	}
+	return values, nil
+}
+
+// replyMap converts a flat field/value array reply into a map.
+func replyMap(reply any, err error) (map[string][]byte, error) {
+	items, err := replyBulks(reply, err)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string][]byte, len(items)/2)
+	for i := 0; i+1 < len(items); i += 2 {
+		result[string(items[i])] = items[i+1]
+	}
+	return result, nil
+}
+
+// replyStrings converts an array reply of bulk strings into strings.
+func replyStrings(reply any, err error) ([]string, error) {
+	items, err := replyBulks(reply, err)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil
+	}
+	result := make([]string, len(items))
+	for i, item := range items {
+		result[i] = string(item)
+	}
+	return result, nil
+}
+
+// Hset sets the field value in a hash.
+func (c *Client) Hset(key, field string, value []byte) error {
+	_, err := c.doBytes([]byte("HSET"), []byte(key), []byte(field), value)
+	return err
+}
+
+// Hget retrieves the value of a field in a hash, nil if it does not exist.
+func (c *Client) Hget(key, field string) ([]byte, error) {
+	reply, err := c.do("HGET", key, field)
+	if err != nil {
+		return nil, err
+	}
+	value, _ := reply.([]byte)
+	return value, nil
+}
+
+// Hmset sets multiple field values in a hash.
+func (c *Client) Hmset(key string, fields map[string][]byte) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	args := [][]byte{[]byte("HMSET"), []byte(key)}
+	for field, value := range fields {
+		args = append(args, []byte(field), value)
+	}
+	_, err := c.doBytes(args...)
+	return err
+}
+
+// Hmget retrieves the values of multiple fields in a hash.
+func (c *Client) Hmget(key string, fields []string) ([][]byte, error) {
+	if len(fields) == 0 {
+		return [][]byte{}, nil
+	}
+	return replyBulks(c.do(append([]string{"HMGET", key}, fields...)...))
+}
+
+// Hincr increments the integer value of a field in a hash.
+func (c *Client) Hincr(key, field string, delta int64) (int64, error) {
+	return replyInt(c.do("HINCRBY", key, field, strconv.FormatInt(delta, 10)))
+}
+
+// HgetInt retrieves the integer value of a field in a hash.
+func (c *Client) HgetInt(key, field string) (int64, error) {
+	return replyInt(c.do("HGETINT", key, field))
+}
+
+// HhasKey checks if a field exists in a hash.
+func (c *Client) HhasKey(key, field string) (bool, error) {
+	n, err := replyInt(c.do("HEXISTS", key, field))
+	return n == 1, err
+}
+
+// Hdel deletes a field from a hash.
+func (c *Client) Hdel(key, field string) error {
+	_, err := c.do("HDEL", key, field)
+	return err
+}
+
+// Hmdel deletes multiple fields from a hash.
+func (c *Client) Hmdel(key string, fields []string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	_, err := c.do(append([]string{"HDEL", key}, fields...)...)
+	return err
+}
+
+// Hscan scans all fields and values in a hash.
+func (c *Client) Hscan(key string) (map[string][]byte, error) {
+	return replyMap(c.do("HGETALL", key))
+}
+
+// Hprefix scans fields with a prefix in a hash.
+func (c *Client) Hprefix(key, prefix string) (map[string][]byte, error) {
+	return replyMap(c.do("HPREFIX", key, prefix))
+}
+
+// Hrscan scans all fields and values in a hash. The result is a map, so it is the same as Hscan.
+func (c *Client) Hrscan(key string) (map[string][]byte, error) {
+	return c.Hscan(key)
+}
+
+// HdelBucket deletes an entire hash or sorted set.
+func (c *Client) HdelBucket(key string) error {
+	n, err := replyInt(c.do("DEL", key))
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrKeyNotFound // Same as DB.HdelBucket
+	}
+	return nil
+}
+
+// Zadd adds a member to a sorted set.
+func (c *Client) Zadd(key string, score float64, member string) error {
+	_, err := c.do("ZADD", key, formatScore(score), member)
+	return err
+}
+
+// Zrange returns members within a specified range in a sorted set (ascending order).
+func (c *Client) Zrange(key string, start, stop int) ([]string, error) {
+	return replyStrings(c.do("ZRANGE", key, strconv.Itoa(start), strconv.Itoa(stop)))
+}
+
+// Zrevrange returns members within a specified range in a sorted set (descending order).
+func (c *Client) Zrevrange(key string, start, stop int) ([]string, error) {
+	return replyStrings(c.do("ZREVRANGE", key, strconv.Itoa(start), strconv.Itoa(stop)))
+}
+
+// Zscore returns the score of a member in a sorted set, 0 if it does not exist.
+func (c *Client) Zscore(key, member string) (float64, error) {
+	reply, err := c.do("ZSCORE", key, member)
+	if err != nil {
+		return 0, err
+	}
+	b, _ := reply.([]byte)
+	if b == nil {
+		return 0, nil
+	}
+	return parseScore(string(b))
+}
+
+// Zrem removes a member from a sorted set.
+func (c *Client) Zrem(key, member string) error {
+	_, err := c.do("ZREM", key, member)
+	return err
+}
+
+// Zcard returns the number of members in a sorted set.
+func (c *Client) Zcard(key string) (int, error) {
+	n, err := replyInt(c.do("ZCARD", key))
+	return int(n), err
+}
+
+// TryLockToken acquires the lock name for owner for ttl, rounded down to milliseconds,
+// and returns its fencing token if it did (see DB.TryLockToken).
+func (c *Client) TryLockToken(name, owner string, ttl time.Duration) (token uint64, ok bool, err error) {
+	n, err := replyInt(c.do("TRYLOCK", name, owner, strconv.FormatInt(ttl.Milliseconds(), 10)))
+	return uint64(n), n > 0, err
+}
+
+// Unlock releases the lock name if owner holds it, and returns ErrLockNotHeld otherwise.
+func (c *Client) Unlock(name, owner string) error {
+	n, err := replyInt(c.do("UNLOCK", name, owner))
+	if err == nil && n == 0 {
+		err = fmt.Errorf("%w: %s", ErrLockNotHeld, name)
+	}
+	return err
+}
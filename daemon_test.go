@@ -0,0 +1,143 @@
+package jungledb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestServeUnixClient tests that a Client over a unix socket behaves like the DB it serves.
+func TestServeUnixClient(t *testing.T) {
+	db, err := Open("testdata/daemon.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	const socket = "testdata/daemon.sock"
+	go db.ServeUnix(socket)
+
+	var client *Client
+	waitFor(t, "daemon socket", func() bool {
+		client, err = DialUnix(socket)
+		return err == nil
+	})
+	defer client.Close()
+
+	var store Store = client
+
+	if err := store.Hset("user:1", "name", []byte("Alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := store.Hset("user:1", "empty", []byte{}); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := store.Hmset("user:1", map[string][]byte{"pre:a": []byte("1"), "pre:b": {0, 1, 2}}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	value, err := store.Hget("user:1", "name")
+	if err != nil || string(value) != "Alice" {
+		t.Errorf("Hget mismatch: got %q, %v", value, err)
+	}
+	if value, _ := store.Hget("user:1", "missing"); value != nil {
+		t.Errorf("Hget of missing field should be nil, got %q", value)
+	}
+	values, err := store.Hmget("user:1", []string{"pre:b", "missing"})
+	if err != nil {
+		t.Fatalf("Hmget failed: %v", err)
+	}
+	if !equalByteSlices(values, [][]byte{{0, 1, 2}, nil}) {
+		t.Errorf("Hmget mismatch: got %v", values)
+	}
+	prefixed, err := store.Hprefix("user:1", "pre:")
+	if err != nil {
+		t.Fatalf("Hprefix failed: %v", err)
+	}
+	if !equalByteMap(prefixed, map[string][]byte{"pre:a": []byte("1"), "pre:b": {0, 1, 2}}) {
+		t.Errorf("Hprefix mismatch: got %v", prefixed)
+	}
+	all, err := store.Hscan("user:1")
+	if err != nil {
+		t.Fatalf("Hscan failed: %v", err)
+	}
+	if len(all) != 4 {
+		t.Errorf("Hscan mismatch: got %v", all)
+	}
+
+	if n, err := store.Hincr("user:1", "visits", 2); err != nil || n != 2 {
+		t.Errorf("Hincr mismatch: got %d, %v", n, err)
+	}
+	if n, err := db.HgetInt("user:1", "visits"); err != nil || n != 2 {
+		t.Errorf("Hincr not visible to DB: got %d, %v", n, err)
+	}
+	if n, err := store.HgetInt("user:1", "visits"); err != nil || n != 2 {
+		t.Errorf("HgetInt mismatch: got %d, %v", n, err)
+	}
+	if _, err := store.HgetInt("user:1", "name"); err == nil {
+		t.Error("HgetInt of a non-integer field should fail")
+	}
+
+	if err := store.Hmdel("user:1", []string{"pre:a", "pre:b"}); err != nil {
+		t.Fatalf("Hmdel failed: %v", err)
+	}
+	if exists, _ := store.HhasKey("user:1", "pre:a"); exists {
+		t.Error("field still exists after Hmdel")
+	}
+
+	if err := store.Zadd("ranking", 2.5, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := store.Zadd("ranking", 1, "b"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	members, err := store.Zrevrange("ranking", 0, -1)
+	if err != nil || !equal(members, []string{"a", "b"}) {
+		t.Errorf("Zrevrange mismatch: got %v, %v", members, err)
+	}
+	if score, err := store.Zscore("ranking", "a"); err != nil || score != 2.5 {
+		t.Errorf("Zscore mismatch: got %f, %v", score, err)
+	}
+	if err := store.Zrem("ranking", "a"); err != nil {
+		t.Fatalf("Zrem failed: %v", err)
+	}
+	if card, err := store.Zcard("ranking"); err != nil || card != 1 {
+		t.Errorf("Zcard mismatch: got %d, %v", card, err)
+	}
+
+	if err := store.HdelBucket("ranking"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if err := store.HdelBucket("ranking"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("HdelBucket of missing key: expected ErrKeyNotFound, got %v", err)
+	}
+
+	// Locks are shared with the serving process
+	if token, ok, err := client.TryLockToken("cron", "worker-a", time.Minute); err != nil || !ok || token != 1 {
+		t.Errorf("TryLockToken mismatch: got %d %v %v", token, ok, err)
+	}
+	if ok, err := db.TryLock("cron", "worker-b", time.Minute); err != nil || ok {
+		t.Errorf("lock taken through the client should be held, got %v %v", ok, err)
+	}
+	if _, ok, err := client.TryLockToken("cron", "worker-b", time.Minute); err != nil || ok {
+		t.Errorf("TryLockToken of a held lock: expected false, got %v %v", ok, err)
+	}
+	if err := client.Unlock("cron", "worker-b"); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("Unlock by another owner: expected ErrLockNotHeld, got %v", err)
+	}
+	if err := client.Unlock("cron", "worker-a"); err != nil {
+		t.Errorf("Unlock failed: %v", err)
+	}
+}
+
+// equalByteSlices compares two slices of byte slices, distinguishing nil from empty.
+func equalByteSlices(a, b [][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if (a[i] == nil) != (b[i] == nil) || string(a[i]) != string(b[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package jungledb
+
+import (
+	"errors"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+	berrors "go.etcd.io/bbolt/errors"
+)
+
+// Errors returned by database operations; test for them with errors.Is, as they may be
+// wrapped with details such as the key involved.
+var (
+	// ErrKeyNotFound is returned by operations that require an existing key, such as
+	// HdelBucket, Rename or Expire. Reads of missing keys return empty results instead.
+	ErrKeyNotFound = errors.New("key not found")
+
+	// ErrFieldNotFound is returned by operations that require an existing hash field.
+	ErrFieldNotFound = errors.New("field not found")
+
+	// ErrKeyExists is returned when the destination of Rename or Copy already exists.
+	ErrKeyExists = errors.New("key already exists")
+
+	// ErrWrongType is returned by hash operations on a sorted set and vice versa, and
+	// by integer operations on fields that do not hold an 8-byte integer.
+	ErrWrongType = errors.New("operation against a key holding the wrong kind of value")
+
+	// ErrOverflow is returned when an increment would overflow an int64.
+	ErrOverflow = errors.New("integer overflow")
+
+	// ErrClosed is returned by operations on a closed database.
+	ErrClosed = errors.New("database is closed")
+
+	// ErrReadOnly is returned by writes to a database opened with OpenReadOnly.
+	ErrReadOnly = errors.New("database is read-only")
+
+	// ErrQuotaExceeded is returned by writes that would break a limit set with WithLimits.
+	ErrQuotaExceeded = errors.New("quota exceeded")
+
+	// ErrValueTooLarge is returned by writes of a hash value or sorted set member larger
+	// than the maximum set with WithMaxValueSize or Limits. It matches ErrQuotaExceeded too.
+	ErrValueTooLarge = fmt.Errorf("%w: value too large", ErrQuotaExceeded)
+
+	// ErrUnauthenticated is returned by Authorize when the database has an ACL and the
+	// token does not belong to any of its users.
+	ErrUnauthenticated = errors.New("authentication required")
+
+	// ErrPermissionDenied is returned by Authorize when the user lacks the access needed.
+	ErrPermissionDenied = errors.New("permission denied")
+
+	// ErrMalformed is returned by the Decode functions for input that no Encode function
+	// produces.
+	ErrMalformed = errors.New("malformed encoding")
+
+	// ErrUndeclaredKey is returned by the methods of a transaction started by
+	// KeySet.Update for keys that were not declared with WithKeys.
+	ErrUndeclaredKey = errors.New("key not declared for the transaction")
+
+	// ErrImmutable is returned by writes to a key made write-once with WithImmutable
+	// after its first write.
+	ErrImmutable = errors.New("key is immutable")
+
+	// ErrReservedKey is returned for keys whose names the database keeps for itself:
+	// those of namespaces, internal buckets, locks, leases, links, blobs and soft deleted
+	// keys, which all start with "__jungledb".
+	ErrReservedKey = errors.New("key name is reserved")
+)
+
+// checkType returns ErrWrongType if key exists and holds something other than want.
+func checkType(tx *bbolt.Tx, key string, want string) error {
+	if tx.Bucket([]byte(key)) == nil {
+		return nil
+	}
+	if isInternalBucket(tx, []byte(key)) {
+		return fmt.Errorf("%w: %s holds the member index of a sorted set", ErrWrongType, key)
+	}
+	if keyType(tx, []byte(key)) != want {
+		return ErrWrongType
+	}
+	return nil
+}
+
+// hashBucket returns the live hash stored under key, nil if there is none, or ErrWrongType
+// if key holds a sorted set.
+func (db *DB) hashBucket(tx *bbolt.Tx, key string) (*bbolt.Bucket, error) {
+	bucket := db.liveBucket(tx, key)
+	if bucket != nil && keyType(tx, []byte(key)) != typeHash {
+		return nil, ErrWrongType
+	}
+	return bucket, nil
+}
+
+// zsetBucket returns the score-ordered bucket of the live sorted set stored under key,
+// nil if there is none, or ErrWrongType if key holds a hash.
+func (db *DB) zsetBucket(tx *bbolt.Tx, key string) (*bbolt.Bucket, error) {
+	bucket := db.liveBucket(tx, key)
+	if bucket != nil && keyType(tx, []byte(key)) != typeZset {
+		return nil, ErrWrongType
+	}
+	return bucket, nil
+}
+
+// closedError translates bbolt's error for a closed database into ErrClosed.
+func closedError(err error) error {
+	if errors.Is(err, berrors.ErrDatabaseNotOpen) {
+		return ErrClosed
+	}
+	return err
+}
@@ -0,0 +1,69 @@
+package jungledb
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestSentinelErrors tests that operations report failures with the exported sentinels.
+func TestSentinelErrors(t *testing.T) {
+	db, err := Open("testdata/errors.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	if err := db.Hset("hash", "f", []byte("not a counter")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Zadd("zset", 1, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if _, err := db.Hincr("counters", "n", math.MaxInt64); err != nil {
+		t.Fatalf("Hincr failed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"HdelBucket missing", db.HdelBucket("missing"), ErrKeyNotFound},
+		{"Rename missing", db.Rename("missing", "other"), ErrKeyNotFound},
+		{"Copy onto existing", db.Copy("hash", "zset"), ErrKeyExists},
+		{"Hset on zset", db.Hset("zset", "f", nil), ErrWrongType},
+		{"Hdel on zset", db.Hdel("zset", "a"), ErrWrongType},
+		{"Zadd on hash", db.Zadd("hash", 1, "a"), ErrWrongType},
+		{"Zrem on hash", db.Zrem("hash", "a"), ErrWrongType},
+		{"Hincr on non-integer", second(db.Hincr("hash", "f", 1)), ErrWrongType},
+		{"HgetInt on non-integer", second(db.HgetInt("hash", "f")), ErrWrongType},
+		{"Hincr overflow", second(db.Hincr("counters", "n", 1)), ErrOverflow},
+		{"Hget on zset", second(db.Hget("zset", "a")), ErrWrongType},
+		{"Hscan on zset", second(db.Hscan("zset")), ErrWrongType},
+		{"Zrange on hash", second(db.Zrange("hash", 0, -1)), ErrWrongType},
+		{"Zscore on hash", second(db.Zscore("hash", "f")), ErrWrongType},
+	}
+	for _, tc := range tests {
+		if !errors.Is(tc.err, tc.want) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.want, tc.err)
+		}
+	}
+
+	// Missing keys and fields read as empty rather than failing
+	if value, err := db.Hget("missing", "f"); err != nil || value != nil {
+		t.Errorf("Hget of a missing key: expected nil, got %q %v", value, err)
+	}
+
+	db.Close()
+	if err := db.Hset("hash", "f", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Hset after Close: expected ErrClosed, got %v", err)
+	}
+	if _, err := db.Hget("hash", "f"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Hget after Close: expected ErrClosed, got %v", err)
+	}
+}
+
+// second returns the error of a two-value result.
+func second[T any](_ T, err error) error {
+	return err
+}
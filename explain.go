@@ -0,0 +1,109 @@
+package jungledb
+
+import (
+	"bytes"
+
+	"go.etcd.io/bbolt"
+)
+
+// Plan describes how a read would run, as reported by the Explain methods, which read no
+// values: it lets access patterns be checked before they meet production data.
+type Plan struct {
+	Index   string // Index the read would use, empty for a scan
+	Keys    int    // Keys examined
+	Entries int    // Fields and index entries visited
+	Pages   int    // Estimate of the database pages read, from the shape of the buckets
+	Results int    // Fields or hashes the read would return
+}
+
+// HprefixExplain reports how Hprefix(key, prefix) would run.
+func (db *DB) HprefixExplain(key, prefix string) (Plan, error) {
+	if err := checkKey(key); err != nil {
+		return Plan{}, err
+	}
+	key = db.nsKey(key)
+	var plan Plan
+	err := db.view("Explain", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err
+		}
+		plan.Keys = 1
+		c := bucket.Cursor()
+		for k, _ := c.Seek([]byte(prefix)); k != nil && bytes.HasPrefix(k, []byte(prefix)); k, _ = c.Next() {
+			plan.Entries++
+		}
+		plan.Results = plan.Entries
+		plan.Pages = pagesRead(bucket, max(plan.Entries, 1))
+		return nil
+	})
+	return plan, err
+}
+
+// Explain reports how Run would evaluate the query: whether it would use an index, and
+// how many hashes it would examine and return.
+func (q *Query) Explain() (Plan, error) {
+	if q.err != nil {
+		return Plan{}, q.err
+	}
+
+	var plan Plan
+	err := q.db.view("Explain", q.db.nsKey(q.prefix), func(tx *bbolt.Tx) error {
+		examine := func(name string) {
+			plan.Keys++
+			_, bucket, lookups, ok := q.match(tx, name)
+			if bucket == nil {
+				return
+			}
+			plan.Entries += lookups
+			if ok {
+				n := bucket.Stats().KeyN
+				plan.Results++
+				plan.Entries += n
+				plan.Pages += pagesRead(bucket, n)
+			} else {
+				plan.Pages += pagesRead(bucket, 1)
+			}
+		}
+
+		idx, values := q.index()
+		if idx == nil {
+			return q.scan(tx, func(name string) bool {
+				examine(name)
+				return q.order != "" || q.limit <= 0 || plan.Results < q.limit
+			})
+		}
+		plan.Index = idx.Name
+		prefix := appendIndexValue(nil, []byte(q.db.ns))
+		for _, v := range values {
+			prefix = appendIndexValue(prefix, v)
+		}
+		var indexEntries int
+		err := scanIndex(tx, idx, prefix, nil, func(name string) bool {
+			indexEntries++
+			examine(name)
+			return true
+		})
+		if bucket := tx.Bucket([]byte(indexPrefix + idx.Name)); bucket != nil && bucket.Bucket(indexEntriesKey) != nil {
+			plan.Pages += pagesRead(bucket.Bucket(indexEntriesKey), max(indexEntries, 1))
+		}
+		plan.Entries += indexEntries
+		return err
+	})
+	if q.limit > 0 && plan.Results > q.limit {
+		plan.Results = q.limit
+	}
+	return plan, err
+}
+
+// pagesRead estimates the pages read to visit n entries of b in order: its branch pages
+// down to the first leaf, then its leaf pages in proportion to n. Inline buckets are
+// stored in the page of their parent and count as none.
+func pagesRead(b *bbolt.Bucket, n int) int {
+	s := b.Stats()
+	if s.LeafPageN == 0 || s.KeyN == 0 {
+		return 0
+	}
+	leaves := min(max((n*s.LeafPageN+s.KeyN-1)/s.KeyN, 1), s.LeafPageN)
+	return s.Depth - 1 + leaves
+}
@@ -0,0 +1,63 @@
+package jungledb
+
+import (
+	"fmt"
+	"testing"
+)
+
+// TestExplain tests that the Explain methods report index use and the keys, entries and
+// results of a read.
+func TestExplain(t *testing.T) {
+	db, err := Open("testdata/explain.db", WithIndex(Index{Name: "country", Prefix: "user:", Fields: []string{"country"}}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i, country := range []string{"fr", "fr", "de", "fr"} {
+		if err := db.Hmset(fmt.Sprintf("user:%d", i), map[string][]byte{"country": []byte(country), "age": []byte("30")}); err != nil {
+			t.Fatalf("Hmset failed: %v", err)
+		}
+	}
+	fields := make(map[string][]byte)
+	for i := range 1000 {
+		fields[fmt.Sprintf("f%03d", i)] = make([]byte, 100)
+	}
+	if err := db.Hmset("big", fields); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+
+	plan, err := db.Query("user:").Where("country", "=", "fr").Explain()
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if want := (Plan{Index: "country", Keys: 3, Entries: 3 + 3*2 + 3, Results: 3}); plan.Index != want.Index || plan.Keys != want.Keys || plan.Entries != want.Entries || plan.Results != want.Results {
+		t.Errorf("indexed query plan = %+v, expected %+v", plan, want)
+	}
+
+	plan, err = db.Query("user:").Where("age", ">", "18").Limit(2).Explain()
+	if err != nil {
+		t.Fatalf("Explain failed: %v", err)
+	}
+	if plan.Index != "" || plan.Keys != 2 || plan.Results != 2 {
+		t.Errorf("expected a scan of 2 keys returning 2 hashes, got %+v", plan)
+	}
+
+	small, err := db.HprefixExplain("big", "f00")
+	if err != nil {
+		t.Fatalf("HprefixExplain failed: %v", err)
+	}
+	all, err := db.HprefixExplain("big", "f")
+	if err != nil {
+		t.Fatalf("HprefixExplain failed: %v", err)
+	}
+	if small.Keys != 1 || small.Entries != 10 || small.Results != 10 || all.Entries != 1000 {
+		t.Errorf("unexpected Hprefix plans: %+v and %+v", small, all)
+	}
+	if small.Pages < 1 || all.Pages <= small.Pages {
+		t.Errorf("expected a full scan to read more pages than a prefix, got %d and %d", all.Pages, small.Pages)
+	}
+	if plan, err := db.HprefixExplain("missing", ""); err != nil || plan != (Plan{}) {
+		t.Errorf("HprefixExplain of a missing key = %+v, %v; expected an empty plan", plan, err)
+	}
+}
@@ -0,0 +1,263 @@
+package jungledb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"go.etcd.io/bbolt"
+)
+
+// importBatchSize bounds how many records Import writes per transaction.
+const importBatchSize = 500
+
+// ExportOptions controls which keys Export writes.
+type ExportOptions struct {
+	// Pattern restricts the export to keys matching a glob (see ListKeys). Empty exports everything.
+	Pattern string
+
+	// After resumes an interrupted export: keys up to After, as passed to Checkpoint,
+	// are skipped. The resumed export is a snapshot of its own, taken when it starts.
+	After string
+
+	// Checkpoint, if set, is called every CheckpointEvery keys (1000 if zero) and once
+	// the export completes, with the last key written, after everything written so far
+	// has been flushed to the writer. Saving it along with the output length lets a
+	// long export interrupted by a restart be resumed by truncating the output there
+	// and exporting again with After set to it. An error stops the export.
+	Checkpoint      func(after string) error
+	CheckpointEvery int
+}
+
+// exportRecord is one line of a JSON export: a whole hash or sorted set.
+// Values are []byte so encoding/json writes them as base64, keeping binary data intact.
+type exportRecord struct {
+	Key     string            `json:"key"`
+	Type    string            `json:"type"`
+	Fields  map[string][]byte `json:"fields,omitempty"`
+	Members []exportMember    `json:"members,omitempty"`
+
+	ExpiresAt int64 `json:"expires_at,omitempty"` // TTL deadline in Unix nanoseconds
+}
+
+// exportMember is a sorted set member and its score, encoded as a string so that
+// infinite scores survive exports, replication and Raft snapshots.
+type exportMember struct {
+	Member string    `json:"member"`
+	Score  jsonScore `json:"score"`
+}
+
+// Export writes the database as line-delimited JSON, one record per key.
+// Hash records carry a "fields" object with base64 values, sorted set records carry
+// a "members" array in ascending score order, with scores as strings such as "2.5" or
+// "-inf", and keys with a TTL an "expires_at" deadline in Unix nanoseconds. Expired
+// keys are skipped. The export is a consistent snapshot.
+func (db *DB) Export(w io.Writer, opts ExportOptions) error {
+	return db.view("Export", opts.Pattern, func(tx *bbolt.Tx) error {
+		return db.export(tx, w, opts)
+	})
+}
+
+// export writes the keys of tx selected by opts, see Export.
+func (db *DB) export(tx *bbolt.Tx, w io.Writer, opts ExportOptions) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	err := db.exportKeys(tx, opts, bw.Flush, func(name []byte, b *bbolt.Bucket) error {
+		return enc.Encode(newExportRecord(tx, name, b))
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Snapshot is a consistent view of a database, taken by DB.Snapshot to be exported while
+// writes go on.
+type Snapshot struct {
+	db *DB
+	tx *bbolt.Tx
+}
+
+// Snapshot starts a read-only transaction and returns it as a Snapshot, whose Export
+// writes the keys as they were when Snapshot returned, however long writing them takes.
+// Release the snapshot once exported: its transaction keeps the pages it sees from being
+// reused, which grows the file, and Close, as well as writes that need the file to grow,
+// wait for it.
+func (db *DB) Snapshot() (*Snapshot, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if db.closed {
+		return nil, ErrClosed
+	}
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		return nil, closedError(err)
+	}
+	return &Snapshot{db: db.At(db.now()), tx: tx}, nil // Keys expire as of the snapshot
+}
+
+// Export writes the keys of the snapshot selected by opts, as DB.Export does.
+func (s *Snapshot) Export(w io.Writer, opts ExportOptions) error {
+	return s.db.export(s.tx, w, opts)
+}
+
+// Release ends the transaction of the snapshot. Releasing it again has no effect.
+func (s *Snapshot) Release() {
+	s.tx.Rollback()
+}
+
+// newExportRecord builds the export record for the bucket stored under name.
+func newExportRecord(tx *bbolt.Tx, name []byte, b *bbolt.Bucket) exportRecord {
+	rec := exportRecord{Key: string(name), Type: keyType(tx, name), ExpiresAt: expiry(tx, string(name))}
+
+	if rec.Type == typeZset {
+		rec.Members = []exportMember{}
+		b.ForEach(func(k, _ []byte) error {
+			rec.Members = append(rec.Members, exportMember{
+				Member: string(k[8:]),
+				Score:  jsonScore(math.Float64frombits(binary.BigEndian.Uint64(k[:8]))),
+			})
+			return nil
+		})
+		return rec
+	}
+
+	rec.Fields = make(map[string][]byte)
+	b.ForEach(func(k, v []byte) error {
+		rec.Fields[string(k)] = append([]byte{}, v...) // Copy out of the mmap
+		return nil
+	})
+	return rec
+}
+
+// Load replaces every key of db with those of a line-delimited JSON dump produced by
+// Export, in a single transaction: readers see either the old keys or the new ones, and
+// a failure leaves the old ones in place. Unlike FlushAll followed by Import, it may
+// write keys made write-once with WithImmutable again, since the dump is taken as the
+// authoritative contents; they stay sealed. Internal buckets are kept, as by FlushAll.
+// It returns the number of keys loaded.
+func (db *DB) Load(r io.Reader) (int, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	loaded := 0
+	err := db.update("Load", "", func(tx *txn) error {
+		tx.replace = true
+		var names [][]byte
+		c := tx.Cursor()
+		for k, v := c.Seek([]byte(db.ns)); k != nil && bytes.HasPrefix(k, []byte(db.ns)); k, v = c.Next() {
+			if v == nil && !isInternalBucket(tx.Tx, k) {
+				names = append(names, bytes.Clone(k))
+			}
+		}
+		for _, name := range names {
+			if err := deleteKey(tx, string(name)); err != nil {
+				return fmt.Errorf("failed to delete key %s: %v", name, err)
+			}
+			tx.record(Event{Type: EventDelete, Key: string(name)})
+		}
+
+		for {
+			var rec exportRecord
+			if err := dec.Decode(&rec); err == io.EOF {
+				return nil
+			} else if err != nil {
+				return fmt.Errorf("failed to decode import record: %v", err)
+			}
+			if err := importRecord(tx, rec); err != nil {
+				return err
+			}
+			loaded++
+		}
+	})
+	if err != nil {
+		return 0, err
+	}
+	return loaded, nil
+}
+
+// Import loads a line-delimited JSON dump produced by Export and returns the number
+// of keys imported. Hash fields are merged into existing hashes and sorted set
+// members are added with Zadd semantics. Records are written in batched transactions.
+func (db *DB) Import(r io.Reader) (int, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	imported := 0
+	batch := make([]exportRecord, 0, importBatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := db.update("Import", "", func(tx *txn) error {
+			for _, rec := range batch {
+				if err := importRecord(tx, rec); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		imported += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		var rec exportRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return imported, fmt.Errorf("failed to decode import record: %v", err)
+		}
+
+		batch = append(batch, rec)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return imported, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return imported, err
+	}
+	return imported, nil
+}
+
+// importRecord writes a single export record inside a read-write transaction.
+func importRecord(tx *txn, rec exportRecord) error {
+	switch rec.Type {
+	case typeHash:
+		bucket, err := tx.CreateBucketIfNotExists([]byte(rec.Key))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		for field, value := range rec.Fields {
+			if err := bucket.Put([]byte(field), value); err != nil {
+				return err
+			}
+			tx.record(Event{Type: EventHset, Key: rec.Key, Field: field, Value: value})
+		}
+	case typeZset:
+		// Create the buckets up front so empty sorted sets survive a round trip
+		if _, _, err := createZset(tx.Tx, rec.Key); err != nil {
+			return err
+		}
+		for _, m := range rec.Members {
+			if err := zadd(tx, rec.Key, float64(m.Score), m.Member); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("unknown type %q for key %s", rec.Type, rec.Key)
+	}
+
+	if rec.ExpiresAt != 0 {
+		return setExpiry(tx, rec.Key, rec.ExpiresAt)
+	}
+	return nil
+}
@@ -0,0 +1,185 @@
+package jungledb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestExportImport tests a JSON export/import round trip between two databases.
+func TestExportImport(t *testing.T) {
+	src, err := Open("testdata/export_src.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer src.Close()
+
+	binary := []byte{0x00, 0xff, 0x10, '\n'}
+	if err := src.Hmset("user:1", map[string][]byte{"name": []byte("Alice"), "blob": binary}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	if err := src.Zadd("ranking", 1.5, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := src.Zadd("ranking", 3, "bob"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := src.Hset("other:1", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.Export(&buf, ExportOptions{}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Errorf("expected 3 export lines, got %d:\n%s", lines, buf.String())
+	}
+
+	dst, err := Open("testdata/export_dst.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer dst.Close()
+
+	n, err := dst.Import(&buf)
+	if err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("imported count mismatch: expected 3, got %d", n)
+	}
+
+	fields, err := dst.Hscan("user:1")
+	if err != nil {
+		t.Fatalf("Hscan failed: %v", err)
+	}
+	if !equalByteMap(fields, map[string][]byte{"name": []byte("Alice"), "blob": binary}) {
+		t.Errorf("imported hash mismatch: got %v", fields)
+	}
+
+	members, err := dst.Zrange("ranking", 0, -1)
+	if err != nil {
+		t.Fatalf("Zrange failed: %v", err)
+	}
+	if !equal(members, []string{"alice", "bob"}) {
+		t.Errorf("imported zset mismatch: got %v", members)
+	}
+	score, err := dst.Zscore("ranking", "alice")
+	if err != nil {
+		t.Fatalf("Zscore failed: %v", err)
+	}
+	if score != 1.5 {
+		t.Errorf("imported score mismatch: expected 1.5, got %f", score)
+	}
+
+	// Pattern-restricted export
+	buf.Reset()
+	if err := src.Export(&buf, ExportOptions{Pattern: "user:*"}); err != nil {
+		t.Fatalf("Export with pattern failed: %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 1 {
+		t.Errorf("expected 1 export line for pattern, got %d", lines)
+	}
+
+	// Malformed input
+	if _, err := dst.Import(strings.NewReader("{not json")); err == nil {
+		t.Error("Import of malformed input should fail")
+	}
+}
+
+// TestExportCheckpoint tests that an interrupted export resumed from its last checkpoint
+// writes every key once.
+func TestExportCheckpoint(t *testing.T) {
+	db, err := Open("testdata/export_checkpoint.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	for i := range 10 {
+		if err := db.Hset(fmt.Sprintf("key:%02d", i), "f", []byte("v")); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	var after string
+	var written int
+	interrupted := errors.New("interrupted")
+	err = db.Export(&buf, ExportOptions{CheckpointEvery: 3, Checkpoint: func(key string) error {
+		after, written = key, buf.Len()
+		if key == "key:05" {
+			return interrupted
+		}
+		return nil
+	}})
+	if !errors.Is(err, interrupted) {
+		t.Fatalf("expected the checkpoint's error, got %v", err)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 6 {
+		t.Errorf("expected 6 lines flushed at the checkpoint, got %d", lines)
+	}
+
+	buf.Truncate(written)
+	var checkpoints []string
+	err = db.Export(&buf, ExportOptions{After: after, CheckpointEvery: 3, Checkpoint: func(key string) error {
+		checkpoints = append(checkpoints, key)
+		return nil
+	}})
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if !reflect.DeepEqual(checkpoints, []string{"key:08", "key:09"}) {
+		t.Errorf("unexpected checkpoints of the resumed export: %v", checkpoints)
+	}
+	for i := range 10 {
+		if n := strings.Count(buf.String(), fmt.Sprintf(`"key:%02d"`, i)); n != 1 {
+			t.Errorf("expected key:%02d once, got %d times", i, n)
+		}
+	}
+}
+
+// TestExportInfiniteScores tests that infinite scores survive an export and import, and
+// that exports with scores as JSON numbers still import.
+func TestExportInfiniteScores(t *testing.T) {
+	src, err := Open("testdata/export_inf_src.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer src.Close()
+	dst, err := Open("testdata/export_inf_dst.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer dst.Close()
+
+	scores := map[string]float64{"top": math.Inf(1), "bottom": math.Inf(-1), "middle": 2.5}
+	for member, score := range scores {
+		if err := src.Zadd("ranking", score, member); err != nil {
+			t.Fatalf("Zadd of %v failed: %v", score, err)
+		}
+	}
+	var buf bytes.Buffer
+	if err := src.Export(&buf, ExportOptions{}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if !strings.Contains(buf.String(), `"score":"-inf"`) {
+		t.Errorf("export does not hold the score as a string: %s", buf.String())
+	}
+	buf.WriteString(`{"key":"old","type":"zset","members":[{"member":"a","score":1.5}]}` + "\n")
+	if _, err := dst.Import(&buf); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	for member, want := range scores {
+		if score, err := dst.Zscore("ranking", member); err != nil || score != want {
+			t.Errorf("Zscore of %s after Import: got %v, %v, want %v", member, score, err, want)
+		}
+	}
+	if score, err := dst.Zscore("old", "a"); err != nil || score != 1.5 {
+		t.Errorf("Zscore of a numeric score after Import: got %v, %v, want 1.5", score, err)
+	}
+}
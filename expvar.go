@@ -0,0 +1,91 @@
+package jungledb
+
+import (
+	"expvar"
+	"sync"
+)
+
+// expvarDBs maps the prefixes published with WithExpvar to the database they report.
+// expvar cannot unpublish a variable, so a prefix stays published once used and reports
+// whichever database last opened with it, or nothing once that database is closed.
+var expvarDBs struct {
+	mu  sync.Mutex
+	dbs map[string]*DB
+}
+
+// WithExpvar publishes the database's runtime stats as the expvar variable prefix, so
+// they appear on /debug/vars: operation counts and errors, open transactions, file size,
+// bytes read and written, and cache hits, misses and hit ratio (see Metrics). The
+// variable is computed when read and reads null after the database is closed. Opening
+// another database with the same prefix makes it report that database instead.
+func WithExpvar(prefix string) Option {
+	return func(o *options) {
+		o.expvarPrefix = prefix
+	}
+}
+
+// publishExpvar publishes db under its expvar prefix, if it has one.
+func (db *DB) publishExpvar() {
+	prefix := db.opts.expvarPrefix
+	if prefix == "" {
+		return
+	}
+	expvarDBs.mu.Lock()
+	defer expvarDBs.mu.Unlock()
+	if expvarDBs.dbs == nil {
+		expvarDBs.dbs = make(map[string]*DB)
+	}
+	if _, ok := expvarDBs.dbs[prefix]; !ok && expvar.Get(prefix) == nil {
+		expvar.Publish(prefix, expvar.Func(func() any {
+			return expvarStats(prefix)
+		}))
+	}
+	expvarDBs.dbs[prefix] = db
+}
+
+// unpublishExpvar stops reporting db under its expvar prefix.
+func (db *DB) unpublishExpvar() {
+	prefix := db.opts.expvarPrefix
+	if prefix == "" {
+		return
+	}
+	expvarDBs.mu.Lock()
+	defer expvarDBs.mu.Unlock()
+	if expvarDBs.dbs[prefix] == db {
+		expvarDBs.dbs[prefix] = nil
+	}
+}
+
+// expvarStats returns the stats published under prefix, or nil if no open database
+// reports there.
+func expvarStats(prefix string) any {
+	expvarDBs.mu.Lock()
+	db := expvarDBs.dbs[prefix]
+	expvarDBs.mu.Unlock()
+	if db == nil {
+		return nil
+	}
+
+	m := db.Metrics()
+	ops := make(map[string]uint64, len(m.Ops))
+	errs := make(map[string]uint64, len(m.Ops))
+	for op, om := range m.Ops {
+		ops[op] = om.Latency.Count
+		errs[op] = om.Errors
+	}
+	ratio := 0.0
+	if total := m.CacheHits + m.CacheMisses; total > 0 {
+		ratio = float64(m.CacheHits) / float64(total)
+	}
+	return map[string]any{
+		"ops":             ops,
+		"errors":          errs,
+		"open_tx":         m.OpenTx,
+		"file_size":       m.FileSize,
+		"bytes_read":      m.BytesRead,
+		"bytes_written":   m.BytesWritten,
+		"cache_hits":      m.CacheHits,
+		"cache_misses":    m.CacheMisses,
+		"cache_hit_ratio": ratio,
+	}
+}
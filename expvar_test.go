@@ -0,0 +1,67 @@
+package jungledb
+
+import (
+	"encoding/json"
+	"expvar"
+	"testing"
+)
+
+// TestExpvar tests that WithExpvar publishes operation counts and cache hits, and that
+// the variable reads null once the database is closed.
+func TestExpvar(t *testing.T) {
+	db, err := Open("testdata/expvar.db", WithExpvar("jungledb_test"), WithCache(CacheNamespace{Prefix: "cache:"}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("cache:a", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	for _, field := range []string{"f", "f", "f", "missing"} {
+		if _, err := db.Hget("cache:a", field); err != nil {
+			t.Fatalf("Hget failed: %v", err)
+		}
+	}
+
+	v := expvar.Get("jungledb_test")
+	if v == nil {
+		t.Fatalf("expected the jungledb_test variable to be published")
+	}
+	var stats struct {
+		Ops           map[string]uint64 `json:"ops"`
+		FileSize      int64             `json:"file_size"`
+		CacheHits     uint64            `json:"cache_hits"`
+		CacheMisses   uint64            `json:"cache_misses"`
+		CacheHitRatio float64           `json:"cache_hit_ratio"`
+	}
+	if err := json.Unmarshal([]byte(v.String()), &stats); err != nil {
+		t.Fatalf("failed to decode %s: %v", v, err)
+	}
+	if stats.Ops["Hget"] != 4 || stats.Ops["Hset"] != 1 {
+		t.Errorf("unexpected operation counts: %v", stats.Ops)
+	}
+	if stats.FileSize == 0 {
+		t.Errorf("expected a file size")
+	}
+	if stats.CacheHits != 3 || stats.CacheMisses != 1 || stats.CacheHitRatio != 0.75 {
+		t.Errorf("expected 3 hits, 1 miss and a 0.75 ratio, got %d, %d and %v", stats.CacheHits, stats.CacheMisses, stats.CacheHitRatio)
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if got := v.String(); got != "null" {
+		t.Errorf("expected null after Close, got %s", got)
+	}
+
+	// Reopening with the same prefix reports the new database rather than panicking
+	db, err = Open("testdata/expvar.db", WithExpvar("jungledb_test"))
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	if got := v.String(); got == "null" {
+		t.Errorf("expected the reopened database to be reported")
+	}
+}
@@ -0,0 +1,72 @@
+package jungledb
+
+// fillRule is a fill percent set by WithFillPercent or WithSortedSetFillPercent.
+type fillRule struct {
+	pattern    string // Glob the key must match, see matchPattern
+	sortedSets bool   // Whether the rule applies to sorted sets only
+	fill       float64
+}
+
+// WithFillPercent sets how full bbolt packs the pages it splits when writing to the keys
+// matching the glob pattern (see ListKeys), in every namespace. bbolt fills pages to 50%
+// by default, which leaves room for inserts in the middle of a key; keys written in
+// order, such as logs with time-ordered fields, are best packed fully with a fill of
+// 1.0, which makes the file smaller and splits fewer pages. The fill is clamped to
+// bbolt's range of 0.1 to 1.0. The first matching rule applies, and rules of
+// WithFillPercent apply before those of WithSortedSetFillPercent.
+func WithFillPercent(pattern string, fill float64) Option {
+	return func(o *options) {
+		o.fillRules = append(o.fillRules, fillRule{pattern: pattern, fill: fill})
+	}
+}
+
+// WithSortedSetFillPercent is like WithFillPercent for every sorted set, for example those
+// scored by time. The fill applies to the score-ordered entries; the member index, which
+// is ordered by member, keeps bbolt's default.
+func WithSortedSetFillPercent(fill float64) Option {
+	return func(o *options) {
+		o.fillRules = append(o.fillRules, fillRule{sortedSets: true, fill: fill})
+	}
+}
+
+// applyFillPercent sets the fill percent of the buckets of the keys written by tx.
+// bbolt reads it when the transaction commits and splits the pages written, and forgets
+// it with the transaction, so it is set again on every write.
+func (db *DB) applyFillPercent(tx *txn) {
+	if len(db.opts.fillRules) == 0 {
+		return
+	}
+	for _, ev := range tx.events {
+		for _, name := range []string{ev.Key, ev.Target} {
+			if name == "" {
+				continue
+			}
+			bucket := tx.Bucket([]byte(name))
+			if bucket == nil || isInternalBucket(tx.Tx, []byte(name)) {
+				continue
+			}
+			if fill, ok := db.fillPercent(keyType(tx.Tx, []byte(name)), name); ok {
+				bucket.FillPercent = fill
+			}
+		}
+	}
+}
+
+// fillPercent returns the fill percent configured for the key stored under name, of type typ.
+func (db *DB) fillPercent(typ, name string) (float64, bool) {
+	_, key, ok := splitKey(name)
+	if !ok {
+		return 0, false
+	}
+	for _, r := range db.opts.fillRules {
+		if !r.sortedSets && matchPattern(r.pattern, key) {
+			return r.fill, true
+		}
+	}
+	for _, r := range db.opts.fillRules {
+		if r.sortedSets && typ == typeZset {
+			return r.fill, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,45 @@
+package jungledb
+
+import (
+	"fmt"
+	"testing"
+
+	"go.etcd.io/bbolt"
+)
+
+// TestFillPercent tests that keys written in order take fewer pages with a full fill.
+func TestFillPercent(t *testing.T) {
+	leafPages := func(path string, opts ...Option) int {
+		db, err := Open(path, opts...)
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		defer db.Close()
+		err = db.Update(func(tx *Tx) error {
+			for i := range 5000 {
+				if err := tx.Zadd("events", float64(i), fmt.Sprintf("event:%05d", i)); err != nil {
+					return err
+				}
+				if err := tx.Hset("log:1", fmt.Sprintf("%05d", i), []byte("entry")); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Update failed: %v", err)
+		}
+		var n int
+		db.db.View(func(tx *bbolt.Tx) error {
+			n = tx.Bucket([]byte("events")).Stats().LeafPageN + tx.Bucket([]byte("log:1")).Stats().LeafPageN
+			return nil
+		})
+		return n
+	}
+
+	def := leafPages("testdata/fill_default.db")
+	full := leafPages("testdata/fill_full.db", WithSortedSetFillPercent(1), WithFillPercent("log:*", 1))
+	if full*3/2 > def {
+		t.Errorf("expected a full fill to take far fewer pages than the default %d, got %d", def, full)
+	}
+}
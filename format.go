@@ -0,0 +1,130 @@
+package jungledb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+
+	"go.etcd.io/bbolt"
+)
+
+// formatVersion is the version of the on-disk layout written by this package. It is
+// stamped in the meta bucket and increases with every change to how data is encoded,
+// such as the score encoding, the index layout or the TTL buckets.
+const formatVersion = 2
+
+// metaBucket holds information about the database itself, such as its format version
+// under the "format" key. FlushAll keeps it.
+const metaBucket = internalPrefix + "meta"
+
+var formatKey = []byte("format")
+
+// ErrIncompatibleFormat is returned by Open for a database written in a format this
+// package cannot read, either newer than it supports or older and not upgraded.
+var ErrIncompatibleFormat = errors.New("incompatible database format")
+
+// formatUpgrades[v] upgrades a database from format v to v+1, in the transaction that
+// then stamps the new version.
+var formatUpgrades = []func(tx *bbolt.Tx) error{
+	0: func(tx *bbolt.Tx) error { return nil }, // Unstamped databases predate versioning and share format 1
+	1: recordZsets,
+}
+
+// recordZsets records the sorted sets of a database in format 1, which told them from
+// hashes by the presence of their member index.
+func recordZsets(tx *bbolt.Tx) error {
+	var zsets []string
+	err := tx.ForEach(func(name []byte, _ *bbolt.Bucket) error {
+		if !bytes.HasPrefix(name, []byte(internalPrefix)) && tx.Bucket(append(bytes.Clone(name), membersSuffix...)) != nil {
+			zsets = append(zsets, string(name))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range zsets {
+		if err := markZset(tx, name, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithoutFormatUpgrade makes Open fail with ErrIncompatibleFormat on a database in an
+// older format instead of upgrading it. By default Open writes a backup of the file next
+// to it, named after the old format (for example "data.db.format0.bak"), and then
+// upgrades it in a single transaction. OpenReadOnly never upgrades.
+func WithoutFormatUpgrade() Option {
+	return func(o *options) {
+		o.noFormatUpgrade = true
+	}
+}
+
+// checkFormat makes sure the database is in the current format, upgrading it if needed
+// and allowed, and stamps new databases.
+func (db *DB) checkFormat() error {
+	var version int
+	var empty bool
+	err := db.db.View(func(tx *bbolt.Tx) error {
+		k, _ := tx.Cursor().First()
+		empty = k == nil
+		bucket := tx.Bucket([]byte(metaBucket))
+		if bucket == nil {
+			return nil
+		}
+		v, err := strconv.Atoi(string(bucket.Get(formatKey)))
+		if err != nil {
+			return fmt.Errorf("%w: invalid format version %q", ErrIncompatibleFormat, bucket.Get(formatKey))
+		}
+		version = v
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case empty && db.readOnly:
+		return nil
+	case empty:
+		return db.db.Update(func(tx *bbolt.Tx) error { return stampNew(tx, &db.opts) })
+	case version > formatVersion:
+		return fmt.Errorf("%w: format %d is newer than the supported format %d", ErrIncompatibleFormat, version, formatVersion)
+	case version == formatVersion:
+		return nil
+	case db.readOnly || db.opts.noFormatUpgrade:
+		return fmt.Errorf("%w: format %d must be upgraded to format %d, which Open does by default", ErrIncompatibleFormat, version, formatVersion)
+	}
+
+	backup := fmt.Sprintf("%s.format%d.bak", db.filePath, version)
+	if _, err := os.Stat(backup); errors.Is(err, os.ErrNotExist) {
+		if err := db.BackupFile(backup); err != nil {
+			return fmt.Errorf("failed to back up the database before upgrading it: %w", err)
+		}
+	} // Otherwise an earlier upgrade failed after making the backup
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		for v := version; v < formatVersion; v++ {
+			if err := formatUpgrades[v](tx); err != nil {
+				return fmt.Errorf("failed to upgrade from format %d: %w", v, err)
+			}
+		}
+		return stampFormat(tx)
+	})
+	if err != nil {
+		return err
+	}
+	db.log.Info("upgraded database format", "from", version, "to", formatVersion, "backup", backup)
+	return nil
+}
+
+// stampFormat records that the database is in the current format.
+func stampFormat(tx *bbolt.Tx) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(metaBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create meta bucket: %v", err)
+	}
+	return bucket.Put(formatKey, []byte(strconv.Itoa(formatVersion)))
+}
@@ -0,0 +1,133 @@
+package jungledb
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"go.etcd.io/bbolt"
+)
+
+// setFormat overwrites the format version stamped in the database at path, removing it
+// if version is empty.
+func setFormat(t *testing.T, path, version string) {
+	t.Helper()
+	db, err := bbolt.Open(path, 0666, nil)
+	if err != nil {
+		t.Fatalf("failed to open bbolt database: %v", err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *bbolt.Tx) error {
+		if version == "" {
+			return tx.DeleteBucket([]byte(metaBucket))
+		}
+		return tx.Bucket([]byte(metaBucket)).Put(formatKey, []byte(version))
+	})
+	if err != nil {
+		t.Fatalf("failed to set the format: %v", err)
+	}
+}
+
+// TestFormatUpgrade tests that Open upgrades older formats after backing them up, unless
+// told not to, and refuses newer ones.
+func TestFormatUpgrade(t *testing.T) {
+	path := "testdata/format.db"
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Hset("user:1", "name", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	if err := db.Hset("user:1", "name", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	db.Close()
+
+	// FlushAll keeps the stamp, so the database opens without an upgrade
+	db, err = Open(path, WithoutFormatUpgrade())
+	if err != nil {
+		t.Fatalf("expected a current database to open, got %v", err)
+	}
+	db.Close()
+
+	setFormat(t, path, "")
+	if _, err := Open(path, WithoutFormatUpgrade()); !errors.Is(err, ErrIncompatibleFormat) {
+		t.Errorf("expected ErrIncompatibleFormat without upgrades, got %v", err)
+	}
+	if _, err := OpenReadOnly(path); !errors.Is(err, ErrIncompatibleFormat) {
+		t.Errorf("expected ErrIncompatibleFormat from OpenReadOnly, got %v", err)
+	}
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("failed to upgrade database: %v", err)
+	}
+	if v, err := db.Hget("user:1", "name"); err != nil || string(v) != "alice" {
+		t.Errorf("expected the data to survive the upgrade, got %q %v", v, err)
+	}
+	db.Close()
+	if _, err := os.Stat(path + ".format0.bak"); err != nil {
+		t.Errorf("expected a backup of the old format: %v", err)
+	}
+	db, err = OpenReadOnly(path)
+	if err != nil {
+		t.Fatalf("expected the upgraded database to open read-only, got %v", err)
+	}
+	db.Close()
+
+	setFormat(t, path, "99")
+	if _, err := Open(path); !errors.Is(err, ErrIncompatibleFormat) {
+		t.Errorf("expected ErrIncompatibleFormat for a newer format, got %v", err)
+	}
+}
+
+// TestFormatUpgradeZsets tests that upgrading from format 1 records which keys hold
+// sorted sets, which format 1 told by their member index.
+func TestFormatUpgradeZsets(t *testing.T) {
+	path := "testdata/format-zsets.db"
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Zadd("scores", 1, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Hset("user:1", "name", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	db.Close()
+
+	bdb, err := bbolt.Open(path, 0666, nil)
+	if err != nil {
+		t.Fatalf("failed to open bbolt database: %v", err)
+	}
+	err = bdb.Update(func(tx *bbolt.Tx) error {
+		return tx.DeleteBucket(zsetsName)
+	})
+	bdb.Close()
+	if err != nil {
+		t.Fatalf("failed to remove the sorted set records: %v", err)
+	}
+	setFormat(t, path, "1")
+
+	db, err = Open(path)
+	if err != nil {
+		t.Fatalf("failed to upgrade database: %v", err)
+	}
+	defer db.Close()
+	if score, err := db.Zscore("scores", "alice"); err != nil || score != 1 {
+		t.Errorf("Zscore after the upgrade: got %v, %v", score, err)
+	}
+	if _, err := db.Hget("scores", "alice"); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Hget of a sorted set after the upgrade: got error %v, want ErrWrongType", err)
+	}
+	if v, err := db.Hget("user:1", "name"); err != nil || string(v) != "alice" {
+		t.Errorf("Hget after the upgrade: got %q, %v", v, err)
+	}
+	if problems, err := db.Check(false); err != nil || len(problems) != 0 {
+		t.Errorf("Check after the upgrade: got %v, %v", problems, err)
+	}
+}
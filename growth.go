@@ -0,0 +1,52 @@
+package jungledb
+
+import (
+	"fmt"
+	"os"
+)
+
+// WithPreallocation makes Open extend the database file to size bytes ahead of use, and
+// map that much of it in memory. bbolt otherwise grows the file, and syncs its new size,
+// as it fills up, and remaps it when it outgrows the mapping, which blocks writers and
+// readers alike: preallocating the size expected at peak moves that cost to Open. A file
+// already larger is left alone, and read-only databases are never extended.
+func WithPreallocation(size int64) Option {
+	return func(o *options) {
+		o.preallocate = size
+	}
+}
+
+// WithGrowthIncrement sets how many bytes bbolt adds to the database file each time it
+// fills up, once the file is past that size; smaller files grow to what they need. The
+// default is 16MB. Larger increments mean fewer, if longer, pauses for growing the file.
+func WithGrowthIncrement(n int) Option {
+	return func(o *options) {
+		o.growthIncrement = n
+	}
+}
+
+// preallocate extends the database file to the size set with WithPreallocation.
+func (db *DB) preallocate() error {
+	if db.opts.preallocate <= 0 || db.readOnly {
+		return nil
+	}
+	info, err := os.Stat(db.filePath)
+	if err != nil {
+		return err
+	}
+	if info.Size() >= db.opts.preallocate {
+		return nil
+	}
+
+	// bbolt checks the size of the file before growing it, so it leaves this space alone
+	// and fills it before growing the file again
+	f, err := os.OpenFile(db.filePath, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := f.Truncate(db.opts.preallocate); err != nil {
+		return fmt.Errorf("failed to preallocate %d bytes: %v", db.opts.preallocate, err)
+	}
+	return f.Sync()
+}
@@ -0,0 +1,50 @@
+package jungledb
+
+import (
+	"fmt"
+	"os"
+	"testing"
+)
+
+// TestPreallocation tests that Open extends the file and that bbolt keeps using the space.
+func TestPreallocation(t *testing.T) {
+	const size = 8 << 20
+	db, err := Open("testdata/preallocated.db", WithPreallocation(size), WithGrowthIncrement(1<<20))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if db.db.AllocSize != 1<<20 {
+		t.Errorf("expected a growth increment of 1MB, got %d", db.db.AllocSize)
+	}
+	if info, err := os.Stat("testdata/preallocated.db"); err != nil || info.Size() != size {
+		t.Fatalf("expected the file to be preallocated, got %v %v", info, err)
+	}
+	for i := range 100 {
+		if err := db.Hset("user:1", fmt.Sprintf("f%d", i), make([]byte, 1024)); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+
+	stats, err := db.Stats()
+	if err != nil {
+		t.Fatalf("Stats failed: %v", err)
+	}
+	if stats.AllocatedSize != size || stats.FileSize >= stats.AllocatedSize {
+		t.Errorf("expected the data to fit in the preallocated space, got %+v", stats)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	db, err = Open("testdata/preallocated.db")
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	if fields, err := db.Hscan("user:1"); err != nil || len(fields) != 100 {
+		t.Errorf("expected the data to survive a reopen, got %d fields %v", len(fields), err)
+	}
+	if info, err := os.Stat("testdata/preallocated.db"); err != nil || info.Size() != size {
+		t.Errorf("expected the file to keep its size, got %v %v", info, err)
+	}
+}
@@ -0,0 +1,717 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: jungledb.proto
+
+package grpcapi
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	JungleDB_Hset_FullMethodName       = "/jungledb.v1.JungleDB/Hset"
+	JungleDB_Hmset_FullMethodName      = "/jungledb.v1.JungleDB/Hmset"
+	JungleDB_Hget_FullMethodName       = "/jungledb.v1.JungleDB/Hget"
+	JungleDB_Hmget_FullMethodName      = "/jungledb.v1.JungleDB/Hmget"
+	JungleDB_Hincr_FullMethodName      = "/jungledb.v1.JungleDB/Hincr"
+	JungleDB_HgetInt_FullMethodName    = "/jungledb.v1.JungleDB/HgetInt"
+	JungleDB_HhasKey_FullMethodName    = "/jungledb.v1.JungleDB/HhasKey"
+	JungleDB_Hdel_FullMethodName       = "/jungledb.v1.JungleDB/Hdel"
+	JungleDB_HdelBucket_FullMethodName = "/jungledb.v1.JungleDB/HdelBucket"
+	JungleDB_Hscan_FullMethodName      = "/jungledb.v1.JungleDB/Hscan"
+	JungleDB_Zadd_FullMethodName       = "/jungledb.v1.JungleDB/Zadd"
+	JungleDB_Zrem_FullMethodName       = "/jungledb.v1.JungleDB/Zrem"
+	JungleDB_Zscore_FullMethodName     = "/jungledb.v1.JungleDB/Zscore"
+	JungleDB_Zcard_FullMethodName      = "/jungledb.v1.JungleDB/Zcard"
+	JungleDB_Zrange_FullMethodName     = "/jungledb.v1.JungleDB/Zrange"
+	JungleDB_ScanKeys_FullMethodName   = "/jungledb.v1.JungleDB/ScanKeys"
+)
+
+// JungleDBClient is the client API for JungleDB service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// JungleDB exposes the hash and sorted set operations of a jungledb database.
+type JungleDBClient interface {
+	// Hash operations
+	Hset(ctx context.Context, in *HsetRequest, opts ...grpc.CallOption) (*Empty, error)
+	Hmset(ctx context.Context, in *HmsetRequest, opts ...grpc.CallOption) (*Empty, error)
+	Hget(ctx context.Context, in *HgetRequest, opts ...grpc.CallOption) (*Value, error)
+	Hmget(ctx context.Context, in *HmgetRequest, opts ...grpc.CallOption) (*Values, error)
+	Hincr(ctx context.Context, in *HincrRequest, opts ...grpc.CallOption) (*IntValue, error)
+	HgetInt(ctx context.Context, in *HgetRequest, opts ...grpc.CallOption) (*IntValue, error)
+	HhasKey(ctx context.Context, in *HgetRequest, opts ...grpc.CallOption) (*BoolValue, error)
+	Hdel(ctx context.Context, in *HdelRequest, opts ...grpc.CallOption) (*Empty, error)
+	HdelBucket(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*Empty, error)
+	// Hscan streams the fields of a hash in field order, optionally limited to a prefix
+	// or in reverse order.
+	Hscan(ctx context.Context, in *HscanRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HashEntry], error)
+	// Sorted set operations
+	Zadd(ctx context.Context, in *ZaddRequest, opts ...grpc.CallOption) (*Empty, error)
+	Zrem(ctx context.Context, in *ZremRequest, opts ...grpc.CallOption) (*Empty, error)
+	Zscore(ctx context.Context, in *ZscoreRequest, opts ...grpc.CallOption) (*ScoreValue, error)
+	Zcard(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*IntValue, error)
+	// Zrange streams the members of a sorted set between two ranks with their scores.
+	Zrange(ctx context.Context, in *ZrangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ZsetMember], error)
+	// ScanKeys streams the keys matching a glob pattern in lexicographical order.
+	ScanKeys(ctx context.Context, in *ScanKeysRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Key], error)
+}
+
+type jungleDBClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewJungleDBClient(cc grpc.ClientConnInterface) JungleDBClient {
+	return &jungleDBClient{cc}
+}
+
+func (c *jungleDBClient) Hset(ctx context.Context, in *HsetRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, JungleDB_Hset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) Hmset(ctx context.Context, in *HmsetRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, JungleDB_Hmset_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) Hget(ctx context.Context, in *HgetRequest, opts ...grpc.CallOption) (*Value, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Value)
+	err := c.cc.Invoke(ctx, JungleDB_Hget_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) Hmget(ctx context.Context, in *HmgetRequest, opts ...grpc.CallOption) (*Values, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Values)
+	err := c.cc.Invoke(ctx, JungleDB_Hmget_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) Hincr(ctx context.Context, in *HincrRequest, opts ...grpc.CallOption) (*IntValue, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IntValue)
+	err := c.cc.Invoke(ctx, JungleDB_Hincr_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) HgetInt(ctx context.Context, in *HgetRequest, opts ...grpc.CallOption) (*IntValue, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IntValue)
+	err := c.cc.Invoke(ctx, JungleDB_HgetInt_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) HhasKey(ctx context.Context, in *HgetRequest, opts ...grpc.CallOption) (*BoolValue, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BoolValue)
+	err := c.cc.Invoke(ctx, JungleDB_HhasKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) Hdel(ctx context.Context, in *HdelRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, JungleDB_Hdel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) HdelBucket(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, JungleDB_HdelBucket_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) Hscan(ctx context.Context, in *HscanRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HashEntry], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &JungleDB_ServiceDesc.Streams[0], JungleDB_Hscan_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[HscanRequest, HashEntry]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JungleDB_HscanClient = grpc.ServerStreamingClient[HashEntry]
+
+func (c *jungleDBClient) Zadd(ctx context.Context, in *ZaddRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, JungleDB_Zadd_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) Zrem(ctx context.Context, in *ZremRequest, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, JungleDB_Zrem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) Zscore(ctx context.Context, in *ZscoreRequest, opts ...grpc.CallOption) (*ScoreValue, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScoreValue)
+	err := c.cc.Invoke(ctx, JungleDB_Zscore_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) Zcard(ctx context.Context, in *KeyRequest, opts ...grpc.CallOption) (*IntValue, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(IntValue)
+	err := c.cc.Invoke(ctx, JungleDB_Zcard_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *jungleDBClient) Zrange(ctx context.Context, in *ZrangeRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ZsetMember], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &JungleDB_ServiceDesc.Streams[1], JungleDB_Zrange_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ZrangeRequest, ZsetMember]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JungleDB_ZrangeClient = grpc.ServerStreamingClient[ZsetMember]
+
+func (c *jungleDBClient) ScanKeys(ctx context.Context, in *ScanKeysRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Key], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &JungleDB_ServiceDesc.Streams[2], JungleDB_ScanKeys_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ScanKeysRequest, Key]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JungleDB_ScanKeysClient = grpc.ServerStreamingClient[Key]
+
+// JungleDBServer is the server API for JungleDB service.
+// All implementations must embed UnimplementedJungleDBServer
+// for forward compatibility.
+//
+// JungleDB exposes the hash and sorted set operations of a jungledb database.
+type JungleDBServer interface {
+	// Hash operations
+	Hset(context.Context, *HsetRequest) (*Empty, error)
+	Hmset(context.Context, *HmsetRequest) (*Empty, error)
+	Hget(context.Context, *HgetRequest) (*Value, error)
+	Hmget(context.Context, *HmgetRequest) (*Values, error)
+	Hincr(context.Context, *HincrRequest) (*IntValue, error)
+	HgetInt(context.Context, *HgetRequest) (*IntValue, error)
+	HhasKey(context.Context, *HgetRequest) (*BoolValue, error)
+	Hdel(context.Context, *HdelRequest) (*Empty, error)
+	HdelBucket(context.Context, *KeyRequest) (*Empty, error)
+	// Hscan streams the fields of a hash in field order, optionally limited to a prefix
+	// or in reverse order.
+	Hscan(*HscanRequest, grpc.ServerStreamingServer[HashEntry]) error
+	// Sorted set operations
+	Zadd(context.Context, *ZaddRequest) (*Empty, error)
+	Zrem(context.Context, *ZremRequest) (*Empty, error)
+	Zscore(context.Context, *ZscoreRequest) (*ScoreValue, error)
+	Zcard(context.Context, *KeyRequest) (*IntValue, error)
+	// Zrange streams the members of a sorted set between two ranks with their scores.
+	Zrange(*ZrangeRequest, grpc.ServerStreamingServer[ZsetMember]) error
+	// ScanKeys streams the keys matching a glob pattern in lexicographical order.
+	ScanKeys(*ScanKeysRequest, grpc.ServerStreamingServer[Key]) error
+	mustEmbedUnimplementedJungleDBServer()
+}
+
+// UnimplementedJungleDBServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedJungleDBServer struct{}
+
+func (UnimplementedJungleDBServer) Hset(context.Context, *HsetRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hset not implemented")
+}
+func (UnimplementedJungleDBServer) Hmset(context.Context, *HmsetRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hmset not implemented")
+}
+func (UnimplementedJungleDBServer) Hget(context.Context, *HgetRequest) (*Value, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hget not implemented")
+}
+func (UnimplementedJungleDBServer) Hmget(context.Context, *HmgetRequest) (*Values, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hmget not implemented")
+}
+func (UnimplementedJungleDBServer) Hincr(context.Context, *HincrRequest) (*IntValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hincr not implemented")
+}
+func (UnimplementedJungleDBServer) HgetInt(context.Context, *HgetRequest) (*IntValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HgetInt not implemented")
+}
+func (UnimplementedJungleDBServer) HhasKey(context.Context, *HgetRequest) (*BoolValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HhasKey not implemented")
+}
+func (UnimplementedJungleDBServer) Hdel(context.Context, *HdelRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Hdel not implemented")
+}
+func (UnimplementedJungleDBServer) HdelBucket(context.Context, *KeyRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HdelBucket not implemented")
+}
+func (UnimplementedJungleDBServer) Hscan(*HscanRequest, grpc.ServerStreamingServer[HashEntry]) error {
+	return status.Errorf(codes.Unimplemented, "method Hscan not implemented")
+}
+func (UnimplementedJungleDBServer) Zadd(context.Context, *ZaddRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Zadd not implemented")
+}
+func (UnimplementedJungleDBServer) Zrem(context.Context, *ZremRequest) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Zrem not implemented")
+}
+func (UnimplementedJungleDBServer) Zscore(context.Context, *ZscoreRequest) (*ScoreValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Zscore not implemented")
+}
+func (UnimplementedJungleDBServer) Zcard(context.Context, *KeyRequest) (*IntValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Zcard not implemented")
+}
+func (UnimplementedJungleDBServer) Zrange(*ZrangeRequest, grpc.ServerStreamingServer[ZsetMember]) error {
+	return status.Errorf(codes.Unimplemented, "method Zrange not implemented")
+}
+func (UnimplementedJungleDBServer) ScanKeys(*ScanKeysRequest, grpc.ServerStreamingServer[Key]) error {
+	return status.Errorf(codes.Unimplemented, "method ScanKeys not implemented")
+}
+func (UnimplementedJungleDBServer) mustEmbedUnimplementedJungleDBServer() {}
+func (UnimplementedJungleDBServer) testEmbeddedByValue()                  {}
+
+// UnsafeJungleDBServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to JungleDBServer will
+// result in compilation errors.
+type UnsafeJungleDBServer interface {
+	mustEmbedUnimplementedJungleDBServer()
+}
+
+func RegisterJungleDBServer(s grpc.ServiceRegistrar, srv JungleDBServer) {
+	// If the following call pancis, it indicates UnimplementedJungleDBServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&JungleDB_ServiceDesc, srv)
+}
+
+func _JungleDB_Hset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HsetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).Hset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_Hset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).Hset(ctx, req.(*HsetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_Hmset_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HmsetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).Hmset(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_Hmset_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).Hmset(ctx, req.(*HmsetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_Hget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HgetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).Hget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_Hget_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).Hget(ctx, req.(*HgetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_Hmget_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HmgetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).Hmget(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_Hmget_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).Hmget(ctx, req.(*HmgetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_Hincr_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HincrRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).Hincr(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_Hincr_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).Hincr(ctx, req.(*HincrRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_HgetInt_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HgetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).HgetInt(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_HgetInt_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).HgetInt(ctx, req.(*HgetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_HhasKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HgetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).HhasKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_HhasKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).HhasKey(ctx, req.(*HgetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_Hdel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HdelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).Hdel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_Hdel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).Hdel(ctx, req.(*HdelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_HdelBucket_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).HdelBucket(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_HdelBucket_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).HdelBucket(ctx, req.(*KeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_Hscan_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(HscanRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JungleDBServer).Hscan(m, &grpc.GenericServerStream[HscanRequest, HashEntry]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JungleDB_HscanServer = grpc.ServerStreamingServer[HashEntry]
+
+func _JungleDB_Zadd_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ZaddRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).Zadd(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_Zadd_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).Zadd(ctx, req.(*ZaddRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_Zrem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ZremRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).Zrem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_Zrem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).Zrem(ctx, req.(*ZremRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_Zscore_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ZscoreRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).Zscore(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_Zscore_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).Zscore(ctx, req.(*ZscoreRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_Zcard_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(KeyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(JungleDBServer).Zcard(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: JungleDB_Zcard_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(JungleDBServer).Zcard(ctx, req.(*KeyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _JungleDB_Zrange_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ZrangeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JungleDBServer).Zrange(m, &grpc.GenericServerStream[ZrangeRequest, ZsetMember]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JungleDB_ZrangeServer = grpc.ServerStreamingServer[ZsetMember]
+
+func _JungleDB_ScanKeys_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ScanKeysRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(JungleDBServer).ScanKeys(m, &grpc.GenericServerStream[ScanKeysRequest, Key]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type JungleDB_ScanKeysServer = grpc.ServerStreamingServer[Key]
+
+// JungleDB_ServiceDesc is the grpc.ServiceDesc for JungleDB service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var JungleDB_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "jungledb.v1.JungleDB",
+	HandlerType: (*JungleDBServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Hset",
+			Handler:    _JungleDB_Hset_Handler,
+		},
+		{
+			MethodName: "Hmset",
+			Handler:    _JungleDB_Hmset_Handler,
+		},
+		{
+			MethodName: "Hget",
+			Handler:    _JungleDB_Hget_Handler,
+		},
+		{
+			MethodName: "Hmget",
+			Handler:    _JungleDB_Hmget_Handler,
+		},
+		{
+			MethodName: "Hincr",
+			Handler:    _JungleDB_Hincr_Handler,
+		},
+		{
+			MethodName: "HgetInt",
+			Handler:    _JungleDB_HgetInt_Handler,
+		},
+		{
+			MethodName: "HhasKey",
+			Handler:    _JungleDB_HhasKey_Handler,
+		},
+		{
+			MethodName: "Hdel",
+			Handler:    _JungleDB_Hdel_Handler,
+		},
+		{
+			MethodName: "HdelBucket",
+			Handler:    _JungleDB_HdelBucket_Handler,
+		},
+		{
+			MethodName: "Zadd",
+			Handler:    _JungleDB_Zadd_Handler,
+		},
+		{
+			MethodName: "Zrem",
+			Handler:    _JungleDB_Zrem_Handler,
+		},
+		{
+			MethodName: "Zscore",
+			Handler:    _JungleDB_Zscore_Handler,
+		},
+		{
+			MethodName: "Zcard",
+			Handler:    _JungleDB_Zcard_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Hscan",
+			Handler:       _JungleDB_Hscan_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "Zrange",
+			Handler:       _JungleDB_Zrange_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ScanKeys",
+			Handler:       _JungleDB_ScanKeys_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "jungledb.proto",
+}
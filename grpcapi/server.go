@@ -0,0 +1,330 @@
+// Package grpcapi serves a jungledb database over gRPC.
+//
+// The service is defined in jungledb.proto; jungledb.pb.go and jungledb_grpc.pb.go are
+// generated from it with "buf generate" and provide both the server interface and the
+// client (NewJungleDBClient). NewServer wraps a *jungledb.DB as a JungleDBServer:
+//
+//	s := grpc.NewServer()
+//	grpcapi.RegisterJungleDBServer(s, grpcapi.NewServer(db))
+//	s.Serve(ln)
+//
+// Requests apply to the root namespace of the database unless their "x-jungledb-namespace"
+// metadata names another (see jungledb.DB.Namespace). When the database was opened with
+// jungledb.WithACL, requests must carry "authorization: Bearer <token>" metadata and fail
+// with Unauthenticated or PermissionDenied otherwise. For TLS, create the gRPC server with
+// grpc.Creds(credentials.NewTLS(cfg)), cfg coming for example from jungledb.LoadTLSConfig.
+package grpcapi
+
+//go:generate buf generate
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+
+	"github.com/ehebe/jungledb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// scanPageSize is how many keys ScanKeys reads from the database at a time.
+const scanPageSize = 256
+
+// namespaceKey is the metadata key selecting the namespace of a request.
+const namespaceKey = "x-jungledb-namespace"
+
+// Server implements JungleDBServer on top of a *jungledb.DB.
+type Server struct {
+	UnimplementedJungleDBServer
+	db *jungledb.DB
+}
+
+// NewServer returns a gRPC service backed by db.
+func NewServer(db *jungledb.DB) *Server {
+	return &Server{db: db}
+}
+
+// toStatus converts a database error into a gRPC status error.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	switch {
+	case errors.Is(err, jungledb.ErrKeyNotFound), errors.Is(err, jungledb.ErrFieldNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, jungledb.ErrKeyExists), errors.Is(err, jungledb.ErrUniqueViolation):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, jungledb.ErrWrongType), errors.Is(err, jungledb.ErrOverflow), errors.Is(err, jungledb.ErrReadOnly):
+		return status.Error(codes.FailedPrecondition, err.Error())
+	case errors.Is(err, jungledb.ErrReservedKey):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, jungledb.ErrQuotaExceeded):
+		return status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, jungledb.ErrClosed):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, jungledb.ErrUnauthenticated):
+		return status.Error(codes.Unauthenticated, err.Error())
+	case errors.Is(err, jungledb.ErrPermissionDenied):
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// authorize returns the database handle for a request, authorized with jungledb.DB.Authorize
+// from the "authorization" ("Bearer <token>") and "x-jungledb-namespace" metadata.
+func (s *Server) authorize(ctx context.Context, need jungledb.Access) (*jungledb.DB, error) {
+	var token, ns string
+	md, _ := metadata.FromIncomingContext(ctx)
+	if v := md.Get("authorization"); len(v) > 0 {
+		token, _ = strings.CutPrefix(v[0], "Bearer ")
+	}
+	if v := md.Get(namespaceKey); len(v) > 0 {
+		ns = v[0]
+	}
+	db, err := s.db.Authorize(token, ns, need)
+	if err != nil {
+		if errors.Is(err, jungledb.ErrUnauthenticated) || errors.Is(err, jungledb.ErrPermissionDenied) {
+			return nil, toStatus(err)
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return db, nil
+}
+
+func (s *Server) Hset(ctx context.Context, req *HsetRequest) (*Empty, error) {
+	db, err := s.authorize(ctx, jungledb.AccessReadWrite)
+	if err != nil {
+		return nil, err
+	}
+	value := req.Value
+	if value == nil {
+		value = []byte{}
+	}
+	return &Empty{}, toStatus(db.Hset(req.Key, req.Field, value))
+}
+
+func (s *Server) Hmset(ctx context.Context, req *HmsetRequest) (*Empty, error) {
+	db, err := s.authorize(ctx, jungledb.AccessReadWrite)
+	if err != nil {
+		return nil, err
+	}
+	return &Empty{}, toStatus(db.Hmset(req.Key, req.Fields))
+}
+
+func (s *Server) Hget(ctx context.Context, req *HgetRequest) (*Value, error) {
+	db, err := s.authorize(ctx, jungledb.AccessRead)
+	if err != nil {
+		return nil, err
+	}
+	value, err := db.Hget(req.Key, req.Field)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &Value{Value: value, Found: value != nil}, nil
+}
+
+func (s *Server) Hmget(ctx context.Context, req *HmgetRequest) (*Values, error) {
+	db, err := s.authorize(ctx, jungledb.AccessRead)
+	if err != nil {
+		return nil, err
+	}
+	values, err := db.Hmget(req.Key, req.Fields)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	resp := &Values{Values: make([]*Value, len(values))}
+	for i, value := range values {
+		resp.Values[i] = &Value{Value: value, Found: value != nil}
+	}
+	return resp, nil
+}
+
+func (s *Server) Hincr(ctx context.Context, req *HincrRequest) (*IntValue, error) {
+	db, err := s.authorize(ctx, jungledb.AccessReadWrite)
+	if err != nil {
+		return nil, err
+	}
+	n, err := db.Hincr(req.Key, req.Field, req.Delta)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &IntValue{Value: n}, nil
+}
+
+func (s *Server) HgetInt(ctx context.Context, req *HgetRequest) (*IntValue, error) {
+	db, err := s.authorize(ctx, jungledb.AccessRead)
+	if err != nil {
+		return nil, err
+	}
+	n, err := db.HgetInt(req.Key, req.Field)
+	if err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return &IntValue{Value: n}, nil
+}
+
+func (s *Server) HhasKey(ctx context.Context, req *HgetRequest) (*BoolValue, error) {
+	db, err := s.authorize(ctx, jungledb.AccessRead)
+	if err != nil {
+		return nil, err
+	}
+	exists, err := db.HhasKey(req.Key, req.Field)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &BoolValue{Value: exists}, nil
+}
+
+func (s *Server) Hdel(ctx context.Context, req *HdelRequest) (*Empty, error) {
+	db, err := s.authorize(ctx, jungledb.AccessReadWrite)
+	if err != nil {
+		return nil, err
+	}
+	return &Empty{}, toStatus(db.Hmdel(req.Key, req.Fields))
+}
+
+func (s *Server) HdelBucket(ctx context.Context, req *KeyRequest) (*Empty, error) {
+	db, err := s.authorize(ctx, jungledb.AccessReadWrite)
+	if err != nil {
+		return nil, err
+	}
+	return &Empty{}, toStatus(db.HdelBucket(req.Key))
+}
+
+// Hscan streams the hash in field order (or reverse order), optionally filtered by prefix.
+func (s *Server) Hscan(req *HscanRequest, stream grpc.ServerStreamingServer[HashEntry]) error {
+	db, err := s.authorize(stream.Context(), jungledb.AccessRead)
+	if err != nil {
+		return err
+	}
+	var fields map[string][]byte
+	if req.Prefix != "" {
+		fields, err = db.Hprefix(req.Key, req.Prefix)
+	} else {
+		fields, err = db.Hscan(req.Key)
+	}
+	if err != nil {
+		return toStatus(err)
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if req.Reverse {
+		sort.Sort(sort.Reverse(sort.StringSlice(names)))
+	}
+
+	for _, name := range names {
+		if err := stream.Send(&HashEntry{Field: name, Value: fields[name]}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) Zadd(ctx context.Context, req *ZaddRequest) (*Empty, error) {
+	db, err := s.authorize(ctx, jungledb.AccessReadWrite)
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range req.Members {
+		if err := db.Zadd(req.Key, m.Score, m.Member); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	return &Empty{}, nil
+}
+
+func (s *Server) Zrem(ctx context.Context, req *ZremRequest) (*Empty, error) {
+	db, err := s.authorize(ctx, jungledb.AccessReadWrite)
+	if err != nil {
+		return nil, err
+	}
+	for _, member := range req.Members {
+		if err := db.Zrem(req.Key, member); err != nil {
+			return nil, toStatus(err)
+		}
+	}
+	return &Empty{}, nil
+}
+
+func (s *Server) Zscore(ctx context.Context, req *ZscoreRequest) (*ScoreValue, error) {
+	db, err := s.authorize(ctx, jungledb.AccessRead)
+	if err != nil {
+		return nil, err
+	}
+	score, err := db.Zscore(req.Key, req.Member)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &ScoreValue{Score: score}, nil
+}
+
+func (s *Server) Zcard(ctx context.Context, req *KeyRequest) (*IntValue, error) {
+	db, err := s.authorize(ctx, jungledb.AccessRead)
+	if err != nil {
+		return nil, err
+	}
+	n, err := db.Zcard(req.Key)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &IntValue{Value: int64(n)}, nil
+}
+
+// Zrange streams members between the start and stop ranks together with their scores.
+func (s *Server) Zrange(req *ZrangeRequest, stream grpc.ServerStreamingServer[ZsetMember]) error {
+	db, err := s.authorize(stream.Context(), jungledb.AccessRead)
+	if err != nil {
+		return err
+	}
+	var members []string
+	if req.Reverse {
+		members, err = db.Zrevrange(req.Key, int(req.Start), int(req.Stop))
+	} else {
+		members, err = db.Zrange(req.Key, int(req.Start), int(req.Stop))
+	}
+	if err != nil {
+		return toStatus(err)
+	}
+
+	for _, member := range members {
+		score, err := db.Zscore(req.Key, member)
+		if err != nil {
+			return toStatus(err)
+		}
+		if err := stream.Send(&ZsetMember{Member: member, Score: score}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanKeys streams matching keys, reading them from the database a page at a time.
+func (s *Server) ScanKeys(req *ScanKeysRequest, stream grpc.ServerStreamingServer[Key]) error {
+	db, err := s.authorize(stream.Context(), jungledb.AccessRead)
+	if err != nil {
+		return err
+	}
+	cursor := ""
+	for {
+		keys, next, err := db.ListKeys(req.Pattern, cursor, scanPageSize)
+		if err != nil {
+			return toStatus(err)
+		}
+		for _, key := range keys {
+			if err := stream.Send(&Key{Key: key}); err != nil {
+				return err
+			}
+		}
+		if next == "" {
+			return nil
+		}
+		cursor = next
+	}
+}
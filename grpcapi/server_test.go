@@ -0,0 +1,212 @@
+package grpcapi
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/ehebe/jungledb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// TestMain cleans up test files before and after running tests.
+func TestMain(m *testing.M) {
+	os.RemoveAll("testdata")
+	os.MkdirAll("testdata", 0755)
+
+	code := m.Run()
+
+	os.RemoveAll("testdata")
+	os.Exit(code)
+}
+
+// TestServer tests the gRPC service through the generated client.
+func TestServer(t *testing.T) {
+	db, err := jungledb.Open("testdata/grpc.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	RegisterJungleDBServer(srv, NewServer(db))
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer conn.Close()
+	client := NewJungleDBClient(conn)
+	ctx := context.Background()
+
+	// Hashes
+	if _, err := client.Hmset(ctx, &HmsetRequest{Key: "user:1", Fields: map[string][]byte{"a": []byte("1"), "b": []byte("2"), "c": []byte("3")}}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	v, err := client.Hget(ctx, &HgetRequest{Key: "user:1", Field: "b"})
+	if err != nil {
+		t.Fatalf("Hget failed: %v", err)
+	}
+	if !v.Found || string(v.Value) != "2" {
+		t.Errorf("Hget mismatch: got %v", v)
+	}
+	vs, err := client.Hmget(ctx, &HmgetRequest{Key: "user:1", Fields: []string{"a", "zz"}})
+	if err != nil {
+		t.Fatalf("Hmget failed: %v", err)
+	}
+	if len(vs.Values) != 2 || !vs.Values[0].Found || vs.Values[1].Found {
+		t.Errorf("Hmget mismatch: got %v", vs.Values)
+	}
+	n, err := client.Hincr(ctx, &HincrRequest{Key: "counters", Field: "hits", Delta: 4})
+	if err != nil {
+		t.Fatalf("Hincr failed: %v", err)
+	}
+	if n.Value != 4 {
+		t.Errorf("Hincr mismatch: expected 4, got %d", n.Value)
+	}
+
+	stream, err := client.Hscan(ctx, &HscanRequest{Key: "user:1", Reverse: true})
+	if err != nil {
+		t.Fatalf("Hscan failed: %v", err)
+	}
+	var fields []string
+	for {
+		entry, err := stream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Hscan stream failed: %v", err)
+		}
+		fields = append(fields, entry.Field)
+	}
+	if len(fields) != 3 || fields[0] != "c" || fields[2] != "a" {
+		t.Errorf("Hscan order mismatch: got %v", fields)
+	}
+
+	// Sorted sets
+	members := []*ZsetMember{{Member: "x", Score: 3}, {Member: "y", Score: 1}, {Member: "z", Score: 2}}
+	if _, err := client.Zadd(ctx, &ZaddRequest{Key: "ranking", Members: members}); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	zstream, err := client.Zrange(ctx, &ZrangeRequest{Key: "ranking", Start: 0, Stop: -1})
+	if err != nil {
+		t.Fatalf("Zrange failed: %v", err)
+	}
+	var ranked []*ZsetMember
+	for {
+		m, err := zstream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("Zrange stream failed: %v", err)
+		}
+		ranked = append(ranked, m)
+	}
+	if len(ranked) != 3 || ranked[0].Member != "y" || ranked[0].Score != 1 || ranked[2].Member != "x" {
+		t.Errorf("Zrange mismatch: got %v", ranked)
+	}
+	card, err := client.Zcard(ctx, &KeyRequest{Key: "ranking"})
+	if err != nil {
+		t.Fatalf("Zcard failed: %v", err)
+	}
+	if card.Value != 3 {
+		t.Errorf("Zcard mismatch: expected 3, got %d", card.Value)
+	}
+
+	// Keys
+	kstream, err := client.ScanKeys(ctx, &ScanKeysRequest{Pattern: "*r*"})
+	if err != nil {
+		t.Fatalf("ScanKeys failed: %v", err)
+	}
+	var keys []string
+	for {
+		k, err := kstream.Recv()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("ScanKeys stream failed: %v", err)
+		}
+		keys = append(keys, k.Key)
+	}
+	if len(keys) != 3 || keys[0] != "counters" || keys[1] != "ranking" || keys[2] != "user:1" {
+		t.Errorf("ScanKeys mismatch: got %v", keys)
+	}
+
+	// Errors carry gRPC status codes
+	_, err = client.HdelBucket(ctx, &KeyRequest{Key: "missing"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("HdelBucket of missing key: expected NotFound, got %v", err)
+	}
+}
+
+// TestServerACL tests that the service authenticates bearer tokens from the request
+// metadata and applies the namespace metadata and the permissions of the ACL.
+func TestServerACL(t *testing.T) {
+	acl, err := jungledb.NewACL(jungledb.ACLUser{Name: "reports", Token: "s3cret",
+		Namespaces: map[string]jungledb.Access{"": jungledb.AccessRead, "reports": jungledb.AccessReadWrite}})
+	if err != nil {
+		t.Fatalf("NewACL failed: %v", err)
+	}
+	db, err := jungledb.Open("testdata/grpc-acl.db", jungledb.WithACL(acl))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Hset("user:1", "name", []byte("Alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	ln := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	RegisterJungleDBServer(srv, NewServer(db))
+	go srv.Serve(ln)
+	defer srv.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	defer conn.Close()
+	client := NewJungleDBClient(conn)
+	anonymous := context.Background()
+	root := metadata.AppendToOutgoingContext(anonymous, "authorization", "Bearer s3cret")
+	reports := metadata.AppendToOutgoingContext(root, "x-jungledb-namespace", "reports")
+
+	if _, err := client.Hget(anonymous, &HgetRequest{Key: "user:1", Field: "name"}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Hget without token: expected Unauthenticated, got %v", err)
+	}
+	if v, err := client.Hget(root, &HgetRequest{Key: "user:1", Field: "name"}); err != nil || string(v.Value) != "Alice" {
+		t.Errorf("Hget mismatch: got %v %v", v, err)
+	}
+	if _, err := client.Hset(root, &HsetRequest{Key: "user:1", Field: "name", Value: []byte("Bob")}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Hset in read-only namespace: expected PermissionDenied, got %v", err)
+	}
+	if _, err := client.Hset(reports, &HsetRequest{Key: "user:1", Field: "name", Value: []byte("Carol")}); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if v, err := client.Hget(reports, &HgetRequest{Key: "user:1", Field: "name"}); err != nil || string(v.Value) != "Carol" {
+		t.Errorf("namespaced Hget mismatch: got %v %v", v, err)
+	}
+
+	stream, err := client.ScanKeys(anonymous, &ScanKeysRequest{Pattern: "*"})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("ScanKeys without token: expected Unauthenticated, got %v", err)
+	}
+}
@@ -0,0 +1,168 @@
+package jungledb
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// heatmapSlots is how many slots the window of WithWriteHeatmap is divided into; the
+	// window slides by one slot at a time.
+	heatmapSlots = 12
+
+	// maxHeatmapKeys bounds how many keys WithWriteHeatmap tracks.
+	maxHeatmapKeys = 10000
+)
+
+// WithWriteHeatmap counts the write transactions to each key over a sliding window of
+// the given length, one minute if zero, so that HotKeys can tell which keys are written
+// the most. At most 10000 keys are tracked: once that many have been written during the
+// window, a new key replaces the least written one, inheriting its count, so counts may
+// then be overestimated but heavily written keys are not missed.
+func WithWriteHeatmap(window time.Duration) Option {
+	return func(o *options) {
+		if window <= 0 {
+			window = time.Minute
+		}
+		o.heatmapWindow = window
+	}
+}
+
+// KeyWrites is the write rate of a key, see HotKeys.
+type KeyWrites struct {
+	Key       string
+	Writes    uint64  // Write transactions to the key during the window
+	PerSecond float64 // Writes divided by the length of the window
+}
+
+// keyHeat counts the writes to a key by slot of the window.
+type keyHeat struct {
+	counts [heatmapSlots]uint64
+	slots  [heatmapSlots]int64 // Slot each count belongs to
+}
+
+// add counts n writes during slot.
+func (h *keyHeat) add(slot int64, n uint64) {
+	i := slot % heatmapSlots
+	if h.slots[i] != slot {
+		h.slots[i], h.counts[i] = slot, 0
+	}
+	h.counts[i] += n
+}
+
+// total returns the writes during the window ending with slot.
+func (h *keyHeat) total(slot int64) uint64 {
+	var n uint64
+	for i, s := range h.slots {
+		if s > slot-heatmapSlots && s <= slot {
+			n += h.counts[i]
+		}
+	}
+	return n
+}
+
+// heatmap holds the write counts of WithWriteHeatmap, by bucket name.
+type heatmap struct {
+	mu   sync.Mutex
+	keys map[string]*keyHeat
+}
+
+// heatSlot returns the slot of the window of WithWriteHeatmap that t falls in.
+func (db *DB) heatSlot(t time.Time) int64 {
+	return t.UnixNano() / int64(db.opts.heatmapWindow/heatmapSlots)
+}
+
+// recordWrites counts a write to each key changed by a committed transaction.
+func (db *DB) recordWrites(events []Event) {
+	if db.opts.heatmapWindow == 0 || len(events) == 0 {
+		return
+	}
+	slot := db.heatSlot(db.now())
+	m := &db.heatmap
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.keys == nil {
+		m.keys = make(map[string]*keyHeat)
+	}
+	written := make(map[string]bool, 1)
+	for _, ev := range events {
+		for _, name := range []string{ev.Key, ev.Target} {
+			if name == "" || written[name] {
+				continue
+			}
+			written[name] = true
+			m.heat(name, slot).add(slot, 1)
+		}
+	}
+}
+
+// heat returns the counts of the key stored under name, making room for it if needed.
+// It is called with m.mu held.
+func (m *heatmap) heat(name string, slot int64) *keyHeat {
+	if h := m.keys[name]; h != nil {
+		return h
+	}
+	if len(m.keys) >= maxHeatmapKeys {
+		for name, h := range m.keys {
+			if h.total(slot) == 0 {
+				delete(m.keys, name)
+			}
+		}
+	}
+	h := &keyHeat{}
+	if len(m.keys) >= maxHeatmapKeys {
+		var coldest string
+		var least uint64
+		for name, h := range m.keys {
+			if n := h.total(slot); coldest == "" || n < least {
+				coldest, least = name, n
+			}
+		}
+		delete(m.keys, coldest)
+		h.add(slot, least)
+	}
+	m.keys[name] = h
+	return h
+}
+
+// HotKeys returns the n keys written the most during the window of WithWriteHeatmap,
+// most written first; n <= 0 returns every key written during the window. Without
+// WithWriteHeatmap it returns nothing. Unlike WithHotKeys, which keeps chosen keys in
+// memory, it only reports.
+func (db *DB) HotKeys(n int) []KeyWrites {
+	window := db.opts.heatmapWindow
+	if window == 0 {
+		return nil
+	}
+	slot := db.heatSlot(db.now())
+	var hot []KeyWrites
+	m := &db.heatmap
+	m.mu.Lock()
+	for name, h := range m.keys {
+		writes := h.total(slot)
+		if writes == 0 {
+			delete(m.keys, name) // Not written during the window
+			continue
+		}
+		key, ok := db.userKey(name)
+		if !ok {
+			continue
+		}
+		hot = append(hot, KeyWrites{Key: key, Writes: writes, PerSecond: float64(writes) / window.Seconds()})
+	}
+	m.mu.Unlock()
+
+	slices.SortFunc(hot, func(a, b KeyWrites) int {
+		if c := cmp.Compare(b.Writes, a.Writes); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Key, b.Key)
+	})
+	if n > 0 && len(hot) > n {
+		hot = hot[:n]
+	}
+	return hot
+}
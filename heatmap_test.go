@@ -0,0 +1,63 @@
+package jungledb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestWriteHeatmap tests that HotKeys ranks keys by their writes during the window.
+func TestWriteHeatmap(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	db, err := Open("testdata/heatmap.db", WithWriteHeatmap(time.Minute), WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for range 5 {
+		if _, err := db.Hincr("busy", "n", 1); err != nil {
+			t.Fatalf("Hincr failed: %v", err)
+		}
+	}
+	// A write to several fields counts once
+	if err := db.Hmset("quiet", map[string][]byte{"a": []byte("1"), "b": []byte("2")}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	if err := db.Namespace("tenant").Hset("busy", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	want := []KeyWrites{{Key: "busy", Writes: 5, PerSecond: 5.0 / 60}, {Key: "quiet", Writes: 1, PerSecond: 1.0 / 60}}
+	if got := db.HotKeys(0); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+	if got := db.HotKeys(1); len(got) != 1 || got[0].Key != "busy" {
+		t.Errorf("expected only the hottest key, got %v", got)
+	}
+	if got := db.Namespace("tenant").HotKeys(0); len(got) != 1 || got[0].Key != "busy" || got[0].Writes != 1 {
+		t.Errorf("expected the namespace's own key, got %v", got)
+	}
+
+	// Writes slide out of the window
+	clock.advance(45 * time.Second)
+	if err := db.Hset("quiet", "a", []byte("3")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	clock.advance(30 * time.Second)
+	if got := db.HotKeys(0); len(got) != 1 || got[0].Key != "quiet" || got[0].Writes != 1 {
+		t.Errorf("expected only the recent write, got %v", got)
+	}
+
+	plain, err := Open("testdata/heatmap_off.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer plain.Close()
+	if err := plain.Hset("a", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if got := plain.HotKeys(0); got != nil {
+		t.Errorf("expected nothing without WithWriteHeatmap, got %v", got)
+	}
+}
@@ -0,0 +1,280 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// historyBucket holds the versions of the fields of versioned keys: a bucket per key,
+// holding a bucket per field, holding the versions by number (8-byte big-endian) as the
+// time of the write (8-byte Unix nanoseconds), a deleted flag byte and the value.
+const historyBucket = internalPrefix + "history"
+
+// ErrVersionNotFound is returned by Hrollback for a version that does not exist or was
+// dropped from the history.
+var ErrVersionNotFound = errors.New("version not found")
+
+// FieldVersion is a value a hash field held, as returned by Hhistory.
+type FieldVersion struct {
+	Version uint64    // Increases with every change of the field
+	Time    time.Time // When the value was written
+	Value   []byte
+	Deleted bool // Whether the field was deleted rather than set, Value being nil
+}
+
+// WithVersionedKeys keeps the history of the fields of the hashes whose keys start with one
+// of prefixes, in every namespace: each committed transaction that changes a field, or
+// deletes it or its hash, adds a version of it, and the depth versions preceding the
+// current one are kept. Versions follow their hash when it is renamed; they are kept when
+// it is deleted, so that it can be restored with Hrollback.
+func WithVersionedKeys(depth int, prefixes ...string) Option {
+	return func(o *options) {
+		o.historyDepth = depth
+		o.historyPrefixes = append(o.historyPrefixes, prefixes...)
+	}
+}
+
+// Hhistory returns the last n versions of a field of a versioned hash, newest first, the
+// first being the current value if the field exists. With n <= 0 it returns all of them.
+func (db *DB) Hhistory(key, field string, n int) ([]FieldVersion, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
+	var versions []FieldVersion
+	err := db.view("Hhistory", key, func(tx *bbolt.Tx) error {
+		bucket := historyOf(tx, key, field)
+		if bucket == nil {
+			return nil
+		}
+		c := bucket.Cursor()
+		for k, v := c.Last(); k != nil && (n <= 0 || len(versions) < n); k, v = c.Prev() {
+			version, err := decodeVersion(k, v)
+			if err != nil {
+				return err
+			}
+			versions = append(versions, version)
+		}
+		return nil
+	})
+	return versions, err
+}
+
+// Hrollback restores a field of a versioned hash to the value it held at version, which
+// adds a new version, or deletes it if it was deleted then.
+func (db *DB) Hrollback(key, field string, version uint64) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("Hrollback", key, func(tx *txn) error {
+		var v []byte
+		if bucket := historyOf(tx.Tx, key, field); bucket != nil {
+			v = bucket.Get(encodeSeq(version))
+		}
+		if v == nil {
+			return fmt.Errorf("%w: %s %s version %d", ErrVersionNotFound, key, field, version)
+		}
+		old, err := decodeVersion(encodeSeq(version), v)
+		if err != nil {
+			return err
+		}
+		if old.Deleted {
+			return hdel(tx, key, field)
+		}
+		return hset(tx, key, field, old.Value)
+	})
+}
+
+// updateHistory adds versions for the fields of versioned keys changed by tx.
+func (db *DB) updateHistory(tx *txn) error {
+	if len(db.opts.historyPrefixes) == 0 {
+		return nil
+	}
+	type field struct{ key, field string }
+	var changed []field
+	seen := make(map[field]bool)
+	add := func(f field) {
+		if !seen[f] {
+			seen[f] = true
+			changed = append(changed, f)
+		}
+	}
+	for _, ev := range tx.events {
+		if !db.versioned(tx.Tx, ev.Key) && (ev.Target == "" || !db.versioned(tx.Tx, ev.Target)) {
+			continue
+		}
+		switch ev.Type {
+		case EventHset, EventHdel:
+			add(field{ev.Key, ev.Field})
+		case EventRename:
+			if db.versioned(tx.Tx, ev.Target) {
+				if err := moveHistory(tx.Tx, ev.Key, ev.Target); err != nil {
+					return err
+				}
+			}
+			fallthrough
+		case EventDelete, EventExpired, EventEvicted, EventCopy:
+			// Every field with a history may have changed, and those of the target too
+			for _, key := range []string{ev.Key, ev.Target} {
+				if key == "" || !db.versioned(tx.Tx, key) {
+					continue
+				}
+				if bucket := historyOf(tx.Tx, key, ""); bucket != nil {
+					bucket.ForEachBucket(func(k []byte) error {
+						add(field{key, string(k)})
+						return nil
+					})
+				}
+				if hash := tx.Bucket([]byte(key)); hash != nil && keyType(tx.Tx, []byte(key)) == typeHash {
+					hash.ForEach(func(k, _ []byte) error {
+						add(field{key, string(k)})
+						return nil
+					})
+				}
+			}
+		}
+	}
+
+	now := db.now().UnixNano()
+	for _, f := range changed {
+		value, exists := []byte(nil), false
+		if hash := tx.Bucket([]byte(f.key)); hash != nil && keyType(tx.Tx, []byte(f.key)) == typeHash {
+			value, exists = getField(hash, f.field)
+		}
+		if err := db.addVersion(tx.Tx, f.key, f.field, now, value, exists); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// versioned reports whether the key name keeps a history.
+func (db *DB) versioned(tx *bbolt.Tx, name string) bool {
+	_, key, ok := splitKey(name)
+	if !ok || isInternalBucket(tx, []byte(name)) {
+		return false
+	}
+	for _, prefix := range db.opts.historyPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// addVersion records the current value of a field, unless it is that of its last version,
+// and drops the versions beyond the configured depth.
+func (db *DB) addVersion(tx *bbolt.Tx, key, field string, now int64, value []byte, exists bool) error {
+	bucket := historyOf(tx, key, field)
+	if bucket == nil {
+		if !exists {
+			return nil // Nothing to record for a field never seen
+		}
+		root, err := tx.CreateBucketIfNotExists([]byte(historyBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create history bucket: %v", err)
+		}
+		keyBucket, err := root.CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return err
+		}
+		if bucket, err = keyBucket.CreateBucket([]byte(field)); err != nil {
+			return err
+		}
+	}
+
+	seq := uint64(1)
+	if k, v := bucket.Cursor().Last(); k != nil {
+		last, err := decodeVersion(k, v)
+		if err != nil {
+			return err
+		}
+		if last.Deleted == !exists && bytes.Equal(last.Value, value) {
+			return nil
+		}
+		seq = last.Version + 1
+	}
+	record := binary.BigEndian.AppendUint64(nil, uint64(now))
+	if exists {
+		record = append(append(record, 0), value...)
+	} else {
+		record = append(record, 1)
+	}
+	if err := bucket.Put(encodeSeq(seq), record); err != nil {
+		return err
+	}
+
+	// Keep the current version and depth older ones
+	var old [][]byte
+	kept := 0
+	c := bucket.Cursor()
+	for k, _ := c.Last(); k != nil; k, _ = c.Prev() {
+		if kept <= db.opts.historyDepth {
+			kept++
+		} else {
+			old = append(old, k)
+		}
+	}
+	for _, k := range old {
+		if err := bucket.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// moveHistory moves the history of the key src to dst, replacing that of dst.
+func moveHistory(tx *bbolt.Tx, src, dst string) error {
+	root := tx.Bucket([]byte(historyBucket))
+	if root == nil || root.Bucket([]byte(src)) == nil {
+		return nil
+	}
+	if err := root.DeleteBucket([]byte(dst)); err != nil && !errors.Is(err, bbolt.ErrBucketNotFound) {
+		return err
+	}
+	bucket, err := root.CreateBucket([]byte(dst))
+	if err != nil {
+		return err
+	}
+	if err := copyBucket(root.Bucket([]byte(src)), bucket); err != nil {
+		return fmt.Errorf("failed to move history: %v", err)
+	}
+	return root.DeleteBucket([]byte(src))
+}
+
+// historyOf returns the history bucket of a field of the key name, or of the key itself if
+// field is empty, nil if there is none.
+func historyOf(tx *bbolt.Tx, name, field string) *bbolt.Bucket {
+	root := tx.Bucket([]byte(historyBucket))
+	if root == nil {
+		return nil
+	}
+	bucket := root.Bucket([]byte(name))
+	if bucket == nil || field == "" {
+		return bucket
+	}
+	return bucket.Bucket([]byte(field))
+}
+
+// decodeVersion decodes a version stored by addVersion.
+func decodeVersion(k, v []byte) (FieldVersion, error) {
+	if len(k) != 8 || len(v) < 9 {
+		return FieldVersion{}, errors.New("corrupt field version")
+	}
+	version := FieldVersion{
+		Version: binary.BigEndian.Uint64(k),
+		Time:    time.Unix(0, int64(binary.BigEndian.Uint64(v))),
+		Deleted: v[8] == 1,
+	}
+	if !version.Deleted {
+		version.Value = bytes.Clone(v[9:])
+	}
+	return version, nil
+}
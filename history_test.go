@@ -0,0 +1,76 @@
+package jungledb
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestHistory tests that versioned hashes keep the configured number of versions, which
+// can be restored, and that other hashes keep none.
+func TestHistory(t *testing.T) {
+	db, err := Open("testdata/history.db", WithVersionedKeys(2, "config:"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, v := range []string{"1", "2", "2", "3", "4"} {
+		if err := db.Hset("config:app", "replicas", []byte(v)); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	versions, err := db.Hhistory("config:app", "replicas", 0)
+	if err != nil {
+		t.Fatalf("Hhistory failed: %v", err)
+	}
+	var values []string
+	for _, v := range versions {
+		values = append(values, string(v.Value))
+	}
+	if len(versions) != 3 || values[0] != "4" || values[1] != "3" || values[2] != "2" || versions[0].Version != 4 {
+		t.Fatalf("expected versions 4, 3 and 2 of the field, got %+v", versions)
+	}
+	if versions, err := db.Hhistory("config:app", "replicas", 1); err != nil || len(versions) != 1 {
+		t.Errorf("expected 1 version, got %+v %v", versions, err)
+	}
+
+	if err := db.Hrollback("config:app", "replicas", 2); err != nil {
+		t.Fatalf("Hrollback failed: %v", err)
+	}
+	if v, err := db.Hget("config:app", "replicas"); err != nil || string(v) != "2" {
+		t.Errorf("expected 2 after the rollback, got %q %v", v, err)
+	}
+	if err := db.Hrollback("config:app", "replicas", 1); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("expected ErrVersionNotFound for a dropped version, got %v", err)
+	}
+
+	// Deleting the hash is a version, which can be rolled back
+	if err := db.HdelBucket("config:app"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	versions, err = db.Hhistory("config:app", "replicas", 2)
+	if err != nil || len(versions) != 2 || !versions[0].Deleted || string(versions[1].Value) != "2" {
+		t.Fatalf("expected a deleted version after the rollback, got %+v %v", versions, err)
+	}
+	if err := db.Hrollback("config:app", "replicas", versions[1].Version); err != nil {
+		t.Fatalf("Hrollback failed: %v", err)
+	}
+
+	// History follows renames
+	if err := db.Rename("config:app", "config:web"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if versions, err := db.Hhistory("config:web", "replicas", 0); err != nil || len(versions) != 3 || string(versions[0].Value) != "2" {
+		t.Errorf("expected the history to follow the rename, got %+v %v", versions, err)
+	}
+	if versions, err := db.Hhistory("config:app", "replicas", 0); err != nil || len(versions) != 0 {
+		t.Errorf("expected no history left for the old name, got %+v %v", versions, err)
+	}
+
+	if err := db.Hset("user:1", "name", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if versions, err := db.Hhistory("user:1", "name", 0); err != nil || len(versions) != 0 {
+		t.Errorf("expected no history for a key that is not versioned, got %+v %v", versions, err)
+	}
+}
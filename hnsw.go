@@ -0,0 +1,373 @@
+package jungledb
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"go.etcd.io/bbolt"
+)
+
+// hnswPrefix starts the names of the buckets holding the HNSW graph of a vector key,
+// followed by the key. Each has a "nodes" bucket mapping the ids of the vectors to their
+// JSON hnswNode and an "entry" key holding the id of the node searches start from.
+const hnswPrefix = internalPrefix + "hnsw:"
+
+var (
+	hnswNodesKey = []byte("nodes")
+	hnswEntryKey = []byte("entry")
+)
+
+// hnswMaxLevel caps the level of the graph nodes.
+const hnswMaxLevel = 16
+
+// hnswNode is a vector in an HNSW graph.
+type hnswNode struct {
+	Level int        `json:"level"`
+	Links [][]string `json:"links"` // Ids of the neighbors on each layer, from the bottom
+}
+
+// hnswCandidate is a vector considered by a graph search.
+type hnswCandidate struct {
+	id   string
+	dist float64
+}
+
+// hnsw reads and updates the graph of the vectors of a key within a transaction.
+type hnsw struct {
+	vectors *bbolt.Bucket // Hash holding the vectors, nil once the key is gone
+	index   *bbolt.Bucket
+	nodes   *bbolt.Bucket
+	metric  Metric
+	m       int
+	efBuild int
+	ef      int
+	cache   map[string][]float32
+}
+
+// newHNSW returns the graph stored in the index bucket for the vectors of a hash.
+func (db *DB) newHNSW(vectors, index *bbolt.Bucket) (*hnsw, error) {
+	nodes := index.Bucket(hnswNodesKey)
+	if nodes == nil {
+		return nil, fmt.Errorf("corrupt vector index: no nodes bucket")
+	}
+	opts := db.opts.vectors
+	h := &hnsw{vectors: vectors, index: index, nodes: nodes, metric: opts.Metric, m: opts.M, efBuild: opts.EfConstruction, ef: opts.EfSearch, cache: make(map[string][]float32)}
+	if h.m <= 0 {
+		h.m = 16
+	}
+	if h.efBuild <= 0 {
+		h.efBuild = 200
+	}
+	if h.ef <= 0 {
+		h.ef = 64
+	}
+	return h, nil
+}
+
+// vector returns the vector stored under id.
+func (h *hnsw) vector(id string) ([]float32, bool) {
+	if vec, ok := h.cache[id]; ok {
+		return vec, true
+	}
+	if h.vectors == nil {
+		return nil, false
+	}
+	v, ok := getField(h.vectors, id)
+	if !ok {
+		return nil, false
+	}
+	vec, ok := decodeVector(v)
+	if ok {
+		h.cache[id] = vec
+	}
+	return vec, ok
+}
+
+// distance returns the distance from q to the vector stored under id, or false if it is
+// missing or has another dimension.
+func (h *hnsw) distance(q []float32, id string) (float64, bool) {
+	vec, ok := h.vector(id)
+	if !ok || len(vec) != len(q) {
+		return 0, false
+	}
+	return h.metric.distance(q, vec), true
+}
+
+func (h *hnsw) node(id string) (*hnswNode, error) {
+	data := h.nodes.Get([]byte(id))
+	if data == nil {
+		return nil, nil
+	}
+	var n hnswNode
+	if err := json.Unmarshal(data, &n); err != nil {
+		return nil, fmt.Errorf("failed to decode vector index node: %v", err)
+	}
+	return &n, nil
+}
+
+func (h *hnsw) putNode(id string, n *hnswNode) error {
+	data, err := json.Marshal(n)
+	if err != nil {
+		return err
+	}
+	return h.nodes.Put([]byte(id), data)
+}
+
+// searchLayer returns up to ef of the nodes nearest to q on a layer, nearest first,
+// starting from entries.
+func (h *hnsw) searchLayer(q []float32, entries []hnswCandidate, ef, layer int) ([]hnswCandidate, error) {
+	visited := make(map[string]bool)
+	var queue, found []hnswCandidate
+	for _, c := range entries {
+		visited[c.id] = true
+		queue = insertCandidate(queue, c)
+		found = insertCandidate(found, c)
+	}
+	for len(found) > ef {
+		found = found[:ef]
+	}
+
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		if len(found) >= ef && c.dist > found[len(found)-1].dist {
+			break
+		}
+		n, err := h.node(c.id)
+		if err != nil {
+			return nil, err
+		}
+		if n == nil || layer >= len(n.Links) {
+			continue
+		}
+		for _, id := range n.Links[layer] {
+			if visited[id] {
+				continue
+			}
+			visited[id] = true
+			d, ok := h.distance(q, id)
+			if !ok {
+				continue // Removed without unlinking
+			}
+			if len(found) < ef || d < found[len(found)-1].dist {
+				queue = insertCandidate(queue, hnswCandidate{id, d})
+				found = insertCandidate(found, hnswCandidate{id, d})
+				if len(found) > ef {
+					found = found[:ef]
+				}
+			}
+		}
+	}
+	return found, nil
+}
+
+// entry returns the node searches start from, or false if the graph is empty.
+func (h *hnsw) entry(q []float32) (hnswCandidate, *hnswNode, bool, error) {
+	id := string(h.index.Get(hnswEntryKey))
+	if id == "" {
+		return hnswCandidate{}, nil, false, nil
+	}
+	n, err := h.node(id)
+	if err != nil || n == nil {
+		return hnswCandidate{}, nil, false, err
+	}
+	d, ok := h.distance(q, id)
+	return hnswCandidate{id, d}, n, ok, nil
+}
+
+// search returns the k nodes nearest to q, nearest first.
+func (h *hnsw) search(q []float32, k int) ([]hnswCandidate, error) {
+	ep, top, ok, err := h.entry(q)
+	if err != nil || !ok {
+		return nil, err
+	}
+	entries := []hnswCandidate{ep}
+	for layer := top.Level; layer > 0; layer-- {
+		if entries, err = h.searchLayer(q, entries, 1, layer); err != nil {
+			return nil, err
+		}
+	}
+	found, err := h.searchLayer(q, entries, max(h.ef, k), 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(found) > k {
+		found = found[:k]
+	}
+	return found, nil
+}
+
+// insert adds the vector stored under id to the graph, which must not hold it yet.
+func (h *hnsw) insert(id string) error {
+	q, ok := h.vector(id)
+	if !ok {
+		return nil // Not a vector
+	}
+	level := min(int(-math.Log(1-rand.Float64())/math.Log(float64(h.m))), hnswMaxLevel)
+	node := &hnswNode{Level: level, Links: make([][]string, level+1)}
+
+	ep, top, ok, err := h.entry(q)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		if err := h.putNode(id, node); err != nil {
+			return err
+		}
+		return h.index.Put(hnswEntryKey, []byte(id))
+	}
+
+	entries := []hnswCandidate{ep}
+	for layer := top.Level; layer > level; layer-- {
+		if entries, err = h.searchLayer(q, entries, 1, layer); err != nil {
+			return err
+		}
+	}
+	for layer := min(top.Level, level); layer >= 0; layer-- {
+		if entries, err = h.searchLayer(q, entries, h.efBuild, layer); err != nil {
+			return err
+		}
+		for _, c := range entries {
+			if len(node.Links[layer]) == h.m {
+				break
+			}
+			if c.id != id {
+				node.Links[layer] = append(node.Links[layer], c.id)
+			}
+		}
+		for _, neighbor := range node.Links[layer] {
+			if err := h.link(neighbor, layer, id); err != nil {
+				return err
+			}
+		}
+	}
+	if err := h.putNode(id, node); err != nil {
+		return err
+	}
+	if level > top.Level {
+		return h.index.Put(hnswEntryKey, []byte(id))
+	}
+	return nil
+}
+
+// link adds links from the node from to the nodes to on a layer, keeping only the
+// nearest ones if it ends up with too many.
+func (h *hnsw) link(from string, layer int, to ...string) error {
+	n, err := h.node(from)
+	if err != nil || n == nil || layer >= len(n.Links) {
+		return err
+	}
+	links := n.Links[layer]
+	for _, id := range to {
+		if id != from && !containsString(links, id) {
+			links = append(links, id)
+		}
+	}
+
+	limit := h.m
+	if layer == 0 {
+		limit = 2 * h.m
+	}
+	if len(links) > limit {
+		q, ok := h.vector(from)
+		if !ok {
+			return nil
+		}
+		var candidates []hnswCandidate
+		for _, id := range links {
+			if d, ok := h.distance(q, id); ok {
+				candidates = insertCandidate(candidates, hnswCandidate{id, d})
+			}
+		}
+		links = links[:0]
+		for _, c := range candidates[:min(limit, len(candidates))] {
+			links = append(links, c.id)
+		}
+	}
+	n.Links[layer] = links
+	return h.putNode(from, n)
+}
+
+// remove removes the node id from the graph, linking its neighbors to each other so they
+// stay reachable.
+func (h *hnsw) remove(id string) error {
+	n, err := h.node(id)
+	if err != nil || n == nil {
+		return err
+	}
+	if err := h.nodes.Delete([]byte(id)); err != nil {
+		return err
+	}
+	for layer, links := range n.Links {
+		for _, neighbor := range links {
+			other, err := h.node(neighbor)
+			if err != nil {
+				return err
+			}
+			if other == nil || layer >= len(other.Links) {
+				continue
+			}
+			other.Links[layer] = removeString(other.Links[layer], id)
+			if err := h.putNode(neighbor, other); err != nil {
+				return err
+			}
+			if err := h.link(neighbor, layer, links...); err != nil {
+				return err
+			}
+		}
+	}
+
+	if string(h.index.Get(hnswEntryKey)) != id {
+		return nil
+	}
+	// Start from the highest remaining node
+	entry, level := "", -1
+	err = h.nodes.ForEach(func(k, v []byte) error {
+		var other hnswNode
+		if err := json.Unmarshal(v, &other); err != nil {
+			return fmt.Errorf("failed to decode vector index node: %v", err)
+		}
+		if other.Level > level {
+			entry, level = string(k), other.Level
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if entry == "" {
+		return h.index.Delete(hnswEntryKey)
+	}
+	return h.index.Put(hnswEntryKey, []byte(entry))
+}
+
+// insertCandidate inserts c into candidates, which are sorted by distance.
+func insertCandidate(candidates []hnswCandidate, c hnswCandidate) []hnswCandidate {
+	i := sort.Search(len(candidates), func(i int) bool { return candidates[i].dist > c.dist })
+	candidates = append(candidates, hnswCandidate{})
+	copy(candidates[i+1:], candidates[i:])
+	candidates[i] = c
+	return candidates
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(list []string, s string) []string {
+	out := list[:0]
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
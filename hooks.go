@@ -0,0 +1,54 @@
+package jungledb
+
+import "time"
+
+// Op describes an operation passed to hooks.
+type Op struct {
+	Name  string // Public method being served, such as "Hset"; servers use the method they map to
+	Key   string // Key the operation targets, or "" for operations on the whole database
+	Write bool   // Whether the operation runs a read-write transaction
+	Actor string // Actor of the handle the operation went through, see WithActor
+}
+
+// BeforeHook runs before an operation starts its transaction. Returning an error vetoes the
+// operation, which then fails with that error without touching the database.
+type BeforeHook func(op Op) error
+
+// AfterHook runs once an operation has finished, with its result and total latency.
+type AfterHook func(op Op, err error, dur time.Duration)
+
+// WithBeforeHook adds a hook run before every operation, for example to enforce rate limits
+// or access rules. Hooks run in the order they were added, stopping at the first veto.
+// An operation spanning several transactions, such as FlushAll, runs the hooks for each.
+// Hooks run outside of any transaction and without database locks held.
+func WithBeforeHook(hook BeforeHook) Option {
+	return func(o *options) {
+		o.beforeHooks = append(o.beforeHooks, hook)
+	}
+}
+
+// WithAfterHook adds a hook run after every operation, vetoed ones included, for example
+// to feed metrics or an audit trail. Hooks run in the order they were added, outside of
+// any transaction and without database locks held.
+func WithAfterHook(hook AfterHook) Option {
+	return func(o *options) {
+		o.afterHooks = append(o.afterHooks, hook)
+	}
+}
+
+// runBeforeHooks runs the before hooks for op, returning the first veto.
+func (db *DB) runBeforeHooks(op Op) error {
+	for _, hook := range db.opts.beforeHooks {
+		if err := hook(op); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runAfterHooks runs the after hooks for op.
+func (db *DB) runAfterHooks(op Op, err error, d time.Duration) {
+	for _, hook := range db.opts.afterHooks {
+		hook(op, err, d)
+	}
+}
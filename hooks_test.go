@@ -0,0 +1,66 @@
+package jungledb
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHooks tests before hooks vetoing operations and after hooks observing results.
+func TestHooks(t *testing.T) {
+	errReadOnly := errors.New("key is read-only")
+
+	var seen []Op
+	var results []error
+	db, err := Open("testdata/hooks.db",
+		WithBeforeHook(func(op Op) error {
+			if op.Write && strings.HasPrefix(op.Key, "frozen:") {
+				return errReadOnly
+			}
+			return nil
+		}),
+		WithAfterHook(func(op Op, err error, dur time.Duration) {
+			if dur <= 0 {
+				t.Errorf("%s: expected a positive duration", op.Name)
+			}
+			seen = append(seen, op)
+			results = append(results, err)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("user:1", "name", []byte("Alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if _, err := db.Hget("user:1", "name"); err != nil {
+		t.Fatalf("Hget failed: %v", err)
+	}
+	if err := db.Hset("frozen:1", "name", []byte("Bob")); !errors.Is(err, errReadOnly) {
+		t.Fatalf("expected the write to be vetoed, got %v", err)
+	}
+	if value, err := db.Hget("frozen:1", "name"); err != nil || value != nil {
+		t.Errorf("vetoed write reached the database: %q %v", value, err)
+	}
+
+	want := []Op{
+		{Name: "Hset", Key: "user:1", Write: true},
+		{Name: "Hget", Key: "user:1"},
+		{Name: "Hset", Key: "frozen:1", Write: true},
+		{Name: "Hget", Key: "frozen:1"},
+	}
+	if len(seen) != len(want) {
+		t.Fatalf("expected %d operations, got %+v", len(want), seen)
+	}
+	for i := range want {
+		if seen[i] != want[i] {
+			t.Errorf("operation %d: expected %+v, got %+v", i, want[i], seen[i])
+		}
+	}
+	if results[0] != nil || !errors.Is(results[2], errReadOnly) {
+		t.Errorf("unexpected results: %v", results)
+	}
+}
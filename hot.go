@@ -0,0 +1,152 @@
+package jungledb
+
+import (
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// WithHotKeys keeps the hashes whose keys match one of patterns (see ListKeys; keys of
+// namespaces include the namespace prefix, as for WithCache) decoded in memory once read,
+// so Hget, HgetOK and Hscan of them take no transaction. Writes to a hot key drop its
+// copy before they return, so reads never see stale data. Meant for small, read-mostly
+// keys such as configuration read thousands of times per second: hot copies are never
+// evicted.
+func WithHotKeys(patterns ...string) Option {
+	return func(o *options) {
+		o.hotKeys = append(o.hotKeys, patterns...)
+	}
+}
+
+// hotHash is the in-memory copy of a hot key.
+type hotHash struct {
+	fields    map[string][]byte // nil if the key does not exist or holds a sorted set
+	wrongType bool              // The key holds a sorted set
+	expiresAt int64             // Deadline of the key in Unix nanoseconds, zero without a TTL
+}
+
+// hotCache holds the copies of hot keys, by bucket name.
+type hotCache struct {
+	mu     sync.Mutex
+	hashes map[string]*hotHash
+}
+
+// isHot reports whether the key stored under name is hot.
+func (db *DB) isHot(name string) bool {
+	for _, pattern := range db.opts.hotKeys {
+		if matchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// hotRead serves the read op of the hot key stored under name from memory, loading the
+// key on first use. It runs the hooks and records the metrics as a read would.
+func (db *DB) hotRead(op, name string) (h *hotHash, err error) {
+	o := Op{Name: op, Key: name, Actor: db.actor}
+	c := &db.hot
+	c.mu.Lock()
+	h = c.hashes[name]
+	c.mu.Unlock()
+	if h == nil || (h.expiresAt != 0 && h.expiresAt <= db.now().UnixNano()) {
+		return db.loadHot(op, name)
+	}
+
+	defer db.observe(o, time.Now(), &err)
+	if db.closing.Load() {
+		return nil, ErrClosed
+	}
+	if err := db.runBeforeHooks(o); err != nil {
+		return nil, err
+	}
+	if h.wrongType {
+		return nil, ErrWrongType
+	}
+	return h, nil
+}
+
+// loadHot reads the hot key stored under name and keeps it in memory unless a write
+// committed meanwhile, which may have changed it after the read.
+func (db *DB) loadHot(op, name string) (*hotHash, error) {
+	gen := db.writeGen.Load()
+	h := &hotHash{}
+	err := db.view(op, name, func(tx *bbolt.Tx) error {
+		bucket := db.liveBucket(tx, name)
+		if bucket == nil {
+			return nil
+		}
+		if keyType(tx, []byte(name)) != typeHash {
+			h.wrongType = true
+			return nil
+		}
+		h.expiresAt = expiry(tx, name)
+		h.fields = make(map[string][]byte)
+		return bucket.ForEach(func(k, v []byte) error {
+			h.fields[string(k)] = append([]byte{}, v...)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c := &db.hot
+	c.mu.Lock()
+	if db.writeGen.Load() == gen {
+		if c.hashes == nil {
+			c.hashes = make(map[string]*hotHash)
+		}
+		c.hashes[name] = h
+	}
+	c.mu.Unlock()
+	if h.wrongType {
+		return nil, ErrWrongType
+	}
+	return h, nil
+}
+
+// invalidateHot drops the copies of the hot keys changed by a committed transaction.
+// It is called in commit order, after the write generation moved on, so reads that
+// started before the write cannot store what they read.
+func (db *DB) invalidateHot(events []Event) {
+	if len(db.opts.hotKeys) == 0 {
+		return
+	}
+	c := &db.hot
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, ev := range events {
+		delete(c.hashes, ev.Key)
+		if ev.Target != "" {
+			delete(c.hashes, ev.Target)
+		}
+	}
+}
+
+// clearHot drops the copies of every hot key, after writes that record no events.
+func (db *DB) clearHot() {
+	c := &db.hot
+	c.mu.Lock()
+	c.hashes = nil
+	c.mu.Unlock()
+}
+
+// field returns a copy of a field of a hot key, and whether it exists.
+func (h *hotHash) field(field string) ([]byte, bool) {
+	v, ok := h.fields[field]
+	if !ok {
+		return nil, false
+	}
+	return append([]byte{}, v...), true
+}
+
+// all returns a copy of the fields of a hot key.
+func (h *hotHash) all() map[string][]byte {
+	fields := make(map[string][]byte, len(h.fields))
+	for k, v := range h.fields {
+		fields[k] = append([]byte{}, v...)
+	}
+	return fields
+}
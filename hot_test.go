@@ -0,0 +1,74 @@
+package jungledb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestHotKeys tests that hot keys are served from memory and that writes, including
+// renames and expiry, are seen right away.
+func TestHotKeys(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	db, err := Open("testdata/hot.db", WithHotKeys("config*"), WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hmset("config", map[string][]byte{"flags": []byte("on"), "mode": []byte("fast")}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	for range 10 {
+		if v, err := db.Hget("config", "flags"); err != nil || string(v) != "on" {
+			t.Fatalf("Hget = %q, %v; expected on", v, err)
+		}
+	}
+	if got := db.Metrics().ReadTx.Count; got != 1 {
+		t.Errorf("expected 1 read transaction for 10 reads of a hot key, got %d", got)
+	}
+	v, _ := db.Hget("config", "flags")
+	v[0] = 'X' // Callers get copies
+	fields, err := db.Hscan("config")
+	if err != nil || string(fields["flags"]) != "on" || string(fields["mode"]) != "fast" {
+		t.Errorf("Hscan = %q, %v", fields, err)
+	}
+
+	if err := db.Hset("config", "flags", []byte("off")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if v, err := db.Hget("config", "flags"); err != nil || string(v) != "off" {
+		t.Errorf("Hget after Hset = %q, %v; expected off", v, err)
+	}
+	if _, found, err := db.HgetOK("config_new", "flags"); err != nil || found {
+		t.Errorf("HgetOK of a missing hot key = %v, %v", found, err)
+	}
+	if err := db.Rename("config", "config_new"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if v, found, err := db.HgetOK("config_new", "flags"); err != nil || !found || string(v) != "off" {
+		t.Errorf("HgetOK after Rename = %q, %v, %v", v, found, err)
+	}
+	if v, err := db.Hget("config", "flags"); err != nil || v != nil {
+		t.Errorf("Hget of the renamed key = %q, %v; expected nil", v, err)
+	}
+
+	// A TTL elapsing needs no write to be seen
+	if err := db.Expire("config_new", time.Minute); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if v, _ := db.Hget("config_new", "flags"); string(v) != "off" {
+		t.Errorf("Hget before expiry = %q; expected off", v)
+	}
+	clock.advance(time.Hour)
+	if v, err := db.Hget("config_new", "flags"); err != nil || v != nil {
+		t.Errorf("Hget after expiry = %q, %v; expected nil", v, err)
+	}
+
+	if err := db.Zadd("config_z", 1, "m"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if _, err := db.Hscan("config_z"); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Hscan of a sorted set: expected ErrWrongType, got %v", err)
+	}
+}
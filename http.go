@@ -0,0 +1,476 @@
+package jungledb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// maxHTTPBody bounds request bodies accepted by the HTTP API.
+const maxHTTPBody = 32 << 20
+
+// namespaceHeader selects the namespace an HTTP API request works in.
+const namespaceHeader = "X-Jungledb-Namespace"
+
+// HTTPHandler returns an http.Handler exposing the database as a JSON REST API:
+//
+//	GET    /keys?pattern=&cursor=&limit=       list keys: {"keys": [...], "cursor": "..."}
+//	GET    /hash/{key}[?fields=a,b]            all (or the named) fields as a JSON object
+//	PUT    /hash/{key}                         set fields from a JSON object
+//	DELETE /hash/{key}                         delete the hash or sorted set
+//	GET    /hash/{key}/{field}                 raw field value, 404 if missing
+//	PUT    /hash/{key}/{field}                 set the field to the raw request body
+//	DELETE /hash/{key}/{field}                 delete the field
+//	POST   /hash/{key}/{field}/incr?delta=N    increment an integer field: {"value": n}
+//	GET    /zset/{key}                         {"card": n}
+//	POST   /zset/{key}                         add members: [{"member": "a", "score": 1}, ...]
+//	GET    /zset/{key}/range?start=&stop=&rev= members with scores, in order
+//	GET    /zset/{key}/{member}                {"member": "a", "score": 1}, 404 if missing
+//	DELETE /zset/{key}/{member}                remove the member
+//	POST   /batch                              apply operations atomically, see below
+//
+// Hash values are carried as JSON strings in JSON bodies; use the single-field endpoints
+// for binary values. A batch is a JSON array of operations applied in one transaction:
+// {"op": "hset"|"hdel"|"zadd"|"zrem"|"del", "key": ..., "field": ..., "value": ...,
+// "member": ..., "score": ...}. Errors are returned as {"error": "..."}.
+//
+// Requests work in the namespace named by the X-Jungledb-Namespace header (see Namespace),
+// or the root namespace without it. With WithACL, requests must carry a user's token as
+// "Authorization: Bearer <token>"; GET requests need read access to the namespace and
+// all others read-write access. For TLS, serve the handler with an http.Server whose
+// TLSConfig comes from LoadTLSConfig.
+func (db *DB) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	handle := func(pattern string, need Access, h func(*DB, http.ResponseWriter, *http.Request)) {
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			token, _ := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			scoped, err := db.Authorize(token, r.Header.Get(namespaceHeader), need)
+			if err != nil {
+				if errors.Is(err, ErrUnauthenticated) {
+					w.Header().Set("WWW-Authenticate", "Bearer")
+				}
+				writeHTTPError(w, errorStatus(err), err)
+				return
+			}
+			if err := checkKey(r.PathValue("key")); err != nil {
+				writeHTTPError(w, errorStatus(err), err)
+				return
+			}
+			h(scoped, w, r)
+		})
+	}
+	handle("GET /keys", AccessRead, (*DB).httpListKeys)
+	handle("GET /hash/{key}", AccessRead, (*DB).httpGetHash)
+	handle("PUT /hash/{key}", AccessReadWrite, (*DB).httpPutHash)
+	handle("DELETE /hash/{key}", AccessReadWrite, (*DB).httpDeleteKey)
+	handle("GET /hash/{key}/{field}", AccessRead, (*DB).httpGetField)
+	handle("PUT /hash/{key}/{field}", AccessReadWrite, (*DB).httpPutField)
+	handle("DELETE /hash/{key}/{field}", AccessReadWrite, (*DB).httpDeleteField)
+	handle("POST /hash/{key}/{field}/incr", AccessReadWrite, (*DB).httpIncrField)
+	handle("GET /zset/{key}", AccessRead, (*DB).httpZcard)
+	handle("POST /zset/{key}", AccessReadWrite, (*DB).httpZadd)
+	handle("DELETE /zset/{key}", AccessReadWrite, (*DB).httpDeleteKey)
+	handle("GET /zset/{key}/range", AccessRead, (*DB).httpZrange)
+	handle("GET /zset/{key}/{member}", AccessRead, (*DB).httpZscore)
+	handle("DELETE /zset/{key}/{member}", AccessReadWrite, (*DB).httpZrem)
+	handle("POST /batch", AccessReadWrite, (*DB).httpBatch)
+	return mux
+}
+
+// ListenAndServeHTTP serves HTTPHandler on the TCP address addr.
+func (db *DB) ListenAndServeHTTP(addr string) error {
+	return http.ListenAndServe(addr, db.HTTPHandler())
+}
+
+// zsetMember is a sorted set member with its score, as used by the HTTP API.
+type zsetMember struct {
+	Member string  `json:"member"`
+	Score  float64 `json:"score"`
+}
+
+// batchOp is one operation of a POST /batch request.
+type batchOp struct {
+	Op     string  `json:"op"`
+	Key    string  `json:"key"`
+	Field  string  `json:"field,omitempty"`
+	Value  string  `json:"value,omitempty"`
+	Member string  `json:"member,omitempty"`
+	Score  float64 `json:"score,omitempty"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeHTTPError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// errorStatus maps a database error to an HTTP status code.
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, ErrReservedKey):
+		return http.StatusBadRequest
+	case errors.Is(err, ErrKeyNotFound), errors.Is(err, ErrFieldNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrKeyExists), errors.Is(err, ErrWrongType), errors.Is(err, ErrOverflow), errors.Is(err, ErrUniqueViolation):
+		return http.StatusConflict
+	case errors.Is(err, ErrClosed):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, ErrReadOnly), errors.Is(err, ErrPermissionDenied):
+		return http.StatusForbidden
+	case errors.Is(err, ErrUnauthenticated):
+		return http.StatusUnauthorized
+	case errors.Is(err, ErrQuotaExceeded):
+		return http.StatusInsufficientStorage
+	}
+	return http.StatusInternalServerError
+}
+
+// readBody reads a request body of up to maxHTTPBody bytes. Longer bodies fail with an
+// *http.MaxBytesError, see bodyStatus, rather than being truncated.
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxHTTPBody))
+	if err != nil {
+		return nil, fmt.Errorf("invalid request body: %w", err)
+	}
+	return body, nil
+}
+
+// readJSON decodes a size-limited JSON request body into v, see readBody.
+func readJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxHTTPBody)).Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+// bodyStatus maps an error of readBody or readJSON to an HTTP status code.
+func bodyStatus(err error) int {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusBadRequest
+}
+
+func (db *DB) httpListKeys(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	limit := 0
+	if s := q.Get("limit"); s != "" {
+		var err error
+		if limit, err = strconv.Atoi(s); err != nil {
+			writeHTTPError(w, http.StatusBadRequest, fmt.Errorf("invalid limit: %s", s))
+			return
+		}
+	}
+	keys, next, err := db.ListKeys(q.Get("pattern"), q.Get("cursor"), limit)
+	if err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	if keys == nil {
+		keys = []string{}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"keys": keys, "cursor": next})
+}
+
+func (db *DB) httpGetHash(w http.ResponseWriter, r *http.Request) {
+	key := r.PathValue("key")
+	result := make(map[string]string)
+
+	if names := r.URL.Query().Get("fields"); names != "" {
+		fields := strings.Split(names, ",")
+		values, err := db.Hmget(key, fields)
+		if err != nil {
+			writeHTTPError(w, errorStatus(err), err)
+			return
+		}
+		for i, field := range fields {
+			if values[i] != nil {
+				result[field] = string(values[i])
+			}
+		}
+		writeJSON(w, http.StatusOK, result)
+		return
+	}
+
+	fields, err := db.Hscan(key)
+	if err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	for field, value := range fields {
+		result[field] = string(value)
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (db *DB) httpPutHash(w http.ResponseWriter, r *http.Request) {
+	var body map[string]string
+	if err := readJSON(w, r, &body); err != nil {
+		writeHTTPError(w, bodyStatus(err), err)
+		return
+	}
+	fields := make(map[string][]byte, len(body))
+	for field, value := range body {
+		fields[field] = []byte(value)
+	}
+	if err := db.Hmset(r.PathValue("key"), fields); err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (db *DB) httpDeleteKey(w http.ResponseWriter, r *http.Request) {
+	err := db.HdelBucket(r.PathValue("key"))
+	if errors.Is(err, ErrKeyNotFound) {
+		writeHTTPError(w, http.StatusNotFound, fmt.Errorf("key %s does not exist", r.PathValue("key")))
+		return
+	} else if err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (db *DB) httpGetField(w http.ResponseWriter, r *http.Request) {
+	var value []byte
+	key := db.nsKey(r.PathValue("key"))
+	err := db.view("Hget", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err
+		}
+		if v := bucket.Get([]byte(r.PathValue("field"))); v != nil {
+			value = append([]byte{}, v...) // Copy out of the transaction
+		}
+		return nil
+	})
+	if err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	if value == nil {
+		writeHTTPError(w, http.StatusNotFound, ErrFieldNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(value)
+}
+
+func (db *DB) httpPutField(w http.ResponseWriter, r *http.Request) {
+	value, err := readBody(w, r)
+	if err != nil {
+		writeHTTPError(w, bodyStatus(err), err)
+		return
+	}
+	if err := db.Hset(r.PathValue("key"), r.PathValue("field"), value); err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (db *DB) httpDeleteField(w http.ResponseWriter, r *http.Request) {
+	if err := db.Hdel(r.PathValue("key"), r.PathValue("field")); err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (db *DB) httpIncrField(w http.ResponseWriter, r *http.Request) {
+	delta := int64(1)
+	if s := r.URL.Query().Get("delta"); s != "" {
+		var err error
+		if delta, err = strconv.ParseInt(s, 10, 64); err != nil {
+			writeHTTPError(w, http.StatusBadRequest, fmt.Errorf("invalid delta: %s", s))
+			return
+		}
+	}
+	value, err := db.Hincr(r.PathValue("key"), r.PathValue("field"), delta)
+	if err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int64{"value": value})
+}
+
+func (db *DB) httpZcard(w http.ResponseWriter, r *http.Request) {
+	card, err := db.Zcard(r.PathValue("key"))
+	if err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"card": card})
+}
+
+func (db *DB) httpZadd(w http.ResponseWriter, r *http.Request) {
+	var members []zsetMember
+	if err := readJSON(w, r, &members); err != nil {
+		writeHTTPError(w, bodyStatus(err), err)
+		return
+	}
+	key := db.nsKey(r.PathValue("key"))
+	err := db.update("Zadd", key, func(tx *txn) error {
+		for _, m := range members {
+			if err := zadd(tx, key, m.Score, m.Member); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (db *DB) httpZrange(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	start, stop := 0, -1
+	var err error
+	if s := q.Get("start"); s != "" {
+		if start, err = strconv.Atoi(s); err != nil {
+			writeHTTPError(w, http.StatusBadRequest, fmt.Errorf("invalid start: %s", s))
+			return
+		}
+	}
+	if s := q.Get("stop"); s != "" {
+		if stop, err = strconv.Atoi(s); err != nil {
+			writeHTTPError(w, http.StatusBadRequest, fmt.Errorf("invalid stop: %s", s))
+			return
+		}
+	}
+	rev, _ := strconv.ParseBool(q.Get("rev"))
+
+	key := r.PathValue("key")
+	var names []string
+	if rev {
+		names, err = db.Zrevrange(key, start, stop)
+	} else {
+		names, err = db.Zrange(key, start, stop)
+	}
+	if err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+
+	members := make([]zsetMember, 0, len(names))
+	key = db.nsKey(key)
+	err = db.view("Zrange", key, func(tx *bbolt.Tx) error {
+		idx := membersBucket(tx, key)
+		if idx == nil {
+			return nil
+		}
+		for _, name := range names {
+			if v := idx.Get([]byte(name)); len(v) == 8 {
+				members = append(members, zsetMember{name, math.Float64frombits(binary.BigEndian.Uint64(v))})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, members)
+}
+
+func (db *DB) httpZscore(w http.ResponseWriter, r *http.Request) {
+	key, member := db.nsKey(r.PathValue("key")), r.PathValue("member")
+	found := false
+	var score float64
+	err := db.view("Zscore", key, func(tx *bbolt.Tx) error {
+		if idx := membersBucket(tx, key); idx != nil {
+			if v := idx.Get([]byte(member)); len(v) == 8 {
+				found = true
+				score = math.Float64frombits(binary.BigEndian.Uint64(v))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	if !found {
+		writeHTTPError(w, http.StatusNotFound, errors.New("member not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, zsetMember{member, score})
+}
+
+func (db *DB) httpZrem(w http.ResponseWriter, r *http.Request) {
+	if err := db.Zrem(r.PathValue("key"), r.PathValue("member")); err != nil {
+		writeHTTPError(w, errorStatus(err), err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (db *DB) httpBatch(w http.ResponseWriter, r *http.Request) {
+	var ops []batchOp
+	if err := readJSON(w, r, &ops); err != nil {
+		writeHTTPError(w, bodyStatus(err), err)
+		return
+	}
+	err := db.update("Batch", "", func(tx *txn) error {
+		for i, op := range ops {
+			if err := checkKey(op.Key); err != nil {
+				return fmt.Errorf("operation %d: %w", i, err)
+			}
+			op.Key = db.nsKey(op.Key)
+			if err := applyBatchOp(tx, op); err != nil {
+				return fmt.Errorf("operation %d: %w", i, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		status := errorStatus(err)
+		if status == http.StatusInternalServerError {
+			status = http.StatusBadRequest // Such as unknown operations
+		}
+		writeHTTPError(w, status, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]int{"applied": len(ops)})
+}
+
+// applyBatchOp applies one batch operation inside a read-write transaction.
+func applyBatchOp(tx *txn, op batchOp) error {
+	switch op.Op {
+	case "hset":
+		return hset(tx, op.Key, op.Field, []byte(op.Value))
+	case "hdel":
+		return hdel(tx, op.Key, op.Field)
+	case "zadd":
+		return zadd(tx, op.Key, op.Score, op.Member)
+	case "zrem":
+		return zrem(tx, op.Key, op.Member)
+	case "del":
+		if err := deleteKey(tx, op.Key); errors.Is(err, ErrKeyNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		tx.record(Event{Type: EventDelete, Key: op.Key})
+		return nil
+	default:
+		return fmt.Errorf("unknown op %q", op.Op)
+	}
+}
@@ -0,0 +1,241 @@
+package jungledb
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestHTTPHandler tests the REST API endpoints.
+func TestHTTPHandler(t *testing.T) {
+	db, err := Open("testdata/http.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	srv := httptest.NewServer(db.HTTPHandler())
+	defer srv.Close()
+
+	do := func(method, path, body string) (int, string) {
+		t.Helper()
+		req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatalf("failed to build request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", method, path, err)
+		}
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, strings.TrimSpace(string(data))
+	}
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		want               string
+	}{
+		{"PUT", "/hash/user:1/name", "Alice", http.StatusNoContent, ""},
+		{"GET", "/hash/user:1/name", "", http.StatusOK, "Alice"},
+		{"GET", "/hash/user:1/missing", "", http.StatusNotFound, `{"error":"field not found"}`},
+		{"PUT", "/hash/user:1", `{"city":"Paris","age":"30"}`, http.StatusNoContent, ""},
+		{"GET", "/hash/user:1", "", http.StatusOK, `{"age":"30","city":"Paris","name":"Alice"}`},
+		{"GET", "/hash/user:1?fields=name,nope", "", http.StatusOK, `{"name":"Alice"}`},
+		{"DELETE", "/hash/user:1/age", "", http.StatusNoContent, ""},
+		{"POST", "/hash/stats/hits/incr?delta=5", "", http.StatusOK, `{"value":5}`},
+		{"POST", "/hash/stats/hits/incr", "", http.StatusOK, `{"value":6}`},
+		{"POST", "/zset/ranking", `[{"member":"a","score":2},{"member":"b","score":1}]`, http.StatusNoContent, ""},
+		{"GET", "/zset/ranking", "", http.StatusOK, `{"card":2}`},
+		{"GET", "/zset/ranking/range", "", http.StatusOK, `[{"member":"b","score":1},{"member":"a","score":2}]`},
+		{"GET", "/zset/ranking/range?start=0&stop=0&rev=true", "", http.StatusOK, `[{"member":"a","score":2}]`},
+		{"GET", "/zset/ranking/a", "", http.StatusOK, `{"member":"a","score":2}`},
+		{"GET", "/zset/ranking/zzz", "", http.StatusNotFound, `{"error":"member not found"}`},
+		{"DELETE", "/zset/ranking/a", "", http.StatusNoContent, ""},
+		{"POST", "/batch", `[{"op":"hset","key":"b","field":"f","value":"v"},{"op":"zadd","key":"z","member":"m","score":3},{"op":"del","key":"stats"}]`, http.StatusOK, `{"applied":3}`},
+		{"POST", "/batch", `[{"op":"hset","key":"c","field":"f","value":"v"},{"op":"bogus","key":"c"}]`, http.StatusBadRequest, `{"error":"operation 1: unknown op \"bogus\""}`},
+		{"POST", "/batch", `[{"op":"hset","key":"z","field":"f","value":"v"}]`, http.StatusConflict, `{"error":"operation 0: operation against a key holding the wrong kind of value"}`},
+		{"POST", "/batch", `[{"op":"hdel","key":"z","field":"m"}]`, http.StatusConflict, `{"error":"operation 0: operation against a key holding the wrong kind of value"}`},
+		{"GET", "/zset/z/range", "", http.StatusOK, `[{"member":"m","score":3}]`},
+		{"GET", "/keys?pattern=*", "", http.StatusOK, `{"cursor":"","keys":["b","ranking","user:1","z"]}`},
+		{"GET", "/keys?limit=x", "", http.StatusBadRequest, `{"error":"invalid limit: x"}`},
+		{"DELETE", "/hash/b", "", http.StatusNoContent, ""},
+		{"DELETE", "/hash/b", "", http.StatusNotFound, `{"error":"key b does not exist"}`},
+	}
+
+	for _, tc := range tests {
+		status, body := do(tc.method, tc.path, tc.body)
+		if status != tc.status {
+			t.Errorf("%s %s: expected status %d, got %d (%s)", tc.method, tc.path, tc.status, status, body)
+			continue
+		}
+		if tc.want == "" || !strings.HasPrefix(tc.want, "{") && !strings.HasPrefix(tc.want, "[") {
+			if body != tc.want {
+				t.Errorf("%s %s: expected body %q, got %q", tc.method, tc.path, tc.want, body)
+			}
+			continue
+		}
+		// Compare JSON structurally, map key order is not significant
+		var got, want any
+		if err := json.Unmarshal([]byte(body), &got); err != nil {
+			t.Errorf("%s %s: invalid JSON %q: %v", tc.method, tc.path, body, err)
+			continue
+		}
+		json.Unmarshal([]byte(tc.want), &want)
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("%s %s: expected %s, got %s", tc.method, tc.path, wantJSON, gotJSON)
+		}
+	}
+
+	// The failed batch must not have been partially applied
+	if exists, _ := db.HhasKey("c", "f"); exists {
+		t.Error("failed batch was partially applied")
+	}
+}
+
+// TestHTTPHandlerReservedKeys tests that the REST API rejects keys naming another
+// namespace or an internal bucket with 400 Bad Request, in paths and in batches.
+func TestHTTPHandlerReservedKeys(t *testing.T) {
+	db, err := Open("testdata/http-reserved.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Namespace("billing").Hset("secret", "card", []byte("4242")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	srv := httptest.NewServer(db.HTTPHandler())
+	defer srv.Close()
+
+	for _, tc := range []struct{ method, path, body string }{
+		{"GET", "/hash/__jungledb.ns:billing%00secret", ""},
+		{"GET", "/hash/__jungledb.ns:billing%00secret/card", ""},
+		{"PUT", "/hash/__jungledb:sealed/f", "v"},
+		{"DELETE", "/zset/__jungledb.lock:job", ""},
+		{"POST", "/batch", `[{"op":"hset","key":"__jungledb.ns:billing\u0000secret","field":"card","value":"0000"}]`},
+	} {
+		req, err := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatalf("failed to build request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", tc.method, tc.path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), "reserved") {
+			t.Errorf("%s %s: got status %d (%s), want 400 and a reserved key error", tc.method, tc.path, resp.StatusCode, body)
+		}
+	}
+	if value, err := db.Namespace("billing").Hget("secret", "card"); err != nil || string(value) != "4242" {
+		t.Fatalf("Hget in the namespace: got %q, %v, want %q", value, err, "4242")
+	}
+}
+
+// TestHTTPHandlerBodyLimit tests that the REST API rejects bodies larger than
+// maxHTTPBody with 413 Request Entity Too Large rather than truncating them.
+func TestHTTPHandlerBodyLimit(t *testing.T) {
+	db, err := Open("testdata/http-limit.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	srv := httptest.NewServer(db.HTTPHandler())
+	defer srv.Close()
+
+	big := strings.Repeat("x", maxHTTPBody+1)
+	for _, tc := range []struct{ method, path, body string }{
+		{"PUT", "/hash/blob/data", big},
+		{"PUT", "/hash/blob", `{"data":"` + big + `"}`},
+	} {
+		req, err := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatalf("failed to build request: %v", err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", tc.method, tc.path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s %s: got status %d, want 413", tc.method, tc.path, resp.StatusCode)
+		}
+	}
+	if value, err := db.Hget("blob", "data"); err != nil || value != nil {
+		t.Errorf("Hget after the rejected writes: got %d bytes, %v, want nothing", len(value), err)
+	}
+}
+
+// TestHTTPHandlerACL tests that the REST API authenticates bearer tokens and applies the
+// namespace header and the permissions of the ACL.
+func TestHTTPHandlerACL(t *testing.T) {
+	acl, err := NewACL(ACLUser{Name: "reports", Token: "s3cret", Namespaces: map[string]Access{"": AccessRead, "reports": AccessReadWrite}})
+	if err != nil {
+		t.Fatalf("NewACL failed: %v", err)
+	}
+	db, err := Open("testdata/http-acl.db", WithACL(acl))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Hset("user:1", "name", []byte("Alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	srv := httptest.NewServer(db.HTTPHandler())
+	defer srv.Close()
+
+	tests := []struct {
+		method, path, body string
+		token, namespace   string
+		status             int
+		want               string
+	}{
+		{"GET", "/hash/user:1/name", "", "", "", http.StatusUnauthorized, ""},
+		{"GET", "/hash/user:1/name", "", "wrong", "", http.StatusUnauthorized, ""},
+		{"GET", "/hash/user:1/name", "", "s3cret", "", http.StatusOK, "Alice"},
+		{"PUT", "/hash/user:1/name", "Bob", "s3cret", "", http.StatusForbidden, ""},
+		{"GET", "/hash/user:1/name", "", "s3cret", "other", http.StatusForbidden, ""},
+		{"PUT", "/hash/user:1/name", "Carol", "s3cret", "reports", http.StatusNoContent, ""},
+		{"GET", "/hash/user:1/name", "", "s3cret", "reports", http.StatusOK, "Carol"},
+		{"GET", "/hash/user:1/name", "", "s3cret", "", http.StatusOK, "Alice"},
+	}
+
+	for _, tc := range tests {
+		req, err := http.NewRequest(tc.method, srv.URL+tc.path, strings.NewReader(tc.body))
+		if err != nil {
+			t.Fatalf("failed to build request: %v", err)
+		}
+		if tc.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.token)
+		}
+		if tc.namespace != "" {
+			req.Header.Set(namespaceHeader, tc.namespace)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("%s %s failed: %v", tc.method, tc.path, err)
+		}
+		data, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s %s as %q in %q: expected status %d, got %d (%s)", tc.method, tc.path, tc.token, tc.namespace, tc.status, resp.StatusCode, data)
+			continue
+		}
+		if resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") == "" {
+			t.Errorf("%s %s: 401 without WWW-Authenticate", tc.method, tc.path)
+		}
+		if tc.want != "" && string(data) != tc.want {
+			t.Errorf("%s %s: expected body %q, got %q", tc.method, tc.path, tc.want, data)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package jungledb
+
+import (
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// sealBucket holds the immutable keys that were written, by bucket name, including those
+// deleted since.
+const sealBucket = internalPrefix + "sealed"
+
+// WithImmutable makes the keys matching one of patterns (see ListKeys; keys of namespaces
+// include the namespace prefix, as for WithHotKeys) write-once, such as "audit:*" for
+// audit artifacts: the transaction that creates such a key may write it freely, and every
+// later write to it fails with ErrImmutable, whichever method or transaction makes it.
+// Deleting the key, or letting it expire, remains possible, but the name stays sealed:
+// it can never be written again, even after FlushAll, so a name never refers to two
+// different contents. Setting a TTL is allowed. Keys that existed before the option was
+// set become immutable with their next write.
+func WithImmutable(patterns ...string) Option {
+	return func(o *options) {
+		o.immutable = append(o.immutable, patterns...)
+	}
+}
+
+// isImmutable reports whether the key stored under name is write-once.
+func (db *DB) isImmutable(name string) bool {
+	for _, pattern := range db.opts.immutable {
+		if matchPattern(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkImmutable fails with ErrImmutable if tx writes an immutable key that an earlier
+// transaction wrote, and seals the immutable keys written first by tx.
+func (db *DB) checkImmutable(tx *txn) error {
+	if len(db.opts.immutable) == 0 {
+		return nil
+	}
+	var seals *bbolt.Bucket
+	created := make(map[string]bool) // Keys first written by tx, which it may keep writing
+	write := func(name string) error {
+		if !db.isImmutable(name) || created[name] {
+			return nil
+		}
+		if seals == nil {
+			var err error
+			if seals, err = tx.CreateBucketIfNotExists([]byte(sealBucket)); err != nil {
+				return fmt.Errorf("failed to create bucket: %v", err)
+			}
+		}
+		if seals.Get([]byte(name)) != nil && !tx.replace {
+			key, _ := db.userKey(name)
+			return fmt.Errorf("%w: %s", ErrImmutable, key)
+		}
+		created[name] = true
+		return seals.Put([]byte(name), []byte{})
+	}
+	for _, ev := range tx.events {
+		var err error
+		switch ev.Type {
+		case EventHset, EventHdel, EventZadd, EventZrem:
+			err = write(ev.Key)
+		case EventCopy, EventRename:
+			err = write(ev.Target)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package jungledb
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestImmutable tests that keys made write-once accept writes from the transaction that
+// creates them only, even once deleted, and that other keys are unaffected.
+func TestImmutable(t *testing.T) {
+	db, err := Open("testdata/immutable.db", WithImmutable("blobs:*"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hmset("blobs:a", map[string][]byte{"data": []byte("v1"), "size": []byte("2")}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	err = db.Update(func(tx *Tx) error {
+		if err := tx.Hset("blobs:b", "data", []byte("v1")); err != nil {
+			return err
+		}
+		return tx.Hset("blobs:b", "size", []byte("2")) // Same transaction as the first write
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	for name, write := range map[string]func() error{
+		"Hset":   func() error { return db.Hset("blobs:a", "data", []byte("v2")) },
+		"Hdel":   func() error { return db.Hdel("blobs:a", "size") },
+		"Hincr":  func() error { _, err := db.Hincr("blobs:a", "n", 1); return err },
+		"Update": func() error { return db.Update(func(tx *Tx) error { return tx.Hset("blobs:b", "data", nil) }) },
+	} {
+		if err := write(); !errors.Is(err, ErrImmutable) {
+			t.Errorf("%s of an immutable key: got error %v, want ErrImmutable", name, err)
+		}
+	}
+	if v, _ := db.Hget("blobs:a", "data"); string(v) != "v1" {
+		t.Fatalf("immutable field: got %q, want %q", v, "v1")
+	}
+
+	if err := db.Hset("other", "f", []byte("v1")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Hset("other", "f", []byte("v2")); err != nil {
+		t.Fatalf("Hset of a mutable key failed: %v", err)
+	}
+
+	// Deleting leaves the name sealed
+	if err := db.Rename("blobs:a", "moved"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if err := db.Hset("blobs:a", "data", []byte("v3")); !errors.Is(err, ErrImmutable) {
+		t.Fatalf("Hset of a renamed immutable key: got error %v, want ErrImmutable", err)
+	}
+	if err := db.Rename("moved", "blobs:a"); !errors.Is(err, ErrImmutable) {
+		t.Fatalf("Rename onto a sealed name: got error %v, want ErrImmutable", err)
+	}
+	if err := db.HdelBucket("blobs:b"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if err := db.Hset("blobs:b", "data", []byte("v3")); !errors.Is(err, ErrImmutable) {
+		t.Fatalf("Hset of a deleted immutable key: got error %v, want ErrImmutable", err)
+	}
+	if err := db.Hset("blobs:c", "data", []byte("v1")); err != nil {
+		t.Fatalf("first Hset of a new immutable key failed: %v", err)
+	}
+}
+
+// TestImmutableFlushAll tests that FlushAll deletes immutable keys but keeps their names
+// sealed.
+func TestImmutableFlushAll(t *testing.T) {
+	db, err := Open("testdata/immutable-flush.db", WithImmutable("audit:*"))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("audit:1", "entry", []byte("v1")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Zadd("scores", 1, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	if fields, err := db.Hscan("audit:1"); err != nil || len(fields) != 0 {
+		t.Fatalf("Hscan after FlushAll: got %v, %v, want no fields", fields, err)
+	}
+	if err := db.Hset("audit:1", "entry", []byte("v2")); !errors.Is(err, ErrImmutable) {
+		t.Fatalf("Hset of a sealed name after FlushAll: got error %v, want ErrImmutable", err)
+	}
+	if err := db.Hset("audit:2", "entry", []byte("v1")); err != nil {
+		t.Fatalf("Hset of a new immutable key after FlushAll failed: %v", err)
+	}
+}
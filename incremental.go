@@ -0,0 +1,232 @@
+package jungledb
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"go.etcd.io/bbolt"
+)
+
+// ErrDeltaOutOfSequence is returned by ApplyIncremental for a delta that does not start
+// where the database stands, because a delta was skipped or applied twice.
+var ErrDeltaOutOfSequence = errors.New("incremental backup out of sequence")
+
+// incrementalKey records, in the meta bucket, the operation log sequence the database
+// was restored up to by ApplyIncremental.
+var incrementalKey = []byte("incremental")
+
+// deltaHeader is the first line of an incremental backup: the operation log sequences
+// it covers, after From up to and including To.
+type deltaHeader struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+}
+
+// deltaRecord is a line of an incremental backup: the whole current content of a key
+// changed since the previous backup, or its deletion.
+type deltaRecord struct {
+	exportRecord
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// BackupIncrementalTo stores in target, as the backup name, the keys changed since the
+// operation log sequence since and returns the sequence the backup goes up to, to pass
+// as since to the next call. The backup is a delta holding the current content of every
+// hash and sorted set written, renamed or deleted after since, in the line-delimited
+// JSON of Export, so its size follows the amount of data changed rather than the size
+// of the file. With since 0, it holds every key and is the base the next deltas apply
+// to. ApplyIncremental restores the deltas in sequence. The operation log must be
+// enabled with WithOpLog, and keep the entries after since: ErrOpLogTruncated is
+// returned otherwise, and a new base must be taken.
+func (db *DB) BackupIncrementalTo(ctx context.Context, target BackupTarget, name string, since uint64) (uint64, error) {
+	if !db.opts.opLog {
+		return since, errors.New("operation log is not enabled")
+	}
+
+	// The delta is staged next to the database, as target needs its size up front
+	f, err := os.CreateTemp(filepath.Dir(db.filePath), ".delta-*")
+	if err != nil {
+		return since, fmt.Errorf("failed to create delta file: %v", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	var header deltaHeader
+	bw := bufio.NewWriter(f)
+	err = db.view("BackupIncrementalTo", "", func(tx *bbolt.Tx) error {
+		var err error
+		header, err = writeDelta(db, tx, bw, since)
+		return err
+	})
+	if err != nil {
+		return since, err
+	}
+	if err := bw.Flush(); err != nil {
+		return since, err
+	}
+
+	size, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return since, err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return since, err
+	}
+	if err := target.Put(ctx, name, f, size); err != nil {
+		return since, fmt.Errorf("failed to back up to %s: %w", name, err)
+	}
+	return header.To, nil
+}
+
+// writeDelta writes the incremental backup of the keys changed after since.
+func writeDelta(db *DB, tx *bbolt.Tx, w io.Writer, since uint64) (deltaHeader, error) {
+	enc := json.NewEncoder(w)
+	header := deltaHeader{From: since, To: since}
+	oplog := tx.Bucket([]byte(opLogBucket))
+	if oplog != nil {
+		header.To = oplog.Sequence()
+	}
+	if since > header.To {
+		return header, fmt.Errorf("sequence %d is past the last logged sequence %d", since, header.To)
+	}
+	if err := enc.Encode(header); err != nil {
+		return header, err
+	}
+
+	write := func(name []byte) error {
+		if isInternalBucket(tx, name) {
+			return nil
+		}
+		if b := db.liveBucket(tx, string(name)); b != nil {
+			return enc.Encode(deltaRecord{exportRecord: newExportRecord(tx, name, b)})
+		}
+		return enc.Encode(deltaRecord{exportRecord: exportRecord{Key: string(name)}, Deleted: true})
+	}
+
+	if since == 0 {
+		return header, tx.ForEach(func(name []byte, _ *bbolt.Bucket) error {
+			if db.liveBucket(tx, string(name)) == nil {
+				return nil
+			}
+			return write(name)
+		})
+	}
+
+	c := oplog.Cursor()
+	if since < header.To {
+		if k, _ := c.First(); k == nil || binary.BigEndian.Uint64(k) > since+1 {
+			oldest := header.To + 1
+			if k != nil {
+				oldest = binary.BigEndian.Uint64(k)
+			}
+			return header, fmt.Errorf("%w: requested sequence %d, oldest available %d", ErrOpLogTruncated, since+1, oldest)
+		}
+	}
+	changed := make(map[string]bool)
+	for k, v := c.Seek(encodeSeq(since + 1)); k != nil; k, v = c.Next() {
+		var ev Event
+		if err := json.Unmarshal(v, &ev); err != nil {
+			return header, fmt.Errorf("failed to decode operation log entry %d: %v", binary.BigEndian.Uint64(k), err)
+		}
+		for _, key := range []string{ev.Key, ev.Target} {
+			if key == "" || changed[key] {
+				continue
+			}
+			changed[key] = true
+			if err := write([]byte(key)); err != nil {
+				return header, err
+			}
+		}
+	}
+	return header, nil
+}
+
+// ApplyIncremental applies an incremental backup written by BackupIncrementalTo and
+// returns the operation log sequence the database is now restored up to. Deltas must be
+// applied in the order they were taken, starting with the base one on an empty
+// database, or the next one after restoring a file backup of a database with the
+// operation log enabled: ErrDeltaOutOfSequence is returned for any other delta, which
+// is then left unapplied. Each key of the delta replaces the key of the database, and
+// the whole delta is applied in a single transaction.
+func (db *DB) ApplyIncremental(r io.Reader) (uint64, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var header deltaHeader
+	if err := dec.Decode(&header); err != nil {
+		return 0, fmt.Errorf("failed to decode delta header: %v", err)
+	}
+	var records []deltaRecord
+	for {
+		var rec deltaRecord
+		if err := dec.Decode(&rec); err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, fmt.Errorf("failed to decode delta record: %v", err)
+		}
+		records = append(records, rec)
+	}
+
+	err := db.update("ApplyIncremental", "", func(tx *txn) error {
+		restored, err := restoredSeq(tx.Tx)
+		if err != nil {
+			return err
+		}
+		if header.From != restored {
+			return fmt.Errorf("%w: delta starts after sequence %d, database is restored up to %d", ErrDeltaOutOfSequence, header.From, restored)
+		}
+
+		for _, rec := range records {
+			if isInternalBucket(tx.Tx, []byte(rec.Key)) {
+				return fmt.Errorf("delta writes internal key %s", rec.Key)
+			}
+			err := deleteKey(tx, rec.Key)
+			if err == nil {
+				tx.record(Event{Type: EventDelete, Key: rec.Key})
+			} else if !errors.Is(err, ErrKeyNotFound) {
+				return err
+			}
+			if rec.Deleted {
+				continue
+			}
+			if err := importRecord(tx, rec.exportRecord); err != nil {
+				return err
+			}
+		}
+
+		bucket, err := tx.CreateBucketIfNotExists([]byte(metaBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create meta bucket: %v", err)
+		}
+		return bucket.Put(incrementalKey, []byte(strconv.FormatUint(header.To, 10)))
+	})
+	if err != nil {
+		return 0, err
+	}
+	return header.To, nil
+}
+
+// restoredSeq returns the operation log sequence the database is restored up to: the
+// one recorded by the last delta applied, or else the last sequence of its own log,
+// which is where the deltas of a database restored from a file backup start.
+func restoredSeq(tx *bbolt.Tx) (uint64, error) {
+	if bucket := tx.Bucket([]byte(metaBucket)); bucket != nil {
+		if v := bucket.Get(incrementalKey); v != nil {
+			seq, err := strconv.ParseUint(string(v), 10, 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid restored sequence %q", v)
+			}
+			return seq, nil
+		}
+	}
+	if bucket := tx.Bucket([]byte(opLogBucket)); bucket != nil {
+		return bucket.Sequence(), nil
+	}
+	return 0, nil
+}
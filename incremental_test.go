@@ -0,0 +1,86 @@
+package jungledb
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+// TestBackupIncremental tests restoring a base backup and a delta in sequence.
+func TestBackupIncremental(t *testing.T) {
+	db, err := Open("testdata/incremental.db", WithOpLog())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	ctx := context.Background()
+	target := DirTarget("testdata/deltas")
+
+	if err := db.Hset("user:1", "name", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Hset("user:2", "name", []byte("bob")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Zadd("scores", 1, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	seq, err := db.BackupIncrementalTo(ctx, target, "0.delta", 0)
+	if err != nil {
+		t.Fatalf("base backup failed: %v", err)
+	}
+
+	if err := db.Hset("user:1", "name", []byte("carol")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.HdelBucket("user:2"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if err := db.Rename("scores", "ranking"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if _, err := db.BackupIncrementalTo(ctx, target, "1.delta", seq); err != nil {
+		t.Fatalf("incremental backup failed: %v", err)
+	}
+
+	restored, err := Open("testdata/incremental_restored.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer restored.Close()
+	apply := func(name string) error {
+		f, err := os.Open("testdata/deltas/" + name)
+		if err != nil {
+			t.Fatalf("failed to open delta: %v", err)
+		}
+		defer f.Close()
+		_, err = restored.ApplyIncremental(f)
+		return err
+	}
+	if err := apply("1.delta"); !errors.Is(err, ErrDeltaOutOfSequence) {
+		t.Errorf("expected ErrDeltaOutOfSequence before the base, got %v", err)
+	}
+	if err := apply("0.delta"); err != nil {
+		t.Fatalf("failed to apply the base: %v", err)
+	}
+	if err := apply("1.delta"); err != nil {
+		t.Fatalf("failed to apply the delta: %v", err)
+	}
+	if err := apply("1.delta"); !errors.Is(err, ErrDeltaOutOfSequence) {
+		t.Errorf("expected ErrDeltaOutOfSequence applying a delta twice, got %v", err)
+	}
+
+	if v, err := restored.Hget("user:1", "name"); err != nil || string(v) != "carol" {
+		t.Errorf("expected user:1 to be updated, got %q %v", v, err)
+	}
+	if v, err := restored.Hget("user:2", "name"); err != nil || v != nil {
+		t.Errorf("expected user:2 to be deleted, got %q %v", v, err)
+	}
+	if score, err := restored.Zscore("ranking", "alice"); err != nil || score != 1 {
+		t.Errorf("expected the renamed sorted set, got %v %v", score, err)
+	}
+	if keys, _, err := restored.ListKeys("scores", "", 0); err != nil || len(keys) != 0 {
+		t.Errorf("expected the old sorted set name to be gone, got %v %v", keys, err)
+	}
+}
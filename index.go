@@ -0,0 +1,302 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// indexPrefix starts the names of the buckets holding indexes, followed by the index
+// name. Each has a "def" key holding the definition it was built for, an "entries"
+// bucket (namespace + field values + key -> empty) and a "keys" bucket (key -> namespace
+// + field values), which finds the entry to replace when a hash changes.
+const indexPrefix = internalPrefix + "index:"
+
+var (
+	indexDefKey     = []byte("def")
+	indexEntriesKey = []byte("entries")
+	indexKeysKey    = []byte("keys")
+)
+
+// ErrUniqueViolation is returned by writes that would give two hashes the same values
+// for the fields of a unique index.
+var ErrUniqueViolation = errors.New("unique constraint violated")
+
+// Index indexes the hashes whose keys start with Prefix by the values of Fields, so they
+// can be found with Lookup instead of scanning them. An index on several fields is
+// composite: its entries are ordered by the first field, then the second and so on.
+// Hashes lacking one of the fields are not indexed. Keys of every namespace are indexed
+// separately, each matching Prefix within its namespace.
+type Index struct {
+	Name   string   `json:"-"`
+	Prefix string   `json:"prefix"`
+	Fields []string `json:"fields"`
+
+	// Unique makes writes that would give two hashes of a namespace the same values for
+	// Fields fail with ErrUniqueViolation, within the writing transaction.
+	Unique bool `json:"unique"`
+}
+
+// WithIndex maintains indexes on every write. Open builds an index that is new or whose
+// definition changed from the hashes already stored, and fails if they break a unique
+// constraint; the buckets of indexes no longer configured are left in place.
+func WithIndex(indexes ...Index) Option {
+	return func(o *options) {
+		o.indexes = append(o.indexes, indexes...)
+	}
+}
+
+// Lookup returns, in index order, the keys of the hashes of db whose indexed fields hold
+// values. With fewer values than the index has fields, the values match its leading
+// fields and the others can hold anything.
+func (db *DB) Lookup(index string, values ...string) ([]string, error) {
+	idx, err := db.index(index)
+	if err != nil {
+		return nil, err
+	}
+	if len(values) > len(idx.Fields) {
+		return nil, fmt.Errorf("index %s has %d fields, got %d values", index, len(idx.Fields), len(values))
+	}
+
+	prefix := appendIndexValue(nil, []byte(db.ns))
+	for _, v := range values {
+		prefix = appendIndexValue(prefix, []byte(v))
+	}
+	var keys []string
+	err = db.view("Lookup", "", func(tx *bbolt.Tx) error {
+		return scanIndex(tx, idx, prefix, nil, func(name string) bool {
+			if key, ok := db.userKey(name); ok && db.liveBucket(tx, name) != nil {
+				keys = append(keys, key)
+			}
+			return true
+		})
+	})
+	return keys, err
+}
+
+// index returns the configured index called name.
+func (db *DB) index(name string) (*Index, error) {
+	for i := range db.opts.indexes {
+		if db.opts.indexes[i].Name == name {
+			return &db.opts.indexes[i], nil
+		}
+	}
+	return nil, fmt.Errorf("no index named %q", name)
+}
+
+// scanIndex calls fn with the key of every entry of idx starting with prefix, in order,
+// until fn returns false. If stop is not nil, the scan ends at the first entry at or
+// after it.
+func scanIndex(tx *bbolt.Tx, idx *Index, prefix, stop []byte, fn func(name string) bool) error {
+	bucket := tx.Bucket([]byte(indexPrefix + idx.Name))
+	if bucket == nil {
+		return nil
+	}
+	entries := bucket.Bucket(indexEntriesKey)
+	if entries == nil {
+		return nil
+	}
+	c := entries.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		if stop != nil && bytes.Compare(k, stop) >= 0 {
+			break
+		}
+		name, ok := indexEntryKey(k, 1+len(idx.Fields))
+		if !ok {
+			return fmt.Errorf("corrupt entry in index %s", idx.Name)
+		}
+		if !fn(name) {
+			break
+		}
+	}
+	return nil
+}
+
+// updateIndexes brings the indexes up to date with the keys changed by tx.
+func (db *DB) updateIndexes(tx *txn) error {
+	if len(db.opts.indexes) == 0 {
+		return nil
+	}
+	done := make(map[string]bool)
+	for _, ev := range tx.events {
+		for _, key := range []string{ev.Key, ev.Target} {
+			if key == "" || done[key] {
+				continue
+			}
+			done[key] = true
+			for i := range db.opts.indexes {
+				if err := indexKey(tx.Tx, &db.opts.indexes[i], key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// indexKey replaces the entry of key in idx by one for its current contents.
+func indexKey(tx *bbolt.Tx, idx *Index, key string) error {
+	if isInternalBucket(tx, []byte(key)) {
+		return nil
+	}
+	ns, user, ok := splitKey(key)
+	if !ok || !strings.HasPrefix(user, idx.Prefix) {
+		return nil
+	}
+
+	bucket, err := tx.CreateBucketIfNotExists([]byte(indexPrefix + idx.Name))
+	if err != nil {
+		return fmt.Errorf("failed to create index bucket: %v", err)
+	}
+	entries, err := bucket.CreateBucketIfNotExists(indexEntriesKey)
+	if err != nil {
+		return err
+	}
+	keys, err := bucket.CreateBucketIfNotExists(indexKeysKey)
+	if err != nil {
+		return err
+	}
+
+	if old := keys.Get([]byte(key)); old != nil {
+		if err := entries.Delete(append(old[:len(old):len(old)], key...)); err != nil {
+			return err
+		}
+		if err := keys.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+
+	hash := tx.Bucket([]byte(key))
+	if hash == nil || keyType(tx, []byte(key)) != typeHash {
+		return nil
+	}
+	prefix := appendIndexValue(nil, []byte(ns))
+	for _, field := range idx.Fields {
+		value, ok := getField(hash, field)
+		if !ok {
+			return nil
+		}
+		prefix = appendIndexValue(prefix, value)
+	}
+
+	if idx.Unique {
+		if k, _ := entries.Cursor().Seek(prefix); k != nil && bytes.HasPrefix(k, prefix) {
+			other, _ := indexEntryKey(k, 1+len(idx.Fields))
+			_, other, _ = splitKey(other)
+			return fmt.Errorf("%w: %s has the same %s as %s", ErrUniqueViolation, user, strings.Join(idx.Fields, ", "), other)
+		}
+	}
+	if err := entries.Put(append(prefix[:len(prefix):len(prefix)], key...), []byte{}); err != nil {
+		return err
+	}
+	return keys.Put([]byte(key), prefix)
+}
+
+// buildIndexes builds the configured indexes whose stored definition is missing or
+// differs, from the hashes in the database.
+func (db *DB) buildIndexes() error {
+	for i := range db.opts.indexes {
+		idx := &db.opts.indexes[i]
+		if idx.Name == "" || len(idx.Fields) == 0 {
+			return fmt.Errorf("index %q needs a name and at least one field", idx.Name)
+		}
+		def, err := json.Marshal(idx)
+		if err != nil {
+			return err
+		}
+		err = db.db.Update(func(tx *bbolt.Tx) error {
+			name := []byte(indexPrefix + idx.Name)
+			if bucket := tx.Bucket(name); bucket != nil {
+				if bytes.Equal(bucket.Get(indexDefKey), def) {
+					return nil
+				}
+				if err := tx.DeleteBucket(name); err != nil {
+					return err
+				}
+			}
+			bucket, err := tx.CreateBucket(name)
+			if err != nil {
+				return fmt.Errorf("failed to create index bucket: %v", err)
+			}
+			if err := bucket.Put(indexDefKey, def); err != nil {
+				return err
+			}
+			return tx.ForEach(func(key []byte, _ *bbolt.Bucket) error {
+				return indexKey(tx, idx, string(key))
+			})
+		})
+		if err != nil {
+			return fmt.Errorf("failed to build index %s: %w", idx.Name, err)
+		}
+	}
+	return nil
+}
+
+// splitKey splits a stored key name into the namespace it belongs to, as the prefix of
+// its namespace handle, and its name there. It returns false for the keys of locks,
+// leases, links, blobs and soft deleted keys.
+func splitKey(name string) (ns, key string, ok bool) {
+	key = name
+	for strings.HasPrefix(key, namespacePrefix) {
+		i := strings.IndexByte(key, 0)
+		if i < 0 {
+			break
+		}
+		key = key[i+1:]
+	}
+	ns = name[:len(name)-len(key)]
+	if isReservedKey(key) {
+		return ns, key, false
+	}
+	return ns, key, true
+}
+
+// appendIndexValue appends an order-preserving encoding of v to b: NUL bytes are escaped
+// as 0x00 0xFF and the value ends with 0x00 0x01, so that entries sort by their first
+// value, then their second and so on.
+func appendIndexValue(b, v []byte) []byte {
+	for _, c := range v {
+		if c == 0 {
+			b = append(b, 0, 0xFF)
+		} else {
+			b = append(b, c)
+		}
+	}
+	return append(b, 0, 1)
+}
+
+// indexEntryKey returns the key at the end of an index entry holding n encoded values.
+func indexEntryKey(entry []byte, n int) (string, bool) {
+	for ; n > 0; n-- {
+		var ok bool
+		if _, entry, ok = decodeIndexValue(entry); !ok {
+			return "", false
+		}
+	}
+	return string(entry), true
+}
+
+// decodeIndexValue decodes the value at the start of b encoded by appendIndexValue and
+// returns it with the bytes that follow it.
+func decodeIndexValue(b []byte) (value, rest []byte, ok bool) {
+	for i := 0; i+1 < len(b); i++ {
+		if b[i] != 0 {
+			value = append(value, b[i])
+			continue
+		}
+		switch b[i+1] {
+		case 0xFF:
+			value = append(value, 0)
+			i++
+		case 1:
+			return value, b[i+2:], true
+		default:
+			return nil, nil, false
+		}
+	}
+	return nil, nil, false
+}
@@ -0,0 +1,146 @@
+package jungledb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestIndexUnique tests that a unique index rejects conflicting writes without applying
+// any part of them and lets a hash keep or give up its values.
+func TestIndexUnique(t *testing.T) {
+	db, err := Open("testdata/index_unique.db", WithIndex(Index{Name: "username", Prefix: "user:", Fields: []string{"name"}, Unique: true}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("user:1", "name", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Hset("user:1", "name", []byte("alice")); err != nil {
+		t.Errorf("expected rewriting the same value to succeed, got %v", err)
+	}
+	if err := db.Hset("user:2", "name", []byte("alice")); !errors.Is(err, ErrUniqueViolation) {
+		t.Errorf("expected ErrUniqueViolation, got %v", err)
+	}
+	err = db.Hmset("user:2", map[string][]byte{"name": []byte("alice"), "email": []byte("a@example.com")})
+	if !errors.Is(err, ErrUniqueViolation) {
+		t.Errorf("expected ErrUniqueViolation from Hmset, got %v", err)
+	}
+	if _, found, err := db.HgetOK("user:2", "email"); err != nil || found {
+		t.Errorf("expected the failed Hmset to write nothing, got %v %v", found, err)
+	}
+
+	// Keys outside the prefix are not constrained
+	if err := db.Hset("admin:1", "name", []byte("alice")); err != nil {
+		t.Errorf("expected a key outside the prefix to be accepted, got %v", err)
+	}
+
+	// Renaming the value or deleting the hash frees it
+	if err := db.Hset("user:1", "name", []byte("alicia")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Hset("user:2", "name", []byte("alice")); err != nil {
+		t.Errorf("expected the freed value to be accepted, got %v", err)
+	}
+	if err := db.HdelBucket("user:2"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if err := db.Hset("user:3", "name", []byte("alice")); err != nil {
+		t.Errorf("expected the value of a deleted hash to be accepted, got %v", err)
+	}
+
+	// Each namespace has its own values
+	if err := db.Namespace("tenant").Hset("user:1", "name", []byte("alice")); err != nil {
+		t.Errorf("expected another namespace to be accepted, got %v", err)
+	}
+	if keys, err := db.Namespace("tenant").Lookup("username", "alice"); err != nil || !reflect.DeepEqual(keys, []string{"user:1"}) {
+		t.Errorf("expected [user:1] in the namespace, got %v %v", keys, err)
+	}
+	if keys, err := db.Lookup("username", "alice"); err != nil || !reflect.DeepEqual(keys, []string{"user:3"}) {
+		t.Errorf("expected [user:3], got %v %v", keys, err)
+	}
+}
+
+// TestIndexComposite tests lookups on all or the leading fields of a composite index and
+// that renames and expiry keep it up to date.
+func TestIndexComposite(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	db, err := Open("testdata/index_composite.db", WithExpirySweep(-1), WithClock(clock), WithIndex(Index{Name: "city", Prefix: "user:", Fields: []string{"country", "city"}}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	users := map[string][2]string{
+		"user:1": {"fr", "paris"},
+		"user:2": {"fr", "lyon"},
+		"user:3": {"de", "berlin"},
+		"user:4": {"fr", "paris"},
+	}
+	for key, v := range users {
+		if err := db.Hmset(key, map[string][]byte{"country": []byte(v[0]), "city": []byte(v[1])}); err != nil {
+			t.Fatalf("Hmset failed: %v", err)
+		}
+	}
+	if err := db.Hset("user:5", "country", []byte("fr")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	if keys, err := db.Lookup("city", "fr", "paris"); err != nil || !reflect.DeepEqual(keys, []string{"user:1", "user:4"}) {
+		t.Errorf("expected [user:1 user:4], got %v %v", keys, err)
+	}
+	if keys, err := db.Lookup("city", "fr"); err != nil || !reflect.DeepEqual(keys, []string{"user:2", "user:1", "user:4"}) {
+		t.Errorf("expected the French users ordered by city, got %v %v", keys, err)
+	}
+	if _, err := db.Lookup("city", "fr", "paris", "extra"); err == nil {
+		t.Errorf("expected too many values to fail")
+	}
+	if _, err := db.Lookup("missing"); err == nil {
+		t.Errorf("expected an unknown index to fail")
+	}
+
+	if err := db.Rename("user:4", "user:6"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if err := db.Expire("user:1", time.Minute); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	clock.advance(time.Minute)
+	if keys, err := db.Lookup("city", "fr", "paris"); err != nil || !reflect.DeepEqual(keys, []string{"user:6"}) {
+		t.Errorf("expected [user:6], got %v %v", keys, err)
+	}
+}
+
+// TestIndexBuild tests that Open indexes the hashes already stored and fails when they
+// break a unique constraint.
+func TestIndexBuild(t *testing.T) {
+	path := "testdata/index_build.db"
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	for _, key := range []string{"user:1", "user:2"} {
+		if err := db.Hset(key, "name", []byte("bob")); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	if _, err := Open(path, WithIndex(Index{Name: "username", Prefix: "user:", Fields: []string{"name"}, Unique: true})); !errors.Is(err, ErrUniqueViolation) {
+		t.Fatalf("expected ErrUniqueViolation for existing duplicates, got %v", err)
+	}
+
+	db, err = Open(path, WithIndex(Index{Name: "username", Prefix: "user:", Fields: []string{"name"}}))
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	if keys, err := db.Lookup("username", "bob"); err != nil || !reflect.DeepEqual(keys, []string{"user:1", "user:2"}) {
+		t.Errorf("expected the existing hashes to be indexed, got %v %v", keys, err)
+	}
+}
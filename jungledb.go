@@ -5,81 +5,321 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"log/slog"
 	"math"
+	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.etcd.io/bbolt"
 )
 
 // DB represents the database instance.
+// Handles derived with WithActor or Namespace share the database with the DB they came from.
 type DB struct {
+	*core
+	actor  string // Recorded in the audit log for writes made through this handle
+	reason string
+	ns     string    // Prepended to keys by namespace handles, see Namespace
+	at     time.Time // Current time of the handle if set, see At
+}
+
+// core is the state shared by every handle of an open database.
+type core struct {
 	db       *bbolt.DB
 	filePath string
-	mu       sync.RWMutex
+	readOnly bool // Opened with OpenReadOnly
+	opts     options
+	log      *slog.Logger
+	mu       sync.RWMutex // Held shared by operations in flight, exclusively by Close
+	locks    keyLocks     // Taken by writes, by key
+	commits  commitOrder  // Orders the steps writes take after they commit
+
+	watchMu  sync.Mutex
+	watchers map[*watcher]struct{}
+	triggers triggers
+	txnStats transactionStats
+
+	appliedSeqs seqNotifier // Notified when a replica applies mutations
+
+	attachMu sync.Mutex
+	attached map[string]*DB // Databases attached by alias, see Attach
+
+	shadowMu sync.Mutex // Serializes StartShadow and StopShadow
+	shadow   atomic.Pointer[shadowMirror]
+
+	writeGen atomic.Uint64 // Incremented by every committed write, see WithReadCoalescing
+	flights  readFlights
+	hot      hotCache
+	heatmap  heatmap
+
+	slowOps      slowOpLog
+	metrics      metricsState
+	accesses     pendingAccesses
+	tierAccesses tierReads
+
+	stopSweep     chan struct{}
+	sweepDone     chan struct{}
+	stopSweepOnce sync.Once
+	maint         maintenance
+	throttle      throttle
+
+	closed  bool        // Set by Close, guarded by mu
+	closing atomic.Bool // Set by Shutdown to turn away new operations while it drains
+
+	serveMu   sync.Mutex
+	listeners map[net.Listener]struct{} // Listeners of running servers, closed by Shutdown
+	servers   sync.WaitGroup
 }
 
-// Open opens or creates a JungleDB database file.
-func Open(filePath string) (*DB, error) {
+// Open opens or creates a JungleDB database file. A new file is initialized under a
+// temporary name and renamed into place, so a crash during creation never leaves a
+// partial database behind.
+func Open(filePath string, opts ...Option) (*DB, error) {
 	if err := ensureDir(filePath); err != nil {
 		return nil, err
 	}
+	if err := createFile(filePath, opts); err != nil {
+		return nil, err
+	}
+	return open(filePath, false, opts)
+}
 
+// OpenReadOnly opens an existing database file for reading only; writes fail with
+// ErrReadOnly and no expiry sweeper runs. The file lock is shared in this mode, so any
+// number of processes, such as analytics jobs, can read the file at once. bbolt does not
+// allow this while a process has the file open with Open, because readers could otherwise
+// see pages the writer is reusing: OpenReadOnly then fails after a one second timeout,
+// and readers should go through that process instead, for example with ServeUnix.
+func OpenReadOnly(filePath string, opts ...Option) (*DB, error) {
+	return open(filePath, true, opts)
+}
+
+func open(filePath string, readOnly bool, opts []Option) (*DB, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+	mmapSize := 0
+	if !readOnly {
+		mmapSize = int(o.preallocate)
+	}
 	db, err := bbolt.Open(filePath, 0666, &bbolt.Options{
-		Timeout: 1 * time.Second,
+		Timeout:         1 * time.Second,
+		ReadOnly:        readOnly,
+		InitialMmapSize: mmapSize,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %v", err)
 	}
+	if o.growthIncrement > 0 {
+		db.AllocSize = o.growthIncrement
+	}
 
-	return &DB{
+	jdb := &DB{core: &core{
 		db:       db,
 		filePath: filePath,
-	}, nil
+		readOnly: readOnly,
+		opts:     o,
+	}}
+	jdb.slowOps.capacity = jdb.opts.slowLogCapacity
+	jdb.throttle.limits = o.backgroundLimits
+	jdb.log = jdb.opts.logger
+	if jdb.log == nil {
+		jdb.log = slog.New(slog.DiscardHandler)
+	}
+	if err := jdb.checkFormat(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := jdb.preallocate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if !readOnly {
+		if err := jdb.buildIndexes(); err != nil {
+			db.Close()
+			return nil, err
+		}
+		if err := jdb.buildViews(); err != nil {
+			db.Close()
+			return nil, err
+		}
+		if err := jdb.migrate(); err != nil {
+			db.Close()
+			return nil, err
+		}
+		jdb.startSweeper()
+		jdb.startMaintenance()
+	}
+	jdb.publishExpvar()
+	return jdb, nil
 }
 
-// Close closes the database.
+// Close closes the database. It waits for in-flight operations to finish; operations
+// started afterwards return ErrClosed. The databases attached to it are closed too.
+// Closing a closed database does nothing.
 func (db *DB) Close() error {
+	db.stopSweeper()
+	db.stopMaintenance()
+	db.unpublishExpvar()
+	db.StopShadow()
+	return errors.Join(db.closeFile(false), db.detachAll())
+}
+
+// closeFile waits for in-flight operations and closes the database file, first flushing
+// it to disk if sync is set.
+func (db *DB) closeFile(sync bool) error {
 	db.mu.Lock()
 	defer db.mu.Unlock()
-	return db.db.Close()
+	if db.closed {
+		return nil
+	}
+	var syncErr error
+	if sync && !db.readOnly {
+		if err := db.db.Sync(); err != nil {
+			syncErr = fmt.Errorf("failed to sync database: %v", err)
+		}
+	}
+	db.closed = true
+	db.closeWatchers()
+	db.appliedSeqs.notify()
+	return errors.Join(syncErr, db.db.Close())
+}
+
+// isClosed reports whether Close has been called.
+func (db *DB) isClosed() bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.closed
 }
 
 // Hset sets the field value in a hash.
 // Accepts []byte for value to minimize conversions.
 func (db *DB) Hset(key, field string, value []byte) error {
-	return db.update(func(tx *bbolt.Tx) error {
-		bucket, err := tx.CreateBucketIfNotExists([]byte(key))
-		if err != nil {
-			return fmt.Errorf("failed to create bucket: %v", err)
-		}
-		return bucket.Put([]byte(field), value)
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("Hset", key, func(tx *txn) error {
+		return hset(tx, key, field, value)
 	})
 }
 
+// hset sets a hash field inside an existing read-write transaction.
+func hset(tx *txn, key, field string, value []byte) error {
+	if err := checkType(tx.Tx, key, typeHash); err != nil {
+		return err
+	}
+	bucket, err := tx.CreateBucketIfNotExists([]byte(key))
+	if err != nil {
+		return fmt.Errorf("failed to create bucket: %v", err)
+	}
+	tx.record(Event{Type: EventHset, Key: key, Field: field, Value: value})
+	return bucket.Put([]byte(field), value)
+}
+
 // Hget retrieves the value of a field in a hash.
-// Returns []byte to minimize conversions.
+// Returns []byte to minimize conversions. A missing field reads as nil while a field
+// holding an empty value reads as a non-nil empty slice; HgetOK reports presence explicitly.
 func (db *DB) Hget(key, field string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
+	value, _, err := db.hget("Hget", key, field)
+	return value, err
+}
+
+// HgetOK is like Hget but also reports whether the field exists, so a missing field
+// and a field holding an empty value are never confused.
+func (db *DB) HgetOK(key, field string) (value []byte, found bool, err error) {
+	if err := checkKey(key); err != nil {
+		return nil, false, err
+	}
+	key = db.nsKey(key)
+	return db.hget("HgetOK", key, field)
+}
+
+// HgetAppend appends the value of a field in a hash to dst and returns the extended
+// buffer, leaving dst as is if the field does not exist. The value is copied out of the
+// transaction into dst, so a caller reusing its buffer across reads, for example with
+// HgetAppend(buf[:0], key, field), copies values without allocating.
+func (db *DB) HgetAppend(dst []byte, key, field string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
+	err := db.view("HgetAppend", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err
+		}
+		if v, ok := getField(bucket, field); ok {
+			dst = append(dst, v...)
+			db.metrics.addRead(len(v))
+		}
+		return nil
+	})
+	return dst, err
+}
+
+func (db *DB) hget(op, key, field string) ([]byte, bool, error) {
+	if db.isHot(key) {
+		h, err := db.hotRead(op, key)
+		if err != nil {
+			return nil, false, err
+		}
+		value, found := h.field(field)
+		db.metrics.addRead(len(value))
+		return value, found, nil
+	}
+	if db.opts.coalesceReads {
+		return db.coalescedHget(op, key, field)
+	}
+	return db.readField(op, key, field, false)
+}
+
+// readField reads a hash field for hget, copying its value out of the transaction if
+// clone is set.
+func (db *DB) readField(op, key, field string, clone bool) ([]byte, bool, error) {
 	var value []byte
-	err := db.view(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(key))
-		if bucket == nil {
-			return nil // Bucket does not exist, return nil
+	var found bool
+	err := db.view(op, key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err // Bucket does not exist, return nil
+		}
+		value, found = getField(bucket, field)
+		if found && clone {
+			value = append([]byte{}, value...)
 		}
-		value = bucket.Get([]byte(field))
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return nil, false, err
 	}
-	return value, nil
+	if db.cacheFor(key) >= 0 {
+		db.metrics.addCacheRead(found)
+	}
+	db.metrics.addRead(len(value))
+	return value, found, nil
 }
 
 // Hmset sets multiple field values in a hash.
 func (db *DB) Hmset(key string, fields map[string][]byte) error {
-	return db.update(func(tx *bbolt.Tx) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("Hmset", key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
 		bucket, err := tx.CreateBucketIfNotExists([]byte(key))
 		if err != nil {
 			return fmt.Errorf("failed to create bucket: %v", err)
@@ -89,23 +329,70 @@ func (db *DB) Hmset(key string, fields map[string][]byte) error {
 			if err := bucket.Put([]byte(field), value); err != nil {
 				return err
 			}
+			tx.record(Event{Type: EventHset, Key: key, Field: field, Value: value})
 		}
 		return nil
 	})
 }
 
+// getField looks up a field and reports whether it exists. Found values are never nil:
+// bbolt may hand back nil for a field stored with an empty value, so presence is decided
+// by the cursor position rather than by the value.
+func getField(bucket *bbolt.Bucket, field string) ([]byte, bool) {
+	k, v := bucket.Cursor().Seek([]byte(field))
+	if k == nil || string(k) != field {
+		return nil, false
+	}
+	if v == nil {
+		v = []byte{}
+	}
+	return v, true
+}
+
+// GetResult is the value of one field read by HmgetOK.
+type GetResult struct {
+	Value []byte // Field value, nil if the field does not exist
+	Found bool   // Whether the field exists, even if its value is empty
+}
+
 // Hmget retrieves the values of multiple fields in a hash.
+// Missing fields read as nil and fields holding an empty value as non-nil empty slices.
 func (db *DB) Hmget(key string, fields []string) ([][]byte, error) {
-	values := make([][]byte, len(fields))
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
+	results, err := db.hmget("Hmget", key, fields)
+	if err != nil {
+		return nil, err
+	}
+	values := make([][]byte, len(results))
+	for i, r := range results {
+		values[i] = r.Value
+	}
+	return values, nil
+}
 
-	err := db.view(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(key))
-		if bucket == nil {
-			return nil // Bucket does not exist, return slice of nils
+// HmgetOK is like Hmget but reports for each field whether it exists.
+func (db *DB) HmgetOK(key string, fields []string) ([]GetResult, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
+	return db.hmget("HmgetOK", key, fields)
+}
+
+func (db *DB) hmget(op, key string, fields []string) ([]GetResult, error) {
+	results := make([]GetResult, len(fields))
+
+	err := db.view(op, key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err // Bucket does not exist, every field is missing
 		}
 
 		for i, field := range fields {
-			values[i] = bucket.Get([]byte(field))
+			results[i].Value, results[i].Found = getField(bucket, field)
 		}
 		return nil
 	})
@@ -114,88 +401,172 @@ func (db *DB) Hmget(key string, fields []string) ([][]byte, error) {
 		return nil, err
 	}
 
-	return values, nil
+	db.metrics.addRead(resultBytes(results))
+	return results, nil
 }
 
 // Hincr increments the integer value of a field in a hash.
 // Values are stored and retrieved as 8-byte binary integers.
 func (db *DB) Hincr(key, field string, delta int64) (int64, error) {
-	var newValue int64
-	err := db.update(func(tx *bbolt.Tx) error {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	key = db.nsKey(key)
+	newValue, err := db.hincr("Hincr", key, field, delta, OverflowError, false)
+	return int64(newValue), err
+}
+
+// hincr increments the counter field of the hash key, returning its new value as stored.
+func (db *DB) hincr(op, key, field string, delta int64, overflow Overflow, unsigned bool) (uint64, error) {
+	var newValue uint64
+	err := db.update(op, key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
 		bucket, err := tx.CreateBucketIfNotExists([]byte(key))
 		if err != nil {
 			return fmt.Errorf("failed to create bucket: %v", err)
 		}
+		newValue, err = incrField(tx, bucket, key, field, delta, overflow, unsigned)
+		return err
+	})
 
-		currentValueBytes := bucket.Get([]byte(field))
-		currentValue := int64(0)
+	if err != nil {
+		return 0, err
+	}
+
+	return newValue, nil
+}
 
-		if currentValueBytes != nil {
-			if len(currentValueBytes) != 8 {
-				return errors.New("field value is not a valid 8-byte integer")
+// HincrMulti increments the integer values of several fields of a hash by their deltas,
+// in a single transaction, and returns the new values by field. If any field would
+// overflow, or holds something other than an integer, none is incremented and the error
+// names the field.
+func (db *DB) HincrMulti(key string, deltas map[string]int64) (map[string]int64, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
+	fields := make([]string, 0, len(deltas))
+	for field := range deltas {
+		fields = append(fields, field)
+	}
+	slices.Sort(fields) // Record events in a stable order
+	values := make(map[string]int64, len(deltas))
+	err := db.update("HincrMulti", key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
+		if len(fields) == 0 {
+			return nil
+		}
+		bucket, err := tx.CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		for _, field := range fields {
+			value, err := incrField(tx, bucket, key, field, deltas[field], OverflowError, false)
+			if err != nil {
+				return fmt.Errorf("field %q: %w", field, err)
 			}
-			currentValue = int64(binary.BigEndian.Uint64(currentValueBytes))
+			values[field] = int64(value)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
 
-		newValue = currentValue + delta
+// incrField increments the counter field in the bucket of the hash key by delta and
+// returns its new value as stored: the bits of an int64, or a uint64 if unsigned.
+func incrField(tx *txn, bucket *bbolt.Bucket, key, field string, delta int64, overflow Overflow, unsigned bool) (uint64, error) {
+	currentValueBytes := bucket.Get([]byte(field))
+	currentValue := uint64(0)
 
-		// Check for overflow
-		if (delta > 0 && newValue < currentValue) || (delta < 0 && newValue > currentValue) {
-			return errors.New("integer overflow")
+	if currentValueBytes != nil {
+		if len(currentValueBytes) != 8 {
+			return 0, fmt.Errorf("%w: field value is not a valid 8-byte integer", ErrWrongType)
 		}
+		currentValue = binary.BigEndian.Uint64(currentValueBytes)
+	}
 
-		// Save new value as 8-byte binary
-		newValueBytes := make([]byte, 8)
-		binary.BigEndian.PutUint64(newValueBytes, uint64(newValue))
-		return bucket.Put([]byte(field), newValueBytes)
-	})
-
+	newValue, err := addCounter(currentValue, delta, overflow, unsigned)
 	if err != nil {
 		return 0, err
 	}
 
-	return newValue, nil
+	// Save new value as 8-byte binary
+	newValueBytes := binary.BigEndian.AppendUint64(nil, newValue)
+	tx.record(Event{Type: EventHset, Key: key, Field: field, Value: newValueBytes})
+	return newValue, bucket.Put([]byte(field), newValueBytes)
 }
 
 // HgetInt retrieves the integer value of a field in a hash.
-// Values are retrieved as 8-byte binary integers.
+// Values are retrieved as 8-byte binary integers. A missing field reads as 0.
 func (db *DB) HgetInt(key, field string) (int64, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	key = db.nsKey(key)
+	value, _, err := db.hgetInt("HgetInt", key, field)
+	return value, err
+}
+
+// HgetIntOK is like HgetInt but also reports whether the field exists, so a missing
+// field is not mistaken for a stored 0.
+func (db *DB) HgetIntOK(key, field string) (value int64, found bool, err error) {
+	if err := checkKey(key); err != nil {
+		return 0, false, err
+	}
+	key = db.nsKey(key)
+	return db.hgetInt("HgetIntOK", key, field)
+}
+
+func (db *DB) hgetInt(op, key, field string) (int64, bool, error) {
 	var value int64
-	err := db.view(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(key))
-		if bucket == nil {
-			return nil // Bucket does not exist, return 0
+	var found bool
+	err := db.view(op, key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err // Bucket does not exist, return 0
 		}
 
-		valueBytes := bucket.Get([]byte(field))
-		if valueBytes == nil {
+		valueBytes, ok := getField(bucket, field)
+		if !ok {
 			return nil // Field does not exist, return 0
 		}
 
 		if len(valueBytes) != 8 {
-			return errors.New("field value is not a valid 8-byte integer")
+			return fmt.Errorf("%w: field value is not a valid 8-byte integer", ErrWrongType)
 		}
 		value = int64(binary.BigEndian.Uint64(valueBytes))
+		found = true
 		return nil
 	})
 
 	if err != nil {
-		return 0, err
+		return 0, false, err
 	}
 
-	return value, nil
+	return value, found, nil
 }
 
 // HhasKey checks if a field exists in a hash.
 func (db *DB) HhasKey(key, field string) (bool, error) {
+	if err := checkKey(key); err != nil {
+		return false, err
+	}
+	key = db.nsKey(key)
 	var exists bool
-	err := db.view(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(key))
-		if bucket == nil {
-			return nil // Bucket does not exist, return false
+	err := db.view("HhasKey", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err // Bucket does not exist, return false
 		}
 
-		exists = bucket.Get([]byte(field)) != nil
+		_, exists = getField(bucket, field)
 		return nil
 	})
 
@@ -208,25 +579,82 @@ func (db *DB) HhasKey(key, field string) (bool, error) {
 
 // Hdel deletes a field from a hash.
 func (db *DB) Hdel(key, field string) error {
-	return db.update(func(tx *bbolt.Tx) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("Hdel", key, func(tx *txn) error {
+		return hdel(tx, key, field)
+	})
+}
+
+// HdelIfEquals deletes a field of a hash only if it holds expected, and reports
+// whether it did, so that releasing a lock cannot delete one another holder took since.
+func (db *DB) HdelIfEquals(key, field string, expected []byte) (bool, error) {
+	if err := checkKey(key); err != nil {
+		return false, err
+	}
+	key = db.nsKey(key)
+	var deleted bool
+	err := db.update("HdelIfEquals", key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
 		bucket := tx.Bucket([]byte(key))
 		if bucket == nil {
 			return nil // Bucket does not exist, nothing to delete
 		}
-
+		if value, ok := getField(bucket, field); !ok || !bytes.Equal(value, expected) {
+			return nil
+		}
+		deleted = true
+		tx.record(Event{Type: EventHdel, Key: key, Field: field})
 		return bucket.Delete([]byte(field))
 	})
+	if err != nil {
+		return false, err
+	}
+	return deleted, nil
+}
+
+// hdel deletes a hash field inside an existing read-write transaction.
+func hdel(tx *txn, key, field string) error {
+	if err := checkType(tx.Tx, key, typeHash); err != nil {
+		return err
+	}
+	bucket := tx.Bucket([]byte(key))
+	if bucket == nil {
+		return nil // Bucket does not exist, nothing to delete
+	}
+
+	if bucket.Get([]byte(field)) != nil {
+		tx.record(Event{Type: EventHdel, Key: key, Field: field})
+	}
+	return bucket.Delete([]byte(field))
 }
 
 // Hmdel deletes multiple fields from a hash.
 func (db *DB) Hmdel(key string, fields []string) error {
-	return db.update(func(tx *bbolt.Tx) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("Hmdel", key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
 		bucket := tx.Bucket([]byte(key))
 		if bucket == nil {
 			return nil // Bucket does not exist, nothing to delete
 		}
+		if err := db.saveUndo(tx, "Hmdel", key, false, fields, nil); err != nil {
+			return err
+		}
 
 		for _, field := range fields {
+			if bucket.Get([]byte(field)) != nil {
+				tx.record(Event{Type: EventHdel, Key: key, Field: field})
+			}
 			if err := bucket.Delete([]byte(field)); err != nil {
 				return err
 			}
@@ -238,11 +666,24 @@ func (db *DB) Hmdel(key string, fields []string) error {
 // Hscan scans all fields and values in a hash.
 // Returns map[string][]byte to minimize conversions.
 func (db *DB) Hscan(key string) (map[string][]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
+	if db.isHot(key) {
+		h, err := db.hotRead("Hscan", key)
+		if err != nil {
+			return nil, err
+		}
+		result := h.all()
+		db.metrics.addRead(mapBytes(result))
+		return result, nil
+	}
 	result := make(map[string][]byte)
-	err := db.view(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(key))
-		if bucket == nil {
-			return nil // Bucket does not exist, return empty map
+	err := db.view("Hscan", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err // Bucket does not exist, return empty map
 		}
 
 		return bucket.ForEach(func(k, v []byte) error {
@@ -255,17 +696,22 @@ func (db *DB) Hscan(key string) (map[string][]byte, error) {
 		return nil, err
 	}
 
+	db.metrics.addRead(mapBytes(result))
 	return result, nil
 }
 
 // Hprefix scans fields in a hash that start with a specified prefix.
 // Returns map[string][]byte to minimize conversions.
 func (db *DB) Hprefix(key, prefix string) (map[string][]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
 	result := make(map[string][]byte)
-	err := db.view(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(key))
-		if bucket == nil {
-			return nil // Bucket does not exist, return empty map
+	err := db.view("Hprefix", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err // Bucket does not exist, return empty map
 		}
 
 		cursor := bucket.Cursor()
@@ -282,17 +728,22 @@ func (db *DB) Hprefix(key, prefix string) (map[string][]byte, error) {
 		return nil, err
 	}
 
+	db.metrics.addRead(mapBytes(result))
 	return result, nil
 }
 
 // Hrscan scans all fields and values in a hash in reverse order.
 // Returns map[string][]byte to minimize conversions.
 func (db *DB) Hrscan(key string) (map[string][]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
 	result := make(map[string][]byte)
-	err := db.view(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(key))
-		if bucket == nil {
-			return nil // Bucket does not exist, return empty map
+	err := db.view("Hrscan", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err // Bucket does not exist, return empty map
 		}
 
 		cursor := bucket.Cursor()
@@ -309,124 +760,187 @@ func (db *DB) Hrscan(key string) (map[string][]byte, error) {
 		return nil, err
 	}
 
+	db.metrics.addRead(mapBytes(result))
 	return result, nil
 }
 
-// HdelBucket deletes an entire hash.
+// HdelBucket deletes an entire hash (or sorted set). It returns ErrKeyNotFound if key does not exist.
 func (db *DB) HdelBucket(key string) error {
-	return db.update(func(tx *bbolt.Tx) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("HdelBucket", key, func(tx *txn) error {
 		// Also delete the sorted set secondary index if it exists for this key
 		// This assumes a convention that sorted set secondary indexes are named key + "_members"
 		// If HdelBucket is used for generic bucket deletion, this might need refinement.
-		if err := tx.DeleteBucket([]byte(key + "_members")); err != nil && !errors.Is(err, bbolt.ErrBucketNotFound) {
-			return fmt.Errorf("failed to delete associated sorted set index bucket: %v", err)
+		if err := db.saveUndo(tx, "HdelBucket", key, true, nil, nil); err != nil {
+			return err
+		}
+		if err := deleteKey(tx, key); err != nil {
+			return err
 		}
-		return tx.DeleteBucket([]byte(key))
+		tx.record(Event{Type: EventDelete, Key: key})
+		return nil
 	})
 }
 
+// deleteKey deletes the bucket for key together with its sorted set index, if any.
+// It returns ErrKeyNotFound if key does not exist.
+func deleteKey(tx *txn, key string) error {
+	index := membersBucket(tx.Tx, key)
+	if tx.countFreed {
+		if b := tx.Bucket([]byte(key)); b != nil {
+			tx.freed += bucketBytes(b)
+		}
+		if index != nil {
+			tx.freed += bucketBytes(index)
+		}
+	}
+	if index != nil {
+		if err := tx.DeleteBucket([]byte(key + membersSuffix)); err != nil {
+			return fmt.Errorf("failed to delete associated sorted set index bucket: %v", err)
+		}
+	}
+	if err := markZset(tx.Tx, key, false); err != nil {
+		return err
+	}
+	if err := putExpiry(tx.Tx, key, 0); err != nil {
+		return fmt.Errorf("failed to clear TTL: %v", err)
+	}
+	if err := tx.DeleteBucket([]byte(key)); errors.Is(err, bbolt.ErrBucketNotFound) {
+		return ErrKeyNotFound
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
 // Zadd adds a member to a sorted set.
 // Implements a secondary index for efficient member lookup.
 func (db *DB) Zadd(key string, score float64, member string) error {
-	return db.update(func(tx *bbolt.Tx) error {
-		// Main sorted set bucket (score-ordered)
-		ssBucket, err := tx.CreateBucketIfNotExists([]byte(key))
-		if err != nil {
-			return fmt.Errorf("failed to create sorted set bucket: %v", err)
-		}
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("Zadd", key, func(tx *txn) error {
+		return zadd(tx, key, score, member)
+	})
+}
 
-		// Secondary index bucket for member lookup (member -> score)
-		idxBucket, err := tx.CreateBucketIfNotExists([]byte(key + "_members"))
-		if err != nil {
-			return fmt.Errorf("failed to create member index bucket: %v", err)
-		}
+// zadd adds or updates a sorted set member inside an existing read-write transaction.
+func zadd(tx *txn, key string, score float64, member string) error {
+	if err := checkType(tx.Tx, key, typeZset); err != nil {
+		return err
+	}
 
-		memberBytes := []byte(member)
-		scoreBytes := make([]byte, 8)
-		binary.BigEndian.PutUint64(scoreBytes, math.Float64bits(score))
+	// Main sorted set bucket (score-ordered) and secondary index for member lookup (member -> score)
+	ssBucket, idxBucket, err := createZset(tx.Tx, key)
+	if err != nil {
+		return err
+	}
 
-		// Check for existing score for the member and remove the old entry
-		existingScoreBytes := idxBucket.Get(memberBytes)
-		if existingScoreBytes != nil {
-			oldSsKey := append(existingScoreBytes, memberBytes...)
-			if err := ssBucket.Delete(oldSsKey); err != nil {
-				return fmt.Errorf("failed to delete old sorted set entry for member: %v", err)
-			}
-		}
+	memberBytes := []byte(member)
+	scoreBytes := EncodeScore(score)
 
-		// Store in main sorted set bucket (key: score + member, value: empty)
-		ssKey := append(scoreBytes, memberBytes...)
-		if err := ssBucket.Put(ssKey, []byte{}); err != nil {
-			return fmt.Errorf("failed to put into sorted set bucket: %v", err)
+	// Check for existing score for the member and remove the old entry
+	existingScoreBytes := idxBucket.Get(memberBytes)
+	if existingScoreBytes != nil {
+		oldSsKey := append(existingScoreBytes, memberBytes...)
+		if err := ssBucket.Delete(oldSsKey); err != nil {
+			return fmt.Errorf("failed to delete old sorted set entry for member: %v", err)
 		}
+	}
 
-		// Store in secondary index (key: member, value: score)
-		return idxBucket.Put(memberBytes, scoreBytes)
-	})
+	// Store in main sorted set bucket (key: score + member, value: empty)
+	ssKey := append(scoreBytes, memberBytes...)
+	if err := ssBucket.Put(ssKey, []byte{}); err != nil {
+		return fmt.Errorf("failed to put into sorted set bucket: %v", err)
+	}
+
+	// Store in secondary index (key: member, value: score)
+	tx.record(Event{Type: EventZadd, Key: key, Field: member, Score: score})
+	return idxBucket.Put(memberBytes, scoreBytes)
 }
 
 // Zrange returns members within a specified range in a sorted set (ascending order).
 func (db *DB) Zrange(key string, start, stop int) ([]string, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
 	var members []string
-	err := db.view(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(key))
-		if bucket == nil {
-			return nil // Bucket does not exist, return empty list
-		}
+	err := db.view("Zrange", key, func(tx *bbolt.Tx) error {
+		var err error
+		members, err = db.zrange(tx, key, start, stop)
+		return err
+	})
 
-		size := bucket.Stats().KeyN // Get the current size of the bucket for negative index handling
+	if err != nil {
+		return nil, err
+	}
 
-		// Handle negative indices
+	return members, nil
+}
+
+// zrange returns the members of the sorted set key from rank start to stop, see Zrange.
+func (db *DB) zrange(tx *bbolt.Tx, key string, start, stop int) ([]string, error) {
+	bucket, err := db.zsetBucket(tx, key)
+	if err != nil || bucket == nil {
+		return nil, err // Bucket does not exist, return empty list
+	}
+
+	size := bucket.Stats().KeyN // Get the current size of the bucket for negative index handling
+
+	// Handle negative indices
+	if start < 0 {
+		start = size + start
 		if start < 0 {
-			start = size + start
-			if start < 0 {
-				start = 0
-			}
+			start = 0
 		}
+	}
 
+	if stop < 0 {
+		stop = size + stop
 		if stop < 0 {
-			stop = size + stop
-			if stop < 0 {
-				stop = -1 // Effectively makes range empty if stop is before start
-			}
-		}
-
-		if start > stop || start >= size { // Handle empty or out-of-bounds ranges
-			return nil
+			stop = -1 // Effectively makes range empty if stop is before start
 		}
+	}
 
-		cursor := bucket.Cursor()
-		count := 0
-
-		for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
-			if count >= start {
-				// Extract member part (skip the first 8 bytes for score)
-				member := string(k[8:])
-				members = append(members, member)
-			}
-			count++
+	if start > stop || start >= size { // Handle empty or out-of-bounds ranges
+		return nil, nil
+	}
 
-			if count > stop {
-				break
-			}
+	var members []string
+	cursor := bucket.Cursor()
+	count := 0
+
+	for k, _ := cursor.First(); k != nil; k, _ = cursor.Next() {
+		if count >= start {
+			// Extract member part (skip the first 8 bytes for score)
+			member := string(k[8:])
+			members = append(members, member)
 		}
-		return nil
-	})
+		count++
 
-	if err != nil {
-		return nil, err
+		if count > stop {
+			break
+		}
 	}
-
 	return members, nil
 }
 
 // Zrevrange returns members within a specified range in a sorted set (descending order).
 func (db *DB) Zrevrange(key string, start, stop int) ([]string, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
 	var members []string
-	err := db.view(func(tx *bbolt.Tx) error {
-		bucket := tx.Bucket([]byte(key))
-		if bucket == nil {
-			return nil // Bucket does not exist, return empty list
+	err := db.view("Zrevrange", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.zsetBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err // Bucket does not exist, return empty list
 		}
 
 		size := bucket.Stats().KeyN
@@ -478,24 +992,15 @@ func (db *DB) Zrevrange(key string, start, stop int) ([]string, error) {
 // Zscore returns the score of a member in a sorted set.
 // Uses the secondary index for efficient lookup.
 func (db *DB) Zscore(key, member string) (float64, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	key = db.nsKey(key)
 	var score float64
-	err := db.view(func(tx *bbolt.Tx) error {
-		idxBucket := tx.Bucket([]byte(key + "_members")) // Use secondary index
-		if idxBucket == nil {
-			return nil // Index bucket does not exist, so member won't be found
-		}
-
-		scoreBytes := idxBucket.Get([]byte(member))
-		if scoreBytes == nil {
-			return nil // Member not found
-		}
-
-		if len(scoreBytes) != 8 {
-			return fmt.Errorf("invalid score format for member %s", member)
-		}
-
-		score = math.Float64frombits(binary.BigEndian.Uint64(scoreBytes))
-		return nil
+	err := db.view("Zscore", key, func(tx *bbolt.Tx) error {
+		var err error
+		score, _, err = db.zscore(tx, key, member)
+		return err
 	})
 
 	if err != nil {
@@ -505,44 +1010,84 @@ func (db *DB) Zscore(key, member string) (float64, error) {
 	return score, nil
 }
 
+// zscore returns the score of a member of the sorted set key and whether it exists.
+func (db *DB) zscore(tx *bbolt.Tx, key, member string) (float64, bool, error) {
+	if bucket, err := db.zsetBucket(tx, key); err != nil || bucket == nil {
+		return 0, false, err // Sorted set does not exist, so member won't be found
+	}
+	idxBucket := membersBucket(tx, key) // Use secondary index
+
+	scoreBytes := idxBucket.Get([]byte(member))
+	if scoreBytes == nil {
+		return 0, false, nil // Member not found
+	}
+
+	if len(scoreBytes) != 8 {
+		return 0, false, fmt.Errorf("%w: score of member %s is not an 8-byte float", ErrWrongType, member)
+	}
+
+	return math.Float64frombits(binary.BigEndian.Uint64(scoreBytes)), true, nil
+}
+
 // Zrem removes a member from a sorted set.
 // Uses the secondary index for efficient lookup and deletion.
 func (db *DB) Zrem(key, member string) error {
-	return db.update(func(tx *bbolt.Tx) error {
-		ssBucket := tx.Bucket([]byte(key))
-		idxBucket := tx.Bucket([]byte(key + "_members"))
-
-		if ssBucket == nil || idxBucket == nil {
-			return nil // Buckets don't exist, nothing to delete
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("Zrem", key, func(tx *txn) error {
+		if err := db.saveUndo(tx, "Zrem", key, false, nil, []string{member}); err != nil {
+			return err
 		}
+		return zrem(tx, key, member)
+	})
+}
 
-		memberBytes := []byte(member)
+// zrem removes a sorted set member inside an existing read-write transaction.
+func zrem(tx *txn, key, member string) error {
+	if err := checkType(tx.Tx, key, typeZset); err != nil {
+		return err
+	}
 
-		// Get score from secondary index
-		scoreBytes := idxBucket.Get(memberBytes)
-		if scoreBytes == nil {
-			return nil // Member not found in index
-		}
+	ssBucket := tx.Bucket([]byte(key))
+	idxBucket := membersBucket(tx.Tx, key)
 
-		// Delete from main sorted set bucket
-		ssKey := append(scoreBytes, memberBytes...)
-		if err := ssBucket.Delete(ssKey); err != nil {
-			return fmt.Errorf("failed to delete from sorted set bucket: %v", err)
-		}
+	if ssBucket == nil || idxBucket == nil {
+		return nil // Buckets don't exist, nothing to delete
+	}
 
-		// Delete from secondary index
-		return idxBucket.Delete(memberBytes)
-	})
+	memberBytes := []byte(member)
+
+	// Get score from secondary index
+	scoreBytes := idxBucket.Get(memberBytes)
+	if scoreBytes == nil {
+		return nil // Member not found in index
+	}
+
+	// Delete from main sorted set bucket
+	ssKey := append(scoreBytes, memberBytes...)
+	if err := ssBucket.Delete(ssKey); err != nil {
+		return fmt.Errorf("failed to delete from sorted set bucket: %v", err)
+	}
+
+	// Delete from secondary index
+	tx.record(Event{Type: EventZrem, Key: key, Field: member})
+	return idxBucket.Delete(memberBytes)
 }
 
 // Zcard returns the number of members in a sorted set.
 func (db *DB) Zcard(key string) (int, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	key = db.nsKey(key)
 	var count int
-	err := db.view(func(tx *bbolt.Tx) error {
+	err := db.view("Zcard", key, func(tx *bbolt.Tx) error {
 		// Count from the primary sorted set bucket
-		bucket := tx.Bucket([]byte(key))
-		if bucket == nil {
-			return nil // Bucket does not exist, return 0
+		bucket, err := db.zsetBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err // Bucket does not exist, return 0
 		}
 
 		count = bucket.Stats().KeyN
@@ -563,15 +1108,160 @@ func ensureDir(filePath string) error {
 }
 
 // Helper function: execute read-only transaction.
-func (db *DB) view(fn func(tx *bbolt.Tx) error) error {
+// op and key identify the operation being served, for instrumentation and hooks.
+func (db *DB) view(op, key string, fn func(tx *bbolt.Tx) error) (err error) {
+	o := Op{Name: op, Key: key, Actor: db.actor}
+	defer db.observe(o, time.Now(), &err)
+	if db.closing.Load() {
+		return ErrClosed
+	}
+	if err := db.runBeforeHooks(o); err != nil {
+		return err
+	}
+	if err := db.faultIn(key); err != nil {
+		return err
+	}
+	db.touch(key)
+	db.touchTier(key)
 	db.mu.RLock()
 	defer db.mu.RUnlock()
-	return db.db.View(fn)
+	if db.closed {
+		return ErrClosed
+	}
+
+	start := time.Now()
+	err = closedError(db.labeled(op, key, func() error { return db.db.View(fn) }))
+	d := time.Since(start)
+	db.metrics.observeTx(false, d, nil)
+	if threshold := db.slowThreshold(); threshold >= 0 && d > threshold {
+		db.log.Warn("slow transaction", "op", op, "key", key, "writable", false, "duration", d)
+	}
+	return err
 }
 
 // Helper function: execute read-write transaction.
-func (db *DB) update(fn func(tx *bbolt.Tx) error) error {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-	return db.db.Update(fn)
+// Keys whose TTL has elapsed are removed before fn runs. Events recorded by fn are
+// appended to the operation log (if enabled) before commit and delivered to watchers after commit.
+// It holds the lock of key, or every key lock if key is empty, see updateKeys.
+func (db *DB) update(op, key string, fn func(tx *txn) error) error {
+	return db.updateKeys(op, key, []string{key}, fn)
+}
+
+// updateKeys is update holding the locks of the keys stored under names, every key lock
+// if one is empty. Writes are serialized by bbolt anyway; the locks only keep writes to
+// the same keys from interleaving the steps they take before and after their transaction.
+func (db *DB) updateKeys(op, key string, names []string, fn func(tx *txn) error) (err error) {
+	o := Op{Name: op, Key: key, Write: true, Actor: db.actor}
+	defer db.observe(o, time.Now(), &err)
+	if db.closing.Load() {
+		return ErrClosed
+	}
+	if db.readOnly {
+		return ErrReadOnly
+	}
+	if err := db.runBeforeHooks(o); err != nil {
+		return err
+	}
+	if err := db.faultIn(key); err != nil {
+		return err
+	}
+
+	var events []Event
+	defer func() {
+		if err == nil {
+			db.notifyExpired(events) // After the lock is released
+		}
+	}()
+	unlock := db.locks.lock(names)
+	defer unlock()
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	if db.closed {
+		return ErrClosed
+	}
+
+	start := time.Now()
+	var ticket uint64
+	err = db.db.Update(func(btx *bbolt.Tx) error {
+		tx := &txn{Tx: btx, countFreed: db.throttle.countBytes()}
+		// Writes never see keys whose TTL has elapsed
+		if _, err := expireDue(tx, db.now(), expireBatchSize); err != nil {
+			return err
+		}
+		if err := fn(tx); err != nil {
+			return err
+		}
+		if err := db.runTriggers(tx); err != nil {
+			return err
+		}
+		if err := db.checkLimits(tx); err != nil {
+			return err
+		}
+		if err := db.trackCache(tx); err != nil {
+			return err
+		}
+		if err := db.trackTiering(tx); err != nil {
+			return err
+		}
+		if err := db.updateTrash(tx); err != nil {
+			return err
+		}
+		if err := db.updateLinks(tx); err != nil {
+			return err
+		}
+		if err := db.updateViews(tx); err != nil {
+			return err
+		}
+		if err := db.updateHistory(tx); err != nil {
+			return err
+		}
+		if err := db.updateIndexes(tx); err != nil {
+			return err
+		}
+		if err := db.updateTextIndex(tx); err != nil {
+			return err
+		}
+		if err := db.updateVectorIndex(tx); err != nil {
+			return err
+		}
+		if err := db.checkImmutable(tx); err != nil { // After every step that records events
+			return err
+		}
+		db.applyFillPercent(tx)
+		tx.events = foldSwaps(tx.events)
+		events = tx.events
+		if err := db.appendOpLog(tx); err != nil {
+			return err
+		}
+		if err := db.appendAudit(tx, op); err != nil {
+			return err
+		}
+		ticket = db.commits.issue()
+		return nil
+	})
+	if ticket != 0 {
+		// Whether or not the commit succeeded, later ones wait for this one's turn
+		db.commits.wait(ticket)
+		defer db.commits.finish()
+	}
+	err = closedError(err)
+	d := time.Since(start)
+	if threshold := db.slowThreshold(); threshold >= 0 && d > threshold {
+		db.log.Warn("slow transaction", "op", op, "key", key, "writable", true, "duration", d, "events", len(events))
+	}
+	if err != nil {
+		db.metrics.observeTx(true, d, nil)
+		if !errors.Is(err, errRollback) {
+			db.log.Warn("write failed", "op", op, "key", key, "error", err)
+		}
+		return err
+	}
+	db.metrics.observeTx(true, d, events)
+	db.writeGen.Add(1)
+	db.invalidateHot(events)
+	db.recordWrites(events)
+
+	db.mirror(events)
+	db.notifyWatchers(events)
+	return nil
 }
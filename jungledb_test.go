@@ -2,9 +2,17 @@ package jungledb
 
 import (
 	"bytes" // For bytes.Equal
+	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
+	"strings"
+	"sync"
 	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
 )
 
 // TestMain cleans up test files before and after running tests.
@@ -66,6 +74,38 @@ func TestHsetHget(t *testing.T) {
 	}
 }
 
+// TestHgetAppend tests reading values into a caller-provided buffer.
+func TestHgetAppend(t *testing.T) {
+	db, err := Open("testdata/hget_append.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Hmset("user:1", map[string][]byte{"name": []byte("Alice"), "city": []byte("Paris")}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+
+	buf := make([]byte, 0, 64)
+	buf, err = db.HgetAppend(buf, "user:1", "name")
+	if err != nil || string(buf) != "Alice" {
+		t.Fatalf("expected Alice, got %q %v", buf, err)
+	}
+	buf, err = db.HgetAppend(buf, "user:1", "city")
+	if err != nil || string(buf) != "AliceParis" {
+		t.Errorf("expected the value to be appended, got %q %v", buf, err)
+	}
+	if out, err := db.HgetAppend(buf[:0], "user:1", "missing"); err != nil || len(out) != 0 {
+		t.Errorf("expected nothing appended for a missing field, got %q %v", out, err)
+	}
+
+	// Reading into a reused buffer allocates no more than reading the value in place
+	inPlace := testing.AllocsPerRun(100, func() { db.Hget("user:1", "name") })
+	appended := testing.AllocsPerRun(100, func() { buf, _ = db.HgetAppend(buf[:0], "user:1", "name") })
+	if appended > inPlace {
+		t.Errorf("expected HgetAppend to allocate at most %v times, got %v", inPlace, appended)
+	}
+}
+
 // TestHmsetHmget tests the Hmset and Hmget operations with byte slices.
 func TestHmsetHmget(t *testing.T) {
 	db, err := Open("testdata/test.db")
@@ -195,6 +235,101 @@ func TestHincrHgetInt(t *testing.T) {
 	}
 }
 
+// TestHincrMulti tests that HincrMulti increments several fields at once, and none of
+// them if one overflows.
+func TestHincrMulti(t *testing.T) {
+	db, err := Open("testdata/test.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	key := "multi_counter"
+	if _, err := db.Hincr(key, "b", 5); err != nil {
+		t.Fatalf("Hincr failed: %v", err)
+	}
+	values, err := db.HincrMulti(key, map[string]int64{"a": 1, "b": 2, "c": -3})
+	if err != nil {
+		t.Fatalf("HincrMulti failed: %v", err)
+	}
+	if values["a"] != 1 || values["b"] != 7 || values["c"] != -3 || len(values) != 3 {
+		t.Errorf("unexpected values: %v", values)
+	}
+
+	_, err = db.HincrMulti(key, map[string]int64{"a": 1, "b": math.MaxInt64})
+	if !errors.Is(err, ErrOverflow) || !strings.Contains(err.Error(), `"b"`) {
+		t.Errorf("expected an overflow of b, got %v", err)
+	}
+	if value, _ := db.HgetInt(key, "a"); value != 1 {
+		t.Errorf("expected a to be left at 1 after the overflow, got %d", value)
+	}
+
+	if err := db.Zadd("multi_zset", 1, "m"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if _, err := db.HincrMulti("multi_zset", map[string]int64{"a": 1}); !errors.Is(err, ErrWrongType) {
+		t.Errorf("expected ErrWrongType on a sorted set, got %v", err)
+	}
+}
+
+// TestEmptyValue tests that a field holding an empty value is told apart from a missing one.
+func TestEmptyValue(t *testing.T) {
+	db, err := Open("testdata/empty_value.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	key := "user:1"
+	if err := db.Hmset(key, map[string][]byte{"empty": {}, "nil": nil, "name": []byte("Alice")}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+
+	for _, field := range []string{"empty", "nil"} {
+		value, found, err := db.HgetOK(key, field)
+		if err != nil {
+			t.Fatalf("HgetOK failed: %v", err)
+		}
+		if !found || value == nil || len(value) != 0 {
+			t.Errorf("HgetOK(%q) = %q, %v, expected empty non-nil value, true", field, value, found)
+		}
+		if value, err := db.Hget(key, field); err != nil || value == nil {
+			t.Errorf("Hget(%q) = %v, %v, expected non-nil empty value", field, value, err)
+		}
+		if exists, err := db.HhasKey(key, field); err != nil || !exists {
+			t.Errorf("HhasKey(%q) = %v, %v, expected true", field, exists, err)
+		}
+	}
+
+	if value, found, err := db.HgetOK(key, "missing"); err != nil || found || value != nil {
+		t.Errorf("HgetOK(missing) = %q, %v, %v, expected nil, false", value, found, err)
+	}
+	if _, found, err := db.HgetOK("missing", "empty"); err != nil || found {
+		t.Errorf("HgetOK on missing key = %v, %v, expected false", found, err)
+	}
+
+	results, err := db.HmgetOK(key, []string{"name", "empty", "missing"})
+	if err != nil {
+		t.Fatalf("HmgetOK failed: %v", err)
+	}
+	expected := []GetResult{{Value: []byte("Alice"), Found: true}, {Value: []byte{}, Found: true}, {}}
+	for i, r := range results {
+		if r.Found != expected[i].Found || !bytes.Equal(r.Value, expected[i].Value) || (r.Value == nil) != (expected[i].Value == nil) {
+			t.Errorf("HmgetOK result %d = %+v, expected %+v", i, r, expected[i])
+		}
+	}
+
+	if _, err := db.Hincr(key, "count", 0); err != nil {
+		t.Fatalf("Hincr failed: %v", err)
+	}
+	if value, found, err := db.HgetIntOK(key, "count"); err != nil || !found || value != 0 {
+		t.Errorf("HgetIntOK(count) = %d, %v, %v, expected 0, true", value, found, err)
+	}
+	if value, found, err := db.HgetIntOK(key, "missing"); err != nil || found || value != 0 {
+		t.Errorf("HgetIntOK(missing) = %d, %v, %v, expected 0, false", value, found, err)
+	}
+}
+
 // TestHhasKey tests the HhasKey operation.
 func TestHhasKey(t *testing.T) {
 	db, err := Open("testdata/test.db")
@@ -282,6 +417,40 @@ func TestHdel(t *testing.T) {
 	} // Should not return an error
 }
 
+// TestHdelIfEquals tests that HdelIfEquals only deletes a field holding the expected value.
+func TestHdelIfEquals(t *testing.T) {
+	db, err := Open("testdata/test.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	key := "locks"
+	if err := db.Hset(key, "job", []byte("token-2")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if deleted, err := db.HdelIfEquals(key, "job", []byte("token-1")); err != nil || deleted {
+		t.Errorf("HdelIfEquals with a stale token = %v, %v; expected false", deleted, err)
+	}
+	if v, _ := db.Hget(key, "job"); string(v) != "token-2" {
+		t.Errorf("expected the field to be kept, got %q", v)
+	}
+	if deleted, err := db.HdelIfEquals(key, "job", []byte("token-2")); err != nil || !deleted {
+		t.Errorf("HdelIfEquals with the current token = %v, %v; expected true", deleted, err)
+	}
+	if ok, _ := db.HhasKey(key, "job"); ok {
+		t.Errorf("expected the field to be deleted")
+	}
+
+	// A missing field does not equal an empty value
+	if deleted, err := db.HdelIfEquals(key, "job", nil); err != nil || deleted {
+		t.Errorf("HdelIfEquals of a missing field = %v, %v; expected false", deleted, err)
+	}
+	if deleted, err := db.HdelIfEquals("missing_locks", "job", nil); err != nil || deleted {
+		t.Errorf("HdelIfEquals of a missing key = %v, %v; expected false", deleted, err)
+	}
+}
+
 // TestHmdel tests the Hmdel operation.
 func TestHmdel(t *testing.T) {
 	db, err := Open("testdata/test.db")
@@ -765,6 +934,17 @@ func TestZscore(t *testing.T) {
 	if nonExistentKeyScore != 0 {
 		t.Errorf("expected 0 for non-existent zset key, got %f", nonExistentKeyScore)
 	}
+
+	// Test a corrupt score in the member index
+	err = db.db.Update(func(tx *bbolt.Tx) error {
+		return membersBucket(tx, key).Put([]byte(member), []byte("bad"))
+	})
+	if err != nil {
+		t.Fatalf("failed to corrupt the member index: %v", err)
+	}
+	if _, err := db.Zscore(key, member); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Zscore of a corrupt score: got error %v, want ErrWrongType", err)
+	}
 }
 
 // TestZrem tests Zrem with the optimized secondary index lookup.
@@ -933,3 +1113,104 @@ func equalByteMap(a, b map[string][]byte) bool {
 	}
 	return true
 }
+
+// TestClose tests that Close drains in-flight operations, can be called more than once
+// and makes every later operation fail with ErrClosed.
+func TestClose(t *testing.T) {
+	db, err := Open("testdata/close.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 400)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				errs <- db.Hset("hash", fmt.Sprintf("f%d-%d", i, j), []byte("v"))
+				_, err := db.Hget("hash", "f0-0")
+				errs <- err
+			}
+		}(i)
+	}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := db.Close(); err != nil {
+				t.Errorf("Close failed: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil && !errors.Is(err, ErrClosed) {
+			t.Errorf("operation during Close: expected nil or ErrClosed, got %v", err)
+		}
+	}
+
+	if err := db.Close(); err != nil {
+		t.Errorf("second Close: expected nil, got %v", err)
+	}
+	if err := db.Zadd("zset", 1, "a"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Zadd after Close: expected ErrClosed, got %v", err)
+	}
+	if _, err := db.Stats(); !errors.Is(err, ErrClosed) {
+		t.Errorf("Stats after Close: expected ErrClosed, got %v", err)
+	}
+	if _, err := db.Backup(io.Discard); !errors.Is(err, ErrClosed) {
+		t.Errorf("Backup after Close: expected ErrClosed, got %v", err)
+	}
+	if err := db.Compact("testdata/close_compact.db"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Compact after Close: expected ErrClosed, got %v", err)
+	}
+	events, cancel := db.Watch("")
+	defer cancel()
+	if _, ok := <-events; ok {
+		t.Errorf("Watch after Close: expected a closed channel")
+	}
+}
+
+// TestOpenReadOnly tests that read-only handles can share a file and reject writes.
+func TestOpenReadOnly(t *testing.T) {
+	if _, err := OpenReadOnly("testdata/readonly_missing.db"); err == nil {
+		t.Errorf("OpenReadOnly of a missing file: expected an error")
+	}
+
+	db, err := Open("testdata/readonly.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Hset("hash", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	first, err := OpenReadOnly("testdata/readonly.db")
+	if err != nil {
+		t.Fatalf("OpenReadOnly failed: %v", err)
+	}
+	defer first.Close()
+	second, err := OpenReadOnly("testdata/readonly.db")
+	if err != nil {
+		t.Fatalf("second OpenReadOnly failed: %v", err)
+	}
+	defer second.Close()
+
+	for _, ro := range []*DB{first, second} {
+		if value, err := ro.Hget("hash", "f"); err != nil || string(value) != "v" {
+			t.Errorf("Hget: expected v, got %q, %v", value, err)
+		}
+	}
+	if err := first.Hset("hash", "f", []byte("w")); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Hset: expected ErrReadOnly, got %v", err)
+	}
+	if err := first.Expire("hash", time.Minute); !errors.Is(err, ErrReadOnly) {
+		t.Errorf("Expire: expected ErrReadOnly, got %v", err)
+	}
+}
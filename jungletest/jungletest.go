@@ -0,0 +1,240 @@
+// Package jungletest provides helpers for testing code that uses jungledb: temporary
+// databases, fixtures, golden files and a fake clock.
+//
+//	func TestSignup(t *testing.T) {
+//		clock := jungletest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+//		db := jungletest.NewTempDB(t, jungledb.WithClock(clock))
+//		jungletest.LoadFixtures(t, db, "testdata/users.json")
+//
+//		signup(db, "alice")
+//		clock.Advance(time.Hour) // Expire the signup token
+//		jungletest.AssertGolden(t, db, "user:*", "testdata/signup.golden.json")
+//	}
+//
+// Fixtures and golden files share a JSON format, so a golden file can seed another test:
+//
+//	{
+//	  "hashes": {"user:1": {"name": "alice"}},
+//	  "sorted_sets": {"ranking": {"alice": 10}}
+//	}
+//
+// Field values are strings. Run the tests with -jungletest.update to write golden files
+// from the databases instead of comparing them.
+package jungletest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ehebe/jungledb"
+)
+
+// update makes AssertGolden write golden files rather than compare them.
+var update = flag.Bool("jungletest.update", false, "write jungletest golden files instead of comparing them")
+
+// NewTempDB opens a database in a temporary directory, with opts, and closes it when the
+// test ends.
+func NewTempDB(t testing.TB, opts ...jungledb.Option) *jungledb.DB {
+	t.Helper()
+	db, err := jungledb.Open(filepath.Join(t.TempDir(), "test.db"), opts...)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("failed to close database: %v", err)
+		}
+	})
+	return db
+}
+
+// Snapshot is the content of a database as kept in fixtures and golden files.
+type Snapshot struct {
+	Hashes     map[string]map[string]string  `json:"hashes,omitempty"`
+	SortedSets map[string]map[string]float64 `json:"sorted_sets,omitempty"`
+}
+
+// LoadFixtures writes the keys of the JSON snapshot at path to db.
+func LoadFixtures(t testing.TB, db *jungledb.DB, path string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read fixtures: %v", err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatalf("failed to parse fixtures %s: %v", path, err)
+	}
+	if err := Load(db, s); err != nil {
+		t.Fatalf("failed to load fixtures %s: %v", path, err)
+	}
+}
+
+// Load writes the keys of s to db.
+func Load(db *jungledb.DB, s Snapshot) error {
+	for key, fields := range s.Hashes {
+		values := make(map[string][]byte, len(fields))
+		for field, v := range fields {
+			values[field] = []byte(v)
+		}
+		if err := db.Hmset(key, values); err != nil {
+			return err
+		}
+	}
+	for key, members := range s.SortedSets {
+		for member, score := range members {
+			if err := db.Zadd(key, score, member); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Take returns the keys of db matching pattern (see DB.ListKeys) as a Snapshot.
+func Take(db *jungledb.DB, pattern string) (Snapshot, error) {
+	s := Snapshot{Hashes: make(map[string]map[string]string), SortedSets: make(map[string]map[string]float64)}
+	keys, _, err := db.ListKeys(pattern, "", 0)
+	if err != nil {
+		return s, err
+	}
+	for _, key := range keys {
+		fields, err := db.Hscan(key)
+		if errors.Is(err, jungledb.ErrWrongType) {
+			members, err := db.Zrange(key, 0, -1)
+			if err != nil {
+				return s, err
+			}
+			s.SortedSets[key] = make(map[string]float64, len(members))
+			for _, member := range members {
+				if s.SortedSets[key][member], err = db.Zscore(key, member); err != nil {
+					return s, err
+				}
+			}
+			continue
+		}
+		if err != nil {
+			return s, err
+		}
+		s.Hashes[key] = make(map[string]string, len(fields))
+		for field, v := range fields {
+			s.Hashes[key][field] = string(v)
+		}
+	}
+	return s, nil
+}
+
+// AssertGolden fails the test unless the keys of db matching pattern are those of the
+// golden file at path. With -jungletest.update it writes the file instead.
+func AssertGolden(t testing.TB, db *jungledb.DB, pattern, path string) {
+	t.Helper()
+	s, err := Take(db, pattern)
+	if err != nil {
+		t.Fatalf("failed to read keys: %v", err)
+	}
+	got, err := json.MarshalIndent(s, "", "  ") // Map keys are sorted, so the output is stable
+	if err != nil {
+		t.Fatalf("failed to encode keys: %v", err)
+	}
+	got = append(got, '\n')
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatalf("failed to write golden file: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file (run with -jungletest.update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("keys matching %q differ from %s:\n--- got\n%s--- want\n%s", pattern, path, got, want)
+	}
+}
+
+// Clock is a fake clock for WithClock, whose time only moves when told to: timers set
+// with After fire as Advance or Set moves the clock past them. It is safe for concurrent use.
+type Clock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+}
+
+// waiter is a channel returned by After and the time it fires.
+type waiter struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewClock returns a Clock set to now.
+func NewClock(now time.Time) *Clock {
+	return &Clock{now: now}
+}
+
+// Now returns the time of the clock.
+func (c *Clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving the time of the clock once it has moved by d.
+func (c *Clock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	w := waiter{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.c <- c.now
+	} else {
+		c.waiters = append(c.waiters, w)
+	}
+	return w.c
+}
+
+// Waiters returns the number of channels returned by After yet to fire, which lets a
+// test wait for a goroutine to block on the clock before advancing it.
+func (c *Clock) Waiters() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+// Advance moves the clock forward by d.
+func (c *Clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(c.now.Add(d))
+}
+
+// Set sets the time of the clock.
+func (c *Clock) Set(now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.set(now)
+}
+
+// set sets the time of the clock and fires the waiters due.
+func (c *Clock) set(now time.Time) {
+	c.now = now
+	pending := c.waiters[:0]
+	for _, w := range c.waiters {
+		if w.at.After(now) {
+			pending = append(pending, w)
+		} else {
+			w.c <- now
+		}
+	}
+	clear(c.waiters[len(pending):])
+	c.waiters = pending
+}
@@ -0,0 +1,158 @@
+package jungletest
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ehebe/jungledb"
+)
+
+// TestFixturesAndGolden tests that fixtures load and that golden files round-trip them.
+func TestFixturesAndGolden(t *testing.T) {
+	dir := t.TempDir()
+	fixtures := filepath.Join(dir, "fixtures.json")
+	err := os.WriteFile(fixtures, []byte(`{
+  "hashes": {"user:1": {"name": "alice"}, "order:1": {"total": "12"}},
+  "sorted_sets": {"user:ranking": {"alice": 10, "bob": 2.5}}
+}`), 0644)
+	if err != nil {
+		t.Fatalf("failed to write fixtures: %v", err)
+	}
+
+	db := NewTempDB(t)
+	LoadFixtures(t, db, fixtures)
+	if v, err := db.Hget("user:1", "name"); err != nil || string(v) != "alice" {
+		t.Errorf("expected the fixture hash, got %q %v", v, err)
+	}
+	if score, err := db.Zscore("user:ranking", "bob"); err != nil || score != 2.5 {
+		t.Errorf("expected the fixture sorted set, got %v %v", score, err)
+	}
+
+	golden := filepath.Join(dir, "users.golden.json")
+	*update = true
+	AssertGolden(t, db, "user:*", golden)
+	*update = false
+	AssertGolden(t, db, "user:*", golden)
+
+	// The golden file seeds another database with the same keys
+	other := NewTempDB(t)
+	LoadFixtures(t, other, golden)
+	AssertGolden(t, other, "", golden)
+	if keys, _, err := other.ListKeys("order:*", "", 0); err != nil || len(keys) != 0 {
+		t.Errorf("expected the golden file to hold only users, got %v %v", keys, err)
+	}
+
+	// A change is reported
+	if err := db.Hset("user:1", "name", []byte("bob")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	inner := &recorder{TB: t}
+	AssertGolden(inner, db, "user:*", golden)
+	if !inner.failed {
+		t.Error("expected AssertGolden to report the changed hash")
+	}
+}
+
+// TestClock tests that the fake clock drives expiry.
+func TestClock(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewClock(start)
+	db := NewTempDB(t, jungledb.WithClock(clock))
+	if err := db.Hset("token", "v", []byte("x")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Expire("token", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+
+	clock.Advance(59 * time.Minute)
+	if ttl, err := db.TTL("token"); err != nil || ttl != time.Minute {
+		t.Errorf("expected a minute left, got %v %v", ttl, err)
+	}
+	clock.Advance(time.Minute)
+	if _, err := db.TTL("token"); !errors.Is(err, jungledb.ErrKeyNotFound) {
+		t.Errorf("expected the key to have expired, got %v", err)
+	}
+	clock.Set(start)
+	if !clock.Now().Equal(start) {
+		t.Errorf("expected Set to move the clock, got %v", clock.Now())
+	}
+}
+
+// recorder records the failures of a test instead of reporting them.
+type recorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failed = true
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.failed = true
+}
+
+// TestClockSchedules tests that the expiry sweeper and Lock wait on the fake clock.
+func TestClockSchedules(t *testing.T) {
+	clock := NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	expired := make(chan string, 1)
+	db := NewTempDB(t, jungledb.WithClock(clock), jungledb.WithExpirySweep(time.Minute), jungledb.WithOnExpire(func(key string) {
+		expired <- key
+	}))
+	if err := db.Hset("token", "v", []byte("x")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Expire("token", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	waitWaiters(t, clock)
+	clock.Advance(time.Hour)
+	select {
+	case key := <-expired:
+		if key != "token" {
+			t.Errorf("expected token to expire, got %s", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the sweeper to run once the clock advanced")
+	}
+
+	clock = NewClock(clock.Now())
+	db = NewTempDB(t, jungledb.WithClock(clock), jungledb.WithExpirySweep(-1))
+	if ok, err := db.TryLock("job", "a", time.Minute); err != nil || !ok {
+		t.Fatalf("TryLock failed: %v %v", ok, err)
+	}
+	acquired := make(chan error, 1)
+	go func() {
+		_, err := db.Lock(context.Background(), "job", "b", time.Minute)
+		acquired <- err
+	}()
+	waitWaiters(t, clock)
+	clock.Advance(time.Minute)
+	select {
+	case err := <-acquired:
+		if err != nil {
+			t.Errorf("Lock failed: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Lock to acquire the expired lock once the clock advanced")
+	}
+}
+
+// waitWaiters waits until a goroutine waits on clock.
+func waitWaiters(t *testing.T, clock *Clock) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for clock.Waiters() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("expected a goroutine to wait on the clock")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
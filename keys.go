@@ -0,0 +1,482 @@
+package jungledb
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// membersSuffix is appended to a sorted set key to name its member -> score index bucket.
+	membersSuffix = "_members"
+
+	// internalPrefix marks buckets used by jungledb itself; they are never listed as keys.
+	internalPrefix = "__jungledb:"
+
+	// zsetsBucket records which keys hold sorted sets, by bucket name. Types cannot be
+	// told from bucket names alone, as a hash may be named like the member index of
+	// another key.
+	zsetsBucket = internalPrefix + "zsets"
+)
+
+var zsetsName = []byte(zsetsBucket)
+
+// Key types as reported in exports.
+const (
+	typeHash = "hash"
+	typeZset = "zset"
+)
+
+// ListKeys lists top-level keys (hashes and sorted sets) in lexicographical order.
+// Only keys matching pattern are returned; an empty pattern matches every key.
+// Patterns use Redis-style globs: '*', '?', '[abc]', '[a-z]', '[^a]' and '\' escapes.
+// Listing starts after cursor (pass "" to start from the beginning) and returns at
+// most limit keys (limit <= 0 means no limit). The returned cursor is "" once the
+// keyspace has been exhausted, otherwise it can be passed back to fetch the next page.
+// Internal buckets, such as sorted set member indexes, and expired keys are never returned.
+func (db *DB) ListKeys(pattern string, cursor string, limit int) ([]string, string, error) {
+	var keys []string
+	var next string
+	err := db.view("ListKeys", pattern, func(tx *bbolt.Tx) error {
+		c := tx.Cursor()
+
+		k, _ := c.Seek([]byte(db.nsKey(cursor)))
+		if k != nil && cursor != "" && string(k) == db.nsKey(cursor) {
+			k, _ = c.Next() // Cursor is exclusive
+		}
+
+		for ; k != nil && bytes.HasPrefix(k, []byte(db.ns)); k, _ = c.Next() {
+			key, ok := db.userKey(string(k))
+			if !ok || isInternalBucket(tx, k) || db.liveBucket(tx, string(k)) == nil {
+				continue
+			}
+			if !matchPattern(pattern, key) {
+				continue
+			}
+			if limit > 0 && len(keys) == limit {
+				next = keys[len(keys)-1]
+				break
+			}
+			keys = append(keys, key)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return keys, next, nil
+}
+
+// isInternalBucket reports whether a top-level bucket is managed by jungledb rather than
+// being a user key: either an internal bucket or the member index of an existing sorted set.
+func isInternalBucket(tx *bbolt.Tx, name []byte) bool {
+	if bytes.HasPrefix(name, []byte(internalPrefix)) {
+		return true
+	}
+	if bytes.HasSuffix(name, []byte(membersSuffix)) {
+		return isZset(tx, name[:len(name)-len(membersSuffix)])
+	}
+	return false
+}
+
+// nameBuffers holds buffers for building bucket names on the read path, which runs for
+// every read and would otherwise allocate a name per read.
+var nameBuffers = sync.Pool{New: func() any { return new([]byte) }}
+
+// membersBucket returns the member index of the sorted set stored under key, nil if there
+// is none, such as when key holds a hash.
+func membersBucket(tx *bbolt.Tx, key string) *bbolt.Bucket {
+	buf := nameBuffers.Get().(*[]byte)
+	defer nameBuffers.Put(buf)
+	*buf = append((*buf)[:0], key...)
+	if !isZset(tx, *buf) {
+		return nil
+	}
+	*buf = append(*buf, membersSuffix...)
+	return tx.Bucket(*buf)
+}
+
+// keyType reports whether the top-level bucket name holds a hash or a sorted set.
+func keyType(tx *bbolt.Tx, name []byte) string {
+	if isZset(tx, name) {
+		return typeZset
+	}
+	return typeHash
+}
+
+// isZset reports whether the bucket name holds a sorted set, as recorded by markZset.
+func isZset(tx *bbolt.Tx, name []byte) bool {
+	zsets := tx.Bucket(zsetsName)
+	return zsets != nil && zsets.Get(name) != nil
+}
+
+// markZset records whether the bucket name holds a sorted set.
+func markZset(tx *bbolt.Tx, name string, zset bool) error {
+	if !zset {
+		if zsets := tx.Bucket(zsetsName); zsets != nil {
+			return zsets.Delete([]byte(name))
+		}
+		return nil
+	}
+	zsets, err := tx.CreateBucketIfNotExists(zsetsName)
+	if err != nil {
+		return fmt.Errorf("failed to create sorted set registry: %v", err)
+	}
+	return zsets.Put([]byte(name), []byte{})
+}
+
+// createZset returns the score-ordered bucket and the member index of the sorted set
+// stored under key, creating them if needed. It fails with ErrKeyExists if key is new
+// and a hash is named like its member index.
+func createZset(tx *bbolt.Tx, key string) (main, index *bbolt.Bucket, err error) {
+	if !isZset(tx, []byte(key)) {
+		if tx.Bucket([]byte(key+membersSuffix)) != nil {
+			return nil, nil, fmt.Errorf("%w: %s, which sorted set %s needs for its member index", ErrKeyExists, key+membersSuffix, key)
+		}
+		if err := markZset(tx, key, true); err != nil {
+			return nil, nil, err
+		}
+	}
+	main, err = tx.CreateBucketIfNotExists([]byte(key))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create sorted set bucket: %v", err)
+	}
+	index, err = tx.CreateBucketIfNotExists([]byte(key + membersSuffix))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create member index bucket: %v", err)
+	}
+	return main, index, nil
+}
+
+// matchPattern reports whether s matches the Redis-style glob pattern.
+// An empty pattern matches everything.
+func matchPattern(pattern, s string) bool {
+	if pattern == "" {
+		return true
+	}
+	return globMatch(pattern, s)
+}
+
+// globMatch implements glob matching with '*', '?', character classes and '\' escapes.
+// Every element but '*' matches exactly one byte, so on a mismatch it is enough to let the
+// last star absorb one more byte and retry from there: matching takes O(len(pattern) *
+// len(s)) steps at worst, however many stars the pattern has.
+func globMatch(pattern, s string) bool {
+	p, i := 0, 0
+	star, mark := -1, 0 // Pattern position after the last star, and where in s it stopped
+	for i < len(s) {
+		if p < len(pattern) && pattern[p] == '*' {
+			p++
+			star, mark = p, i
+			continue
+		}
+		if p < len(pattern) {
+			if next, ok := matchElem(pattern, p, s[i]); ok {
+				p, i = next, i+1
+				continue
+			}
+		}
+		if star < 0 {
+			return false
+		}
+		mark++
+		p, i = star, mark
+	}
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+	return p == len(pattern)
+}
+
+// matchElem reports whether the pattern element starting at p, which is not '*', matches
+// c, and returns the position of the next element.
+func matchElem(pattern string, p int, c byte) (int, bool) {
+	switch pattern[p] {
+	case '?':
+		return p + 1, true
+	case '[':
+		end := strings.IndexByte(pattern[p+1:], ']')
+		if end < 0 {
+			// Unterminated class, treat '[' literally
+			return p + 1, c == '['
+		}
+		return p + end + 2, matchClass(pattern[p+1:p+1+end], c)
+	case '\\':
+		if p+1 < len(pattern) {
+			p++
+		}
+	}
+	return p + 1, pattern[p] == c
+}
+
+// matchClass reports whether c is matched by a character class body such as "a-z" or "^abc".
+func matchClass(class string, c byte) bool {
+	negate := false
+	if len(class) > 0 && class[0] == '^' {
+		negate = true
+		class = class[1:]
+	}
+
+	matched := false
+	for i := 0; i < len(class); i++ {
+		if i+2 < len(class) && class[i+1] == '-' {
+			lo, hi := class[i], class[i+2]
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			if c >= lo && c <= hi {
+				matched = true
+			}
+			i += 2
+			continue
+		}
+		if class[i] == c {
+			matched = true
+		}
+	}
+	return matched != negate
+}
+
+// Rename moves an entire hash or sorted set (including its member index) to newKey
+// in a single transaction. It fails with ErrKeyNotFound if key does not exist and with
+// ErrKeyExists if newKey already exists.
+func (db *DB) Rename(key, newKey string) error {
+	if key == newKey {
+		return nil
+	}
+	if err := checkKey(key, newKey); err != nil {
+		return err
+	}
+	key, newKey = db.nsKey(key), db.nsKey(newKey)
+	if err := db.faultIn(newKey); err != nil { // updateKeys faults in key
+		return err
+	}
+	return db.updateKeys("Rename", key, []string{key, newKey}, func(tx *txn) error {
+		return renameKey(tx, key, newKey)
+	})
+}
+
+// renameKey moves key to newKey inside an existing read-write transaction.
+func renameKey(tx *txn, key, newKey string) error {
+	if err := copyKey(tx, key, newKey); err != nil {
+		return err
+	}
+	if err := deleteKey(tx, key); err != nil {
+		return err
+	}
+	tx.record(Event{Type: EventRename, Key: key, Target: newKey})
+	return nil
+}
+
+// Swap exchanges the contents of keyA and keyB, including their TTLs, in a single
+// transaction, so a dataset rebuilt under a staging key can replace the live one at once.
+// It fails with ErrKeyNotFound unless both keys exist. Watchers and the operation log see
+// the swap as a single EventSwap.
+func (db *DB) Swap(keyA, keyB string) error {
+	if keyA == keyB {
+		return nil
+	}
+	if err := checkKey(keyA, keyB); err != nil {
+		return err
+	}
+	nameA, nameB := db.nsKey(keyA), db.nsKey(keyB)
+	if err := db.faultIn(nameB); err != nil { // updateKeys faults in nameA
+		return err
+	}
+	return db.updateKeys("Swap", nameA, []string{nameA, nameB}, func(tx *txn) error {
+		for _, key := range []string{keyA, keyB} {
+			if tx.Bucket([]byte(db.nsKey(key))) == nil {
+				return fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+			}
+		}
+		return swapKeys(tx, nameA, nameB)
+	})
+}
+
+// swapKey is the name, in the namespace of the keys swapped, under which swapKeys moves
+// the first one aside. Being internal, it cannot name a key.
+const swapKey = internalPrefix + "swap"
+
+// swapKeys exchanges the keys stored under a and b, which must both exist, inside an
+// existing read-write transaction. It renames them through a temporary key, so that the
+// steps run before commit move their links, histories and indexes along; foldSwaps then
+// records the three renames as one EventSwap.
+func swapKeys(tx *txn, a, b string) error {
+	ns, _, _ := splitKey(a)
+	tmp := ns + swapKey
+	if err := renameKey(tx, a, tmp); err != nil {
+		return err
+	}
+	if err := renameKey(tx, b, a); err != nil {
+		return err
+	}
+	return renameKey(tx, tmp, b)
+}
+
+// foldSwaps replaces the renames recorded by each swapKeys in events by a single
+// EventSwap, so that the temporary key never reaches watchers or the operation log.
+func foldSwaps(events []Event) []Event {
+	folded := events[:0]
+	for i := 0; i < len(events); i++ {
+		ev := events[i]
+		if ev.Type == EventRename && i+2 < len(events) {
+			if _, key, _ := splitKey(ev.Target); key == swapKey {
+				folded = append(folded, Event{Type: EventSwap, Key: ev.Key, Target: events[i+1].Key})
+				i += 2
+				continue
+			}
+		}
+		folded = append(folded, ev)
+	}
+	return folded
+}
+
+// Copy duplicates an entire hash or sorted set (including its member index) under dstKey
+// in a single transaction. It fails with ErrKeyNotFound if srcKey does not exist and with
+// ErrKeyExists if dstKey already exists.
+func (db *DB) Copy(srcKey, dstKey string) error {
+	if srcKey == dstKey {
+		return fmt.Errorf("source and destination keys are the same: %s", srcKey)
+	}
+	if err := checkKey(srcKey, dstKey); err != nil {
+		return err
+	}
+	srcKey, dstKey = db.nsKey(srcKey), db.nsKey(dstKey)
+	if err := db.faultIn(dstKey); err != nil { // updateKeys faults in srcKey
+		return err
+	}
+	return db.updateKeys("Copy", srcKey, []string{srcKey, dstKey}, func(tx *txn) error {
+		if err := copyKey(tx, srcKey, dstKey); err != nil {
+			return err
+		}
+		tx.record(Event{Type: EventCopy, Key: srcKey, Target: dstKey})
+		return nil
+	})
+}
+
+// copyKey copies the bucket for src (and its sorted set index, if any) to dst.
+func copyKey(tx *txn, src, dst string) error {
+	srcBucket := tx.Bucket([]byte(src))
+	if srcBucket == nil {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, src)
+	}
+	if tx.Bucket([]byte(dst)) != nil {
+		return fmt.Errorf("%w: %s", ErrKeyExists, dst)
+	}
+
+	dstBucket, err := tx.CreateBucket([]byte(dst))
+	if err != nil {
+		return fmt.Errorf("failed to create bucket: %v", err)
+	}
+	if err := copyBucket(srcBucket, dstBucket); err != nil {
+		return err
+	}
+	if err := putExpiry(tx.Tx, dst, expiry(tx.Tx, src)); err != nil {
+		return fmt.Errorf("failed to copy TTL: %v", err)
+	}
+
+	// Carry the sorted set member index along, if present
+	srcIdx := membersBucket(tx.Tx, src)
+	if srcIdx == nil {
+		return nil
+	}
+	_, dstIdx, err := createZset(tx.Tx, dst)
+	if err != nil {
+		return err
+	}
+	return copyBucket(srcIdx, dstIdx)
+}
+
+// copyBucket copies every key/value pair (recursing into nested buckets) from src to dst.
+func copyBucket(src, dst *bbolt.Bucket) error {
+	return src.ForEach(func(k, v []byte) error {
+		if v == nil {
+			// Nested bucket
+			child, err := dst.CreateBucketIfNotExists(k)
+			if err != nil {
+				return err
+			}
+			return copyBucket(src.Bucket(k), child)
+		}
+		return dst.Put(k, v)
+	})
+}
+
+// deleteBatchSize bounds how many keys FlushAll and DeleteByPattern remove per transaction.
+const deleteBatchSize = 1000
+
+// FlushAll deletes every key in the database, recording the deletions like any other
+// mutation, so that indexes, views and the operation log follow. Internal buckets, such
+// as the schema, migration versions or immutability seals, are kept.
+// On a namespace handle it deletes the keys of the namespace only.
+// Keys are removed in chunked transactions to avoid one giant commit.
+func (db *DB) FlushAll() error {
+	_, err := db.deleteKeys("FlushAll", func(tx *bbolt.Tx, name []byte) bool {
+		return bytes.HasPrefix(name, []byte(db.ns)) && !isInternalBucket(tx, name)
+	})
+	return err
+}
+
+// DeleteByPattern deletes every key matching the glob pattern (see ListKeys for the syntax),
+// together with any associated sorted set index. It returns the number of keys deleted.
+// Keys are removed in chunked transactions, so a failure may leave some matching keys behind.
+func (db *DB) DeleteByPattern(pattern string) (int, error) {
+	return db.deleteKeys("DeleteByPattern", func(tx *bbolt.Tx, name []byte) bool {
+		key, ok := db.userKey(string(name))
+		return ok && !isInternalBucket(tx, name) && matchPattern(pattern, key)
+	})
+}
+
+// deleteKeys removes top-level buckets selected by match in batches of deleteBatchSize,
+// deleting each selected key's sorted set index alongside it. op names the public operation.
+func (db *DB) deleteKeys(op string, match func(tx *bbolt.Tx, name []byte) bool) (int, error) {
+	deleted := 0
+	var resume []byte
+	for {
+		done := false
+		batchSize := db.throttle.batch(deleteBatchSize)
+		count, freed := 0, 0
+		err := db.update(op, "", func(tx *txn) error {
+			var batch [][]byte
+			c := tx.Cursor()
+			k, _ := c.First()
+			if resume != nil {
+				k, _ = c.Seek(resume)
+			}
+			for ; k != nil && len(batch) < batchSize; k, _ = c.Next() {
+				if match(tx.Tx, k) {
+					batch = append(batch, append([]byte(nil), k...))
+				}
+			}
+			if k == nil {
+				done = true
+			} else {
+				resume = append([]byte(nil), k...)
+			}
+
+			for _, name := range batch {
+				if err := deleteKey(tx, string(name)); err != nil {
+					return fmt.Errorf("failed to delete key %s: %v", name, err)
+				}
+				tx.record(Event{Type: EventDelete, Key: string(name)})
+				count++
+			}
+			freed = tx.freed
+			return nil
+		})
+		if err != nil {
+			return deleted, err
+		}
+		// Counted only once committed, a batch rolled back by a hook is not reported
+		deleted += count
+		if done {
+			return deleted, nil
+		}
+		db.throttle.wait(count, freed, nil)
+	}
+}
@@ -0,0 +1,443 @@
+package jungledb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestListKeys tests ListKeys with patterns, pagination and hidden index buckets.
+func TestListKeys(t *testing.T) {
+	db, err := Open("testdata/keys.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i := 1; i <= 5; i++ {
+		if err := db.Hset(fmt.Sprintf("user:%d", i), "name", []byte("x")); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	if err := db.Zadd("scores", 1, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+
+	// All keys, the zset index bucket must be hidden
+	keys, next, err := db.ListKeys("", "", 0)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	expected := []string{"scores", "user:1", "user:2", "user:3", "user:4", "user:5"}
+	if !equal(keys, expected) {
+		t.Errorf("ListKeys mismatch: expected %v, got %v", expected, keys)
+	}
+	if next != "" {
+		t.Errorf("expected empty cursor after full listing, got %q", next)
+	}
+
+	// Pattern filtering
+	keys, _, err = db.ListKeys("user:[2-3]", "", 0)
+	if err != nil {
+		t.Fatalf("ListKeys with pattern failed: %v", err)
+	}
+	if !equal(keys, []string{"user:2", "user:3"}) {
+		t.Errorf("ListKeys pattern mismatch: got %v", keys)
+	}
+
+	// Pagination
+	var paged []string
+	cursor := ""
+	for {
+		page, next, err := db.ListKeys("user:*", cursor, 2)
+		if err != nil {
+			t.Fatalf("ListKeys page failed: %v", err)
+		}
+		if len(page) > 2 {
+			t.Fatalf("page exceeds limit: %v", page)
+		}
+		paged = append(paged, page...)
+		if next == "" {
+			break
+		}
+		cursor = next
+	}
+	if !equal(paged, expected[1:]) {
+		t.Errorf("paginated listing mismatch: expected %v, got %v", expected[1:], paged)
+	}
+}
+
+// TestMatchPattern tests the glob matcher used for key patterns.
+func TestMatchPattern(t *testing.T) {
+	tests := []struct {
+		pattern string
+		s       string
+		want    bool
+	}{
+		{"", "anything", true},
+		{"*", "", true},
+		{"user:*", "user:42", true},
+		{"user:*", "post:1", false},
+		{"h?llo", "hello", true},
+		{"h?llo", "hllo", false},
+		{"h[ae]llo", "hallo", true},
+		{"h[^e]llo", "hello", false},
+		{"h[a-c]llo", "hbllo", true},
+		{"*:*:id", "a:b:id", true},
+		{`a\*b`, "a*b", true},
+		{`a\*b`, "axb", false},
+		{"a*b*c", "abxbc", true},
+		{"a*b*c", "abxbcx", false},
+		{"*[0-9]", "v12", true},
+		{"[x", "[x", true},
+		{"ab\\", "ab\\", true},
+	}
+
+	for _, tc := range tests {
+		if got := matchPattern(tc.pattern, tc.s); got != tc.want {
+			t.Errorf("matchPattern(%q, %q) = %v, expected %v", tc.pattern, tc.s, got, tc.want)
+		}
+	}
+}
+
+// TestMatchPatternPathological tests that patterns with many stars fail fast on subjects
+// that nearly match, rather than backtracking over every way of splitting them.
+func TestMatchPatternPathological(t *testing.T) {
+	pattern := strings.Repeat("*a", 20) + "*b"
+	s := strings.Repeat("a", 200)
+	done := make(chan bool)
+	go func() { done <- matchPattern(pattern, s) }()
+	select {
+	case got := <-done:
+		if got {
+			t.Errorf("matchPattern(%q, %q) = true, expected false", pattern, s)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("matchPattern(%q, %q) did not return", pattern, s)
+	}
+}
+
+// TestRenameCopy tests renaming and copying hashes and sorted sets.
+func TestRenameCopy(t *testing.T) {
+	db, err := Open("testdata/keys.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Rename a hash
+	if err := db.Hmset("rename_src", map[string][]byte{"a": []byte("1"), "b": []byte("2")}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	if err := db.Rename("rename_src", "rename_dst"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	old, err := db.Hscan("rename_src")
+	if err != nil {
+		t.Fatalf("Hscan failed: %v", err)
+	}
+	if len(old) != 0 {
+		t.Errorf("old key still has data after Rename: %v", old)
+	}
+	renamed, err := db.Hscan("rename_dst")
+	if err != nil {
+		t.Fatalf("Hscan failed: %v", err)
+	}
+	if !equalByteMap(renamed, map[string][]byte{"a": []byte("1"), "b": []byte("2")}) {
+		t.Errorf("renamed hash mismatch: got %v", renamed)
+	}
+
+	// Rename onto an existing key must fail
+	if err := db.Hset("rename_taken", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Rename("rename_dst", "rename_taken"); err == nil {
+		t.Error("Rename onto an existing key should fail")
+	}
+
+	// Rename a missing key must fail
+	if err := db.Rename("rename_missing", "rename_other"); err == nil {
+		t.Error("Rename of a missing key should fail")
+	}
+
+	// Copy a sorted set, the member index must follow
+	if err := db.Zadd("copy_zset", 1, "one"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Zadd("copy_zset", 2, "two"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Copy("copy_zset", "copy_zset2"); err != nil {
+		t.Fatalf("Copy failed: %v", err)
+	}
+	members, err := db.Zrange("copy_zset2", 0, -1)
+	if err != nil {
+		t.Fatalf("Zrange failed: %v", err)
+	}
+	if !equal(members, []string{"one", "two"}) {
+		t.Errorf("copied zset mismatch: got %v", members)
+	}
+	score, err := db.Zscore("copy_zset2", "two")
+	if err != nil {
+		t.Fatalf("Zscore failed: %v", err)
+	}
+	if score != 2 {
+		t.Errorf("copied zset score mismatch: expected 2, got %f", score)
+	}
+
+	// Source is untouched and independent of the copy
+	if err := db.Zrem("copy_zset2", "one"); err != nil {
+		t.Fatalf("Zrem failed: %v", err)
+	}
+	card, err := db.Zcard("copy_zset")
+	if err != nil {
+		t.Fatalf("Zcard failed: %v", err)
+	}
+	if card != 2 {
+		t.Errorf("source zset modified by change to copy: expected 2 members, got %d", card)
+	}
+}
+
+// TestSwap tests that Swap exchanges the contents and TTLs of two keys, even of
+// different types, and fails unless both exist.
+func TestSwap(t *testing.T) {
+	db, err := Open("testdata/swap.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("live", "v", []byte("old")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Zadd("staging", 1, "new"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Expire("staging", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if err := db.Swap("live", "staging"); err != nil {
+		t.Fatalf("Swap failed: %v", err)
+	}
+
+	if members, err := db.Zrange("live", 0, -1); err != nil || !equal(members, []string{"new"}) {
+		t.Errorf("Zrange(live) = %v, %v; expected [new]", members, err)
+	}
+	if ttl, err := db.TTL("live"); err != nil || ttl <= 0 {
+		t.Errorf("TTL(live) = %v, %v; expected the TTL of staging", ttl, err)
+	}
+	if v, err := db.Hget("staging", "v"); err != nil || string(v) != "old" {
+		t.Errorf("Hget(staging) = %q, %v; expected old", v, err)
+	}
+	if ttl, err := db.TTL("staging"); err != nil || ttl != 0 {
+		t.Errorf("TTL(staging) = %v, %v; expected no TTL", ttl, err)
+	}
+	keys, _, err := db.ListKeys("*", "", 0)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if !equal(keys, []string{"live", "staging"}) {
+		t.Errorf("expected only live and staging after Swap, got %v", keys)
+	}
+
+	if err := db.Swap("live", "missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Swap with a missing key: expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+// TestSwapEvents tests that watchers and the operation log see a swap in a namespace as
+// a single event naming the user keys, which replays, and that errors name user keys too.
+func TestSwapEvents(t *testing.T) {
+	src, err := Open("testdata/swap_events.db", WithOpLog())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer src.Close()
+	dst, err := Open("testdata/swap_events_dst.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer dst.Close()
+
+	ns := src.Namespace("app")
+	for key, value := range map[string]string{"live": "old", "staging": "new"} {
+		if err := ns.Hset(key, "v", []byte(value)); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	events, cancel := ns.Watch("*")
+	defer cancel()
+	if err := ns.Swap("live", "staging"); err != nil {
+		t.Fatalf("Swap failed: %v", err)
+	}
+	if ev := <-events; ev.Type != EventSwap || ev.Key != "live" || ev.Target != "staging" {
+		t.Errorf("expected a swap of live and staging, got %+v", ev)
+	}
+	select {
+	case ev := <-events:
+		t.Errorf("unexpected event after the swap: %+v", ev)
+	default:
+	}
+
+	var buf bytes.Buffer
+	if _, err := src.ExportSince(&buf, 0); err != nil {
+		t.Fatalf("ExportSince failed: %v", err)
+	}
+	if strings.Contains(buf.String(), swapKey) {
+		t.Errorf("operation log mentions the temporary key: %s", buf.String())
+	}
+	if _, err := dst.ApplyChanges(&buf); err != nil {
+		t.Fatalf("ApplyChanges failed: %v", err)
+	}
+	if v, err := dst.Namespace("app").Hget("live", "v"); err != nil || string(v) != "new" {
+		t.Errorf("Hget(live) on the replica = %q, %v; expected new", v, err)
+	}
+
+	err = ns.Swap("live", "missing")
+	if !errors.Is(err, ErrKeyNotFound) || !strings.HasSuffix(err.Error(), ": missing") {
+		t.Errorf("Swap with a missing key: expected ErrKeyNotFound naming missing, got %v", err)
+	}
+}
+
+// TestDeleteByPatternFlushAll tests pattern-based bulk deletion and FlushAll.
+func TestDeleteByPatternFlushAll(t *testing.T) {
+	db, err := Open("testdata/flush.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < deleteBatchSize+10; i++ { // Spans more than one batch
+		if err := db.Hset(fmt.Sprintf("tenant:a:%d", i), "f", []byte("v")); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	if err := db.Zadd("tenant:a:zset", 1, "m"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Hset("tenant:b:1", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	deleted, err := db.DeleteByPattern("tenant:a:*")
+	if err != nil {
+		t.Fatalf("DeleteByPattern failed: %v", err)
+	}
+	if deleted != deleteBatchSize+11 {
+		t.Errorf("deleted count mismatch: expected %d, got %d", deleteBatchSize+11, deleted)
+	}
+
+	keys, _, err := db.ListKeys("", "", 0)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if !equal(keys, []string{"tenant:b:1"}) {
+		t.Errorf("remaining keys mismatch: got %v", keys)
+	}
+
+	// Re-adding the zset must not see a stale member index
+	if err := db.Zadd("tenant:a:zset", 5, "other"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	score, err := db.Zscore("tenant:a:zset", "m")
+	if err != nil {
+		t.Fatalf("Zscore failed: %v", err)
+	}
+	if score != 0 {
+		t.Errorf("stale member index survived DeleteByPattern, score: %f", score)
+	}
+
+	if err := db.FlushAll(); err != nil {
+		t.Fatalf("FlushAll failed: %v", err)
+	}
+	keys, _, err = db.ListKeys("", "", 0)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("expected empty keyspace after FlushAll, got %v", keys)
+	}
+}
+
+// TestDeleteByPatternRolledBack tests that the count returned by DeleteByPattern leaves out
+// a batch whose transaction was rolled back.
+func TestDeleteByPatternRolledBack(t *testing.T) {
+	db, err := Open("testdata/flush_rollback.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	total := deleteBatchSize + 10 // The last key falls in the second batch
+	for i := 0; i < total; i++ {
+		if err := db.Hset(fmt.Sprintf("tmp:%04d", i), "f", []byte("v")); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	errVeto := errors.New("veto")
+	db.RegisterTrigger(fmt.Sprintf("tmp:%04d", total-1), func(tx *Tx, ev Event) error { return errVeto })
+
+	deleted, err := db.DeleteByPattern("tmp:*")
+	if !errors.Is(err, errVeto) {
+		t.Fatalf("DeleteByPattern: expected the trigger error, got %v", err)
+	}
+	keys, _, err := db.ListKeys("tmp:*", "", 0)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if len(keys) == 0 || deleted != total-len(keys) {
+		t.Errorf("DeleteByPattern reported %d deleted keys, but %d of %d are left", deleted, len(keys), total)
+	}
+}
+
+// TestKeyTypes tests that key types are recorded rather than guessed from names, so that a
+// hash named like the member index of another key does not change the type of that key.
+func TestKeyTypes(t *testing.T) {
+	db, err := Open("testdata/keytypes.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("team_members", "alice", []byte("admin")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Hset("team", "name", []byte("core")); err != nil {
+		t.Fatalf("Hset of a hash whose index name is taken failed: %v", err)
+	}
+	if v, err := db.Hget("team", "name"); err != nil || string(v) != "core" {
+		t.Fatalf("Hget: got %q, %v", v, err)
+	}
+	if err := db.HdelBucket("team"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if v, err := db.Hget("team_members", "alice"); err != nil || string(v) != "admin" {
+		t.Fatalf("Hget of the hash named like an index after deleting the other key: got %q, %v", v, err)
+	}
+	if err := db.Zadd("team", 1, "alice"); !errors.Is(err, ErrKeyExists) {
+		t.Fatalf("Zadd whose member index name is taken: got error %v, want ErrKeyExists", err)
+	}
+
+	if err := db.Zadd("scores", 1, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Hset("scores_members", "alice", []byte("x")); !errors.Is(err, ErrWrongType) {
+		t.Fatalf("Hset of a member index: got error %v, want ErrWrongType", err)
+	}
+	if _, err := db.Hget("scores", "alice"); !errors.Is(err, ErrWrongType) {
+		t.Fatalf("Hget of a sorted set: got error %v, want ErrWrongType", err)
+	}
+	keys, _, err := db.ListKeys("", "", 0)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if !equal(keys, []string{"scores", "team_members"}) {
+		t.Fatalf("ListKeys: got %v", keys)
+	}
+	if problems, err := db.Check(false); err != nil || len(problems) != 0 {
+		t.Fatalf("Check: got %v, %v", problems, err)
+	}
+}
@@ -0,0 +1,56 @@
+package jungledb
+
+import (
+	"fmt"
+	"slices"
+)
+
+// KeySet is a set of keys declared for a transaction, see WithKeys.
+type KeySet struct {
+	db   *DB
+	keys []string // Sorted, without duplicates
+}
+
+// WithKeys declares the keys a transaction is about to use, for KeySet.Update. Keys are
+// those of the namespace of db.
+func (db *DB) WithKeys(keys ...string) *KeySet {
+	keys = slices.Clone(keys)
+	slices.Sort(keys)
+	return &KeySet{db: db, keys: slices.Compact(keys)}
+}
+
+// Update is like DB.Update, but the transaction may only use the declared keys: its
+// methods fail with ErrUndeclaredKey for others, so an invariant spanning several keys
+// cannot leak to keys its function was not meant to touch. It only takes the locks of
+// the declared keys, in a fixed order so that transactions declaring overlapping keys
+// cannot deadlock, rather than every key lock as Update does.
+func (s *KeySet) Update(fn func(tx *Tx) error) error {
+	if err := checkKey(s.keys...); err != nil {
+		return err
+	}
+	declared := make(map[string]bool, len(s.keys))
+	names := make([]string, 0, len(s.keys))
+	for _, key := range s.keys {
+		name := s.db.nsKey(key)
+		declared[name] = true
+		names = append(names, name)
+	}
+	return s.db.updateKeys("Update", "", names, func(tx *txn) error {
+		return fn(&Tx{db: s.db, tx: tx, declared: declared})
+	})
+}
+
+// check returns ErrUndeclaredKey unless the transaction may use the keys stored under
+// names.
+func (t *Tx) check(names ...string) error {
+	if t.declared == nil {
+		return nil
+	}
+	for _, name := range names {
+		if !t.declared[name] {
+			key, _ := t.db.userKey(name)
+			return fmt.Errorf("%w: %s", ErrUndeclaredKey, key)
+		}
+	}
+	return nil
+}
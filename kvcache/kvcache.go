@@ -0,0 +1,80 @@
+// Package kvcache adapts a jungledb database to the Get/Set/Delete interface that Go
+// caching libraries commonly expect from a backend:
+//
+//	c := kvcache.New(db.Namespace("cache"))
+//	err := c.Set(ctx, "user:1", data, time.Minute)
+//	data, err = c.Get(ctx, "user:1") // kvcache.ErrMiss once expired
+//
+// Each entry is a hash holding the value in a single field, with the entry's TTL on the
+// key, so entries expire through the database's TTL machinery and can be capped with
+// jungledb.WithCache. Use a namespace handle to keep entries apart from other keys.
+package kvcache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ehebe/jungledb"
+)
+
+// valueField is the hash field holding the value of an entry.
+const valueField = "v"
+
+// ErrMiss is returned by Get for keys that are not cached.
+var ErrMiss = errors.New("cache miss")
+
+// Cache is a byte-slice cache stored in a jungledb database. It is safe for concurrent use.
+type Cache struct {
+	db *jungledb.DB
+}
+
+// New returns a Cache storing its entries in db.
+func New(db *jungledb.DB) *Cache {
+	return &Cache{db: db}
+}
+
+// Get returns the value cached under key, or ErrMiss.
+func (c *Cache) Get(ctx context.Context, key string) ([]byte, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	value, found, err := c.db.HgetOK(key, valueField)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrMiss
+	}
+	return value, nil
+}
+
+// Set caches value under key for ttl, or until deleted if ttl is 0.
+func (c *Cache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if value == nil {
+		value = []byte{}
+	}
+	return c.db.HsetEx(key, valueField, value, ttl)
+}
+
+// Delete removes key from the cache. Deleting a key that is not cached is not an error.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := c.db.HdelBucket(key); err != nil && !errors.Is(err, jungledb.ErrKeyNotFound) {
+		return err
+	}
+	return nil
+}
+
+// Clear removes every entry of the cache, that is every key of its database handle.
+func (c *Cache) Clear(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return c.db.FlushAll()
+}
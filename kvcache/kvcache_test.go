@@ -0,0 +1,87 @@
+package kvcache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ehebe/jungledb"
+	"github.com/ehebe/jungledb/jungletest"
+)
+
+// TestMain cleans up test files before and after running tests.
+func TestMain(m *testing.M) {
+	os.RemoveAll("testdata")
+	os.MkdirAll("testdata", 0755)
+
+	code := m.Run()
+
+	os.RemoveAll("testdata")
+	os.Exit(code)
+}
+
+// TestCache tests getting, setting, expiring, deleting and clearing entries.
+func TestCache(t *testing.T) {
+	clock := jungletest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	db, err := jungledb.Open("testdata/kvcache.db", jungledb.WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	c := New(db.Namespace("cache"))
+	ctx := context.Background()
+
+	if _, err := c.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get of a missing key: expected ErrMiss, got %v", err)
+	}
+	if err := c.Set(ctx, "a", []byte("1"), time.Minute); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := c.Set(ctx, "empty", nil, 0); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := c.Set(ctx, "short", []byte("x"), time.Second); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if value, err := c.Get(ctx, "a"); err != nil || string(value) != "1" {
+		t.Errorf("Get mismatch: got %q %v", value, err)
+	}
+	if value, err := c.Get(ctx, "empty"); err != nil || value == nil || len(value) != 0 {
+		t.Errorf("empty value should be a hit, got %q %v", value, err)
+	}
+	clock.Advance(time.Second)
+	if _, err := c.Get(ctx, "short"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get of an expired key: expected ErrMiss, got %v", err)
+	}
+
+	if err := c.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if err := c.Delete(ctx, "a"); err != nil {
+		t.Errorf("Delete of a missing key failed: %v", err)
+	}
+	if _, err := c.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get of a deleted key: expected ErrMiss, got %v", err)
+	}
+
+	if err := db.Hset("other", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := c.Clear(ctx); err != nil {
+		t.Fatalf("Clear failed: %v", err)
+	}
+	if _, err := c.Get(ctx, "empty"); !errors.Is(err, ErrMiss) {
+		t.Errorf("Get after Clear: expected ErrMiss, got %v", err)
+	}
+	if ok, _ := db.HhasKey("other", "f"); !ok {
+		t.Error("Clear should only remove the cache's own keys")
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := c.Set(cancelled, "a", []byte("1"), 0); !errors.Is(err, context.Canceled) {
+		t.Errorf("Set with a cancelled context: expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,191 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// leasePrefix starts the names of the hashes holding leases: the prefix and the lease
+// name. A lease hash has a "ttl" field holding its time to live in nanoseconds.
+const leasePrefix = "__jungledb.lease:"
+
+// ErrLeaseExpired is returned by Lease methods once the lease has expired or was revoked.
+var ErrLeaseExpired = errors.New("lease expired")
+
+// Lease is a registration that stays alive while its holder keeps calling KeepAlive,
+// as created by Register.
+type Lease struct {
+	leases *DB // Handle whose keys are the leases of the registering handle
+	name   string
+	ttl    time.Duration
+}
+
+// LeaseEventType identifies a change of the lease registry.
+type LeaseEventType string
+
+const (
+	LeaseRegistered LeaseEventType = "registered" // A lease was registered
+	LeaseExpired    LeaseEventType = "expired"    // A lease was not kept alive in time
+	LeaseRevoked    LeaseEventType = "revoked"    // A lease was revoked
+	LeaseOverflow   LeaseEventType = "overflow"   // The watcher fell behind and was closed, see WatchLeases
+)
+
+// LeaseEvent reports a change of the lease registry to WatchLeases.
+type LeaseEvent struct {
+	Type LeaseEventType
+	Name string
+}
+
+// leases returns the handle holding the leases of db.
+func (db *DB) leases() *DB {
+	return &DB{core: db.core, actor: db.actor, reason: db.reason, ns: db.nsKey(leasePrefix), at: db.at}
+}
+
+// Register registers the lease name for ttl and returns it. The lease stays alive as
+// long as KeepAlive is called within each ttl, typically every third of it, and is
+// then removed like an expired key. Leases are stored in the database, so they survive
+// restarts; registering a name that is still alive, as a restarted process does, renews
+// it with the new ttl. Leases live in the namespace of db (see Namespace) and their keys
+// are hidden from ListKeys, DeleteByPattern and Watch.
+func (db *DB) Register(name string, ttl time.Duration) (*Lease, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("invalid lease TTL %v", ttl)
+	}
+	l := &Lease{leases: db.leases(), name: name, ttl: ttl}
+	key := l.leases.nsKey(name)
+	err := db.update("Register", key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
+		if db.liveBucket(tx.Tx, key) == nil && tx.Bucket([]byte(key)) != nil {
+			if err := expireKey(tx, key); err != nil { // Elapsed, not swept yet
+				return err
+			}
+		}
+		bucket, err := tx.CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		if value := encodeSeq(uint64(ttl)); !bytes.Equal(bucket.Get([]byte("ttl")), value) {
+			tx.record(Event{Type: EventHset, Key: key, Field: "ttl", Value: value})
+			if err := bucket.Put([]byte("ttl"), value); err != nil {
+				return err
+			}
+		}
+		return setExpiry(tx, key, db.now().Add(ttl).UnixNano())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Name returns the name of the lease.
+func (l *Lease) Name() string {
+	return l.name
+}
+
+// KeepAlive extends the lease to its ttl from now. It returns ErrLeaseExpired if the
+// lease is no longer alive, in which case it must be registered again.
+func (l *Lease) KeepAlive() error {
+	key := l.leases.nsKey(l.name)
+	return l.leases.update("KeepAlive", key, func(tx *txn) error {
+		if l.leases.liveBucket(tx.Tx, key) == nil {
+			return fmt.Errorf("%w: %s", ErrLeaseExpired, l.name)
+		}
+		return setExpiry(tx, key, l.leases.now().Add(l.ttl).UnixNano())
+	})
+}
+
+// Revoke ends the lease. It returns ErrLeaseExpired if the lease is no longer alive.
+func (l *Lease) Revoke() error {
+	key := l.leases.nsKey(l.name)
+	return l.leases.update("Revoke", key, func(tx *txn) error {
+		if l.leases.liveBucket(tx.Tx, key) == nil {
+			return fmt.Errorf("%w: %s", ErrLeaseExpired, l.name)
+		}
+		if err := deleteKey(tx, key); err != nil {
+			return err
+		}
+		tx.record(Event{Type: EventDelete, Key: key})
+		return nil
+	})
+}
+
+// ListAlive lists, in order, the names of the leases of db that are alive.
+func (db *DB) ListAlive() ([]string, error) {
+	names, _, err := db.leases().ListKeys("", "", 0)
+	return names, err
+}
+
+// LeaseTTL returns the ttl the lease name was registered with, or ErrLeaseExpired if it
+// is not alive.
+func (db *DB) LeaseTTL(name string) (time.Duration, error) {
+	leases := db.leases()
+	key := leases.nsKey(name)
+	var ttl time.Duration
+	err := db.view("LeaseTTL", key, func(tx *bbolt.Tx) error {
+		bucket, err := leases.hashBucket(tx, key)
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return fmt.Errorf("%w: %s", ErrLeaseExpired, name)
+		}
+		if v := bucket.Get([]byte("ttl")); len(v) == 8 {
+			ttl = time.Duration(binary.BigEndian.Uint64(v))
+		}
+		return nil
+	})
+	return ttl, err
+}
+
+// WatchLeases subscribes to changes of the lease registry of db, delivered like the
+// events of Watch. Expiries are reported once the expired lease is removed, by the
+// expiry sweeper (see WithExpirySweep) or the next write. Renewals are not reported.
+// A consumer falling behind receives a LeaseOverflow and its channel is closed, as with
+// Watch. Call cancel to stop watching; the channel is closed once cancelled or when the
+// database is closed.
+func (db *DB) WatchLeases() (<-chan LeaseEvent, func()) {
+	events, cancel := db.leases().Watch("")
+	names, _ := db.ListAlive() // After subscribing, so no registration goes unseen
+	alive := make(map[string]bool, len(names))
+	for _, name := range names {
+		alive[name] = true
+	}
+
+	ch := make(chan LeaseEvent, watchBuffer)
+	go func() {
+		defer close(ch)
+		for ev := range events {
+			var typ LeaseEventType
+			switch {
+			case ev.Type == EventOverflow:
+				typ = LeaseOverflow
+			case ev.Type == EventHset && !alive[ev.Key]:
+				typ = LeaseRegistered
+				alive[ev.Key] = true
+			case ev.Type == EventExpired:
+				typ = LeaseExpired
+				delete(alive, ev.Key)
+			case ev.Type == EventDelete:
+				typ = LeaseRevoked
+				delete(alive, ev.Key)
+			default:
+				continue // Renewals
+			}
+			if typ == LeaseOverflow || len(ch) == cap(ch)-1 { // The last slot is kept for LeaseOverflow
+				ch <- LeaseEvent{Type: LeaseOverflow}
+				cancel()
+				return
+			}
+			ch <- LeaseEvent{Type: typ, Name: ev.Key}
+		}
+	}()
+	return ch, cancel
+}
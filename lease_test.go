@@ -0,0 +1,102 @@
+package jungledb
+
+import (
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+// TestLease tests registering, keeping alive, expiring and revoking leases, the events
+// reported for them and their persistence across restarts.
+func TestLease(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	sweepInterval := 10 * time.Millisecond
+	db, err := Open("testdata/lease.db", WithExpirySweep(sweepInterval), WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer func() { db.Close() }()
+
+	events, cancel := db.WatchLeases()
+	defer cancel()
+	next := func() LeaseEvent {
+		t.Helper()
+		select {
+		case ev := <-events:
+			return ev
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a lease event")
+			return LeaseEvent{}
+		}
+	}
+
+	worker, err := db.Register("worker-1", 100*time.Millisecond)
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if ev := next(); ev != (LeaseEvent{LeaseRegistered, "worker-1"}) {
+		t.Errorf("expected registration of worker-1, got %+v", ev)
+	}
+	api, err := db.Register("api-1", time.Minute)
+	if err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	if ev := next(); ev != (LeaseEvent{LeaseRegistered, "api-1"}) {
+		t.Errorf("expected registration of api-1, got %+v", ev)
+	}
+	if _, err := db.Register("api-1", time.Minute); err != nil { // Renewal, not reported
+		t.Fatalf("Register failed: %v", err)
+	}
+	if names, err := db.ListAlive(); err != nil || !slices.Equal(names, []string{"api-1", "worker-1"}) {
+		t.Errorf("ListAlive mismatch: got %v %v", names, err)
+	}
+	if keys, _, err := db.ListKeys("", "", 0); err != nil || len(keys) != 0 {
+		t.Errorf("ListKeys should not list leases, got %v %v", keys, err)
+	}
+	if ttl, err := db.LeaseTTL("api-1"); err != nil || ttl != time.Minute {
+		t.Errorf("LeaseTTL mismatch: got %v %v", ttl, err)
+	}
+
+	// Keeping a lease alive outlasts its ttl
+	for range 3 {
+		clock.advance(50 * time.Millisecond)
+		if err := worker.KeepAlive(); err != nil {
+			t.Fatalf("KeepAlive failed: %v", err)
+		}
+	}
+	// Once the sweeper waits again, moving past its interval makes it sweep at that time
+	clock.advance(100 * time.Millisecond)
+	waitFor(t, "the expiry sweeper", func() bool { return clock.waiting() > 0 })
+	clock.advance(sweepInterval)
+	if ev := next(); ev != (LeaseEvent{LeaseExpired, "worker-1"}) {
+		t.Errorf("expected expiry of worker-1, got %+v", ev)
+	}
+	if err := worker.KeepAlive(); !errors.Is(err, ErrLeaseExpired) {
+		t.Errorf("KeepAlive of an expired lease: expected ErrLeaseExpired, got %v", err)
+	}
+	if err := api.Revoke(); err != nil {
+		t.Fatalf("Revoke failed: %v", err)
+	}
+	if ev := next(); ev != (LeaseEvent{LeaseRevoked, "api-1"}) {
+		t.Errorf("expected revocation of api-1, got %+v", ev)
+	}
+	if _, err := db.LeaseTTL("api-1"); !errors.Is(err, ErrLeaseExpired) {
+		t.Errorf("LeaseTTL of a revoked lease: expected ErrLeaseExpired, got %v", err)
+	}
+
+	// Leases survive restarts
+	if _, err := db.Namespace("jobs").Register("worker-2", time.Minute); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	db.Close()
+	if db, err = Open("testdata/lease.db", WithClock(clock)); err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	if names, err := db.Namespace("jobs").ListAlive(); err != nil || !slices.Equal(names, []string{"worker-2"}) {
+		t.Errorf("ListAlive after reopen mismatch: got %v %v", names, err)
+	}
+	if names, err := db.ListAlive(); err != nil || len(names) != 0 {
+		t.Errorf("namespaced leases should not be listed in the root namespace, got %v %v", names, err)
+	}
+}
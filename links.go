@@ -0,0 +1,222 @@
+package jungledb
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// linkPrefix starts the names of the hashes holding links, followed by "out" or "in", a
+// NUL byte and the key the links start or end at. Their fields are the relation, a NUL
+// byte and the key at the other end, with empty values.
+const linkPrefix = "__jungledb.link:"
+
+// Link records that fromKey is related to toKey by relation, for example that a user
+// authored a post, along with the reverse link used by Backlinks, in one transaction.
+// Linking twice is harmless. The keys need not exist; links of a key are removed when it
+// is deleted or expires, and follow it when it is renamed. Links are stored in hidden
+// hashes of the namespace of db, so they are replicated and exported with the keys.
+func (db *DB) Link(fromKey, relation, toKey string) error {
+	if err := checkRelation(relation); err != nil {
+		return err
+	}
+	if err := checkKey(fromKey, toKey); err != nil {
+		return err
+	}
+	return db.update("Link", db.nsKey(fromKey), func(tx *txn) error {
+		return link(tx, db.ns, fromKey, relation, toKey)
+	})
+}
+
+// Unlink removes a link recorded by Link, if any, in both directions.
+func (db *DB) Unlink(fromKey, relation, toKey string) error {
+	if err := checkRelation(relation); err != nil {
+		return err
+	}
+	if err := checkKey(fromKey, toKey); err != nil {
+		return err
+	}
+	return db.update("Unlink", db.nsKey(fromKey), func(tx *txn) error {
+		return unlink(tx, db.ns, fromKey, relation, toKey)
+	})
+}
+
+// Links returns the keys key links to by relation, in order.
+func (db *DB) Links(key, relation string) ([]string, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	return db.links("Links", linkOut(db.ns, key), relation)
+}
+
+// Backlinks returns the keys that link to key by relation, in order.
+func (db *DB) Backlinks(key, relation string) ([]string, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	return db.links("Backlinks", linkIn(db.ns, key), relation)
+}
+
+// Link is like DB.Link within the transaction.
+func (t *Tx) Link(fromKey, relation, toKey string) error {
+	if err := checkRelation(relation); err != nil {
+		return err
+	}
+	if err := checkKey(fromKey, toKey); err != nil {
+		return err
+	}
+	if err := t.check(t.db.nsKey(fromKey), t.db.nsKey(toKey)); err != nil {
+		return err
+	}
+	t.save(linkOut(t.db.ns, fromKey), linkIn(t.db.ns, toKey))
+	return link(t.tx, t.db.ns, fromKey, relation, toKey)
+}
+
+// Unlink is like DB.Unlink within the transaction.
+func (t *Tx) Unlink(fromKey, relation, toKey string) error {
+	if err := checkRelation(relation); err != nil {
+		return err
+	}
+	if err := checkKey(fromKey, toKey); err != nil {
+		return err
+	}
+	if err := t.check(t.db.nsKey(fromKey), t.db.nsKey(toKey)); err != nil {
+		return err
+	}
+	t.save(linkOut(t.db.ns, fromKey), linkIn(t.db.ns, toKey))
+	return unlink(t.tx, t.db.ns, fromKey, relation, toKey)
+}
+
+func (db *DB) links(op, name, relation string) ([]string, error) {
+	var keys []string
+	err := db.view(op, name, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(name))
+		if bucket == nil {
+			return nil
+		}
+		prefix := []byte(relation + "\x00")
+		c := bucket.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+			keys = append(keys, string(k[len(prefix):]))
+		}
+		return nil
+	})
+	return keys, err
+}
+
+// link records a link and its reverse between keys of the namespace ns.
+func link(tx *txn, ns, from, relation, to string) error {
+	if err := hset(tx, linkOut(ns, from), relation+"\x00"+to, []byte{}); err != nil {
+		return err
+	}
+	return hset(tx, linkIn(ns, to), relation+"\x00"+from, []byte{})
+}
+
+// unlink removes a link and its reverse between keys of the namespace ns.
+func unlink(tx *txn, ns, from, relation, to string) error {
+	if err := hdelLink(tx, linkOut(ns, from), relation+"\x00"+to); err != nil {
+		return err
+	}
+	return hdelLink(tx, linkIn(ns, to), relation+"\x00"+from)
+}
+
+// hdelLink deletes a field of a link hash, and the hash once it is empty.
+func hdelLink(tx *txn, name, field string) error {
+	if err := hdel(tx, name, field); err != nil {
+		return err
+	}
+	if bucket := tx.Bucket([]byte(name)); bucket != nil {
+		if k, _ := bucket.Cursor().First(); k == nil {
+			if err := deleteKey(tx, name); err != nil {
+				return err
+			}
+			tx.record(Event{Type: EventDelete, Key: name})
+		}
+	}
+	return nil
+}
+
+// updateLinks removes the links of the keys deleted by tx and moves those of renamed keys.
+func (db *DB) updateLinks(tx *txn) error {
+	events := tx.events // Unlinking records more events, which need no processing
+	for _, ev := range events {
+		switch ev.Type {
+		case EventDelete, EventExpired, EventEvicted, EventRename:
+		default:
+			continue
+		}
+		ns, key, _ := splitKey(ev.Key) // Soft deleted keys keep their links until purged
+		if strings.HasPrefix(key, linkPrefix) {
+			continue
+		}
+		out, in := linkFields(tx.Tx, linkOut(ns, key)), linkFields(tx.Tx, linkIn(ns, key))
+		if len(out) == 0 && len(in) == 0 {
+			continue
+		}
+
+		target := ""
+		if ev.Type == EventRename {
+			_, target, _ = splitKey(ev.Target)
+		}
+		for _, l := range out {
+			if err := unlink(tx, ns, key, l[0], l[1]); err != nil {
+				return err
+			}
+			if target != "" {
+				if l[1] == key {
+					l[1] = target
+				}
+				if err := link(tx, ns, target, l[0], l[1]); err != nil {
+					return err
+				}
+			}
+		}
+		for _, l := range in {
+			if err := unlink(tx, ns, l[1], l[0], key); err != nil {
+				return err
+			}
+			if target != "" && l[1] != key { // Self-links were moved above
+				if err := link(tx, ns, l[1], l[0], target); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// linkFields returns the relation and other key of every link in the hash name.
+func linkFields(tx *bbolt.Tx, name string) [][2]string {
+	bucket := tx.Bucket([]byte(name))
+	if bucket == nil {
+		return nil
+	}
+	var links [][2]string
+	bucket.ForEach(func(k, _ []byte) error {
+		if relation, other, ok := strings.Cut(string(k), "\x00"); ok {
+			links = append(links, [2]string{relation, other})
+		}
+		return nil
+	})
+	return links
+}
+
+// linkOut returns the name of the hash holding the links from key in the namespace ns.
+func linkOut(ns, key string) string {
+	return ns + linkPrefix + "out\x00" + key
+}
+
+// linkIn returns the name of the hash holding the links to key in the namespace ns.
+func linkIn(ns, key string) string {
+	return ns + linkPrefix + "in\x00" + key
+}
+
+// checkRelation rejects relation names that cannot be stored.
+func checkRelation(relation string) error {
+	if relation == "" || strings.Contains(relation, "\x00") {
+		return fmt.Errorf("invalid relation %q", relation)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package jungledb
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestLinks tests that links are kept in both directions and follow deletes and renames.
+func TestLinks(t *testing.T) {
+	db, err := Open("testdata/links.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, post := range []string{"post:2", "post:1"} {
+		if err := db.Hset(post, "title", []byte(post)); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+		if err := db.Link("user:1", "posts", post); err != nil {
+			t.Fatalf("Link failed: %v", err)
+		}
+	}
+	if err := db.Link("user:1", "posts", "post:1"); err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+	if links, err := db.Links("user:1", "posts"); err != nil || !reflect.DeepEqual(links, []string{"post:1", "post:2"}) {
+		t.Errorf("expected [post:1 post:2], got %v %v", links, err)
+	}
+	if links, err := db.Backlinks("post:1", "posts"); err != nil || !reflect.DeepEqual(links, []string{"user:1"}) {
+		t.Errorf("expected [user:1], got %v %v", links, err)
+	}
+	if links, err := db.Links("user:1", "likes"); err != nil || len(links) != 0 {
+		t.Errorf("expected no links for another relation, got %v %v", links, err)
+	}
+	if keys, _, err := db.ListKeys("", "", 0); err != nil || !reflect.DeepEqual(keys, []string{"post:1", "post:2"}) {
+		t.Errorf("expected the links to be hidden, got %v %v", keys, err)
+	}
+	if err := db.Link("user:1", "bad\x00relation", "post:1"); err == nil {
+		t.Errorf("expected an error for a relation with a NUL byte")
+	}
+
+	// Deleting a key drops its links in both directions
+	if err := db.HdelBucket("post:2"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if links, err := db.Links("user:1", "posts"); err != nil || !reflect.DeepEqual(links, []string{"post:1"}) {
+		t.Errorf("expected [post:1] after the delete, got %v %v", links, err)
+	}
+
+	// Renaming a key moves its links
+	if err := db.Rename("post:1", "post:3"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if links, err := db.Links("user:1", "posts"); err != nil || !reflect.DeepEqual(links, []string{"post:3"}) {
+		t.Errorf("expected [post:3] after the rename, got %v %v", links, err)
+	}
+	if links, err := db.Backlinks("post:3", "posts"); err != nil || !reflect.DeepEqual(links, []string{"user:1"}) {
+		t.Errorf("expected [user:1] after the rename, got %v %v", links, err)
+	}
+
+	// Links are written in transactions too, and unlinking drops both directions
+	err = db.Update(func(tx *Tx) error {
+		return tx.Unlink("user:1", "posts", "post:3")
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if links, err := db.Backlinks("post:3", "posts"); err != nil || len(links) != 0 {
+		t.Errorf("expected no backlinks after Unlink, got %v %v", links, err)
+	}
+	if keys, _, err := db.ListKeys("", "", 0); err != nil || !reflect.DeepEqual(keys, []string{"post:3"}) {
+		t.Errorf("expected only post:3 left, got %v %v", keys, err)
+	}
+}
@@ -0,0 +1,164 @@
+package jungledb
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Key distributions of LoadConfig.
+const (
+	DistUniform = "uniform" // Every key equally likely
+	DistZipf    = "zipf"    // A few hot keys take most of the traffic
+)
+
+// LoadConfig describes the traffic generated by RunLoad. Zero fields take their defaults.
+type LoadConfig struct {
+	Duration     time.Duration // How long to run, 10s by default
+	Ops          int           // Stop after this many operations instead, if positive
+	Concurrency  int           // Goroutines issuing operations, 1 by default
+	ReadRatio    float64       // Share of operations that are reads (Hget), the rest are writes (Hset)
+	Keys         int           // Number of distinct keys, 10000 by default
+	Distribution string        // DistUniform (the default) or DistZipf
+	ValueSize    int           // Bytes per value written, 100 by default
+	KeyPrefix    string        // Prefix of the keys used, "bench:" by default
+	Populate     bool          // Write every key once before the run, so reads find them
+	Seed         uint64        // Seed of the key and operation choices
+}
+
+// LoadReport is the outcome of RunLoad.
+type LoadReport struct {
+	Ops        int           // Operations completed, including failed ones
+	Reads      int           // Reads among them
+	Writes     int           // Writes among them
+	Errors     int           // Operations that failed
+	Elapsed    time.Duration // Wall time of the run, excluding Populate
+	Throughput float64       // Operations per second
+
+	// Latency percentiles over all operations
+	P50, P90, P99, P999, Max time.Duration
+}
+
+// String formats r as one "name\tvalue" line per figure.
+func (r LoadReport) String() string {
+	return fmt.Sprintf("ops\t%d\nreads\t%d\nwrites\t%d\nerrors\t%d\nelapsed\t%s\nops_per_sec\t%.1f\np50\t%s\np90\t%s\np99\t%s\np999\t%s\nmax\t%s\n",
+		r.Ops, r.Reads, r.Writes, r.Errors, r.Elapsed, r.Throughput, r.P50, r.P90, r.P99, r.P999, r.Max)
+}
+
+// RunLoad generates traffic against s, a database or a Client connected to a server, and
+// reports its throughput and latency, for capacity planning. Each key is a hash with one
+// field, "v". The run stops after cfg.Duration or cfg.Ops operations, or when ctx is
+// done; it only fails if populating the keys does.
+func RunLoad(ctx context.Context, s Store, cfg LoadConfig) (LoadReport, error) {
+	if cfg.Duration <= 0 {
+		cfg.Duration = 10 * time.Second
+	}
+	cfg.Concurrency = max(cfg.Concurrency, 1)
+	if cfg.Keys <= 0 {
+		cfg.Keys = 10000
+	}
+	if cfg.ValueSize <= 0 {
+		cfg.ValueSize = 100
+	}
+	if cfg.KeyPrefix == "" {
+		cfg.KeyPrefix = "bench:"
+	}
+	if cfg.Distribution == "" {
+		cfg.Distribution = DistUniform
+	}
+	if cfg.Distribution != DistUniform && cfg.Distribution != DistZipf {
+		return LoadReport{}, fmt.Errorf("unknown key distribution %q", cfg.Distribution)
+	}
+
+	value := make([]byte, cfg.ValueSize)
+	for i := range value {
+		value[i] = 'a' + byte(i%26)
+	}
+	key := func(i uint64) string { return cfg.KeyPrefix + fmt.Sprint(i) }
+	if cfg.Populate {
+		for i := range uint64(cfg.Keys) {
+			if err := s.Hset(key(i), "v", value); err != nil {
+				return LoadReport{}, fmt.Errorf("failed to populate keys: %v", err)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.Duration)
+	defer cancel()
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		report    LoadReport
+		latencies []time.Duration
+		remaining = cfg.Ops // Ops left, when cfg.Ops is set
+	)
+	take := func() bool {
+		if cfg.Ops <= 0 {
+			return ctx.Err() == nil
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if remaining == 0 || ctx.Err() != nil {
+			return false
+		}
+		remaining--
+		return true
+	}
+
+	start := time.Now()
+	for w := range cfg.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := rand.New(rand.NewPCG(cfg.Seed, uint64(w)))
+			next := func() uint64 { return r.Uint64N(uint64(cfg.Keys)) }
+			if cfg.Distribution == DistZipf {
+				next = rand.NewZipf(r, 1.1, 1, uint64(cfg.Keys-1)).Uint64
+			}
+			var local LoadReport
+			var lats []time.Duration
+			for take() {
+				k := key(next())
+				read := r.Float64() < cfg.ReadRatio
+				t := time.Now()
+				var err error
+				if read {
+					_, err = s.Hget(k, "v")
+					local.Reads++
+				} else {
+					err = s.Hset(k, "v", value)
+					local.Writes++
+				}
+				lats = append(lats, time.Since(t))
+				if err != nil {
+					local.Errors++
+				}
+			}
+			mu.Lock()
+			report.Reads += local.Reads
+			report.Writes += local.Writes
+			report.Errors += local.Errors
+			latencies = append(latencies, lats...)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	report.Elapsed = time.Since(start)
+	report.Ops = len(latencies)
+	if report.Elapsed > 0 {
+		report.Throughput = float64(report.Ops) / report.Elapsed.Seconds()
+	}
+	if len(latencies) > 0 {
+		slices.Sort(latencies)
+		percentile := func(p float64) time.Duration {
+			return latencies[min(len(latencies)-1, int(p*float64(len(latencies))))]
+		}
+		report.P50, report.P90, report.P99, report.P999 = percentile(0.5), percentile(0.9), percentile(0.99), percentile(0.999)
+		report.Max = latencies[len(latencies)-1]
+	}
+	return report, nil
+}
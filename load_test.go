@@ -0,0 +1,41 @@
+package jungledb
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// TestRunLoad tests that RunLoad honours the operation budget and read ratio.
+func TestRunLoad(t *testing.T) {
+	db, err := Open("testdata/load.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	report, err := RunLoad(context.Background(), db, LoadConfig{Ops: 500, Concurrency: 4, ReadRatio: 1, Keys: 20, Populate: true, Distribution: DistZipf})
+	if err != nil {
+		t.Fatalf("RunLoad failed: %v", err)
+	}
+	if report.Ops != 500 || report.Reads != 500 || report.Writes != 0 || report.Errors != 0 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if report.Throughput <= 0 || report.P50 > report.P99 || report.P99 > report.Max {
+		t.Errorf("inconsistent figures: %+v", report)
+	}
+	if v, err := db.Hget("bench:19", "v"); err != nil || len(v) != 100 {
+		t.Errorf("expected populated keys, got %d bytes %v", len(v), err)
+	}
+
+	report, err = RunLoad(context.Background(), db, LoadConfig{Duration: 50 * time.Millisecond, Keys: 20})
+	if err != nil {
+		t.Fatalf("RunLoad failed: %v", err)
+	}
+	if report.Writes != report.Ops || report.Ops == 0 || report.Elapsed > time.Second {
+		t.Errorf("expected a short write-only run, got %+v", report)
+	}
+	if _, err := RunLoad(context.Background(), db, LoadConfig{Distribution: "bogus"}); err == nil {
+		t.Error("expected an unknown distribution to fail")
+	}
+}
@@ -0,0 +1,137 @@
+package jungledb
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// lockPrefix starts the names of the hashes holding locks: the prefix and the lock
+	// name. A lock hash has an "owner" and a "token" field and expires with the lock.
+	lockPrefix = "__jungledb.lock:"
+
+	// lockBucket holds the last fencing token issued for each lock: name -> 8-byte token.
+	// It outlives the locks so that tokens keep increasing.
+	lockBucket = internalPrefix + "locks"
+
+	// lockPollInterval is how often Lock retries a held lock.
+	lockPollInterval = 50 * time.Millisecond
+)
+
+// ErrLockNotHeld is returned by Unlock when the lock is free or held by another owner.
+var ErrLockNotHeld = errors.New("lock not held")
+
+// TryLock acquires the lock name for owner for ttl, and reports whether it did. A lock
+// is free if it was never taken, was unlocked or its ttl has elapsed, so a crashed owner
+// cannot hold it forever. If owner already holds the lock, TryLock extends it to ttl
+// from now and succeeds, which lets long jobs renew their lock. Locks live in the
+// namespace of db (see Namespace) and can be shared with other processes through the
+// servers; their keys are hidden from ListKeys and DeleteByPattern.
+func (db *DB) TryLock(name, owner string, ttl time.Duration) (bool, error) {
+	_, ok, err := db.tryLock("TryLock", name, owner, ttl)
+	return ok, err
+}
+
+// TryLockToken is like TryLock and also returns the fencing token of the lock when it
+// is acquired. Tokens of a lock increase with each acquisition and stay the same when an
+// owner renews it, so a resource can reject writes carrying a token lower than the
+// highest it has seen, from an owner that lost the lock without noticing.
+func (db *DB) TryLockToken(name, owner string, ttl time.Duration) (token uint64, ok bool, err error) {
+	return db.tryLock("TryLockToken", name, owner, ttl)
+}
+
+// Lock is like TryLockToken but waits until the lock is acquired or ctx is done, in
+// which case it returns ctx.Err().
+func (db *DB) Lock(ctx context.Context, name, owner string, ttl time.Duration) (uint64, error) {
+	for {
+		token, ok, err := db.tryLock("Lock", name, owner, ttl)
+		if err != nil || ok {
+			return token, err
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-db.clock().After(lockPollInterval):
+		}
+	}
+}
+
+// Unlock releases the lock name if owner holds it, and returns ErrLockNotHeld otherwise.
+func (db *DB) Unlock(name, owner string) error {
+	key := db.nsKey(lockPrefix + name)
+	return db.update("Unlock", key, func(tx *txn) error {
+		bucket, err := db.hashBucket(tx.Tx, key)
+		if err != nil {
+			return err
+		}
+		if bucket == nil || string(bucket.Get([]byte("owner"))) != owner {
+			return fmt.Errorf("%w: %s", ErrLockNotHeld, name)
+		}
+		if err := deleteKey(tx, key); err != nil {
+			return err
+		}
+		tx.record(Event{Type: EventDelete, Key: key})
+		return nil
+	})
+}
+
+func (db *DB) tryLock(op, name, owner string, ttl time.Duration) (token uint64, ok bool, err error) {
+	if ttl <= 0 {
+		return 0, false, fmt.Errorf("invalid lock TTL %v", ttl)
+	}
+	key := db.nsKey(lockPrefix + name)
+	err = db.update(op, key, func(tx *txn) error {
+		bucket, err := db.hashBucket(tx.Tx, key)
+		if err != nil {
+			return err
+		}
+		deadline := db.now().Add(ttl).UnixNano()
+		if bucket != nil {
+			if string(bucket.Get([]byte("owner"))) != owner {
+				return nil // Held by someone else
+			}
+			token, ok = decodeToken(bucket.Get([]byte("token"))), true
+			return setExpiry(tx, key, deadline)
+		}
+		if tx.Bucket([]byte(key)) != nil {
+			if err := expireKey(tx, key); err != nil { // Elapsed, not swept yet
+				return err
+			}
+		}
+
+		tokens, err := tx.CreateBucketIfNotExists([]byte(lockBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create lock bucket: %v", err)
+		}
+		token = decodeToken(tokens.Get([]byte(key))) + 1
+		if err := tokens.Put([]byte(key), encodeSeq(token)); err != nil {
+			return err
+		}
+		if bucket, err = tx.CreateBucket([]byte(key)); err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		for field, value := range map[string][]byte{"owner": []byte(owner), "token": encodeSeq(token)} {
+			tx.record(Event{Type: EventHset, Key: key, Field: field, Value: value})
+			if err := bucket.Put([]byte(field), value); err != nil {
+				return err
+			}
+		}
+		ok = true
+		return setExpiry(tx, key, deadline)
+	})
+	if err != nil {
+		return 0, false, err
+	}
+	return token, ok, nil
+}
+
+// decodeToken decodes a fencing token, 0 if there is none.
+func decodeToken(b []byte) uint64 {
+	if len(b) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(b)
+}
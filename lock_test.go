@@ -0,0 +1,83 @@
+package jungledb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestLock tests that locks exclude other owners, can be renewed and released only by
+// their owner, expire with their TTL and issue increasing fencing tokens.
+func TestLock(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	db, err := Open("testdata/lock.db", WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	token, ok, err := db.TryLockToken("cron", "worker-a", time.Minute)
+	if err != nil || !ok || token != 1 {
+		t.Fatalf("TryLockToken: expected token 1, got %d %v %v", token, ok, err)
+	}
+	if ok, err := db.TryLock("cron", "worker-b", time.Minute); err != nil || ok {
+		t.Errorf("TryLock by another owner: expected false, got %v %v", ok, err)
+	}
+	if token, ok, err := db.TryLockToken("cron", "worker-a", time.Minute); err != nil || !ok || token != 1 {
+		t.Errorf("renewal: expected token 1, got %d %v %v", token, ok, err)
+	}
+	if err := db.Unlock("cron", "worker-b"); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("Unlock by another owner: expected ErrLockNotHeld, got %v", err)
+	}
+	if err := db.Unlock("cron", "worker-a"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	if err := db.Unlock("cron", "worker-a"); !errors.Is(err, ErrLockNotHeld) {
+		t.Errorf("second Unlock: expected ErrLockNotHeld, got %v", err)
+	}
+
+	// An abandoned lock expires
+	if ok, err := db.TryLock("cron", "worker-a", time.Minute); err != nil || !ok {
+		t.Fatalf("TryLock failed: %v %v", ok, err)
+	}
+	clock.advance(time.Minute)
+	if token, ok, err := db.TryLockToken("cron", "worker-b", time.Minute); err != nil || !ok || token != 3 {
+		t.Errorf("TryLockToken after expiry: expected token 3, got %d %v %v", token, ok, err)
+	}
+
+	// Lock waits for the holder to unlock
+	type result struct {
+		token uint64
+		err   error
+	}
+	locked := make(chan result, 1)
+	go func() {
+		token, err := db.Lock(context.Background(), "cron", "worker-a", time.Minute)
+		locked <- result{token, err}
+	}()
+	waitFor(t, "Lock to poll", func() bool { return clock.waiting() > 0 })
+	if err := db.Unlock("cron", "worker-b"); err != nil {
+		t.Fatalf("Unlock failed: %v", err)
+	}
+	clock.advance(lockPollInterval)
+	if res := <-locked; res.err != nil || res.token != 4 {
+		t.Errorf("Lock: expected token 4, got %d %v", res.token, res.err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := db.Lock(ctx, "cron", "worker-b", time.Minute); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lock on a held lock: expected DeadlineExceeded, got %v", err)
+	}
+
+	// Locks are namespaced and hidden from key listings
+	if ok, err := db.Namespace("other").TryLock("cron", "worker-b", time.Minute); err != nil || !ok {
+		t.Errorf("TryLock in another namespace: expected true, got %v %v", ok, err)
+	}
+	if keys, _, err := db.ListKeys("*", "", 0); err != nil || len(keys) != 0 {
+		t.Errorf("ListKeys should not list locks, got %v %v", keys, err)
+	}
+	if _, err := db.TryLock("cron", "worker-a", 0); err == nil {
+		t.Error("TryLock with a zero TTL should fail")
+	}
+}
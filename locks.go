@@ -0,0 +1,88 @@
+package jungledb
+
+import (
+	"slices"
+	"sync"
+)
+
+// lockStripes is the number of locks writes are spread over by key.
+const lockStripes = 256
+
+// keyLocks serializes writes to the same keys before they reach bbolt, which serializes
+// all writes itself: keys hash to one of lockStripes locks, so writes to unrelated keys
+// rarely wait on each other here.
+type keyLocks [lockStripes]sync.Mutex
+
+// lock takes the locks of the keys stored under names, or every lock if a name is empty,
+// always in the same order so that writes to overlapping keys cannot deadlock, and
+// returns the function that releases them.
+func (l *keyLocks) lock(names []string) (unlock func()) {
+	var stripes []int
+	if slices.Contains(names, "") {
+		stripes = make([]int, lockStripes)
+		for i := range stripes {
+			stripes[i] = i
+		}
+	} else {
+		for _, name := range names {
+			stripes = append(stripes, stripeOf(name))
+		}
+		slices.Sort(stripes)
+		stripes = slices.Compact(stripes)
+	}
+	for _, i := range stripes {
+		l[i].Lock()
+	}
+	return func() {
+		for _, i := range slices.Backward(stripes) {
+			l[i].Unlock()
+		}
+	}
+}
+
+// stripeOf returns the lock of the key stored under name, from its FNV-1a hash.
+func stripeOf(name string) int {
+	h := uint32(2166136261)
+	for i := 0; i < len(name); i++ {
+		h ^= uint32(name[i])
+		h *= 16777619
+	}
+	return int(h % lockStripes)
+}
+
+// commitOrder hands out tickets to transactions as they commit, so that the steps that
+// follow a commit (watchers, shadows, caches) run in commit order although writes to
+// different keys no longer hold a common lock once committed.
+type commitOrder struct {
+	mu     sync.Mutex
+	turn   sync.Cond // Broadcast when done moves on
+	issued uint64    // Last ticket handed out
+	done   uint64    // Last ticket whose steps have run
+}
+
+// issue returns the next ticket. It is called from within bbolt's write transaction,
+// which serializes the callers.
+func (c *commitOrder) issue() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.turn.L = &c.mu
+	c.issued++
+	return c.issued
+}
+
+// wait returns once the steps of every earlier ticket have run.
+func (c *commitOrder) wait(ticket uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.done+1 != ticket {
+		c.turn.Wait()
+	}
+}
+
+// finish lets the next ticket proceed.
+func (c *commitOrder) finish() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.done++
+	c.turn.Broadcast()
+}
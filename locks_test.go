@@ -0,0 +1,108 @@
+package jungledb
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestReadsDuringWrite tests that reads do not wait for a write in progress.
+func TestReadsDuringWrite(t *testing.T) {
+	db, err := Open("testdata/locks.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("a", "f", []byte("old")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- db.Update(func(tx *Tx) error {
+			if err := tx.Hset("a", "f", []byte("new")); err != nil {
+				return err
+			}
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	read := make(chan []byte)
+	go func() {
+		v, _ := db.Hget("a", "f")
+		read <- v
+	}()
+	select {
+	case v := <-read:
+		if string(v) != "old" {
+			t.Errorf("expected the value before the write, got %q", v)
+		}
+	case <-time.After(5 * time.Second):
+		t.Errorf("read blocked by a write in progress")
+	}
+	close(release)
+	if err := <-done; err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if v, _ := db.Hget("a", "f"); string(v) != "new" {
+		t.Errorf("expected the written value, got %q", v)
+	}
+}
+
+// TestKeyLocks tests that key locks can be taken for keys sharing a lock, and that
+// concurrent writes to many keys are all applied with their events in commit order.
+func TestKeyLocks(t *testing.T) {
+	var l keyLocks
+	a := "a"
+	b := ""
+	for i := 0; b == ""; i++ {
+		if k := string(rune('b' + i)); stripeOf(k) == stripeOf(a) {
+			b = k
+		}
+	}
+	unlock := l.lock([]string{a, b, a})
+	unlock()
+	l.lock([]string{""})()
+
+	db, err := Open("testdata/keylocks.db", WithOpLog())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	events, stop := db.Watch("counter:*")
+	defer stop()
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			key := string(rune('a' + i))
+			for range 25 {
+				if _, err := db.Hincr("counter:"+key, "n", 1); err != nil {
+					t.Errorf("Hincr failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var last uint64
+	for range 200 {
+		ev := <-events
+		if ev.Seq <= last {
+			t.Fatalf("expected events in commit order, got %d after %d", ev.Seq, last)
+		}
+		last = ev.Seq
+	}
+	for i := range 8 {
+		if n, _ := db.HgetInt("counter:"+string(rune('a'+i)), "n"); n != 25 {
+			t.Errorf("expected 25 increments of %c, got %d", 'a'+i, n)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package jungledb
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// defaultMaintenancePace is the pause between batches of maintenance work unless the
+	// window says otherwise.
+	defaultMaintenancePace = 10 * time.Millisecond
+
+	// defaultCompactFreeRatio is the share of the file on the freelist past which
+	// maintenance compacts unless the window says otherwise.
+	defaultCompactFreeRatio = 0.25
+)
+
+// MaintenanceWindow is a daily period in which the database does its housekeeping, see
+// WithMaintenanceWindow.
+type MaintenanceWindow struct {
+	// Start and End are the times of day the window opens and closes, such as 3*time.Hour
+	// and 4*time.Hour for 03:00 to 04:00. A window ending before it starts spans midnight.
+	Start, End time.Duration
+	Location   *time.Location // Time zone of Start and End, time.Local if nil
+
+	Pace   time.Duration // Pause between batches of work, 10ms by default
+	Repair bool          // Whether verification repairs what it finds, see Check
+
+	// CompactTo is where a compacted copy of the database is written once the freelist
+	// holds CompactFreeRatio of the file (25% by default), replacing the previous copy.
+	// bbolt cannot shrink a file in use: the copy replaces it while the database is
+	// closed, as the compact command does. Empty skips compaction.
+	CompactTo        string
+	CompactFreeRatio float64
+}
+
+// MaintenanceStats reports the progress of maintenance, see WithMaintenanceWindow.
+type MaintenanceStats struct {
+	Task      string    // Task running: "retention", "sweep", "verify" or "compact", empty between runs
+	Runs      int       // Runs completed since Open
+	LastStart time.Time // Start of the current or last run
+	LastEnd   time.Time // End of the last run, zero until one completes
+
+	// Work of the current or last run
+	Expired   int    // Keys removed because their TTL elapsed
+	Aged      int    // Keys given a TTL by retention rules, see WithRetention
+	Trimmed   int    // Sorted set members removed by retention rules
+	Problems  int    // Inconsistencies found by verification
+	Compacted bool   // Whether a compacted copy was written
+	LastError string // Error that ended a task early, empty if none did
+}
+
+// maintenance is the state of the maintenance goroutine.
+type maintenance struct {
+	mu    sync.Mutex
+	stats MaintenanceStats
+
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// WithMaintenanceWindow runs the database's housekeeping every day within w, so that it
+// does not compete with traffic outside of it: retention rules are enforced (see
+// WithRetention) and expired keys removed in paced batches, the database is verified (see Check) and, if w.CompactTo is set and enough
+// of the file is free, compacted. Work left when the window closes waits for the next
+// one, and MaintenanceStats reports progress. To leave expired keys to the window
+// rather than the expiry sweeper, disable it with WithExpirySweep(-1): they then read as
+// missing until removed. Read-only databases run no maintenance.
+func WithMaintenanceWindow(w MaintenanceWindow) Option {
+	return func(o *options) {
+		o.maintenance = &w
+	}
+}
+
+// MaintenanceStats returns the progress of maintenance.
+func (db *DB) MaintenanceStats() MaintenanceStats {
+	db.maint.mu.Lock()
+	defer db.maint.mu.Unlock()
+	return db.maint.stats
+}
+
+// startMaintenance starts the maintenance goroutine if a window is configured.
+func (db *DB) startMaintenance() {
+	if db.opts.maintenance == nil {
+		return
+	}
+	db.maint.stop = make(chan struct{})
+	db.maint.done = make(chan struct{})
+	go db.maintain(*db.opts.maintenance, db.maint.stop, db.maint.done)
+}
+
+// stopMaintenance stops the maintenance goroutine and waits for it to exit.
+func (db *DB) stopMaintenance() {
+	if db.maint.stop == nil {
+		return
+	}
+	db.maint.stopOnce.Do(func() { close(db.maint.stop) })
+	<-db.maint.done
+}
+
+// maintain runs maintenance in every window until stop is closed.
+func (db *DB) maintain(w MaintenanceWindow, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	for {
+		open, end := w.next(db.now())
+		if !db.sleepUntil(open, stop) {
+			return
+		}
+		db.runMaintenance(w, end, stop)
+		if !db.sleepUntil(end, stop) { // Once per window
+			return
+		}
+	}
+}
+
+// next returns when the window containing t, or else the next one, opens and closes.
+func (w MaintenanceWindow) next(t time.Time) (open, end time.Time) {
+	loc := w.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	t = t.In(loc)
+	length := w.End - w.Start
+	if length <= 0 {
+		length += 24 * time.Hour
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+	for day := -1; ; day++ { // From yesterday, whose window may span midnight
+		open = midnight.AddDate(0, 0, day).Add(w.Start)
+		if end = open.Add(length); end.After(t) {
+			return open, end
+		}
+	}
+}
+
+// sleep waits for d and reports whether stop stayed open.
+func sleep(d time.Duration, stop <-chan struct{}) bool {
+	if d <= 0 {
+		select {
+		case <-stop:
+			return false
+		default:
+			return true
+		}
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-stop:
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// runMaintenance runs the maintenance tasks until they are done, end passes or stop is closed.
+func (db *DB) runMaintenance(w MaintenanceWindow, end time.Time, stop <-chan struct{}) {
+	pace := w.Pace
+	if pace <= 0 {
+		pace = defaultMaintenancePace
+	}
+	progress := func(fn func(s *MaintenanceStats)) {
+		db.maint.mu.Lock()
+		fn(&db.maint.stats)
+		db.maint.mu.Unlock()
+	}
+	progress(func(s *MaintenanceStats) {
+		*s = MaintenanceStats{Runs: s.Runs, LastStart: db.now()}
+	})
+	db.log.Info("maintenance started", "until", end)
+
+	// Each task reports whether the window is still open for the next one
+	tasks := []struct {
+		name string
+		run  func() (bool, error)
+	}{
+		{"retention", func() (bool, error) {
+			aged, trimmed, err := db.enforceRetention(pace, func() bool { return db.now().Before(end) }, stop)
+			progress(func(s *MaintenanceStats) { s.Aged, s.Trimmed = aged, trimmed })
+			return err == nil && db.now().Before(end), err
+		}},
+		{"sweep", func() (bool, error) {
+			for db.sweepDue() {
+				var expired, freed int
+				err := db.update("Maintenance", "", func(tx *txn) error {
+					expired, freed = len(tx.events), tx.freed
+					return nil
+				})
+				if err != nil {
+					return false, err
+				}
+				progress(func(s *MaintenanceStats) { s.Expired += expired })
+				if !db.throttle.wait(expired, freed, stop) || !sleep(pace, stop) || !db.now().Before(end) {
+					return false, nil
+				}
+			}
+			return true, nil
+		}},
+		{"verify", func() (bool, error) {
+			found, err := db.Check(w.Repair)
+			progress(func(s *MaintenanceStats) { s.Problems = len(found) })
+			for _, p := range found {
+				db.log.Warn("inconsistency found", "key", p.Key, "problem", p.Problem, "repaired", p.Repaired)
+			}
+			return err == nil && sleep(pace, stop) && db.now().Before(end), err
+		}},
+		{"compact", func() (bool, error) {
+			if w.CompactTo == "" || !db.compactDue(w.CompactFreeRatio) {
+				return true, nil
+			}
+			if err := os.Remove(w.CompactTo); err != nil && !errors.Is(err, os.ErrNotExist) {
+				return false, err
+			}
+			if err := db.Compact(w.CompactTo); err != nil {
+				return false, err
+			}
+			progress(func(s *MaintenanceStats) { s.Compacted = true })
+			return true, nil
+		}},
+	}
+
+	for _, task := range tasks {
+		progress(func(s *MaintenanceStats) { s.Task = task.name })
+		more, err := task.run()
+		if err != nil {
+			db.log.Warn("maintenance task failed", "task", task.name, "error", err)
+			progress(func(s *MaintenanceStats) { s.LastError = task.name + ": " + err.Error() })
+		}
+		if !more {
+			break
+		}
+	}
+
+	var stats MaintenanceStats
+	progress(func(s *MaintenanceStats) {
+		s.Task = ""
+		s.Runs++
+		s.LastEnd = db.now()
+		stats = *s
+	})
+	db.log.Info("maintenance done", "aged", stats.Aged, "trimmed", stats.Trimmed, "expired", stats.Expired, "problems", stats.Problems, "compacted", stats.Compacted, "duration", stats.LastEnd.Sub(stats.LastStart))
+}
+
+// compactDue reports whether the freelist holds at least ratio of the file, or of the
+// default ratio if it is zero.
+func (db *DB) compactDue(ratio float64) bool {
+	if ratio <= 0 {
+		ratio = defaultCompactFreeRatio
+	}
+	due := false
+	db.db.View(func(tx *bbolt.Tx) error {
+		stats := tx.DB().Stats() // Pending pages are free once the readers using them are done
+		free := (stats.FreePageN + stats.PendingPageN) * tx.DB().Info().PageSize
+		due = float64(free) >= ratio*float64(tx.Size())
+		return nil
+	})
+	return due
+}
@@ -0,0 +1,61 @@
+package jungledb
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestMaintenanceWindow tests that maintenance runs once in its window.
+func TestMaintenanceWindow(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	db, err := Open("testdata/maintenance.db", WithExpirySweep(-1), WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	deadline := clock.Now().Add(time.Minute)
+	for _, key := range []string{"a", "b", "c"} {
+		if err := db.Hset(key, "f", make([]byte, 4096)); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+		if err := db.ExpireAt(key, deadline); err != nil {
+			t.Fatalf("ExpireAt failed: %v", err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	clock.advance(time.Minute)
+
+	// A window around now
+	db, err = Open("testdata/maintenance.db", WithExpirySweep(-1), WithClock(clock), WithMaintenanceWindow(MaintenanceWindow{
+		Start:            11 * time.Hour,
+		End:              13 * time.Hour,
+		Location:         time.UTC,
+		Pace:             time.Millisecond,
+		CompactTo:        "testdata/maintenance_compacted.db",
+		CompactFreeRatio: 0.01,
+	}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	waitFor(t, "maintenance", func() bool { return db.MaintenanceStats().Runs > 0 })
+	stats := db.MaintenanceStats()
+	if stats.Runs != 1 || stats.Expired != 3 || stats.Problems != 0 || !stats.Compacted || stats.LastError != "" {
+		t.Fatalf("unexpected maintenance stats %+v", stats)
+	}
+	if _, err := os.Stat("testdata/maintenance_compacted.db"); err != nil {
+		t.Errorf("expected a compacted copy, got %v", err)
+	}
+	if keys, _, err := db.ListKeys("", "", 0); err != nil || len(keys) != 0 {
+		t.Errorf("expected the expired keys to be removed, got %v %v", keys, err)
+	}
+
+	// Outside of its window, maintenance waits
+	open, end := MaintenanceWindow{Start: 3 * time.Hour, End: time.Hour}.next(time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC).In(time.Local))
+	if open.Hour() != 3 || end.Sub(open) != 22*time.Hour {
+		t.Errorf("expected a window from 03:00 spanning midnight, got %v to %v", open, end)
+	}
+}
@@ -0,0 +1,114 @@
+package jungledb
+
+import (
+	"crypto/rand"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// Keys of the meta bucket identifying the database, written when it is created.
+var (
+	idKey      = []byte("id")
+	createdKey = []byte("created")
+	creatorKey = []byte("creator")
+	versionKey = []byte("version")
+)
+
+// modulePath is the path of this module, to find its version in the build info.
+const modulePath = "github.com/ehebe/jungledb"
+
+// Meta identifies a database file and what created it, so a file found on disk can be
+// traced back to the service that wrote it. Databases created before this was recorded
+// only have a Format.
+type Meta struct {
+	ID      string    // Random UUID given to the database when it was created
+	Created time.Time // When the database was created, on the clock of its creator
+	Format  int       // Format version of the file
+	Creator string    // What created the database, as set with WithCreator
+	Version string    // Version of jungledb that created the database
+}
+
+// WithCreator names what creates the database, such as "billing-service v1.4.2", for
+// Meta. It is recorded when Open creates a new file and ignored otherwise.
+func WithCreator(creator string) Option {
+	return func(o *options) {
+		o.creator = creator
+	}
+}
+
+// Meta returns the identity of the database and the format it is stored in.
+func (db *DB) Meta() (Meta, error) {
+	var meta Meta
+	err := db.view("Meta", "", func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(metaBucket))
+		if bucket == nil {
+			return nil
+		}
+		meta.ID = string(bucket.Get(idKey))
+		meta.Creator = string(bucket.Get(creatorKey))
+		meta.Version = string(bucket.Get(versionKey))
+		meta.Format, _ = strconv.Atoi(string(bucket.Get(formatKey)))
+		if created, err := DecodeExpiry(bucket.Get(createdKey)); err == nil {
+			meta.Created = time.Unix(0, created)
+		}
+		return nil
+	})
+	return meta, err
+}
+
+// stampNew initializes the meta bucket of a new database: its format and identity.
+func stampNew(tx *bbolt.Tx, o *options) error {
+	if err := stampFormat(tx); err != nil {
+		return err
+	}
+	now := time.Now()
+	if o.clock != nil {
+		now = o.clock.Now()
+	}
+	bucket := tx.Bucket([]byte(metaBucket))
+	for k, v := range map[string][]byte{
+		string(idKey):      []byte(newUUID()),
+		string(createdKey): EncodeExpiry(now.UnixNano()),
+		string(creatorKey): []byte(o.creator),
+		string(versionKey): []byte(moduleVersion()),
+	} {
+		if err := bucket.Put([]byte(k), v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newUUID returns a random (version 4) UUID.
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40 // Version 4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// moduleVersion returns the version of this module in the running binary, "(devel)" when
+// built from a checkout of it, or "unknown" without build information.
+func moduleVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if info.Main.Path == modulePath {
+		return info.Main.Version
+	}
+	for _, dep := range info.Deps {
+		if dep.Path == modulePath {
+			if dep.Replace != nil {
+				return dep.Replace.Version
+			}
+			return dep.Version
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,39 @@
+package jungledb
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+// TestMeta tests that new databases record their identity and creator, and keep them
+// when reopened.
+func TestMeta(t *testing.T) {
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	clock := newManualClock(created)
+	db, err := Open("testdata/meta.db", WithCreator("billing v1.2.3"), WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	meta, err := db.Meta()
+	db.Close()
+	if err != nil {
+		t.Fatalf("Meta failed: %v", err)
+	}
+	if !regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`).MatchString(meta.ID) {
+		t.Errorf("expected a version 4 UUID, got %q", meta.ID)
+	}
+	if !meta.Created.Equal(created) || meta.Format != formatVersion || meta.Creator != "billing v1.2.3" || meta.Version == "" {
+		t.Errorf("unexpected meta: %+v", meta)
+	}
+
+	// Reopening, even with another creator, keeps the identity
+	db, err = Open("testdata/meta.db", WithCreator("other"))
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	if again, err := db.Meta(); err != nil || again != meta {
+		t.Errorf("Meta after reopening = %+v, %v; expected %+v", again, err, meta)
+	}
+}
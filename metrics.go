@@ -0,0 +1,196 @@
+package jungledb
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// LatencyBuckets are the upper bounds of the latency histograms kept by the database.
+var LatencyBuckets = []time.Duration{
+	50 * time.Microsecond, 100 * time.Microsecond, 250 * time.Microsecond, 500 * time.Microsecond,
+	time.Millisecond, 2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond,
+	25 * time.Millisecond, 50 * time.Millisecond, 100 * time.Millisecond, 250 * time.Millisecond,
+	500 * time.Millisecond, time.Second, 2500 * time.Millisecond, 5 * time.Second,
+}
+
+// Histogram is a cumulative latency distribution over LatencyBuckets.
+type Histogram struct {
+	Count   uint64
+	Sum     time.Duration
+	Buckets []uint64 // Buckets[i] counts observations <= LatencyBuckets[i], cumulatively
+}
+
+// observe adds one observation.
+func (h *Histogram) observe(d time.Duration) {
+	if h.Buckets == nil {
+		h.Buckets = make([]uint64, len(LatencyBuckets))
+	}
+	h.Count++
+	h.Sum += d
+	for i := sort.Search(len(LatencyBuckets), func(i int) bool { return d <= LatencyBuckets[i] }); i < len(LatencyBuckets); i++ {
+		h.Buckets[i]++
+	}
+}
+
+// clone returns a copy that does not share the bucket slice.
+func (h Histogram) clone() Histogram {
+	h.Buckets = append([]uint64(nil), h.Buckets...)
+	if h.Buckets == nil {
+		h.Buckets = make([]uint64, len(LatencyBuckets))
+	}
+	return h
+}
+
+// OpMetrics are cumulative statistics for one kind of operation, such as "Hget".
+type OpMetrics struct {
+	Errors  uint64    // Operations that returned an error
+	Latency Histogram // Latency including time spent waiting for the database lock
+}
+
+// Metrics is a snapshot of the database's cumulative instrumentation.
+type Metrics struct {
+	Ops          map[string]OpMetrics // Keyed by operation name
+	ReadTx       Histogram            // Durations of read-only transactions
+	WriteTx      Histogram            // Durations of read-write transactions, including commit
+	BytesRead    uint64               // Value bytes returned by hash reads
+	BytesWritten uint64               // Key, field and value bytes of committed mutations
+	FileSize     int64                // Current size of the database file
+	OpenTx       int                  // Read transactions currently open
+	CacheHits    uint64               // Reads of cache namespace fields (see WithCache) that found the field
+	CacheMisses  uint64               // Reads of cache namespace fields that did not
+	Coalesced    uint64               // Reads served by another read in flight, see WithReadCoalescing
+	AuthFailures uint64               // Unknown tokens, failed AUTH commands and TLS handshakes of the servers
+}
+
+// metricsState accumulates the counters behind Metrics.
+type metricsState struct {
+	mu           sync.Mutex
+	ops          map[string]*OpMetrics
+	readTx       Histogram
+	writeTx      Histogram
+	bytesRead    uint64
+	bytesWritten uint64
+	cacheHits    uint64
+	cacheMisses  uint64
+	coalesced    uint64
+	authFailures uint64
+}
+
+// observeOp records one completed operation.
+func (m *metricsState) observeOp(op string, d time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ops == nil {
+		m.ops = make(map[string]*OpMetrics)
+	}
+	om := m.ops[op]
+	if om == nil {
+		om = &OpMetrics{}
+		m.ops[op] = om
+	}
+	om.Latency.observe(d)
+	if err != nil {
+		om.Errors++
+	}
+}
+
+// observeTx records one transaction; events are the mutations it committed.
+func (m *metricsState) observeTx(writable bool, d time.Duration, events []Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !writable {
+		m.readTx.observe(d)
+		return
+	}
+	m.writeTx.observe(d)
+	for _, ev := range events {
+		m.bytesWritten += uint64(len(ev.Key) + len(ev.Field) + len(ev.Value) + len(ev.Target))
+	}
+}
+
+// addRead counts value bytes returned to callers.
+func (m *metricsState) addRead(n int) {
+	m.mu.Lock()
+	m.bytesRead += uint64(n)
+	m.mu.Unlock()
+}
+
+// addCacheRead counts a field read from a cache namespace.
+func (m *metricsState) addCacheRead(hit bool) {
+	m.mu.Lock()
+	if hit {
+		m.cacheHits++
+	} else {
+		m.cacheMisses++
+	}
+	m.mu.Unlock()
+}
+
+// addCoalesced counts a read served by another read in flight.
+func (m *metricsState) addCoalesced() {
+	m.mu.Lock()
+	m.coalesced++
+	m.mu.Unlock()
+}
+
+// mapBytes sums the value sizes of a hash read.
+func mapBytes(m map[string][]byte) int {
+	n := 0
+	for _, v := range m {
+		n += len(v)
+	}
+	return n
+}
+
+// resultBytes sums the sizes of values read with Hmget.
+func resultBytes(results []GetResult) int {
+	n := 0
+	for _, r := range results {
+		n += len(r.Value)
+	}
+	return n
+}
+
+// Metrics returns a snapshot of operation counts, latencies, errors, transaction durations,
+// bytes read and written and cache hits, all cumulative since Open, along with the file
+// size and the number of open transactions.
+func (db *DB) Metrics() Metrics {
+	m := &db.metrics
+	m.mu.Lock()
+	snap := Metrics{
+		Ops:          make(map[string]OpMetrics, len(m.ops)),
+		ReadTx:       m.readTx.clone(),
+		WriteTx:      m.writeTx.clone(),
+		BytesRead:    m.bytesRead,
+		BytesWritten: m.bytesWritten,
+		CacheHits:    m.cacheHits,
+		CacheMisses:  m.cacheMisses,
+		Coalesced:    m.coalesced,
+		AuthFailures: m.authFailures,
+	}
+	for op, om := range m.ops {
+		snap.Ops[op] = OpMetrics{Errors: om.Errors, Latency: om.Latency.clone()}
+	}
+	m.mu.Unlock()
+
+	db.db.View(func(tx *bbolt.Tx) error {
+		snap.FileSize = tx.Size()
+		return nil
+	})
+	snap.OpenTx = db.db.Stats().OpenTxN
+	return snap
+}
+
+// observe records the outcome of the operation started at start in the metrics, and in
+// the slow log if it ran longer than the slow log threshold, then runs the after hooks.
+func (db *DB) observe(op Op, start time.Time, err *error) {
+	d := time.Since(start)
+	db.metrics.observeOp(op.Name, d, *err)
+	if threshold := db.slowThreshold(); threshold >= 0 && d > threshold {
+		db.slowOps.add(SlowOp{Op: op.Name, Key: op.Key, Duration: d, Time: start})
+	}
+	db.runAfterHooks(op, *err, d)
+}
@@ -0,0 +1,103 @@
+// Package metrics exports jungledb instrumentation to Prometheus.
+//
+// Register a Collector with your registry,
+//
+//	prometheus.MustRegister(metrics.NewCollector(db))
+//
+// or serve a database's metrics on their own with Handler:
+//
+//	http.Handle("/metrics", metrics.Handler(db))
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/ehebe/jungledb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Collector is a prometheus.Collector reading a database's cumulative metrics on each scrape.
+type Collector struct {
+	db *jungledb.DB
+
+	ops          *prometheus.Desc
+	opErrors     *prometheus.Desc
+	opDuration   *prometheus.Desc
+	txDuration   *prometheus.Desc
+	bytesRead    *prometheus.Desc
+	bytesWritten *prometheus.Desc
+	fileSize     *prometheus.Desc
+	authFailures *prometheus.Desc
+}
+
+// NewCollector returns a Collector for db. Use prometheus.WrapRegistererWith to add
+// constant labels when registering collectors for several databases.
+func NewCollector(db *jungledb.DB) *Collector {
+	return &Collector{
+		db: db,
+		ops: prometheus.NewDesc("jungledb_operations_total",
+			"Operations performed, by operation.", []string{"op"}, nil),
+		opErrors: prometheus.NewDesc("jungledb_operation_errors_total",
+			"Operations that returned an error, by operation.", []string{"op"}, nil),
+		opDuration: prometheus.NewDesc("jungledb_operation_duration_seconds",
+			"Operation latency including lock waits, by operation.", []string{"op"}, nil),
+		txDuration: prometheus.NewDesc("jungledb_transaction_duration_seconds",
+			"Transaction duration, by transaction type (read or write).", []string{"type"}, nil),
+		bytesRead: prometheus.NewDesc("jungledb_read_bytes_total",
+			"Value bytes returned by hash reads.", nil, nil),
+		bytesWritten: prometheus.NewDesc("jungledb_written_bytes_total",
+			"Key, field and value bytes of committed mutations.", nil, nil),
+		fileSize: prometheus.NewDesc("jungledb_file_size_bytes",
+			"Size of the database file.", nil, nil),
+		authFailures: prometheus.NewDesc("jungledb_auth_failures_total",
+			"Failed authentications of server clients.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.ops
+	ch <- c.opErrors
+	ch <- c.opDuration
+	ch <- c.txDuration
+	ch <- c.bytesRead
+	ch <- c.bytesWritten
+	ch <- c.fileSize
+	ch <- c.authFailures
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	m := c.db.Metrics()
+
+	for op, om := range m.Ops {
+		ch <- prometheus.MustNewConstMetric(c.ops, prometheus.CounterValue, float64(om.Latency.Count), op)
+		ch <- prometheus.MustNewConstMetric(c.opErrors, prometheus.CounterValue, float64(om.Errors), op)
+		ch <- histogram(c.opDuration, om.Latency, op)
+	}
+	ch <- histogram(c.txDuration, m.ReadTx, "read")
+	ch <- histogram(c.txDuration, m.WriteTx, "write")
+	ch <- prometheus.MustNewConstMetric(c.bytesRead, prometheus.CounterValue, float64(m.BytesRead))
+	ch <- prometheus.MustNewConstMetric(c.bytesWritten, prometheus.CounterValue, float64(m.BytesWritten))
+	ch <- prometheus.MustNewConstMetric(c.fileSize, prometheus.GaugeValue, float64(m.FileSize))
+	ch <- prometheus.MustNewConstMetric(c.authFailures, prometheus.CounterValue, float64(m.AuthFailures))
+}
+
+// histogram converts a jungledb latency histogram into a Prometheus one in seconds.
+func histogram(desc *prometheus.Desc, h jungledb.Histogram, label string) prometheus.Metric {
+	buckets := make(map[float64]uint64, len(jungledb.LatencyBuckets))
+	for i, bound := range jungledb.LatencyBuckets {
+		buckets[bound.Seconds()] = h.Buckets[i]
+	}
+	return prometheus.MustNewConstHistogram(desc, h.Count, h.Sum.Seconds(), buckets, label)
+}
+
+// Handler returns an http.Handler serving db's metrics, and the Go runtime's, in the
+// Prometheus exposition format from a dedicated registry.
+func Handler(db *jungledb.DB) http.Handler {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(NewCollector(db), prometheus.NewGoCollector())
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{Timeout: 10 * time.Second})
+}
@@ -0,0 +1,76 @@
+package metrics
+
+import (
+	"io"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/ehebe/jungledb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMain(m *testing.M) {
+	os.RemoveAll("testdata")
+	os.MkdirAll("testdata", 0755)
+
+	code := m.Run()
+
+	os.RemoveAll("testdata")
+	os.Exit(code)
+}
+
+// TestCollector tests the exported metric families and the HTTP handler.
+func TestCollector(t *testing.T) {
+	db, err := jungledb.Open("testdata/collector.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("h", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if _, err := db.Hget("h", "f"); err != nil {
+		t.Fatalf("Hget failed: %v", err)
+	}
+
+	c := NewCollector(db)
+	if err := testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP jungledb_written_bytes_total Key, field and value bytes of committed mutations.
+# TYPE jungledb_written_bytes_total counter
+jungledb_written_bytes_total 3
+# HELP jungledb_read_bytes_total Value bytes returned by hash reads.
+# TYPE jungledb_read_bytes_total counter
+jungledb_read_bytes_total 1
+`), "jungledb_written_bytes_total", "jungledb_read_bytes_total"); err != nil {
+		t.Errorf("unexpected byte counters: %v", err)
+	}
+	if err := testutil.CollectAndCompare(c, strings.NewReader(`
+# HELP jungledb_operations_total Operations performed, by operation.
+# TYPE jungledb_operations_total counter
+jungledb_operations_total{op="Hget"} 1
+jungledb_operations_total{op="Hset"} 1
+`), "jungledb_operations_total"); err != nil {
+		t.Errorf("unexpected operation counters: %v", err)
+	}
+	if problems, err := testutil.CollectAndLint(c); err != nil || len(problems) > 0 {
+		t.Errorf("lint failed: %v %v", err, problems)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler(db).ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+	for _, want := range []string{
+		`jungledb_operation_duration_seconds_bucket{op="Hset",le="+Inf"} 1`,
+		`jungledb_transaction_duration_seconds_count{type="write"} 1`,
+		"jungledb_file_size_bytes ",
+		"jungledb_auth_failures_total 0",
+		"go_goroutines ",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected %q in handler output", want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package jungledb
+
+import (
+	"testing"
+)
+
+// TestMetrics tests that operations, errors, transactions and bytes are counted.
+func TestMetrics(t *testing.T) {
+	db, err := Open("testdata/metrics.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("h", "f", []byte("hello")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if _, err := db.Hget("h", "f"); err != nil {
+		t.Fatalf("Hget failed: %v", err)
+	}
+	if err := db.HdelBucket("missing"); err == nil {
+		t.Fatalf("expected HdelBucket on a missing key to fail")
+	}
+
+	m := db.Metrics()
+	if got := m.Ops["Hset"]; got.Latency.Count != 1 || got.Errors != 0 {
+		t.Errorf("unexpected Hset metrics: %+v", got)
+	}
+	if got := m.Ops["HdelBucket"]; got.Latency.Count != 1 || got.Errors != 1 {
+		t.Errorf("unexpected HdelBucket metrics: %+v", got)
+	}
+	hget := m.Ops["Hget"]
+	if len(hget.Latency.Buckets) != len(LatencyBuckets) || hget.Latency.Buckets[len(LatencyBuckets)-1] != 1 {
+		t.Errorf("unexpected Hget buckets: %v", hget.Latency.Buckets)
+	}
+	if m.ReadTx.Count != 1 || m.WriteTx.Count != 2 {
+		t.Errorf("expected 1 read and 2 write transactions, got %d and %d", m.ReadTx.Count, m.WriteTx.Count)
+	}
+	if m.BytesRead != 5 {
+		t.Errorf("expected 5 bytes read, got %d", m.BytesRead)
+	}
+	if m.BytesWritten != 7 {
+		t.Errorf("expected 7 bytes written, got %d", m.BytesWritten)
+	}
+	if m.FileSize == 0 {
+		t.Errorf("expected a file size")
+	}
+
+	// Snapshots do not alias the live counters
+	m.Ops["Hget"].Latency.Buckets[0] = 100
+	if db.Metrics().Ops["Hget"].Latency.Buckets[0] == 100 {
+		t.Errorf("snapshot shares buckets with the database")
+	}
+}
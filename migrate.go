@@ -0,0 +1,278 @@
+package jungledb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// migrationsBucket stores Redis migration checkpoints (SCAN cursors) so migrations can resume.
+const migrationsBucket = internalPrefix + "migrations"
+
+// MigrateOptions configures MigrateFromRedis.
+type MigrateOptions struct {
+	Addr     string // Redis address, host:port
+	Password string // Optional AUTH password
+	Database int    // Redis logical database (SELECT)
+	Pattern  string // SCAN MATCH pattern, defaults to "*"
+	Count    int    // SCAN/HSCAN/ZSCAN COUNT hint, defaults to 1000
+
+	// Resume continues from the checkpoint left by a previous, interrupted migration
+	// with the same address, database and pattern instead of starting over.
+	Resume bool
+
+	// Progress, if set, is called after every SCAN page has been written.
+	Progress func(MigrateProgress)
+}
+
+// MigrateProgress reports the state of a running or finished migration.
+type MigrateProgress struct {
+	Cursor   string // SCAN cursor to resume from, "0" once the keyspace has been covered
+	Scanned  int    // Keys returned by SCAN
+	Migrated int    // Hashes and sorted sets written to jungledb
+	Skipped  int    // Keys of unsupported types (strings, lists, ...)
+}
+
+// MigrateFromRedis copies hashes and sorted sets from a live Redis server into the database.
+// Keys are discovered with SCAN and copied page by page with HSCAN/ZSCAN, so arbitrarily
+// large datasets and keys are streamed rather than loaded at once. After every SCAN page the
+// cursor is checkpointed, letting a later call with Resume pick up where this one stopped.
+// Existing jungledb keys are merged into, not replaced.
+func (db *DB) MigrateFromRedis(ctx context.Context, opts MigrateOptions) (MigrateProgress, error) {
+	if opts.Pattern == "" {
+		opts.Pattern = "*"
+	}
+	if opts.Count <= 0 {
+		opts.Count = 1000
+	}
+	checkpoint := fmt.Sprintf("%s/%d/%s", opts.Addr, opts.Database, opts.Pattern)
+
+	progress := MigrateProgress{Cursor: "0"}
+	if opts.Resume {
+		err := db.view("MigrateFromRedis", "", func(tx *bbolt.Tx) error {
+			if b := tx.Bucket([]byte(migrationsBucket)); b != nil {
+				if v := b.Get([]byte(checkpoint)); v != nil {
+					progress.Cursor = string(v)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return progress, err
+		}
+	}
+
+	client, err := dialRedis(ctx, opts.Addr)
+	if err != nil {
+		return progress, err
+	}
+	defer client.close()
+
+	if opts.Password != "" {
+		if _, err := client.do("AUTH", opts.Password); err != nil {
+			return progress, fmt.Errorf("redis AUTH failed: %v", err)
+		}
+	}
+	if opts.Database != 0 {
+		if _, err := client.do("SELECT", strconv.Itoa(opts.Database)); err != nil {
+			return progress, fmt.Errorf("redis SELECT failed: %v", err)
+		}
+	}
+
+	count := strconv.Itoa(opts.Count)
+	for {
+		if err := ctx.Err(); err != nil {
+			return progress, err
+		}
+
+		next, keys, err := client.scan("SCAN", "", progress.Cursor, "MATCH", opts.Pattern, "COUNT", count)
+		if err != nil {
+			return progress, fmt.Errorf("redis SCAN failed: %v", err)
+		}
+
+		for _, key := range keys {
+			progress.Scanned++
+			migrated, err := db.migrateRedisKey(client, string(key), count)
+			if err != nil {
+				return progress, err
+			}
+			if migrated {
+				progress.Migrated++
+			} else {
+				progress.Skipped++
+			}
+		}
+
+		// Checkpoint the cursor now that the whole page has been written
+		progress.Cursor = next
+		err = db.update("MigrateFromRedis", "", func(tx *txn) error {
+			b, err := tx.CreateBucketIfNotExists([]byte(migrationsBucket))
+			if err != nil {
+				return fmt.Errorf("failed to create migrations bucket: %v", err)
+			}
+			if next == "0" {
+				return b.Delete([]byte(checkpoint))
+			}
+			return b.Put([]byte(checkpoint), []byte(next))
+		})
+		if err != nil {
+			return progress, err
+		}
+
+		if opts.Progress != nil {
+			opts.Progress(progress)
+		}
+		if next == "0" {
+			return progress, nil
+		}
+	}
+}
+
+// migrateRedisKey copies one Redis key. It reports false for keys of unsupported types.
+func (db *DB) migrateRedisKey(client *redisClient, key, count string) (bool, error) {
+	reply, err := client.do("TYPE", key)
+	if err != nil {
+		return false, fmt.Errorf("redis TYPE %s failed: %v", key, err)
+	}
+	keyType, _ := reply.(string)
+
+	var scanCmd string
+	switch keyType {
+	case "hash":
+		scanCmd = "HSCAN"
+	case "zset":
+		scanCmd = "ZSCAN"
+	default:
+		return false, nil // Unsupported or already deleted key
+	}
+
+	cursor := "0"
+	for {
+		next, items, err := client.scan(scanCmd, key, cursor, "COUNT", count)
+		if err != nil {
+			return false, fmt.Errorf("redis %s %s failed: %v", scanCmd, key, err)
+		}
+		if len(items)%2 != 0 {
+			return false, fmt.Errorf("redis %s %s returned an odd number of items", scanCmd, key)
+		}
+
+		err = db.update("MigrateFromRedis", key, func(tx *txn) error {
+			if scanCmd == "ZSCAN" {
+				for i := 0; i < len(items); i += 2 {
+					score, err := parseScore(string(items[i+1]))
+					if err != nil {
+						return err
+					}
+					if err := zadd(tx, key, score, string(items[i])); err != nil {
+						return err
+					}
+				}
+				return nil
+			}
+
+			bucket, err := tx.CreateBucketIfNotExists([]byte(key))
+			if err != nil {
+				return fmt.Errorf("failed to create bucket: %v", err)
+			}
+			for i := 0; i < len(items); i += 2 {
+				if err := bucket.Put(items[i], items[i+1]); err != nil {
+					return err
+				}
+				tx.record(Event{Type: EventHset, Key: key, Field: string(items[i]), Value: items[i+1]})
+			}
+			return nil
+		})
+		if err != nil {
+			return false, err
+		}
+
+		if next == "0" {
+			return true, nil
+		}
+		cursor = next
+	}
+}
+
+// redisClient is a minimal synchronous Redis protocol client.
+type redisClient struct {
+	conn net.Conn
+	r    *respReader
+	w    *respWriter
+}
+
+// dialRedis connects to a Redis-protocol server.
+func dialRedis(ctx context.Context, addr string) (*redisClient, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to redis: %v", err)
+	}
+	return &redisClient{conn: conn, r: newRESPReader(conn), w: newRESPWriter(conn)}, nil
+}
+
+// do sends a command and returns its reply. Error replies are returned as errors.
+func (c *redisClient) do(args ...string) (any, error) {
+	cmd := make([][]byte, len(args))
+	for i, arg := range args {
+		cmd[i] = []byte(arg)
+	}
+	return c.doBytes(cmd...)
+}
+
+// doBytes is do for binary arguments.
+func (c *redisClient) doBytes(args ...[]byte) (any, error) {
+	c.w.writeCommand(args...)
+	if err := c.w.flush(); err != nil {
+		return nil, err
+	}
+
+	reply, err := c.r.readValue()
+	if err != nil {
+		return nil, err
+	}
+	if e, ok := reply.(respError); ok {
+		return nil, e
+	}
+	return reply, nil
+}
+
+// scan runs a SCAN-family command and returns the next cursor and the page of items.
+// For SCAN, key must be empty; for HSCAN/ZSCAN it names the key being scanned.
+func (c *redisClient) scan(cmd, key, cursor string, args ...string) (string, [][]byte, error) {
+	full := []string{cmd}
+	if key != "" {
+		full = append(full, key)
+	}
+	full = append(full, cursor)
+	full = append(full, args...)
+
+	reply, err := c.do(full...)
+	if err != nil {
+		return "", nil, err
+	}
+	parts, ok := reply.([]any)
+	if !ok || len(parts) != 2 {
+		return "", nil, errors.New("malformed scan reply")
+	}
+	next, ok := parts[0].([]byte)
+	if !ok {
+		return "", nil, errors.New("malformed scan cursor")
+	}
+	raw, _ := parts[1].([]any)
+	items := make([][]byte, len(raw))
+	for i, item := range raw {
+		if items[i], ok = item.([]byte); !ok {
+			return "", nil, errors.New("malformed scan item")
+		}
+	}
+	return strings.TrimSpace(string(next)), items, nil
+}
+
+func (c *redisClient) close() error {
+	return c.conn.Close()
+}
@@ -0,0 +1,239 @@
+package jungledb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// fakeRedis is a tiny in-memory server speaking enough of the Redis protocol for migrations.
+type fakeRedis struct {
+	ln     net.Listener
+	hashes map[string]map[string]string
+	zsets  map[string]map[string]float64
+	other  map[string]bool
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	f := &fakeRedis{
+		ln:     ln,
+		hashes: make(map[string]map[string]string),
+		zsets:  make(map[string]map[string]float64),
+		other:  make(map[string]bool),
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go f.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { ln.Close() })
+	return f
+}
+
+func (f *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+	r, w := newRESPReader(conn), newRESPWriter(conn)
+	for {
+		args, err := r.readCommand()
+		if err != nil {
+			return
+		}
+		f.handle(w, args)
+		if err := w.flush(); err != nil {
+			return
+		}
+	}
+}
+
+// page returns items[cursor:cursor+count] and the next cursor ("0" when done).
+func page(items []string, cursor string, count int) ([]string, string) {
+	start, _ := strconv.Atoi(cursor)
+	end := start + count
+	if end >= len(items) {
+		return items[start:], "0"
+	}
+	return items[start:end], strconv.Itoa(end)
+}
+
+func (f *fakeRedis) handle(w *respWriter, args [][]byte) {
+	opt := func(name string, def string) string {
+		for i := 0; i+1 < len(args); i++ {
+			if strings.EqualFold(string(args[i]), name) {
+				return string(args[i+1])
+			}
+		}
+		return def
+	}
+	count, _ := strconv.Atoi(opt("COUNT", "10"))
+
+	writeScan := func(next string, items []string) {
+		w.writeArrayHeader(2)
+		w.writeBulk([]byte(next))
+		w.writeArrayHeader(len(items))
+		for _, item := range items {
+			w.writeBulk([]byte(item))
+		}
+	}
+
+	switch strings.ToUpper(string(args[0])) {
+	case "SCAN":
+		var keys []string
+		for k := range f.keySet() {
+			if matchPattern(opt("MATCH", "*"), k) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		items, next := page(keys, string(args[1]), count)
+		writeScan(next, items)
+	case "TYPE":
+		key := string(args[1])
+		switch {
+		case f.hashes[key] != nil:
+			w.writeSimple("hash")
+		case f.zsets[key] != nil:
+			w.writeSimple("zset")
+		case f.other[key]:
+			w.writeSimple("string")
+		default:
+			w.writeSimple("none")
+		}
+	case "HSCAN":
+		var flat []string
+		h := f.hashes[string(args[1])]
+		for _, field := range sortedKeys(h) {
+			flat = append(flat, field, h[field])
+		}
+		items, next := page(flat, string(args[2]), count*2)
+		writeScan(next, items)
+	case "ZSCAN":
+		var flat []string
+		z := f.zsets[string(args[1])]
+		for member, score := range z {
+			flat = append(flat, member, formatScore(score))
+		}
+		items, next := page(flat, string(args[2]), count*2)
+		writeScan(next, items)
+	default:
+		w.writeError("ERR unknown command")
+	}
+}
+
+func (f *fakeRedis) keySet() map[string]bool {
+	keys := make(map[string]bool)
+	for k := range f.hashes {
+		keys[k] = true
+	}
+	for k := range f.zsets {
+		keys[k] = true
+	}
+	for k := range f.other {
+		keys[k] = true
+	}
+	return keys
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TestMigrateFromRedis tests streaming migration from a Redis server, including resume.
+func TestMigrateFromRedis(t *testing.T) {
+	redis := newFakeRedis(t)
+	for i := 0; i < 10; i++ {
+		h := make(map[string]string)
+		for j := 0; j < 25; j++ {
+			h[fmt.Sprintf("f%d", j)] = fmt.Sprintf("v%d", j)
+		}
+		redis.hashes[fmt.Sprintf("user:%d", i)] = h
+	}
+	redis.zsets["user:ranking"] = map[string]float64{"a": 1, "b": 2.5}
+	redis.other["user:counter"] = true
+	redis.hashes["skip:me"] = map[string]string{"x": "y"}
+
+	db, err := Open("testdata/migrate.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Interrupt the migration after the first SCAN page
+	ctx, cancel := context.WithCancel(context.Background())
+	opts := MigrateOptions{
+		Addr:    redis.ln.Addr().String(),
+		Pattern: "user:*",
+		Count:   4,
+		Progress: func(p MigrateProgress) {
+			cancel()
+		},
+	}
+	first, err := db.MigrateFromRedis(ctx, opts)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected interrupted migration, got %v", err)
+	}
+	if first.Scanned != 4 {
+		t.Errorf("expected 4 keys scanned before interruption, got %d", first.Scanned)
+	}
+
+	// Resume from the checkpoint
+	opts.Resume = true
+	opts.Progress = nil
+	rest, err := db.MigrateFromRedis(context.Background(), opts)
+	if err != nil {
+		t.Fatalf("resumed migration failed: %v", err)
+	}
+	if first.Scanned+rest.Scanned != 12 {
+		t.Errorf("expected 12 keys scanned in total, got %d", first.Scanned+rest.Scanned)
+	}
+	if first.Skipped+rest.Skipped != 1 {
+		t.Errorf("expected 1 skipped key, got %d", first.Skipped+rest.Skipped)
+	}
+	if rest.Cursor != "0" {
+		t.Errorf("expected finished cursor, got %q", rest.Cursor)
+	}
+
+	fields, err := db.Hscan("user:7")
+	if err != nil {
+		t.Fatalf("Hscan failed: %v", err)
+	}
+	if len(fields) != 25 || string(fields["f3"]) != "v3" {
+		t.Errorf("migrated hash mismatch: got %d fields", len(fields))
+	}
+	score, err := db.Zscore("user:ranking", "b")
+	if err != nil {
+		t.Fatalf("Zscore failed: %v", err)
+	}
+	if score != 2.5 {
+		t.Errorf("migrated score mismatch: expected 2.5, got %f", score)
+	}
+	if exists, _ := db.HhasKey("skip:me", "x"); exists {
+		t.Error("key outside the pattern was migrated")
+	}
+
+	// Only user keys are visible, the checkpoint bucket is internal
+	keys, _, err := db.ListKeys("", "", 0)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if len(keys) != 11 {
+		t.Errorf("expected 11 migrated keys, got %d: %v", len(keys), keys)
+	}
+}
@@ -0,0 +1,110 @@
+package jungledb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// namespacePrefix starts the names of keys stored in a namespace: the prefix, the
+// namespace name, a NUL byte and the key.
+const namespacePrefix = "__jungledb.ns:"
+
+// Namespace returns a handle whose keys are isolated from those of db and of every other
+// namespace: hash, sorted set, key and TTL operations, ListKeys, DeleteByPattern,
+// FlushAll and Watch only see the keys of the namespace, and servers started from the
+// handle serve only those. Everything else, such as Stats, Export, the operation log,
+// replication, Close and Shutdown, concerns the whole database, where namespaced keys
+// appear under their stored names. Namespaces can be nested; name must not be empty or
+// contain a NUL byte. The handle shares the database with db and is cheap to create.
+// Names the database keeps for itself, such as the stored names of namespaced keys, are
+// rejected as keys with ErrReservedKey by every handle.
+func (db *DB) Namespace(name string) *DB {
+	if name == "" || strings.IndexByte(name, 0) >= 0 {
+		panic(fmt.Sprintf("jungledb: invalid namespace name %q", name))
+	}
+	return &DB{core: db.core, actor: db.actor, reason: db.reason, ns: db.nsKey(namespacePrefix + name + "\x00"), at: db.at}
+}
+
+// Namespaces lists, in order, the namespaces of db that hold at least one key.
+func (db *DB) Namespaces() ([]string, error) {
+	var names []string
+	prefix := []byte(db.nsKey(namespacePrefix))
+	err := db.view("Namespaces", "", func(tx *bbolt.Tx) error {
+		c := tx.Cursor()
+		for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); {
+			name, _, ok := bytes.Cut(k[len(prefix):], []byte{0})
+			if !ok {
+				k, _ = c.Next()
+				continue
+			}
+			names = append(names, string(name))
+			k, _ = c.Seek(append(append(prefix[:len(prefix):len(prefix)], name...), 1)) // Skip the rest of the namespace
+		}
+		return nil
+	})
+	return names, err
+}
+
+// Drop deletes every key of a namespace handle, including nested namespaces, in a
+// single transaction.
+func (db *DB) Drop() error {
+	if db.ns == "" {
+		return errors.New("Drop requires a namespace handle")
+	}
+	return db.update("Drop", "", func(tx *txn) error {
+		var names [][]byte
+		c := tx.Cursor()
+		for k, _ := c.Seek([]byte(db.ns)); k != nil && bytes.HasPrefix(k, []byte(db.ns)); k, _ = c.Next() {
+			names = append(names, append([]byte(nil), k...))
+		}
+		for _, name := range names {
+			if tx.Bucket(name) == nil || isInternalBucket(tx.Tx, name) {
+				continue // Sorted set indexes go with their sets
+			}
+			if err := deleteKey(tx, string(name)); err != nil {
+				return fmt.Errorf("failed to delete key %s: %v", name, err)
+			}
+			tx.record(Event{Type: EventDelete, Key: string(name)})
+		}
+		return nil
+	})
+}
+
+// nsKey returns the stored name of a key of db.
+func (db *DB) nsKey(key string) string {
+	return db.ns + key
+}
+
+// userKey returns the key of db stored under name, or false if name does not belong
+// to db but to another or a nested namespace, or holds a lock, a lease, links, a blob or
+// a soft deleted key (see TryLock, Register, Link, BlobPut and SoftDelete).
+func (db *DB) userKey(name string) (string, bool) {
+	key, ok := strings.CutPrefix(name, db.ns)
+	if !ok || strings.HasPrefix(key, namespacePrefix) || isReservedKey(key) {
+		return "", false
+	}
+	return key, true
+}
+
+// checkKey returns ErrReservedKey if one of keys, given by a user, names a namespace, an
+// internal bucket or a reserved key: reading or writing them would reach around the
+// namespace of db or corrupt the data kept by the database.
+func checkKey(keys ...string) error {
+	for _, key := range keys {
+		if strings.HasPrefix(key, namespacePrefix) || strings.HasPrefix(key, internalPrefix) || isReservedKey(key) {
+			return fmt.Errorf("%w: %q", ErrReservedKey, key)
+		}
+	}
+	return nil
+}
+
+// isReservedKey reports whether key, within its namespace, holds a lock, a lease, links, a
+// blob or a soft deleted key rather than user data.
+func isReservedKey(key string) bool {
+	return strings.HasPrefix(key, lockPrefix) || strings.HasPrefix(key, leasePrefix) || strings.HasPrefix(key, linkPrefix) ||
+		strings.HasPrefix(key, blobPrefix) || strings.HasPrefix(key, trashPrefix) || key == trashIndexKey
+}
@@ -0,0 +1,167 @@
+package jungledb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestNamespace tests that namespace handles isolate their keys from each other and from
+// the root handle.
+func TestNamespace(t *testing.T) {
+	db, err := Open("testdata/namespace.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	a, b := db.Namespace("a"), db.Namespace("b")
+	events, cancel := a.Watch("user:*")
+	defer cancel()
+	rootEvents, cancelRoot := db.Watch("")
+	defer cancelRoot()
+
+	if err := db.Hset("user:1", "name", []byte("root")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := a.Hset("user:1", "name", []byte("a")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := b.Zadd("scores", 1, "x"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := a.Namespace("nested").Hset("user:1", "name", []byte("nested")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	for _, tc := range []struct {
+		db   *DB
+		want string
+	}{{db, "root"}, {a, "a"}, {a.Namespace("nested"), "nested"}} {
+		if value, err := tc.db.Hget("user:1", "name"); err != nil || string(value) != tc.want {
+			t.Errorf("Hget: expected %q, got %q, %v", tc.want, value, err)
+		}
+	}
+	if value, _ := b.Hget("user:1", "name"); value != nil {
+		t.Errorf("namespace b sees user:1 of another namespace: %q", value)
+	}
+
+	for _, tc := range []struct {
+		db   *DB
+		want []string
+	}{{db, []string{"user:1"}}, {a, []string{"user:1"}}, {b, []string{"scores"}}} {
+		if keys, _, err := tc.db.ListKeys("", "", 0); err != nil || !reflect.DeepEqual(keys, tc.want) {
+			t.Errorf("ListKeys: expected %v, got %v, %v", tc.want, keys, err)
+		}
+	}
+
+	if err := a.Rename("user:1", "user:2"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if members, err := b.Zrange("scores", 0, -1); err != nil || !reflect.DeepEqual(members, []string{"x"}) {
+		t.Errorf("Zrange: expected [x], got %v, %v", members, err)
+	}
+
+	if ev := <-events; ev.Type != EventHset || ev.Key != "user:1" {
+		t.Errorf("namespace watcher: expected hset of user:1, got %+v", ev)
+	}
+	if ev := <-events; ev.Type != EventRename || ev.Key != "user:1" || ev.Target != "user:2" {
+		t.Errorf("namespace watcher: expected rename of user:1 to user:2, got %+v", ev)
+	}
+	if ev := <-rootEvents; ev.Key != "user:1" || string(ev.Value) != "root" {
+		t.Errorf("root watcher: expected the root hset, got %+v", ev)
+	}
+	if len(rootEvents) != 0 {
+		t.Errorf("root watcher received %d events of namespaces", len(rootEvents))
+	}
+
+	if names, err := db.Namespaces(); err != nil || !reflect.DeepEqual(names, []string{"a", "b"}) {
+		t.Errorf("Namespaces: expected [a b], got %v, %v", names, err)
+	}
+	if names, err := a.Namespaces(); err != nil || !reflect.DeepEqual(names, []string{"nested"}) {
+		t.Errorf("Namespaces of a: expected [nested], got %v, %v", names, err)
+	}
+
+	if err := a.Drop(); err != nil {
+		t.Fatalf("Drop failed: %v", err)
+	}
+	if names, _ := db.Namespaces(); !reflect.DeepEqual(names, []string{"b"}) {
+		t.Errorf("Namespaces after Drop: expected [b], got %v", names)
+	}
+	if value, _ := a.Namespace("nested").Hget("user:1", "name"); value != nil {
+		t.Errorf("nested namespace survived Drop of its parent")
+	}
+	if value, _ := db.Hget("user:1", "name"); string(value) != "root" {
+		t.Errorf("Drop removed a root key")
+	}
+	if err := db.Drop(); err == nil {
+		t.Errorf("Drop on the root handle: expected an error")
+	}
+	if err := b.FlushAll(); err != nil {
+		t.Errorf("FlushAll failed: %v", err)
+	}
+	if _, err := db.Usage("user:1"); err != nil {
+		t.Errorf("FlushAll of a namespace removed a root key: %v", err)
+	}
+	if _, err := b.Usage("scores"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("FlushAll of a namespace: expected scores removed, got %v", err)
+	}
+}
+
+// TestReservedKeys tests that keys naming another namespace, an internal bucket or a
+// reserved key are rejected, so that they cannot reach around a namespace.
+func TestReservedKeys(t *testing.T) {
+	db, err := Open("testdata/reserved.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Namespace("billing").Hset("secret", "card", []byte("4242")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if _, err := db.TryLock("job", "worker", time.Minute); err != nil {
+		t.Fatalf("TryLock failed: %v", err)
+	}
+
+	for _, key := range []string{
+		"__jungledb.ns:billing\x00secret",
+		"__jungledb:zsets",
+		"__jungledb.lock:job",
+		"__jungledb.blob:00",
+		"__jungledb.trash:secret",
+	} {
+		for name, call := range map[string]func() error{
+			"Hset":       func() error { return db.Hset(key, "card", []byte("0000")) },
+			"Hget":       func() error { _, err := db.Hget(key, "card"); return err },
+			"Hscan":      func() error { _, err := db.Hscan(key); return err },
+			"Zadd":       func() error { return db.Zadd(key, 1, "a") },
+			"HdelBucket": func() error { return db.HdelBucket(key) },
+			"Rename":     func() error { return db.Rename(key, "stolen") },
+			"Copy":       func() error { return db.Copy("stolen", key) },
+			"Expire":     func() error { return db.Expire(key, time.Second) },
+			"Update":     func() error { return db.Update(func(tx *Tx) error { return tx.Hset(key, "card", nil) }) },
+			"Pipeline": func() error {
+				p := db.Pipeline()
+				p.Hget(key, "card")
+				results, err := p.Exec()
+				if err != nil {
+					return err
+				}
+				return results[0].Err
+			},
+		} {
+			if err := call(); !errors.Is(err, ErrReservedKey) {
+				t.Errorf("%s of %q: got error %v, want ErrReservedKey", name, key, err)
+			}
+		}
+	}
+
+	if value, err := db.Namespace("billing").Hget("secret", "card"); err != nil || string(value) != "4242" {
+		t.Fatalf("Hget in the namespace: got %q, %v, want %q", value, err, "4242")
+	}
+	if err := db.Hset("__jungledbx", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset of a key that only looks reserved failed: %v", err)
+	}
+}
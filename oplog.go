@@ -0,0 +1,409 @@
+package jungledb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"go.etcd.io/bbolt"
+)
+
+// opLogBucket holds the operation log: 8-byte big-endian sequence -> JSON encoded Event.
+const opLogBucket = internalPrefix + "oplog"
+
+// replayBatchSize bounds how many events ReplayFrom reads per read transaction.
+const replayBatchSize = 256
+
+// ErrOpLogTruncated is returned when requested operation log entries have already been truncated.
+var ErrOpLogTruncated = errors.New("operation log truncated")
+
+// EventType identifies the kind of mutation an Event describes.
+type EventType string
+
+const (
+	EventHset    EventType = "hset"    // Hash field set; Field and Value are populated
+	EventHdel    EventType = "hdel"    // Hash field deleted; Field is populated
+	EventZadd    EventType = "zadd"    // Sorted set member added or updated; Field holds the member, Score the score
+	EventZrem    EventType = "zrem"    // Sorted set member removed; Field holds the member
+	EventDelete  EventType = "delete"  // Whole key (hash or sorted set) deleted
+	EventRename  EventType = "rename"  // Key renamed to Target
+	EventCopy    EventType = "copy"    // Key copied to Target
+	EventSwap    EventType = "swap"    // Contents of Key and Target exchanged
+	EventExpire  EventType = "expire"  // Key TTL set to ExpiresAt, or removed if ExpiresAt is zero
+	EventExpired EventType = "expired" // Key removed because its TTL elapsed
+	EventEvicted EventType = "evicted" // Key removed to keep a cache namespace within its caps
+
+	// EventOverflow is the last event of a Watch channel whose consumer fell behind by
+	// more than its buffer; it names no key and is never logged.
+	EventOverflow EventType = "overflow"
+)
+
+// Event describes a single committed mutation.
+type Event struct {
+	Seq    uint64    `json:"seq,omitempty"` // Operation log sequence, zero when the log is disabled
+	Type   EventType `json:"type"`
+	Key    string    `json:"key"`
+	Field  string    `json:"field,omitempty"`
+	Value  []byte    `json:"value,omitempty"`
+	Score  float64   `json:"score,omitempty"`
+	Target string    `json:"target,omitempty"`
+
+	ExpiresAt int64 `json:"expires_at,omitempty"` // Deadline in Unix nanoseconds, for EventExpire
+}
+
+// MarshalJSON encodes ev with its score as a string, see formatScore, so that infinite
+// scores, which JSON numbers cannot hold, survive the operation log and replication.
+func (ev Event) MarshalJSON() ([]byte, error) {
+	type event Event // Without the methods, so as not to recurse
+	return json.Marshal(struct {
+		event
+		Score jsonScore `json:"score,omitempty"`
+	}{event(ev), jsonScore(ev.Score)})
+}
+
+// UnmarshalJSON decodes an event encoded by MarshalJSON, or with its score as a number.
+func (ev *Event) UnmarshalJSON(data []byte) error {
+	type event Event
+	v := struct {
+		*event
+		Score jsonScore `json:"score"`
+	}{event: (*event)(ev)}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	ev.Score = float64(v.Score)
+	return nil
+}
+
+// jsonScore is a sorted set score encoded in JSON as a string, which unlike a JSON
+// number can hold infinities. Numbers are accepted too, as written by earlier versions.
+type jsonScore float64
+
+func (s jsonScore) MarshalJSON() ([]byte, error) {
+	return strconv.AppendQuote(nil, formatScore(float64(s))), nil
+}
+
+func (s *jsonScore) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		var f float64
+		if err := json.Unmarshal(data, &f); err != nil {
+			return fmt.Errorf("invalid score %s", data)
+		}
+		*s = jsonScore(f)
+		return nil
+	}
+	f, err := parseScore(str)
+	*s = jsonScore(f)
+	return err
+}
+
+// txn is a read-write transaction that collects the events produced by its mutations.
+type txn struct {
+	*bbolt.Tx
+	events []Event
+
+	countFreed bool // Whether to count the bytes of the keys deleted, see BackgroundLimits
+	freed      int
+	replace    bool // Replacing the contents of the database, see Load
+}
+
+// record adds an event describing a mutation made in this transaction.
+func (tx *txn) record(ev Event) {
+	if ev.Value != nil {
+		ev.Value = append([]byte{}, ev.Value...) // Callers may reuse their buffers
+	}
+	tx.events = append(tx.events, ev)
+}
+
+// appendOpLog persists the transaction's events to the operation log, assigning sequences.
+func (db *DB) appendOpLog(tx *txn) error {
+	if !db.opts.opLog || len(tx.events) == 0 {
+		return nil
+	}
+
+	bucket, err := tx.CreateBucketIfNotExists([]byte(opLogBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create operation log bucket: %v", err)
+	}
+
+	for i := range tx.events {
+		seq, err := bucket.NextSequence()
+		if err != nil {
+			return err
+		}
+		tx.events[i].Seq = seq
+
+		data, err := json.Marshal(tx.events[i])
+		if err != nil {
+			return fmt.Errorf("failed to encode operation log entry: %v", err)
+		}
+		if err := bucket.Put(encodeSeq(seq), data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LastSeq returns the sequence of the most recent operation log entry, or 0 if there is none.
+func (db *DB) LastSeq() (uint64, error) {
+	var seq uint64
+	err := db.view("LastSeq", "", func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(opLogBucket))
+		if bucket == nil {
+			return nil
+		}
+		seq = bucket.Sequence()
+		return nil
+	})
+	return seq, err
+}
+
+// ExportSince writes every logged mutation with a sequence greater than seq as
+// line-delimited JSON events and returns the last sequence written (seq itself if
+// nothing newer exists). Feed the result into the next call for incremental exports.
+// If entries after seq have already been truncated, ErrOpLogTruncated is returned and
+// nothing is written, so a consumer never applies a partial stream; it has to start
+// over from a full export. The operation log must be enabled with WithOpLog.
+func (db *DB) ExportSince(w io.Writer, seq uint64) (uint64, error) {
+	if !db.opts.opLog {
+		return seq, errors.New("operation log is not enabled")
+	}
+
+	bw := bufio.NewWriter(w)
+	last := seq
+	err := db.view("ExportSince", "", func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(opLogBucket))
+		if bucket == nil {
+			return nil
+		}
+
+		if err := checkTruncated(bucket, seq+1); err != nil {
+			return err
+		}
+		c := bucket.Cursor()
+		for k, v := c.Seek(encodeSeq(seq + 1)); k != nil; k, v = c.Next() {
+			if _, err := bw.Write(v); err != nil {
+				return err
+			}
+			if err := bw.WriteByte('\n'); err != nil {
+				return err
+			}
+			last = binary.BigEndian.Uint64(k)
+		}
+		return nil
+	})
+	if err != nil {
+		return seq, err
+	}
+
+	if err := bw.Flush(); err != nil {
+		return seq, err
+	}
+	return last, nil
+}
+
+// ApplyChanges applies a stream of events produced by ExportSince, bringing this database
+// up to date with the exporting one. It returns the sequence of the last event applied.
+// All events are applied in a single transaction.
+func (db *DB) ApplyChanges(r io.Reader) (uint64, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	var events []Event
+	for {
+		var ev Event
+		if err := dec.Decode(&ev); err == io.EOF {
+			break
+		} else if err != nil {
+			return 0, fmt.Errorf("failed to decode event: %v", err)
+		}
+		events = append(events, ev)
+	}
+
+	var last uint64
+	err := db.update("ApplyChanges", "", func(tx *txn) error {
+		for _, ev := range events {
+			if err := applyEvent(tx, ev); err != nil {
+				return err
+			}
+			last = ev.Seq
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return last, nil
+}
+
+// checkTruncated returns ErrOpLogTruncated if the log held in bucket no longer has the
+// entry of sequence next, although it was written.
+func checkTruncated(bucket *bbolt.Bucket, next uint64) error {
+	oldest := bucket.Sequence() + 1
+	if k, _ := bucket.Cursor().First(); k != nil {
+		oldest = binary.BigEndian.Uint64(k)
+	}
+	if next < oldest && next <= bucket.Sequence() {
+		return fmt.Errorf("%w: requested sequence %d, oldest available %d", ErrOpLogTruncated, next, oldest)
+	}
+	return nil
+}
+
+// ReplayFrom calls fn for every logged mutation with a sequence greater than or equal to seq,
+// in sequence order, stopping at the first error fn returns. Consumers can persist the
+// sequence of the last event they processed and resume with ReplayFrom(last+1, fn) after
+// a restart. If entries at or after seq have already been truncated, ErrOpLogTruncated is
+// returned before fn is called, so a consumer never silently misses writes.
+// Events are read in batches, so fn runs outside of any transaction and may use the database.
+func (db *DB) ReplayFrom(seq uint64, fn func(Event) error) error {
+	if !db.opts.opLog {
+		return errors.New("operation log is not enabled")
+	}
+	if seq == 0 {
+		seq = 1
+	}
+
+	first := true
+	for {
+		var batch []Event
+		err := db.view("ReplayFrom", "", func(tx *bbolt.Tx) error {
+			bucket := tx.Bucket([]byte(opLogBucket))
+			if bucket == nil {
+				return nil
+			}
+
+			if first {
+				if err := checkTruncated(bucket, seq); err != nil {
+					return err
+				}
+			}
+			c := bucket.Cursor()
+
+			for k, v := c.Seek(encodeSeq(seq)); k != nil && len(batch) < replayBatchSize; k, v = c.Next() {
+				var ev Event
+				if err := json.Unmarshal(v, &ev); err != nil {
+					return fmt.Errorf("failed to decode operation log entry %d: %v", binary.BigEndian.Uint64(k), err)
+				}
+				batch = append(batch, ev)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		first = false
+
+		for _, ev := range batch {
+			if err := fn(ev); err != nil {
+				return err
+			}
+			seq = ev.Seq + 1
+		}
+		if len(batch) < replayBatchSize {
+			return nil
+		}
+	}
+}
+
+// TruncateOpLog removes operation log entries with a sequence less than or equal to seq,
+// for example once every consumer has exported past it. Sequences are never reused.
+func (db *DB) TruncateOpLog(seq uint64) error {
+	return db.update("TruncateOpLog", "", func(tx *txn) error {
+		bucket := tx.Bucket([]byte(opLogBucket))
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		for k, _ := c.First(); k != nil && binary.BigEndian.Uint64(k) <= seq; k, _ = c.First() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// applyEvent replays a single event inside a read-write transaction.
+func applyEvent(tx *txn, ev Event) error {
+	switch ev.Type {
+	case EventHset:
+		if err := checkType(tx.Tx, ev.Key, typeHash); err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucketIfNotExists([]byte(ev.Key))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		value := ev.Value
+		if value == nil {
+			value = []byte{}
+		}
+		tx.record(Event{Type: EventHset, Key: ev.Key, Field: ev.Field, Value: value})
+		return bucket.Put([]byte(ev.Field), value)
+	case EventHdel:
+		if err := checkType(tx.Tx, ev.Key, typeHash); err != nil {
+			return err
+		}
+		bucket := tx.Bucket([]byte(ev.Key))
+		if bucket == nil {
+			return nil
+		}
+		tx.record(Event{Type: EventHdel, Key: ev.Key, Field: ev.Field})
+		return bucket.Delete([]byte(ev.Field))
+	case EventZadd:
+		return zadd(tx, ev.Key, ev.Score, ev.Field)
+	case EventZrem:
+		return zrem(tx, ev.Key, ev.Field)
+	case EventDelete:
+		if err := deleteKey(tx, ev.Key); errors.Is(err, ErrKeyNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		tx.record(ev)
+		return nil
+	case EventRename:
+		if err := copyKey(tx, ev.Key, ev.Target); err != nil {
+			return err
+		}
+		if err := deleteKey(tx, ev.Key); err != nil {
+			return err
+		}
+		tx.record(ev)
+		return nil
+	case EventCopy:
+		if err := copyKey(tx, ev.Key, ev.Target); err != nil {
+			return err
+		}
+		tx.record(ev)
+		return nil
+	case EventSwap:
+		return swapKeys(tx, ev.Key, ev.Target)
+	case EventExpire:
+		if tx.Bucket([]byte(ev.Key)) == nil {
+			return nil
+		}
+		return setExpiry(tx, ev.Key, ev.ExpiresAt)
+	case EventExpired:
+		return expireKey(tx, ev.Key)
+	case EventEvicted:
+		if err := deleteKey(tx, ev.Key); err != nil && !errors.Is(err, ErrKeyNotFound) {
+			return err
+		}
+		tx.record(ev)
+		return nil
+	default:
+		return fmt.Errorf("unknown event type %q", ev.Type)
+	}
+}
+
+// encodeSeq encodes a sequence number as an 8-byte big-endian key.
+func encodeSeq(seq uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, seq)
+	return b
+}
@@ -0,0 +1,286 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestExportSince tests incremental exports from the operation log and applying them elsewhere.
+func TestExportSince(t *testing.T) {
+	src, err := Open("testdata/oplog_src.db", WithOpLog())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer src.Close()
+
+	dst, err := Open("testdata/oplog_dst.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer dst.Close()
+
+	if err := src.Hmset("user:1", map[string][]byte{"name": []byte("Alice"), "tmp": []byte("x")}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	if err := src.Zadd("ranking", 10, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+
+	// First (full) incremental export
+	var buf bytes.Buffer
+	seq, err := src.ExportSince(&buf, 0)
+	if err != nil {
+		t.Fatalf("ExportSince failed: %v", err)
+	}
+	if seq != 3 {
+		t.Errorf("expected last sequence 3, got %d", seq)
+	}
+	if _, err := dst.ApplyChanges(&buf); err != nil {
+		t.Fatalf("ApplyChanges failed: %v", err)
+	}
+
+	// Second export only carries the new mutations
+	if err := src.Hdel("user:1", "tmp"); err != nil {
+		t.Fatalf("Hdel failed: %v", err)
+	}
+	if err := src.Rename("ranking", "ranking:old"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if _, err := src.Hincr("user:1", "visits", 2); err != nil {
+		t.Fatalf("Hincr failed: %v", err)
+	}
+
+	buf.Reset()
+	next, err := src.ExportSince(&buf, seq)
+	if err != nil {
+		t.Fatalf("ExportSince failed: %v", err)
+	}
+	if next != seq+3 {
+		t.Errorf("expected last sequence %d, got %d", seq+3, next)
+	}
+	if lines := strings.Count(buf.String(), "\n"); lines != 3 {
+		t.Errorf("expected 3 incremental events, got %d:\n%s", lines, buf.String())
+	}
+	applied, err := dst.ApplyChanges(&buf)
+	if err != nil {
+		t.Fatalf("ApplyChanges failed: %v", err)
+	}
+	if applied != next {
+		t.Errorf("ApplyChanges returned sequence %d, expected %d", applied, next)
+	}
+
+	fields, err := dst.Hscan("user:1")
+	if err != nil {
+		t.Fatalf("Hscan failed: %v", err)
+	}
+	if len(fields) != 2 || string(fields["name"]) != "Alice" {
+		t.Errorf("replicated hash mismatch: got %v", fields)
+	}
+	visits, err := dst.HgetInt("user:1", "visits")
+	if err != nil {
+		t.Fatalf("HgetInt failed: %v", err)
+	}
+	if visits != 2 {
+		t.Errorf("replicated counter mismatch: expected 2, got %d", visits)
+	}
+	score, err := dst.Zscore("ranking:old", "alice")
+	if err != nil {
+		t.Fatalf("Zscore failed: %v", err)
+	}
+	if score != 10 {
+		t.Errorf("replicated rename mismatch: expected score 10, got %f", score)
+	}
+
+	// Nothing new to export
+	buf.Reset()
+	same, err := src.ExportSince(&buf, next)
+	if err != nil {
+		t.Fatalf("ExportSince failed: %v", err)
+	}
+	if same != next || buf.Len() != 0 {
+		t.Errorf("expected empty export at sequence %d, got %d and %q", next, same, buf.String())
+	}
+
+	// Truncation drops old entries but keeps the sequence moving forward
+	if err := src.TruncateOpLog(next); err != nil {
+		t.Fatalf("TruncateOpLog failed: %v", err)
+	}
+	buf.Reset()
+	if _, err := src.ExportSince(&buf, 0); !errors.Is(err, ErrOpLogTruncated) {
+		t.Errorf("ExportSince of truncated entries: expected ErrOpLogTruncated, got %v", err)
+	}
+	if _, err := src.ExportSince(&buf, next); err != nil || buf.Len() != 0 {
+		t.Errorf("expected nothing after the truncated entries, got %q, %v", buf.String(), err)
+	}
+	last, err := src.LastSeq()
+	if err != nil {
+		t.Fatalf("LastSeq failed: %v", err)
+	}
+	if last != next {
+		t.Errorf("LastSeq mismatch after truncation: expected %d, got %d", next, last)
+	}
+
+	// The log is opt-in
+	if _, err := dst.ExportSince(&buf, 0); err == nil {
+		t.Error("ExportSince should fail when the operation log is disabled")
+	}
+}
+
+// TestReplayFrom tests resumable consumption of the operation log.
+func TestReplayFrom(t *testing.T) {
+	db, err := Open("testdata/replay.db", WithOpLog())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	total := replayBatchSize + 20 // Spans more than one read batch
+	for i := 0; i < total; i++ {
+		if _, err := db.Hincr("counters", "hits", 1); err != nil {
+			t.Fatalf("Hincr failed: %v", err)
+		}
+	}
+
+	// Consume part of the log, then "restart" and resume after the last processed event
+	var processed uint64
+	stop := errors.New("stop")
+	err = db.ReplayFrom(0, func(ev Event) error {
+		if ev.Seq == 100 {
+			return stop
+		}
+		processed = ev.Seq
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("expected consumer error to be returned, got %v", err)
+	}
+	if processed != 99 {
+		t.Fatalf("expected 99 events processed, got %d", processed)
+	}
+
+	count := 0
+	err = db.ReplayFrom(processed+1, func(ev Event) error {
+		if ev.Seq != processed+1 {
+			t.Fatalf("sequence gap: expected %d, got %d", processed+1, ev.Seq)
+		}
+		processed = ev.Seq
+		count++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ReplayFrom failed: %v", err)
+	}
+	if processed != uint64(total) || count != total-99 {
+		t.Errorf("resume mismatch: processed up to %d with %d events", processed, count)
+	}
+
+	// Truncated entries are reported instead of being skipped silently
+	if err := db.TruncateOpLog(50); err != nil {
+		t.Fatalf("TruncateOpLog failed: %v", err)
+	}
+	err = db.ReplayFrom(10, func(Event) error { return nil })
+	if !errors.Is(err, ErrOpLogTruncated) {
+		t.Errorf("expected ErrOpLogTruncated, got %v", err)
+	}
+	if err := db.ReplayFrom(51, func(Event) error { return nil }); err != nil {
+		t.Errorf("ReplayFrom at the oldest entry failed: %v", err)
+	}
+}
+
+// TestOpLogInfiniteScores tests that infinite scores are logged, exported and applied
+// elsewhere, and that events with scores as JSON numbers still decode.
+func TestOpLogInfiniteScores(t *testing.T) {
+	src, err := Open("testdata/oplog_inf_src.db", WithOpLog())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer src.Close()
+	dst, err := Open("testdata/oplog_inf_dst.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer dst.Close()
+
+	scores := map[string]float64{"top": math.Inf(1), "bottom": math.Inf(-1), "middle": 2.5}
+	for member, score := range scores {
+		if err := src.Zadd("ranking", score, member); err != nil {
+			t.Fatalf("Zadd of %v failed: %v", score, err)
+		}
+	}
+	var buf bytes.Buffer
+	if _, err := src.ExportSince(&buf, 0); err != nil {
+		t.Fatalf("ExportSince failed: %v", err)
+	}
+	if _, err := dst.ApplyChanges(&buf); err != nil {
+		t.Fatalf("ApplyChanges failed: %v", err)
+	}
+	for member, want := range scores {
+		if score, err := dst.Zscore("ranking", member); err != nil || score != want {
+			t.Errorf("Zscore of %s after ApplyChanges: got %v, %v, want %v", member, score, err, want)
+		}
+	}
+
+	var ev Event
+	if err := json.Unmarshal([]byte(`{"type":"zadd","key":"k","field":"m","score":1.5}`), &ev); err != nil || ev.Score != 1.5 {
+		t.Fatalf("decoding a numeric score: got %v, %v, want 1.5", ev.Score, err)
+	}
+}
+
+// TestApplyChangesWrongType tests that ApplyChanges refuses hash events for a key holding
+// a sorted set rather than corrupting it.
+func TestApplyChangesWrongType(t *testing.T) {
+	db, err := Open("testdata/oplog_wrongtype.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Zadd("z", 1, "m"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+
+	for _, line := range []string{
+		`{"seq":1,"type":"hset","key":"z","field":"f","value":"dg=="}`,
+		`{"seq":1,"type":"hdel","key":"z","field":"m"}`,
+	} {
+		if _, err := db.ApplyChanges(strings.NewReader(line)); !errors.Is(err, ErrWrongType) {
+			t.Errorf("%s: expected ErrWrongType, got %v", line, err)
+		}
+	}
+	if members, err := db.Zrange("z", 0, -1); err != nil || len(members) != 1 || members[0] != "m" {
+		t.Errorf("Zrange after the rejected changes: got %v, %v, want [m]", members, err)
+	}
+}
+
+// TestExportSinceTruncated tests that exporting from a sequence whose successors were
+// truncated fails with ErrOpLogTruncated instead of skipping them.
+func TestExportSinceTruncated(t *testing.T) {
+	db, err := Open("testdata/oplog_truncated.db", WithOpLog())
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for i := 0; i < 4; i++ {
+		if err := db.Hset("h", "f", []byte{byte('0' + i)}); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	if err := db.TruncateOpLog(2); err != nil {
+		t.Fatalf("TruncateOpLog failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if seq, err := db.ExportSince(&buf, 1); !errors.Is(err, ErrOpLogTruncated) || seq != 1 || buf.Len() != 0 {
+		t.Errorf("ExportSince(1): expected ErrOpLogTruncated and nothing written, got %d, %v, %q", seq, err, buf.String())
+	}
+	if seq, err := db.ExportSince(&buf, 2); err != nil || seq != 4 {
+		t.Errorf("ExportSince(2): expected to reach sequence 4, got %d, %v", seq, err)
+	}
+	if seq, err := db.ExportSince(&buf, 4); err != nil || seq != 4 {
+		t.Errorf("ExportSince(4): expected nothing newer, got %d, %v", seq, err)
+	}
+}
@@ -0,0 +1,174 @@
+package jungledb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"reflect"
+	"sync/atomic"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// ErrConflict is returned by RunTransaction when the data its function read kept changing
+// before its writes could commit, on every attempt.
+var ErrConflict = errors.New("transaction conflict")
+
+// errConflict rolls back an attempt of RunTransaction whose reads are stale.
+var errConflict = fmt.Errorf("%w: data read changed", errRollback)
+
+// TransactionOptions configures RunTransaction. Zero values select the defaults.
+type TransactionOptions struct {
+	MaxRetries int           // Attempts after the first one, 10 by default
+	Backoff    time.Duration // Delay before the first retry, doubled for each next one, 1ms by default
+}
+
+// TransactionStats are cumulative statistics of RunTransaction.
+type TransactionStats struct {
+	Commits   uint64 // Transactions committed
+	Conflicts uint64 // Attempts rolled back because data they read changed
+	Failures  uint64 // Transactions that failed, with ErrConflict or the error of their function
+}
+
+// transactionStats counts the outcomes of RunTransaction.
+type transactionStats struct {
+	commits, conflicts, failures atomic.Uint64
+}
+
+// OptimisticTx is the transaction passed to the functions run by RunTransaction. Reads see
+// a snapshot of the database taken when the attempt started; writes are buffered and only
+// applied, in order, at commit, so reads do not see the writes of the same attempt.
+type OptimisticTx struct {
+	snap   *Tx
+	reads  []func(tx *Tx) bool // Report whether a read returns the same as in the snapshot
+	writes []func(tx *Tx) error
+}
+
+// RunTransaction runs fn, which reads and then writes data, without holding the write lock
+// while it runs: fn reads a snapshot and its writes are applied when it returns nil, in a
+// transaction that first checks that everything fn read is unchanged. If it changed, the
+// writes are discarded and fn runs again on a fresh snapshot, after a backoff delay, up
+// to opts.MaxRetries times before RunTransaction gives up with ErrConflict. An error
+// from fn is returned as is, without retrying. fn must not have side effects other than
+// through tx, since it can run several times.
+func (db *DB) RunTransaction(fn func(tx *OptimisticTx) error, opts TransactionOptions) error {
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = 10
+	}
+	if opts.Backoff <= 0 {
+		opts.Backoff = time.Millisecond
+	}
+
+	backoff := opts.Backoff
+	for attempt := 0; ; attempt++ {
+		t := &OptimisticTx{}
+		err := db.view("RunTransaction", "", func(snap *bbolt.Tx) error {
+			t.snap = &Tx{db: db, tx: &txn{Tx: snap}}
+			return fn(t)
+		})
+		if err == nil {
+			err = db.update("RunTransaction", "", func(tx *txn) error {
+				current := &Tx{db: db, tx: tx}
+				for _, unchanged := range t.reads {
+					if !unchanged(current) {
+						return errConflict
+					}
+				}
+				for _, write := range t.writes {
+					if err := write(current); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		}
+		switch {
+		case err == nil:
+			db.txnStats.commits.Add(1)
+			return nil
+		case !errors.Is(err, errConflict):
+			db.txnStats.failures.Add(1)
+			return err
+		}
+
+		db.txnStats.conflicts.Add(1)
+		if attempt == opts.MaxRetries {
+			db.txnStats.failures.Add(1)
+			return fmt.Errorf("%w: gave up after %d attempts", ErrConflict, attempt+1)
+		}
+		time.Sleep(backoff/2 + rand.N(backoff/2+1))
+		backoff *= 2
+	}
+}
+
+// TransactionStats returns the cumulative statistics of RunTransaction.
+func (db *DB) TransactionStats() TransactionStats {
+	return TransactionStats{
+		Commits:   db.txnStats.commits.Load(),
+		Conflicts: db.txnStats.conflicts.Load(),
+		Failures:  db.txnStats.failures.Load(),
+	}
+}
+
+// optimisticRead runs read on the snapshot of t and records it to be checked at commit.
+func optimisticRead[T any](t *OptimisticTx, read func(tx *Tx) (T, error)) (T, error) {
+	v, err := read(t.snap)
+	if err != nil {
+		return v, err
+	}
+	t.reads = append(t.reads, func(tx *Tx) bool {
+		current, err := read(tx)
+		return err == nil && reflect.DeepEqual(current, v)
+	})
+	return v, nil
+}
+
+// Hget returns the value of a field in a hash, nil if it does not exist.
+func (t *OptimisticTx) Hget(key, field string) ([]byte, error) {
+	return optimisticRead(t, func(tx *Tx) ([]byte, error) { return tx.Hget(key, field) })
+}
+
+// Hscan returns all fields and values of a hash.
+func (t *OptimisticTx) Hscan(key string) (map[string][]byte, error) {
+	return optimisticRead(t, func(tx *Tx) (map[string][]byte, error) { return tx.Hscan(key) })
+}
+
+// Zscore returns the score of a sorted set member, 0 if it does not exist.
+func (t *OptimisticTx) Zscore(key, member string) (float64, error) {
+	return optimisticRead(t, func(tx *Tx) (float64, error) { return tx.Zscore(key, member) })
+}
+
+// Keys returns the keys matching the glob pattern (see ListKeys), in order. Creating or
+// deleting a matching key before the commit is a conflict.
+func (t *OptimisticTx) Keys(pattern string) ([]string, error) {
+	return optimisticRead(t, func(tx *Tx) ([]string, error) { return tx.Keys(pattern) })
+}
+
+// Hset sets the field value in a hash at commit.
+func (t *OptimisticTx) Hset(key, field string, value []byte) {
+	value = bytes.Clone(value)
+	t.writes = append(t.writes, func(tx *Tx) error { return tx.Hset(key, field, value) })
+}
+
+// Hdel deletes a field from a hash at commit.
+func (t *OptimisticTx) Hdel(key, field string) {
+	t.writes = append(t.writes, func(tx *Tx) error { return tx.Hdel(key, field) })
+}
+
+// Delete deletes a hash or sorted set at commit, which fails with ErrKeyNotFound if it
+// does not exist then.
+func (t *OptimisticTx) Delete(key string) {
+	t.writes = append(t.writes, func(tx *Tx) error { return tx.Delete(key) })
+}
+
+// Zadd adds a member with a score to a sorted set, or updates its score, at commit.
+func (t *OptimisticTx) Zadd(key string, score float64, member string) {
+	t.writes = append(t.writes, func(tx *Tx) error { return tx.Zadd(key, score, member) })
+}
+
+// Zrem removes a member from a sorted set at commit.
+func (t *OptimisticTx) Zrem(key, member string) {
+	t.writes = append(t.writes, func(tx *Tx) error { return tx.Zrem(key, member) })
+}
@@ -0,0 +1,69 @@
+package jungledb
+
+import (
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// TestRunTransaction tests that concurrent read-then-write transactions retry on conflicts
+// so that no update is lost.
+func TestRunTransaction(t *testing.T) {
+	db, err := Open("testdata/run_transaction.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	increment := func(tx *OptimisticTx) error {
+		v, err := tx.Hget("counter", "n")
+		if err != nil {
+			return err
+		}
+		n, _ := strconv.Atoi(string(v))
+		tx.Hset("counter", "n", []byte(strconv.Itoa(n+1)))
+		return nil
+	}
+	const workers, increments = 8, 20
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < increments; j++ {
+				if err := db.RunTransaction(increment, TransactionOptions{MaxRetries: 1000}); err != nil {
+					t.Errorf("RunTransaction failed: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v, err := db.Hget("counter", "n"); err != nil || string(v) != strconv.Itoa(workers*increments) {
+		t.Errorf("expected %d, got %q %v", workers*increments, v, err)
+	}
+	stats := db.TransactionStats()
+	if stats.Commits != workers*increments || stats.Failures != 0 {
+		t.Errorf("expected %d commits and no failures, got %+v", workers*increments, stats)
+	}
+
+	// Errors of the function are returned without retrying
+	errAbort := errors.New("abort")
+	attempts := 0
+	err = db.RunTransaction(func(tx *OptimisticTx) error {
+		attempts++
+		tx.Hset("counter", "n", []byte("0"))
+		return errAbort
+	}, TransactionOptions{})
+	if !errors.Is(err, errAbort) || attempts != 1 {
+		t.Errorf("expected the function error after 1 attempt, got %v after %d", err, attempts)
+	}
+	if v, _ := db.Hget("counter", "n"); string(v) != strconv.Itoa(workers*increments) {
+		t.Errorf("expected the writes of a failed transaction to be discarded, got %q", v)
+	}
+	if stats := db.TransactionStats(); stats.Failures != 1 {
+		t.Errorf("expected 1 failure, got %+v", stats)
+	}
+}
@@ -0,0 +1,90 @@
+package jungledb
+
+import (
+	"crypto/tls"
+	"log/slog"
+	"time"
+)
+
+// Option configures optional database behavior at Open.
+type Option func(*options)
+
+// options holds the settings applied by Option values.
+type options struct {
+	opLog            bool
+	audit            bool
+	logger           *slog.Logger
+	slowLogThreshold time.Duration
+	slowLogCapacity  int
+	sweepInterval    time.Duration
+	onExpire         func(key string)
+	beforeHooks      []BeforeHook
+	afterHooks       []AfterHook
+	limits           Limits
+	maxValueSize     int
+	caches           []CacheNamespace
+	indexes          []Index
+	views            []MaterializedView
+	historyDepth     int
+	historyPrefixes  []string
+	trashRetention   time.Duration
+	undoWindow       time.Duration
+	tiering          TieringOptions
+	fillRules        []fillRule
+	preallocate      int64
+	growthIncrement  int
+	maintenance      *MaintenanceWindow
+	backgroundLimits BackgroundLimits
+	clock            Clock
+	expvarPrefix     string
+	pprofLabels      bool
+	creator          string
+	coalesceReads    bool
+	hotKeys          []string
+	heatmapWindow    time.Duration
+	retention        []RetentionRule
+	immutable        []string
+	text             TextOptions
+	vectors          VectorOptions
+	migrations       []Migration
+	noFormatUpgrade  bool
+	acl              *ACL
+	tls              *tls.Config
+	replicaToken     string
+	replicaTLS       *tls.Config
+}
+
+// WithOpLog enables the persisted operation log. Every committed mutation is appended
+// to an internal bucket with a monotonically increasing sequence number, which makes
+// incremental exports (ExportSince) and resumable consumers (ReplayFrom) possible.
+func WithOpLog() Option {
+	return func(o *options) {
+		o.opLog = true
+	}
+}
+
+// WithLogger sets the logger the database reports to: slow transactions and failed writes
+// at Warn level, background activity such as replication sessions at Info, and compaction
+// progress. Without it the database logs nothing.
+func WithLogger(logger *slog.Logger) Option {
+	return func(o *options) {
+		o.logger = logger
+	}
+}
+
+// Logger returns the logger set with WithLogger, which discards everything without it,
+// for packages built on the database, such as cluster, to report to.
+func (db *DB) Logger() *slog.Logger {
+	return db.log
+}
+
+// WithSlowLog configures the slow log (see SlowLog): operations taking longer than threshold
+// are recorded, keeping the newest capacity entries. The defaults are 10ms and 128 entries;
+// a zero argument keeps its default and a negative threshold disables the slow log. The
+// threshold also applies to the slow transactions reported through WithLogger.
+func WithSlowLog(threshold time.Duration, capacity int) Option {
+	return func(o *options) {
+		o.slowLogThreshold = threshold
+		o.slowLogCapacity = capacity
+	}
+}
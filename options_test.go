@@ -0,0 +1,43 @@
+package jungledb
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// TestWithLogger tests that failed writes and compaction are logged.
+func TestWithLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	db, err := Open("testdata/logger.db", WithLogger(logger))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("h", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected nothing logged for a fast write, got %q", buf.String())
+	}
+
+	if err := db.HdelBucket("missing"); err == nil {
+		t.Fatalf("expected HdelBucket on a missing key to fail")
+	}
+	if !strings.Contains(buf.String(), `msg="write failed" op=HdelBucket key=missing`) {
+		t.Errorf("expected the failed write to be logged, got %q", buf.String())
+	}
+
+	if err := db.Compact("testdata/logger.compacted.db"); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	for _, want := range []string{`msg="compaction started"`, `msg="compaction finished"`, "compacted_size="} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("expected %q in log, got %q", want, buf.String())
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package jungledb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+
+	"go.etcd.io/bbolt"
+)
+
+// parquetRowGroupRows is how many rows ExportParquet buffers before writing them out as
+// a row group.
+const parquetRowGroupRows = 1 << 16
+
+// parquetMagic starts and ends a Parquet file.
+const parquetMagic = "PAR1"
+
+// Parquet physical types, repetitions, encodings and page types used by ExportParquet,
+// as numbered by the Parquet format.
+const (
+	parquetDouble    = 5
+	parquetByteArray = 6
+
+	parquetRequired = 0
+	parquetOptional = 1
+
+	parquetUTF8 = 0 // Converted type
+
+	parquetPlain = 0
+	parquetRLE   = 3
+
+	parquetDataPage = 0
+)
+
+// parquetColumn describes a column of ExportParquet.
+type parquetColumn struct {
+	name       string
+	typ        int32
+	repetition int32
+	utf8       bool
+}
+
+// parquetColumns are the columns of ExportParquet, in order.
+var parquetColumns = []parquetColumn{
+	{"key", parquetByteArray, parquetRequired, true},
+	{"field", parquetByteArray, parquetRequired, true},
+	{"value", parquetByteArray, parquetOptional, false},
+	{"type", parquetByteArray, parquetRequired, true},
+	{"score", parquetDouble, parquetOptional, false},
+}
+
+// parquetRow is a row of ExportParquet: a hash field, or a sorted set member with the
+// member as field.
+type parquetRow struct {
+	key, field, typ string
+	value           []byte // nil for sorted sets
+	score           float64
+}
+
+// ExportParquet writes the keys matching pattern (see ListKeys) as a Parquet file, so
+// dumps can be queried by DuckDB, Athena or Spark without a conversion step. Each hash
+// field and each sorted set member is a row with the columns key, field (the field or
+// the member), value (binary, null for sorted sets), type ("hash" or "zset") and score
+// (a double, null for hashes). Pages are PLAIN encoded and uncompressed. Expired keys
+// are skipped and TTLs are not exported. The export is a consistent snapshot.
+func (db *DB) ExportParquet(w io.Writer, pattern string) error {
+	pw := &parquetWriter{w: bufio.NewWriter(w)}
+	if err := pw.write([]byte(parquetMagic)); err != nil {
+		return err
+	}
+
+	var rows []parquetRow
+	err := db.view("ExportParquet", pattern, func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+			if isInternalBucket(tx, name) || !matchPattern(pattern, string(name)) || db.liveBucket(tx, string(name)) == nil {
+				return nil
+			}
+			typ := keyType(tx, name)
+			return b.ForEach(func(k, v []byte) error {
+				row := parquetRow{key: string(name), typ: typ}
+				if typ == typeZset {
+					row.score, row.field, _ = DecodeZsetEntry(k)
+				} else {
+					row.field, row.value = string(k), append([]byte{}, v...) // Copy out of the mmap
+				}
+				if rows = append(rows, row); len(rows) == parquetRowGroupRows {
+					if err := pw.writeRowGroup(rows); err != nil {
+						return err
+					}
+					rows = rows[:0]
+				}
+				return nil
+			})
+		})
+	})
+	if err != nil {
+		return err
+	}
+	if len(rows) > 0 {
+		if err := pw.writeRowGroup(rows); err != nil {
+			return err
+		}
+	}
+	return pw.close()
+}
+
+// parquetWriter writes a Parquet file row group by row group.
+type parquetWriter struct {
+	w         *bufio.Writer
+	offset    int64
+	rowGroups []parquetRowGroup
+}
+
+// parquetRowGroup records where a row group was written, for the footer.
+type parquetRowGroup struct {
+	rows    int64
+	size    int64
+	columns []parquetChunk
+}
+
+// parquetChunk records where a column chunk of a row group was written.
+type parquetChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+func (pw *parquetWriter) write(b []byte) error {
+	n, err := pw.w.Write(b)
+	pw.offset += int64(n)
+	return err
+}
+
+// writeRowGroup writes rows as a row group of one page per column.
+func (pw *parquetWriter) writeRowGroup(rows []parquetRow) error {
+	rg := parquetRowGroup{rows: int64(len(rows))}
+	for _, col := range parquetColumns {
+		var levels []bool // Whether each value is present, for optional columns
+		var data []byte
+		for _, row := range rows {
+			switch col.name {
+			case "key":
+				data = appendParquetBytes(data, row.key)
+			case "field":
+				data = appendParquetBytes(data, row.field)
+			case "type":
+				data = appendParquetBytes(data, row.typ)
+			case "value":
+				levels = append(levels, row.typ == typeHash)
+				if row.typ == typeHash {
+					data = appendParquetBytes(data, row.value)
+				}
+			case "score":
+				levels = append(levels, row.typ == typeZset)
+				if row.typ == typeZset {
+					data = binary.LittleEndian.AppendUint64(data, math.Float64bits(row.score))
+				}
+			}
+		}
+
+		var page []byte
+		if col.repetition == parquetOptional {
+			encoded := appendParquetLevels(nil, levels)
+			page = binary.LittleEndian.AppendUint32(page, uint32(len(encoded)))
+			page = append(page, encoded...)
+		}
+		page = append(page, data...)
+
+		var header thriftWriter
+		header.i32(1, parquetDataPage)
+		header.i32(2, int32(len(page)))
+		header.i32(3, int32(len(page)))
+		header.beginStruct(5) // Data page header
+		header.i32(1, int32(len(rows)))
+		header.i32(2, parquetPlain)
+		header.i32(3, parquetRLE)
+		header.i32(4, parquetRLE)
+		header.endStruct()
+		header.stop()
+
+		chunk := parquetChunk{offset: pw.offset, size: int64(len(header.b) + len(page)), values: int64(len(rows))}
+		if err := pw.write(header.b); err != nil {
+			return err
+		}
+		if err := pw.write(page); err != nil {
+			return err
+		}
+		rg.columns = append(rg.columns, chunk)
+		rg.size += chunk.size
+	}
+	pw.rowGroups = append(pw.rowGroups, rg)
+	return nil
+}
+
+// close writes the footer.
+func (pw *parquetWriter) close() error {
+	var meta thriftWriter
+	var rows int64
+	for _, rg := range pw.rowGroups {
+		rows += rg.rows
+	}
+	meta.i32(1, 1) // Format version
+	meta.beginList(2, thriftStruct, len(parquetColumns)+1)
+	meta.beginElem() // Root of the schema
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(parquetColumns)))
+	meta.endStruct()
+	for _, col := range parquetColumns {
+		meta.beginElem()
+		meta.i32(1, col.typ)
+		meta.i32(3, col.repetition)
+		meta.binary(4, col.name)
+		if col.utf8 {
+			meta.i32(6, parquetUTF8)
+		}
+		meta.endStruct()
+	}
+	meta.i64(3, rows)
+	meta.beginList(4, thriftStruct, len(pw.rowGroups))
+	for _, rg := range pw.rowGroups {
+		meta.beginElem()
+		meta.beginList(1, thriftStruct, len(rg.columns))
+		for i, chunk := range rg.columns {
+			col := parquetColumns[i]
+			meta.beginElem()
+			meta.i64(2, chunk.offset)
+			meta.beginStruct(3) // Column metadata
+			meta.i32(1, col.typ)
+			meta.beginList(2, thriftI32, 2)
+			meta.varint(parquetPlain)
+			meta.varint(parquetRLE)
+			meta.beginList(3, thriftBinary, 1)
+			meta.str(col.name)
+			meta.i32(4, 0) // Uncompressed
+			meta.i64(5, chunk.values)
+			meta.i64(6, chunk.size)
+			meta.i64(7, chunk.size)
+			meta.i64(9, chunk.offset)
+			meta.endStruct()
+			meta.endStruct()
+		}
+		meta.i64(2, rg.size)
+		meta.i64(3, rg.rows)
+		meta.endStruct()
+	}
+	meta.binary(6, "jungledb")
+	meta.stop()
+
+	if err := pw.write(meta.b); err != nil {
+		return err
+	}
+	if err := pw.write(binary.LittleEndian.AppendUint32(nil, uint32(len(meta.b)))); err != nil {
+		return err
+	}
+	if err := pw.write([]byte(parquetMagic)); err != nil {
+		return err
+	}
+	return pw.w.Flush()
+}
+
+// appendParquetBytes appends a PLAIN encoded byte array.
+func appendParquetBytes[T string | []byte](b []byte, v T) []byte {
+	b = binary.LittleEndian.AppendUint32(b, uint32(len(v)))
+	return append(b, v...)
+}
+
+// appendParquetLevels appends definition levels of bit width 1 in the RLE hybrid
+// encoding, as runs of equal levels.
+func appendParquetLevels(b []byte, levels []bool) []byte {
+	for i := 0; i < len(levels); {
+		j := i + 1
+		for j < len(levels) && levels[j] == levels[i] {
+			j++
+		}
+		b = binary.AppendUvarint(b, uint64(j-i)<<1) // RLE run header
+		if levels[i] {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+		i = j
+	}
+	return b
+}
+
+// Thrift compact protocol types used by Parquet metadata.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes Parquet metadata in the Thrift compact protocol.
+type thriftWriter struct {
+	b      []byte
+	last   int16   // Id of the last field of the current struct
+	parent []int16 // Ids of the last fields of the enclosing structs
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.b = append(t.b, byte(delta)<<4|typ)
+	} else {
+		t.b = append(t.b, typ)
+		t.b = binary.AppendVarint(t.b, int64(id))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) varint(v int64) {
+	t.b = binary.AppendVarint(t.b, v) // Zigzag, as the compact protocol wants
+}
+
+func (t *thriftWriter) str(s string) {
+	t.b = binary.AppendUvarint(t.b, uint64(len(s)))
+	t.b = append(t.b, s...)
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.str(s)
+}
+
+func (t *thriftWriter) beginList(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.b = append(t.b, byte(n)<<4|elem)
+	} else {
+		t.b = append(t.b, 0xf0|elem)
+		t.b = binary.AppendUvarint(t.b, uint64(n))
+	}
+}
+
+// beginStruct starts a struct field; beginElem starts a struct element of a list.
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElem()
+}
+
+func (t *thriftWriter) beginElem() {
+	t.parent = append(t.parent, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) endStruct() {
+	t.stop()
+	t.last = t.parent[len(t.parent)-1]
+	t.parent = t.parent[:len(t.parent)-1]
+}
+
+func (t *thriftWriter) stop() {
+	t.b = append(t.b, 0)
+}
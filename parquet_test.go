@@ -0,0 +1,197 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+// TestExportParquet tests the layout of a Parquet export by decoding its footer and pages.
+func TestExportParquet(t *testing.T) {
+	db, err := Open("testdata/parquet.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Hmset("user:1", map[string][]byte{"city": []byte("Paris"), "name": []byte("alice")}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	if err := db.Zadd("user:ranking", 2.5, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Hset("order:1", "total", []byte("12")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := db.ExportParquet(&buf, "user:*"); err != nil {
+		t.Fatalf("ExportParquet failed: %v", err)
+	}
+	file := buf.Bytes()
+	if !bytes.HasPrefix(file, []byte("PAR1")) || !bytes.HasSuffix(file, []byte("PAR1")) {
+		t.Fatalf("expected the Parquet magic, got %q", file)
+	}
+	size := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	r := &thriftReader{b: file[len(file)-8-size : len(file)-8]}
+	meta := r.readStruct()
+	if r.err != nil {
+		t.Fatalf("failed to decode footer: %v", r.err)
+	}
+	if meta[3] != int64(3) {
+		t.Errorf("expected 3 rows, got %v", meta[3])
+	}
+	schema := meta[2].([]any)
+	var names []string
+	for _, el := range schema[1:] {
+		names = append(names, el.(map[int16]any)[4].(string))
+	}
+	if len(names) != 5 || names[0] != "key" || names[4] != "score" {
+		t.Errorf("unexpected columns %v", names)
+	}
+
+	// Read every column of the row group back
+	columns := make(map[string][]any)
+	rowGroup := meta[4].([]any)[0].(map[int16]any)
+	for i, chunk := range rowGroup[1].([]any) {
+		offset := chunk.(map[int16]any)[2].(int64)
+		pr := &thriftReader{b: file[offset:]}
+		header := pr.readStruct()
+		page := file[int(offset)+pr.pos:][:header[3].(int64)]
+		n := int(header[5].(map[int16]any)[1].(int64))
+
+		present := make([]bool, n)
+		for j := range present {
+			present[j] = true
+		}
+		if names[i] == "value" || names[i] == "score" {
+			levels := int(binary.LittleEndian.Uint32(page))
+			lr := page[4 : 4+levels]
+			present = present[:0]
+			for len(lr) > 0 {
+				run, k := binary.Uvarint(lr)
+				for range run >> 1 {
+					present = append(present, lr[k] == 1)
+				}
+				lr = lr[k+1:]
+			}
+			page = page[4+levels:]
+		}
+		for _, ok := range present {
+			switch {
+			case !ok:
+				columns[names[i]] = append(columns[names[i]], nil)
+			case names[i] == "score":
+				columns[names[i]] = append(columns[names[i]], math.Float64frombits(binary.LittleEndian.Uint64(page)))
+				page = page[8:]
+			default:
+				l := binary.LittleEndian.Uint32(page)
+				columns[names[i]] = append(columns[names[i]], string(page[4:4+l]))
+				page = page[4+l:]
+			}
+		}
+	}
+
+	want := map[string][]any{
+		"key":   {"user:1", "user:1", "user:ranking"},
+		"field": {"city", "name", "alice"},
+		"value": {"Paris", "alice", nil},
+		"type":  {"hash", "hash", "zset"},
+		"score": {nil, nil, 2.5},
+	}
+	for name, values := range want {
+		if len(columns[name]) != len(values) {
+			t.Errorf("column %s: expected %v, got %v", name, values, columns[name])
+			continue
+		}
+		for j := range values {
+			if columns[name][j] != values[j] {
+				t.Errorf("column %s: expected %v, got %v", name, values, columns[name])
+				break
+			}
+		}
+	}
+}
+
+// thriftReader decodes the Thrift compact protocol into maps of field ids to values.
+type thriftReader struct {
+	b   []byte
+	pos int
+	err error
+}
+
+func (r *thriftReader) byte() byte {
+	if r.pos >= len(r.b) {
+		r.err = errShortThrift
+		return 0
+	}
+	r.pos++
+	return r.b[r.pos-1]
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b[min(r.pos, len(r.b)):])
+	if n <= 0 {
+		r.err = errShortThrift
+	}
+	r.pos += max(n, 0)
+	return v
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case thriftI32, thriftI64:
+		v := r.uvarint()
+		return int64(v>>1) ^ -int64(v&1)
+	case thriftBinary:
+		n := int(r.uvarint())
+		if r.err != nil || r.pos+n > len(r.b) {
+			r.err = errShortThrift
+			return ""
+		}
+		r.pos += n
+		return string(r.b[r.pos-n : r.pos])
+	case thriftList:
+		h := r.byte()
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		var list []any
+		for range n {
+			if r.err != nil {
+				break
+			}
+			list = append(list, r.value(h&0x0f))
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	r.err = errShortThrift
+	return nil
+}
+
+func (r *thriftReader) readStruct() map[int16]any {
+	fields := make(map[int16]any)
+	var last int16
+	for r.err == nil {
+		h := r.byte()
+		if h == 0 {
+			break
+		}
+		if delta := h >> 4; delta != 0 {
+			last += int16(delta)
+		} else {
+			v := r.uvarint()
+			last = int16(int64(v>>1) ^ -int64(v&1))
+		}
+		fields[last] = r.value(h & 0x0f)
+	}
+	return fields
+}
+
+var errShortThrift = errors.New("malformed thrift")
@@ -0,0 +1,191 @@
+package jungledb
+
+import (
+	"bytes"
+
+	"go.etcd.io/bbolt"
+)
+
+// Pipeline queues reads to run together in a single read transaction, which saves the
+// cost of a transaction per read for services doing many small ones. Reads are queued
+// with its methods and run by Exec; a Pipeline is not safe for concurrent use.
+type Pipeline struct {
+	db    *DB
+	keys  []string // Keys read, as stored
+	reads []func(tx *bbolt.Tx, r *PipelineResult) error
+}
+
+// PipelineResult is the result of one read of a Pipeline. Only the fields of its kind
+// of read are set.
+type PipelineResult struct {
+	Value   []byte            // Hget: the field value, nil if it does not exist
+	Values  [][]byte          // Hmget: the field values, nil for those that do not exist
+	Fields  map[string][]byte // Hscan: the fields of the hash
+	Score   float64           // Zscore: the score of the member, 0 if it does not exist
+	Members []string          // Zrange: the members in the range
+	Count   int               // Zcard: the number of members
+	Found   bool              // Hget and Zscore: whether the field or member exists
+	Err     error             // Error of this read, such as ErrWrongType
+}
+
+// Pipeline returns an empty Pipeline reading from db.
+func (db *DB) Pipeline() *Pipeline {
+	return &Pipeline{db: db}
+}
+
+// queue adds a read of key.
+func (p *Pipeline) queue(key string, read func(tx *bbolt.Tx, r *PipelineResult) error) {
+	p.keys = append(p.keys, key)
+	p.reads = append(p.reads, read)
+}
+
+// reserved queues a read failing with ErrReservedKey if key is reserved, see checkKey,
+// and reports whether it did.
+func (p *Pipeline) reserved(key string) bool {
+	err := checkKey(key)
+	if err != nil {
+		p.reads = append(p.reads, func(*bbolt.Tx, *PipelineResult) error { return err })
+	}
+	return err != nil
+}
+
+// Hget queues a read of a field of a hash, see DB.HgetOK.
+func (p *Pipeline) Hget(key, field string) {
+	if p.reserved(key) {
+		return
+	}
+	key = p.db.nsKey(key)
+	p.queue(key, func(tx *bbolt.Tx, r *PipelineResult) error {
+		bucket, err := p.db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err
+		}
+		v, ok := getField(bucket, field)
+		r.Value, r.Found = bytes.Clone(v), ok
+		return nil
+	})
+}
+
+// Hmget queues a read of fields of a hash, see DB.Hmget.
+func (p *Pipeline) Hmget(key string, fields ...string) {
+	if p.reserved(key) {
+		return
+	}
+	key = p.db.nsKey(key)
+	p.queue(key, func(tx *bbolt.Tx, r *PipelineResult) error {
+		r.Values = make([][]byte, len(fields))
+		bucket, err := p.db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err
+		}
+		for i, field := range fields {
+			v, _ := getField(bucket, field)
+			r.Values[i] = bytes.Clone(v)
+		}
+		return nil
+	})
+}
+
+// Hscan queues a read of all the fields of a hash, see DB.Hscan.
+func (p *Pipeline) Hscan(key string) {
+	if p.reserved(key) {
+		return
+	}
+	key = p.db.nsKey(key)
+	p.queue(key, func(tx *bbolt.Tx, r *PipelineResult) error {
+		r.Fields = make(map[string][]byte)
+		bucket, err := p.db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			r.Fields[string(k)] = bytes.Clone(v)
+			return nil
+		})
+	})
+}
+
+// Zscore queues a read of the score of a sorted set member, see DB.Zscore.
+func (p *Pipeline) Zscore(key, member string) {
+	if p.reserved(key) {
+		return
+	}
+	key = p.db.nsKey(key)
+	p.queue(key, func(tx *bbolt.Tx, r *PipelineResult) error {
+		var err error
+		r.Score, r.Found, err = p.db.zscore(tx, key, member)
+		return err
+	})
+}
+
+// Zrange queues a read of sorted set members by rank, see DB.Zrange.
+func (p *Pipeline) Zrange(key string, start, stop int) {
+	if p.reserved(key) {
+		return
+	}
+	key = p.db.nsKey(key)
+	p.queue(key, func(tx *bbolt.Tx, r *PipelineResult) error {
+		var err error
+		r.Members, err = p.db.zrange(tx, key, start, stop)
+		return err
+	})
+}
+
+// Zcard queues a read of the number of members of a sorted set, see DB.Zcard.
+func (p *Pipeline) Zcard(key string) {
+	if p.reserved(key) {
+		return
+	}
+	key = p.db.nsKey(key)
+	p.queue(key, func(tx *bbolt.Tx, r *PipelineResult) error {
+		bucket, err := p.db.zsetBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err
+		}
+		r.Count = bucket.Stats().KeyN
+		return nil
+	})
+}
+
+// Len returns the number of reads queued.
+func (p *Pipeline) Len() int {
+	return len(p.reads)
+}
+
+// Exec runs the queued reads in a single read transaction, so they see the same
+// snapshot, and returns their results in the order they were queued. The error of a
+// read is reported in its result and does not stop the others; Exec itself only fails
+// if the transaction does, for example with ErrClosed. The queue is emptied, so the
+// Pipeline can be reused.
+func (p *Pipeline) Exec() ([]PipelineResult, error) {
+	keys, reads := p.keys, p.reads
+	p.keys, p.reads = nil, nil
+
+	for _, key := range keys {
+		if err := p.db.faultIn(key); err != nil {
+			return nil, err
+		}
+		p.db.touch(key)
+		p.db.touchTier(key)
+	}
+	results := make([]PipelineResult, len(reads))
+	err := p.db.view("Pipeline", "", func(tx *bbolt.Tx) error {
+		for i, read := range reads {
+			results[i].Err = read(tx, &results[i])
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	n := 0
+	for _, r := range results {
+		n += len(r.Value) + mapBytes(r.Fields)
+		for _, v := range r.Values {
+			n += len(v)
+		}
+	}
+	p.db.metrics.addRead(n)
+	return results, nil
+}
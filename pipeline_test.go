@@ -0,0 +1,68 @@
+package jungledb
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestPipeline tests running heterogeneous reads in one transaction.
+func TestPipeline(t *testing.T) {
+	db, err := Open("testdata/pipeline.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Hmset("user:1", map[string][]byte{"name": []byte("alice"), "city": []byte("Paris")}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	for i, member := range []string{"a", "b", "c"} {
+		if err := db.Zadd("ranking", float64(i+1), member); err != nil {
+			t.Fatalf("Zadd failed: %v", err)
+		}
+	}
+
+	p := db.Pipeline()
+	p.Hget("user:1", "name")
+	p.Hget("user:1", "missing")
+	p.Zscore("ranking", "b")
+	p.Zrange("ranking", 0, -1)
+	p.Zcard("ranking")
+	p.Hmget("user:1", "city", "missing")
+	p.Hscan("user:1")
+	p.Zscore("user:1", "a")
+	if p.Len() != 8 {
+		t.Fatalf("expected 8 queued reads, got %d", p.Len())
+	}
+	results, err := p.Exec()
+	if err != nil {
+		t.Fatalf("Exec failed: %v", err)
+	}
+	if len(results) != 8 || p.Len() != 0 {
+		t.Fatalf("expected 8 results and an empty queue, got %d and %d", len(results), p.Len())
+	}
+
+	if r := results[0]; string(r.Value) != "alice" || !r.Found || r.Err != nil {
+		t.Errorf("unexpected Hget result %+v", r)
+	}
+	if r := results[1]; r.Value != nil || r.Found || r.Err != nil {
+		t.Errorf("unexpected Hget result for a missing field %+v", r)
+	}
+	if r := results[2]; r.Score != 2 || !r.Found {
+		t.Errorf("unexpected Zscore result %+v", r)
+	}
+	if r := results[3]; len(r.Members) != 3 || r.Members[0] != "a" || r.Members[2] != "c" {
+		t.Errorf("unexpected Zrange result %+v", r)
+	}
+	if r := results[4]; r.Count != 3 {
+		t.Errorf("unexpected Zcard result %+v", r)
+	}
+	if r := results[5]; len(r.Values) != 2 || string(r.Values[0]) != "Paris" || r.Values[1] != nil {
+		t.Errorf("unexpected Hmget result %+v", r)
+	}
+	if r := results[6]; len(r.Fields) != 2 || string(r.Fields["city"]) != "Paris" {
+		t.Errorf("unexpected Hscan result %+v", r)
+	}
+	if r := results[7]; !errors.Is(r.Err, ErrWrongType) {
+		t.Errorf("expected ErrWrongType for a hash read as a sorted set, got %v", r.Err)
+	}
+}
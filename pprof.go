@@ -0,0 +1,59 @@
+package jungledb
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// profiledOps are the operations WithPprofLabels tags: those that scan whole hashes, key
+// ranges or the whole database.
+var profiledOps = map[string]bool{
+	"Backup":              true,
+	"BackupIncrementalTo": true,
+	"BlobGC":              true,
+	"Check":               true,
+	"Compact":             true,
+	"Export":              true,
+	"ExportCSV":           true,
+	"ExportParquet":       true,
+	"ExportRESP":          true,
+	"ExportSince":         true,
+	"HaggregateInt":       true,
+	"Hprefix":             true,
+	"Hrscan":              true,
+	"Hscan":               true,
+	"ListKeys":            true,
+	"Query":               true,
+	"RawHprefix":          true,
+	"RawHscan":            true,
+	"ScanAll":             true,
+	"Search":              true,
+	"VSearch":             true,
+	"Warm":                true,
+	"Zrange":              true,
+	"Zrevrange":           true,
+}
+
+// WithPprofLabels makes long-running operations (scans, exports, checks, backups and
+// compactions) run with the pprof labels "operation", such as "Hscan", and "key", the
+// key or pattern they work on, so CPU profiles attribute their time to them. Since the
+// labels of a goroutine cannot be read back, labels the caller set on the goroutine are
+// cleared when such an operation returns.
+func WithPprofLabels() Option {
+	return func(o *options) {
+		o.pprofLabels = true
+	}
+}
+
+// labeled runs fn, with the pprof labels of op and key if WithPprofLabels is set and op
+// is long-running.
+func (db *DB) labeled(op, key string, fn func() error) error {
+	if !db.opts.pprofLabels || !profiledOps[op] {
+		return fn()
+	}
+	var err error
+	pprof.Do(context.Background(), pprof.Labels("operation", op, "key", key), func(context.Context) {
+		err = fn()
+	})
+	return err
+}
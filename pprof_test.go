@@ -0,0 +1,40 @@
+package jungledb
+
+import (
+	"bytes"
+	"runtime/pprof"
+	"strings"
+	"testing"
+)
+
+// TestPprofLabels tests that long-running operations carry pprof labels with
+// WithPprofLabels, and only with it.
+func TestPprofLabels(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		var opts []Option
+		if enabled {
+			opts = append(opts, WithPprofLabels())
+		}
+		db, err := Open("testdata/pprof.db", opts...)
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		if err := db.Hset("h", "f", []byte("v")); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+
+		// The goroutine profile lists the labels of each goroutine, including this one
+		var profile bytes.Buffer
+		err = db.Raw().Hscan("h", 0, func(field, value []byte) error {
+			return pprof.Lookup("goroutine").WriteTo(&profile, 1)
+		})
+		db.Close()
+		if err != nil {
+			t.Fatalf("Hscan failed: %v", err)
+		}
+		labeled := strings.Contains(profile.String(), `"operation":"RawHscan"`) && strings.Contains(profile.String(), `"key":"h"`)
+		if labeled != enabled {
+			t.Errorf("with WithPprofLabels %v, expected labels %v, got %v", enabled, enabled, labeled)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package jungledb
+
+import (
+	"fmt"
+
+	"go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+// protoMarshal encodes protobuf messages deterministically, so that equal messages are
+// stored as equal bytes.
+var protoMarshal = proto.MarshalOptions{Deterministic: true}
+
+// HsetProto sets the field of a hash to the protobuf wire encoding of msg.
+func (db *DB) HsetProto(key, field string, msg proto.Message) error {
+	value, err := protoMarshal.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %v", msg.ProtoReflect().Descriptor().FullName(), err)
+	}
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("HsetProto", key, func(tx *txn) error {
+		return hset(tx, key, field, value)
+	})
+}
+
+// HgetProto decodes the field of a hash set by HsetProto into msg, which it resets
+// first. It returns ErrFieldNotFound if the hash or the field does not exist.
+func (db *DB) HgetProto(key, field string, msg proto.Message) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.view("HgetProto", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil {
+			return err
+		}
+		var value []byte
+		found := false
+		if bucket != nil {
+			value, found = getField(bucket, field)
+		}
+		if !found {
+			return fmt.Errorf("%w: %s", ErrFieldNotFound, field)
+		}
+		if err := proto.Unmarshal(value, msg); err != nil {
+			return fmt.Errorf("failed to decode %s: %v", msg.ProtoReflect().Descriptor().FullName(), err)
+		}
+		db.metrics.addRead(len(value))
+		return nil
+	})
+}
@@ -0,0 +1,63 @@
+package jungledb
+
+import (
+	"errors"
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// TestProto tests storing protobuf messages with HsetProto and in structs.
+func TestProto(t *testing.T) {
+	db, err := Open("testdata/proto.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	msg, err := structpb.NewStruct(map[string]any{"name": "alice", "age": 34, "tags": []any{"a", "b"}})
+	if err != nil {
+		t.Fatalf("failed to build message: %v", err)
+	}
+	if err := db.HsetProto("user:1", "profile", msg); err != nil {
+		t.Fatalf("HsetProto failed: %v", err)
+	}
+	var got structpb.Struct
+	if err := db.HgetProto("user:1", "profile", &got); err != nil {
+		t.Fatalf("HgetProto failed: %v", err)
+	}
+	if !proto.Equal(&got, msg) {
+		t.Errorf("expected %v, got %v", msg, &got)
+	}
+	if err := db.HgetProto("user:1", "missing", &got); !errors.Is(err, ErrFieldNotFound) {
+		t.Errorf("expected ErrFieldNotFound, got %v", err)
+	}
+	if err := db.Hset("user:1", "bad", []byte{0xFF}); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.HgetProto("user:1", "bad", &got); err == nil {
+		t.Errorf("expected an invalid encoding to fail")
+	}
+
+	type event struct {
+		Name string
+		At   *timestamppb.Timestamp
+	}
+	saved := event{Name: "launch", At: timestamppb.New(timestamppb.Now().AsTime())}
+	if err := db.SaveStruct("event:1", saved); err != nil {
+		t.Fatalf("SaveStruct failed: %v", err)
+	}
+	var at timestamppb.Timestamp
+	if err := db.HgetProto("event:1", "At", &at); err != nil || !proto.Equal(&at, saved.At) {
+		t.Errorf("expected the struct field in wire encoding, got %v %v", &at, err)
+	}
+	var loaded event
+	if err := db.LoadStruct("event:1", &loaded); err != nil {
+		t.Fatalf("LoadStruct failed: %v", err)
+	}
+	if loaded.Name != saved.Name || !proto.Equal(loaded.At, saved.At) {
+		t.Errorf("expected %v, got %v", saved, loaded)
+	}
+}
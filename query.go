@@ -0,0 +1,257 @@
+package jungledb
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// Query selects hashes by key prefix and field values. Build one with DB.Query, narrow it
+// with Where, OrderBy and Limit, then call Run. A Query is not safe for concurrent use but
+// can be run several times.
+type Query struct {
+	db     *DB
+	prefix string
+	preds  []predicate
+	order  string
+	desc   bool
+	limit  int
+	err    error
+}
+
+// predicate is a condition added by Where.
+type predicate struct {
+	field string
+	op    string
+	value []byte
+}
+
+// QueryResult is one hash matched by a query.
+type QueryResult struct {
+	Key    string
+	Fields map[string][]byte
+}
+
+// Query starts a query over the hashes whose keys start with prefix; an empty prefix
+// selects every hash.
+func (db *DB) Query(prefix string) *Query {
+	return &Query{db: db, prefix: prefix}
+}
+
+// Where keeps the hashes whose field compares to value with op, one of "=", "!=", "<",
+// "<=", ">" and ">=". "=" and "!=" compare bytes exactly; the other operators compare
+// numerically when both sides parse as numbers and bytewise otherwise. Hashes lacking the
+// field never match. Conditions added by several calls must all hold.
+func (q *Query) Where(field, op, value string) *Query {
+	switch op {
+	case "=", "!=", "<", "<=", ">", ">=":
+	default:
+		if q.err == nil {
+			q.err = fmt.Errorf("unknown query operator %q", op)
+		}
+	}
+	q.preds = append(q.preds, predicate{field: field, op: op, value: []byte(value)})
+	return q
+}
+
+// OrderBy sorts the results by the value of field, compared as by Where, instead of by
+// key. Hashes lacking the field come last; ties are broken by key.
+func (q *Query) OrderBy(field string) *Query {
+	q.order, q.desc = field, false
+	return q
+}
+
+// OrderByDesc is like OrderBy but sorts in descending order; hashes lacking the field
+// still come last.
+func (q *Query) OrderByDesc(field string) *Query {
+	q.order, q.desc = field, true
+	return q
+}
+
+// Limit returns at most n results; n <= 0 means no limit.
+func (q *Query) Limit(n int) *Query {
+	q.limit = n
+	return q
+}
+
+// Run evaluates the query and returns the matching hashes, in key order unless OrderBy
+// was called. Conditions are checked while scanning, so only matching hashes are read in
+// full. When an index configured with WithIndex covers the prefix and the query has "="
+// conditions on its leading fields, only the hashes found in the index are scanned.
+func (q *Query) Run() ([]QueryResult, error) {
+	if q.err != nil {
+		return nil, q.err
+	}
+
+	var results []QueryResult
+	err := q.db.view("Query", q.db.nsKey(q.prefix), func(tx *bbolt.Tx) error {
+		idx, values := q.index()
+		if idx == nil {
+			return q.scan(tx, func(name string) bool {
+				return q.collect(tx, name, &results)
+			})
+		}
+		prefix := appendIndexValue(nil, []byte(q.db.ns))
+		for _, v := range values {
+			prefix = appendIndexValue(prefix, v)
+		}
+		return scanIndex(tx, idx, prefix, nil, func(name string) bool {
+			q.collect(tx, name, &results)
+			return true
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if q.order != "" {
+		sort.SliceStable(results, func(i, j int) bool {
+			a, aOK := results[i].Fields[q.order]
+			b, bOK := results[j].Fields[q.order]
+			if aOK != bOK {
+				return aOK
+			}
+			if c := compareValues(a, b); aOK && c != 0 {
+				return (c < 0) != q.desc
+			}
+			return results[i].Key < results[j].Key
+		})
+	} else {
+		sort.Slice(results, func(i, j int) bool { return results[i].Key < results[j].Key })
+	}
+	if q.limit > 0 && len(results) > q.limit {
+		results = results[:q.limit]
+	}
+
+	n := 0
+	for _, r := range results {
+		n += mapBytes(r.Fields)
+	}
+	q.db.metrics.addRead(n)
+	return results, nil
+}
+
+// index returns the configured index that serves the query best, with the values its
+// leading fields must hold, or nil if none of them helps.
+func (q *Query) index() (*Index, [][]byte) {
+	var best *Index
+	var bestValues [][]byte
+	for i := range q.db.opts.indexes {
+		idx := &q.db.opts.indexes[i]
+		if !strings.HasPrefix(q.prefix, idx.Prefix) {
+			continue
+		}
+		var values [][]byte
+		for _, field := range idx.Fields {
+			v, ok := q.equals(field)
+			if !ok {
+				break
+			}
+			values = append(values, v)
+		}
+		if len(values) > len(bestValues) {
+			best, bestValues = idx, values
+		}
+	}
+	return best, bestValues
+}
+
+// equals returns the value an "=" condition requires field to hold.
+func (q *Query) equals(field string) ([]byte, bool) {
+	for _, p := range q.preds {
+		if p.field == field && p.op == "=" {
+			return p.value, true
+		}
+	}
+	return nil, false
+}
+
+// scan calls fn with the name of every bucket whose key starts with the prefix of the
+// query, in order, until fn returns false.
+func (q *Query) scan(tx *bbolt.Tx, fn func(name string) bool) error {
+	start := []byte(q.db.nsKey(q.prefix))
+	c := tx.Cursor()
+	for k, _ := c.Seek(start); k != nil && bytes.HasPrefix(k, start); k, _ = c.Next() {
+		if !fn(string(k)) {
+			break
+		}
+	}
+	return nil
+}
+
+// collect appends the hash stored under name to results if it matches the query, and
+// reports whether more results are wanted.
+func (q *Query) collect(tx *bbolt.Tx, name string, results *[]QueryResult) bool {
+	key, bucket, _, ok := q.match(tx, name)
+	if !ok {
+		return true
+	}
+
+	fields := make(map[string][]byte)
+	bucket.ForEach(func(k, v []byte) error {
+		fields[string(k)] = bytes.Clone(v)
+		return nil
+	})
+	*results = append(*results, QueryResult{Key: key, Fields: fields})
+	return q.order != "" || q.limit <= 0 || len(*results) < q.limit
+}
+
+// match checks the hash stored under name against the query. It returns its key and
+// bucket, the bucket being nil if name is not a hash selected by the prefix, the number
+// of fields looked up and whether the hash matches.
+func (q *Query) match(tx *bbolt.Tx, name string) (string, *bbolt.Bucket, int, bool) {
+	key, ok := q.db.userKey(name)
+	if !ok || !strings.HasPrefix(key, q.prefix) || isInternalBucket(tx, []byte(name)) {
+		return key, nil, 0, false
+	}
+	bucket, err := q.db.hashBucket(tx, name)
+	if err != nil || bucket == nil {
+		return key, nil, 0, false // Sorted sets and expired keys do not match
+	}
+	for i, p := range q.preds {
+		v, ok := getField(bucket, p.field)
+		if !ok || !p.match(v) {
+			return key, bucket, i + 1, false
+		}
+	}
+	return key, bucket, len(q.preds), true
+}
+
+// match reports whether v satisfies the predicate.
+func (p predicate) match(v []byte) bool {
+	switch p.op {
+	case "=":
+		return bytes.Equal(v, p.value)
+	case "!=":
+		return !bytes.Equal(v, p.value)
+	case "<":
+		return compareValues(v, p.value) < 0
+	case "<=":
+		return compareValues(v, p.value) <= 0
+	case ">":
+		return compareValues(v, p.value) > 0
+	case ">=":
+		return compareValues(v, p.value) >= 0
+	}
+	return false
+}
+
+// compareValues compares a and b as numbers if both parse as one, and as bytes otherwise.
+func compareValues(a, b []byte) int {
+	x, errA := strconv.ParseFloat(string(a), 64)
+	y, errB := strconv.ParseFloat(string(b), 64)
+	if errA != nil || errB != nil {
+		return bytes.Compare(a, b)
+	}
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package jungledb
+
+import (
+	"reflect"
+	"testing"
+)
+
+// queryKeys returns the keys of results.
+func queryKeys(results []QueryResult) []string {
+	keys := make([]string, len(results))
+	for i, r := range results {
+		keys[i] = r.Key
+	}
+	return keys
+}
+
+// TestQuery tests filtering, ordering and limiting hashes with and without an index.
+func TestQuery(t *testing.T) {
+	db, err := Open("testdata/query.db", WithIndex(Index{Name: "country", Prefix: "user:", Fields: []string{"country"}}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	users := map[string][2]string{
+		"user:1": {"fr", "34"},
+		"user:2": {"fr", "9"},
+		"user:3": {"de", "51"},
+		"user:4": {"fr", "27"},
+	}
+	for key, v := range users {
+		if err := db.Hmset(key, map[string][]byte{"country": []byte(v[0]), "age": []byte(v[1])}); err != nil {
+			t.Fatalf("Hmset failed: %v", err)
+		}
+	}
+	if err := db.Hset("user:5", "country", []byte("fr")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Hset("item:1", "age", []byte("40")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Zadd("user:zset", 1, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		query *Query
+		want  []string
+	}{
+		{"prefix", db.Query("user:"), []string{"user:1", "user:2", "user:3", "user:4", "user:5"}},
+		{"numeric", db.Query("user:").Where("age", ">", "10"), []string{"user:1", "user:3", "user:4"}},
+		{"indexed", db.Query("user:").Where("country", "=", "fr").Where("age", "<=", "27"), []string{"user:2", "user:4"}},
+		{"not equal", db.Query("").Where("country", "!=", "fr"), []string{"user:3"}},
+		{"ordered", db.Query("user:").Where("country", "=", "fr").OrderBy("age"), []string{"user:2", "user:4", "user:1", "user:5"}},
+		{"descending", db.Query("user:").OrderByDesc("age").Limit(2), []string{"user:3", "user:1"}},
+		{"limit", db.Query("").Where("age", ">=", "30").Limit(2), []string{"item:1", "user:1"}},
+	}
+	for _, tt := range tests {
+		results, err := tt.query.Run()
+		if err != nil {
+			t.Errorf("%s: Run failed: %v", tt.name, err)
+			continue
+		}
+		if keys := queryKeys(results); !reflect.DeepEqual(keys, tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, keys)
+		}
+	}
+
+	results, err := db.Query("user:1").Run()
+	if err != nil || len(results) != 1 || string(results[0].Fields["age"]) != "34" {
+		t.Errorf("expected the fields of user:1, got %v %v", results, err)
+	}
+	if _, err := db.Query("").Where("age", "~", "1").Run(); err == nil {
+		t.Errorf("expected an unknown operator to fail")
+	}
+
+	// Namespaces see their own hashes only
+	if err := db.Namespace("tenant").Hset("user:1", "country", []byte("fr")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	results, err = db.Namespace("tenant").Query("").Where("country", "=", "fr").Run()
+	if keys := queryKeys(results); err != nil || !reflect.DeepEqual(keys, []string{"user:1"}) {
+		t.Errorf("expected [user:1] in the namespace, got %v %v", keys, err)
+	}
+}
@@ -0,0 +1,215 @@
+package jungledb
+
+import (
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// DefaultMaxValueSize is the largest hash value or sorted set member a write may store
+// unless WithMaxValueSize says otherwise. bbolt stores large values on runs of overflow
+// pages that are copied on every change, so values this large are best kept elsewhere.
+const DefaultMaxValueSize = 4 << 20
+
+// Limits bounds how much data writes may store, so that one tenant of a shared database
+// cannot fill the disk. Zero fields impose no limit. Writes that would exceed a limit
+// fail with ErrQuotaExceeded and change nothing; deletions are always allowed.
+type Limits struct {
+	MaxHashFields  int   // Fields per hash
+	MaxZsetMembers int   // Members per sorted set
+	MaxValueSize   int   // Bytes per hash field value or sorted set member, see also WithMaxValueSize
+	MaxFileSize    int64 // Bytes in use in the database file, not counting free pages
+}
+
+// WithLimits enforces limits on every write. Field and member counts are checked by
+// counting up to the limit in the keys a write touches, so low limits are cheap and
+// high ones cost a scan of that many entries per write. MaxFileSize is checked against
+// the space in use when the write starts, so a single write may overshoot it.
+func WithLimits(limits Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+// WithMaxValueSize sets the largest hash value or sorted set member a write may store,
+// DefaultMaxValueSize by default. Larger writes fail with ErrValueTooLarge and change
+// nothing. A negative size lifts the limit. Limits.MaxValueSize applies too, if lower.
+func WithMaxValueSize(size int) Option {
+	return func(o *options) {
+		o.maxValueSize = size
+	}
+}
+
+// maxValueSize returns the largest value writes may store, or 0 if there is no limit.
+func (db *DB) maxValueSize() int {
+	size := db.opts.maxValueSize
+	if size == 0 {
+		size = DefaultMaxValueSize
+	}
+	if limit := db.opts.limits.MaxValueSize; limit > 0 && (size < 0 || limit < size) {
+		size = limit
+	}
+	return max(size, 0)
+}
+
+// ValueSize locates the largest hash value of a database, see LargestValue.
+type ValueSize struct {
+	Size  int    // Bytes of the value, to compare against WithMaxValueSize
+	Key   string // Hash holding the value, including the namespace prefix
+	Field string // Field holding the value
+}
+
+// LargestValue reads every hash value of the database in a single read transaction and
+// returns the largest, for example to check that WithMaxValueSize can be lowered. Unlike
+// Stats, which only reads bucket statistics, it costs a scan of the whole file. Its Size
+// is zero if no hash holds a value.
+func (db *DB) LargestValue() (ValueSize, error) {
+	var largest ValueSize
+	err := db.view("LargestValue", "", func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+			if !isInternalBucket(tx, name) && keyType(tx, name) == typeHash {
+				largest.scan(name, b)
+			}
+			return nil
+		})
+	})
+	return largest, err
+}
+
+// scan updates v with the values of the hash b stored under name that are larger.
+func (v *ValueSize) scan(name []byte, b *bbolt.Bucket) {
+	b.ForEach(func(field, value []byte) error {
+		if len(value) > v.Size {
+			*v = ValueSize{Size: len(value), Key: string(name), Field: string(field)}
+		}
+		return nil
+	})
+}
+
+// Usage describes the current size of a key.
+type Usage struct {
+	Type    string // "hash" or "zset"
+	Entries int    // Fields or members
+	Bytes   int64  // Total size of field names and values, or of members and scores
+}
+
+// Usage reports the current size of key, to compare against Limits.
+// It returns ErrKeyNotFound if the key does not exist.
+func (db *DB) Usage(key string) (Usage, error) {
+	if err := checkKey(key); err != nil {
+		return Usage{}, err
+	}
+	key = db.nsKey(key)
+	var usage Usage
+	err := db.view("Usage", key, func(tx *bbolt.Tx) error {
+		if db.liveBucket(tx, key) == nil {
+			return ErrKeyNotFound
+		}
+		usage, _ = keyUsage(tx, key)
+		return nil
+	})
+	return usage, err
+}
+
+// keyUsage measures key, reporting false if it does not exist.
+func keyUsage(tx *bbolt.Tx, key string) (Usage, bool) {
+	bucket := tx.Bucket([]byte(key))
+	if bucket == nil {
+		return Usage{}, false
+	}
+	usage := Usage{Type: keyType(tx, []byte(key))}
+	if usage.Type == typeZset {
+		bucket = membersBucket(tx, key)
+	}
+	bucket.ForEach(func(k, v []byte) error {
+		usage.Entries++
+		usage.Bytes += int64(len(k) + len(v))
+		return nil
+	})
+	return usage, true
+}
+
+// checkLimits returns ErrQuotaExceeded, or ErrValueTooLarge, if the mutations recorded
+// in tx break a limit.
+func (db *DB) checkLimits(tx *txn) error {
+	if err := db.checkValueSizes(tx); err != nil {
+		return err
+	}
+	limits := db.opts.limits
+	if limits == (Limits{}) {
+		return nil
+	}
+
+	grows := false
+	checked := make(map[string]bool)
+	for _, ev := range tx.events {
+		var key string
+		switch ev.Type {
+		case EventHset, EventZadd:
+			key = ev.Key
+		case EventRename, EventCopy:
+			key = ev.Target
+		default:
+			continue
+		}
+		grows = true
+		if checked[key] {
+			continue
+		}
+		checked[key] = true
+		if err := checkKeyLimits(tx.Tx, key, limits); err != nil {
+			return err
+		}
+	}
+
+	if grows && limits.MaxFileSize > 0 {
+		stats := tx.DB().Stats()
+		free := int64(stats.FreePageN+stats.PendingPageN) * int64(tx.DB().Info().PageSize)
+		if used := tx.Size() - free; used >= limits.MaxFileSize {
+			return fmt.Errorf("%w: %d bytes in use, limit is %d", ErrQuotaExceeded, used, limits.MaxFileSize)
+		}
+	}
+	return nil
+}
+
+// checkValueSizes returns ErrValueTooLarge if tx stores a value larger than allowed.
+func (db *DB) checkValueSizes(tx *txn) error {
+	limit := db.maxValueSize()
+	if limit == 0 {
+		return nil
+	}
+	for _, ev := range tx.events {
+		switch {
+		case ev.Type == EventHset && len(ev.Value) > limit:
+			return fmt.Errorf("%w: value of %s.%s is %d bytes, limit is %d", ErrValueTooLarge, ev.Key, ev.Field, len(ev.Value), limit)
+		case ev.Type == EventZadd && len(ev.Field) > limit:
+			return fmt.Errorf("%w: member of %s is %d bytes, limit is %d", ErrValueTooLarge, ev.Key, len(ev.Field), limit)
+		}
+	}
+	return nil
+}
+
+// checkKeyLimits returns ErrQuotaExceeded if key holds more entries than its limit allows.
+func checkKeyLimits(tx *bbolt.Tx, key string, limits Limits) error {
+	bucket := tx.Bucket([]byte(key))
+	if bucket == nil {
+		return nil
+	}
+	limit, kind := limits.MaxHashFields, "fields"
+	if keyType(tx, []byte(key)) == typeZset {
+		bucket = membersBucket(tx, key)
+		limit, kind = limits.MaxZsetMembers, "members"
+	}
+	if limit <= 0 {
+		return nil
+	}
+	// Count only up to the limit; bucket statistics would not include this transaction
+	n := 0
+	c := bucket.Cursor()
+	for k, _ := c.First(); k != nil; k, _ = c.Next() {
+		if n++; n > limit {
+			return fmt.Errorf("%w: %s has more than %d %s", ErrQuotaExceeded, key, limit, kind)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package jungledb
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// TestLimits tests that writes breaking per-key limits are rejected and Usage reports sizes.
+func TestLimits(t *testing.T) {
+	db, err := Open("testdata/limits.db", WithLimits(Limits{MaxHashFields: 2, MaxZsetMembers: 2, MaxValueSize: 8}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hmset("hash", map[string][]byte{"a": []byte("1"), "b": []byte("2")}); err != nil {
+		t.Fatalf("Hmset within limits failed: %v", err)
+	}
+	if err := db.Hset("hash", "a", []byte("updated")); err != nil {
+		t.Errorf("overwriting a field at the limit: %v", err)
+	}
+	if err := db.Hset("hash", "c", []byte("3")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("third field: expected ErrQuotaExceeded, got %v", err)
+	}
+	if exists, _ := db.HhasKey("hash", "c"); exists {
+		t.Errorf("rejected field was stored")
+	}
+	if err := db.Hset("other", "f", []byte("too long value")); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("oversized value: expected ErrQuotaExceeded, got %v", err)
+	}
+
+	for i, member := range []string{"x", "y"} {
+		if err := db.Zadd("zset", float64(i), member); err != nil {
+			t.Fatalf("Zadd within limits failed: %v", err)
+		}
+	}
+	if err := db.Zadd("zset", 3, "z"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("third member: expected ErrQuotaExceeded, got %v", err)
+	}
+	if err := db.Zadd("zset2", 1, strings.Repeat("m", 9)); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("oversized member: expected ErrQuotaExceeded, got %v", err)
+	}
+
+	// Deleting always works and frees room
+	if err := db.Hdel("hash", "b"); err != nil {
+		t.Fatalf("Hdel failed: %v", err)
+	}
+	if err := db.Hset("hash", "c", []byte("3")); err != nil {
+		t.Errorf("Hset after freeing room: %v", err)
+	}
+
+	usage, err := db.Usage("hash")
+	if err != nil {
+		t.Fatalf("Usage failed: %v", err)
+	}
+	if want := (Usage{Type: typeHash, Entries: 2, Bytes: int64(len("a") + len("updated") + len("c") + len("3"))}); usage != want {
+		t.Errorf("Usage(hash) = %+v, expected %+v", usage, want)
+	}
+	if usage, err := db.Usage("zset"); err != nil || usage.Type != typeZset || usage.Entries != 2 || usage.Bytes != 2*(1+8) {
+		t.Errorf("Usage(zset) = %+v, %v", usage, err)
+	}
+	if _, err := db.Usage("missing"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("Usage(missing): expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+// TestMaxFileSize tests that a full database rejects new data but still allows deletes.
+func TestMaxFileSize(t *testing.T) {
+	db, err := Open("testdata/limits_file.db", WithLimits(Limits{MaxFileSize: 256 << 10}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	value := []byte(strings.Repeat("v", 1024))
+	var i int
+	for i = 0; i < 1000; i++ {
+		if err = db.Hset("big", fmt.Sprintf("f%04d", i), value); err != nil {
+			break
+		}
+	}
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("filling the file: expected ErrQuotaExceeded, got %v after %d fields", err, i)
+	}
+	if err := db.HdelBucket("big"); err != nil {
+		t.Errorf("HdelBucket on a full database: %v", err)
+	}
+	if err := db.Hset("small", "f", value); err != nil {
+		t.Errorf("Hset after freeing space: %v", err)
+	}
+}
+
+// TestMaxValueSize tests that values over the maximum size fail with ErrValueTooLarge,
+// and that LargestValue reports the largest value.
+func TestMaxValueSize(t *testing.T) {
+	db, err := Open("testdata/maxvalue.db", WithMaxValueSize(16))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("h", "small", []byte("0123456789abcdef")); err != nil {
+		t.Fatalf("Hset at the limit failed: %v", err)
+	}
+	if err := db.Hset("h", "large", []byte("0123456789abcdefg")); !errors.Is(err, ErrValueTooLarge) || !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("oversized value: expected ErrValueTooLarge, got %v", err)
+	}
+	if err := db.Zadd("z", 1, strings.Repeat("m", 17)); !errors.Is(err, ErrValueTooLarge) {
+		t.Errorf("oversized member: expected ErrValueTooLarge, got %v", err)
+	}
+	if exists, _ := db.HhasKey("h", "large"); exists {
+		t.Errorf("rejected value was stored")
+	}
+	if err := db.Hset("h2", "tiny", []byte("x")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	largest, err := db.LargestValue()
+	if err != nil {
+		t.Fatalf("LargestValue failed: %v", err)
+	}
+	if largest != (ValueSize{Size: 16, Key: "h", Field: "small"}) {
+		t.Errorf("expected the largest value to be h.small of 16 bytes, got %+v", largest)
+	}
+	db.Close()
+
+	// The default applies without the option, and a negative size lifts it
+	big := make([]byte, DefaultMaxValueSize+1)
+	for _, opts := range [][]Option{nil, {WithMaxValueSize(-1)}} {
+		db, err := Open("testdata/maxvalue.db", opts...)
+		if err != nil {
+			t.Fatalf("failed to open database: %v", err)
+		}
+		err = db.Hset("h", "big", big)
+		db.Close()
+		if opts == nil && !errors.Is(err, ErrValueTooLarge) {
+			t.Errorf("value over the default: expected ErrValueTooLarge, got %v", err)
+		} else if opts != nil && err != nil {
+			t.Errorf("value without a limit: %v", err)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package jungledb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+)
+
+// Allow reports whether one more request may be made under the rate limit key, which
+// allows limit requests in any window, and if so records it. remaining is how many
+// more requests the window allows after this one. The limit is a sliding window log:
+// key is a sorted set of the allowed requests scored by their time (Unix nanoseconds),
+// from which requests older than window are dropped, so it survives restarts and is
+// shared by every process using the database. It expires window after its latest
+// request. Allow returns ErrWrongType if key holds a hash.
+func (db *DB) Allow(key string, limit int, window time.Duration) (allowed bool, remaining int, err error) {
+	if limit <= 0 || window <= 0 {
+		return false, 0, fmt.Errorf("invalid rate limit %d per %v", limit, window)
+	}
+	if err := checkKey(key); err != nil {
+		return false, 0, err
+	}
+	key = db.nsKey(key)
+	err = db.update("Allow", key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeZset); err != nil {
+			return err
+		}
+		now := db.now()
+		if tx.Bucket([]byte(key)) != nil && db.liveBucket(tx.Tx, key) == nil {
+			if err := expireKey(tx, key); err != nil { // Elapsed, not swept yet
+				return err
+			}
+		}
+
+		// Drop requests that left the window and count the rest, up to the limit
+		var old []string
+		count := 0
+		cutoff := float64(now.Add(-window).UnixNano())
+		if bucket := tx.Bucket([]byte(key)); bucket != nil {
+			c := bucket.Cursor()
+			for k, _ := c.First(); k != nil && count < limit; k, _ = c.Next() {
+				if math.Float64frombits(binary.BigEndian.Uint64(k[:8])) <= cutoff {
+					old = append(old, string(k[8:]))
+				} else {
+					count++
+				}
+			}
+		}
+		for _, member := range old {
+			if err := zrem(tx, key, member); err != nil {
+				return err
+			}
+		}
+		if count >= limit {
+			return nil
+		}
+
+		member := strconv.FormatInt(now.UnixNano(), 10) + ":" + strconv.Itoa(count)
+		if err := zadd(tx, key, float64(now.UnixNano()), member); err != nil {
+			return err
+		}
+		allowed, remaining = true, limit-count-1
+		return setExpiry(tx, key, now.Add(window).UnixNano())
+	})
+	if err != nil {
+		return false, 0, err
+	}
+	return allowed, remaining, nil
+}
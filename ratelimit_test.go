@@ -0,0 +1,57 @@
+package jungledb
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestAllow tests that Allow admits limit requests per sliding window, and that its state
+// survives reopening the database.
+func TestAllow(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	db, err := Open("testdata/ratelimit.db", WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer func() { db.Close() }()
+
+	const window = 200 * time.Millisecond
+	for i, want := range []int{2, 1, 0} {
+		allowed, remaining, err := db.Allow("rl:api", 3, window)
+		if err != nil || !allowed || remaining != want {
+			t.Errorf("request %d: expected allowed with %d remaining, got %v %d %v", i, want, allowed, remaining, err)
+		}
+	}
+	if allowed, remaining, err := db.Allow("rl:api", 3, window); err != nil || allowed || remaining != 0 {
+		t.Errorf("request over the limit: expected refusal, got %v %d %v", allowed, remaining, err)
+	}
+	if n, err := db.Zcard("rl:api"); err != nil || n != 3 {
+		t.Errorf("refused requests should not be recorded: Zcard %d %v", n, err)
+	}
+
+	// The limit holds across restarts
+	db.Close()
+	if db, err = Open("testdata/ratelimit.db", WithClock(clock)); err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	if allowed, _, err := db.Allow("rl:api", 3, window); err != nil || allowed {
+		t.Errorf("request over the limit after reopen: expected refusal, got %v %v", allowed, err)
+	}
+
+	// Requests leave the window as it slides
+	clock.advance(window)
+	if allowed, remaining, err := db.Allow("rl:api", 3, window); err != nil || !allowed || remaining != 2 {
+		t.Errorf("request after the window: expected allowed with 2 remaining, got %v %d %v", allowed, remaining, err)
+	}
+
+	if err := db.Hset("rl:hash", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if _, _, err := db.Allow("rl:hash", 3, window); !errors.Is(err, ErrWrongType) {
+		t.Errorf("Allow on a hash: expected ErrWrongType, got %v", err)
+	}
+	if _, _, err := db.Allow("rl:api", 0, window); err == nil {
+		t.Error("Allow with a zero limit should fail")
+	}
+}
@@ -0,0 +1,75 @@
+package jungledb
+
+import (
+	"bytes"
+
+	"go.etcd.io/bbolt"
+)
+
+// Raw gives zero-copy access to the data of a database, for exporters and other bulk
+// readers that cannot afford to copy every value. Its visitors are passed slices of the
+// memory-mapped file, valid only until the visitor returns: a caller that retains one
+// past that must copy it, or ask for a copy with RawFlags. Writing to them, or keeping
+// them, crashes the process or corrupts what it reads. Obtain a Raw with DB.Raw.
+type Raw struct {
+	db *DB
+}
+
+// RawFlags asks Raw visitors for copies of what they retain.
+type RawFlags uint8
+
+const (
+	// RawCopyFields passes copies of field names, safe to keep after the visitor returns.
+	RawCopyFields RawFlags = 1 << iota
+	// RawCopyValues passes copies of values, safe to keep after the visitor returns.
+	RawCopyValues
+)
+
+// Raw returns the zero-copy API of db. Read its contract before using it.
+func (db *DB) Raw() Raw {
+	return Raw{db: db}
+}
+
+// Hscan calls visit for each field of a hash in order, in a single read transaction.
+// field and value are only valid during the call unless flags asks for copies. An
+// error from visit stops the scan and is returned. A missing hash is not visited.
+func (r Raw) Hscan(key string, flags RawFlags, visit func(field, value []byte) error) error {
+	return r.scan("RawHscan", key, nil, flags, visit)
+}
+
+// Hprefix is like Hscan but only visits the fields starting with prefix.
+func (r Raw) Hprefix(key, prefix string, flags RawFlags, visit func(field, value []byte) error) error {
+	return r.scan("RawHprefix", key, []byte(prefix), flags, visit)
+}
+
+// scan visits the fields of a hash starting with prefix.
+func (r Raw) scan(op, key string, prefix []byte, flags RawFlags, visit func(field, value []byte) error) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = r.db.nsKey(key)
+	n := 0
+	err := r.db.view(op, key, func(tx *bbolt.Tx) error {
+		bucket, err := r.db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err
+		}
+
+		cursor := bucket.Cursor()
+		for k, v := cursor.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, v = cursor.Next() {
+			if flags&RawCopyFields != 0 {
+				k = bytes.Clone(k)
+			}
+			if flags&RawCopyValues != 0 {
+				v = bytes.Clone(v)
+			}
+			n += len(v)
+			if err := visit(k, v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	r.db.metrics.addRead(n)
+	return err
+}
@@ -0,0 +1,63 @@
+package jungledb
+
+import (
+	"errors"
+	"testing"
+)
+
+// TestRaw tests zero-copy scans, their copy flags and stopping them early.
+func TestRaw(t *testing.T) {
+	db, err := Open("testdata/raw.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	data := map[string][]byte{"a:1": []byte("x"), "a:2": []byte("y"), "b:1": []byte("z")}
+	if err := db.Hmset("user:1", data); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+
+	var fields []string
+	err = db.Raw().Hscan("user:1", 0, func(field, value []byte) error {
+		if string(value) != string(data[string(field)]) {
+			t.Errorf("unexpected value for %s: %q", field, value)
+		}
+		fields = append(fields, string(field))
+		return nil
+	})
+	if err != nil || len(fields) != 3 || fields[0] != "a:1" || fields[2] != "b:1" {
+		t.Errorf("expected all fields in order, got %v %v", fields, err)
+	}
+
+	kept := make(map[string][]byte)
+	err = db.Raw().Hprefix("user:1", "a:", RawCopyFields|RawCopyValues, func(field, value []byte) error {
+		kept[string(field)] = value
+		return nil
+	})
+	if err != nil || !equalByteMap(kept, map[string][]byte{"a:1": []byte("x"), "a:2": []byte("y")}) {
+		t.Errorf("expected the prefixed fields, got %v %v", kept, err)
+	}
+
+	errStop := errors.New("stop")
+	visited := 0
+	err = db.Raw().Hscan("user:1", 0, func(field, value []byte) error {
+		visited++
+		return errStop
+	})
+	if !errors.Is(err, errStop) || visited != 1 {
+		t.Errorf("expected the visitor's error to stop the scan, got %v after %d fields", err, visited)
+	}
+
+	if err := db.Raw().Hscan("missing", 0, func(field, value []byte) error {
+		t.Errorf("unexpected field %s", field)
+		return nil
+	}); err != nil {
+		t.Errorf("Hscan of a missing hash failed: %v", err)
+	}
+	if err := db.Zadd("ranking", 1, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Raw().Hscan("ranking", 0, func(field, value []byte) error { return nil }); !errors.Is(err, ErrWrongType) {
+		t.Errorf("expected ErrWrongType, got %v", err)
+	}
+}
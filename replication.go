@@ -0,0 +1,475 @@
+package jungledb
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// replicationBucket holds replica state, currently the last applied primary sequence.
+	replicationBucket = internalPrefix + "replication"
+
+	// replicaBatchSize bounds how many streamed events a replica applies per transaction.
+	replicaBatchSize = 256
+
+	// replicaPollInterval is how often a primary re-checks the log when no writes signal it.
+	replicaPollInterval = time.Second
+)
+
+// Replication message types.
+const (
+	replSnapshot    = "snapshot"     // Start of a full snapshot taken at Seq
+	replRecord      = "record"       // One key of the snapshot
+	replSnapshotEnd = "snapshot_end" // Snapshot complete, streaming follows
+	replEvent       = "event"        // One logged mutation
+	replError       = "error"        // The primary refused the replica
+)
+
+// ErrReplicationGap is returned by a replica that receives an event out of sequence.
+var ErrReplicationGap = errors.New("replication gap")
+
+// replHello is sent by a replica when it connects: the first primary sequence it needs,
+// and its token if the primary has an ACL.
+type replHello struct {
+	From  uint64 `json:"from"`
+	Token string `json:"token,omitempty"`
+}
+
+// replMessage is one line of the primary -> replica stream.
+type replMessage struct {
+	Type   string        `json:"type"`
+	Seq    uint64        `json:"seq,omitempty"`
+	Record *exportRecord `json:"record,omitempty"`
+	Event  *Event        `json:"event,omitempty"`
+	Error  string        `json:"error,omitempty"`
+}
+
+// ServeReplication accepts replica connections on ln and streams the operation log to them.
+// A replica that is new, or that has fallen behind a truncated log, first receives a full
+// snapshot. With WithACL, replicas must present the token of a user with read access to
+// every namespace (see WithReplicaAuth). ServeReplication requires WithOpLog and returns
+// when ln is closed.
+func (db *DB) ServeReplication(ln net.Listener) error {
+	if !db.opts.opLog {
+		return errors.New("operation log is not enabled")
+	}
+
+	return db.serve(ln, func(conn net.Conn) {
+		addr := conn.RemoteAddr().String()
+		db.log.Info("replica connected", "addr", addr)
+		if err := db.serveReplica(conn); err != nil {
+			db.log.Warn("replica disconnected", "addr", addr, "error", err)
+			return
+		}
+		db.log.Info("replica disconnected", "addr", addr)
+	})
+}
+
+// serveReplica streams to a single replica until the connection fails.
+func (db *DB) serveReplica(conn net.Conn) error {
+	var hello replHello
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(line, &hello); err != nil {
+		return fmt.Errorf("invalid replica handshake: %v", err)
+	}
+	if acl := db.opts.acl; acl != nil {
+		if u, ok := acl.user(hello.Token); !ok || !u.readsAll() {
+			db.authFailed("authentication failed", "reason", "replica refused", "addr", conn.RemoteAddr().String())
+			json.NewEncoder(conn).Encode(replMessage{Type: replError, Error: ErrUnauthenticated.Error()})
+			return ErrUnauthenticated
+		}
+	}
+
+	// Subscribe before reading the log so no write slips between replay and wait
+	wake, cancel := db.Watch("")
+	defer func() { cancel() }()
+
+	bw := bufio.NewWriter(conn)
+	enc := json.NewEncoder(bw)
+
+	next := hello.From
+	if next == 0 {
+		if next, err = db.sendSnapshot(enc); err != nil {
+			return err
+		}
+	}
+
+	// Stop the stream when the replica hangs up
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(closed)
+	}()
+
+	ticker := time.NewTicker(replicaPollInterval)
+	defer ticker.Stop()
+	for {
+		err := db.ReplayFrom(next, func(ev Event) error {
+			next = ev.Seq + 1
+			return enc.Encode(replMessage{Type: replEvent, Event: &ev})
+		})
+		if errors.Is(err, ErrOpLogTruncated) {
+			if next, err = db.sendSnapshot(enc); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+
+		select {
+		case ev, ok := <-wake:
+			if !ok {
+				return errors.New("database closed")
+			}
+			if ev.Type == EventOverflow {
+				// Events only wake the stream, which reads the log itself
+				cancel()
+				wake, cancel = db.Watch("")
+			}
+		case <-ticker.C:
+		case <-closed:
+			return nil
+		}
+	}
+}
+
+// sendSnapshot writes a consistent snapshot and returns the first sequence to stream after it.
+func (db *DB) sendSnapshot(enc *json.Encoder) (uint64, error) {
+	var seq uint64
+	err := db.view("ServeReplication", "", func(tx *bbolt.Tx) error {
+		if bucket := tx.Bucket([]byte(opLogBucket)); bucket != nil {
+			seq = bucket.Sequence()
+		}
+		if err := enc.Encode(replMessage{Type: replSnapshot, Seq: seq}); err != nil {
+			return err
+		}
+
+		err := tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+			if isInternalBucket(tx, name) {
+				return nil
+			}
+			rec := newExportRecord(tx, name, b)
+			return enc.Encode(replMessage{Type: replRecord, Record: &rec})
+		})
+		if err != nil {
+			return err
+		}
+		return enc.Encode(replMessage{Type: replSnapshotEnd, Seq: seq})
+	})
+	return seq + 1, err
+}
+
+// Replicate makes this database a replica of the primary serving replication at addr,
+// presenting the credentials set with WithReplicaAuth.
+// It loads a snapshot if needed, then applies the primary's mutations as they stream in,
+// reconnecting with backoff whenever the connection drops. Events must arrive in sequence;
+// a gap aborts the connection and the replica re-syncs from its last applied sequence.
+// Replicate blocks until ctx is cancelled and then returns ctx.Err().
+// Local writes to a replica are not prevented but may be overwritten by the primary.
+func (db *DB) Replicate(ctx context.Context, addr string) error {
+	const minBackoff, maxBackoff = 100 * time.Millisecond, 5 * time.Second
+
+	backoff := minBackoff
+	for {
+		started := time.Now()
+		err := db.replicateOnce(ctx, addr)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if time.Since(started) > maxBackoff {
+			backoff = minBackoff // The session was healthy for a while, reconnect quickly
+		}
+		db.log.Warn("replication session ended", "primary", addr, "error", err, "retry_in", backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		if backoff < maxBackoff {
+			backoff *= 2
+		}
+	}
+}
+
+// AppliedSeq returns the last primary sequence this replica has applied, or 0 if none.
+func (db *DB) AppliedSeq() (uint64, error) {
+	var seq uint64
+	err := db.view("AppliedSeq", "", func(tx *bbolt.Tx) error {
+		seq, _ = appliedSeq(tx)
+		return nil
+	})
+	return seq, err
+}
+
+// UpdateWithToken is like Update but also returns a consistency token: the operation log
+// sequence of the last mutation of the transaction, or the last sequence of the log if it
+// made none. Passing the token to ReadAtLeast on a replica waits until the replica has
+// applied the transaction, so that a client can read its own writes from a replica.
+// It requires WithOpLog; without it the token is always 0. LastSeq, called after a write
+// returns, gives a token that is as good but may wait for later writes too.
+func (db *DB) UpdateWithToken(fn func(tx *Tx) error) (uint64, error) {
+	var last *txn
+	err := db.update("Update", "", func(tx *txn) error {
+		last = tx
+		return fn(&Tx{db: db, tx: tx})
+	})
+	if err != nil {
+		return 0, err
+	}
+	if n := len(last.events); n > 0 {
+		return last.events[n-1].Seq, nil // Set by appendOpLog
+	}
+	return db.LastSeq()
+}
+
+// ReadAtLeast waits until this replica has applied the primary's mutations up to the
+// sequence token, returned by UpdateWithToken or LastSeq on the primary, so that reads
+// that follow see them. It returns ctx.Err() if ctx ends first and ErrClosed if the
+// database is closed meanwhile.
+func (db *DB) ReadAtLeast(ctx context.Context, token uint64) error {
+	for {
+		changed := db.appliedSeqs.wait() // Before reading, not to miss a change
+		seq, err := db.AppliedSeq()
+		if err != nil {
+			return err
+		}
+		if seq >= token {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// seqNotifier wakes up the callers of ReadAtLeast when the applied sequence changes.
+type seqNotifier struct {
+	mu sync.Mutex
+	ch chan struct{}
+}
+
+// wait returns a channel closed at the next call to notify.
+func (n *seqNotifier) wait() <-chan struct{} {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch == nil {
+		n.ch = make(chan struct{})
+	}
+	return n.ch
+}
+
+// notify wakes up the waiters.
+func (n *seqNotifier) notify() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ch != nil {
+		close(n.ch)
+		n.ch = nil
+	}
+}
+
+// appliedSeq reads the replica's last applied primary sequence. synced is false until
+// the replica has loaded a snapshot, in which case it needs one before streaming.
+func appliedSeq(tx *bbolt.Tx) (seq uint64, synced bool) {
+	bucket := tx.Bucket([]byte(replicationBucket))
+	if bucket == nil {
+		return 0, false
+	}
+	v := bucket.Get([]byte("applied"))
+	if len(v) != 8 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint64(v), true
+}
+
+// setAppliedSeq stores the replica's last applied primary sequence.
+func setAppliedSeq(tx *txn, seq uint64) error {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(replicationBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create replication bucket: %v", err)
+	}
+	return bucket.Put([]byte("applied"), encodeSeq(seq))
+}
+
+// clearAppliedSeq forgets the replica's applied sequence, so that it needs a snapshot again.
+func clearAppliedSeq(tx *txn) error {
+	if bucket := tx.Bucket([]byte(replicationBucket)); bucket != nil {
+		return bucket.Delete([]byte("applied"))
+	}
+	return nil
+}
+
+// replicateOnce runs a single replication session until the connection fails or ctx ends.
+func (db *DB) replicateOnce(ctx context.Context, addr string) error {
+	var conn net.Conn
+	var err error
+	if cfg := db.opts.replicaTLS; cfg != nil {
+		d := tls.Dialer{Config: cfg}
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	} else {
+		var d net.Dialer
+		conn, err = d.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	from := uint64(0) // Asks the primary for a snapshot
+	err = db.view("Replicate", "", func(tx *bbolt.Tx) error {
+		if applied, synced := appliedSeq(tx); synced {
+			from = applied + 1
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(conn).Encode(replHello{From: from, Token: db.opts.replicaToken}); err != nil {
+		return err
+	}
+
+	br := bufio.NewReader(conn)
+	read := func() (replMessage, error) {
+		var msg replMessage
+		line, err := br.ReadBytes('\n')
+		if err != nil {
+			return msg, err
+		}
+		return msg, json.Unmarshal(line, &msg)
+	}
+
+	expected := from
+	for {
+		msg, err := read()
+		if err != nil {
+			return err
+		}
+
+		if msg.Type == replError {
+			return fmt.Errorf("primary refused replication: %s", msg.Error)
+		}
+		if msg.Type == replSnapshot {
+			if expected, err = db.loadSnapshot(read); err != nil {
+				return err
+			}
+			continue
+		}
+		if msg.Type != replEvent || msg.Event == nil {
+			return fmt.Errorf("unexpected replication message %q", msg.Type)
+		}
+
+		// Gather whatever else has already arrived into one transaction
+		batch := []Event{*msg.Event}
+		for br.Buffered() > 0 && len(batch) < replicaBatchSize {
+			if msg, err = read(); err != nil {
+				return err
+			}
+			if msg.Type != replEvent || msg.Event == nil {
+				return fmt.Errorf("unexpected replication message %q", msg.Type)
+			}
+			batch = append(batch, *msg.Event)
+		}
+
+		err = db.update("Replicate", "", func(tx *txn) error {
+			for _, ev := range batch {
+				if expected != 0 && ev.Seq != expected {
+					return fmt.Errorf("%w: expected sequence %d, got %d", ErrReplicationGap, expected, ev.Seq)
+				}
+				if err := applyEvent(tx, ev); err != nil {
+					return err
+				}
+				expected = ev.Seq + 1
+			}
+			return setAppliedSeq(tx, expected-1)
+		})
+		if err != nil {
+			return err
+		}
+		db.appliedSeqs.notify()
+	}
+}
+
+// loadSnapshot replaces the local data with a snapshot streamed by the primary and
+// returns the first sequence expected after it.
+func (db *DB) loadSnapshot(read func() (replMessage, error)) (uint64, error) {
+	// Forget the applied sequence first, as FlushAll keeps it, so that an interrupted load
+	// asks for a snapshot again instead of streaming on top of partial data. It is stored
+	// again once the whole snapshot is loaded.
+	if err := db.update("Replicate", "", clearAppliedSeq); err != nil {
+		return 0, err
+	}
+	if err := db.FlushAll(); err != nil {
+		return 0, err
+	}
+
+	batch := make([]exportRecord, 0, importBatchSize)
+	flush := func() error {
+		err := db.update("Replicate", "", func(tx *txn) error {
+			for _, rec := range batch {
+				if err := importRecord(tx, rec); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		batch = batch[:0]
+		return err
+	}
+
+	for {
+		msg, err := read()
+		if err != nil {
+			return 0, err
+		}
+
+		switch msg.Type {
+		case replRecord:
+			if msg.Record == nil {
+				return 0, errors.New("snapshot record without payload")
+			}
+			batch = append(batch, *msg.Record)
+			if len(batch) == importBatchSize {
+				if err := flush(); err != nil {
+					return 0, err
+				}
+			}
+		case replSnapshotEnd:
+			if err := flush(); err != nil {
+				return 0, err
+			}
+			err := db.update("Replicate", "", func(tx *txn) error {
+				return setAppliedSeq(tx, msg.Seq)
+			})
+			db.appliedSeqs.notify()
+			return msg.Seq + 1, err
+		default:
+			return 0, fmt.Errorf("unexpected replication message %q during snapshot", msg.Type)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package jungledb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// waitFor polls cond until it holds or fails the test after a timeout.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// TestReplication tests snapshot transfer, live streaming and resuming a replica.
+func TestReplication(t *testing.T) {
+	primary, err := Open("testdata/repl_primary.db", WithOpLog())
+	if err != nil {
+		t.Fatalf("failed to open primary: %v", err)
+	}
+	defer primary.Close()
+
+	replica, err := Open("testdata/repl_replica.db")
+	if err != nil {
+		t.Fatalf("failed to open replica: %v", err)
+	}
+	defer replica.Close()
+
+	// Data that exists before the replica connects arrives through the snapshot
+	if err := primary.Hset("config", "mode", []byte("blue")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := primary.Zadd("ranking", 1, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := primary.TruncateOpLog(2); err != nil {
+		t.Fatalf("TruncateOpLog failed: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go primary.ServeReplication(ln)
+
+	start := func() context.CancelFunc {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			replica.Replicate(ctx, ln.Addr().String())
+			close(done)
+		}()
+		return func() {
+			cancel()
+			<-done
+		}
+	}
+	stop := start()
+
+	hget := func(key, field string) string {
+		v, err := replica.Hget(key, field)
+		if err != nil {
+			t.Fatalf("Hget failed: %v", err)
+		}
+		return string(v)
+	}
+
+	waitFor(t, "snapshot", func() bool { return hget("config", "mode") == "blue" })
+	if score, _ := replica.Zscore("ranking", "a"); score != 1 {
+		t.Errorf("snapshot zset mismatch: expected score 1, got %f", score)
+	}
+
+	// Live streaming
+	if err := primary.Hset("config", "mode", []byte("green")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := primary.Zadd("ranking", 2, "b"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	waitFor(t, "streamed zadd", func() bool {
+		score, _ := replica.Zscore("ranking", "b")
+		return score == 2
+	})
+	if hget("config", "mode") != "green" {
+		t.Errorf("streamed Hset not applied")
+	}
+
+	last, err := primary.LastSeq()
+	if err != nil {
+		t.Fatalf("LastSeq failed: %v", err)
+	}
+	applied, err := replica.AppliedSeq()
+	if err != nil {
+		t.Fatalf("AppliedSeq failed: %v", err)
+	}
+	if applied != last {
+		t.Errorf("applied sequence mismatch: expected %d, got %d", last, applied)
+	}
+
+	// Writes made while the replica is down are streamed after it reconnects
+	stop()
+	if err := primary.Hdel("config", "mode"); err != nil {
+		t.Fatalf("Hdel failed: %v", err)
+	}
+	if err := primary.Hset("config", "region", []byte("eu")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	stop = start()
+	defer stop()
+
+	waitFor(t, "catch-up after reconnect", func() bool { return hget("config", "region") == "eu" })
+	if hget("config", "mode") != "" {
+		t.Error("Hdel made while disconnected was not replicated")
+	}
+}
+
+// TestReplicationACL tests that a primary with an ACL refuses replicas without a token
+// granting read access to every namespace.
+func TestReplicationACL(t *testing.T) {
+	acl, err := NewACL(
+		ACLUser{Name: "replica", Token: "r3pl", Namespaces: map[string]Access{"": AccessRead, "*": AccessRead}},
+		ACLUser{Name: "partial", Token: "part", Namespaces: map[string]Access{"": AccessReadWrite}},
+	)
+	if err != nil {
+		t.Fatalf("NewACL failed: %v", err)
+	}
+	primary, err := Open("testdata/repl_acl_primary.db", WithOpLog(), WithACL(acl))
+	if err != nil {
+		t.Fatalf("failed to open primary: %v", err)
+	}
+	defer primary.Close()
+	if err := primary.Hset("config", "mode", []byte("blue")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go primary.ServeReplication(ln)
+
+	for i, token := range []string{"", "part", "r3pl"} {
+		replica, err := Open(fmt.Sprintf("testdata/repl_acl_replica%d.db", i), WithReplicaAuth(token, nil))
+		if err != nil {
+			t.Fatalf("failed to open replica: %v", err)
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		err = replica.replicateOnce(ctx, ln.Addr().String())
+		cancel()
+		value, _ := replica.Hget("config", "mode")
+		replica.Close()
+
+		if token == "r3pl" {
+			if string(value) != "blue" {
+				t.Errorf("replica with token %q: expected the snapshot, got %q (%v)", token, value, err)
+			}
+			continue
+		}
+		if err == nil || !strings.Contains(err.Error(), "refused") || value != nil {
+			t.Errorf("replica with token %q: expected refusal, got %v", token, err)
+		}
+	}
+	if n := primary.Metrics().AuthFailures; n != 2 {
+		t.Errorf("expected 2 authentication failures, got %d", n)
+	}
+}
+
+// TestReadAtLeast tests that a replica waits for the writes of a consistency token.
+func TestReadAtLeast(t *testing.T) {
+	primary, err := Open("testdata/read_at_least_primary.db", WithOpLog())
+	if err != nil {
+		t.Fatalf("failed to open primary: %v", err)
+	}
+	defer primary.Close()
+	replica, err := Open("testdata/read_at_least_replica.db")
+	if err != nil {
+		t.Fatalf("failed to open replica: %v", err)
+	}
+	defer replica.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go primary.ServeReplication(ln)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go replica.Replicate(ctx, ln.Addr().String())
+
+	for i := 0; i < 20; i++ {
+		value := []byte(fmt.Sprint(i))
+		token, err := primary.UpdateWithToken(func(tx *Tx) error {
+			return tx.Hset("counter", "n", value)
+		})
+		if err != nil {
+			t.Fatalf("UpdateWithToken failed: %v", err)
+		}
+		wait, cancelWait := context.WithTimeout(ctx, 5*time.Second)
+		err = replica.ReadAtLeast(wait, token)
+		cancelWait()
+		if err != nil {
+			t.Fatalf("ReadAtLeast failed: %v", err)
+		}
+		if v, err := replica.Hget("counter", "n"); err != nil || string(v) != string(value) {
+			t.Fatalf("expected to read %s from the replica, got %q %v", value, v, err)
+		}
+	}
+
+	last, err := primary.LastSeq()
+	if err != nil {
+		t.Fatalf("LastSeq failed: %v", err)
+	}
+	wait, cancelWait := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancelWait()
+	if err := replica.ReadAtLeast(wait, last+1); err != context.DeadlineExceeded {
+		t.Errorf("expected a timeout waiting for a future token, got %v", err)
+	}
+}
+
+// TestReplicationInterruptedSnapshot tests that a snapshot load cut off midway leaves the
+// replica unsynced, so that it asks for a new snapshot rather than resuming the stream.
+func TestReplicationInterruptedSnapshot(t *testing.T) {
+	replica, err := Open("testdata/repl_interrupted.db")
+	if err != nil {
+		t.Fatalf("failed to open replica: %v", err)
+	}
+	defer replica.Close()
+
+	msgs := []replMessage{
+		{Type: replRecord, Record: &exportRecord{Key: "a", Type: typeHash, Fields: map[string][]byte{"f": []byte("1")}}},
+		{Type: replSnapshotEnd, Seq: 7},
+	}
+	read := func() (replMessage, error) {
+		msg := msgs[0]
+		msgs = msgs[1:]
+		return msg, nil
+	}
+	if next, err := replica.loadSnapshot(read); err != nil || next != 8 {
+		t.Fatalf("loadSnapshot: got %d, %v, want 8", next, err)
+	}
+
+	lost := errors.New("connection lost")
+	msgs = []replMessage{{Type: replRecord, Record: &exportRecord{Key: "b", Type: typeHash, Fields: map[string][]byte{"f": []byte("2")}}}}
+	read = func() (replMessage, error) {
+		if len(msgs) == 0 {
+			return replMessage{}, lost
+		}
+		msg := msgs[0]
+		msgs = msgs[1:]
+		return msg, nil
+	}
+	if _, err := replica.loadSnapshot(read); !errors.Is(err, lost) {
+		t.Fatalf("loadSnapshot: expected the read error, got %v", err)
+	}
+	err = replica.db.View(func(tx *bbolt.Tx) error {
+		if seq, synced := appliedSeq(tx); synced {
+			t.Errorf("replica still synced at sequence %d after an interrupted snapshot", seq)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("View failed: %v", err)
+	}
+}
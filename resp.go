@@ -0,0 +1,445 @@
+package jungledb
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// respCommandChunk bounds how many field/value or score/member pairs ExportRESP packs into one command.
+const respCommandChunk = 128
+
+// Limits on the values respReader accepts, so that a peer cannot make it allocate without
+// bound: lengths beyond them are protocol errors. Buffers grow as data arrives rather than
+// to the announced lengths, so announcing a large value costs nothing until it is sent.
+const (
+	respMaxBulk  = 512 << 20 // Bytes of a bulk string, as Redis allows
+	respMaxArray = 1 << 20   // Elements of an array
+	respMaxDepth = 16        // Arrays nested in arrays
+	respMaxLine  = 64 << 10  // Bytes of a line, such as an inline command
+	respPrealloc = 64 << 10  // Bytes or elements allocated before data arrives
+)
+
+// respError is an error reply ("-ERR ...") received over the Redis protocol.
+type respError string
+
+func (e respError) Error() string { return string(e) }
+
+// Is lets callers match WRONGTYPE replies with errors.Is(err, ErrWrongType).
+func (e respError) Is(target error) bool {
+	return target == ErrWrongType && strings.HasPrefix(string(e), "WRONGTYPE ")
+}
+
+// respReader decodes values in the Redis serialization protocol (RESP2).
+type respReader struct {
+	r *bufio.Reader
+}
+
+func newRESPReader(r io.Reader) *respReader {
+	if br, ok := r.(*bufio.Reader); ok {
+		return &respReader{r: br}
+	}
+	return &respReader{r: bufio.NewReader(r)}
+}
+
+// readLine reads a CRLF (or LF) terminated line without the terminator, of at most
+// respMaxLine bytes.
+func (r *respReader) readLine() ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.r.ReadSlice('\n')
+		if len(line)+len(chunk) > respMaxLine+2 {
+			return nil, fmt.Errorf("resp: line longer than %d bytes", respMaxLine)
+		}
+		line = append(line, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(line) > 0 {
+				return nil, io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		return bytes.TrimSuffix(line[:len(line)-1], []byte{'\r'}), nil
+	}
+}
+
+// readValue reads one RESP value. Simple strings decode to string, errors to respError,
+// integers to int64, bulk strings to []byte and arrays to []any. Null bulk strings and
+// null arrays decode to a nil []byte and a nil []any respectively.
+func (r *respReader) readValue() (any, error) {
+	return r.readNested(0)
+}
+
+// readNested reads one RESP value nested in depth arrays.
+func (r *respReader) readNested(depth int) (any, error) {
+	line, err := r.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errors.New("resp: empty line")
+	}
+
+	switch line[0] {
+	case '+':
+		return string(line[1:]), nil
+	case '-':
+		return respError(line[1:]), nil
+	case ':':
+		n, err := strconv.ParseInt(string(line[1:]), 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("resp: invalid integer %q", line[1:])
+		}
+		return n, nil
+	case '$':
+		return r.readBulk(line)
+	case '*':
+		n, err := strconv.Atoi(string(line[1:]))
+		if err != nil {
+			return nil, fmt.Errorf("resp: invalid array length %q", line[1:])
+		}
+		if n < 0 {
+			return []any(nil), nil
+		}
+		if n > respMaxArray {
+			return nil, fmt.Errorf("resp: array of %d elements exceeds the limit of %d", n, respMaxArray)
+		}
+		if depth >= respMaxDepth {
+			return nil, fmt.Errorf("resp: arrays nested deeper than %d", respMaxDepth)
+		}
+		values := make([]any, 0, min(n, respPrealloc))
+		for range n {
+			v, err := r.readNested(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+		return values, nil
+	default:
+		return nil, fmt.Errorf("resp: unexpected type byte %q", line[0])
+	}
+}
+
+// readBulk reads the payload of a bulk string whose "$<len>" header line has been consumed.
+func (r *respReader) readBulk(header []byte) ([]byte, error) {
+	n, err := strconv.Atoi(string(header[1:]))
+	if err != nil {
+		return nil, fmt.Errorf("resp: invalid bulk length %q", header[1:])
+	}
+	if n < 0 {
+		return nil, nil
+	}
+	if n > respMaxBulk {
+		return nil, fmt.Errorf("resp: bulk string of %d bytes exceeds the limit of %d", n, respMaxBulk)
+	}
+	buf := make([]byte, 0, min(n+2, respPrealloc))
+	for len(buf) < n+2 {
+		k := min(n+2-len(buf), max(len(buf), respPrealloc)) // Double as data arrives
+		buf = slices.Grow(buf, k)
+		if _, err := io.ReadFull(r.r, buf[len(buf):len(buf)+k]); err != nil {
+			if err == io.EOF {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		buf = buf[:len(buf)+k]
+	}
+	if buf[n] != '\r' || buf[n+1] != '\n' {
+		return nil, errors.New("resp: bulk string not terminated by CRLF")
+	}
+	return buf[:n], nil
+}
+
+// readCommand reads a command as sent by Redis clients: an array of bulk strings.
+// Inline commands (space separated words on one line) are accepted as well.
+func (r *respReader) readCommand() ([][]byte, error) {
+	for {
+		b, err := r.r.Peek(1)
+		if err != nil {
+			return nil, err
+		}
+
+		if b[0] != '*' {
+			line, err := r.readLine()
+			if err != nil {
+				return nil, err
+			}
+			args := bytes.Fields(line)
+			if len(args) == 0 {
+				continue // Blank line between inline commands
+			}
+			return args, nil
+		}
+
+		v, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		items, _ := v.([]any)
+		args := make([][]byte, len(items))
+		for i, item := range items {
+			arg, ok := item.([]byte)
+			if !ok {
+				return nil, errors.New("resp: command arguments must be bulk strings")
+			}
+			args[i] = arg
+		}
+		if len(args) == 0 {
+			continue
+		}
+		return args, nil
+	}
+}
+
+// respWriter encodes values in the Redis serialization protocol (RESP2).
+type respWriter struct {
+	w *bufio.Writer
+}
+
+func newRESPWriter(w io.Writer) *respWriter {
+	if bw, ok := w.(*bufio.Writer); ok {
+		return &respWriter{w: bw}
+	}
+	return &respWriter{w: bufio.NewWriter(w)}
+}
+
+func (w *respWriter) writeSimple(s string) {
+	w.w.WriteString("+" + s + "\r\n")
+}
+
+func (w *respWriter) writeError(msg string) {
+	w.w.WriteString("-" + strings.NewReplacer("\r", " ", "\n", " ").Replace(msg) + "\r\n")
+}
+
+func (w *respWriter) writeInt(n int64) {
+	w.w.WriteString(":" + strconv.FormatInt(n, 10) + "\r\n")
+}
+
+// writeBulk writes a bulk string; a nil slice is written as the null bulk string.
+func (w *respWriter) writeBulk(b []byte) {
+	if b == nil {
+		w.w.WriteString("$-1\r\n")
+		return
+	}
+	w.w.WriteString("$" + strconv.Itoa(len(b)) + "\r\n")
+	w.w.Write(b)
+	w.w.WriteString("\r\n")
+}
+
+func (w *respWriter) writeArrayHeader(n int) {
+	w.w.WriteString("*" + strconv.Itoa(n) + "\r\n")
+}
+
+// writeCommand writes a command as an array of bulk strings.
+func (w *respWriter) writeCommand(args ...[]byte) {
+	w.writeArrayHeader(len(args))
+	for _, arg := range args {
+		if arg == nil {
+			arg = []byte{}
+		}
+		w.writeBulk(arg)
+	}
+}
+
+func (w *respWriter) flush() error {
+	return w.w.Flush()
+}
+
+// formatScore formats a score the way Redis does, including "inf" and "-inf".
+func formatScore(score float64) string {
+	switch {
+	case math.IsInf(score, 1):
+		return "inf"
+	case math.IsInf(score, -1):
+		return "-inf"
+	}
+	return strconv.FormatFloat(score, 'g', -1, 64)
+}
+
+// parseScore parses a score as accepted by Redis, including "inf", "+inf" and "-inf".
+func parseScore(s string) (float64, error) {
+	switch strings.ToLower(s) {
+	case "inf", "+inf":
+		return math.Inf(1), nil
+	case "-inf":
+		return math.Inf(-1), nil
+	}
+	score, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, fmt.Errorf("value is not a valid float: %s", s)
+	}
+	return score, nil
+}
+
+// ExportRESP writes the database as a stream of Redis commands (HSET for hashes, ZADD for
+// sorted sets, PEXPIREAT for TTLs) encoded in the Redis protocol, suitable for
+// "redis-cli --pipe". Only keys matching opts.Pattern are written; expired keys are skipped.
+// Checkpoints work as for Export.
+func (db *DB) ExportRESP(w io.Writer, opts ExportOptions) error {
+	rw := newRESPWriter(w)
+
+	err := db.view("ExportRESP", opts.Pattern, func(tx *bbolt.Tx) error {
+		return db.exportKeys(tx, opts, rw.flush, func(name []byte, b *bbolt.Bucket) error {
+			cmd := "HSET"
+			if keyType(tx, name) == typeZset {
+				cmd = "ZADD"
+			}
+
+			args := [][]byte{[]byte(cmd), name}
+			emit := func() {
+				if len(args) > 2 {
+					rw.writeCommand(args...)
+					args = args[:2]
+				}
+			}
+
+			b.ForEach(func(k, v []byte) error {
+				if cmd == "ZADD" {
+					score := math.Float64frombits(binary.BigEndian.Uint64(k[:8]))
+					args = append(args, []byte(formatScore(score)), k[8:])
+				} else {
+					args = append(args, k, v)
+				}
+				if len(args)-2 == 2*respCommandChunk {
+					emit()
+				}
+				return nil
+			})
+			emit()
+
+			if at := expiry(tx, string(name)); at != 0 {
+				ms := strconv.FormatInt(time.Unix(0, at).UnixMilli(), 10)
+				rw.writeCommand([]byte("PEXPIREAT"), name, []byte(ms))
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return err
+	}
+
+	return rw.flush()
+}
+
+// ImportRESP loads a stream of Redis commands in the Redis protocol, such as one produced by
+// ExportRESP. HSET, HMSET, ZADD, DEL and PEXPIREAT are supported; any other command is an error.
+// It returns the number of commands applied. Commands are applied in batched transactions.
+func (db *DB) ImportRESP(r io.Reader) (int, error) {
+	rr := newRESPReader(r)
+	applied := 0
+	batch := make([][][]byte, 0, importBatchSize)
+
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		err := db.update("ImportRESP", "", func(tx *txn) error {
+			for _, args := range batch {
+				if err := applyRESPCommand(tx, args); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		applied += len(batch)
+		batch = batch[:0]
+		return nil
+	}
+
+	for {
+		args, err := rr.readCommand()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return applied, fmt.Errorf("failed to read command: %v", err)
+		}
+
+		batch = append(batch, args)
+		if len(batch) == importBatchSize {
+			if err := flush(); err != nil {
+				return applied, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return applied, err
+	}
+	return applied, nil
+}
+
+// applyRESPCommand applies a single imported Redis command inside a read-write transaction.
+func applyRESPCommand(tx *txn, args [][]byte) error {
+	name := strings.ToUpper(string(args[0]))
+	switch name {
+	case "HSET", "HMSET":
+		if len(args) < 4 || len(args)%2 != 0 {
+			return fmt.Errorf("wrong number of arguments for %s", name)
+		}
+		bucket, err := tx.CreateBucketIfNotExists(args[1])
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		for i := 2; i < len(args); i += 2 {
+			if err := bucket.Put(args[i], args[i+1]); err != nil {
+				return err
+			}
+			tx.record(Event{Type: EventHset, Key: string(args[1]), Field: string(args[i]), Value: args[i+1]})
+		}
+		return nil
+	case "ZADD":
+		if len(args) < 4 || len(args)%2 != 0 {
+			return fmt.Errorf("wrong number of arguments for %s", name)
+		}
+		for i := 2; i < len(args); i += 2 {
+			score, err := parseScore(string(args[i]))
+			if err != nil {
+				return err
+			}
+			if err := zadd(tx, string(args[1]), score, string(args[i+1])); err != nil {
+				return err
+			}
+		}
+		return nil
+	case "DEL":
+		for _, key := range args[1:] {
+			if err := deleteKey(tx, string(key)); errors.Is(err, ErrKeyNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			tx.record(Event{Type: EventDelete, Key: string(key)})
+		}
+		return nil
+	case "PEXPIREAT":
+		if len(args) != 3 {
+			return fmt.Errorf("wrong number of arguments for %s", name)
+		}
+		ms, err := strconv.ParseInt(string(args[2]), 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid expire time %q", args[2])
+		}
+		if tx.Bucket(args[1]) == nil {
+			return nil
+		}
+		return setExpiry(tx, string(args[1]), time.UnixMilli(ms).UnixNano())
+	default:
+		return fmt.Errorf("unsupported command %s", name)
+	}
+}
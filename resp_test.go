@@ -0,0 +1,142 @@
+package jungledb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"testing"
+)
+
+// TestExportImportRESP tests a Redis protocol export/import round trip.
+func TestExportImportRESP(t *testing.T) {
+	src, err := Open("testdata/resp_src.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer src.Close()
+
+	fields := make(map[string][]byte)
+	for i := 0; i < respCommandChunk+5; i++ { // Forces more than one HSET command
+		fields[fmt.Sprintf("f%d", i)] = []byte(fmt.Sprintf("v%d\r\n", i))
+	}
+	if err := src.Hmset("big", fields); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	if err := src.Zadd("z", -2.5, "low"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := src.Zadd("z", math.Inf(1), "top"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := src.ExportRESP(&buf, ExportOptions{}); err != nil {
+		t.Fatalf("ExportRESP failed: %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "*") {
+		t.Fatalf("ExportRESP output is not RESP encoded: %q", buf.String()[:20])
+	}
+
+	dst, err := Open("testdata/resp_dst.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer dst.Close()
+
+	n, err := dst.ImportRESP(&buf)
+	if err != nil {
+		t.Fatalf("ImportRESP failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("applied command count mismatch: expected 3, got %d", n)
+	}
+
+	got, err := dst.Hscan("big")
+	if err != nil {
+		t.Fatalf("Hscan failed: %v", err)
+	}
+	if !equalByteMap(got, fields) {
+		t.Errorf("imported hash mismatch: expected %d fields, got %d", len(fields), len(got))
+	}
+
+	score, err := dst.Zscore("z", "top")
+	if err != nil {
+		t.Fatalf("Zscore failed: %v", err)
+	}
+	if !math.IsInf(score, 1) {
+		t.Errorf("expected +inf score, got %f", score)
+	}
+	score, err = dst.Zscore("z", "low")
+	if err != nil {
+		t.Fatalf("Zscore failed: %v", err)
+	}
+	if score != -2.5 {
+		t.Errorf("expected -2.5 score, got %f", score)
+	}
+}
+
+// TestImportRESPCommands tests ImportRESP with inline commands, DEL and unsupported commands.
+func TestImportRESPCommands(t *testing.T) {
+	db, err := Open("testdata/resp_cmds.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	input := "HSET h a 1 b 2\r\nZADD z 1 one\r\nDEL h\r\nhmset h2 x y\r\n"
+	if _, err := db.ImportRESP(strings.NewReader(input)); err != nil {
+		t.Fatalf("ImportRESP failed: %v", err)
+	}
+
+	keys, _, err := db.ListKeys("", "", 0)
+	if err != nil {
+		t.Fatalf("ListKeys failed: %v", err)
+	}
+	if !equal(keys, []string{"h2", "z"}) {
+		t.Errorf("keys mismatch after import: got %v", keys)
+	}
+
+	if _, err := db.ImportRESP(strings.NewReader("SET k v\r\n")); err == nil {
+		t.Error("ImportRESP should reject unsupported commands")
+	}
+	if _, err := db.ImportRESP(strings.NewReader("HSET h only_field\r\n")); err == nil {
+		t.Error("ImportRESP should reject malformed HSET")
+	}
+}
+
+// TestRESPLimits tests that the reader rejects oversized, negative and deeply nested
+// lengths before allocating for them.
+func TestRESPLimits(t *testing.T) {
+	tests := []string{
+		"*900000000000000\r\n",
+		"*-900000000000000000000\r\n",
+		"$900000000000000\r\nabc\r\n",
+		"$536870913\r\n",
+		"*2000000\r\n",
+		strings.Repeat("*1\r\n", respMaxDepth+1) + "$1\r\na\r\n",
+		"+" + strings.Repeat("x", respMaxLine+10),
+	}
+	for _, input := range tests {
+		r := newRESPReader(strings.NewReader(input))
+		if _, err := r.readValue(); err == nil || err == io.EOF {
+			t.Errorf("%.40q: got error %v, want a protocol error", input, err)
+		}
+	}
+	inline := "PING " + strings.Repeat("x", respMaxLine) + "\r\n"
+	if _, err := newRESPReader(strings.NewReader(inline)).readCommand(); err == nil {
+		t.Errorf("inline command longer than %d bytes accepted", respMaxLine)
+	}
+
+	// Large values that are actually sent still decode
+	value := strings.Repeat("v", 3*respPrealloc+5)
+	r := newRESPReader(strings.NewReader(fmt.Sprintf("*2\r\n$3\r\nGET\r\n$%d\r\n%s\r\n", len(value), value)))
+	args, err := r.readCommand()
+	if err != nil {
+		t.Fatalf("readCommand failed: %v", err)
+	}
+	if len(args) != 2 || string(args[1]) != value {
+		t.Fatalf("readCommand: got %d arguments, want GET and a %d byte value", len(args), len(value))
+	}
+}
@@ -0,0 +1,167 @@
+package jungledb
+
+import (
+	"bytes"
+	"time"
+)
+
+const (
+	// retentionBatchSize bounds the keys visited plus the members removed per
+	// transaction by retention.
+	retentionBatchSize = 500
+
+	// retentionInterval is how often the expiry sweeper enforces retention rules without
+	// a maintenance window.
+	retentionInterval = time.Hour
+)
+
+// RetentionRule bounds the keys matching Pattern, see WithRetention.
+type RetentionRule struct {
+	Pattern string // Keys the rule applies to, see ListKeys; keys of namespaces include the namespace prefix
+
+	// MaxAge gives the keys without a TTL one of MaxAge, so that they expire MaxAge
+	// after retention first finds them. Zero leaves TTLs alone.
+	MaxAge time.Duration
+
+	// MaxMembers trims sorted sets to their MaxMembers highest scoring members, such as
+	// the most recent ones when scores are timestamps. Zero keeps every member.
+	MaxMembers int
+}
+
+// WithRetention registers rules that the database enforces in the background, so that
+// pruning old data does not need code in every application: during the maintenance
+// window if one is set (see WithMaintenanceWindow), otherwise at every run of the expiry
+// sweeper (see WithExpirySweep). Keys matching several rules get the shortest MaxAge and
+// the smallest MaxMembers. Changes are reported to watchers and the operation log as
+// those of Expire and Zrem. Read-only databases enforce nothing.
+func WithRetention(rules ...RetentionRule) Option {
+	return func(o *options) {
+		o.retention = append(o.retention, rules...)
+	}
+}
+
+// retentionFor returns the limits that apply to the key stored under name, merging the
+// rules it matches, and whether any does.
+func (db *DB) retentionFor(name string) (RetentionRule, bool) {
+	var limits RetentionRule
+	found := false
+	for _, rule := range db.opts.retention {
+		if !matchPattern(rule.Pattern, name) {
+			continue
+		}
+		if rule.MaxAge > 0 && (limits.MaxAge == 0 || rule.MaxAge < limits.MaxAge) {
+			limits.MaxAge = rule.MaxAge
+		}
+		if rule.MaxMembers > 0 && (limits.MaxMembers == 0 || rule.MaxMembers < limits.MaxMembers) {
+			limits.MaxMembers = rule.MaxMembers
+		}
+		found = true
+	}
+	return limits, found
+}
+
+// sweepRetention enforces the retention rules from the expiry sweeper if they are due,
+// that is if there is no maintenance window and last is zero or an interval old.
+func (db *DB) sweepRetention(last *time.Time, stop <-chan struct{}) {
+	if db.opts.maintenance != nil || len(db.opts.retention) == 0 || (!last.IsZero() && db.now().Sub(*last) < retentionInterval) {
+		return
+	}
+	*last = db.now()
+	aged, trimmed, err := db.enforceRetention(0, func() bool { return true }, stop)
+	if err != nil {
+		db.log.Warn("retention failed", "error", err)
+		return
+	}
+	if aged+trimmed > 0 {
+		db.log.Info("retention enforced", "aged", aged, "trimmed", trimmed)
+	}
+}
+
+// enforceRetention applies the retention rules to every key, in paced batches, until
+// it is done, keepGoing returns false or stop is closed, and returns the keys given a
+// TTL and the sorted set members removed.
+func (db *DB) enforceRetention(pace time.Duration, keepGoing func() bool, stop <-chan struct{}) (aged, trimmed int, err error) {
+	if len(db.opts.retention) == 0 || db.readOnly {
+		return 0, 0, nil
+	}
+	var after []byte
+	for {
+		var batchAged, batchTrimmed, freed int
+		var more bool
+		err := db.update("Retention", "", func(tx *txn) error {
+			var err error
+			after, more, batchAged, batchTrimmed, err = db.retainBatch(tx, after)
+			freed = tx.freed
+			return err
+		})
+		if err != nil {
+			return aged, trimmed, err
+		}
+		aged += batchAged
+		trimmed += batchTrimmed
+		if !more || !db.throttle.wait(batchAged+batchTrimmed, freed, stop) || !sleep(pace, stop) || !keepGoing() {
+			return aged, trimmed, nil
+		}
+	}
+}
+
+// retainBatch applies the retention rules to the keys after the bucket name after, up
+// to retentionBatchSize of work, and returns the last key it is done with and whether
+// keys are left.
+func (db *DB) retainBatch(tx *txn, after []byte) (last []byte, more bool, aged, trimmed int, err error) {
+	// Collect the keys first: setting TTLs may create buckets, which moves cursors
+	var names [][]byte
+	c := tx.Cursor()
+	name, v := c.First()
+	if after != nil {
+		name, v = c.Seek(after)
+		if bytes.Equal(name, after) {
+			name, v = c.Next()
+		}
+	}
+	for ; name != nil && len(names) < retentionBatchSize; name, v = c.Next() {
+		if v == nil {
+			names = append(names, bytes.Clone(name))
+		}
+	}
+	more = name != nil
+
+	now := db.now()
+	work := 0
+	for _, name := range names {
+		work++
+		key := string(name)
+		limits, ok := db.retentionFor(key)
+		if !ok || isInternalBucket(tx.Tx, name) || db.liveBucket(tx.Tx, key) == nil {
+			last = name
+			continue
+		}
+
+		if limits.MaxAge > 0 && expiry(tx.Tx, key) == 0 {
+			if err := setExpiry(tx, key, now.Add(limits.MaxAge).UnixNano()); err != nil {
+				return nil, false, aged, trimmed, err
+			}
+			aged++
+		}
+		if limits.MaxMembers > 0 && keyType(tx.Tx, name) == typeZset {
+			excess := tx.Bucket(name).Stats().KeyN - limits.MaxMembers
+			var members []string
+			zc := tx.Bucket(name).Cursor()
+			for k, _ := zc.First(); k != nil && len(members) < min(excess, retentionBatchSize-work); k, _ = zc.Next() {
+				members = append(members, string(k[8:])) // Lowest scores first
+			}
+			for _, member := range members {
+				if err := zrem(tx, key, member); err != nil {
+					return nil, false, aged, trimmed, err
+				}
+			}
+			work += len(members)
+			trimmed += len(members)
+			if len(members) < excess {
+				return last, true, aged, trimmed, nil // Trim the rest next time
+			}
+		}
+		last = name
+	}
+	return last, more, aged, trimmed, nil
+}
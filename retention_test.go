@@ -0,0 +1,64 @@
+package jungledb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestRetention tests that the expiry sweeper gives matching keys a TTL and trims
+// matching sorted sets, in several batches when needed.
+func TestRetention(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	db, err := Open("testdata/retention.db", WithExpirySweep(-1), WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	for i := range 700 {
+		if err := db.Zadd("history:a", float64(i), fmt.Sprint(i)); err != nil {
+			t.Fatalf("Zadd failed: %v", err)
+		}
+	}
+	for _, key := range []string{"events:1", "events:2", "other"} {
+		if err := db.Hset(key, "f", []byte("v")); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	if err := db.Expire("events:2", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+
+	month := 30 * 24 * time.Hour
+	db, err = Open("testdata/retention.db", WithExpirySweep(time.Minute), WithClock(clock), WithRetention(
+		RetentionRule{Pattern: "events:*", MaxAge: month},
+		RetentionRule{Pattern: "history:*", MaxMembers: 10},
+		RetentionRule{Pattern: "history:*", MaxMembers: 3},
+	))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	// Retention runs from the sweeper, once its interval has passed
+	waitFor(t, "the expiry sweeper", func() bool { return clock.waiting() > 0 })
+	clock.advance(time.Minute)
+	waitFor(t, "the retention rules", func() bool {
+		n, _ := db.Zcard("history:a")
+		return n == 3
+	})
+	if members, err := db.Zrange("history:a", 0, -1); err != nil || fmt.Sprint(members) != "[697 698 699]" {
+		t.Errorf("expected the 3 highest scoring members, got %v %v", members, err)
+	}
+	if ttl, err := db.TTL("events:1"); err != nil || ttl != month {
+		t.Errorf("expected a TTL of 30 days, got %v %v", ttl, err)
+	}
+	if ttl, err := db.TTL("events:2"); err != nil || ttl != time.Hour-time.Minute {
+		t.Errorf("expected the existing TTL to be kept, got %v %v", ttl, err)
+	}
+	if ttl, err := db.TTL("other"); err != nil || ttl != 0 {
+		t.Errorf("expected keys matching no rule to be left alone, got %v %v", ttl, err)
+	}
+}
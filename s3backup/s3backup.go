@@ -0,0 +1,179 @@
+// Package s3backup is a jungledb.BackupTarget storing backups in S3, or in any object
+// storage with an S3-compatible API such as Google Cloud Storage with HMAC keys:
+//
+//	target := &s3backup.Target{
+//		Endpoint:             "https://s3.eu-west-1.amazonaws.com",
+//		Region:               "eu-west-1",
+//		Bucket:               "backups",
+//		Prefix:               "jungledb/",
+//		AccessKeyID:          id,
+//		SecretAccessKey:      secret,
+//		ServerSideEncryption: "aws:kms",
+//		Tags:                 map[string]string{"retention": "30d"},
+//	}
+//	go db.ScheduleBackups(ctx, time.Hour, target, func(t time.Time) string {
+//		return t.UTC().Format("20060102T150405Z") + ".db"
+//	})
+//
+// Backups are streamed in a single PUT request, signed with AWS Signature Version 4 and
+// an unsigned payload, so they are never staged on local disk. A single PUT is limited to
+// 5 GiB by S3.
+package s3backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// unsignedPayload is the payload hash of requests whose body is not signed.
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// Target stores backups as objects of an S3 bucket. Its fields must not change while it
+// is in use.
+type Target struct {
+	Endpoint string // Base URL of the service, the bucket being addressed in the path
+	Region   string
+	Bucket   string
+	Prefix   string // Prepended to backup names to form object keys
+
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string // For temporary credentials, optional
+
+	// ServerSideEncryption is "AES256" or "aws:kms" to have the objects encrypted at rest,
+	// with the KMS key KMSKeyID if set, instead of the bucket's default.
+	ServerSideEncryption string
+	KMSKeyID             string
+
+	StorageClass string            // Such as "STANDARD_IA", optional
+	Tags         map[string]string // Object tags, which lifecycle rules can match
+
+	Client *http.Client // http.DefaultClient if nil
+
+	now func() time.Time // Overridden by tests
+}
+
+// Put uploads the backup as the object Prefix + name.
+func (t *Target) Put(ctx context.Context, name string, r io.Reader, size int64) error {
+	u := strings.TrimSuffix(t.Endpoint, "/") + "/" + escapePath(t.Bucket+"/"+t.Prefix+name)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u, io.NopCloser(r))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	if t.ServerSideEncryption != "" {
+		req.Header.Set("X-Amz-Server-Side-Encryption", t.ServerSideEncryption)
+	}
+	if t.KMSKeyID != "" {
+		req.Header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", t.KMSKeyID)
+	}
+	if t.StorageClass != "" {
+		req.Header.Set("X-Amz-Storage-Class", t.StorageClass)
+	}
+	if len(t.Tags) > 0 {
+		tags := url.Values{}
+		for k, v := range t.Tags {
+			tags.Set(k, v)
+		}
+		req.Header.Set("X-Amz-Tagging", tags.Encode())
+	}
+	if t.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", t.SessionToken)
+	}
+	now := time.Now
+	if t.now != nil {
+		now = t.now
+	}
+	t.sign(req, unsignedPayload, now())
+
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("uploading %s failed with %s: %s", name, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// sign adds the date, payload hash and Signature Version 4 authorization headers to req,
+// signing its host and every header it already has.
+func (t *Target) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + t.Region + "/s3/aws4_request"
+	digest := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(digest[:])
+
+	key := hmacSHA256([]byte("AWS4"+t.SecretAccessKey), day)
+	for _, part := range []string{t.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		t.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escapePath escapes an object path as S3 expects in URLs and signatures: every byte but
+// unreserved characters and slashes is percent-encoded.
+func escapePath(path string) string {
+	var b strings.Builder
+	for i := 0; i < len(path); i++ {
+		c := path[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,142 @@
+package jungledb
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"go.etcd.io/bbolt"
+)
+
+// Savepoint marks a point in a transaction that RollbackTo can return to.
+type Savepoint struct {
+	id int
+}
+
+// savepoint is the state of a transaction when a savepoint was taken.
+type savepoint struct {
+	id     int
+	events int // Number of events recorded
+	undo   int // Number of key snapshots taken
+}
+
+// keySnapshot is the contents of a key before a write following a savepoint.
+type keySnapshot struct {
+	name    string
+	exists  bool
+	pairs   [][2][]byte
+	members [][2][]byte // Member index of a sorted set, nil for a hash
+	expiry  int64
+}
+
+// Savepoint marks the current state of the transaction, so that the writes made after it
+// can be undone with RollbackTo without rolling back the whole transaction, for example
+// to skip one bad record of an import. Once a savepoint is taken, the first write to each
+// key after it copies the key in memory, which RollbackTo restores.
+func (t *Tx) Savepoint() Savepoint {
+	t.lastSavepoint++
+	t.savepoints = append(t.savepoints, savepoint{id: t.lastSavepoint, events: len(t.tx.events), undo: len(t.undo)})
+	t.saved = make(map[string]bool)
+	return Savepoint{id: t.lastSavepoint}
+}
+
+// RollbackTo undoes the writes made since sp was taken. The savepoints taken after sp are
+// released, while sp remains and can be rolled back to again. It fails if sp was released
+// or belongs to another transaction.
+func (t *Tx) RollbackTo(sp Savepoint) error {
+	i := len(t.savepoints) - 1
+	for i >= 0 && t.savepoints[i].id != sp.id {
+		i--
+	}
+	if i < 0 {
+		return errors.New("unknown or released savepoint")
+	}
+	s := t.savepoints[i]
+	for j := len(t.undo) - 1; j >= s.undo; j-- {
+		if err := t.undo[j].restore(t.tx); err != nil {
+			return fmt.Errorf("failed to roll back %s: %v", t.undo[j].name, err)
+		}
+	}
+	t.undo = t.undo[:s.undo]
+	t.tx.events = t.tx.events[:s.events]
+	t.savepoints = t.savepoints[:i+1]
+	t.saved = make(map[string]bool)
+	return nil
+}
+
+// save snapshots the keys named names, as stored, before a write if a savepoint needs it.
+func (t *Tx) save(names ...string) {
+	if len(t.savepoints) == 0 {
+		return
+	}
+	for _, name := range names {
+		if t.saved[name] {
+			continue
+		}
+		t.saved[name] = true
+		t.undo = append(t.undo, snapshotKey(t.tx.Tx, name))
+	}
+}
+
+// snapshotKey copies the contents of the key name.
+func snapshotKey(tx *bbolt.Tx, name string) keySnapshot {
+	s := keySnapshot{name: name}
+	bucket := tx.Bucket([]byte(name))
+	if bucket == nil {
+		return s
+	}
+	s.exists = true
+	s.pairs = snapshotPairs(bucket)
+	if members := membersBucket(tx, name); members != nil {
+		s.members = snapshotPairs(members)
+		if s.members == nil {
+			s.members = [][2][]byte{}
+		}
+	}
+	s.expiry = expiry(tx, name)
+	return s
+}
+
+func snapshotPairs(bucket *bbolt.Bucket) [][2][]byte {
+	var pairs [][2][]byte
+	bucket.ForEach(func(k, v []byte) error {
+		pairs = append(pairs, [2][]byte{bytes.Clone(k), bytes.Clone(v)})
+		return nil
+	})
+	return pairs
+}
+
+// restore puts the key back as it was when s was taken.
+func (s keySnapshot) restore(tx *txn) error {
+	if err := deleteKey(tx, s.name); err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+	if !s.exists {
+		return nil
+	}
+	if err := restorePairs(tx.Tx, s.name, s.pairs); err != nil {
+		return err
+	}
+	if s.members != nil {
+		if err := restorePairs(tx.Tx, s.name+membersSuffix, s.members); err != nil {
+			return err
+		}
+		if err := markZset(tx.Tx, s.name, true); err != nil {
+			return err
+		}
+	}
+	return putExpiry(tx.Tx, s.name, s.expiry)
+}
+
+func restorePairs(tx *bbolt.Tx, name string, pairs [][2][]byte) error {
+	bucket, err := tx.CreateBucket([]byte(name))
+	if err != nil {
+		return err
+	}
+	for _, p := range pairs {
+		if err := bucket.Put(p[0], p[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
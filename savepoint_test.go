@@ -0,0 +1,78 @@
+package jungledb
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSavepoint tests that rolling back to a savepoint undoes the later writes only.
+func TestSavepoint(t *testing.T) {
+	db, err := Open("testdata/savepoint.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Zadd("ranking", 1, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	events, cancel := db.Watch("*")
+	defer cancel()
+
+	err = db.Update(func(tx *Tx) error {
+		if err := tx.Hset("record:1", "name", []byte("alice")); err != nil {
+			return err
+		}
+		sp := tx.Savepoint()
+		if err := tx.Hset("record:1", "name", []byte("bad")); err != nil {
+			return err
+		}
+		if err := tx.Hset("record:2", "name", []byte("bad")); err != nil {
+			return err
+		}
+		inner := tx.Savepoint()
+		if err := tx.Zadd("ranking", 5, "bob"); err != nil {
+			return err
+		}
+		if err := tx.Delete("ranking"); err != nil {
+			return err
+		}
+		if err := tx.RollbackTo(inner); err != nil {
+			return err
+		}
+		if score, err := tx.Zscore("ranking", "alice"); err != nil || score != 1 {
+			t.Errorf("expected the sorted set restored, got %v %v", score, err)
+		}
+		if err := tx.RollbackTo(sp); err != nil {
+			return err
+		}
+		if err := tx.RollbackTo(inner); err == nil {
+			t.Errorf("expected an error rolling back to a released savepoint")
+		}
+		return tx.Hset("record:3", "name", []byte("carol"))
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	for key, want := range map[string]map[string][]byte{
+		"record:1": {"name": []byte("alice")},
+		"record:2": {},
+		"record:3": {"name": []byte("carol")},
+	} {
+		if fields, err := db.Hscan(key); err != nil || !reflect.DeepEqual(fields, want) {
+			t.Errorf("expected %s to hold %v, got %v %v", key, want, fields, err)
+		}
+	}
+	if members, err := db.Zrange("ranking", 0, -1); err != nil || !reflect.DeepEqual(members, []string{"alice"}) {
+		t.Errorf("expected [alice], got %v %v", members, err)
+	}
+
+	// Only the events of the writes kept are published
+	var keys []string
+	for len(events) > 0 {
+		keys = append(keys, (<-events).Key)
+	}
+	if !reflect.DeepEqual(keys, []string{"record:1", "record:3"}) {
+		t.Errorf("expected events for record:1 and record:3, got %v", keys)
+	}
+}
@@ -0,0 +1,152 @@
+package jungledb
+
+import (
+	"bytes"
+	"errors"
+	"runtime"
+	"sync"
+
+	"go.etcd.io/bbolt"
+)
+
+// ScanOptions configures Scan.
+type ScanOptions struct {
+	Pattern string // Keys to scan, see ListKeys; empty scans every hash
+	Workers int    // Goroutines calling fn, GOMAXPROCS if <= 0
+
+	// After resumes an interrupted scan: keys up to After, as passed to Checkpoint, are
+	// skipped.
+	After string
+
+	// Checkpoint, if set, is called every CheckpointEvery keys (1000 if zero) and once
+	// the scan completes, with a key such that fn has returned for it and every key
+	// before it. Saving it lets a scan interrupted by a restart resume with After set
+	// to it rather than start over; keys after it may be visited again. Checkpoint is
+	// not called concurrently. An error stops the scan.
+	Checkpoint      func(after string) error
+	CheckpointEvery int
+}
+
+// ScanAll calls fn with the fields of every hash whose key matches pattern (see ListKeys),
+// spreading the hashes over workers goroutines, GOMAXPROCS if workers <= 0, for fast
+// processing of the whole database such as reindexing or analytics. Each hash is read in
+// its own read transaction, so fn sees each hash consistently but not the database as a
+// whole, and fn runs outside of it, free to write to db. fn is called concurrently, in no
+// particular order, and may keep fields. Sorted sets and hashes deleted during the scan
+// are skipped. The first error from fn stops the scan and is returned.
+func (db *DB) ScanAll(pattern string, workers int, fn func(key string, fields map[string][]byte) error) error {
+	return db.Scan(ScanOptions{Pattern: pattern, Workers: workers}, fn)
+}
+
+// Scan is ScanAll with options, notably checkpoints to resume a long scan.
+func (db *DB) Scan(opts ScanOptions, fn func(key string, fields map[string][]byte) error) error {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	keys, _, err := db.ListKeys(opts.Pattern, opts.After, 0)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+		work     = make(chan int)
+		stop     = make(chan struct{})
+		progress = scanProgress{keys: keys, done: make([]bool, len(keys)), cp: newCheckpoints(opts.Checkpoint, opts.CheckpointEvery)}
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			firstErr = err
+			close(stop)
+		})
+	}
+	for range min(workers, len(keys)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				fields, err := db.scanHash(keys[i])
+				if err == nil && fields != nil {
+					err = fn(keys[i], fields)
+				}
+				if err == nil {
+					err = progress.finished(i)
+				}
+				if err != nil {
+					fail(err)
+					return
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range keys {
+		select {
+		case work <- i:
+		case <-stop:
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	if firstErr != nil {
+		return firstErr
+	}
+	return progress.cp.finish(noFlush)
+}
+
+// scanProgress tracks the keys of a Scan that fn is done with, for its checkpoints.
+type scanProgress struct {
+	mu   sync.Mutex
+	keys []string
+	done []bool
+	next int // Index of the first key not done
+	cp   *checkpoints
+}
+
+// finished records that fn returned for the key at index i.
+func (p *scanProgress) finished(i int) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.done[i] = true
+	if p.next != i {
+		return nil // An earlier key is still in progress
+	}
+	for p.next < len(p.keys) && p.done[p.next] {
+		if err := p.cp.done(p.keys[p.next], noFlush); err != nil {
+			return err
+		}
+		p.next++
+	}
+	return nil
+}
+
+// scanHash returns a copy of the fields of the hash stored under the user key, nil if
+// there is no such hash.
+func (db *DB) scanHash(key string) (map[string][]byte, error) {
+	key = db.nsKey(key)
+	var fields map[string][]byte
+	err := db.view("ScanAll", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err
+		}
+		fields = make(map[string][]byte)
+		return bucket.ForEach(func(k, v []byte) error {
+			fields[string(k)] = bytes.Clone(v)
+			return nil
+		})
+	})
+	if errors.Is(err, ErrWrongType) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	db.metrics.addRead(mapBytes(fields))
+	return fields, nil
+}
@@ -0,0 +1,119 @@
+package jungledb
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+)
+
+// TestScanAll tests that ScanAll visits every matching hash once and stops on errors.
+func TestScanAll(t *testing.T) {
+	db, err := Open("testdata/scanall.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	for i := range 50 {
+		if err := db.Hset(fmt.Sprintf("user:%d", i), "id", []byte(fmt.Sprint(i))); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	if err := db.Hset("order:1", "id", []byte("1")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Zadd("user:ranking", 1, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]string)
+	err = db.ScanAll("user:*", 4, func(key string, fields map[string][]byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if _, ok := seen[key]; ok {
+			t.Errorf("key %s visited twice", key)
+		}
+		seen[key] = string(fields["id"])
+		// Writes are allowed from fn
+		return db.Hset(key, "scanned", []byte("1"))
+	})
+	if err != nil {
+		t.Fatalf("ScanAll failed: %v", err)
+	}
+	if len(seen) != 50 || seen["user:7"] != "7" {
+		t.Errorf("expected the 50 users, got %d: %v", len(seen), seen)
+	}
+	if v, err := db.Hget("user:7", "scanned"); err != nil || string(v) != "1" {
+		t.Errorf("expected the write from fn, got %q %v", v, err)
+	}
+
+	errStop := errors.New("stop")
+	err = db.ScanAll("", 0, func(key string, fields map[string][]byte) error {
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("expected the error of fn, got %v", err)
+	}
+}
+
+// TestScanCheckpoint tests that a scan resumed from its last checkpoint visits the keys
+// the interrupted one did not get to.
+func TestScanCheckpoint(t *testing.T) {
+	db, err := Open("testdata/scan_checkpoint.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	for i := range 100 {
+		if err := db.Hset(fmt.Sprintf("key:%03d", i), "f", []byte("v")); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+
+	var mu sync.Mutex
+	seen := make(map[string]bool)
+	var last string
+	interrupted := errors.New("interrupted")
+	opts := ScanOptions{Workers: 4, CheckpointEvery: 10, Checkpoint: func(key string) error {
+		if key <= last {
+			t.Errorf("checkpoint %s after %s", key, last)
+		}
+		last = key
+		return nil
+	}}
+	err = db.Scan(opts, func(key string, _ map[string][]byte) error {
+		if key == "key:050" {
+			return interrupted
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		seen[key] = true
+		return nil
+	})
+	if !errors.Is(err, interrupted) {
+		t.Fatalf("expected fn's error, got %v", err)
+	}
+	if last == "" || last >= "key:050" {
+		t.Fatalf("expected a checkpoint before key:050, got %q", last)
+	}
+	for i := range 100 {
+		if key := fmt.Sprintf("key:%03d", i); key <= last && !seen[key] {
+			t.Errorf("checkpoint %s passed %s, which was not visited", last, key)
+		}
+	}
+
+	opts.After = last
+	err = db.Scan(opts, func(key string, _ map[string][]byte) error {
+		mu.Lock()
+		defer mu.Unlock()
+		seen[key] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Scan failed: %v", err)
+	}
+	if len(seen) != 100 || last != "key:099" {
+		t.Errorf("expected every key visited and a final checkpoint, got %d keys and %q", len(seen), last)
+	}
+}
@@ -0,0 +1,201 @@
+package jungledb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"go.etcd.io/bbolt"
+)
+
+// schemaBucket holds the version of the last schema migration applied under its "version"
+// key, see WithMigrations.
+const schemaBucket = internalPrefix + "schema"
+
+var schemaVersionKey = []byte("version")
+
+// Migration changes the data of the database from one schema version to the next.
+type Migration struct {
+	Version int    // Positive and unique, migrations are applied in increasing order
+	Name    string // Describes the migration in errors and plans
+	Up      func(tx *Tx) error
+
+	// Down undoes Up, to migrate back to an earlier version. It can be nil if the
+	// migration cannot be undone.
+	Down func(tx *Tx) error
+}
+
+// MigrationStep is a migration applied, or to be applied, by MigrateSchema.
+type MigrationStep struct {
+	Version int
+	Name    string
+	Down    bool // Whether the Down function runs rather than Up
+}
+
+// WithMigrations registers the schema migrations of the application. Open applies the
+// ones newer than the version recorded in the database, each in its own transaction
+// that also records its version, and fails if a migration fails or the database was
+// migrated past the newest of them. Read-only databases are not migrated.
+func WithMigrations(migrations ...Migration) Option {
+	return func(o *options) {
+		o.migrations = append(o.migrations, migrations...)
+	}
+}
+
+// SchemaVersion returns the version of the last migration applied, 0 if there is none.
+func (db *DB) SchemaVersion() (int, error) {
+	var version int
+	err := db.view("SchemaVersion", "", func(tx *bbolt.Tx) error {
+		var err error
+		version, err = schemaVersion(tx)
+		return err
+	})
+	return version, err
+}
+
+// MigrateSchema applies the migrations registered with WithMigrations up or down to
+// version, which is 0 or the version of one of them, and returns the steps taken. With
+// dryRun, the steps all run in a single transaction that is then rolled back, which
+// checks that they succeed without changing anything.
+func (db *DB) MigrateSchema(version int, dryRun bool) ([]MigrationStep, error) {
+	migrations, err := db.migrations()
+	if err != nil {
+		return nil, err
+	}
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	from, to := -1, -1 // Number of migrations applied at current and at version
+	for i, m := range migrations {
+		if m.Version == current {
+			from = i + 1
+		}
+		if m.Version == version {
+			to = i + 1
+		}
+	}
+	if current == 0 {
+		from = 0
+	}
+	if version == 0 {
+		to = 0
+	}
+	if from < 0 {
+		return nil, fmt.Errorf("database schema version %d is unknown to the registered migrations", current)
+	}
+	if to < 0 {
+		return nil, fmt.Errorf("no migration to schema version %d", version)
+	}
+
+	type step struct {
+		MigrationStep
+		fn      func(tx *Tx) error
+		version int // Recorded once the step is applied
+	}
+	var steps []step
+	for i := from; i < to; i++ {
+		m := migrations[i]
+		steps = append(steps, step{MigrationStep{m.Version, m.Name, false}, m.Up, m.Version})
+	}
+	for i := from - 1; i >= to; i-- {
+		m := migrations[i]
+		if m.Down == nil {
+			return nil, fmt.Errorf("migration %d (%s) cannot be undone", m.Version, m.Name)
+		}
+		previous := 0
+		if i > 0 {
+			previous = migrations[i-1].Version
+		}
+		steps = append(steps, step{MigrationStep{m.Version, m.Name, true}, m.Down, previous})
+	}
+
+	run := func(tx *Tx, s step) error {
+		if err := s.fn(tx); err != nil {
+			return fmt.Errorf("migration %d (%s) failed: %w", s.Version, s.Name, err)
+		}
+		bucket, err := tx.tx.CreateBucketIfNotExists([]byte(schemaBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create schema bucket: %v", err)
+		}
+		return bucket.Put(schemaVersionKey, []byte(strconv.Itoa(s.version)))
+	}
+	var applied []MigrationStep
+	if dryRun {
+		err := db.update("MigrateSchema", "", func(tx *txn) error {
+			for _, s := range steps {
+				if err := run(&Tx{db: db, tx: tx}, s); err != nil {
+					return err
+				}
+				applied = append(applied, s.MigrationStep)
+			}
+			return errRollback
+		})
+		if !errors.Is(err, errRollback) {
+			return applied, err
+		}
+		return applied, nil
+	}
+	for _, s := range steps {
+		err := db.update("MigrateSchema", "", func(tx *txn) error {
+			return run(&Tx{db: db, tx: tx}, s)
+		})
+		if err != nil {
+			return applied, err
+		}
+		db.log.Info("applied schema migration", "version", s.Version, "name", s.Name, "down", s.Down)
+		applied = append(applied, s.MigrationStep)
+	}
+	return applied, nil
+}
+
+// migrations returns the registered migrations in version order.
+func (db *DB) migrations() ([]Migration, error) {
+	migrations := append([]Migration(nil), db.opts.migrations...)
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version <= 0 || m.Up == nil {
+			return nil, fmt.Errorf("migration %d (%s) needs a positive version and an Up function", m.Version, m.Name)
+		}
+		if i > 0 && migrations[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", m.Version)
+		}
+	}
+	return migrations, nil
+}
+
+// migrate applies the pending migrations at Open.
+func (db *DB) migrate() error {
+	migrations, err := db.migrations()
+	if err != nil || len(migrations) == 0 {
+		return err
+	}
+	latest := migrations[len(migrations)-1].Version
+	current, err := db.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	if current > latest {
+		return fmt.Errorf("database schema version %d is newer than the latest migration %d", current, latest)
+	}
+	_, err = db.MigrateSchema(latest, false)
+	return err
+}
+
+// schemaVersion reads the schema version recorded in the database.
+func schemaVersion(tx *bbolt.Tx) (int, error) {
+	bucket := tx.Bucket([]byte(schemaBucket))
+	if bucket == nil {
+		return 0, nil
+	}
+	v := bucket.Get(schemaVersionKey)
+	if v == nil {
+		return 0, nil
+	}
+	version, err := strconv.Atoi(string(v))
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema version %q", v)
+	}
+	return version, nil
+}
@@ -0,0 +1,113 @@
+package jungledb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// testMigrations returns migrations that copy the name of user:1 to a display field and
+// then rename the key.
+func testMigrations() []Migration {
+	return []Migration{
+		{
+			Version: 1,
+			Name:    "display name",
+			Up: func(tx *Tx) error {
+				name, err := tx.Hget("user:1", "name")
+				if err != nil {
+					return err
+				}
+				return tx.Hset("user:1", "display", name)
+			},
+			Down: func(tx *Tx) error { return tx.Hdel("user:1", "display") },
+		},
+		{
+			Version: 2,
+			Name:    "rename users",
+			Up:      func(tx *Tx) error { return tx.Rename("user:1", "users:1") },
+			Down:    func(tx *Tx) error { return tx.Rename("users:1", "user:1") },
+		},
+	}
+}
+
+// TestMigrations tests applying migrations at Open, dry runs and migrating down.
+func TestMigrations(t *testing.T) {
+	path := "testdata/migrations.db"
+	db, err := Open(path)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Hset("user:1", "name", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	db.Close()
+
+	db, err = Open(path, WithMigrations(testMigrations()...))
+	if err != nil {
+		t.Fatalf("failed to open database with migrations: %v", err)
+	}
+	if version, err := db.SchemaVersion(); err != nil || version != 2 {
+		t.Errorf("expected version 2, got %v %v", version, err)
+	}
+	if v, err := db.Hget("users:1", "display"); err != nil || string(v) != "alice" {
+		t.Errorf("expected the migrated hash, got %q %v", v, err)
+	}
+
+	steps, err := db.MigrateSchema(0, true)
+	want := []MigrationStep{{2, "rename users", true}, {1, "display name", true}}
+	if err != nil || !reflect.DeepEqual(steps, want) {
+		t.Errorf("expected the dry run to plan %v, got %v %v", want, steps, err)
+	}
+	if version, err := db.SchemaVersion(); err != nil || version != 2 {
+		t.Errorf("expected the dry run to keep version 2, got %v %v", version, err)
+	}
+
+	if _, err := db.MigrateSchema(1, false); err != nil {
+		t.Fatalf("MigrateSchema failed: %v", err)
+	}
+	if fields, err := db.Hscan("user:1"); err != nil || string(fields["display"]) != "alice" {
+		t.Errorf("expected migrating down to 1 to undo the rename only, got %v %v", fields, err)
+	}
+	if _, err := db.MigrateSchema(3, false); err == nil {
+		t.Errorf("expected an unknown version to fail")
+	}
+	db.Close()
+
+	// A database migrated past the known migrations is refused
+	db, err = Open(path, WithMigrations(testMigrations()...))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.Close()
+	if _, err := Open(path, WithMigrations(testMigrations()[0])); err == nil {
+		t.Errorf("expected a newer schema to be refused")
+	}
+}
+
+// TestMigrationFailure tests that a failing migration leaves the database at the previous version.
+func TestMigrationFailure(t *testing.T) {
+	failure := errors.New("failure")
+	migrations := append(testMigrations(), Migration{
+		Version: 3,
+		Name:    "broken",
+		Up: func(tx *Tx) error {
+			if err := tx.Hset("users:1", "broken", []byte("1")); err != nil {
+				return err
+			}
+			return failure
+		},
+	})
+	if _, err := Open("testdata/migration_failure.db", WithMigrations(migrations...)); !errors.Is(err, failure) {
+		t.Fatalf("expected the migration error, got %v", err)
+	}
+
+	db, err := Open("testdata/migration_failure.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if version, err := db.SchemaVersion(); err != nil || version != 2 {
+		t.Errorf("expected version 2, got %v %v", version, err)
+	}
+}
@@ -0,0 +1,395 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go.etcd.io/bbolt"
+)
+
+// textBucket holds the full-text index. Its "docs" bucket maps each indexed field, as the
+// encoded key followed by the field name, to the JSON term frequencies it was indexed
+// with (empty until first indexed); its "terms" bucket holds a bucket per term mapping
+// the fields containing it to their term frequency and length. The "count" and "length"
+// keys hold the number of indexed fields and their total length in terms.
+const textBucket = internalPrefix + "text"
+
+var (
+	textDocsKey   = []byte("docs")
+	textTermsKey  = []byte("terms")
+	textCountKey  = []byte("count")
+	textLengthKey = []byte("length")
+)
+
+// maxTermLength is the length in bytes above which tokens are not indexed.
+const maxTermLength = 128
+
+// BM25 parameters used to rank search results.
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// TextOptions configures how text is split into terms, both when indexing and searching.
+type TextOptions struct {
+	// Stemmer, if not nil, reduces each term to its stem, so that different forms of a
+	// word match each other. StemEnglish suits English text.
+	Stemmer func(term string) string
+
+	// StopWords lists terms, before stemming, that are neither indexed nor searched.
+	StopWords []string
+
+	// MinLength is the length in characters below which terms are ignored.
+	MinLength int
+}
+
+// WithTextSearch configures the tokenization of IndexText and Search. By default text is
+// lowercased and split into runs of letters and digits, and every run is a term. Changing
+// the options does not reindex the text already indexed.
+func WithTextSearch(opts TextOptions) Option {
+	return func(o *options) {
+		o.text = opts
+	}
+}
+
+// SearchResult is a hash field matching a search.
+type SearchResult struct {
+	Key   string
+	Field string
+	Score float64 // Relevance, higher is better
+}
+
+// IndexText sets field of the hash key to text, like Hset, and adds it to the full-text
+// index. The index follows later writes: setting the field again reindexes it, and
+// deleting the field or the key, or letting it expire, removes it. Renaming or copying
+// the key carries its indexed fields along.
+func (db *DB) IndexText(key, field, text string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("IndexText", key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		docs, err := textDocs(tx.Tx)
+		if err != nil {
+			return err
+		}
+		id := textDocID(key, field)
+		if _, ok := getField(docs, string(id)); !ok {
+			if err := docs.Put(id, []byte{}); err != nil {
+				return err
+			}
+		}
+		tx.record(Event{Type: EventHset, Key: key, Field: field, Value: []byte(text)})
+		return bucket.Put([]byte(field), []byte(text)) // Indexed by updateTextIndex
+	})
+}
+
+// Search returns up to limit indexed fields of db containing any of the terms of query,
+// best match first, ranked with BM25. limit <= 0 means no limit. Term statistics are
+// shared by every namespace.
+func (db *DB) Search(query string, limit int) ([]SearchResult, error) {
+	terms := db.tokenize(query)
+	var results []SearchResult
+	err := db.view("Search", "", func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(textBucket))
+		if bucket == nil {
+			return nil
+		}
+		termsBucket := bucket.Bucket(textTermsKey)
+		if termsBucket == nil {
+			return nil
+		}
+		count := float64(decodeToken(bucket.Get(textCountKey)))
+		avg := float64(decodeToken(bucket.Get(textLengthKey))) / max(count, 1)
+
+		scores := make(map[string]float64)
+		seen := make(map[string]bool)
+		for _, term := range terms {
+			if seen[term] {
+				continue
+			}
+			seen[term] = true
+			postings := termsBucket.Bucket([]byte(term))
+			if postings == nil {
+				continue
+			}
+			df := float64(postings.Stats().KeyN)
+			idf := math.Log(1 + (count-df+0.5)/(df+0.5))
+			postings.ForEach(func(id, v []byte) error {
+				if len(v) != 8 {
+					return nil
+				}
+				tf := float64(binary.BigEndian.Uint32(v))
+				length := float64(binary.BigEndian.Uint32(v[4:]))
+				scores[string(id)] += idf * tf * (bm25K1 + 1) / (tf + bm25K1*(1-bm25B+bm25B*length/max(avg, 1)))
+				return nil
+			})
+		}
+
+		for id, score := range scores {
+			name, field, ok := splitTextDocID([]byte(id))
+			if !ok {
+				continue
+			}
+			key, ok := db.userKey(name)
+			if !ok || db.liveBucket(tx, name) == nil {
+				continue
+			}
+			results = append(results, SearchResult{Key: key, Field: field, Score: score})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		a, b := results[i], results[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		if a.Key != b.Key {
+			return a.Key < b.Key
+		}
+		return a.Field < b.Field
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results, nil
+}
+
+// tokenize splits text into terms according to the text search options.
+func (db *DB) tokenize(text string) []string {
+	opts := db.opts.text
+	var terms []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if len([]rune(word)) < opts.MinLength || len(word) > maxTermLength {
+			continue
+		}
+		stop := false
+		for _, w := range opts.StopWords {
+			if strings.EqualFold(w, word) {
+				stop = true
+				break
+			}
+		}
+		if stop {
+			continue
+		}
+		if opts.Stemmer != nil {
+			word = opts.Stemmer(word)
+		}
+		if word != "" {
+			terms = append(terms, word)
+		}
+	}
+	return terms
+}
+
+// StemEnglish reduces an English word to a stem by removing common inflections, such as
+// plurals and the -ed and -ing forms, so that "indexes", "indexed" and "indexing" all
+// become "index". It is much simpler than a full Porter stemmer and meant to be passed
+// as TextOptions.Stemmer.
+func StemEnglish(word string) string {
+	if len(word) <= 3 {
+		return word
+	}
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && !strings.HasSuffix(word, "us"):
+		word = word[:len(word)-1]
+	}
+	for _, suffix := range []string{"ingly", "edly", "ing", "ed", "ly"} {
+		stem, ok := strings.CutSuffix(word, suffix)
+		if !ok || len(stem) < 3 || !strings.ContainsAny(stem, "aeiouy") {
+			continue
+		}
+		word = stem
+		if n := len(word); word[n-1] == word[n-2] && word[n-1] < utf8.RuneSelf && !strings.ContainsRune("aeioulsz", rune(word[n-1])) {
+			word = word[:n-1] // running -> run
+		}
+		break
+	}
+	if n := len(word); n > 3 && word[n-1] == 'e' {
+		word = word[:n-1]
+	}
+	return word
+}
+
+// updateTextIndex brings the full-text index up to date with the keys changed by tx.
+func (db *DB) updateTextIndex(tx *txn) error {
+	bucket := tx.Bucket([]byte(textBucket))
+	if bucket == nil || len(tx.events) == 0 {
+		return nil
+	}
+	docs := bucket.Bucket(textDocsKey)
+	if docs == nil {
+		return nil
+	}
+
+	for _, ev := range tx.events {
+		if ev.Field != "" && (ev.Type == EventHset || ev.Type == EventHdel) {
+			id := textDocID(ev.Key, ev.Field)
+			if _, ok := getField(docs, string(id)); ok {
+				if err := db.reindexText(tx.Tx, bucket, id); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		ids := textDocsOf(docs, ev.Key)
+		if ev.Target != "" && (ev.Type == EventRename || ev.Type == EventCopy) {
+			for _, id := range ids {
+				_, field, _ := splitTextDocID(id)
+				target := textDocID(ev.Target, field)
+				if err := docs.Put(target, []byte{}); err != nil {
+					return err
+				}
+			}
+			ids = append(ids, textDocsOf(docs, ev.Target)...)
+		}
+		for _, id := range ids {
+			if err := db.reindexText(tx.Tx, bucket, id); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reindexText replaces the postings of the indexed field id by those of its current value,
+// or removes it from the index if the field no longer exists.
+func (db *DB) reindexText(tx *bbolt.Tx, bucket *bbolt.Bucket, id []byte) error {
+	docs := bucket.Bucket(textDocsKey)
+	terms, err := bucket.CreateBucketIfNotExists(textTermsKey)
+	if err != nil {
+		return err
+	}
+	count := decodeToken(bucket.Get(textCountKey))
+	length := decodeToken(bucket.Get(textLengthKey))
+
+	if old := docs.Get(id); len(old) > 0 {
+		var freqs map[string]uint32
+		if err := json.Unmarshal(old, &freqs); err != nil {
+			return fmt.Errorf("failed to decode text index entry: %v", err)
+		}
+		for term, tf := range freqs {
+			if postings := terms.Bucket([]byte(term)); postings != nil {
+				if err := postings.Delete(id); err != nil {
+					return err
+				}
+				if k, _ := postings.Cursor().First(); k == nil {
+					if err := terms.DeleteBucket([]byte(term)); err != nil {
+						return err
+					}
+				}
+			}
+			length -= uint64(tf)
+		}
+		count--
+	}
+
+	value, ok := textValue(tx, id)
+	if !ok {
+		if err := docs.Delete(id); err != nil {
+			return err
+		}
+	} else {
+		tokens := db.tokenize(string(value))
+		freqs := make(map[string]uint32)
+		for _, term := range tokens {
+			freqs[term]++
+		}
+		for term, tf := range freqs {
+			postings, err := terms.CreateBucketIfNotExists([]byte(term))
+			if err != nil {
+				return err
+			}
+			v := make([]byte, 8)
+			binary.BigEndian.PutUint32(v, tf)
+			binary.BigEndian.PutUint32(v[4:], uint32(len(tokens)))
+			if err := postings.Put(id, v); err != nil {
+				return err
+			}
+		}
+		data, err := json.Marshal(freqs)
+		if err != nil {
+			return err
+		}
+		if err := docs.Put(id, data); err != nil {
+			return err
+		}
+		count++
+		length += uint64(len(tokens))
+	}
+
+	if err := bucket.Put(textCountKey, encodeSeq(count)); err != nil {
+		return err
+	}
+	return bucket.Put(textLengthKey, encodeSeq(length))
+}
+
+// textValue returns the current value of the indexed field id, if it still exists.
+func textValue(tx *bbolt.Tx, id []byte) ([]byte, bool) {
+	name, field, ok := splitTextDocID(id)
+	if !ok {
+		return nil, false
+	}
+	hash := tx.Bucket([]byte(name))
+	if hash == nil || keyType(tx, []byte(name)) != typeHash {
+		return nil, false
+	}
+	return getField(hash, field)
+}
+
+// textDocs returns the bucket of indexed fields, creating the full-text index if needed.
+func textDocs(tx *bbolt.Tx) (*bbolt.Bucket, error) {
+	bucket, err := tx.CreateBucketIfNotExists([]byte(textBucket))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create text index bucket: %v", err)
+	}
+	return bucket.CreateBucketIfNotExists(textDocsKey)
+}
+
+// textDocsOf returns the ids of the indexed fields of the hash stored under name.
+func textDocsOf(docs *bbolt.Bucket, name string) [][]byte {
+	prefix := appendIndexValue(nil, []byte(name))
+	var ids [][]byte
+	c := docs.Cursor()
+	for k, _ := c.Seek(prefix); k != nil && bytes.HasPrefix(k, prefix); k, _ = c.Next() {
+		ids = append(ids, bytes.Clone(k))
+	}
+	return ids
+}
+
+// textDocID identifies field of the hash stored under name in the full-text index.
+func textDocID(name, field string) []byte {
+	return append(appendIndexValue(nil, []byte(name)), field...)
+}
+
+// splitTextDocID splits an id made by textDocID into the hash name and field.
+func splitTextDocID(id []byte) (name, field string, ok bool) {
+	key, rest, ok := decodeIndexValue(id)
+	return string(key), string(rest), ok
+}
@@ -0,0 +1,103 @@
+package jungledb
+
+import (
+	"reflect"
+	"testing"
+)
+
+// searchKeys returns the keys of results.
+func searchKeys(results []SearchResult) []string {
+	keys := make([]string, len(results))
+	for i, r := range results {
+		keys[i] = r.Key
+	}
+	return keys
+}
+
+// TestSearch tests ranking, stemming and stop words, and that the full-text index follows
+// writes to the indexed fields.
+func TestSearch(t *testing.T) {
+	db, err := Open("testdata/search.db", WithTextSearch(TextOptions{Stemmer: StemEnglish, StopWords: []string{"the", "a"}}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	docs := map[string]string{
+		"doc:1": "The quick brown fox jumps over the lazy dog",
+		"doc:2": "Indexing documents: an index of indexed documents",
+		"doc:3": "A fox, a fox, a fox in the henhouse",
+	}
+	for key, text := range docs {
+		if err := db.IndexText(key, "body", text); err != nil {
+			t.Fatalf("IndexText failed: %v", err)
+		}
+	}
+	if v, err := db.Hget("doc:1", "body"); err != nil || string(v) != docs["doc:1"] {
+		t.Errorf("expected IndexText to store the text, got %q %v", v, err)
+	}
+
+	results, err := db.Search("foxes", 0)
+	if err != nil {
+		t.Fatalf("Search failed: %v", err)
+	}
+	if keys := searchKeys(results); !reflect.DeepEqual(keys, []string{"doc:3", "doc:1"}) {
+		t.Errorf("expected [doc:3 doc:1], got %v", keys)
+	}
+	if results[0].Field != "body" || results[0].Score <= results[1].Score {
+		t.Errorf("expected ranked results on field body, got %+v", results)
+	}
+	if results, err := db.Search("index", 1); err != nil || !reflect.DeepEqual(searchKeys(results), []string{"doc:2"}) {
+		t.Errorf("expected [doc:2] through stemming, got %v %v", results, err)
+	}
+	if results, err := db.Search("the", 0); err != nil || len(results) != 0 {
+		t.Errorf("expected stop words to match nothing, got %v %v", results, err)
+	}
+
+	// Writes to indexed fields keep the index up to date
+	if err := db.Hset("doc:1", "body", []byte("a lazy cat")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if results, err := db.Search("fox", 0); err != nil || !reflect.DeepEqual(searchKeys(results), []string{"doc:3"}) {
+		t.Errorf("expected [doc:3] after rewriting doc:1, got %v %v", results, err)
+	}
+	if err := db.Rename("doc:1", "doc:4"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if results, err := db.Search("cat", 0); err != nil || !reflect.DeepEqual(searchKeys(results), []string{"doc:4"}) {
+		t.Errorf("expected [doc:4] after the rename, got %v %v", results, err)
+	}
+	if err := db.HdelBucket("doc:3"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if results, err := db.Search("fox", 0); err != nil || len(results) != 0 {
+		t.Errorf("expected nothing after deleting doc:3, got %v %v", results, err)
+	}
+
+	// Namespaces see their own documents only
+	if err := db.Namespace("tenant").IndexText("doc:1", "title", "lazy afternoon"); err != nil {
+		t.Fatalf("IndexText failed: %v", err)
+	}
+	if results, err := db.Namespace("tenant").Search("lazy", 0); err != nil || !reflect.DeepEqual(searchKeys(results), []string{"doc:1"}) {
+		t.Errorf("expected [doc:1] in the namespace, got %v %v", results, err)
+	}
+	if results, err := db.Search("lazy", 0); err != nil || !reflect.DeepEqual(searchKeys(results), []string{"doc:4"}) {
+		t.Errorf("expected [doc:4], got %v %v", results, err)
+	}
+}
+
+// TestStemEnglish tests that common forms of a word share a stem.
+func TestStemEnglish(t *testing.T) {
+	for _, group := range [][]string{
+		{"index", "indexes", "indexed", "indexing"},
+		{"run", "runs", "running"},
+		{"study", "studies"},
+		{"make", "makes", "making"},
+	} {
+		for _, word := range group[1:] {
+			if a, b := StemEnglish(group[0]), StemEnglish(word); a != b {
+				t.Errorf("expected %s and %s to share a stem, got %s and %s", group[0], word, a, b)
+			}
+		}
+	}
+}
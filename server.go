@@ -0,0 +1,910 @@
+package jungledb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// respCommand describes a command served by ServeRESP.
+type respCommand struct {
+	// arity follows Redis: a positive value is the exact argument count including the
+	// command name, a negative value is the minimum.
+	arity   int
+	handler func(db *DB, w *respWriter, args [][]byte) error
+	access  Access // Needed in the connection's namespace, see WithACL
+	// lastKey is the position of the last key argument, counting the command name as 0
+	// as Redis does; keys start at position 1. 0 means the command takes no keys and -1
+	// that every argument is a key.
+	lastKey int
+}
+
+// respCommands maps upper-cased command names to their implementations.
+var respCommands map[string]respCommand
+
+func init() {
+	respCommands = map[string]respCommand{
+		"PING":      {-1, respPing, AccessNone, 0},
+		"ECHO":      {2, respEcho, AccessNone, 0},
+		"SELECT":    {2, respSelect, AccessNone, 0},
+		"COMMAND":   {-1, respCommandInfo, AccessNone, 0},
+		"HSET":      {-4, respHset, AccessReadWrite, 1},
+		"HMSET":     {-4, respHmset, AccessReadWrite, 1},
+		"HGET":      {3, respHget, AccessRead, 1},
+		"HMGET":     {-3, respHmget, AccessRead, 1},
+		"HDEL":      {-3, respHdel, AccessReadWrite, 1},
+		"HEXISTS":   {3, respHexists, AccessRead, 1},
+		"HGETALL":   {2, respHgetall, AccessRead, 1},
+		"HPREFIX":   {3, respHprefix, AccessRead, 1},
+		"HKEYS":     {2, respHkeys, AccessRead, 1},
+		"HVALS":     {2, respHvals, AccessRead, 1},
+		"HLEN":      {2, respHlen, AccessRead, 1},
+		"HINCRBY":   {4, respHincrby, AccessReadWrite, 1},
+		"HGETINT":   {3, respHgetint, AccessRead, 1},
+		"ZADD":      {-4, respZadd, AccessReadWrite, 1},
+		"ZREM":      {-3, respZrem, AccessReadWrite, 1},
+		"ZRANGE":    {-4, respZrange, AccessRead, 1},
+		"ZREVRANGE": {-4, respZrange, AccessRead, 1},
+		"ZSCORE":    {3, respZscore, AccessRead, 1},
+		"ZCARD":     {2, respZcard, AccessRead, 1},
+		"DEL":       {-2, respDel, AccessReadWrite, -1},
+		"EXISTS":    {-2, respExists, AccessRead, -1},
+		"TYPE":      {2, respType, AccessRead, 1},
+		"KEYS":      {2, respKeys, AccessRead, 0},
+		"DBSIZE":    {1, respDBSize, AccessRead, 0},
+		"RENAME":    {3, respRename, AccessReadWrite, 2},
+		"FLUSHALL":  {-1, respFlushAll, AccessReadWrite, 0},
+		"FLUSHDB":   {-1, respFlushAll, AccessReadWrite, 0},
+		"SLOWLOG":   {-2, respSlowLog, AccessReadWrite, 0},
+		"EXPIRE":    {3, respExpire, AccessReadWrite, 1},
+		"PEXPIRE":   {3, respExpire, AccessReadWrite, 1},
+		"TTL":       {2, respTTL, AccessRead, 1},
+		"PTTL":      {2, respTTL, AccessRead, 1},
+		"PERSIST":   {2, respPersist, AccessReadWrite, 1},
+		"TRYLOCK":   {4, respTrylock, AccessReadWrite, 0},
+		"UNLOCK":    {3, respUnlock, AccessReadWrite, 0},
+	}
+}
+
+// ServeRESP accepts clients speaking the Redis protocol on ln, so redis-cli and Redis client
+// libraries can use the database. Supported commands are mapped onto the DB methods:
+// PING, ECHO, SELECT 0, HSET, HMSET, HGET, HMGET, HDEL, HEXISTS, HGETALL, HKEYS, HVALS,
+// HLEN, HINCRBY, ZADD, ZREM, ZRANGE, ZREVRANGE, ZSCORE, ZCARD, DEL, EXISTS, TYPE, KEYS,
+// DBSIZE, RENAME, FLUSHALL, FLUSHDB, EXPIRE, PEXPIRE, TTL, PTTL, PERSIST and
+// SLOWLOG GET|LEN|RESET. HINCRBY counters are stored as 8-byte integers,
+// as with Hincr, so HGET returns them in binary form. Extensions mirror DB methods:
+// HPREFIX key prefix (Hprefix, replying like HGETALL) and HGETINT key field (HgetInt);
+// TRYLOCK name owner milliseconds and UNLOCK name owner serve locks (see TryLockToken).
+// NAMESPACE name switches the connection to a namespace (see Namespace), "" being the
+// root. With WithACL, clients must first AUTH [username] token and may only run the
+// commands their access to the namespace allows. With WithTLS, clients connect over TLS.
+// Pipelined commands are supported. ServeRESP returns when ln is closed, after disconnecting any remaining clients.
+func (db *DB) ServeRESP(ln net.Listener) error {
+	return db.serve(ln, db.serveRESPConn)
+}
+
+// ListenAndServeRESP listens on the TCP address addr and calls ServeRESP.
+func (db *DB) ListenAndServeRESP(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return db.ServeRESP(ln)
+}
+
+// serveConns runs handle for every connection accepted on ln until ln is closed,
+// then closes the connections still open and waits for their handlers to return.
+func serveConns(ln net.Listener, handle func(conn net.Conn)) error {
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		conns = make(map[net.Conn]struct{})
+	)
+	defer func() {
+		mu.Lock()
+		for conn := range conns {
+			conn.Close()
+		}
+		mu.Unlock()
+		wg.Wait()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+
+		mu.Lock()
+		conns[conn] = struct{}{}
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				mu.Lock()
+				delete(conns, conn)
+				mu.Unlock()
+				conn.Close()
+			}()
+			handle(conn)
+		}()
+	}
+}
+
+// serveRESPConn executes commands from one client until it disconnects or sends QUIT.
+func (db *DB) serveRESPConn(conn net.Conn) {
+	r := newRESPReader(conn)
+	w := newRESPWriter(conn)
+	sess := respSession{addr: conn.RemoteAddr().String()}
+	defer func() {
+		// A bug reachable by one client must not take down the others
+		if v := recover(); v != nil {
+			db.log.Error("RESP connection failed", "client", sess.addr, "panic", v)
+		}
+	}()
+
+	for {
+		args, err := r.readCommand()
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, net.ErrClosed) {
+				w.writeError("ERR Protocol error: " + err.Error())
+				w.flush()
+			}
+			return
+		}
+
+		name := strings.ToUpper(string(args[0]))
+		if name == "QUIT" {
+			w.writeSimple("OK")
+			w.flush()
+			return
+		}
+		db.execRESP(w, &sess, name, args)
+
+		// Reply to a pipelined batch in one write
+		if r.r.Buffered() == 0 {
+			if err := w.flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// respSession is the state of one RESP connection.
+type respSession struct {
+	addr  string // Of the client
+	token string // Presented with AUTH
+	ns    string // Selected with NAMESPACE
+}
+
+// execRESP runs one command and writes its reply.
+func (db *DB) execRESP(w *respWriter, sess *respSession, name string, args [][]byte) {
+	switch name {
+	case "AUTH":
+		db.respAuth(w, sess, args)
+		return
+	case "NAMESPACE":
+		db.respNamespace(w, sess, args)
+		return
+	}
+	cmd, ok := respCommands[name]
+	if !ok {
+		w.writeError(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+		return
+	}
+	if (cmd.arity > 0 && len(args) != cmd.arity) || (cmd.arity < 0 && len(args) < -cmd.arity) {
+		w.writeError(fmt.Sprintf("ERR wrong number of arguments for '%s' command", strings.ToLower(name)))
+		return
+	}
+	ns := sess.ns
+	if name == "SLOWLOG" {
+		ns = "" // The slow log covers every namespace
+	}
+	h := db
+	var err error
+	if cmd.access != AccessNone { // Such as PING, allowed before AUTH
+		h, err = db.Authorize(sess.token, ns, cmd.access)
+	}
+	if err == nil {
+		err = checkKey(keyArgs(cmd, args)...)
+	}
+	if err == nil {
+		err = cmd.handler(h, w, args)
+	}
+	if err != nil {
+		writeRESPError(w, err)
+	}
+}
+
+// keyArgs returns the key arguments of a command, see respCommand.lastKey.
+func keyArgs(cmd respCommand, args [][]byte) []string {
+	last := cmd.lastKey
+	if last < 0 {
+		last = len(args) - 1
+	}
+	keys := make([]string, 0, last)
+	for _, arg := range args[1 : last+1] {
+		keys = append(keys, string(arg))
+	}
+	return keys
+}
+
+// writeRESPError replies with err, using the Redis error code that matches it.
+func writeRESPError(w *respWriter, err error) {
+	switch {
+	case errors.Is(err, ErrUnauthenticated):
+		w.writeError("NOAUTH Authentication required.")
+	case errors.Is(err, ErrPermissionDenied):
+		w.writeError("NOPERM " + err.Error())
+	case errors.Is(err, ErrWrongType):
+		w.writeError("WRONGTYPE Operation against a key holding the wrong kind of value")
+	case errors.Is(err, ErrReadOnly):
+		w.writeError("READONLY You can't write against a read only database.")
+	default:
+		msg := err.Error()
+		if !strings.HasPrefix(msg, "ERR ") && !strings.HasPrefix(msg, "WRONGTYPE ") {
+			msg = "ERR " + msg
+		}
+		w.writeError(msg)
+	}
+}
+
+// respAuth serves AUTH [username] token, authenticating the connection as a user of the
+// ACL set with WithACL.
+func (db *DB) respAuth(w *respWriter, sess *respSession, args [][]byte) {
+	if len(args) != 2 && len(args) != 3 {
+		w.writeError("ERR wrong number of arguments for 'auth' command")
+		return
+	}
+	if db.opts.acl == nil {
+		w.writeError("ERR AUTH called without any ACL configured")
+		return
+	}
+	token := string(args[len(args)-1])
+	u, ok := db.opts.acl.user(token)
+	if !ok || (len(args) == 3 && u.Name != string(args[1])) {
+		db.authFailed("authentication failed", "reason", "AUTH rejected", "addr", sess.addr)
+		w.writeError("WRONGPASS invalid username-password pair or user is disabled.")
+		return
+	}
+	sess.token = token
+	w.writeSimple("OK")
+}
+
+// respNamespace serves NAMESPACE name, which makes later commands of the connection work
+// in the namespace (see Namespace); an empty name selects the root namespace.
+func (db *DB) respNamespace(w *respWriter, sess *respSession, args [][]byte) {
+	if len(args) != 2 {
+		w.writeError("ERR wrong number of arguments for 'namespace' command")
+		return
+	}
+	if _, err := db.Authorize(sess.token, string(args[1]), AccessRead); err != nil {
+		writeRESPError(w, err)
+		return
+	}
+	sess.ns = string(args[1])
+	w.writeSimple("OK")
+}
+
+// errSyntax is returned for malformed command options.
+var errSyntax = errors.New("ERR syntax error")
+
+// writeBulkArray writes a slice of bulk strings as an array.
+func (w *respWriter) writeBulkArray(items [][]byte) {
+	w.writeArrayHeader(len(items))
+	for _, item := range items {
+		w.writeBulk(item)
+	}
+}
+
+func respPing(db *DB, w *respWriter, args [][]byte) error {
+	if len(args) > 1 {
+		w.writeBulk(args[1])
+		return nil
+	}
+	w.writeSimple("PONG")
+	return nil
+}
+
+func respEcho(db *DB, w *respWriter, args [][]byte) error {
+	w.writeBulk(args[1])
+	return nil
+}
+
+func respSelect(db *DB, w *respWriter, args [][]byte) error {
+	if string(args[1]) != "0" {
+		return errors.New("ERR DB index is out of range")
+	}
+	w.writeSimple("OK")
+	return nil
+}
+
+// respCommandInfo answers COMMAND (used by redis-cli on connect) with an empty list.
+func respCommandInfo(db *DB, w *respWriter, args [][]byte) error {
+	w.writeArrayHeader(0)
+	return nil
+}
+
+func respHset(db *DB, w *respWriter, args [][]byte) error {
+	if len(args)%2 != 0 {
+		return errors.New("ERR wrong number of arguments for 'hset' command")
+	}
+	added := 0
+	key := db.nsKey(string(args[1]))
+	err := db.update("Hset", key, func(tx *txn) error {
+		for i := 2; i < len(args); i += 2 {
+			field := string(args[i])
+			if bucket := tx.Bucket([]byte(key)); bucket == nil {
+				added++
+			} else if _, ok := getField(bucket, field); !ok {
+				added++
+			}
+			if err := hset(tx, key, field, args[i+1]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.writeInt(int64(added))
+	return nil
+}
+
+func respHmset(db *DB, w *respWriter, args [][]byte) error {
+	if len(args)%2 != 0 {
+		return errors.New("ERR wrong number of arguments for 'hmset' command")
+	}
+	fields := make(map[string][]byte, (len(args)-2)/2)
+	for i := 2; i < len(args); i += 2 {
+		fields[string(args[i])] = args[i+1]
+	}
+	if err := db.Hmset(string(args[1]), fields); err != nil {
+		return err
+	}
+	w.writeSimple("OK")
+	return nil
+}
+
+func respHget(db *DB, w *respWriter, args [][]byte) error {
+	value, err := db.Hget(string(args[1]), string(args[2]))
+	if err != nil {
+		return err
+	}
+	w.writeBulk(value)
+	return nil
+}
+
+func respHmget(db *DB, w *respWriter, args [][]byte) error {
+	fields := make([]string, len(args)-2)
+	for i, arg := range args[2:] {
+		fields[i] = string(arg)
+	}
+	values, err := db.Hmget(string(args[1]), fields)
+	if err != nil {
+		return err
+	}
+	w.writeBulkArray(values)
+	return nil
+}
+
+func respHdel(db *DB, w *respWriter, args [][]byte) error {
+	removed := 0
+	key := db.nsKey(string(args[1]))
+	fields := make([]string, len(args)-2)
+	for i, arg := range args[2:] {
+		fields[i] = string(arg)
+	}
+	err := db.update("Hdel", key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
+		bucket := tx.Bucket([]byte(key))
+		if bucket == nil {
+			return nil
+		}
+		if len(fields) > 1 {
+			if err := db.saveUndo(tx, "Hmdel", key, false, fields, nil); err != nil {
+				return err
+			}
+		}
+		for _, field := range fields {
+			if _, ok := getField(bucket, field); !ok {
+				continue
+			}
+			if err := hdel(tx, key, field); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.writeInt(int64(removed))
+	return nil
+}
+
+func respHexists(db *DB, w *respWriter, args [][]byte) error {
+	exists, err := db.HhasKey(string(args[1]), string(args[2]))
+	if err != nil {
+		return err
+	}
+	if exists {
+		w.writeInt(1)
+	} else {
+		w.writeInt(0)
+	}
+	return nil
+}
+
+// hashEntries returns the fields and values of a hash in field order.
+func (db *DB) hashEntries(key string) (fields, values [][]byte, err error) {
+	key = db.nsKey(key)
+	err = db.view("Hscan", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			fields = append(fields, append([]byte(nil), k...))
+			values = append(values, append([]byte(nil), v...))
+			return nil
+		})
+	})
+	return fields, values, err
+}
+
+func respHgetall(db *DB, w *respWriter, args [][]byte) error {
+	fields, values, err := db.hashEntries(string(args[1]))
+	if err != nil {
+		return err
+	}
+	w.writeArrayHeader(2 * len(fields))
+	for i := range fields {
+		w.writeBulk(fields[i])
+		w.writeBulk(values[i])
+	}
+	return nil
+}
+
+func respHprefix(db *DB, w *respWriter, args [][]byte) error {
+	fields, err := db.Hprefix(string(args[1]), string(args[2]))
+	if err != nil {
+		return err
+	}
+	w.writeArrayHeader(2 * len(fields))
+	for field, value := range fields {
+		w.writeBulk([]byte(field))
+		w.writeBulk(value)
+	}
+	return nil
+}
+
+func respHkeys(db *DB, w *respWriter, args [][]byte) error {
+	fields, _, err := db.hashEntries(string(args[1]))
+	if err != nil {
+		return err
+	}
+	w.writeBulkArray(fields)
+	return nil
+}
+
+func respHvals(db *DB, w *respWriter, args [][]byte) error {
+	_, values, err := db.hashEntries(string(args[1]))
+	if err != nil {
+		return err
+	}
+	w.writeBulkArray(values)
+	return nil
+}
+
+func respHlen(db *DB, w *respWriter, args [][]byte) error {
+	var n int
+	key := db.nsKey(string(args[1]))
+	err := db.view("Hlen", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil {
+			return err
+		}
+		n = bucket.Stats().KeyN
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.writeInt(int64(n))
+	return nil
+}
+
+func respHincrby(db *DB, w *respWriter, args [][]byte) error {
+	delta, err := strconv.ParseInt(string(args[3]), 10, 64)
+	if err != nil {
+		return errors.New("ERR value is not an integer or out of range")
+	}
+	n, err := db.Hincr(string(args[1]), string(args[2]), delta)
+	if err != nil {
+		return err
+	}
+	w.writeInt(n)
+	return nil
+}
+
+func respHgetint(db *DB, w *respWriter, args [][]byte) error {
+	n, err := db.HgetInt(string(args[1]), string(args[2]))
+	if err != nil {
+		return err
+	}
+	w.writeInt(n)
+	return nil
+}
+
+func respZadd(db *DB, w *respWriter, args [][]byte) error {
+	if len(args)%2 != 0 {
+		return errSyntax
+	}
+	scores := make([]float64, 0, (len(args)-2)/2)
+	for i := 2; i < len(args); i += 2 {
+		score, err := parseScore(string(args[i]))
+		if err != nil {
+			return err
+		}
+		scores = append(scores, score)
+	}
+
+	added := 0
+	key := db.nsKey(string(args[1]))
+	err := db.update("Zadd", key, func(tx *txn) error {
+		for i, score := range scores {
+			member := args[3+2*i]
+			if idx := membersBucket(tx.Tx, key); idx == nil || idx.Get(member) == nil {
+				added++
+			}
+			if err := zadd(tx, key, score, string(member)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.writeInt(int64(added))
+	return nil
+}
+
+func respZrem(db *DB, w *respWriter, args [][]byte) error {
+	removed := 0
+	key := db.nsKey(string(args[1]))
+	err := db.update("Zrem", key, func(tx *txn) error {
+		for _, member := range args[2:] {
+			if idx := membersBucket(tx.Tx, key); idx == nil || idx.Get(member) == nil {
+				continue
+			}
+			if err := zrem(tx, key, string(member)); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.writeInt(int64(removed))
+	return nil
+}
+
+// respZrange serves ZRANGE and ZREVRANGE, with optional WITHSCORES.
+func respZrange(db *DB, w *respWriter, args [][]byte) error {
+	start, err1 := strconv.Atoi(string(args[2]))
+	stop, err2 := strconv.Atoi(string(args[3]))
+	if err1 != nil || err2 != nil {
+		return errors.New("ERR value is not an integer or out of range")
+	}
+	withScores := false
+	switch {
+	case len(args) == 5 && strings.EqualFold(string(args[4]), "WITHSCORES"):
+		withScores = true
+	case len(args) != 4:
+		return errSyntax
+	}
+
+	key := string(args[1])
+	var members []string
+	var err error
+	if strings.EqualFold(string(args[0]), "ZREVRANGE") {
+		members, err = db.Zrevrange(key, start, stop)
+	} else {
+		members, err = db.Zrange(key, start, stop)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !withScores {
+		w.writeArrayHeader(len(members))
+		for _, member := range members {
+			w.writeBulk([]byte(member))
+		}
+		return nil
+	}
+
+	var reply [][]byte
+	key = db.nsKey(key)
+	err = db.view("Zrange", key, func(tx *bbolt.Tx) error {
+		idx := membersBucket(tx, key)
+		if idx == nil {
+			return nil
+		}
+		for _, member := range members {
+			v := idx.Get([]byte(member))
+			if len(v) != 8 {
+				continue // Removed since the range was read
+			}
+			score := math.Float64frombits(binary.BigEndian.Uint64(v))
+			reply = append(reply, []byte(member), []byte(formatScore(score)))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.writeBulkArray(reply)
+	return nil
+}
+
+func respZscore(db *DB, w *respWriter, args [][]byte) error {
+	var reply []byte
+	key := db.nsKey(string(args[1]))
+	err := db.view("Zscore", key, func(tx *bbolt.Tx) error {
+		idx := membersBucket(tx, key)
+		if idx == nil {
+			return nil
+		}
+		if v := idx.Get(args[2]); len(v) == 8 {
+			reply = []byte(formatScore(math.Float64frombits(binary.BigEndian.Uint64(v))))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.writeBulk(reply)
+	return nil
+}
+
+func respZcard(db *DB, w *respWriter, args [][]byte) error {
+	n, err := db.Zcard(string(args[1]))
+	if err != nil {
+		return err
+	}
+	w.writeInt(int64(n))
+	return nil
+}
+
+func respDel(db *DB, w *respWriter, args [][]byte) error {
+	deleted := 0
+	err := db.update("Delete", "", func(tx *txn) error {
+		for _, arg := range args[1:] {
+			key := db.nsKey(string(arg))
+			if err := deleteKey(tx, key); errors.Is(err, ErrKeyNotFound) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			tx.record(Event{Type: EventDelete, Key: key})
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.writeInt(int64(deleted))
+	return nil
+}
+
+func respExists(db *DB, w *respWriter, args [][]byte) error {
+	count := 0
+	err := db.view("Exists", "", func(tx *bbolt.Tx) error {
+		for _, arg := range args[1:] {
+			if key := []byte(db.nsKey(string(arg))); tx.Bucket(key) != nil && !isInternalBucket(tx, key) {
+				count++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.writeInt(int64(count))
+	return nil
+}
+
+func respType(db *DB, w *respWriter, args [][]byte) error {
+	t := "none"
+	key := []byte(db.nsKey(string(args[1])))
+	err := db.view("Type", string(key), func(tx *bbolt.Tx) error {
+		if tx.Bucket(key) != nil && !isInternalBucket(tx, key) {
+			t = keyType(tx, key)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	w.writeSimple(t)
+	return nil
+}
+
+func respKeys(db *DB, w *respWriter, args [][]byte) error {
+	keys, _, err := db.ListKeys(string(args[1]), "", 0)
+	if err != nil {
+		return err
+	}
+	w.writeArrayHeader(len(keys))
+	for _, key := range keys {
+		w.writeBulk([]byte(key))
+	}
+	return nil
+}
+
+func respDBSize(db *DB, w *respWriter, args [][]byte) error {
+	keys, _, err := db.ListKeys("", "", 0)
+	if err != nil {
+		return err
+	}
+	w.writeInt(int64(len(keys)))
+	return nil
+}
+
+func respRename(db *DB, w *respWriter, args [][]byte) error {
+	if err := db.Rename(string(args[1]), string(args[2])); err != nil {
+		return err
+	}
+	w.writeSimple("OK")
+	return nil
+}
+
+func respFlushAll(db *DB, w *respWriter, args [][]byte) error {
+	if err := db.FlushAll(); err != nil {
+		return err
+	}
+	w.writeSimple("OK")
+	return nil
+}
+
+// respSlowLog serves SLOWLOG GET [count], LEN and RESET. Entries are reported as in Redis:
+// id, unix time, duration in microseconds and the operation with its key as arguments.
+func respSlowLog(db *DB, w *respWriter, args [][]byte) error {
+	switch sub := strings.ToUpper(string(args[1])); {
+	case sub == "GET" && len(args) <= 3:
+		n := 10
+		if len(args) == 3 {
+			var err error
+			if n, err = strconv.Atoi(string(args[2])); err != nil {
+				return errors.New("ERR value is not an integer or out of range")
+			}
+		}
+		ops := db.SlowLog(n)
+		w.writeArrayHeader(len(ops))
+		for _, op := range ops {
+			w.writeArrayHeader(6)
+			w.writeInt(int64(op.ID))
+			w.writeInt(op.Time.Unix())
+			w.writeInt(op.Duration.Microseconds())
+			cmd := [][]byte{[]byte(op.Op)}
+			if op.Key != "" {
+				cmd = append(cmd, []byte(op.Key))
+			}
+			w.writeBulkArray(cmd)
+			w.writeBulk([]byte{})
+			w.writeBulk([]byte{})
+		}
+	case sub == "LEN" && len(args) == 2:
+		w.writeInt(int64(db.SlowLogLen()))
+	case sub == "RESET" && len(args) == 2:
+		db.ResetSlowLog()
+		w.writeSimple("OK")
+	default:
+		return errSyntax
+	}
+	return nil
+}
+
+func respExpire(db *DB, w *respWriter, args [][]byte) error {
+	n, err := strconv.ParseInt(string(args[2]), 10, 64)
+	if err != nil {
+		return errors.New("ERR value is not an integer or out of range")
+	}
+	unit := time.Second
+	if strings.EqualFold(string(args[0]), "PEXPIRE") {
+		unit = time.Millisecond
+	}
+	if err := db.Expire(string(args[1]), time.Duration(n)*unit); errors.Is(err, ErrKeyNotFound) {
+		w.writeInt(0)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	w.writeInt(1)
+	return nil
+}
+
+// respTTL replies like Redis: -2 for a missing key, -1 for a key without TTL.
+func respTTL(db *DB, w *respWriter, args [][]byte) error {
+	ttl, err := db.TTL(string(args[1]))
+	switch {
+	case errors.Is(err, ErrKeyNotFound):
+		w.writeInt(-2)
+	case err != nil:
+		return err
+	case ttl == 0:
+		w.writeInt(-1)
+	case strings.EqualFold(string(args[0]), "PTTL"):
+		w.writeInt(ttl.Milliseconds())
+	default:
+		w.writeInt(int64((ttl + time.Second/2) / time.Second))
+	}
+	return nil
+}
+
+func respPersist(db *DB, w *respWriter, args [][]byte) error {
+	ttl, err := db.TTL(string(args[1]))
+	if errors.Is(err, ErrKeyNotFound) || (err == nil && ttl == 0) {
+		w.writeInt(0)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if err := db.Persist(string(args[1])); err != nil {
+		return err
+	}
+	w.writeInt(1)
+	return nil
+}
+
+// respTrylock serves TRYLOCK name owner milliseconds, replying with the fencing token
+// of the acquired lock or 0 if another owner holds it (see TryLockToken).
+func respTrylock(db *DB, w *respWriter, args [][]byte) error {
+	ms, err := strconv.ParseInt(string(args[3]), 10, 64)
+	if err != nil || ms <= 0 {
+		return errors.New("ERR invalid expire time in 'trylock' command")
+	}
+	token, _, err := db.TryLockToken(string(args[1]), string(args[2]), time.Duration(ms)*time.Millisecond)
+	if err != nil {
+		return err
+	}
+	w.writeInt(int64(token))
+	return nil
+}
+
+// respUnlock serves UNLOCK name owner, replying 1 if the lock was released and 0 if
+// owner did not hold it.
+func respUnlock(db *DB, w *respWriter, args [][]byte) error {
+	if err := db.Unlock(string(args[1]), string(args[2])); errors.Is(err, ErrLockNotHeld) {
+		w.writeInt(0)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	w.writeInt(1)
+	return nil
+}
@@ -0,0 +1,354 @@
+package jungledb
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestServeRESP tests the Redis protocol server with the internal Redis client.
+func TestServeRESP(t *testing.T) {
+	db, err := Open("testdata/server.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go db.ServeRESP(ln)
+
+	client, err := dialRedis(context.Background(), ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.close()
+
+	tests := []struct {
+		args []string
+		want any
+	}{
+		{[]string{"PING"}, "PONG"},
+		{[]string{"HSET", "user:1", "name", "Alice", "age", "30"}, int64(2)},
+		{[]string{"HSET", "user:1", "name", "Bob"}, int64(0)},
+		{[]string{"HGET", "user:1", "name"}, "Bob"},
+		{[]string{"HGET", "user:1", "missing"}, nil},
+		{[]string{"HMGET", "user:1", "age", "missing"}, []any{[]byte("30"), []byte(nil)}},
+		{[]string{"HGETALL", "user:1"}, []any{[]byte("age"), []byte("30"), []byte("name"), []byte("Bob")}},
+		{[]string{"HLEN", "user:1"}, int64(2)},
+		{[]string{"HEXISTS", "user:1", "age"}, int64(1)},
+		{[]string{"HDEL", "user:1", "age", "missing"}, int64(1)},
+		{[]string{"HINCRBY", "counters", "hits", "5"}, int64(5)},
+		{[]string{"ZADD", "scores", "1", "alice", "2.5", "bob", "inf", "carol"}, int64(3)},
+		{[]string{"ZADD", "scores", "3", "alice"}, int64(0)},
+		{[]string{"ZRANGE", "scores", "0", "-1"}, []any{[]byte("bob"), []byte("alice"), []byte("carol")}},
+		{[]string{"ZREVRANGE", "scores", "0", "0", "WITHSCORES"}, []any{[]byte("carol"), []byte("inf")}},
+		{[]string{"ZSCORE", "scores", "bob"}, "2.5"},
+		{[]string{"ZSCORE", "scores", "nobody"}, nil},
+		{[]string{"ZREM", "scores", "carol", "nobody"}, int64(1)},
+		{[]string{"ZCARD", "scores"}, int64(2)},
+		{[]string{"TYPE", "scores"}, "zset"},
+		{[]string{"TYPE", "scores_members"}, "none"},
+		{[]string{"KEYS", "*"}, []any{[]byte("counters"), []byte("scores"), []byte("user:1")}},
+		{[]string{"EXISTS", "user:1", "nope"}, int64(1)},
+		{[]string{"DEL", "counters", "nope"}, int64(1)},
+		{[]string{"DBSIZE"}, int64(2)},
+		{[]string{"TTL", "user:1"}, int64(-1)},
+		{[]string{"EXPIRE", "user:1", "100"}, int64(1)},
+		{[]string{"TTL", "user:1"}, int64(100)},
+		{[]string{"PERSIST", "user:1"}, int64(1)},
+		{[]string{"TTL", "user:1"}, int64(-1)},
+		{[]string{"EXPIRE", "nope", "100"}, int64(0)},
+		{[]string{"PTTL", "nope"}, int64(-2)},
+	}
+
+	for _, tc := range tests {
+		reply, err := client.do(tc.args...)
+		if err != nil {
+			t.Fatalf("%v failed: %v", tc.args, err)
+		}
+		if b, ok := reply.([]byte); ok {
+			if b == nil {
+				reply = nil
+			} else {
+				reply = string(b)
+			}
+		}
+		if !equalReply(reply, tc.want) {
+			t.Errorf("%v: expected %#v, got %#v", tc.args, tc.want, reply)
+		}
+	}
+
+	// The slow log is served like Redis SLOWLOG
+	db.ResetSlowLog()
+	db.slowOps.add(SlowOp{Op: "Hget", Key: "user:1", Duration: 1500 * time.Microsecond, Time: time.Unix(100, 0)})
+	if reply, err := client.do("SLOWLOG", "LEN"); err != nil || reply != int64(1) {
+		t.Errorf("SLOWLOG LEN: expected 1, got %v %v", reply, err)
+	}
+	reply, err := client.do("SLOWLOG", "GET")
+	if err != nil {
+		t.Fatalf("SLOWLOG GET failed: %v", err)
+	}
+	entries, ok := reply.([]any)
+	if !ok || len(entries) != 1 {
+		t.Fatalf("SLOWLOG GET: expected one entry, got %#v", reply)
+	}
+	if entry := entries[0].([]any); entry[1] != int64(100) || entry[2] != int64(1500) ||
+		!equalReply(entry[3], []any{[]byte("Hget"), []byte("user:1")}) {
+		t.Errorf("SLOWLOG GET entry mismatch: %#v", entry)
+	}
+	if reply, err := client.do("SLOWLOG", "RESET"); err != nil || reply != "OK" || db.SlowLogLen() != 0 {
+		t.Errorf("SLOWLOG RESET failed: %v %v", reply, err)
+	}
+
+	// Errors are reported without dropping the connection
+	if _, err := client.do("NOSUCHCMD"); err == nil {
+		t.Error("unknown command should fail")
+	}
+	if _, err := client.do("HGET", "user:1"); err == nil {
+		t.Error("wrong arity should fail")
+	}
+	if _, err := client.do("ZADD", "scores", "notanumber", "x"); err == nil {
+		t.Error("invalid score should fail")
+	}
+	if reply, err := client.do("PING"); err != nil || reply != "PONG" {
+		t.Errorf("connection unusable after errors: %v %v", reply, err)
+	}
+
+	// Writes are visible through the Go API
+	score, err := db.Zscore("scores", "alice")
+	if err != nil {
+		t.Fatalf("Zscore failed: %v", err)
+	}
+	if score != 3 {
+		t.Errorf("Zscore mismatch: expected 3, got %f", score)
+	}
+}
+
+// equalReply compares decoded RESP replies.
+func equalReply(a, b any) bool {
+	switch av := a.(type) {
+	case []any:
+		bv, ok := b.([]any)
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		for i := range av {
+			if !equalReply(av[i], bv[i]) {
+				return false
+			}
+		}
+		return true
+	case []byte:
+		bv, ok := b.([]byte)
+		return ok && string(av) == string(bv) && (av == nil) == (bv == nil)
+	default:
+		return a == b
+	}
+}
+
+// TestServeRESPMalformed tests that a client announcing a huge array gets a protocol error
+// instead of taking down the server.
+func TestServeRESPMalformed(t *testing.T) {
+	db, err := Open("testdata/server-malformed.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go db.ServeRESP(ln)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	if _, err := conn.Write([]byte("*900000000000000\r\n")); err != nil {
+		t.Fatalf("failed to write: %v", err)
+	}
+	reply, err := newRESPReader(conn).readValue()
+	if err != nil {
+		t.Fatalf("failed to read reply: %v", err)
+	}
+	if e, ok := reply.(respError); !ok || !strings.HasPrefix(string(e), "ERR Protocol error") {
+		t.Fatalf("got reply %#v, want a protocol error", reply)
+	}
+
+	client, err := dialRedis(context.Background(), ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.close()
+	if reply, err := client.do("PING"); err != nil || reply != "PONG" {
+		t.Fatalf("PING after a malformed request: got %#v, %v", reply, err)
+	}
+}
+
+// TestServeRESPReservedKeys tests that the Redis protocol server rejects keys naming
+// another namespace or an internal bucket, whichever argument holds them.
+func TestServeRESPReservedKeys(t *testing.T) {
+	db, err := Open("testdata/server-reserved.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Namespace("billing").Hset("secret", "card", []byte("4242")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go db.ServeRESP(ln)
+
+	client, err := dialRedis(context.Background(), ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.close()
+
+	for _, args := range [][]string{
+		{"HGET", "__jungledb.ns:billing\x00secret", "card"},
+		{"HGETALL", "__jungledb.ns:billing\x00secret"},
+		{"HSET", "__jungledb:sealed", "f", "v"},
+		{"DEL", "other", "__jungledb.lock:job"},
+		{"RENAME", "other", "__jungledb.blob:00"},
+	} {
+		if reply, err := client.do(args...); err == nil || !strings.Contains(err.Error(), "reserved") {
+			t.Errorf("%q: got %#v, %v, want a reserved key error", args, reply, err)
+		}
+	}
+	if value, err := db.Namespace("billing").Hget("secret", "card"); err != nil || string(value) != "4242" {
+		t.Fatalf("Hget in the namespace: got %q, %v, want %q", value, err, "4242")
+	}
+}
+
+// TestServeRESPWrongType tests that HSET and HDEL against a sorted set fail with
+// WRONGTYPE and leave it intact.
+func TestServeRESPWrongType(t *testing.T) {
+	db, err := Open("testdata/server-wrongtype.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Zadd("z", 1, "m"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go db.ServeRESP(ln)
+
+	client, err := dialRedis(context.Background(), ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.close()
+
+	for _, args := range [][]string{
+		{"HSET", "z", "f", "v"},
+		{"HDEL", "z", "m"},
+		{"HDEL", "z", "m", "n"},
+	} {
+		if reply, err := client.do(args...); err == nil || !strings.HasPrefix(err.Error(), "WRONGTYPE") {
+			t.Errorf("%q: got %#v, %v, want WRONGTYPE", args, reply, err)
+		}
+	}
+	if members, err := db.Zrange("z", 0, -1); err != nil || len(members) != 1 || members[0] != "m" {
+		t.Errorf("Zrange after the rejected writes: got %v, %v, want [m]", members, err)
+	}
+}
+
+// TestServeRESPACL tests AUTH, NAMESPACE and the permission checks of the Redis protocol
+// server.
+func TestServeRESPACL(t *testing.T) {
+	acl, err := NewACL(ACLUser{Name: "reports", Token: "s3cret", Namespaces: map[string]Access{"": AccessRead, "reports": AccessReadWrite}})
+	if err != nil {
+		t.Fatalf("NewACL failed: %v", err)
+	}
+	db, err := Open("testdata/server-acl.db", WithACL(acl))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Hset("user:1", "name", []byte("Alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go db.ServeRESP(ln)
+
+	client, err := dialRedis(context.Background(), ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.close()
+
+	tests := []struct {
+		args    []string
+		want    any
+		errCode string // Expected error prefix, if any
+	}{
+		{[]string{"PING"}, "PONG", ""},
+		{[]string{"HGET", "user:1", "name"}, nil, "NOAUTH"},
+		{[]string{"AUTH", "wrong"}, nil, "WRONGPASS"},
+		{[]string{"AUTH", "someone", "s3cret"}, nil, "WRONGPASS"},
+		{[]string{"AUTH", "reports", "s3cret"}, "OK", ""},
+		{[]string{"HGET", "user:1", "name"}, "Alice", ""},
+		{[]string{"HSET", "user:1", "name", "Bob"}, nil, "NOPERM"},
+		{[]string{"SLOWLOG", "RESET"}, nil, "NOPERM"},
+		{[]string{"NAMESPACE", "other"}, nil, "NOPERM"},
+		{[]string{"NAMESPACE", "reports"}, "OK", ""},
+		{[]string{"HGET", "user:1", "name"}, nil, ""},
+		{[]string{"HSET", "user:1", "name", "Carol"}, int64(1), ""},
+		{[]string{"KEYS", "*"}, []any{[]byte("user:1")}, ""},
+		{[]string{"NAMESPACE", ""}, "OK", ""},
+		{[]string{"HGET", "user:1", "name"}, "Alice", ""},
+	}
+
+	for _, tc := range tests {
+		reply, err := client.do(tc.args...)
+		if tc.errCode != "" {
+			if err == nil || !strings.HasPrefix(err.Error(), tc.errCode) {
+				t.Errorf("%v: expected %s error, got %#v %v", tc.args, tc.errCode, reply, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%v failed: %v", tc.args, err)
+		}
+		if b, ok := reply.([]byte); ok {
+			if b == nil {
+				reply = nil
+			} else {
+				reply = string(b)
+			}
+		}
+		if !equalReply(reply, tc.want) {
+			t.Errorf("%v: expected %#v, got %#v", tc.args, tc.want, reply)
+		}
+	}
+
+	if value, _ := db.Namespace("reports").Hget("user:1", "name"); string(value) != "Carol" {
+		t.Errorf("namespaced write mismatch: got %q", value)
+	}
+}
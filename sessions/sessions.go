@@ -0,0 +1,213 @@
+// Package sessions stores net/http sessions in a jungledb database.
+//
+// Each session is a hash, in the "sessions" namespace of the database, named by a random
+// ID that the client keeps in a cookie. Saving a session renews its time to live, so
+// abandoned sessions expire and are removed by the database's expiry sweeper without a
+// separate garbage collection job:
+//
+//	store := sessions.NewStore(db, sessions.Options{MaxAge: time.Hour, Secure: true})
+//
+//	func handler(w http.ResponseWriter, r *http.Request) {
+//		sess, err := store.Get(r)
+//		...
+//		sess.Values["user"] = []byte("alice")
+//		err = store.Save(w, sess)
+//	}
+package sessions
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ehebe/jungledb"
+)
+
+// valuePrefix starts the hash fields holding session values; createdField holds the
+// creation time in Unix nanoseconds, so that empty sessions exist too.
+const (
+	valuePrefix  = "v:"
+	createdField = "created"
+)
+
+// ErrNotFound is returned by Load for a session that does not exist or has expired.
+var ErrNotFound = errors.New("session not found")
+
+// Options configures a Store and its session cookie.
+type Options struct {
+	CookieName string        // Defaults to "session"
+	MaxAge     time.Duration // Lifetime of a session after each save; defaults to 24 hours
+	Path       string        // Cookie path; defaults to "/"
+	Domain     string
+	Secure     bool
+	SameSite   http.SameSite // Defaults to http.SameSiteLaxMode
+
+	// AllowScript lets scripts read the session cookie, which is HttpOnly otherwise.
+	AllowScript bool
+}
+
+// Store creates, loads, saves and destroys sessions. It is safe for concurrent use.
+type Store struct {
+	db   *jungledb.DB
+	opts Options
+}
+
+// Session is a set of values kept for a client between requests.
+type Session struct {
+	ID      string
+	Values  map[string][]byte
+	Created time.Time
+	IsNew   bool // Not saved yet
+
+	stored map[string]bool // Values stored when the session was loaded or last saved
+}
+
+// NewStore returns a Store keeping sessions in db.
+func NewStore(db *jungledb.DB, opts Options) *Store {
+	if opts.CookieName == "" {
+		opts.CookieName = "session"
+	}
+	if opts.MaxAge <= 0 {
+		opts.MaxAge = 24 * time.Hour
+	}
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	return &Store{db: db.Namespace("sessions"), opts: opts}
+}
+
+// New returns a new, unsaved session with a random ID.
+func (s *Store) New() (*Session, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return &Session{
+		ID:      base64.RawURLEncoding.EncodeToString(b),
+		Values:  make(map[string][]byte),
+		Created: s.db.Now(),
+		IsNew:   true,
+	}, nil
+}
+
+// Load returns the session with the given ID, or ErrNotFound.
+func (s *Store) Load(id string) (*Session, error) {
+	fields, err := s.db.Hscan(id)
+	if err != nil {
+		return nil, err
+	}
+	created, ok := fields[createdField]
+	if !ok || len(created) != 8 {
+		return nil, ErrNotFound
+	}
+	sess := &Session{
+		ID:      id,
+		Values:  make(map[string][]byte, len(fields)),
+		Created: time.Unix(0, int64(binary.BigEndian.Uint64(created))),
+		stored:  make(map[string]bool, len(fields)),
+	}
+	for field, value := range fields {
+		if name, ok := strings.CutPrefix(field, valuePrefix); ok {
+			sess.Values[name] = value
+			sess.stored[name] = true
+		}
+	}
+	return sess, nil
+}
+
+// Get returns the session named by the cookie of r, or a new session if r has none or
+// it has expired.
+func (s *Store) Get(r *http.Request) (*Session, error) {
+	if c, err := r.Cookie(s.opts.CookieName); err == nil && c.Value != "" {
+		sess, err := s.Load(c.Value)
+		if !errors.Is(err, ErrNotFound) {
+			return sess, err
+		}
+	}
+	return s.New()
+}
+
+// Save stores the values of sess, renews its lifetime to MaxAge and sends the session
+// cookie on w.
+func (s *Store) Save(w http.ResponseWriter, sess *Session) error {
+	fields := make(map[string][]byte, len(sess.Values)+1)
+	fields[createdField] = binary.BigEndian.AppendUint64(nil, uint64(sess.Created.UnixNano()))
+	for name, value := range sess.Values {
+		fields[valuePrefix+name] = value
+	}
+	if err := s.db.Hmset(sess.ID, fields); err != nil {
+		return err
+	}
+	var removed []string
+	for name := range sess.stored {
+		if _, ok := sess.Values[name]; !ok {
+			removed = append(removed, valuePrefix+name)
+		}
+	}
+	if len(removed) > 0 {
+		if err := s.db.Hmdel(sess.ID, removed); err != nil {
+			return err
+		}
+	}
+	if err := s.db.Expire(sess.ID, s.opts.MaxAge); err != nil {
+		return err
+	}
+
+	sess.IsNew = false
+	sess.stored = make(map[string]bool, len(sess.Values))
+	for name := range sess.Values {
+		sess.stored[name] = true
+	}
+	http.SetCookie(w, s.cookie(sess.ID, int(s.opts.MaxAge/time.Second)))
+	return nil
+}
+
+// Regenerate gives sess a new ID, keeping its values, and sends the new cookie on w.
+// Call it when the privileges of a session change, such as at login, so that an ID
+// planted or seen before cannot be used to take the session over.
+func (s *Store) Regenerate(w http.ResponseWriter, sess *Session) error {
+	fresh, err := s.New()
+	if err != nil {
+		return err
+	}
+	if !sess.IsNew {
+		if err := s.db.Rename(sess.ID, fresh.ID); err != nil && !errors.Is(err, jungledb.ErrKeyNotFound) {
+			return err
+		}
+	}
+	sess.ID = fresh.ID
+	return s.Save(w, sess)
+}
+
+// Destroy deletes sess and clears the session cookie on w.
+func (s *Store) Destroy(w http.ResponseWriter, sess *Session) error {
+	if err := s.db.HdelBucket(sess.ID); err != nil && !errors.Is(err, jungledb.ErrKeyNotFound) {
+		return err
+	}
+	sess.Values = make(map[string][]byte)
+	sess.stored = nil
+	sess.IsNew = true
+	http.SetCookie(w, s.cookie("", -1))
+	return nil
+}
+
+// cookie returns the session cookie for id.
+func (s *Store) cookie(id string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     s.opts.CookieName,
+		Value:    id,
+		Path:     s.opts.Path,
+		Domain:   s.opts.Domain,
+		MaxAge:   maxAge,
+		Secure:   s.opts.Secure,
+		HttpOnly: !s.opts.AllowScript,
+		SameSite: s.opts.SameSite,
+	}
+}
@@ -0,0 +1,135 @@
+package sessions
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/ehebe/jungledb"
+	"github.com/ehebe/jungledb/jungletest"
+)
+
+// TestMain cleans up test files before and after running tests.
+func TestMain(m *testing.M) {
+	os.RemoveAll("testdata")
+	os.MkdirAll("testdata", 0755)
+
+	code := m.Run()
+
+	os.RemoveAll("testdata")
+	os.Exit(code)
+}
+
+// TestStore tests sessions kept across requests through their cookie, regenerated,
+// destroyed and expired.
+func TestStore(t *testing.T) {
+	clock := jungletest.NewClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	db, err := jungledb.Open("testdata/sessions.db", jungledb.WithExpirySweep(10*time.Millisecond), jungledb.WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	store := NewStore(db, Options{MaxAge: time.Minute})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/visit", func(w http.ResponseWriter, r *http.Request) {
+		sess, err := store.Get(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		n, _ := strconv.Atoi(string(sess.Values["visits"]))
+		sess.Values["visits"] = []byte(strconv.Itoa(n + 1))
+		delete(sess.Values, "flash")
+		if n == 0 {
+			sess.Values["flash"] = []byte("welcome")
+		}
+		if err := store.Save(w, sess); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		io.WriteString(w, sess.ID+" "+string(sess.Values["visits"]))
+	})
+	mux.HandleFunc("/login", func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := store.Get(r)
+		store.Regenerate(w, sess)
+		io.WriteString(w, sess.ID)
+	})
+	mux.HandleFunc("/logout", func(w http.ResponseWriter, r *http.Request) {
+		sess, _ := store.Get(r)
+		store.Destroy(w, sess)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	jar, _ := cookiejar.New(nil)
+	client := &http.Client{Jar: jar}
+	get := func(path string) string {
+		t.Helper()
+		resp, err := client.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("GET %s: status %d: %s", path, resp.StatusCode, body)
+		}
+		return string(body)
+	}
+
+	first := get("/visit")
+	id := first[:len(first)-2]
+	if second := get("/visit"); second != id+" 2" {
+		t.Errorf("second visit: expected %q, got %q", id+" 2", second)
+	}
+	sess, err := store.Load(id)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if string(sess.Values["visits"]) != "2" || sess.Values["flash"] != nil || sess.IsNew {
+		t.Errorf("loaded session mismatch: %+v", sess)
+	}
+	if ttl, err := db.Namespace("sessions").TTL(id); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("session TTL mismatch: got %v %v", ttl, err)
+	}
+
+	newID := get("/login")
+	if newID == id {
+		t.Error("Regenerate should change the session ID")
+	}
+	if _, err := store.Load(id); !errors.Is(err, ErrNotFound) {
+		t.Errorf("old session ID: expected ErrNotFound, got %v", err)
+	}
+	if third := get("/visit"); third != newID+" 3" {
+		t.Errorf("visit after login: expected %q, got %q", newID+" 3", third)
+	}
+
+	get("/logout")
+	if _, err := store.Load(newID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("destroyed session: expected ErrNotFound, got %v", err)
+	}
+	if after := get("/visit"); after[len(after)-2:] != " 1" {
+		t.Errorf("visit after logout should start a new session, got %q", after)
+	}
+
+	// Abandoned sessions expire
+	short := NewStore(db, Options{MaxAge: time.Second})
+	expiring, _ := short.New()
+	if !expiring.Created.Equal(clock.Now()) {
+		t.Errorf("expected a session created at %v, got %v", clock.Now(), expiring.Created)
+	}
+	if err := short.Save(httptest.NewRecorder(), expiring); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	clock.Advance(time.Second)
+	if _, err := short.Load(expiring.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expired session: expected ErrNotFound, got %v", err)
+	}
+}
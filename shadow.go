@@ -0,0 +1,274 @@
+package jungledb
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"go.etcd.io/bbolt"
+)
+
+// maxShadowExamples bounds the diverging keys listed by VerifyShadow.
+const maxShadowExamples = 10
+
+// ShadowStats counts the writes mirrored by StartShadow.
+type ShadowStats struct {
+	Mirrored uint64 // Transactions applied to the shadow
+	Failed   uint64 // Transactions the shadow failed to apply, each leaving it diverged
+	Pending  int    // Transactions committed but not mirrored yet
+}
+
+// ShadowReport is the outcome of VerifyShadow.
+type ShadowReport struct {
+	Keys      int      // Keys compared
+	Missing   int      // Keys of the database missing from the shadow
+	Extra     int      // Keys of the shadow missing from the database
+	Different int      // Keys whose contents or TTLs differ
+	Examples  []string // Some of the diverging keys
+}
+
+// Diverged reports whether the verification found any difference.
+func (r ShadowReport) Diverged() bool {
+	return r.Missing+r.Extra+r.Different > 0
+}
+
+// shadowMirror applies the writes of a database to its shadow, in commit order, from a
+// goroutine of its own.
+type shadowMirror struct {
+	src, dst *DB
+	mu       sync.Mutex
+	cond     *sync.Cond // Signaled when the queue changes or the mirror stops
+	queue    [][]Event  // Transactions to mirror; the head is removed once applied
+	stopped  bool
+	stats    ShadowStats
+	done     chan struct{}
+}
+
+// StartShadow mirrors every write committed to the keys of db from now on to shadow,
+// which can be another database or a namespace of one, to migrate data live: writes are
+// replayed on shadow, in order, shortly after they commit here, and failures there are
+// counted (see ShadowStats) and logged but do not fail the writes. Keys written before
+// are not copied; copy them, for example with CopyTo, then compare both databases with
+// VerifyShadow before switching over. It fails if db already has a shadow.
+func (db *DB) StartShadow(shadow *DB) error {
+	if shadow.core == db.core && (strings.HasPrefix(shadow.ns, db.ns) || strings.HasPrefix(db.ns, shadow.ns)) {
+		return errors.New("a shadow must not overlap the database it mirrors")
+	}
+	db.shadowMu.Lock()
+	defer db.shadowMu.Unlock()
+	if db.isClosed() {
+		return ErrClosed
+	}
+	if db.shadow.Load() != nil {
+		return errors.New("the database already has a shadow")
+	}
+	m := &shadowMirror{src: db, dst: shadow, done: make(chan struct{})}
+	m.cond = sync.NewCond(&m.mu)
+	go m.run()
+	db.shadow.Store(m)
+	return nil
+}
+
+// StopShadow stops mirroring writes, once those already committed are mirrored, and
+// returns the final counts. It does nothing without a shadow.
+func (db *DB) StopShadow() ShadowStats {
+	db.shadowMu.Lock()
+	defer db.shadowMu.Unlock()
+	m := db.shadow.Swap(nil)
+	if m == nil {
+		return ShadowStats{}
+	}
+	m.mu.Lock()
+	m.stopped = true
+	m.cond.Broadcast()
+	m.mu.Unlock()
+	<-m.done
+	return m.stats
+}
+
+// ShadowStats returns the counts of the current shadow, zero without one.
+func (db *DB) ShadowStats() ShadowStats {
+	m := db.shadow.Load()
+	if m == nil {
+		return ShadowStats{}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats := m.stats
+	stats.Pending = len(m.queue)
+	return stats
+}
+
+// VerifyShadow waits for the writes committed so far to be mirrored, then compares the
+// keys of db matching pattern (see ListKeys) with those of the shadow: their types,
+// contents and TTLs. Both databases are read in turn, so keys written during the
+// verification may be reported as diverging; run it while writes are quiet, or again.
+func (db *DB) VerifyShadow(pattern string) (ShadowReport, error) {
+	m := db.shadow.Load()
+	if m == nil {
+		return ShadowReport{}, errors.New("the database has no shadow")
+	}
+	m.wait()
+
+	src, err := digestKeys(db, "VerifyShadow", pattern)
+	if err != nil {
+		return ShadowReport{}, err
+	}
+	dst, err := digestKeys(m.dst, "VerifyShadow", pattern)
+	if err != nil {
+		return ShadowReport{}, fmt.Errorf("failed to read the shadow: %w", err)
+	}
+
+	var report ShadowReport
+	diverged := func(key string) {
+		if len(report.Examples) < maxShadowExamples {
+			report.Examples = append(report.Examples, key)
+		}
+	}
+	for key, sum := range src {
+		report.Keys++
+		switch other, ok := dst[key]; {
+		case !ok:
+			report.Missing++
+			diverged(key)
+		case other != sum:
+			report.Different++
+			diverged(key)
+		}
+	}
+	for key := range dst {
+		if _, ok := src[key]; !ok {
+			report.Extra++
+			diverged(key)
+		}
+	}
+	return report, nil
+}
+
+// mirror queues the events of a committed transaction that concern keys of the mirrored
+// namespace, named as in the shadow. Writes call it in commit order (see commitOrder), so
+// transactions are queued in that order.
+func (db *DB) mirror(events []Event) {
+	m := db.shadow.Load()
+	if m == nil {
+		return
+	}
+	var mirrored []Event
+	for _, ev := range events {
+		key, ok := m.shadowKey(ev.Key)
+		target, targetOK := m.shadowKey(ev.Target)
+		if ok && targetOK {
+			ev.Key, ev.Target = key, target
+			mirrored = append(mirrored, ev)
+		}
+	}
+	if len(mirrored) == 0 {
+		return
+	}
+	m.mu.Lock()
+	m.queue = append(m.queue, mirrored)
+	m.cond.Broadcast()
+	m.mu.Unlock()
+}
+
+// run applies queued transactions to the shadow until the mirror is stopped and drained.
+func (m *shadowMirror) run() {
+	defer close(m.done)
+	for {
+		m.mu.Lock()
+		for len(m.queue) == 0 && !m.stopped {
+			m.cond.Wait()
+		}
+		if len(m.queue) == 0 {
+			m.mu.Unlock()
+			return
+		}
+		events := m.queue[0]
+		m.mu.Unlock()
+
+		err := m.apply(events)
+		if err != nil {
+			m.src.log.Warn("shadow write failed", "key", events[0].Key, "error", err)
+		}
+
+		m.mu.Lock()
+		m.queue[0] = nil
+		m.queue = m.queue[1:]
+		if err != nil {
+			m.stats.Failed++
+		} else {
+			m.stats.Mirrored++
+		}
+		m.cond.Broadcast()
+		m.mu.Unlock()
+	}
+}
+
+// apply replays the events of one transaction on the shadow, in one transaction.
+func (m *shadowMirror) apply(events []Event) error {
+	return m.dst.update("Shadow", "", func(tx *txn) error {
+		for _, ev := range events {
+			if err := applyEvent(tx, ev); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// shadowKey maps the bucket name of a key of the mirrored database to its name in the
+// shadow, or returns false if the key lies outside the mirrored namespace.
+func (m *shadowMirror) shadowKey(name string) (string, bool) {
+	if name == "" {
+		return "", true
+	}
+	rest, ok := strings.CutPrefix(name, m.src.ns)
+	if !ok {
+		return "", false
+	}
+	return m.dst.ns + rest, true
+}
+
+// wait returns once the transactions queued so far are mirrored.
+func (m *shadowMirror) wait() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for len(m.queue) > 0 {
+		m.cond.Wait()
+	}
+}
+
+// digestKeys returns a digest of the type, contents and TTL of each key of db matching
+// pattern, by key.
+func digestKeys(db *DB, op, pattern string) (map[string][sha256.Size]byte, error) {
+	sums := make(map[string][sha256.Size]byte)
+	err := db.view(op, pattern, func(tx *bbolt.Tx) error {
+		c := tx.Cursor()
+		prefix := []byte(db.ns)
+		for name, v := c.Seek(prefix); name != nil && bytes.HasPrefix(name, prefix); name, v = c.Next() {
+			key := string(name[len(prefix):])
+			if v != nil || isInternalBucket(tx, name) || !matchPattern(pattern, key) || db.liveBucket(tx, string(name)) == nil {
+				continue
+			}
+			h := sha256.New()
+			h.Write([]byte(keyType(tx, name)))
+			h.Write(binary.BigEndian.AppendUint64(nil, uint64(expiry(tx, string(name)))))
+			tx.Bucket(name).ForEach(func(k, v []byte) error {
+				h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(k))))
+				h.Write(k)
+				h.Write(binary.BigEndian.AppendUint32(nil, uint32(len(v))))
+				h.Write(v)
+				return nil
+			})
+			var sum [sha256.Size]byte
+			h.Sum(sum[:0])
+			sums[key] = sum
+		}
+		return nil
+	})
+	return sums, err
+}
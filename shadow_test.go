@@ -0,0 +1,82 @@
+package jungledb
+
+import (
+	"testing"
+	"time"
+)
+
+// TestShadow tests that writes are mirrored to a shadow database in order, and that
+// VerifyShadow finds keys that diverge.
+func TestShadow(t *testing.T) {
+	db, err := Open("testdata/shadow.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Attach("shadow", "testdata/shadow_target.db"); err != nil {
+		t.Fatalf("Attach failed: %v", err)
+	}
+	shadow, _ := db.Attached("shadow")
+
+	if err := db.Hset("before", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.StartShadow(shadow); err != nil {
+		t.Fatalf("StartShadow failed: %v", err)
+	}
+	if err := db.StartShadow(shadow); err == nil {
+		t.Errorf("expected a second StartShadow to fail")
+	}
+	if err := db.StartShadow(db.Namespace("tenant")); err == nil {
+		t.Errorf("expected a shadow overlapping the database to be rejected")
+	}
+
+	for range 100 {
+		if _, err := db.Hincr("counter", "n", 1); err != nil {
+			t.Fatalf("Hincr failed: %v", err)
+		}
+	}
+	if err := db.Zadd("ranking", 1.5, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Rename("ranking", "scores"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if err := db.Expire("scores", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+
+	report, err := db.VerifyShadow("*")
+	if err != nil {
+		t.Fatalf("VerifyShadow failed: %v", err)
+	}
+	// The key written before mirroring started is missing from the shadow
+	if report.Keys != 3 || report.Missing != 1 || report.Extra != 0 || report.Different != 0 || len(report.Examples) != 1 || report.Examples[0] != "before" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if report, err := db.VerifyShadow("[cs]*"); err != nil || report.Diverged() {
+		t.Errorf("VerifyShadow of the mirrored keys = %+v, %v; expected no divergence", report, err)
+	}
+	if n, err := shadow.HgetInt("counter", "n"); err != nil || n != 100 {
+		t.Errorf("shadow counter = %d, %v; expected 100", n, err)
+	}
+
+	// Writes to the shadow alone make it diverge
+	if err := shadow.Hset("counter", "n", []byte("x")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if report, err := db.VerifyShadow("*"); err != nil || report.Different != 1 {
+		t.Errorf("expected counter to differ, got %+v, %v", report, err)
+	}
+
+	stats := db.StopShadow()
+	if stats.Mirrored != 103 || stats.Failed != 0 || stats.Pending != 0 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if err := db.Hset("after", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if exists, _ := shadow.HhasKey("after", "f"); exists {
+		t.Errorf("write after StopShadow was mirrored")
+	}
+}
@@ -0,0 +1,88 @@
+package jungledb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+)
+
+// Shutdown stops the database for process termination. New operations fail with
+// ErrClosed right away; the expiry sweeper and maintenance are stopped and every server
+// started with ServeRESP, ServeUnix or ServeReplication is closed and waited for.
+// Shutdown then waits for in-flight operations, syncs the file and closes the database,
+// along with the databases attached to it.
+//
+// If ctx ends first, Shutdown returns its error joined with any other failure, and the
+// database is closed in the background once the remaining operations finish.
+func (db *DB) Shutdown(ctx context.Context) error {
+	db.closing.Store(true)
+	db.stopSweeper()
+	db.stopMaintenance()
+	db.unpublishExpvar()
+	db.StopShadow()
+
+	var errs []error
+	db.serveMu.Lock()
+	for ln := range db.listeners {
+		if err := ln.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, fmt.Errorf("failed to close listener %s: %v", ln.Addr(), err))
+		}
+	}
+	db.serveMu.Unlock()
+
+	serversDone := make(chan struct{})
+	go func() {
+		db.servers.Wait()
+		close(serversDone)
+	}()
+	select {
+	case <-serversDone:
+	case <-ctx.Done():
+		db.log.Warn("shutdown timed out waiting for servers")
+		return errors.Join(append(errs, fmt.Errorf("servers did not stop: %w", ctx.Err()))...)
+	}
+
+	closed := make(chan error, 1)
+	go func() {
+		closed <- errors.Join(db.closeFile(true), db.detachAll())
+	}()
+	select {
+	case err := <-closed:
+		if err != nil {
+			errs = append(errs, err)
+		}
+	case <-ctx.Done():
+		db.log.Warn("shutdown timed out waiting for in-flight operations")
+		errs = append(errs, fmt.Errorf("in-flight operations did not finish: %w", ctx.Err()))
+	}
+	return errors.Join(errs...)
+}
+
+// serve runs serveConns on ln, registering it so Shutdown can stop it.
+func (db *DB) serve(ln net.Listener, handle func(conn net.Conn)) error {
+	db.serveMu.Lock()
+	if db.closing.Load() {
+		db.serveMu.Unlock()
+		ln.Close()
+		return ErrClosed
+	}
+	if db.listeners == nil {
+		db.listeners = make(map[net.Listener]struct{})
+	}
+	db.listeners[ln] = struct{}{}
+	db.servers.Add(1)
+	db.serveMu.Unlock()
+
+	defer func() {
+		db.serveMu.Lock()
+		delete(db.listeners, ln)
+		db.serveMu.Unlock()
+		db.servers.Done()
+	}()
+	return serveConns(db.tlsListener(ln), func(conn net.Conn) {
+		if db.handshake(conn) {
+			handle(conn)
+		}
+	})
+}
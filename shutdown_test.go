@@ -0,0 +1,124 @@
+package jungledb
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+// TestShutdown tests that Shutdown stops servers and closes the database durably.
+func TestShutdown(t *testing.T) {
+	db, err := Open("testdata/shutdown.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	if err := db.Hset("hash", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	served := make(chan error, 1)
+	go func() { served <- db.ServeRESP(ln) }()
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to dial: %v", err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	conn.Write([]byte("PING\r\n"))
+	if line, err := r.ReadString('\n'); err != nil || line != "+PONG\r\n" {
+		t.Fatalf("PING: got %q, %v", line, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := db.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("ServeRESP: expected nil after Shutdown, got %v", err)
+		}
+	default:
+		t.Errorf("ServeRESP still running after Shutdown")
+	}
+	if _, err := r.ReadString('\n'); err == nil {
+		t.Errorf("client connection still open after Shutdown")
+	}
+
+	if _, err := db.Hget("hash", "f"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Hget after Shutdown: expected ErrClosed, got %v", err)
+	}
+	if err := db.Shutdown(ctx); err != nil {
+		t.Errorf("second Shutdown: expected nil, got %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Errorf("Close after Shutdown: expected nil, got %v", err)
+	}
+
+	ln2, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	if err := db.ServeRESP(ln2); !errors.Is(err, ErrClosed) {
+		t.Errorf("ServeRESP after Shutdown: expected ErrClosed, got %v", err)
+	}
+
+	db, err = Open("testdata/shutdown.db")
+	if err != nil {
+		t.Fatalf("failed to reopen database: %v", err)
+	}
+	defer db.Close()
+	if value, err := db.Hget("hash", "f"); err != nil || string(value) != "v" {
+		t.Errorf("Hget after reopening: expected v, got %q, %v", value, err)
+	}
+}
+
+// TestShutdownTimeout tests that Shutdown gives up waiting for in-flight operations when
+// its context ends, and that the database is still closed once they finish.
+func TestShutdownTimeout(t *testing.T) {
+	db, err := Open("testdata/shutdown_timeout.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan error, 1)
+	go func() {
+		done <- db.update("Slow", "hash", func(tx *txn) error {
+			close(started)
+			<-release
+			return nil
+		})
+	}()
+	<-started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := db.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Shutdown: expected context.DeadlineExceeded, got %v", err)
+	}
+	if err := db.Hset("hash", "f", nil); !errors.Is(err, ErrClosed) {
+		t.Errorf("Hset during Shutdown: expected ErrClosed, got %v", err)
+	}
+
+	close(release)
+	if err := <-done; err != nil {
+		t.Errorf("in-flight update: expected nil, got %v", err)
+	}
+	if err := db.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+	if _, err := db.Hget("hash", "f"); !errors.Is(err, ErrClosed) {
+		t.Errorf("Hget after Shutdown: expected ErrClosed, got %v", err)
+	}
+}
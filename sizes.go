@@ -0,0 +1,80 @@
+package jungledb
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// BucketSize is the size of one bucket of the database file, see SizeReport.
+type BucketSize struct {
+	Name     string // Bucket name; keys of namespaces include the namespace prefix
+	Type     string // "hash" or "zset" for keys, empty for internal buckets
+	Internal bool   // Sorted set member indexes, secondary indexes, logs and the like
+	Entries  int    // Fields, members or entries, including those of nested buckets
+	Bytes    int64  // Bytes of the pages in use, including those of nested buckets
+}
+
+// SizeReport lists the largest buckets of the database file, see DB.SizeReport.
+type SizeReport struct {
+	Buckets   int          // Buckets in the file
+	Bytes     int64        // Bytes of the pages in use by all buckets
+	ByBytes   []BucketSize // Largest buckets by bytes, largest first
+	ByEntries []BucketSize // Largest buckets by entries, largest first
+
+	LargestValue ValueSize // Largest hash value, as reported by DB.LargestValue
+}
+
+// SizeReport walks the statistics of every bucket of the database file, internal ones
+// included, in a single read transaction, and returns the topN largest by bytes and by
+// entries (every bucket if topN <= 0), to investigate what takes room before cleaning up
+// or planning capacity. It reads the hash values too, to report the largest one as
+// LargestValue does. Expired keys not removed yet are included. It reports the whole
+// file, whichever namespace db is a handle of.
+func (db *DB) SizeReport(topN int) (SizeReport, error) {
+	var report SizeReport
+	var sizes []BucketSize
+	err := db.view("SizeReport", "", func(tx *bbolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+			stats := b.Stats()
+			size := BucketSize{
+				Name:     string(name),
+				Internal: isInternalBucket(tx, name),
+				Entries:  stats.KeyN - stats.BucketN + 1, // Nested buckets are keys too, and BucketN counts b
+				Bytes:    int64(stats.BranchInuse + stats.LeafInuse + stats.InlineBucketInuse),
+			}
+			if !size.Internal {
+				size.Type = keyType(tx, name)
+			}
+			if size.Type == typeHash {
+				report.LargestValue.scan(name, b)
+			}
+			report.Buckets++
+			report.Bytes += size.Bytes
+			sizes = append(sizes, size)
+			return nil
+		})
+	})
+	if err != nil {
+		return SizeReport{}, err
+	}
+
+	largest := func(by func(BucketSize) int64) []BucketSize {
+		top := slices.Clone(sizes)
+		slices.SortFunc(top, func(a, b BucketSize) int {
+			if c := cmp.Compare(by(b), by(a)); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Name, b.Name)
+		})
+		if topN > 0 && len(top) > topN {
+			top = top[:topN]
+		}
+		return top
+	}
+	report.ByBytes = largest(func(s BucketSize) int64 { return s.Bytes })
+	report.ByEntries = largest(func(s BucketSize) int64 { return int64(s.Entries) })
+	return report, nil
+}
@@ -0,0 +1,60 @@
+package jungledb
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// TestSizeReport tests that SizeReport ranks buckets, internal ones included, by bytes
+// and by entries.
+func TestSizeReport(t *testing.T) {
+	db, err := Open("testdata/sizes.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("big", "blob", bytes.Repeat([]byte("x"), 64<<10)); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	for i := range 200 {
+		if err := db.Zadd("many", float64(i), fmt.Sprint(i)); err != nil {
+			t.Fatalf("Zadd failed: %v", err)
+		}
+	}
+	if err := db.Hset("small", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	report, err := db.SizeReport(2)
+	if err != nil {
+		t.Fatalf("SizeReport failed: %v", err)
+	}
+	if len(report.ByBytes) != 2 || len(report.ByEntries) != 2 || report.Buckets < 4 {
+		t.Fatalf("expected the top 2 of at least 4 buckets, got %+v", report)
+	}
+	if top := report.ByBytes[0]; top.Name != "big" || top.Type != typeHash || top.Entries != 1 || top.Bytes < 64<<10 {
+		t.Errorf("expected big to be the largest by bytes, got %+v", top)
+	}
+	if report.LargestValue.Key != "big" || report.LargestValue.Size < 64<<10 {
+		t.Errorf("expected the value of big to be the largest, got %+v", report.LargestValue)
+	}
+	if top := report.ByEntries[0]; top.Entries != 200 || (top.Name != "many" && !top.Internal) {
+		t.Errorf("expected a bucket of the sorted set to have the most entries, got %+v", top)
+	}
+
+	all, err := db.SizeReport(0)
+	if err != nil {
+		t.Fatalf("SizeReport failed: %v", err)
+	}
+	internal := false
+	var total int64
+	for _, size := range all.ByBytes {
+		internal = internal || (size.Internal && size.Name == "many"+membersSuffix)
+		total += size.Bytes
+	}
+	if len(all.ByBytes) != all.Buckets || total != all.Bytes || !internal {
+		t.Errorf("expected every bucket, including the members index of the sorted set, got %+v", all)
+	}
+}
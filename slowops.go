@@ -0,0 +1,108 @@
+package jungledb
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSlowLogThreshold is the latency above which an operation is recorded as slow
+	// unless WithSlowLog says otherwise.
+	defaultSlowLogThreshold = 10 * time.Millisecond
+
+	// defaultSlowLogCapacity is how many slow operations are retained by default; older
+	// ones are overwritten.
+	defaultSlowLogCapacity = 128
+)
+
+// SlowOp is an operation that took longer than the slow log threshold.
+type SlowOp struct {
+	ID       uint64        `json:"id"`            // Increases with every slow operation, survives ResetSlowLog
+	Op       string        `json:"op"`            // Operation name, such as "Hget"
+	Key      string        `json:"key,omitempty"` // Key the operation targeted, if any
+	Duration time.Duration `json:"duration"`      // Latency including time spent waiting for the database lock
+	Time     time.Time     `json:"time"`          // When the operation started
+}
+
+// slowOpLog is a fixed-size ring buffer of slow operations.
+type slowOpLog struct {
+	mu       sync.Mutex
+	capacity int
+	entries  []SlowOp
+	next     int
+	lastID   uint64
+}
+
+// add records an operation, assigning its ID and overwriting the oldest entry once the
+// buffer is full.
+func (l *slowOpLog) add(op SlowOp) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.capacity <= 0 {
+		l.capacity = defaultSlowLogCapacity
+	}
+	l.lastID++
+	op.ID = l.lastID
+	if len(l.entries) < l.capacity {
+		l.entries = append(l.entries, op)
+		return
+	}
+	l.entries[l.next] = op
+	l.next = (l.next + 1) % l.capacity
+}
+
+// recent returns up to n slow operations, newest first. n <= 0 returns all of them.
+func (l *slowOpLog) recent(n int) []SlowOp {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n <= 0 || n > len(l.entries) {
+		n = len(l.entries)
+	}
+	ops := make([]SlowOp, 0, n)
+	for i := 0; i < n; i++ {
+		idx := (l.next - 1 - i + 2*len(l.entries)) % len(l.entries)
+		ops = append(ops, l.entries[idx])
+	}
+	return ops
+}
+
+// len returns the number of retained slow operations.
+func (l *slowOpLog) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.entries)
+}
+
+// reset discards every retained slow operation.
+func (l *slowOpLog) reset() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = nil
+	l.next = 0
+}
+
+// SlowLog returns up to n of the most recent operations that exceeded the slow log
+// threshold, newest first; n <= 0 returns every retained entry. Like Redis SLOWLOG,
+// the log is kept in memory and bounded, older entries being overwritten.
+func (db *DB) SlowLog(n int) []SlowOp {
+	return db.slowOps.recent(n)
+}
+
+// SlowLogLen returns the number of entries currently retained in the slow log.
+func (db *DB) SlowLogLen() int {
+	return db.slowOps.len()
+}
+
+// ResetSlowLog empties the slow log. Entry IDs keep increasing across resets.
+func (db *DB) ResetSlowLog() {
+	db.slowOps.reset()
+}
+
+// slowThreshold returns the latency above which operations and transactions are slow,
+// or a negative duration if slow operations are not tracked.
+func (db *DB) slowThreshold() time.Duration {
+	if db.opts.slowLogThreshold == 0 {
+		return defaultSlowLogThreshold
+	}
+	return db.opts.slowLogThreshold
+}
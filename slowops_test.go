@@ -0,0 +1,72 @@
+package jungledb
+
+import (
+	"testing"
+	"time"
+)
+
+// TestSlowOpLog tests the slow operation ring buffer.
+func TestSlowOpLog(t *testing.T) {
+	var l slowOpLog
+	for i := 0; i < defaultSlowLogCapacity+3; i++ {
+		l.add(SlowOp{Duration: time.Duration(i)})
+	}
+	ops := l.recent(2)
+	if len(ops) != 2 || ops[0].Duration != defaultSlowLogCapacity+2 || ops[1].Duration != defaultSlowLogCapacity+1 {
+		t.Errorf("recent(2) mismatch: %+v", ops)
+	}
+	all := l.recent(0)
+	if len(all) != defaultSlowLogCapacity || all[len(all)-1].Duration != 3 {
+		t.Errorf("expected the %d newest entries, oldest 3, got %d entries ending at %v", defaultSlowLogCapacity, len(all), all[len(all)-1].Duration)
+	}
+}
+
+// TestSlowLog tests the slow log threshold, retrieval, IDs and reset.
+func TestSlowLog(t *testing.T) {
+	db, err := Open("testdata/slowlog.db", WithSlowLog(time.Nanosecond, 2))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, key := range []string{"a", "b", "c"} {
+		if err := db.Hset(key, "f", []byte("v")); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+
+	ops := db.SlowLog(0)
+	if len(ops) != 2 || ops[0].Key != "c" || ops[1].Key != "b" || ops[0].Op != "Hset" {
+		t.Fatalf("expected the two newest operations, got %+v", ops)
+	}
+	if ops[0].ID != 3 || ops[1].ID != 2 || ops[0].Duration <= 0 || ops[0].Time.IsZero() {
+		t.Errorf("unexpected entries: %+v", ops)
+	}
+	if ops := db.SlowLog(1); len(ops) != 1 || ops[0].Key != "c" {
+		t.Errorf("SlowLog(1) mismatch: %+v", ops)
+	}
+
+	db.ResetSlowLog()
+	if n := db.SlowLogLen(); n != 0 {
+		t.Errorf("expected an empty slow log, got %d entries", n)
+	}
+	if _, err := db.Hget("a", "f"); err != nil {
+		t.Fatalf("Hget failed: %v", err)
+	}
+	if ops := db.SlowLog(0); len(ops) != 1 || ops[0].ID != 4 {
+		t.Errorf("expected IDs to continue after reset, got %+v", ops)
+	}
+
+	// A negative threshold disables the log
+	off, err := Open("testdata/slowlog_off.db", WithSlowLog(-1, 0))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer off.Close()
+	if err := off.Hset("a", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if n := off.SlowLogLen(); n != 0 {
+		t.Errorf("expected a disabled slow log to stay empty, got %d entries", n)
+	}
+}
@@ -0,0 +1,137 @@
+// Package sqliteexport exports jungledb databases to SQLite files, which far more tools
+// can open than bbolt files:
+//
+//	err := sqliteexport.Export(db, "dump.sqlite", jungledb.ExportOptions{Pattern: "user:*"})
+//
+// The file has three tables:
+//
+//	keys(key TEXT PRIMARY KEY, type TEXT, expires_at INTEGER)  -- "hash" or "zset", Unix ns or NULL
+//	hashes(key TEXT, field TEXT, value BLOB, PRIMARY KEY (key, field))
+//	zsets(key TEXT, member TEXT, score REAL, PRIMARY KEY (key, member))
+//
+// with an index on zsets(key, score) for range queries by score. The package uses the
+// cgo SQLite driver; it is kept out of the jungledb package so that programs not
+// exporting to SQLite build without cgo.
+package sqliteexport
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/ehebe/jungledb"
+	_ "github.com/mattn/go-sqlite3" // Registers the "sqlite3" driver
+)
+
+// schema creates the tables of an export.
+const schema = `
+CREATE TABLE keys (key TEXT PRIMARY KEY, type TEXT NOT NULL, expires_at INTEGER);
+CREATE TABLE hashes (key TEXT NOT NULL, field TEXT NOT NULL, value BLOB, PRIMARY KEY (key, field));
+CREATE TABLE zsets (key TEXT NOT NULL, member TEXT NOT NULL, score REAL NOT NULL, PRIMARY KEY (key, member));
+CREATE INDEX zsets_score ON zsets (key, score);
+`
+
+// record is a line of a jungledb JSON export, see DB.Export.
+type record struct {
+	Key     string            `json:"key"`
+	Type    string            `json:"type"`
+	Fields  map[string][]byte `json:"fields"`
+	Members []struct {
+		Member string `json:"member"`
+		Score  string `json:"score"` // Such as "2.5" or "-inf"
+	} `json:"members"`
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// Export writes the keys of db selected by opts to a new SQLite file at path, replacing
+// any file there once the export is complete. The export is a consistent snapshot of db.
+func Export(db *jungledb.DB, path string, opts jungledb.ExportOptions) error {
+	tmp := path + ".tmp"
+	os.Remove(tmp) // Left over from an interrupted export
+	if err := export(db, tmp, opts); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %v", path, err)
+	}
+	return nil
+}
+
+// export writes the SQLite file at path.
+func export(db *jungledb.DB, path string, opts jungledb.ExportOptions) (err error) {
+	out, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := out.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	if _, err := out.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create tables: %v", err)
+	}
+	tx, err := out.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback() // No-op once committed
+
+	insertKey, err := tx.Prepare("INSERT INTO keys (key, type, expires_at) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	insertField, err := tx.Prepare("INSERT INTO hashes (key, field, value) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+	insertMember, err := tx.Prepare("INSERT INTO zsets (key, member, score) VALUES (?, ?, ?)")
+	if err != nil {
+		return err
+	}
+
+	// Stream the JSON export, which reads a snapshot, into the SQLite transaction
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(db.Export(pw, opts))
+	}()
+	defer pr.Close() // Unblocks the export if decoding fails
+	dec := json.NewDecoder(pr)
+	for {
+		var rec record
+		if err := dec.Decode(&rec); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return err
+		}
+
+		var expiresAt any // NULL without a TTL
+		if rec.ExpiresAt != 0 {
+			expiresAt = rec.ExpiresAt
+		}
+		if _, err := insertKey.Exec(rec.Key, rec.Type, expiresAt); err != nil {
+			return fmt.Errorf("failed to insert key %s: %v", rec.Key, err)
+		}
+		for field, value := range rec.Fields {
+			if _, err := insertField.Exec(rec.Key, field, value); err != nil {
+				return fmt.Errorf("failed to insert field %s of %s: %v", field, rec.Key, err)
+			}
+		}
+		for _, m := range rec.Members {
+			score, err := strconv.ParseFloat(m.Score, 64)
+			if err != nil {
+				return fmt.Errorf("invalid score of member %s of %s: %v", m.Member, rec.Key, err)
+			}
+			if _, err := insertMember.Exec(rec.Key, m.Member, score); err != nil {
+				return fmt.Errorf("failed to insert member %s of %s: %v", m.Member, rec.Key, err)
+			}
+		}
+	}
+	return tx.Commit()
+}
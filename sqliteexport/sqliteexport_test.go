@@ -0,0 +1,82 @@
+package sqliteexport
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ehebe/jungledb"
+)
+
+// TestMain cleans up test files before and after running tests.
+func TestMain(m *testing.M) {
+	os.RemoveAll("testdata")
+	os.MkdirAll("testdata", 0755)
+
+	code := m.Run()
+
+	os.RemoveAll("testdata")
+	os.Exit(code)
+}
+
+// TestExport tests that hashes, sorted sets and TTLs land in their tables.
+func TestExport(t *testing.T) {
+	db, err := jungledb.Open("testdata/export.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if err := db.Hmset("user:1", map[string][]byte{"name": []byte("alice"), "avatar": {0xff, 0x00}}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	if err := db.Expire("user:1", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	for i, member := range []string{"alice", "bob"} {
+		if err := db.Zadd("ranking", float64(i)+0.5, member); err != nil {
+			t.Fatalf("Zadd failed: %v", err)
+		}
+	}
+	if err := db.Hset("order:1", "total", []byte("12")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	if err := Export(db, "testdata/export.sqlite", jungledb.ExportOptions{Pattern: "[ru]*"}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	// Exporting again replaces the file
+	if err := Export(db, "testdata/export.sqlite", jungledb.ExportOptions{Pattern: "[ru]*"}); err != nil {
+		t.Fatalf("second Export failed: %v", err)
+	}
+
+	out, err := sql.Open("sqlite3", "testdata/export.sqlite")
+	if err != nil {
+		t.Fatalf("failed to open export: %v", err)
+	}
+	defer out.Close()
+
+	var keys int
+	var expiresAt sql.NullInt64
+	if err := out.QueryRow("SELECT COUNT(*) FROM keys").Scan(&keys); err != nil || keys != 2 {
+		t.Errorf("expected 2 keys, got %d %v", keys, err)
+	}
+	if err := out.QueryRow("SELECT expires_at FROM keys WHERE key = 'user:1' AND type = 'hash'").Scan(&expiresAt); err != nil || !expiresAt.Valid {
+		t.Errorf("expected the TTL of user:1, got %v %v", expiresAt, err)
+	}
+	var avatar []byte
+	if err := out.QueryRow("SELECT value FROM hashes WHERE key = 'user:1' AND field = 'avatar'").Scan(&avatar); err != nil || string(avatar) != "\xff\x00" {
+		t.Errorf("expected the binary value, got %q %v", avatar, err)
+	}
+	var member string
+	if err := out.QueryRow("SELECT member FROM zsets WHERE key = 'ranking' AND score > 1").Scan(&member); err != nil || member != "bob" {
+		t.Errorf("expected bob by score, got %q %v", member, err)
+	}
+	var index string
+	if err := out.QueryRow("SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'zsets' AND name = 'zsets_score'").Scan(&index); err != nil {
+		t.Errorf("expected the score index: %v", err)
+	}
+	if _, err := os.Stat("testdata/export.sqlite.tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary file to be gone, got %v", err)
+	}
+}
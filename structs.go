@@ -0,0 +1,247 @@
+package jungledb
+
+import (
+	"encoding"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.etcd.io/bbolt"
+	"google.golang.org/protobuf/proto"
+)
+
+// structField is an exported struct field mapped to a hash field.
+type structField struct {
+	index     []int
+	name      string
+	omitEmpty bool
+}
+
+// structFields caches the fields of the struct types seen by SaveStruct and LoadStruct.
+var structFields sync.Map // reflect.Type -> []structField
+
+// SaveStruct stores the exported fields of the struct v, or of the struct it points to, as
+// fields of the hash key, in a single transaction. A field is stored under its name or
+// the name given by a `jungle:"name"` tag; `jungle:"-"` skips it and the omitempty option,
+// as in `jungle:"name,omitempty"`, deletes the hash field when the value is the zero
+// value. Integers are stored as the 8-byte binary integers of Hincr and HgetInt, strings
+// and byte slices as is, booleans and floats as text, protobuf messages in their wire
+// encoding as by HsetProto, types implementing encoding.TextMarshaler (such as time.Time)
+// as their text and anything else, including nested structs, as JSON. Hash fields the struct does not map are left alone.
+func (db *DB) SaveStruct(key string, v any) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return fmt.Errorf("SaveStruct needs a struct, got %T", v)
+	}
+
+	values := make(map[string][]byte)
+	var deleted []string
+	for _, f := range fieldsOf(rv.Type()) {
+		fv := rv.FieldByIndex(f.index)
+		if f.omitEmpty && fv.IsZero() {
+			deleted = append(deleted, f.name)
+			continue
+		}
+		b, err := encodeStructField(fv)
+		if err != nil {
+			return fmt.Errorf("failed to encode field %s: %v", f.name, err)
+		}
+		values[f.name] = b
+	}
+
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("SaveStruct", key, func(tx *txn) error {
+		for name, value := range values {
+			if err := hset(tx, key, name, value); err != nil {
+				return err
+			}
+		}
+		for _, name := range deleted {
+			if err := hdel(tx, key, name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// LoadStruct fills the struct out points to from the hash key, decoding the fields stored
+// by SaveStruct. Struct fields without a hash field keep their value. It returns
+// ErrKeyNotFound if the hash does not exist.
+func (db *DB) LoadStruct(key string, out any) error {
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("LoadStruct needs a pointer to a struct, got %T", out)
+	}
+	rv = rv.Elem()
+
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.view("LoadStruct", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return fmt.Errorf("%w: %s", ErrKeyNotFound, key)
+		}
+		n := 0
+		for _, f := range fieldsOf(rv.Type()) {
+			b, ok := getField(bucket, f.name)
+			if !ok {
+				continue
+			}
+			if err := decodeStructField(rv.FieldByIndex(f.index), b); err != nil {
+				return fmt.Errorf("failed to decode field %s: %v", f.name, err)
+			}
+			n += len(b)
+		}
+		db.metrics.addRead(n)
+		return nil
+	})
+}
+
+// fieldsOf returns the mapped fields of the struct type t.
+func fieldsOf(t reflect.Type) []structField {
+	if fields, ok := structFields.Load(t); ok {
+		return fields.([]structField)
+	}
+	var fields []structField
+	for _, sf := range reflect.VisibleFields(t) {
+		if !sf.IsExported() || sf.Anonymous || throughPointer(t, sf.Index) {
+			continue
+		}
+		f := structField{index: sf.Index, name: sf.Name}
+		if tag, ok := sf.Tag.Lookup("jungle"); ok {
+			name, opts, _ := strings.Cut(tag, ",")
+			if name == "-" {
+				continue
+			}
+			if name != "" {
+				f.name = name
+			}
+			f.omitEmpty = opts == "omitempty"
+		}
+		fields = append(fields, f)
+	}
+	structFields.Store(t, fields)
+	return fields
+}
+
+// throughPointer reports whether the field of t at index is promoted from an embedded
+// pointer, which may be nil.
+func throughPointer(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		t = t.Field(i).Type
+		if t.Kind() == reflect.Pointer {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	protoMessageType    = reflect.TypeFor[proto.Message]()
+	textMarshalerType   = reflect.TypeFor[encoding.TextMarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// encodeStructField encodes a struct field value as a hash field value.
+func encodeStructField(v reflect.Value) ([]byte, error) {
+	if v.Type().Implements(protoMessageType) {
+		if v.IsNil() {
+			return []byte{}, nil
+		}
+		return protoMarshal.Marshal(v.Interface().(proto.Message))
+	}
+	if v.Kind() != reflect.Pointer && v.Type().Implements(textMarshalerType) {
+		return v.Interface().(encoding.TextMarshaler).MarshalText()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return []byte(v.String()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return binary.BigEndian.AppendUint64(nil, uint64(v.Int())), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return binary.BigEndian.AppendUint64(nil, v.Uint()), nil
+	case reflect.Bool:
+		return []byte(strconv.FormatBool(v.Bool())), nil
+	case reflect.Float32, reflect.Float64:
+		return []byte(strconv.FormatFloat(v.Float(), 'g', -1, v.Type().Bits())), nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return append([]byte{}, v.Bytes()...), nil
+		}
+	}
+	return json.Marshal(v.Interface())
+}
+
+// decodeStructField decodes a hash field value encoded by encodeStructField into v.
+func decodeStructField(v reflect.Value, b []byte) error {
+	if v.Type().Implements(protoMessageType) {
+		if v.Kind() == reflect.Pointer && v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+		return proto.Unmarshal(b, v.Interface().(proto.Message))
+	}
+	if v.Kind() != reflect.Pointer && v.Addr().Type().Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText(b)
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(string(b))
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if len(b) != 8 {
+			return fmt.Errorf("expected an 8-byte integer, got %d bytes", len(b))
+		}
+		n := int64(binary.BigEndian.Uint64(b))
+		if v.OverflowInt(n) {
+			return fmt.Errorf("%d overflows %s", n, v.Type())
+		}
+		v.SetInt(n)
+		return nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if len(b) != 8 {
+			return fmt.Errorf("expected an 8-byte integer, got %d bytes", len(b))
+		}
+		n := binary.BigEndian.Uint64(b)
+		if v.OverflowUint(n) {
+			return fmt.Errorf("%d overflows %s", n, v.Type())
+		}
+		v.SetUint(n)
+		return nil
+	case reflect.Bool:
+		x, err := strconv.ParseBool(string(b))
+		if err != nil {
+			return err
+		}
+		v.SetBool(x)
+		return nil
+	case reflect.Float32, reflect.Float64:
+		x, err := strconv.ParseFloat(string(b), v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(x)
+		return nil
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			v.SetBytes(append([]byte{}, b...))
+			return nil
+		}
+	}
+	return json.Unmarshal(b, v.Addr().Interface())
+}
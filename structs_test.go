@@ -0,0 +1,100 @@
+package jungledb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type testAudit struct {
+	Created time.Time `jungle:"created_at"`
+}
+
+type testUser struct {
+	testAudit
+	Name     string `jungle:"name"`
+	Age      int    `jungle:"age"`
+	Score    float64
+	Admin    bool
+	Avatar   []byte
+	Nickname string `jungle:"nick,omitempty"`
+	Address  testAddress
+	Tags     []string
+	Password string `jungle:"-"`
+	internal int
+}
+
+// TestStructs tests saving and loading structs, including the encodings of each kind of
+// field and the tag options.
+func TestStructs(t *testing.T) {
+	db, err := Open("testdata/structs.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	user := testUser{
+		testAudit: testAudit{Created: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		Name:      "alice",
+		Age:       34,
+		Score:     9.5,
+		Admin:     true,
+		Avatar:    []byte{0, 1, 2},
+		Nickname:  "al",
+		Address:   testAddress{City: "Paris", Zip: "75001"},
+		Tags:      []string{"a", "b"},
+		Password:  "secret",
+		internal:  1,
+	}
+	if err := db.SaveStruct("user:1", &user); err != nil {
+		t.Fatalf("SaveStruct failed: %v", err)
+	}
+
+	fields, err := db.Hscan("user:1")
+	if err != nil {
+		t.Fatalf("Hscan failed: %v", err)
+	}
+	if _, ok := fields["Password"]; ok {
+		t.Errorf("expected the skipped field not to be stored")
+	}
+	if string(fields["Address"]) != `{"city":"Paris","zip":"75001"}` || string(fields["created_at"]) != "2024-05-01T12:00:00Z" {
+		t.Errorf("unexpected encodings: %q %q", fields["Address"], fields["created_at"])
+	}
+	if age, err := db.HgetInt("user:1", "age"); err != nil || age != 34 {
+		t.Errorf("expected the age as a binary integer, got %v %v", age, err)
+	}
+
+	var loaded testUser
+	if err := db.LoadStruct("user:1", &loaded); err != nil {
+		t.Fatalf("LoadStruct failed: %v", err)
+	}
+	user.Password, user.internal = "", 0
+	if !reflect.DeepEqual(loaded, user) {
+		t.Errorf("expected %+v, got %+v", user, loaded)
+	}
+
+	// Zero omitempty fields are deleted
+	user.Nickname = ""
+	if err := db.SaveStruct("user:1", user); err != nil {
+		t.Fatalf("SaveStruct failed: %v", err)
+	}
+	if ok, err := db.HhasKey("user:1", "nick"); err != nil || ok {
+		t.Errorf("expected the empty nickname to be deleted, got %v %v", ok, err)
+	}
+
+	if err := db.LoadStruct("missing", &loaded); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound, got %v", err)
+	}
+	if err := db.LoadStruct("user:1", loaded); err == nil {
+		t.Errorf("expected a non-pointer to fail")
+	}
+	if err := db.SaveStruct("user:2", 42); err == nil {
+		t.Errorf("expected a non-struct to fail")
+	}
+}
@@ -0,0 +1,176 @@
+package jungledb
+
+import (
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// BackgroundLimits caps the pace of background and bulk work, so that it leaves room for
+// foreground operations. It applies to the expiry sweeper, maintenance, Compact, FlushAll
+// and DeleteByPattern together: they pause between batches until their work fits in the
+// limits. Zero leaves a limit off.
+type BackgroundLimits struct {
+	KeysPerSecond  float64 // Keys written, copied or deleted per second
+	BytesPerSecond float64 // Bytes of those keys per second
+}
+
+// compactPaceKeys is how many keys Compact copies between pauses at most.
+const compactPaceKeys = 1000
+
+// throttle paces background work within BackgroundLimits.
+type throttle struct {
+	mu     sync.Mutex
+	limits BackgroundLimits
+	next   time.Time // When the work done so far fits in the limits
+}
+
+// WithBackgroundLimits sets the initial limits of background work, see SetBackgroundLimits.
+func WithBackgroundLimits(limits BackgroundLimits) Option {
+	return func(o *options) {
+		o.backgroundLimits = limits
+	}
+}
+
+// SetBackgroundLimits changes the limits of background work, taking effect at the next
+// batch of running jobs.
+func (db *DB) SetBackgroundLimits(limits BackgroundLimits) {
+	db.throttle.mu.Lock()
+	defer db.throttle.mu.Unlock()
+	db.throttle.limits = limits
+	db.throttle.next = time.Time{} // Work done so far was paced under the old limits
+}
+
+// BackgroundLimits returns the current limits of background work.
+func (db *DB) BackgroundLimits() BackgroundLimits {
+	db.throttle.mu.Lock()
+	defer db.throttle.mu.Unlock()
+	return db.throttle.limits
+}
+
+// countBytes reports whether the bytes of background work need counting.
+func (t *throttle) countBytes() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.limits.BytesPerSecond > 0
+}
+
+// batch returns how many keys a job should handle at once, at most n, so that it pauses
+// about ten times a second rather than once per batch of n.
+func (t *throttle) batch(n int) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if kps := t.limits.KeysPerSecond; kps > 0 && kps/10 < float64(n) {
+		return max(1, int(kps/10))
+	}
+	return n
+}
+
+// wait accounts for work done on keys totalling bytes and waits until it fits in the
+// limits. It reports false if stop was closed first.
+func (t *throttle) wait(keys, bytes int, stop <-chan struct{}) bool {
+	t.mu.Lock()
+	var cost time.Duration
+	if kps := t.limits.KeysPerSecond; kps > 0 {
+		cost = time.Duration(float64(keys) / kps * float64(time.Second))
+	}
+	if bps := t.limits.BytesPerSecond; bps > 0 {
+		cost = max(cost, time.Duration(float64(bytes)/bps*float64(time.Second)))
+	}
+	now := time.Now()
+	if t.next.Before(now) {
+		t.next = now
+	}
+	t.next = t.next.Add(cost)
+	d := t.next.Sub(now)
+	t.mu.Unlock()
+	return sleep(d, stop)
+}
+
+// bucketBytes returns the bytes of the keys and values of b and its nested buckets.
+func bucketBytes(b *bbolt.Bucket) int {
+	n := 0
+	b.ForEach(func(k, v []byte) error {
+		n += len(k) + len(v)
+		if v == nil {
+			n += bucketBytes(b.Bucket(k))
+		}
+		return nil
+	})
+	return n
+}
+
+// compactCopy copies src into dst like bbolt.Compact, committing every txMaxSize bytes
+// and pacing the copy with t between commits.
+func compactCopy(dst, src *bbolt.DB, txMaxSize int64, t *throttle) error {
+	tx, err := dst.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer func() { tx.Rollback() }() // Fails once committed
+
+	var size int64
+	var paceKeys, paceBytes int // Copied since the last pause
+	// Incremented at every commit, which invalidates the buckets of tx
+	gen := 0
+	err = src.View(func(stx *bbolt.Tx) error {
+		var copyBucket func(from *bbolt.Bucket, path [][]byte) error
+		copyBucket = func(from *bbolt.Bucket, path [][]byte) error {
+			var to *bbolt.Bucket
+			toGen := -1
+			return from.ForEach(func(k, v []byte) error {
+				if size += int64(len(k) + len(v)); size > txMaxSize {
+					if err := tx.Commit(); err != nil {
+						return err
+					}
+					var err error
+					if tx, err = dst.Begin(true); err != nil {
+						return err
+					}
+					size = 0
+					gen++
+				}
+				if paceKeys++; paceKeys >= t.batch(compactPaceKeys) {
+					t.wait(paceKeys, paceBytes, nil)
+					paceKeys, paceBytes = 0, 0
+				}
+				paceBytes += len(k) + len(v)
+				if toGen != gen {
+					to = tx.Bucket(path[0])
+					for _, name := range path[1:] {
+						to = to.Bucket(name)
+					}
+					to.FillPercent = 1.0 // Fill pages for the best compaction
+					toGen = gen
+				}
+				if v != nil {
+					return to.Put(k, v)
+				}
+				nested, err := to.CreateBucket(k)
+				if err != nil {
+					return err
+				}
+				if err := nested.SetSequence(from.Bucket(k).Sequence()); err != nil {
+					return err
+				}
+				return copyBucket(from.Bucket(k), append(path[:len(path):len(path)], k))
+			})
+		}
+
+		return stx.ForEach(func(name []byte, b *bbolt.Bucket) error {
+			to, err := tx.CreateBucket(name)
+			if err != nil {
+				return err
+			}
+			if err := to.SetSequence(b.Sequence()); err != nil {
+				return err
+			}
+			return copyBucket(b, [][]byte{name})
+		})
+	})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,67 @@
+package jungledb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestBackgroundLimits tests that bulk deletes and compaction keep to the limits.
+func TestBackgroundLimits(t *testing.T) {
+	db, err := Open("testdata/throttle.db", WithBackgroundLimits(BackgroundLimits{KeysPerSecond: 200}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	err = db.Update(func(tx *Tx) error {
+		for i := range 50 {
+			if err := tx.Hset(fmt.Sprintf("tmp:%d", i), "f", []byte("v")); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	// Batches of 20 keys, each taking 100ms of the budget
+	start := time.Now()
+	if n, err := db.DeleteByPattern("tmp:*"); err != nil || n != 50 {
+		t.Fatalf("expected 50 keys deleted, got %d %v", n, err)
+	}
+	if d := time.Since(start); d < 150*time.Millisecond {
+		t.Errorf("expected the deletion to be paced, took %v", d)
+	}
+
+	db.SetBackgroundLimits(BackgroundLimits{KeysPerSecond: 20000, BytesPerSecond: 1 << 30})
+	if limits := db.BackgroundLimits(); limits.KeysPerSecond != 20000 {
+		t.Errorf("expected the new limits, got %+v", limits)
+	}
+	err = db.Update(func(tx *Tx) error {
+		for i := range 3000 {
+			if err := tx.Zadd("ranking", float64(i), fmt.Sprint(i)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	start = time.Now()
+	if err := db.Compact("testdata/throttle_compacted.db"); err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if d := time.Since(start); d < 250*time.Millisecond {
+		t.Errorf("expected the compaction to be paced, took %v", d)
+	}
+	compacted, err := Open("testdata/throttle_compacted.db")
+	if err != nil {
+		t.Fatalf("failed to open the copy: %v", err)
+	}
+	defer compacted.Close()
+	if score, err := compacted.Zscore("ranking", "2999"); err != nil || score != 2999 {
+		t.Errorf("expected the copy to hold the data, got %v %v", score, err)
+	}
+}
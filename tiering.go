@@ -0,0 +1,336 @@
+package jungledb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// tierAccessBucket holds the last access of the keys subject to tiering:
+	// key -> 8-byte Unix nanoseconds.
+	tierAccessBucket = internalPrefix + "tier_access"
+
+	// archivedBucket holds the names of the keys moved to the archive by ArchiveCold.
+	archivedBucket = internalPrefix + "archived"
+)
+
+// Archive stores keys moved out of the database by ArchiveCold, for example in another
+// file (see NewDBArchive) or in object storage. Keys are stored names, including their
+// namespace, and data is opaque.
+type Archive interface {
+	Put(key string, data []byte) error
+	Get(key string) (data []byte, ok bool, err error)
+	Delete(key string) error
+}
+
+// TieringOptions configures WithTiering.
+type TieringOptions struct {
+	Prefix  string        // Only keys starting with Prefix, in any namespace, are archived
+	After   time.Duration // Keys not read or written for that long are archived
+	Archive Archive
+}
+
+// WithTiering enables moving the keys that are rarely used to an archive with ArchiveCold,
+// to keep the database file small. Reads and writes of keys with a TTL are tracked like
+// other keys, and the archived keys keep theirs. Reading or writing an archived key by
+// name brings it back into the database first, transparently, but scans, listings,
+// Lookup, Query and the writes of Update do not see archived keys.
+//
+// Archiving is not a mutation: it is not reported to watchers, triggers or the operation
+// log, and leaves indexes, links and views in place for when the keys come back.
+func WithTiering(opts TieringOptions) Option {
+	return func(o *options) {
+		o.tiering = opts
+	}
+}
+
+// ArchiveCold moves the keys that have not been accessed for the period set with
+// WithTiering to its archive and returns how many were moved. Keys not accessed since
+// tiering was enabled count from the first call. Applications call it periodically, for
+// example daily.
+func (db *DB) ArchiveCold() (int, error) {
+	t := db.opts.tiering
+	if t.Archive == nil {
+		return 0, fmt.Errorf("tiering is not enabled")
+	}
+
+	// Keys that predate tiering start counting now
+	err := db.silentUpdate("ArchiveCold", func(tx *txn) error {
+		access, err := tx.CreateBucketIfNotExists([]byte(tierAccessBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create tier access bucket: %v", err)
+		}
+		now := encodeSeq(uint64(db.now().UnixNano()))
+		var names []string
+		tx.ForEach(func(name []byte, _ *bbolt.Bucket) error {
+			if db.tiered(tx.Tx, string(name)) && access.Get(name) == nil {
+				names = append(names, string(name))
+			}
+			return nil
+		})
+		for _, name := range names {
+			if err := access.Put([]byte(name), now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	type candidate struct {
+		name   string
+		access []byte
+		data   []byte
+	}
+	var cold []candidate
+	err = db.view("ArchiveCold", "", func(tx *bbolt.Tx) error {
+		access := tx.Bucket([]byte(tierAccessBucket))
+		if access == nil {
+			return nil
+		}
+		before := uint64(db.now().Add(-t.After).UnixNano())
+		return access.ForEach(func(k, v []byte) error {
+			bucket := db.liveBucket(tx, string(k))
+			if len(v) != 8 || binary.BigEndian.Uint64(v) > before || bucket == nil {
+				return nil
+			}
+			data, err := json.Marshal(newExportRecord(tx, k, bucket))
+			if err != nil {
+				return err
+			}
+			cold = append(cold, candidate{string(k), append([]byte{}, v...), data})
+			return nil
+		})
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var stored []candidate
+	for _, c := range cold {
+		if err := t.Archive.Put(c.name, c.data); err != nil {
+			err = fmt.Errorf("failed to archive %s: %w", c.name, err)
+			if len(stored) == 0 {
+				return 0, err
+			}
+			db.log.Warn("archiving stopped", "error", err)
+			break
+		}
+		stored = append(stored, c)
+	}
+
+	archived := make(map[string]bool)
+	err = db.silentUpdate("ArchiveCold", func(tx *txn) error {
+		access := tx.Bucket([]byte(tierAccessBucket))
+		marks, err := tx.CreateBucketIfNotExists([]byte(archivedBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create archived bucket: %v", err)
+		}
+		for _, c := range stored {
+			if string(access.Get([]byte(c.name))) != string(c.access) || tx.Bucket([]byte(c.name)) == nil {
+				continue // Accessed meanwhile
+			}
+			if err := deleteKey(tx, c.name); err != nil {
+				return err
+			}
+			if err := access.Delete([]byte(c.name)); err != nil {
+				return err
+			}
+			if err := marks.Put([]byte(c.name), []byte{}); err != nil {
+				return err
+			}
+			archived[c.name] = true
+		}
+		return nil
+	})
+	if err != nil {
+		archived = nil // Rolled back
+	}
+	for _, c := range stored {
+		if !archived[c.name] {
+			if err := t.Archive.Delete(c.name); err != nil {
+				db.log.Warn("failed to delete unused archive copy", "key", c.name, "error", err)
+			}
+		}
+	}
+	if len(archived) > 0 {
+		db.log.Info("archived cold keys", "keys", len(archived))
+	}
+	return len(archived), err
+}
+
+// faultIn brings the key name back from the archive if ArchiveCold moved it there. It
+// runs before operations on name take the database locks.
+func (db *DB) faultIn(name string) error {
+	t := db.opts.tiering
+	if t.Archive == nil || name == "" || db.readOnly || db.isClosed() {
+		return nil
+	}
+	archived := false
+	db.db.View(func(tx *bbolt.Tx) error {
+		marks := tx.Bucket([]byte(archivedBucket))
+		archived = marks != nil && marks.Get([]byte(name)) != nil
+		return nil
+	})
+	if !archived {
+		return nil
+	}
+
+	data, ok, err := t.Archive.Get(name)
+	if err != nil {
+		return fmt.Errorf("failed to load %s from the archive: %w", name, err)
+	}
+	var rec exportRecord
+	if ok {
+		if err := json.Unmarshal(data, &rec); err != nil {
+			return fmt.Errorf("corrupt archived key %s: %v", name, err)
+		}
+	}
+	err = db.silentUpdate("FaultIn", func(tx *txn) error {
+		marks := tx.Bucket([]byte(archivedBucket))
+		if marks == nil || marks.Get([]byte(name)) == nil {
+			return nil // Brought back meanwhile
+		}
+		if err := marks.Delete([]byte(name)); err != nil {
+			return err
+		}
+		if !ok || tx.Bucket([]byte(name)) != nil || (rec.ExpiresAt != 0 && rec.ExpiresAt <= db.now().UnixNano()) {
+			return nil // Lost, written again through Update, or expired while archived
+		}
+		rec.Key = name
+		return importRecord(tx, rec)
+	})
+	if err != nil {
+		return err
+	}
+	if ok {
+		if err := t.Archive.Delete(name); err != nil {
+			db.log.Warn("failed to delete restored archive copy", "key", name, "error", err)
+		}
+	}
+	return nil
+}
+
+// silentUpdate runs fn in a read-write transaction whose mutations are not reported as
+// events, for changes to how data is stored rather than to the data.
+func (db *DB) silentUpdate(op string, fn func(tx *txn) error) error {
+	return db.update(op, "", func(tx *txn) error {
+		kept := len(tx.events) // Those of expired keys
+		err := fn(tx)
+		tx.events = tx.events[:kept]
+		return err
+	})
+}
+
+// trackTiering stores the pending reads of keys subject to tiering and records the
+// writes of tx as accesses.
+func (db *DB) trackTiering(tx *txn) error {
+	if db.opts.tiering.Archive == nil {
+		return nil
+	}
+	p := &db.tierAccesses
+	p.mu.Lock()
+	reads := p.keys
+	p.keys = nil
+	p.mu.Unlock()
+	if len(reads) == 0 && len(tx.events) == 0 {
+		return nil
+	}
+
+	access, err := tx.CreateBucketIfNotExists([]byte(tierAccessBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create tier access bucket: %v", err)
+	}
+	for name, last := range reads {
+		if tx.Bucket([]byte(name)) == nil {
+			continue
+		}
+		if err := access.Put([]byte(name), encodeSeq(uint64(last))); err != nil {
+			return err
+		}
+	}
+	now := encodeSeq(uint64(db.now().UnixNano()))
+	for _, ev := range tx.events {
+		for _, name := range []string{ev.Key, ev.Target} {
+			if !db.tiered(tx.Tx, name) {
+				continue
+			}
+			if tx.Bucket([]byte(name)) == nil {
+				err = access.Delete([]byte(name))
+			} else {
+				err = access.Put([]byte(name), now)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// tierReads collects reads of keys subject to tiering until the next write stores them.
+type tierReads struct {
+	mu   sync.Mutex
+	keys map[string]int64 // Last read in Unix nanoseconds
+}
+
+// touchTier records a read of the key name if it is subject to tiering.
+func (db *DB) touchTier(name string) {
+	if db.opts.tiering.Archive == nil || name == "" {
+		return
+	}
+	if _, key, ok := splitKey(name); !ok || !strings.HasPrefix(key, db.opts.tiering.Prefix) {
+		return
+	}
+	p := &db.tierAccesses
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keys == nil {
+		p.keys = make(map[string]int64)
+	}
+	p.keys[name] = db.now().UnixNano()
+}
+
+// tiered reports whether the stored key name is subject to tiering.
+func (db *DB) tiered(tx *bbolt.Tx, name string) bool {
+	if name == "" || isInternalBucket(tx, []byte(name)) {
+		return false
+	}
+	_, key, ok := splitKey(name)
+	return ok && strings.HasPrefix(key, db.opts.tiering.Prefix)
+}
+
+// dbArchive is an Archive stored in a database.
+type dbArchive struct {
+	db *DB
+}
+
+// archiveKey is the hash holding the keys stored by NewDBArchive.
+const archiveKey = "archive"
+
+// NewDBArchive returns an Archive storing keys in the hash "archive" of db, typically a
+// database in a separate file, on slower and cheaper storage, or attached with Attach.
+func NewDBArchive(db *DB) Archive {
+	return dbArchive{db}
+}
+
+func (a dbArchive) Put(key string, data []byte) error {
+	return a.db.Hset(archiveKey, key, data)
+}
+
+func (a dbArchive) Get(key string) ([]byte, bool, error) {
+	data, err := a.db.Hget(archiveKey, key)
+	return data, data != nil, err
+}
+
+func (a dbArchive) Delete(key string) error {
+	return a.db.Hdel(archiveKey, key)
+}
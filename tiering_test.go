@@ -0,0 +1,78 @@
+package jungledb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestTiering tests that cold keys move to the archive and come back when accessed.
+func TestTiering(t *testing.T) {
+	archiveDB, err := Open("testdata/tiering_archive.db")
+	if err != nil {
+		t.Fatalf("failed to open archive: %v", err)
+	}
+	defer archiveDB.Close()
+	archive := NewDBArchive(archiveDB)
+
+	db, err := Open("testdata/tiering.db", WithTiering(TieringOptions{Prefix: "doc:", After: time.Hour, Archive: archive}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	for _, key := range []string{"doc:1", "doc:2", "user:1"} {
+		if err := db.Hset(key, "body", []byte(key)); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	if err := db.Zadd("doc:ranking", 2, "doc:1"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if n, err := db.ArchiveCold(); err != nil || n != 0 {
+		t.Fatalf("expected nothing archived yet, got %d %v", n, err)
+	}
+
+	// Age every access record past the threshold
+	err = db.silentUpdate("test", func(tx *txn) error {
+		access := tx.Bucket([]byte(tierAccessBucket))
+		for _, key := range []string{"doc:1", "doc:2", "doc:ranking"} {
+			if err := access.Put([]byte(key), encodeSeq(uint64(time.Now().Add(-2*time.Hour).UnixNano()))); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to age accesses: %v", err)
+	}
+	if n, err := db.ArchiveCold(); err != nil || n != 3 {
+		t.Fatalf("expected 3 keys archived, got %d %v", n, err)
+	}
+	if keys, _, err := db.ListKeys("", "", 0); err != nil || !reflect.DeepEqual(keys, []string{"user:1"}) {
+		t.Errorf("expected only user:1 left in the file, got %v %v", keys, err)
+	}
+	if _, ok, err := archive.Get("doc:1"); err != nil || !ok {
+		t.Errorf("expected doc:1 in the archive, got %v %v", ok, err)
+	}
+
+	// Reads and writes bring keys back
+	if v, err := db.Hget("doc:1", "body"); err != nil || string(v) != "doc:1" {
+		t.Errorf("expected doc:1 faulted back in, got %q %v", v, err)
+	}
+	if _, ok, err := archive.Get("doc:1"); err != nil || ok {
+		t.Errorf("expected doc:1 removed from the archive, got %v %v", ok, err)
+	}
+	if err := db.Hset("doc:2", "title", []byte("two")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if fields, err := db.Hscan("doc:2"); err != nil || len(fields) != 2 {
+		t.Errorf("expected the write merged into the archived hash, got %v %v", fields, err)
+	}
+	if score, err := db.Zscore("doc:ranking", "doc:1"); err != nil || score != 2 {
+		t.Errorf("expected the sorted set faulted back in, got %v %v", score, err)
+	}
+	if n, err := db.ArchiveCold(); err != nil || n != 0 {
+		t.Errorf("expected recently accessed keys to stay, got %d %v", n, err)
+	}
+}
@@ -0,0 +1,94 @@
+package jungledb
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// tlsHandshakeTimeout bounds the TLS handshake of a new server connection.
+const tlsHandshakeTimeout = 10 * time.Second
+
+// LoadTLSConfig returns a server TLS configuration using the PEM certificate and key in
+// certFile and keyFile. If clientCAFile is not empty, clients must present a certificate
+// signed by one of the PEM certificates it contains. The configuration can be passed to
+// WithTLS, and equally to an http.Server or to grpc credentials.NewTLS.
+func LoadTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %v", err)
+	}
+	cfg := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile != "" {
+		pem, err := os.ReadFile(clientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", clientCAFile)
+		}
+		cfg.ClientCAs = pool
+		cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+	return cfg, nil
+}
+
+// WithTLS makes ServeRESP and ServeReplication accept only TLS connections configured by
+// cfg, for example one returned by LoadTLSConfig. Unix sockets (see ServeUnix) are served
+// without TLS. Failed handshakes, including rejected client certificates, count as
+// authentication failures in Metrics and are logged.
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) {
+		o.tls = cfg
+	}
+}
+
+// WithReplicaAuth sets the credentials a replica presents to its primary in Replicate:
+// the token of a user of the primary's ACL (see WithACL) and, if tlsConfig is not nil, the
+// TLS configuration to connect with, including any client certificate.
+func WithReplicaAuth(token string, tlsConfig *tls.Config) Option {
+	return func(o *options) {
+		o.replicaToken = token
+		o.replicaTLS = tlsConfig
+	}
+}
+
+// tlsListener wraps ln according to WithTLS.
+func (db *DB) tlsListener(ln net.Listener) net.Listener {
+	if db.opts.tls == nil || ln.Addr().Network() == "unix" {
+		return ln
+	}
+	return tls.NewListener(ln, db.opts.tls)
+}
+
+// handshake completes the TLS handshake of a connection accepted by a tlsListener, so that
+// rejected clients are reported before any request is read.
+func (db *DB) handshake(conn net.Conn) bool {
+	tc, ok := conn.(*tls.Conn)
+	if !ok {
+		return true
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), tlsHandshakeTimeout)
+	defer cancel()
+	if err := tc.HandshakeContext(ctx); err != nil {
+		db.authFailed("TLS handshake failed", "addr", conn.RemoteAddr().String(), "error", err)
+		return false
+	}
+	return true
+}
+
+// authFailed counts a failed authentication in the metrics and logs it.
+func (db *DB) authFailed(msg string, attrs ...any) {
+	db.metrics.mu.Lock()
+	db.metrics.authFailures++
+	db.metrics.mu.Unlock()
+	db.log.Warn(msg, attrs...)
+}
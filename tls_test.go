@@ -0,0 +1,220 @@
+package jungledb
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testPKI is a certificate authority with a server and a client certificate it signed.
+type testPKI struct {
+	dir    string // Holds ca.pem, server.pem and server-key.pem
+	roots  *x509.CertPool
+	client tls.Certificate
+}
+
+// newTestPKI generates a testPKI under testdata/name.
+func newTestPKI(t *testing.T, name string) *testPKI {
+	t.Helper()
+	dir := filepath.Join("testdata", name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", dir, err)
+	}
+
+	newKey := func() *ecdsa.PrivateKey {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		return key
+	}
+	serial := int64(0)
+	sign := func(tmpl *x509.Certificate, key *ecdsa.PrivateKey, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) *x509.Certificate {
+		serial++
+		tmpl.SerialNumber = big.NewInt(serial)
+		tmpl.NotBefore = time.Now().Add(-time.Hour)
+		tmpl.NotAfter = time.Now().Add(time.Hour)
+		if parent == nil {
+			parent, parentKey = tmpl, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, tmpl, parent, &key.PublicKey, parentKey)
+		if err != nil {
+			t.Fatalf("failed to create certificate: %v", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("failed to parse certificate: %v", err)
+		}
+		return cert
+	}
+	writePEM := func(file, kind string, der []byte) {
+		if err := os.WriteFile(filepath.Join(dir, file), pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0600); err != nil {
+			t.Fatalf("failed to write %s: %v", file, err)
+		}
+	}
+
+	caKey := newKey()
+	ca := sign(&x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, caKey, nil, nil)
+	serverKey := newKey()
+	server := sign(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: "server"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, serverKey, ca, caKey)
+	clientKey := newKey()
+	client := sign(&x509.Certificate{
+		Subject:     pkix.Name{CommonName: "client"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, clientKey, ca, caKey)
+
+	writePEM("ca.pem", "CERTIFICATE", ca.Raw)
+	writePEM("server.pem", "CERTIFICATE", server.Raw)
+	serverKeyDER, err := x509.MarshalECPrivateKey(serverKey)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+	writePEM("server-key.pem", "EC PRIVATE KEY", serverKeyDER)
+
+	pki := &testPKI{dir: dir, roots: x509.NewCertPool()}
+	pki.roots.AddCert(ca)
+	pki.client = tls.Certificate{Certificate: [][]byte{client.Raw}, PrivateKey: clientKey}
+	return pki
+}
+
+// serverConfig loads the server configuration, requiring client certificates.
+func (p *testPKI) serverConfig(t *testing.T) *tls.Config {
+	t.Helper()
+	cfg, err := LoadTLSConfig(filepath.Join(p.dir, "server.pem"), filepath.Join(p.dir, "server-key.pem"), filepath.Join(p.dir, "ca.pem"))
+	if err != nil {
+		t.Fatalf("LoadTLSConfig failed: %v", err)
+	}
+	return cfg
+}
+
+// TestServeRESPTLS tests that WithTLS requires verified client certificates and that
+// failed handshakes and AUTH commands are counted as authentication failures.
+func TestServeRESPTLS(t *testing.T) {
+	pki := newTestPKI(t, "tls-resp")
+	acl, err := NewACL(ACLUser{Name: "app", Token: "s3cret", Namespaces: map[string]Access{"": AccessReadWrite}})
+	if err != nil {
+		t.Fatalf("NewACL failed: %v", err)
+	}
+	db, err := Open("testdata/tls-resp.db", WithTLS(pki.serverConfig(t)), WithACL(acl))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go db.ServeRESP(ln)
+
+	dial := func(certs ...tls.Certificate) (*redisClient, error) {
+		conn, err := tls.Dial("tcp", ln.Addr().String(), &tls.Config{RootCAs: pki.roots, Certificates: certs})
+		if err != nil {
+			return nil, err
+		}
+		return &redisClient{conn: conn, r: newRESPReader(conn), w: newRESPWriter(conn)}, nil
+	}
+
+	client, err := dial(pki.client)
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer client.close()
+	if _, err := client.do("AUTH", "wrong"); err == nil {
+		t.Error("AUTH with a wrong token should fail")
+	}
+	if reply, err := client.do("AUTH", "s3cret"); err != nil || reply != "OK" {
+		t.Fatalf("AUTH failed: %v %v", reply, err)
+	}
+	if reply, err := client.do("HSET", "k", "f", "v"); err != nil || reply != int64(1) {
+		t.Errorf("HSET over TLS failed: %v %v", reply, err)
+	}
+
+	// Without a client certificate the server ends the handshake; with TLS 1.3 the client
+	// only notices on its first read
+	if anonymous, err := dial(); err == nil {
+		if _, err := anonymous.do("PING"); err == nil {
+			t.Error("connection without a client certificate should fail")
+		}
+		anonymous.close()
+	}
+	waitFor(t, "authentication failures", func() bool { return db.Metrics().AuthFailures == 2 })
+
+	// Plain connections are refused too
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("failed to connect: %v", err)
+	}
+	defer conn.Close()
+	plain := &redisClient{conn: conn, r: newRESPReader(conn), w: newRESPWriter(conn)}
+	if _, err := plain.do("PING"); err == nil {
+		t.Error("plain connection should fail")
+	}
+}
+
+// TestReplicationTLS tests that a replica connects to a primary over TLS with the
+// credentials set by WithReplicaAuth.
+func TestReplicationTLS(t *testing.T) {
+	pki := newTestPKI(t, "tls-repl")
+	acl, err := NewACL(ACLUser{Name: "replica", Token: "r3pl", Namespaces: map[string]Access{"": AccessRead, "*": AccessRead}})
+	if err != nil {
+		t.Fatalf("NewACL failed: %v", err)
+	}
+	primary, err := Open("testdata/tls-primary.db", WithOpLog(), WithTLS(pki.serverConfig(t)), WithACL(acl))
+	if err != nil {
+		t.Fatalf("failed to open primary: %v", err)
+	}
+	defer primary.Close()
+	replica, err := Open("testdata/tls-replica.db",
+		WithReplicaAuth("r3pl", &tls.Config{RootCAs: pki.roots, Certificates: []tls.Certificate{pki.client}}))
+	if err != nil {
+		t.Fatalf("failed to open replica: %v", err)
+	}
+	defer replica.Close()
+
+	if err := primary.Hset("config", "mode", []byte("blue")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+	go primary.ServeReplication(ln)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		replica.Replicate(ctx, ln.Addr().String())
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	waitFor(t, "snapshot", func() bool {
+		value, _ := replica.Hget("config", "mode")
+		return string(value) == "blue"
+	})
+}
@@ -0,0 +1,163 @@
+package jungledb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// trashPrefix starts the names of the keys removed with SoftDelete, followed by the
+	// name they had.
+	trashPrefix = "__jungledb.trash:"
+
+	// trashIndexKey names the hash recording, for each key of its namespace removed with
+	// SoftDelete, when it was removed (8-byte Unix nanoseconds) and the TTL it had then
+	// (idem, zero if none).
+	trashIndexKey = "__jungledb.trashed"
+)
+
+// defaultTrashRetention is how long SoftDelete keeps keys by default.
+const defaultTrashRetention = 7 * 24 * time.Hour
+
+// DeletedKey is a key removed with SoftDelete, as listed by ListDeleted.
+type DeletedKey struct {
+	Key       string
+	DeletedAt time.Time
+	PurgeAt   time.Time // When the key is deleted for good unless restored
+}
+
+// WithTrashRetention sets how long SoftDelete keeps keys before they are deleted for good,
+// 7 days by default.
+func WithTrashRetention(retention time.Duration) Option {
+	return func(o *options) {
+		o.trashRetention = retention
+	}
+}
+
+// SoftDelete removes a hash or sorted set from reads and scans, as HdelBucket does, but
+// keeps it so that Restore can bring it back until the retention period set with
+// WithTrashRetention has passed, or its TTL if that comes first. It replaces a key of
+// the same name soft deleted before. It fails with ErrKeyNotFound if key does not exist.
+func (db *DB) SoftDelete(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	name, trashed := db.nsKey(key), db.nsKey(trashPrefix+key)
+	return db.update("SoftDelete", name, func(tx *txn) error {
+		if db.liveBucket(tx.Tx, name) == nil {
+			return fmt.Errorf("%w: %s", ErrKeyNotFound, name)
+		}
+		if tx.Bucket([]byte(trashed)) != nil {
+			if err := deleteKey(tx, trashed); err != nil {
+				return err
+			}
+			tx.record(Event{Type: EventDelete, Key: trashed})
+		}
+
+		now := db.now()
+		ttl := expiry(tx.Tx, name)
+		if err := renameKey(tx, name, trashed); err != nil {
+			return err
+		}
+		purgeAt := now.Add(db.trashRetention()).UnixNano()
+		if ttl != 0 && ttl < purgeAt {
+			purgeAt = ttl
+		}
+		if err := setExpiry(tx, trashed, purgeAt); err != nil {
+			return err
+		}
+		record := binary.BigEndian.AppendUint64(encodeSeq(uint64(now.UnixNano())), uint64(ttl))
+		return hset(tx, db.nsKey(trashIndexKey), key, record)
+	})
+}
+
+// Restore brings back a key removed with SoftDelete, with the TTL it had. It fails with
+// ErrKeyNotFound if there is no such key, or it was deleted for good, and with
+// ErrKeyExists if a key of that name was created since.
+func (db *DB) Restore(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	name, trashed := db.nsKey(key), db.nsKey(trashPrefix+key)
+	return db.update("Restore", name, func(tx *txn) error {
+		if db.liveBucket(tx.Tx, trashed) == nil {
+			return fmt.Errorf("%w: no soft deleted key %s", ErrKeyNotFound, name)
+		}
+		if tx.Bucket([]byte(name)) != nil {
+			return fmt.Errorf("%w: %s", ErrKeyExists, name)
+		}
+		var ttl int64
+		if bucket := tx.Bucket([]byte(db.nsKey(trashIndexKey))); bucket != nil {
+			if record, ok := getField(bucket, key); ok && len(record) == 16 {
+				ttl = int64(binary.BigEndian.Uint64(record[8:]))
+			}
+		}
+		if err := renameKey(tx, trashed, name); err != nil {
+			return err
+		}
+		if err := setExpiry(tx, name, ttl); err != nil {
+			return err
+		}
+		return hdel(tx, db.nsKey(trashIndexKey), key)
+	})
+}
+
+// ListDeleted returns the keys removed with SoftDelete that can still be restored, in
+// order.
+func (db *DB) ListDeleted() ([]DeletedKey, error) {
+	var keys []DeletedKey
+	index := db.nsKey(trashIndexKey)
+	err := db.view("ListDeleted", index, func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(index))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			trashed := db.nsKey(trashPrefix + string(k))
+			if db.liveBucket(tx, trashed) == nil || len(v) != 16 {
+				return nil
+			}
+			keys = append(keys, DeletedKey{
+				Key:       string(k),
+				DeletedAt: time.Unix(0, int64(binary.BigEndian.Uint64(v))),
+				PurgeAt:   time.Unix(0, expiry(tx, trashed)),
+			})
+			return nil
+		})
+	})
+	return keys, err
+}
+
+// updateTrash forgets the soft deleted keys that tx deleted for good.
+func (db *DB) updateTrash(tx *txn) error {
+	events := tx.events // Forgetting keys records more events, which need no processing
+	for _, ev := range events {
+		switch ev.Type {
+		case EventDelete, EventExpired, EventEvicted:
+		default:
+			continue
+		}
+		ns, key, _ := splitKey(ev.Key)
+		key, ok := strings.CutPrefix(key, trashPrefix)
+		if !ok || tx.Bucket([]byte(ev.Key)) != nil {
+			continue // Not a soft deleted key, or replaced by SoftDelete
+		}
+		if err := hdel(tx, ns+trashIndexKey, key); err != nil && !errors.Is(err, ErrWrongType) {
+			return err
+		}
+	}
+	return nil
+}
+
+// trashRetention returns how long SoftDelete keeps keys.
+func (db *DB) trashRetention() time.Duration {
+	if db.opts.trashRetention > 0 {
+		return db.opts.trashRetention
+	}
+	return defaultTrashRetention
+}
@@ -0,0 +1,76 @@
+package jungledb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestSoftDelete tests that soft deleted keys are hidden until restored or purged.
+func TestSoftDelete(t *testing.T) {
+	db, err := Open("testdata/soft_delete.db", WithTrashRetention(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("user:1", "name", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Expire("user:1", 10*time.Minute); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if err := db.Link("team:1", "members", "user:1"); err != nil {
+		t.Fatalf("Link failed: %v", err)
+	}
+	if err := db.SoftDelete("user:1"); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+	if fields, err := db.Hscan("user:1"); err != nil || len(fields) != 0 {
+		t.Errorf("expected the key to be hidden, got %v %v", fields, err)
+	}
+	if keys, _, err := db.ListKeys("", "", 0); err != nil || len(keys) != 0 {
+		t.Errorf("expected no keys listed, got %v %v", keys, err)
+	}
+	deleted, err := db.ListDeleted()
+	if err != nil || len(deleted) != 1 || deleted[0].Key != "user:1" {
+		t.Fatalf("expected user:1 listed as deleted, got %+v %v", deleted, err)
+	}
+	if purge := time.Until(deleted[0].PurgeAt); purge > 10*time.Minute || purge < 9*time.Minute {
+		t.Errorf("expected the key purged when its TTL elapses, in %v", purge)
+	}
+	if err := db.SoftDelete("user:1"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound soft deleting a missing key, got %v", err)
+	}
+
+	if err := db.Restore("user:1"); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if v, err := db.Hget("user:1", "name"); err != nil || string(v) != "alice" {
+		t.Errorf("expected the key restored, got %q %v", v, err)
+	}
+	if ttl, err := db.TTL("user:1"); err != nil || ttl <= 9*time.Minute || ttl > 10*time.Minute {
+		t.Errorf("expected the TTL restored, got %v %v", ttl, err)
+	}
+	if links, err := db.Links("team:1", "members"); err != nil || !reflect.DeepEqual(links, []string{"user:1"}) {
+		t.Errorf("expected the links restored, got %v %v", links, err)
+	}
+	if deleted, err := db.ListDeleted(); err != nil || len(deleted) != 0 {
+		t.Errorf("expected nothing left deleted, got %+v %v", deleted, err)
+	}
+	if err := db.Restore("user:1"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound restoring twice, got %v", err)
+	}
+
+	// Restoring over a newer key fails
+	if err := db.SoftDelete("user:1"); err != nil {
+		t.Fatalf("SoftDelete failed: %v", err)
+	}
+	if err := db.Hset("user:1", "name", []byte("bob")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Restore("user:1"); !errors.Is(err, ErrKeyExists) {
+		t.Errorf("expected ErrKeyExists, got %v", err)
+	}
+}
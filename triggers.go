@@ -0,0 +1,80 @@
+package jungledb
+
+import (
+	"fmt"
+	"sync"
+)
+
+// maxTriggerDepth bounds how many times triggers can fire on the writes of other triggers
+// within a transaction, which stops triggers that feed each other.
+const maxTriggerDepth = 16
+
+// trigger is a function registered with RegisterTrigger.
+type trigger struct {
+	db      *DB // Handle that registered the trigger, whose namespace it sees
+	pattern string
+	fn      func(tx *Tx, ev Event) error
+}
+
+// triggers holds the registered triggers of a database.
+type triggers struct {
+	mu   sync.RWMutex
+	list []*trigger
+}
+
+// RegisterTrigger makes fn run for every mutation of a key matching the glob pattern (see
+// ListKeys; empty matches everything), inside the transaction making it and before it
+// commits, so that it can update counters or derived keys atomically with it. An error
+// from fn fails the write and rolls back the whole transaction. Events match as for
+// Watch, with a namespace handle seeing those of its own keys only and fn writing to that
+// namespace. Writes made by fn fire triggers in turn, up to a depth of 16 after which the
+// transaction fails. fn must not call db methods, which would deadlock, but only those of
+// tx. Call unregister to remove the trigger.
+func (db *DB) RegisterTrigger(pattern string, fn func(tx *Tx, ev Event) error) (unregister func()) {
+	t := &trigger{db: db, pattern: pattern, fn: fn}
+	db.triggers.mu.Lock()
+	db.triggers.list = append(db.triggers.list, t)
+	db.triggers.mu.Unlock()
+
+	return func() {
+		db.triggers.mu.Lock()
+		defer db.triggers.mu.Unlock()
+		for i, other := range db.triggers.list {
+			if other == t {
+				db.triggers.list = append(db.triggers.list[:i:i], db.triggers.list[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// runTriggers runs the triggers matching the events of tx, and those matching the events
+// the triggers record in turn.
+func (db *DB) runTriggers(tx *txn) error {
+	db.triggers.mu.RLock()
+	list := db.triggers.list
+	db.triggers.mu.RUnlock()
+	if len(list) == 0 {
+		return nil
+	}
+
+	for start, depth := 0, 0; start < len(tx.events); depth++ {
+		if depth == maxTriggerDepth {
+			return fmt.Errorf("triggers nested more than %d levels deep", maxTriggerDepth)
+		}
+		end := len(tx.events)
+		for _, ev := range tx.events[start:end] {
+			for _, t := range list {
+				ev, ok := eventIn(t.db, t.pattern, ev)
+				if !ok {
+					continue
+				}
+				if err := t.fn(&Tx{db: t.db, tx: tx}, ev); err != nil {
+					return fmt.Errorf("trigger on %q failed: %w", t.pattern, err)
+				}
+			}
+		}
+		start = end
+	}
+	return nil
+}
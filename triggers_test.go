@@ -0,0 +1,74 @@
+package jungledb
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// TestTriggers tests that triggers run in the transaction of the write firing them, roll
+// it back on error and stop when they nest too deep.
+func TestTriggers(t *testing.T) {
+	db, err := Open("testdata/triggers.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	count := func(tx *Tx, delta int) error {
+		v, err := tx.Hget("stats", "posts")
+		if err != nil {
+			return err
+		}
+		n, _ := strconv.Atoi(string(v))
+		return tx.Hset("stats", "posts", []byte(strconv.Itoa(n+delta)))
+	}
+	unregister := db.RegisterTrigger("post:*", func(tx *Tx, ev Event) error {
+		switch ev.Type {
+		case EventHset:
+			if ev.Field == "title" {
+				return count(tx, 1)
+			}
+		case EventDelete:
+			return count(tx, -1)
+		}
+		return nil
+	})
+	for _, post := range []string{"post:1", "post:2", "post:3"} {
+		if err := db.Hset(post, "title", []byte(post)); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	if err := db.HdelBucket("post:2"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if v, err := db.Hget("stats", "posts"); err != nil || string(v) != "2" {
+		t.Errorf("expected 2 posts counted, got %q %v", v, err)
+	}
+
+	// A failing trigger rolls back the write
+	errVeto := errors.New("veto")
+	unregisterVeto := db.RegisterTrigger("post:*", func(tx *Tx, ev Event) error { return errVeto })
+	if err := db.Hset("post:4", "title", []byte("post:4")); !errors.Is(err, errVeto) {
+		t.Errorf("expected the trigger error, got %v", err)
+	}
+	if v, err := db.Hget("post:4", "title"); err != nil || v != nil {
+		t.Errorf("expected the write to be rolled back, got %q %v", v, err)
+	}
+	if v, err := db.Hget("stats", "posts"); err != nil || string(v) != "2" {
+		t.Errorf("expected the count to be rolled back, got %q %v", v, err)
+	}
+	unregisterVeto()
+	unregister()
+
+	// Triggers see namespace keys, and feeding each other is stopped
+	ns := db.Namespace("tenant")
+	ns.RegisterTrigger("ping", func(tx *Tx, ev Event) error { return tx.Hset("pong", "n", []byte("1")) })
+	ns.RegisterTrigger("pong", func(tx *Tx, ev Event) error { return tx.Hset("ping", "n", []byte("1")) })
+	if err := ns.Hset("ping", "n", []byte("0")); err == nil {
+		t.Errorf("expected triggers feeding each other to fail")
+	}
+	if err := db.Hset("ping", "n", []byte("0")); err != nil {
+		t.Errorf("expected triggers of a namespace not to fire on the root, got %v", err)
+	}
+}
@@ -0,0 +1,336 @@
+package jungledb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+const (
+	// ttlBucket maps a key to its expiry deadline: key -> 8-byte big-endian Unix nanoseconds.
+	ttlBucket = internalPrefix + "ttl"
+
+	// expiryBucket orders keys by deadline for the sweeper: deadline + key -> empty.
+	expiryBucket = internalPrefix + "expiry"
+
+	// expireBatchSize bounds how many expired keys are removed per transaction.
+	expireBatchSize = 1000
+
+	// defaultSweepInterval is how often the background sweeper looks for expired keys
+	// unless WithExpirySweep says otherwise.
+	defaultSweepInterval = time.Second
+)
+
+// WithExpirySweep sets how often a background goroutine removes expired keys, emitting
+// EventExpired for each. The default is one second; a negative interval disables the
+// sweeper, leaving expired keys to be removed by the next write.
+func WithExpirySweep(interval time.Duration) Option {
+	return func(o *options) {
+		o.sweepInterval = interval
+	}
+}
+
+// WithOnExpire registers a callback invoked with the name of every key removed because
+// its TTL elapsed. It runs after the removal has committed, without database locks held,
+// so it may use the database.
+func WithOnExpire(fn func(key string)) Option {
+	return func(o *options) {
+		o.onExpire = fn
+	}
+}
+
+// Expire sets a time to live on a hash or sorted set, replacing any previous one. Once it
+// elapses the key reads as missing and is removed by the sweeper or the next write, which
+// emits an EventExpired to watchers. A ttl <= 0 removes the key right away.
+// Expire returns ErrKeyNotFound if the key does not exist.
+func (db *DB) Expire(key string, ttl time.Duration) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.expireAt("Expire", key, db.now().Add(ttl))
+}
+
+// ExpireAt is like Expire with an absolute deadline.
+func (db *DB) ExpireAt(key string, deadline time.Time) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.expireAt("ExpireAt", key, deadline)
+}
+
+func (db *DB) expireAt(op, key string, deadline time.Time) error {
+	return db.update(op, key, func(tx *txn) error {
+		if tx.Bucket([]byte(key)) == nil {
+			return ErrKeyNotFound
+		}
+		if !deadline.After(db.now()) {
+			return expireKey(tx, key)
+		}
+		return setExpiry(tx, key, deadline.UnixNano())
+	})
+}
+
+// Persist removes the time to live of key, if any.
+// It returns ErrKeyNotFound if the key does not exist.
+func (db *DB) Persist(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	return db.update("Persist", key, func(tx *txn) error {
+		if tx.Bucket([]byte(key)) == nil {
+			return ErrKeyNotFound
+		}
+		if expiry(tx.Tx, key) == 0 {
+			return nil
+		}
+		return setExpiry(tx, key, 0)
+	})
+}
+
+// HsetEx is like Hset but also sets the time to live of key to ttl (or removes it if
+// ttl is 0) in the same transaction, so the field is never stored without its expiry.
+func (db *DB) HsetEx(key, field string, value []byte, ttl time.Duration) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	if ttl < 0 {
+		return fmt.Errorf("invalid TTL %v", ttl)
+	}
+	return db.update("HsetEx", key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
+		if tx.Bucket([]byte(key)) != nil && db.liveBucket(tx.Tx, key) == nil {
+			if err := expireKey(tx, key); err != nil { // Elapsed, not swept yet
+				return err
+			}
+		}
+		bucket, err := tx.CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		tx.record(Event{Type: EventHset, Key: key, Field: field, Value: value})
+		if err := bucket.Put([]byte(field), value); err != nil {
+			return err
+		}
+		var at int64
+		if ttl > 0 {
+			at = db.now().Add(ttl).UnixNano()
+		} else if expiry(tx.Tx, key) == 0 {
+			return nil
+		}
+		return setExpiry(tx, key, at)
+	})
+}
+
+// TTL returns the remaining time to live of key, or 0 if it has none.
+// It returns ErrKeyNotFound if the key does not exist or has expired.
+func (db *DB) TTL(key string) (time.Duration, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	key = db.nsKey(key)
+	var ttl time.Duration
+	err := db.view("TTL", key, func(tx *bbolt.Tx) error {
+		if db.liveBucket(tx, key) == nil {
+			return ErrKeyNotFound
+		}
+		if at := expiry(tx, key); at != 0 {
+			ttl = time.Unix(0, at).Sub(db.now())
+		}
+		return nil
+	})
+	return ttl, err
+}
+
+// expiry returns the deadline of key in Unix nanoseconds, or 0 if it has none.
+func expiry(tx *bbolt.Tx, key string) int64 {
+	bucket := tx.Bucket([]byte(ttlBucket))
+	if bucket == nil {
+		return 0
+	}
+	v := bucket.Get([]byte(key))
+	if len(v) != 8 {
+		return 0
+	}
+	return int64(binary.BigEndian.Uint64(v))
+}
+
+// liveBucket returns the bucket of key, or nil if it does not exist or its TTL has elapsed
+// but the sweeper has not removed it yet.
+func (db *DB) liveBucket(tx *bbolt.Tx, key string) *bbolt.Bucket {
+	bucket := tx.Bucket([]byte(key))
+	if bucket == nil || isInternalBucket(tx, []byte(key)) {
+		return nil
+	}
+	if at := expiry(tx, key); at != 0 && at <= db.now().UnixNano() {
+		return nil
+	}
+	return bucket
+}
+
+// setExpiry sets (or with at == 0 clears) the deadline of key and records it.
+func setExpiry(tx *txn, key string, at int64) error {
+	if err := putExpiry(tx.Tx, key, at); err != nil {
+		return err
+	}
+	tx.record(Event{Type: EventExpire, Key: key, ExpiresAt: at})
+	return nil
+}
+
+// putExpiry sets (or with at == 0 clears) the deadline of key in both TTL buckets.
+func putExpiry(tx *bbolt.Tx, key string, at int64) error {
+	if old := expiry(tx, key); old != 0 {
+		if err := tx.Bucket([]byte(ttlBucket)).Delete([]byte(key)); err != nil {
+			return err
+		}
+		if index := tx.Bucket([]byte(expiryBucket)); index != nil {
+			if err := index.Delete(expiryKey(old, key)); err != nil {
+				return err
+			}
+		}
+	}
+	if at == 0 {
+		return nil
+	}
+
+	ttls, err := tx.CreateBucketIfNotExists([]byte(ttlBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create TTL bucket: %v", err)
+	}
+	index, err := tx.CreateBucketIfNotExists([]byte(expiryBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create expiry bucket: %v", err)
+	}
+	if err := ttls.Put([]byte(key), EncodeExpiry(at)); err != nil {
+		return err
+	}
+	return index.Put(expiryKey(at, key), []byte{})
+}
+
+// expireKey removes key because its TTL elapsed.
+func expireKey(tx *txn, key string) error {
+	if err := deleteKey(tx, key); err != nil && !errors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+	tx.record(Event{Type: EventExpired, Key: key})
+	return nil
+}
+
+// expireDue removes up to limit keys whose deadline is at or before now and reports
+// whether more are due.
+func expireDue(tx *txn, now time.Time, limit int) (more bool, err error) {
+	index := tx.Bucket([]byte(expiryBucket))
+	if index == nil {
+		return false, nil
+	}
+
+	var due []string
+	c := index.Cursor()
+	for k, _ := c.First(); k != nil && int64(binary.BigEndian.Uint64(k)) <= now.UnixNano(); k, _ = c.Next() {
+		if len(due) == limit {
+			more = true
+			break
+		}
+		due = append(due, string(k[8:]))
+	}
+
+	for _, key := range due {
+		if err := expireKey(tx, key); err != nil {
+			return false, fmt.Errorf("failed to expire key %s: %v", key, err)
+		}
+	}
+	return more, nil
+}
+
+// expiryKey encodes a deadline and key so the expiry index sorts by deadline.
+func expiryKey(at int64, key string) []byte {
+	return EncodeExpiryKey(at, key)
+}
+
+// sweepDue reports whether any key has expired, without taking the database lock.
+func (db *DB) sweepDue() bool {
+	due := false
+	db.db.View(func(tx *bbolt.Tx) error {
+		if index := tx.Bucket([]byte(expiryBucket)); index != nil {
+			k, _ := index.Cursor().First()
+			due = k != nil && int64(binary.BigEndian.Uint64(k)) <= db.now().UnixNano()
+		}
+		return nil
+	})
+	return due
+}
+
+// sweep runs the background sweeper until stop is closed. Expired keys are removed by
+// the expiry pass every write transaction starts with, so the sweeper only needs to run
+// empty writes while keys are due.
+func (db *DB) sweep(interval time.Duration, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	var retained time.Time // Last enforcement of the retention rules
+	for {
+		select {
+		case <-stop:
+			return
+		case <-db.clock().After(interval):
+		}
+
+		for db.sweepDue() {
+			expired, freed := 0, 0
+			err := db.update("Sweep", "", func(tx *txn) error {
+				expired, freed = len(tx.events), tx.freed
+				return nil
+			})
+			if err != nil {
+				db.log.Warn("expiry sweep failed", "error", err)
+				break
+			}
+			db.log.Info("expired keys removed", "count", expired)
+			if !db.throttle.wait(expired, freed, stop) {
+				return
+			}
+		}
+		db.sweepRetention(&retained, stop)
+	}
+}
+
+// startSweeper starts the background sweeper unless it is disabled.
+func (db *DB) startSweeper() {
+	interval := db.opts.sweepInterval
+	if interval < 0 {
+		return
+	}
+	if interval == 0 {
+		interval = defaultSweepInterval
+	}
+	db.stopSweep = make(chan struct{})
+	db.sweepDone = make(chan struct{})
+	go db.sweep(interval, db.stopSweep, db.sweepDone)
+}
+
+// stopSweeper stops the background sweeper and waits for it to exit.
+func (db *DB) stopSweeper() {
+	if db.stopSweep == nil {
+		return
+	}
+	db.stopSweepOnce.Do(func() { close(db.stopSweep) })
+	<-db.sweepDone
+}
+
+// notifyExpired invokes the OnExpire callback for every key events show as expired.
+func (db *DB) notifyExpired(events []Event) {
+	if db.opts.onExpire == nil {
+		return
+	}
+	for _, ev := range events {
+		if ev.Type == EventExpired {
+			db.opts.onExpire(ev.Key)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package jungledb
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+// TestExpire tests setting, reading and clearing TTLs and that expired keys read as missing.
+func TestExpire(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	db, err := Open("testdata/expire.db", WithExpirySweep(-1), WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Expire("missing", time.Minute); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected ErrKeyNotFound for a missing key, got %v", err)
+	}
+
+	if err := db.Hset("session", "user", []byte("alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if ttl, err := db.TTL("session"); err != nil || ttl != 0 {
+		t.Errorf("expected no TTL, got %v %v", ttl, err)
+	}
+	if err := db.Expire("session", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if ttl, err := db.TTL("session"); err != nil || ttl != time.Hour {
+		t.Errorf("expected a TTL of an hour, got %v %v", ttl, err)
+	}
+	if err := db.Persist("session"); err != nil {
+		t.Fatalf("Persist failed: %v", err)
+	}
+	if ttl, err := db.TTL("session"); err != nil || ttl != 0 {
+		t.Errorf("expected Persist to clear the TTL, got %v %v", ttl, err)
+	}
+
+	// Renames carry the TTL along
+	if err := db.Expire("session", time.Hour); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if err := db.Rename("session", "session2"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if ttl, err := db.TTL("session2"); err != nil || ttl == 0 {
+		t.Errorf("expected the TTL to follow the rename, got %v %v", ttl, err)
+	}
+
+	// Export carries TTLs and Import restores them
+	var buf bytes.Buffer
+	if err := db.Export(&buf, ExportOptions{}); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if err := db.Zadd("ranking", 1, "a"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	copied, err := Open("testdata/expire_import.db", WithExpirySweep(-1), WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer copied.Close()
+	if _, err := copied.Import(&buf); err != nil {
+		t.Fatalf("Import failed: %v", err)
+	}
+	if ttl, err := copied.TTL("session2"); err != nil || ttl == 0 {
+		t.Errorf("expected the TTL to survive export, got %v %v", ttl, err)
+	}
+
+	// Expired keys read as missing before anything removes them
+	if err := db.Expire("ranking", time.Minute); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	clock.advance(time.Minute)
+	if n, err := db.Zcard("ranking"); err != nil || n != 0 {
+		t.Errorf("expected an expired sorted set to be empty, got %d %v", n, err)
+	}
+	if _, err := db.TTL("ranking"); !errors.Is(err, ErrKeyNotFound) {
+		t.Errorf("expected TTL of an expired key to fail, got %v", err)
+	}
+	if keys, _, err := db.ListKeys("", "", 0); err != nil || len(keys) != 1 || keys[0] != "session2" {
+		t.Errorf("expected only session2 to be listed, got %v %v", keys, err)
+	}
+
+	// The next write removes it
+	if err := db.Hset("other", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Zadd("ranking", 2, "b"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if members, err := db.Zrange("ranking", 0, -1); err != nil || len(members) != 1 || members[0] != "b" {
+		t.Errorf("expected a fresh sorted set without TTL, got %v %v", members, err)
+	}
+	if ttl, err := db.TTL("ranking"); err != nil || ttl != 0 {
+		t.Errorf("expected the recreated key to have no TTL, got %v %v", ttl, err)
+	}
+
+	// A non-positive TTL removes the key right away
+	if err := db.Expire("other", 0); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+	if value, err := db.Hget("other", "f"); err != nil || value != nil {
+		t.Errorf("expected the key to be gone, got %q %v", value, err)
+	}
+}
+
+// TestExpireNotifications tests that the sweeper emits expiry events and calls OnExpire.
+func TestExpireNotifications(t *testing.T) {
+	expired := make(chan string, 1)
+	var db *DB
+	db, err := Open("testdata/expire_notify.db",
+		WithExpirySweep(5*time.Millisecond),
+		WithOnExpire(func(key string) {
+			// Callbacks may use the database
+			if _, err := db.Hget(key, "v"); err != nil {
+				t.Errorf("database unusable from OnExpire: %v", err)
+			}
+			expired <- key
+		}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	events, cancel := db.Watch("cache:*")
+	defer cancel()
+
+	if err := db.Hset("cache:1", "v", []byte("x")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Expire("cache:1", 20*time.Millisecond); err != nil {
+		t.Fatalf("Expire failed: %v", err)
+	}
+
+	select {
+	case key := <-expired:
+		if key != "cache:1" {
+			t.Errorf("OnExpire: expected cache:1, got %s", key)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnExpire was not called")
+	}
+
+	var types []EventType
+	for len(types) < 3 {
+		select {
+		case ev := <-events:
+			types = append(types, ev.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("missing events, got %v", types)
+		}
+	}
+	if types[0] != EventHset || types[1] != EventExpire || types[2] != EventExpired {
+		t.Errorf("unexpected events: %v", types)
+	}
+	if exists, err := db.HhasKey("cache:1", "v"); err != nil || exists {
+		t.Errorf("expected the key to be removed, got %v %v", exists, err)
+	}
+}
+
+// TestHsetEx tests that HsetEx sets a field together with the TTL of its key.
+func TestHsetEx(t *testing.T) {
+	clock := newManualClock(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	db, err := Open("testdata/hsetex.db", WithClock(clock))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.HsetEx("page:1", "body", []byte("<html>"), time.Minute); err != nil {
+		t.Fatalf("HsetEx failed: %v", err)
+	}
+	if value, err := db.Hget("page:1", "body"); err != nil || string(value) != "<html>" {
+		t.Errorf("Hget mismatch: got %q %v", value, err)
+	}
+	if ttl, err := db.TTL("page:1"); err != nil || ttl != time.Minute {
+		t.Errorf("TTL mismatch: got %v %v", ttl, err)
+	}
+	if err := db.HsetEx("page:1", "body", []byte("<p>"), 0); err != nil {
+		t.Fatalf("HsetEx failed: %v", err)
+	}
+	if ttl, err := db.TTL("page:1"); err != nil || ttl != 0 {
+		t.Errorf("HsetEx with a zero TTL should persist the key, got %v %v", ttl, err)
+	}
+
+	// An expired key starts afresh
+	if err := db.HsetEx("page:2", "a", []byte("1"), time.Second); err != nil {
+		t.Fatalf("HsetEx failed: %v", err)
+	}
+	clock.advance(time.Second)
+	if err := db.HsetEx("page:2", "b", []byte("2"), time.Minute); err != nil {
+		t.Fatalf("HsetEx failed: %v", err)
+	}
+	if fields, err := db.Hscan("page:2"); err != nil || len(fields) != 1 || string(fields["b"]) != "2" {
+		t.Errorf("Hscan after expiry mismatch: got %v %v", fields, err)
+	}
+
+	if err := db.Zadd("z", 1, "m"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.HsetEx("z", "f", nil, time.Minute); !errors.Is(err, ErrWrongType) {
+		t.Errorf("HsetEx on a sorted set: expected ErrWrongType, got %v", err)
+	}
+}
@@ -0,0 +1,208 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// errRollback is returned by transaction functions to roll back deliberately.
+var errRollback = errors.New("transaction rolled back")
+
+// Tx is a read-write transaction, passed to the functions run by Update and to migrations.
+// Its writes become visible to others, and are recorded in the operation and audit logs,
+// all at once when the function returns nil, and are discarded if it returns an error.
+// Keys are those of the namespace of the handle that started it. A Tx must not be used
+// after its function returns.
+type Tx struct {
+	db *DB
+	tx *txn
+
+	// Savepoints taken and not released, and the keys to restore when rolling back to
+	// them, see Savepoint
+	savepoints    []savepoint
+	lastSavepoint int
+	undo          []keySnapshot
+	saved         map[string]bool // Keys snapshotted since the last savepoint
+
+	declared map[string]bool // Names of the only keys it may use, nil for any, see WithKeys
+}
+
+// Update runs fn in a read-write transaction, committing it if fn returns nil and rolling
+// it back otherwise. Transactions are serialized: fn must not call db methods that write.
+func (db *DB) Update(fn func(tx *Tx) error) error {
+	return db.update("Update", "", func(tx *txn) error {
+		return fn(&Tx{db: db, tx: tx})
+	})
+}
+
+// Hset sets the field value in a hash.
+func (t *Tx) Hset(key, field string, value []byte) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = t.db.nsKey(key)
+	if err := t.check(key); err != nil {
+		return err
+	}
+	t.save(key)
+	return hset(t.tx, key, field, value)
+}
+
+// Hget returns the value of a field in a hash, nil if it does not exist.
+func (t *Tx) Hget(key, field string) ([]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = t.db.nsKey(key)
+	if err := t.check(key); err != nil {
+		return nil, err
+	}
+	bucket, err := t.db.hashBucket(t.tx.Tx, key)
+	if err != nil || bucket == nil {
+		return nil, err
+	}
+	v, ok := getField(bucket, field)
+	if !ok {
+		return nil, nil
+	}
+	return bytes.Clone(v), nil
+}
+
+// Hdel deletes a field from a hash.
+func (t *Tx) Hdel(key, field string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = t.db.nsKey(key)
+	if err := t.check(key); err != nil {
+		return err
+	}
+	t.save(key)
+	return hdel(t.tx, key, field)
+}
+
+// Hscan returns all fields and values of a hash.
+func (t *Tx) Hscan(key string) (map[string][]byte, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = t.db.nsKey(key)
+	if err := t.check(key); err != nil {
+		return nil, err
+	}
+	result := make(map[string][]byte)
+	bucket, err := t.db.hashBucket(t.tx.Tx, key)
+	if err != nil || bucket == nil {
+		return result, err
+	}
+	err = bucket.ForEach(func(k, v []byte) error {
+		result[string(k)] = bytes.Clone(v)
+		return nil
+	})
+	return result, err
+}
+
+// Delete deletes a hash or sorted set. It fails with ErrKeyNotFound if key does not exist.
+func (t *Tx) Delete(key string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = t.db.nsKey(key)
+	if err := t.check(key); err != nil {
+		return err
+	}
+	t.save(key)
+	if err := deleteKey(t.tx, key); err != nil {
+		return err
+	}
+	t.tx.record(Event{Type: EventDelete, Key: key})
+	return nil
+}
+
+// Rename moves a hash or sorted set to newKey, see DB.Rename.
+func (t *Tx) Rename(key, newKey string) error {
+	if key == newKey {
+		return nil
+	}
+	if err := checkKey(key, newKey); err != nil {
+		return err
+	}
+	key, newKey = t.db.nsKey(key), t.db.nsKey(newKey)
+	if err := t.check(key, newKey); err != nil {
+		return err
+	}
+	t.save(key, newKey)
+	return renameKey(t.tx, key, newKey)
+}
+
+// Zadd adds a member with a score to a sorted set, or updates its score.
+func (t *Tx) Zadd(key string, score float64, member string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = t.db.nsKey(key)
+	if err := t.check(key); err != nil {
+		return err
+	}
+	t.save(key)
+	return zadd(t.tx, key, score, member)
+}
+
+// Zrem removes a member from a sorted set.
+func (t *Tx) Zrem(key, member string) error {
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = t.db.nsKey(key)
+	if err := t.check(key); err != nil {
+		return err
+	}
+	t.save(key)
+	return zrem(t.tx, key, member)
+}
+
+// Zscore returns the score of a sorted set member, 0 if it does not exist.
+func (t *Tx) Zscore(key, member string) (float64, error) {
+	if err := checkKey(key); err != nil {
+		return 0, err
+	}
+	key = t.db.nsKey(key)
+	if err := t.check(key); err != nil {
+		return 0, err
+	}
+	if bucket, err := t.db.zsetBucket(t.tx.Tx, key); err != nil || bucket == nil {
+		return 0, err
+	}
+	index := membersBucket(t.tx.Tx, key)
+	if index == nil {
+		return 0, nil
+	}
+	v := index.Get([]byte(member))
+	if v == nil {
+		return 0, nil
+	}
+	if len(v) != 8 {
+		return 0, fmt.Errorf("%w: score of member %s is not an 8-byte float", ErrWrongType, member)
+	}
+	return math.Float64frombits(binary.BigEndian.Uint64(v)), nil
+}
+
+// Keys returns the keys matching the glob pattern (see ListKeys), in order. In a
+// transaction started by KeySet.Update, only declared keys are returned.
+func (t *Tx) Keys(pattern string) ([]string, error) {
+	var keys []string
+	c := t.tx.Cursor()
+	for k, _ := c.Seek([]byte(t.db.ns)); k != nil && bytes.HasPrefix(k, []byte(t.db.ns)); k, _ = c.Next() {
+		key, ok := t.db.userKey(string(k))
+		if !ok || (t.declared != nil && !t.declared[string(k)]) || isInternalBucket(t.tx.Tx, k) || t.db.liveBucket(t.tx.Tx, string(k)) == nil {
+			continue
+		}
+		if matchPattern(pattern, key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
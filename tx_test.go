@@ -0,0 +1,120 @@
+package jungledb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestUpdate tests that Update commits the writes of its function together or not at all.
+func TestUpdate(t *testing.T) {
+	db, err := Open("testdata/update.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	err = db.Update(func(tx *Tx) error {
+		if err := tx.Hset("user:1", "name", []byte("alice")); err != nil {
+			return err
+		}
+		if err := tx.Zadd("ranking", 3, "alice"); err != nil {
+			return err
+		}
+		if v, err := tx.Hget("user:1", "name"); err != nil || string(v) != "alice" {
+			t.Errorf("expected the transaction to read its own write, got %q %v", v, err)
+		}
+		if score, err := tx.Zscore("ranking", "alice"); err != nil || score != 3 {
+			t.Errorf("expected score 3, got %v %v", score, err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+
+	failure := errors.New("failure")
+	err = db.Update(func(tx *Tx) error {
+		if err := tx.Rename("user:1", "user:2"); err != nil {
+			return err
+		}
+		if err := tx.Hset("user:2", "email", []byte("a@example.com")); err != nil {
+			return err
+		}
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("expected the function's error, got %v", err)
+	}
+	if fields, err := db.Hscan("user:1"); err != nil || !reflect.DeepEqual(fields, map[string][]byte{"name": []byte("alice")}) {
+		t.Errorf("expected the failed transaction to change nothing, got %v %v", fields, err)
+	}
+
+	ns := db.Namespace("tenant")
+	err = ns.Update(func(tx *Tx) error {
+		if err := tx.Hset("user:1", "name", []byte("bob")); err != nil {
+			return err
+		}
+		if keys, err := tx.Keys("*"); err != nil || !reflect.DeepEqual(keys, []string{"user:1"}) {
+			t.Errorf("expected the namespace keys, got %v %v", keys, err)
+		}
+		return tx.Delete("user:1")
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if keys, _, err := db.ListKeys("", "", 0); err != nil || !reflect.DeepEqual(keys, []string{"ranking", "user:1"}) {
+		t.Errorf("expected the root keys untouched, got %v %v", keys, err)
+	}
+}
+
+// TestWithKeys tests that a transaction started by KeySet.Update may only use the
+// declared keys.
+func TestWithKeys(t *testing.T) {
+	db, err := Open("testdata/update.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("account:other", "balance", []byte("0")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	err = db.WithKeys("account:b", "account:a", "account:a").Update(func(tx *Tx) error {
+		if err := tx.Hset("account:a", "balance", []byte("60")); err != nil {
+			return err
+		}
+		if err := tx.Hset("account:b", "balance", []byte("40")); err != nil {
+			return err
+		}
+		if keys, err := tx.Keys("account:*"); err != nil || !reflect.DeepEqual(keys, []string{"account:a", "account:b"}) {
+			t.Errorf("expected only the declared keys, got %v %v", keys, err)
+		}
+		if _, err := tx.Hget("account:other", "balance"); !errors.Is(err, ErrUndeclaredKey) {
+			t.Errorf("Hget of an undeclared key: expected ErrUndeclaredKey, got %v", err)
+		}
+		if err := tx.Rename("account:a", "account:c"); !errors.Is(err, ErrUndeclaredKey) {
+			t.Errorf("Rename to an undeclared key: expected ErrUndeclaredKey, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if v, _ := db.Hget("account:b", "balance"); string(v) != "40" {
+		t.Errorf("expected the declared keys to be written, got %q", v)
+	}
+
+	err = db.WithKeys("account:a").Update(func(tx *Tx) error {
+		if err := tx.Hset("account:a", "balance", []byte("0")); err != nil {
+			return err
+		}
+		return tx.Delete("account:other")
+	})
+	if !errors.Is(err, ErrUndeclaredKey) {
+		t.Errorf("expected ErrUndeclaredKey, got %v", err)
+	}
+	if v, _ := db.Hget("account:a", "balance"); string(v) != "60" {
+		t.Errorf("expected the failed transaction to change nothing, got %q", v)
+	}
+}
@@ -0,0 +1,203 @@
+package jungledb
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.etcd.io/bbolt"
+)
+
+// undoBucket holds the data removed by destructive operations while WithUndo is enabled,
+// by entry ID (8-byte big-endian) as JSON undo records.
+const undoBucket = internalPrefix + "undo"
+
+// ErrUndoNotFound is returned by Undo for an entry that does not exist, was undone already
+// or has left the undo window.
+var ErrUndoNotFound = errors.New("undo entry not found")
+
+// UndoEntry is a destructive operation that Undo can revert.
+type UndoEntry struct {
+	ID   uint64
+	Op   string // Operation that removed the data, such as "HdelBucket"
+	Key  string
+	Time time.Time
+}
+
+// undoRecord is the data removed by a destructive operation.
+type undoRecord struct {
+	Op      string             `json:"op"`
+	Key     string             `json:"key"`
+	Time    int64              `json:"time"`
+	Whole   bool               `json:"whole,omitempty"` // The whole key was deleted
+	Fields  map[string][]byte  `json:"fields,omitempty"`
+	Members map[string]float64 `json:"members,omitempty"`
+	Expiry  int64              `json:"expiry,omitempty"`
+}
+
+// WithUndo keeps the data removed by HdelBucket, Hmdel and Zrem for window, so that the
+// operation can be reverted with Undo. Entries are listed by UndoLog and dropped once they
+// are older than window.
+func WithUndo(window time.Duration) Option {
+	return func(o *options) {
+		o.undoWindow = window
+	}
+}
+
+// UndoLog returns the destructive operations on the keys of db that can still be undone,
+// oldest first.
+func (db *DB) UndoLog() ([]UndoEntry, error) {
+	var entries []UndoEntry
+	err := db.view("UndoLog", "", func(tx *bbolt.Tx) error {
+		bucket := tx.Bucket([]byte(undoBucket))
+		if bucket == nil {
+			return nil
+		}
+		oldest := db.now().Add(-db.opts.undoWindow).UnixNano()
+		return bucket.ForEach(func(k, v []byte) error {
+			var rec undoRecord
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("corrupt undo entry: %v", err)
+			}
+			key, ok := db.userKey(rec.Key)
+			if ok && rec.Time >= oldest {
+				entries = append(entries, UndoEntry{ID: binary.BigEndian.Uint64(k), Op: rec.Op, Key: key, Time: time.Unix(0, rec.Time)})
+			}
+			return nil
+		})
+	})
+	return entries, err
+}
+
+// Undo reverts the destructive operation id listed by UndoLog, putting back the data it
+// removed: a deleted key is recreated with its TTL, and deleted fields and members are set
+// again, overwriting values written since. Undoing the deletion of a key fails with
+// ErrKeyExists if the key was created again since.
+func (db *DB) Undo(id uint64) error {
+	return db.update("Undo", "", func(tx *txn) error {
+		bucket := tx.Bucket([]byte(undoBucket))
+		var v []byte
+		if bucket != nil {
+			v = bucket.Get(encodeSeq(id))
+		}
+		var rec undoRecord
+		if v != nil {
+			if err := json.Unmarshal(v, &rec); err != nil {
+				return fmt.Errorf("corrupt undo entry: %v", err)
+			}
+		}
+		if _, ok := db.userKey(rec.Key); !ok || rec.Time < db.now().Add(-db.opts.undoWindow).UnixNano() {
+			return fmt.Errorf("%w: %d", ErrUndoNotFound, id)
+		}
+
+		if rec.Whole && tx.Bucket([]byte(rec.Key)) != nil {
+			return fmt.Errorf("%w: %s", ErrKeyExists, rec.Key)
+		}
+		for field, value := range rec.Fields {
+			if err := hset(tx, rec.Key, field, value); err != nil {
+				return err
+			}
+		}
+		for member, score := range rec.Members {
+			if err := zadd(tx, rec.Key, score, member); err != nil {
+				return err
+			}
+		}
+		if rec.Whole && rec.Expiry != 0 {
+			if rec.Expiry <= db.now().UnixNano() {
+				return expireKey(tx, rec.Key) // Would have expired by now
+			}
+			if err := setExpiry(tx, rec.Key, rec.Expiry); err != nil {
+				return err
+			}
+		}
+		return bucket.Delete(encodeSeq(id))
+	})
+}
+
+// saveUndo records the data of key about to be removed by op: the whole key, or the
+// fields of a hash or members of a sorted set that exist.
+func (db *DB) saveUndo(tx *txn, op, key string, whole bool, fields, members []string) error {
+	if db.opts.undoWindow <= 0 {
+		return nil
+	}
+	bucket := tx.Bucket([]byte(key))
+	if bucket == nil {
+		return nil
+	}
+	now := db.now().UnixNano()
+	rec := undoRecord{Op: op, Key: key, Time: now, Whole: whole}
+	if rec.Whole {
+		rec.Expiry = expiry(tx.Tx, key)
+	}
+
+	if index := membersBucket(tx.Tx, key); index != nil {
+		rec.Members = make(map[string]float64)
+		save := func(member, score []byte) {
+			if len(score) == 8 {
+				rec.Members[string(member)] = math.Float64frombits(binary.BigEndian.Uint64(score))
+			}
+		}
+		if rec.Whole {
+			index.ForEach(func(k, v []byte) error { save(k, v); return nil })
+		}
+		for _, member := range members {
+			save([]byte(member), index.Get([]byte(member)))
+		}
+		if len(rec.Members) == 0 && !rec.Whole {
+			return nil
+		}
+	} else {
+		rec.Fields = make(map[string][]byte)
+		if rec.Whole {
+			bucket.ForEach(func(k, v []byte) error {
+				rec.Fields[string(k)] = append([]byte{}, v...)
+				return nil
+			})
+		}
+		for _, field := range fields {
+			if v, ok := getField(bucket, field); ok {
+				rec.Fields[field] = append([]byte{}, v...)
+			}
+		}
+		if len(rec.Fields) == 0 && !rec.Whole {
+			return nil
+		}
+	}
+
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	undo, err := tx.CreateBucketIfNotExists([]byte(undoBucket))
+	if err != nil {
+		return fmt.Errorf("failed to create undo bucket: %v", err)
+	}
+	id, err := undo.NextSequence()
+	if err != nil {
+		return err
+	}
+	if err := undo.Put(encodeSeq(id), data); err != nil {
+		return err
+	}
+
+	// Drop the entries that left the window, oldest first
+	var old [][]byte
+	c := undo.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		var rec undoRecord
+		if err := json.Unmarshal(v, &rec); err == nil && rec.Time >= now-int64(db.opts.undoWindow) {
+			break
+		}
+		old = append(old, k)
+	}
+	for _, k := range old {
+		if err := undo.Delete(k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
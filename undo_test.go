@@ -0,0 +1,64 @@
+package jungledb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestUndo tests that destructive operations can be undone within the undo window.
+func TestUndo(t *testing.T) {
+	db, err := Open("testdata/undo.db", WithUndo(time.Hour))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hmset("user:1", map[string][]byte{"name": []byte("alice"), "email": []byte("a@example.com")}); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+	if err := db.Zadd("ranking", 3, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Hmdel("user:1", []string{"email", "missing"}); err != nil {
+		t.Fatalf("Hmdel failed: %v", err)
+	}
+	if err := db.HdelBucket("user:1"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if err := db.Zrem("ranking", "alice"); err != nil {
+		t.Fatalf("Zrem failed: %v", err)
+	}
+
+	entries, err := db.UndoLog()
+	if err != nil {
+		t.Fatalf("UndoLog failed: %v", err)
+	}
+	var ops []string
+	for _, e := range entries {
+		ops = append(ops, e.Op+" "+e.Key)
+	}
+	if want := []string{"Hmdel user:1", "HdelBucket user:1", "Zrem ranking"}; !reflect.DeepEqual(ops, want) {
+		t.Fatalf("expected %v, got %v", want, ops)
+	}
+
+	for i := len(entries) - 1; i >= 0; i-- {
+		if err := db.Undo(entries[i].ID); err != nil {
+			t.Fatalf("Undo of %s failed: %v", ops[i], err)
+		}
+	}
+	fields, err := db.Hscan("user:1")
+	if err != nil || !reflect.DeepEqual(fields, map[string][]byte{"name": []byte("alice"), "email": []byte("a@example.com")}) {
+		t.Errorf("expected the hash restored, got %v %v", fields, err)
+	}
+	if score, err := db.Zscore("ranking", "alice"); err != nil || score != 3 {
+		t.Errorf("expected the member restored, got %v %v", score, err)
+	}
+	if err := db.Undo(entries[0].ID); !errors.Is(err, ErrUndoNotFound) {
+		t.Errorf("expected ErrUndoNotFound undoing twice, got %v", err)
+	}
+	if entries, err := db.UndoLog(); err != nil || len(entries) != 0 {
+		t.Errorf("expected an empty undo log, got %v %v", entries, err)
+	}
+}
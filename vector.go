@@ -0,0 +1,269 @@
+package jungledb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"go.etcd.io/bbolt"
+)
+
+// ErrVectorDimension is returned when a vector does not have the dimension of the vectors
+// already stored under a key.
+var ErrVectorDimension = errors.New("vector dimension mismatch")
+
+// Metric is a distance between vectors, see VectorOptions.
+type Metric int
+
+const (
+	Cosine    Metric = iota // One minus the cosine similarity, from 0 to 2
+	Euclidean               // Euclidean distance
+)
+
+// VectorOptions configures vector search.
+type VectorOptions struct {
+	Metric Metric // Distance ranking VSearch results, Cosine by default
+
+	// HNSW maintains a hierarchical navigable small world graph for every key written with
+	// VAdd, stored in an internal bucket, so that VSearch visits a small part of the
+	// vectors instead of all of them, at the price of slower writes and approximate
+	// results. Keys written before it was enabled are searched exhaustively until their
+	// next VAdd, which builds their graph.
+	HNSW bool
+
+	M              int // Links per node and layer of the graph, 16 if zero; twice as many on the bottom layer
+	EfConstruction int // Candidates considered when linking a new node, 200 if zero
+	EfSearch       int // Candidates considered by VSearch, 64 if zero; at least the number of results
+}
+
+// WithVectors configures VAdd and VSearch.
+func WithVectors(opts VectorOptions) Option {
+	return func(o *options) {
+		o.vectors = opts
+	}
+}
+
+// VectorMatch is a vector found by VSearch.
+type VectorMatch struct {
+	ID       string
+	Distance float64
+}
+
+// VAdd stores vec under id in the hash key, replacing any vector with that id. Vectors are
+// stored as hash fields holding little-endian float32 values, so the other hash operations
+// apply to them: Hdel removes one and HdelBucket, expiry or eviction removes the key. Every
+// vector of a key must have the same dimension, or ErrVectorDimension is returned.
+func (db *DB) VAdd(key, id string, vec []float32) error {
+	if len(vec) == 0 {
+		return fmt.Errorf("%w: empty vector", ErrVectorDimension)
+	}
+	if err := checkKey(key); err != nil {
+		return err
+	}
+	key = db.nsKey(key)
+	value := encodeVector(vec)
+	return db.update("VAdd", key, func(tx *txn) error {
+		if err := checkType(tx.Tx, key, typeHash); err != nil {
+			return err
+		}
+		bucket, err := tx.CreateBucketIfNotExists([]byte(key))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket: %v", err)
+		}
+		c := bucket.Cursor()
+		for k, v := c.First(); k != nil; k, v = c.Next() {
+			if string(k) == id {
+				continue // Being replaced
+			}
+			if len(v) != len(value) {
+				return fmt.Errorf("%w: got %d values, key holds %d", ErrVectorDimension, len(vec), len(v)/4)
+			}
+			break
+		}
+		tx.record(Event{Type: EventHset, Key: key, Field: id, Value: value})
+		if err := bucket.Put([]byte(id), value); err != nil {
+			return err
+		}
+		if db.opts.vectors.HNSW && tx.Bucket([]byte(hnswPrefix+key)) == nil {
+			return db.buildHNSW(tx.Tx, key) // updateVectorIndex maintains it from now on
+		}
+		return nil
+	})
+}
+
+// VSearch returns the k vectors of key nearest to query, nearest first. It uses the HNSW
+// graph of key if WithVectors enabled it, and compares query with every vector otherwise.
+func (db *DB) VSearch(key string, query []float32, k int) ([]VectorMatch, error) {
+	if err := checkKey(key); err != nil {
+		return nil, err
+	}
+	key = db.nsKey(key)
+	var matches []VectorMatch
+	err := db.view("VSearch", key, func(tx *bbolt.Tx) error {
+		bucket, err := db.hashBucket(tx, key)
+		if err != nil || bucket == nil || k <= 0 {
+			return err
+		}
+		if _, v := bucket.Cursor().First(); len(v) != 4*len(query) {
+			return fmt.Errorf("%w: got %d values, key holds %d", ErrVectorDimension, len(query), len(v)/4)
+		}
+
+		if index := tx.Bucket([]byte(hnswPrefix + key)); index != nil && db.opts.vectors.HNSW {
+			h, err := db.newHNSW(bucket, index)
+			if err != nil {
+				return err
+			}
+			found, err := h.search(query, k)
+			if err != nil {
+				return err
+			}
+			for _, c := range found {
+				matches = append(matches, VectorMatch{ID: c.id, Distance: c.dist})
+			}
+			return nil
+		}
+
+		metric := db.opts.vectors.Metric
+		return bucket.ForEach(func(id, v []byte) error {
+			vec, ok := decodeVector(v)
+			if !ok || len(vec) != len(query) {
+				return nil // Not a vector of this key
+			}
+			matches = append(matches, VectorMatch{ID: string(id), Distance: metric.distance(query, vec)})
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Distance < matches[j].Distance })
+	if len(matches) > k {
+		matches = matches[:k]
+	}
+	return matches, nil
+}
+
+// updateVectorIndex brings the HNSW graphs up to date with the keys changed by tx.
+func (db *DB) updateVectorIndex(tx *txn) error {
+	for _, ev := range tx.events {
+		index := []byte(hnswPrefix + ev.Key)
+		if !db.opts.vectors.HNSW {
+			// Drop the graphs that would go stale, VAdd rebuilds them once HNSW is enabled again
+			if err := tx.DeleteBucket(index); err != nil && !errors.Is(err, bbolt.ErrBucketNotFound) {
+				return err
+			}
+			continue
+		}
+		switch ev.Type {
+		case EventHset, EventHdel:
+			if bucket := tx.Bucket(index); bucket != nil {
+				h, err := db.newHNSW(tx.Bucket([]byte(ev.Key)), bucket)
+				if err != nil {
+					return err
+				}
+				if err := h.remove(ev.Field); err != nil {
+					return err
+				}
+				if ev.Type == EventHset {
+					if err := h.insert(ev.Field); err != nil {
+						return err
+					}
+				}
+			}
+		case EventDelete, EventExpired, EventEvicted, EventRename, EventCopy:
+			if ev.Target != "" && tx.Bucket(index) != nil {
+				if err := db.buildHNSW(tx.Tx, ev.Target); err != nil {
+					return err
+				}
+			}
+			if ev.Type != EventCopy {
+				if err := tx.DeleteBucket(index); err != nil && !errors.Is(err, bbolt.ErrBucketNotFound) {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// buildHNSW replaces the HNSW graph of key by one holding all of its vectors.
+func (db *DB) buildHNSW(tx *bbolt.Tx, key string) error {
+	name := []byte(hnswPrefix + key)
+	if err := tx.DeleteBucket(name); err != nil && !errors.Is(err, bbolt.ErrBucketNotFound) {
+		return err
+	}
+	index, err := tx.CreateBucket(name)
+	if err != nil {
+		return fmt.Errorf("failed to create vector index bucket: %v", err)
+	}
+	if _, err := index.CreateBucket(hnswNodesKey); err != nil {
+		return err
+	}
+	bucket := tx.Bucket([]byte(key))
+	if bucket == nil {
+		return nil
+	}
+	var ids []string
+	bucket.ForEach(func(k, _ []byte) error {
+		ids = append(ids, string(k))
+		return nil
+	})
+	h, err := db.newHNSW(bucket, index)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err := h.insert(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// distance returns the distance between a and b, which have the same length.
+func (m Metric) distance(a, b []float32) float64 {
+	switch m {
+	case Euclidean:
+		var sum float64
+		for i := range a {
+			d := float64(a[i]) - float64(b[i])
+			sum += d * d
+		}
+		return math.Sqrt(sum)
+	default:
+		var dot, na, nb float64
+		for i := range a {
+			dot += float64(a[i]) * float64(b[i])
+			na += float64(a[i]) * float64(a[i])
+			nb += float64(b[i]) * float64(b[i])
+		}
+		if na == 0 || nb == 0 {
+			return 1
+		}
+		return 1 - dot/math.Sqrt(na*nb)
+	}
+}
+
+// encodeVector encodes vec as little-endian float32 values.
+func encodeVector(vec []float32) []byte {
+	b := make([]byte, 4*len(vec))
+	for i, f := range vec {
+		binary.LittleEndian.PutUint32(b[4*i:], math.Float32bits(f))
+	}
+	return b
+}
+
+// decodeVector decodes a vector encoded by encodeVector.
+func decodeVector(b []byte) ([]float32, bool) {
+	if len(b) == 0 || len(b)%4 != 0 {
+		return nil, false
+	}
+	vec := make([]float32, len(b)/4)
+	for i := range vec {
+		vec[i] = math.Float32frombits(binary.LittleEndian.Uint32(b[4*i:]))
+	}
+	return vec, true
+}
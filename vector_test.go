@@ -0,0 +1,138 @@
+package jungledb
+
+import (
+	"errors"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+)
+
+// matchIDs returns the ids of matches.
+func matchIDs(matches []VectorMatch) []string {
+	ids := make([]string, len(matches))
+	for i, m := range matches {
+		ids[i] = m.ID
+	}
+	return ids
+}
+
+// TestVSearch tests nearest-neighbor search with both metrics and the dimension checks.
+func TestVSearch(t *testing.T) {
+	db, err := Open("testdata/vsearch.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	vectors := map[string][]float32{
+		"east":  {1, 0},
+		"north": {0, 1},
+		"far":   {10, 1},
+		"west":  {-1, 0},
+	}
+	for id, vec := range vectors {
+		if err := db.VAdd("emb", id, vec); err != nil {
+			t.Fatalf("VAdd failed: %v", err)
+		}
+	}
+
+	matches, err := db.VSearch("emb", []float32{2, 0}, 2)
+	if err != nil {
+		t.Fatalf("VSearch failed: %v", err)
+	}
+	if ids := matchIDs(matches); !reflect.DeepEqual(ids, []string{"east", "far"}) {
+		t.Errorf("expected [east far] by cosine distance, got %v", ids)
+	}
+	if matches[0].Distance < 0 || matches[0].Distance > matches[1].Distance {
+		t.Errorf("expected increasing distances, got %+v", matches)
+	}
+
+	if err := db.VAdd("emb", "bad", []float32{1, 2, 3}); !errors.Is(err, ErrVectorDimension) {
+		t.Errorf("expected ErrVectorDimension from VAdd, got %v", err)
+	}
+	if _, err := db.VSearch("emb", []float32{1}, 1); !errors.Is(err, ErrVectorDimension) {
+		t.Errorf("expected ErrVectorDimension from VSearch, got %v", err)
+	}
+	if matches, err := db.VSearch("missing", []float32{1, 0}, 1); err != nil || len(matches) != 0 {
+		t.Errorf("expected no matches for a missing key, got %v %v", matches, err)
+	}
+
+	euclidean, err := Open("testdata/vsearch_euclidean.db", WithVectors(VectorOptions{Metric: Euclidean}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer euclidean.Close()
+	for id, vec := range vectors {
+		if err := euclidean.VAdd("emb", id, vec); err != nil {
+			t.Fatalf("VAdd failed: %v", err)
+		}
+	}
+	matches, err = euclidean.VSearch("emb", []float32{2, 0}, 2)
+	if ids := matchIDs(matches); err != nil || !reflect.DeepEqual(ids, []string{"east", "north"}) {
+		t.Errorf("expected [east north] by euclidean distance, got %v %v", ids, err)
+	}
+}
+
+// TestVSearchHNSW tests that the HNSW graph finds the same neighbors as an exhaustive
+// search on a small set and follows deletes and renames.
+func TestVSearchHNSW(t *testing.T) {
+	db, err := Open("testdata/vsearch_hnsw.db", WithVectors(VectorOptions{Metric: Euclidean, HNSW: true, M: 4}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	exact, err := Open("testdata/vsearch_exact.db", WithVectors(VectorOptions{Metric: Euclidean}))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer exact.Close()
+
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 200; i++ {
+		vec := []float32{rng.Float32(), rng.Float32(), rng.Float32()}
+		id := string(rune('a'+i%26)) + string(rune('a'+i/26))
+		if err := db.VAdd("emb", id, vec); err != nil {
+			t.Fatalf("VAdd failed: %v", err)
+		}
+		if err := exact.VAdd("emb", id, vec); err != nil {
+			t.Fatalf("VAdd failed: %v", err)
+		}
+	}
+
+	query := []float32{0.5, 0.5, 0.5}
+	want, err := exact.VSearch("emb", query, 5)
+	if err != nil {
+		t.Fatalf("VSearch failed: %v", err)
+	}
+	got, err := db.VSearch("emb", query, 5)
+	if err != nil {
+		t.Fatalf("VSearch failed: %v", err)
+	}
+	if !reflect.DeepEqual(matchIDs(got), matchIDs(want)) {
+		t.Errorf("expected %v, got %v", matchIDs(want), matchIDs(got))
+	}
+
+	// Deleted vectors are no longer found
+	if err := db.Hdel("emb", want[0].ID); err != nil {
+		t.Fatalf("Hdel failed: %v", err)
+	}
+	got, err = db.VSearch("emb", query, 4)
+	if err != nil || !reflect.DeepEqual(matchIDs(got), matchIDs(want[1:])) {
+		t.Errorf("expected %v after deleting the nearest, got %v %v", matchIDs(want[1:]), matchIDs(got), err)
+	}
+
+	// The graph follows the key
+	if err := db.Rename("emb", "emb2"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	got, err = db.VSearch("emb2", query, 4)
+	if err != nil || !reflect.DeepEqual(matchIDs(got), matchIDs(want[1:])) {
+		t.Errorf("expected %v after the rename, got %v %v", matchIDs(want[1:]), matchIDs(got), err)
+	}
+	if err := db.HdelBucket("emb2"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if keys, _, err := db.ListKeys("", "", 0); err != nil || len(keys) != 0 {
+		t.Errorf("expected no keys left, got %v %v", keys, err)
+	}
+}
@@ -0,0 +1,157 @@
+package jungledb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"go.etcd.io/bbolt"
+)
+
+// viewsBucket holds the definition each materialized view was built for, by name.
+const viewsBucket = internalPrefix + "views"
+
+// MaterializedView keeps the sorted set Name up to date with the hashes whose keys start
+// with Prefix: each hash holding Field as a number (the text of a float, as compared by
+// Query) is a member of the set, scored by that number. Hashes lacking the field, or
+// holding something else in it, are not members. Every namespace has its own set, fed by
+// the hashes of that namespace.
+//
+// The set is an ordinary sorted set that can be read with Zrange and the like, and is
+// replicated and exported as such, but writing it directly is pointless: writes to the
+// hashes overwrite it.
+type MaterializedView struct {
+	Name   string `json:"-"`
+	Prefix string `json:"prefix"`
+	Field  string `json:"field"`
+}
+
+// WithMaterializedView maintains materialized views, updating them in the transaction of
+// every write to their hashes. Open builds a view that is new or whose definition changed
+// from the hashes already stored, replacing the sorted sets it had built before.
+func WithMaterializedView(views ...MaterializedView) Option {
+	return func(o *options) {
+		o.views = append(o.views, views...)
+	}
+}
+
+// updateViews brings the materialized views up to date with the keys changed by tx.
+func (db *DB) updateViews(tx *txn) error {
+	if len(db.opts.views) == 0 {
+		return nil
+	}
+	events := tx.events // Updating a view records more events, which need no processing
+	done := make(map[string]bool)
+	for _, ev := range events {
+		for _, key := range []string{ev.Key, ev.Target} {
+			if key == "" || done[key] {
+				continue
+			}
+			done[key] = true
+			for i := range db.opts.views {
+				if err := db.viewKey(tx, &db.opts.views[i], key); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// viewKey adds, updates or removes the member of view for the key name, as its current
+// contents require.
+func (db *DB) viewKey(tx *txn, view *MaterializedView, name string) error {
+	if isInternalBucket(tx.Tx, []byte(name)) {
+		return nil
+	}
+	ns, key, ok := splitKey(name)
+	if !ok || key == view.Name || !strings.HasPrefix(key, view.Prefix) {
+		return nil
+	}
+
+	set := ns + view.Name
+	var current []byte
+	if members := membersBucket(tx.Tx, set); members != nil {
+		current = members.Get([]byte(key))
+	}
+	score, ok := viewScore(tx.Tx, view, name)
+	switch {
+	case !ok && current == nil:
+		return nil
+	case !ok:
+		return zrem(tx, set, key)
+	case bytes.Equal(current, binary.BigEndian.AppendUint64(nil, math.Float64bits(score))):
+		return nil
+	}
+	return zadd(tx, set, score, key)
+}
+
+// viewScore returns the score of the hash name in view, or false if it is not a member.
+func viewScore(tx *bbolt.Tx, view *MaterializedView, name string) (float64, bool) {
+	hash := tx.Bucket([]byte(name))
+	if hash == nil || keyType(tx, []byte(name)) != typeHash {
+		return 0, false
+	}
+	value, ok := getField(hash, view.Field)
+	if !ok {
+		return 0, false
+	}
+	score, err := strconv.ParseFloat(string(value), 64)
+	if err != nil || math.IsNaN(score) {
+		return 0, false
+	}
+	return score, true
+}
+
+// buildViews builds the configured materialized views whose stored definition is missing
+// or differs, from the hashes in the database.
+func (db *DB) buildViews() error {
+	for i := range db.opts.views {
+		view := &db.opts.views[i]
+		if view.Name == "" || view.Field == "" {
+			return fmt.Errorf("materialized view %q needs a name and a field", view.Name)
+		}
+		def, err := json.Marshal(view)
+		if err != nil {
+			return err
+		}
+		err = db.update("BuildView", "", func(tx *txn) error {
+			defs, err := tx.CreateBucketIfNotExists([]byte(viewsBucket))
+			if err != nil {
+				return fmt.Errorf("failed to create views bucket: %v", err)
+			}
+			if bytes.Equal(defs.Get([]byte(view.Name)), def) {
+				return nil
+			}
+
+			var names []string
+			tx.ForEach(func(name []byte, _ *bbolt.Bucket) error {
+				names = append(names, string(name))
+				return nil
+			})
+			for _, name := range names {
+				_, key, ok := splitKey(name)
+				if ok && key == view.Name && keyType(tx.Tx, []byte(name)) == typeZset {
+					if err := deleteKey(tx, name); err != nil {
+						return err
+					}
+					tx.record(Event{Type: EventDelete, Key: name})
+				}
+			}
+			for _, name := range names {
+				if err := db.viewKey(tx, view, name); err != nil {
+					return err
+				}
+			}
+			return defs.Put([]byte(view.Name), def)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to build materialized view %s: %w", view.Name, err)
+		}
+	}
+	return nil
+}
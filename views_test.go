@@ -0,0 +1,66 @@
+package jungledb
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestMaterializedView tests that a view follows the writes to its hashes and is built at
+// Open from the hashes already stored.
+func TestMaterializedView(t *testing.T) {
+	db, err := Open("testdata/views.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	for user, score := range map[string]string{"user:a": "3", "user:b": "7"} {
+		if err := db.Hset(user, "score", []byte(score)); err != nil {
+			t.Fatalf("Hset failed: %v", err)
+		}
+	}
+	db.Close()
+
+	view := MaterializedView{Name: "top_scores", Prefix: "user:", Field: "score"}
+	db, err = Open("testdata/views.db", WithMaterializedView(view))
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+	if members, err := db.Zrevrange("top_scores", 0, -1); err != nil || !reflect.DeepEqual(members, []string{"user:b", "user:a"}) {
+		t.Errorf("expected the view to be built at Open, got %v %v", members, err)
+	}
+
+	writes := []struct {
+		desc  string
+		write func() error
+		want  []string
+	}{
+		{"new hash", func() error { return db.Hset("user:c", "score", []byte("5")) }, []string{"user:b", "user:c", "user:a"}},
+		{"score update", func() error { return db.Hset("user:a", "score", []byte("10")) }, []string{"user:a", "user:b", "user:c"}},
+		{"non-numeric score", func() error { return db.Hset("user:b", "score", []byte("high")) }, []string{"user:a", "user:c"}},
+		{"other prefix", func() error { return db.Hset("team:x", "score", []byte("1")) }, []string{"user:a", "user:c"}},
+		{"field deleted", func() error { return db.Hdel("user:c", "score") }, []string{"user:a"}},
+		{"rename", func() error { return db.Rename("user:a", "user:d") }, []string{"user:d"}},
+		{"delete", func() error { return db.HdelBucket("user:d") }, nil},
+	}
+	for _, w := range writes {
+		if err := w.write(); err != nil {
+			t.Fatalf("%s: write failed: %v", w.desc, err)
+		}
+		members, err := db.Zrevrange("top_scores", 0, -1)
+		if err != nil || !reflect.DeepEqual(members, w.want) {
+			t.Errorf("%s: expected %v, got %v %v", w.desc, w.want, members, err)
+		}
+	}
+
+	// Namespaces have their own view
+	ns := db.Namespace("tenant")
+	if err := ns.Hset("user:e", "score", []byte("1")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if members, err := ns.Zrange("top_scores", 0, -1); err != nil || !reflect.DeepEqual(members, []string{"user:e"}) {
+		t.Errorf("expected [user:e] in the namespace, got %v %v", members, err)
+	}
+	if members, err := db.Zrange("top_scores", 0, -1); err != nil || len(members) != 0 {
+		t.Errorf("expected the namespace to be left out of the root view, got %v %v", members, err)
+	}
+}
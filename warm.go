@@ -0,0 +1,69 @@
+package jungledb
+
+import (
+	"bytes"
+	"runtime"
+
+	"go.etcd.io/bbolt"
+)
+
+// warmStride is the distance between the bytes Warm reads in a value: one per page of
+// the operating system, which is the unit data is faulted in.
+const warmStride = 4096
+
+// Warm reads every page of keys so they are in memory before traffic needs them, for
+// example right after a restart, when reads would otherwise each wait for the pages of
+// the memory-mapped file to be read from disk. It returns the number of bytes read.
+// Missing keys are skipped.
+func (db *DB) Warm(keys ...string) (int64, error) {
+	if err := checkKey(keys...); err != nil {
+		return 0, err
+	}
+	var n int64
+	err := db.view("Warm", "", func(tx *bbolt.Tx) error {
+		for _, key := range keys {
+			n += warmKey(tx, db.nsKey(key))
+		}
+		return nil
+	})
+	return n, err
+}
+
+// WarmPattern is like Warm for the keys matching pattern (see ListKeys).
+func (db *DB) WarmPattern(pattern string) (int64, error) {
+	var n int64
+	err := db.view("Warm", pattern, func(tx *bbolt.Tx) error {
+		prefix := []byte(db.ns)
+		c := tx.Cursor()
+		for name, v := c.Seek(prefix); name != nil && bytes.HasPrefix(name, prefix); name, v = c.Next() {
+			key, ok := db.userKey(string(name))
+			if v != nil || !ok || isInternalBucket(tx, name) || !matchPattern(pattern, key) {
+				continue
+			}
+			n += warmKey(tx, string(name))
+		}
+		return nil
+	})
+	return n, err
+}
+
+// warmKey reads the pages of the bucket stored under name, and of its sorted set member
+// index, in key order, and returns the number of bytes they hold.
+func warmKey(tx *bbolt.Tx, name string) int64 {
+	var n int64
+	var sink byte
+	for _, b := range []*bbolt.Bucket{tx.Bucket([]byte(name)), membersBucket(tx, name)} {
+		if b == nil {
+			continue
+		}
+		b.ForEach(func(k, v []byte) error {
+			n += int64(len(k) + len(v))
+			for i := 0; i < len(v); i += warmStride {
+				sink ^= v[i]
+			}
+			return nil
+		})
+	}
+	runtime.KeepAlive(sink) // Keeps the reads from being optimized away
+	return n
+}
@@ -0,0 +1,40 @@
+package jungledb
+
+import (
+	"testing"
+)
+
+// TestWarm tests that Warm and WarmPattern read the keys asked for and skip the others.
+func TestWarm(t *testing.T) {
+	db, err := Open("testdata/warm.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	if err := db.Hset("user:1", "bio", make([]byte, 10000)); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Zadd("user:ranking", 1, "alice"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if err := db.Hset("other", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+
+	n, err := db.Warm("user:1", "missing")
+	if err != nil {
+		t.Fatalf("Warm failed: %v", err)
+	}
+	if want := int64(len("bio") + 10000); n != want {
+		t.Errorf("Warm read %d bytes, expected %d", n, want)
+	}
+	n, err = db.WarmPattern("user:*")
+	if err != nil {
+		t.Fatalf("WarmPattern failed: %v", err)
+	}
+	// The sorted set has an entry (score and member) and a member index entry (member and score)
+	if want := int64(len("bio") + 10000 + 2*(8+len("alice"))); n != want {
+		t.Errorf("WarmPattern read %d bytes, expected %d", n, want)
+	}
+}
@@ -0,0 +1,115 @@
+package jungledb
+
+import "sync"
+
+// watchBuffer is the channel capacity of each watcher.
+const watchBuffer = 1024
+
+// watcher is a subscription created by Watch.
+type watcher struct {
+	db      *DB // Handle that created the watcher, whose namespace it sees
+	pattern string
+	ch      chan Event
+	once    sync.Once
+}
+
+// Watch subscribes to committed mutations on keys matching the glob pattern (see ListKeys;
+// empty matches everything). Events are delivered after their transaction commits, in
+// commit order. Rename and copy events match on either the source or the target key.
+// A namespace handle sees the events of its own keys only, with the namespace removed
+// from their names. Each subscription is buffered rather than blocking writers; if a
+// consumer falls behind by more than the buffer, it receives an EventOverflow and its
+// channel is closed, since it missed events: it should read the keys it follows again
+// and watch anew. Call cancel to stop watching; the channel is closed once cancelled or
+// when the database is closed, and is returned closed if it already is. Event values are
+// shared between watchers and must not be modified.
+func (db *DB) Watch(pattern string) (<-chan Event, func()) {
+	w := &watcher{db: db, pattern: pattern, ch: make(chan Event, watchBuffer)}
+
+	db.mu.RLock() // Close holds mu while it closes the watchers
+	defer db.mu.RUnlock()
+	if db.closed {
+		w.close()
+		return w.ch, func() {}
+	}
+	db.watchMu.Lock()
+	if db.watchers == nil {
+		db.watchers = make(map[*watcher]struct{})
+	}
+	db.watchers[w] = struct{}{}
+	db.watchMu.Unlock()
+
+	cancel := func() {
+		db.watchMu.Lock()
+		delete(db.watchers, w)
+		db.watchMu.Unlock()
+		w.close()
+	}
+	return w.ch, cancel
+}
+
+func (w *watcher) close() {
+	w.once.Do(func() { close(w.ch) })
+}
+
+// view returns ev as seen from the watcher's namespace, or false if the watcher is not
+// interested in it.
+func (w *watcher) view(ev Event) (Event, bool) {
+	return eventIn(w.db, w.pattern, ev)
+}
+
+// eventIn returns ev as seen from the namespace of db, or false if it concerns no key of
+// that namespace matching pattern, on either its source or its target.
+func eventIn(db *DB, pattern string, ev Event) (Event, bool) {
+	key, ok := db.userKey(ev.Key)
+	if !ok {
+		return ev, false
+	}
+	target, targetOK := db.userKey(ev.Target)
+	if !matchPattern(pattern, key) && !(ev.Target != "" && targetOK && matchPattern(pattern, target)) {
+		return ev, false
+	}
+	if db.ns != "" {
+		ev.Key, ev.Target = key, target // ev is a copy
+	}
+	return ev, true
+}
+
+// notifyWatchers delivers committed events to every matching watcher without blocking.
+func (db *DB) notifyWatchers(events []Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	db.watchMu.Lock()
+	defer db.watchMu.Unlock()
+	for w := range db.watchers {
+		for _, ev := range events {
+			ev, ok := w.view(ev)
+			if !ok {
+				continue
+			}
+			// The last slot is kept for EventOverflow; only this function sends, under
+			// watchMu, so the length cannot grow in between
+			if len(w.ch) < cap(w.ch)-1 {
+				w.ch <- ev
+				continue
+			}
+			w.ch <- Event{Type: EventOverflow}
+			delete(db.watchers, w)
+			w.close()
+			db.log.Warn("watcher fell behind", "pattern", w.pattern, "buffer", cap(w.ch))
+			break
+		}
+	}
+}
+
+// closeWatchers closes every watcher channel.
+func (db *DB) closeWatchers() {
+	db.watchMu.Lock()
+	defer db.watchMu.Unlock()
+	for w := range db.watchers {
+		w.close()
+		delete(db.watchers, w)
+	}
+}
@@ -0,0 +1,152 @@
+package jungledb
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// receive reads one event from ch or fails the test after a timeout.
+func receive(t *testing.T, ch <-chan Event) Event {
+	t.Helper()
+	select {
+	case ev, ok := <-ch:
+		if !ok {
+			t.Fatal("watch channel closed unexpectedly")
+		}
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for event")
+	}
+	return Event{}
+}
+
+// TestWatch tests event delivery, pattern filtering and cancellation.
+func TestWatch(t *testing.T) {
+	db, err := Open("testdata/watch.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	events, cancel := db.Watch("user:*")
+
+	if err := db.Hset("other", "f", []byte("ignored")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if err := db.Hset("user:1", "name", []byte("Alice")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	ev := receive(t, events)
+	if ev.Type != EventHset || ev.Key != "user:1" || ev.Field != "name" || string(ev.Value) != "Alice" {
+		t.Errorf("unexpected Hset event: %+v", ev)
+	}
+
+	if err := db.Hdel("user:1", "name"); err != nil {
+		t.Fatalf("Hdel failed: %v", err)
+	}
+	if ev := receive(t, events); ev.Type != EventHdel || ev.Field != "name" {
+		t.Errorf("unexpected Hdel event: %+v", ev)
+	}
+
+	if err := db.Zadd("user:scores", 3, "m"); err != nil {
+		t.Fatalf("Zadd failed: %v", err)
+	}
+	if ev := receive(t, events); ev.Type != EventZadd || ev.Field != "m" || ev.Score != 3 {
+		t.Errorf("unexpected Zadd event: %+v", ev)
+	}
+
+	if err := db.Zrem("user:scores", "m"); err != nil {
+		t.Fatalf("Zrem failed: %v", err)
+	}
+	if ev := receive(t, events); ev.Type != EventZrem || ev.Field != "m" {
+		t.Errorf("unexpected Zrem event: %+v", ev)
+	}
+
+	if err := db.HdelBucket("user:scores"); err != nil {
+		t.Fatalf("HdelBucket failed: %v", err)
+	}
+	if ev := receive(t, events); ev.Type != EventDelete || ev.Key != "user:scores" {
+		t.Errorf("unexpected delete event: %+v", ev)
+	}
+
+	// Failed transactions produce no events
+	if err := db.Rename("user:missing", "user:other"); err == nil {
+		t.Fatal("Rename of a missing key should fail")
+	}
+
+	// Rename out of the watched pattern still matches on the source key
+	if err := db.Hset("user:2", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	receive(t, events)
+	if err := db.Rename("user:2", "archived:2"); err != nil {
+		t.Fatalf("Rename failed: %v", err)
+	}
+	if ev := receive(t, events); ev.Type != EventRename || ev.Target != "archived:2" {
+		t.Errorf("unexpected rename event: %+v", ev)
+	}
+
+	cancel()
+	if _, ok := <-events; ok {
+		t.Error("channel should be closed after cancel")
+	}
+	cancel() // Cancelling twice is harmless
+}
+
+// TestWatchClosedOnClose tests that watchers are released when the database closes.
+func TestWatchClosedOnClose(t *testing.T) {
+	db, err := Open("testdata/watch_close.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+
+	events, cancel := db.Watch("")
+	if err := db.Close(); err != nil {
+		t.Fatalf("Close failed: %v", err)
+	}
+	if _, ok := <-events; ok {
+		t.Error("channel should be closed after Close")
+	}
+	cancel()
+}
+
+// TestWatchOverflow tests that a watcher falling behind by more than its buffer receives
+// EventOverflow and is closed, rather than silently missing events.
+func TestWatchOverflow(t *testing.T) {
+	db, err := Open("testdata/watch_overflow.db")
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	events, cancel := db.Watch("")
+	defer cancel()
+	fields := make(map[string][]byte, watchBuffer)
+	for i := 0; i < watchBuffer; i++ {
+		fields[fmt.Sprint(i)] = []byte("v")
+	}
+	if err := db.Hmset("h", fields); err != nil {
+		t.Fatalf("Hmset failed: %v", err)
+	}
+
+	n := 0
+	var last Event
+	for ev := range events {
+		n++
+		last = ev
+	}
+	if n != watchBuffer || last.Type != EventOverflow {
+		t.Errorf("expected %d events ending with an overflow, got %d ending with %+v", watchBuffer, n, last)
+	}
+
+	// Other watchers and writes are unaffected
+	events, cancel = db.Watch("")
+	defer cancel()
+	if err := db.Hset("h", "f", []byte("v")); err != nil {
+		t.Fatalf("Hset failed: %v", err)
+	}
+	if ev := receive(t, events); ev.Type != EventHset {
+		t.Errorf("unexpected event after the overflow: %+v", ev)
+	}
+}